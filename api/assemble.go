@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// handleAssemble handles POST /api/v1/assemble, returning the encoded program without
+// creating a session or running it
+func (s *Server) handleAssemble(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AssembleRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p := parser.NewParser(req.Source, "api")
+	program, parseErr := p.Parse()
+	if parseErr != nil {
+		writeJSON(w, http.StatusBadRequest, AssembleResponse{Success: false, Errors: parseErrors(p, parseErr)})
+		return
+	}
+
+	// Entry point: as for loading a program unless one is given
+	var entryAddr uint32
+	if req.EntryPoint != nil {
+		entryAddr = *req.EntryPoint
+	} else if startSym, exists := program.SymbolTable.Lookup("_start"); exists {
+		entryAddr = startSym.Value
+	} else if program.OriginSet {
+		entryAddr = program.Origin
+	} else {
+		entryAddr = 0x8000 // Default ARM entry point
+	}
+
+	image, err := loader.Assemble(program, entryAddr, loader.Options{})
+	if err != nil {
+		assembleErr := AssembleError{Message: err.Error()}
+		var encErr *encoder.EncodingError
+		if errors.As(err, &encErr) && encErr.Instruction != nil {
+			assembleErr.Line, assembleErr.Column = encErr.Instruction.Pos.Line, encErr.Instruction.Pos.Column
+		}
+		writeJSON(w, http.StatusBadRequest, AssembleResponse{Success: false, Errors: []AssembleError{assembleErr}})
+		return
+	}
+
+	response := AssembleResponse{
+		Success:    true,
+		EntryPoint: entryAddr,
+		Symbols:    make(map[string]uint32),
+	}
+	for _, inst := range image.Instructions {
+		response.Instructions = append(response.Instructions, AssembledInstruction{
+			Address: inst.Address,
+			Opcode:  inst.Opcode,
+			Line:    inst.Instruction.Pos.Line,
+			Source:  strings.TrimSpace(inst.Instruction.RawLine),
+		})
+	}
+	for _, data := range image.Data {
+		bytes := data.Bytes
+		if data.Words != nil {
+			bytes = make([]byte, 4*len(data.Words))
+			for i, word := range data.Words {
+				binary.LittleEndian.PutUint32(bytes[4*i:], word)
+			}
+		}
+		response.Data = append(response.Data, AssembledData{Address: data.Address, Directive: data.Directive, Bytes: bytes})
+	}
+	for addr, value := range image.LiteralPool {
+		response.Literals = append(response.Literals, AssembledLiteral{Address: addr, Value: value})
+	}
+	sort.Slice(response.Literals, func(i, j int) bool { return response.Literals[i].Address < response.Literals[j].Address })
+	for name, symbol := range program.SymbolTable.GetAllSymbols() {
+		if symbol.Type == parser.SymbolLabel {
+			response.Symbols[name] = symbol.Value
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleAST handles POST /api/v1/ast, returning the parsed instructions and directives
+// without encoding them, for tools such as linters
+func (s *Server) handleAST(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ASTRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p := parser.NewParser(req.Source, "api")
+	program, parseErr := p.Parse()
+	if parseErr != nil {
+		writeJSON(w, http.StatusBadRequest, ASTResponse{Success: false, Errors: parseErrors(p, parseErr)})
+		return
+	}
+
+	ast := parser.NewAST(program)
+	writeJSON(w, http.StatusOK, ASTResponse{Success: true, Instructions: ast.Instructions, Directives: ast.Directives})
+}
+
+// parseErrors lists the parser's errors at their source positions, falling back to err
+// when none were recorded
+func parseErrors(p *parser.Parser, err error) []AssembleError {
+	var errs []AssembleError
+	for _, e := range p.Errors().Errors {
+		errs = append(errs, AssembleError{Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Message})
+	}
+	if len(errs) == 0 {
+		errs = []AssembleError{{Message: err.Error()}}
+	}
+	return errs
+}
@@ -61,5 +61,30 @@ func (w *EventWriter) GetBuffer() string {
 	return w.buffer.String()
 }
 
-// Ensure EventWriter implements io.Writer
-var _ io.Writer = (*EventWriter)(nil)
+// ConsoleBuffer is an io.Writer that captures a session's console output for
+// GET /console. It is registered as a VM output sink, so it keeps capturing whatever
+// OutputWriter is set to.
+type ConsoleBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+// Write implements io.Writer interface
+func (b *ConsoleBuffer) Write(p []byte) (n int, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+// String returns everything written so far
+func (b *ConsoleBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// Ensure EventWriter and ConsoleBuffer implement io.Writer
+var (
+	_ io.Writer = (*EventWriter)(nil)
+	_ io.Writer = (*ConsoleBuffer)(nil)
+)
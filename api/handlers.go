@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/lookbusy1344/arm-emulator/parser"
 	"github.com/lookbusy1344/arm-emulator/service"
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // handleCreateSession handles POST /api/v1/session
@@ -20,6 +23,10 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if err := vm.ValidateEnvironment(req.Env); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	session, err := s.sessions.CreateSession(req)
 	if err != nil {
@@ -65,6 +72,7 @@ func (s *Server) handleGetSessionStatus(w http.ResponseWriter, r *http.Request,
 		State:     string(state),
 		PC:        regs.PC,
 		Cycles:    regs.Cycles,
+		Error:     session.Service.GetLastError(),
 		HasWrite:  memWrite.HasWrite,
 		WriteAddr: memWrite.Address,
 		WriteSize: memWrite.Size,
@@ -169,6 +177,23 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request, sessionID str
 		return
 	}
 
+	wait := false
+	if value := r.URL.Query().Get("wait"); value != "" {
+		if wait, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid wait parameter")
+			return
+		}
+	}
+
+	// An optional wall-clock limit for this run, e.g. ?timeout=2s
+	var timeout time.Duration
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		if timeout, err = time.ParseDuration(value); err != nil || timeout <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid timeout parameter (use a positive duration such as 500ms or 2s)")
+			return
+		}
+	}
+
 	// Capture service pointer to avoid race with DestroySession
 	svc := session.Service
 
@@ -192,8 +217,17 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request, sessionID str
 	s.broadcastStateChange(sessionID, &regs, broadcastState)
 
 	// Run the program asynchronously
+	done := make(chan struct{})
 	go func() {
-		_ = svc.RunUntilHalt()
+		defer close(done)
+		// The run outlives an asynchronous request, so its context is not the request's
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		_ = svc.RunUntilHaltContext(ctx)
 
 		// Broadcast final state after execution completes
 		finalRegs := svc.GetRegisterState()
@@ -201,9 +235,95 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request, sessionID str
 		s.broadcastStateChange(sessionID, &finalRegs, finalState)
 	}()
 
+	if !wait {
+		writeJSON(w, http.StatusOK, SuccessResponse{
+			Success: true,
+			Message: "Program started",
+		})
+		return
+	}
+
+	// A synchronous run may outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	select {
+	case <-done:
+	case <-r.Context().Done():
+		// The client has gone, so stop the program as /stop would
+		svc.Pause()
+		return
+	}
+
+	finalRegs := svc.GetRegisterState()
+	writeJSON(w, http.StatusOK, RunResultResponse{
+		State:     string(svc.GetExecutionState()),
+		Registers: ToRegisterResponse(&finalRegs),
+		Output:    session.Console.String(),
+		ExitCode:  svc.GetExitCode(),
+		Error:     svc.GetLastError(),
+	})
+}
+
+// handleRunTo handles POST /api/v1/session/{id}/run-to
+func (s *Server) handleRunTo(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	var req RunToRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Capture service pointer to avoid race with DestroySession
+	svc := session.Service
+
+	address := req.Address
+	if req.Label != "" {
+		addr, ok := svc.GetSymbols()[req.Label]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown label: %s", req.Label))
+			return
+		}
+		address = addr
+	}
+
+	// As with run, a finished program starts again from the entry point
+	state := svc.GetExecutionState()
+	if state == service.StateHalted || state == service.StateError {
+		if err := svc.ResetToEntryPoint(); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reset: %v", err))
+			return
+		}
+	}
+
+	if err := svc.RunTo(address); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Run-to failed: %v", err))
+		return
+	}
+
+	regs := svc.GetRegisterState()
+	broadcastState := svc.GetExecutionState()
+	s.broadcastStateChange(sessionID, &regs, broadcastState)
+
+	go func() {
+		_ = svc.RunUntilHalt()
+
+		finalRegs := svc.GetRegisterState()
+		finalState := svc.GetExecutionState()
+		s.broadcastStateChange(sessionID, &finalRegs, finalState)
+	}()
+
 	writeJSON(w, http.StatusOK, SuccessResponse{
 		Success: true,
-		Message: "Program started",
+		Message: fmt.Sprintf("Running to 0x%08X", address),
 	})
 }
 
@@ -254,7 +374,7 @@ func (s *Server) handleStep(w http.ResponseWriter, r *http.Request, sessionID st
 
 	stepErr := session.Service.Step()
 	if stepErr != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Step failed: %v", stepErr))
+		writeRuntimeError(w, "Step failed", stepErr)
 		return
 	}
 
@@ -285,7 +405,7 @@ func (s *Server) handleStepOver(w http.ResponseWriter, r *http.Request, sessionI
 
 	stepErr := session.Service.StepOver()
 	if stepErr != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Step over failed: %v", stepErr))
+		writeRuntimeError(w, "Step over failed", stepErr)
 		return
 	}
 
@@ -316,7 +436,7 @@ func (s *Server) handleStepOut(w http.ResponseWriter, r *http.Request, sessionID
 
 	stepErr := session.Service.StepOut()
 	if stepErr != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Step out failed: %v", stepErr))
+		writeRuntimeError(w, "Step out failed", stepErr)
 		return
 	}
 
@@ -332,6 +452,35 @@ func (s *Server) handleStepOut(w http.ResponseWriter, r *http.Request, sessionID
 	writeJSON(w, http.StatusOK, response)
 }
 
+// handleStepBack handles POST /api/v1/session/{id}/stepback
+func (s *Server) handleStepBack(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if stepErr := session.Service.StepBack(); stepErr != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Step back failed: %v", stepErr))
+		return
+	}
+
+	regs := session.Service.GetRegisterState()
+	state := session.Service.GetExecutionState()
+
+	// Broadcast state change to WebSocket clients
+	s.broadcastStateChange(sessionID, &regs, state)
+
+	// Return restored registers
+	response := ToRegisterResponse(&regs)
+	writeJSON(w, http.StatusOK, response)
+}
+
 // handleReset handles POST /api/v1/session/{id}/reset
 func (s *Server) handleReset(w http.ResponseWriter, r *http.Request, sessionID string) {
 	if r.Method != http.MethodPost {
@@ -400,6 +549,41 @@ func (s *Server) handleGetRegisters(w http.ResponseWriter, r *http.Request, sess
 	writeJSON(w, http.StatusOK, response)
 }
 
+// handleSetRegisters handles PUT /api/v1/session/{id}/registers
+func (s *Server) handleSetRegisters(w http.ResponseWriter, r *http.Request, sessionID string) {
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	var req RegistersUpdateRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	update, err := req.ToRegisterUpdate()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid register update: %v", err))
+		return
+	}
+
+	if err := session.Service.SetRegisters(update); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to set registers: %v", err))
+		return
+	}
+
+	regs := session.Service.GetRegisterState()
+	state := session.Service.GetExecutionState()
+
+	// Broadcast state change to WebSocket clients
+	s.broadcastStateChange(sessionID, &regs, state)
+
+	response := ToRegisterResponse(&regs)
+	writeJSON(w, http.StatusOK, response)
+}
+
 // handleGetMemory handles GET /api/v1/session/{id}/memory
 func (s *Server) handleGetMemory(w http.ResponseWriter, r *http.Request, sessionID string) {
 	if r.Method != http.MethodGet {
@@ -450,36 +634,72 @@ func (s *Server) handleGetMemory(w http.ResponseWriter, r *http.Request, session
 	writeJSON(w, http.StatusOK, response)
 }
 
-// handleGetConsoleOutput handles GET /api/v1/session/{id}/console
-func (s *Server) handleGetConsoleOutput(w http.ResponseWriter, r *http.Request, sessionID string) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleWriteMemory handles POST /api/v1/session/{id}/memory
+func (s *Server) handleWriteMemory(w http.ResponseWriter, r *http.Request, sessionID string) {
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
 		return
 	}
 
-	session, err := s.sessions.GetSession(sessionID)
+	var req MemoryWriteRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	data := req.Data
+	if req.Hex != "" {
+		if len(data) > 0 {
+			writeError(w, http.StatusBadRequest, "Provide either data or hex, not both")
+			return
+		}
+		data, err = hex.DecodeString(strings.TrimPrefix(req.Hex, "0x"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid hex data")
+			return
+		}
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, "No data to write")
+		return
+	}
+
+	// Limit memory writes (the request body itself is capped at 1MB)
+	const maxMemoryWrite = 64 * 1024 // 64KB
+	if len(data) > maxMemoryWrite {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Data too large (max %d bytes)", maxMemoryWrite))
+		return
+	}
+
+	written, err := session.Service.WriteMemory(req.Address, data)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "Session not found")
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to write memory after %d bytes: %v", written, err))
 		return
 	}
 
-	// Get the VM's output writer
-	vm := session.Service.GetVM()
-	if vm.OutputWriter == nil {
-		writeJSON(w, http.StatusOK, ConsoleOutputResponse{Output: ""})
+	response := MemoryWriteResponse{
+		Address:      req.Address,
+		BytesWritten: written,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleGetConsoleOutput handles GET /api/v1/session/{id}/console
+func (s *Server) handleGetConsoleOutput(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Type assert to EventWriter to access GetBuffer method
-	eventWriter, ok := vm.OutputWriter.(*EventWriter)
-	if !ok {
-		// Fallback: OutputWriter is not an EventWriter (shouldn't happen in normal API usage)
-		writeJSON(w, http.StatusOK, ConsoleOutputResponse{Output: ""})
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
 		return
 	}
 
-	output := eventWriter.GetBuffer()
-	writeJSON(w, http.StatusOK, ConsoleOutputResponse{Output: output})
+	writeJSON(w, http.StatusOK, ConsoleOutputResponse{Output: session.Console.String()})
 }
 
 // handleGetDisassembly handles GET /api/v1/session/{id}/disassembly
@@ -603,6 +823,7 @@ func (s *Server) handleListBreakpoints(w http.ResponseWriter, r *http.Request, s
 
 	response := BreakpointsResponse{
 		Breakpoints: addresses,
+		Details:     breakpoints,
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -628,6 +849,8 @@ func (s *Server) handleGetSourceMap(w http.ResponseWriter, r *http.Request, sess
 		Address    uint32 `json:"address"`
 		LineNumber int    `json:"lineNumber"`
 		Line       string `json:"line"`
+		StartCol   int    `json:"startCol"`
+		EndCol     int    `json:"endCol"`
 	}
 
 	entries := make([]SourceMapEntry, len(sourceMap))
@@ -636,6 +859,8 @@ func (s *Server) handleGetSourceMap(w http.ResponseWriter, r *http.Request, sess
 			Address:    entry.Address,
 			LineNumber: entry.LineNumber,
 			Line:       entry.Line,
+			StartCol:   entry.StartCol,
+			EndCol:     entry.EndCol,
 		}
 	}
 
@@ -646,6 +871,22 @@ func (s *Server) handleGetSourceMap(w http.ResponseWriter, r *http.Request, sess
 	writeJSON(w, http.StatusOK, response)
 }
 
+// handleGetMemoryMap handles GET /api/v1/session/{id}/memory-map
+func (s *Server) handleGetMemoryMap(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, err := s.sessions.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, MemoryMapResponse{Segments: session.Service.GetMemoryMap()})
+}
+
 // handleSendStdin handles POST /api/v1/session/{id}/stdin
 func (s *Server) handleSendStdin(w http.ResponseWriter, r *http.Request, sessionID string) {
 	if r.Method != http.MethodPost {
@@ -1122,12 +1363,13 @@ func (s *Server) getDefaultConfig() ConfigResponse {
 	// In a full implementation, this would load from config package
 	return ConfigResponse{
 		Execution: ExecutionConfig{
-			MaxCycles:      1000000,
-			StackSize:      65536,
-			DefaultEntry:   "0x8000",
-			EnableTrace:    false,
-			EnableMemTrace: false,
-			EnableStats:    false,
+			MaxCycles:       1000000,
+			MaxInstructions: 0,
+			StackSize:       65536,
+			DefaultEntry:    "0x8000",
+			EnableTrace:     false,
+			EnableMemTrace:  false,
+			EnableStats:     false,
 		},
 		Debugger: DebuggerConfig{
 			HistorySize:    1000,
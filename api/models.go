@@ -1,9 +1,12 @@
 package api
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/lookbusy1344/arm-emulator/parser"
 	"github.com/lookbusy1344/arm-emulator/service"
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // SessionCreateRequest represents a request to create a new session
@@ -12,6 +15,14 @@ type SessionCreateRequest struct {
 	StackSize  uint32 `json:"stackSize,omitempty"`  // Stack size in bytes (default: 64KB)
 	HeapSize   uint32 `json:"heapSize,omitempty"`   // Heap size in bytes (default: 256KB)
 	FSRoot     string `json:"fsRoot,omitempty"`     // Filesystem root directory
+	Seed       *int64 `json:"seed,omitempty"`       // Seed for SWI_GET_RANDOM (default: time-seeded)
+
+	MaxInstructions uint64   `json:"maxInstructions,omitempty"` // Instruction limit (default: unlimited)
+	MaxSyscalls     uint64   `json:"maxSyscalls,omitempty"`     // SWI limit (default: unlimited)
+	MaxFileOpens    uint64   `json:"maxFileOpens,omitempty"`    // SWI_OPEN and region SWI limit (default: unlimited)
+	MaxBytesWritten uint64   `json:"maxBytesWritten,omitempty"` // Console and file write limit in bytes (default: unlimited)
+	Args            []string `json:"args,omitempty"`            // Arguments returned by SWI_GET_ARGUMENTS
+	Env             []string `json:"env,omitempty"`             // KEY=VALUE variables returned by SWI_GET_ENVIRONMENT
 }
 
 // SessionCreateResponse represents the response from creating a session
@@ -44,6 +55,64 @@ type LoadProgramResponse struct {
 	Symbols map[string]uint32 `json:"symbols,omitempty"`
 }
 
+// AssembleRequest represents a request to assemble source without running it
+type AssembleRequest struct {
+	Source     string  `json:"source"`               // Assembly source code
+	EntryPoint *uint32 `json:"entryPoint,omitempty"` // Default: _start, then .org, then 0x8000
+}
+
+// AssembleResponse is the machine code for an assembled program
+type AssembleResponse struct {
+	Success      bool                   `json:"success"`
+	Errors       []AssembleError        `json:"errors,omitempty"`
+	EntryPoint   uint32                 `json:"entryPoint"`
+	Instructions []AssembledInstruction `json:"instructions,omitempty"`
+	Data         []AssembledData        `json:"data,omitempty"`
+	Literals     []AssembledLiteral     `json:"literals,omitempty"`
+	Symbols      map[string]uint32      `json:"symbols,omitempty"`
+}
+
+// AssembleError is a parse or encoding error at a source location (Line 0 if unknown)
+type AssembleError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// AssembledInstruction is one encoded instruction
+type AssembledInstruction struct {
+	Address uint32 `json:"address"`
+	Opcode  uint32 `json:"opcode"`
+	Line    int    `json:"line"`
+	Source  string `json:"source"`
+}
+
+// AssembledData is the initialised contents of one data directive, in little-endian order
+type AssembledData struct {
+	Address   uint32 `json:"address"`
+	Directive string `json:"directive"`
+	Bytes     []byte `json:"bytes"`
+}
+
+// AssembledLiteral is a literal pool word used by LDR Rd, =value
+type AssembledLiteral struct {
+	Address uint32 `json:"address"`
+	Value   uint32 `json:"value"`
+}
+
+// ASTRequest is a request for the parsed structure of a program
+type ASTRequest struct {
+	Source string `json:"source"` // Assembly source code
+}
+
+// ASTResponse is a program's parsed instructions and directives, in source order
+type ASTResponse struct {
+	Success      bool                    `json:"success"`
+	Errors       []AssembleError         `json:"errors,omitempty"`
+	Instructions []parser.ASTInstruction `json:"instructions,omitempty"`
+	Directives   []parser.ASTDirective   `json:"directives,omitempty"`
+}
+
 // RegistersResponse represents the current register state
 type RegistersResponse struct {
 	R0     uint32    `json:"r0"`
@@ -66,6 +135,15 @@ type RegistersResponse struct {
 	Cycles uint64    `json:"cycles"`
 }
 
+// RunResultResponse is returned by POST /run?wait=true once the program stops
+type RunResultResponse struct {
+	State     string             `json:"state"` // "halted", "breakpoint" or "error"
+	Registers *RegistersResponse `json:"registers"`
+	Output    string             `json:"output"` // Accumulated console output
+	ExitCode  int32              `json:"exitCode"`
+	Error     string             `json:"error,omitempty"`
+}
+
 // CPSRFlags represents the CPSR flags
 type CPSRFlags struct {
 	N bool `json:"n"` // Negative
@@ -74,6 +152,73 @@ type CPSRFlags struct {
 	V bool `json:"v"` // Overflow
 }
 
+// RegistersUpdateRequest sets any subset of registers and CPSR flags; omitted fields are unchanged.
+// SP, LR and PC are aliases for R13, R14 and R15.
+type RegistersUpdateRequest struct {
+	R0   *uint32          `json:"r0,omitempty"`
+	R1   *uint32          `json:"r1,omitempty"`
+	R2   *uint32          `json:"r2,omitempty"`
+	R3   *uint32          `json:"r3,omitempty"`
+	R4   *uint32          `json:"r4,omitempty"`
+	R5   *uint32          `json:"r5,omitempty"`
+	R6   *uint32          `json:"r6,omitempty"`
+	R7   *uint32          `json:"r7,omitempty"`
+	R8   *uint32          `json:"r8,omitempty"`
+	R9   *uint32          `json:"r9,omitempty"`
+	R10  *uint32          `json:"r10,omitempty"`
+	R11  *uint32          `json:"r11,omitempty"`
+	R12  *uint32          `json:"r12,omitempty"`
+	R13  *uint32          `json:"r13,omitempty"`
+	R14  *uint32          `json:"r14,omitempty"`
+	R15  *uint32          `json:"r15,omitempty"`
+	SP   *uint32          `json:"sp,omitempty"`
+	LR   *uint32          `json:"lr,omitempty"`
+	PC   *uint32          `json:"pc,omitempty"`
+	CPSR *CPSRFlagsUpdate `json:"cpsr,omitempty"`
+}
+
+// CPSRFlagsUpdate sets any subset of the CPSR flags
+type CPSRFlagsUpdate struct {
+	N *bool `json:"n,omitempty"`
+	Z *bool `json:"z,omitempty"`
+	C *bool `json:"c,omitempty"`
+	V *bool `json:"v,omitempty"`
+}
+
+// ToRegisterUpdate converts the request to a service update, rejecting a register given
+// under both its number and its alias
+func (req *RegistersUpdateRequest) ToRegisterUpdate() (service.RegisterUpdate, error) {
+	var update service.RegisterUpdate
+	update.Registers = [16]*uint32{
+		req.R0, req.R1, req.R2, req.R3, req.R4, req.R5, req.R6, req.R7,
+		req.R8, req.R9, req.R10, req.R11, req.R12, req.R13, req.R14, req.R15,
+	}
+
+	aliases := []struct {
+		name  string
+		reg   int
+		value *uint32
+	}{
+		{"sp", vm.ARMRegisterSP, req.SP},
+		{"lr", vm.ARMRegisterLR, req.LR},
+		{"pc", vm.ARMRegisterPC, req.PC},
+	}
+	for _, alias := range aliases {
+		if alias.value == nil {
+			continue
+		}
+		if update.Registers[alias.reg] != nil {
+			return update, fmt.Errorf("r%d and %s both given", alias.reg, alias.name)
+		}
+		update.Registers[alias.reg] = alias.value
+	}
+
+	if req.CPSR != nil {
+		update.N, update.Z, update.C, update.V = req.CPSR.N, req.CPSR.Z, req.CPSR.C, req.CPSR.V
+	}
+	return update, nil
+}
+
 // MemoryRequest represents a request for memory data
 type MemoryRequest struct {
 	Address uint32 `json:"address"`
@@ -87,6 +232,20 @@ type MemoryResponse struct {
 	Length  uint32 `json:"length"`
 }
 
+// MemoryWriteRequest represents a request to write bytes to memory.
+// Exactly one of Data (base64 in JSON) or Hex must be provided.
+type MemoryWriteRequest struct {
+	Address uint32 `json:"address"`
+	Data    []byte `json:"data,omitempty"`
+	Hex     string `json:"hex,omitempty"`
+}
+
+// MemoryWriteResponse represents the result of a memory write
+type MemoryWriteResponse struct {
+	Address      uint32 `json:"address"`
+	BytesWritten uint32 `json:"bytesWritten"`
+}
+
 // DisassemblyRequest represents a request for disassembly
 type DisassemblyRequest struct {
 	Address uint32 `json:"address"`
@@ -111,9 +270,18 @@ type BreakpointRequest struct {
 	Address uint32 `json:"address"`
 }
 
-// BreakpointsResponse represents a list of breakpoints
+// RunToRequest represents a request to run until an address or label is reached.
+// Label takes precedence over Address when both are given.
+type RunToRequest struct {
+	Address uint32 `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+// BreakpointsResponse represents a list of breakpoints. Breakpoints holds just the
+// addresses; Details has the full entries, matching "info breakpoints json".
 type BreakpointsResponse struct {
-	Breakpoints []uint32 `json:"breakpoints"`
+	Breakpoints []uint32                 `json:"breakpoints"`
+	Details     []service.BreakpointInfo `json:"details"`
 }
 
 // StdinRequest represents a request to send stdin data
@@ -139,11 +307,24 @@ type WatchpointsResponse struct {
 	Watchpoints []service.WatchpointInfo `json:"watchpoints"`
 }
 
+// MemoryMapResponse lists the memory segments
+type MemoryMapResponse struct {
+	Segments []service.MemorySegmentInfo `json:"segments"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code,omitempty"`
+	Error   string     `json:"error"`
+	Message string     `json:"message,omitempty"`
+	Code    int        `json:"code,omitempty"`
+	Fault   *FaultInfo `json:"fault,omitempty"`
+}
+
+// FaultInfo describes a typed runtime fault raised by the program
+type FaultInfo struct {
+	Category string `json:"category"`
+	PC       uint32 `json:"pc"`
+	Address  uint32 `json:"address"`
 }
 
 // SuccessResponse represents a simple success response
@@ -261,12 +442,13 @@ type ConfigResponse struct {
 
 // ExecutionConfig represents execution settings
 type ExecutionConfig struct {
-	MaxCycles      uint64 `json:"maxCycles"`
-	StackSize      uint   `json:"stackSize"`
-	DefaultEntry   string `json:"defaultEntry"`
-	EnableTrace    bool   `json:"enableTrace"`
-	EnableMemTrace bool   `json:"enableMemTrace"`
-	EnableStats    bool   `json:"enableStats"`
+	MaxCycles       uint64 `json:"maxCycles"`
+	MaxInstructions uint64 `json:"maxInstructions"` // 0 = unlimited
+	StackSize       uint   `json:"stackSize"`
+	DefaultEntry    string `json:"defaultEntry"`
+	EnableTrace     bool   `json:"enableTrace"`
+	EnableMemTrace  bool   `json:"enableMemTrace"`
+	EnableStats     bool   `json:"enableStats"`
 }
 
 // DebuggerConfig represents debugger settings
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // Server represents the HTTP API server
@@ -89,6 +91,10 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/v1/session", s.handleSession)
 	s.mux.HandleFunc("/api/v1/session/", s.handleSessionRoute)
 
+	// Assemble without a session
+	s.mux.HandleFunc("/api/v1/assemble", s.handleAssemble)
+	s.mux.HandleFunc("/api/v1/ast", s.handleAST)
+
 	// Configuration
 	s.mux.HandleFunc("/api/v1/config", s.handleConfig)
 
@@ -237,6 +243,8 @@ func (s *Server) handleSessionRoute(w http.ResponseWriter, r *http.Request) {
 		s.handleLoadProgram(w, r, sessionID)
 	case "run":
 		s.handleRun(w, r, sessionID)
+	case "run-to":
+		s.handleRunTo(w, r, sessionID)
 	case "stop":
 		s.handleStop(w, r, sessionID)
 	case "step":
@@ -245,14 +253,26 @@ func (s *Server) handleSessionRoute(w http.ResponseWriter, r *http.Request) {
 		s.handleStepOver(w, r, sessionID)
 	case "step-out":
 		s.handleStepOut(w, r, sessionID)
+	case "stepback":
+		s.handleStepBack(w, r, sessionID)
 	case "reset":
 		s.handleReset(w, r, sessionID)
 	case "restart":
 		s.handleRestart(w, r, sessionID)
 	case "registers":
-		s.handleGetRegisters(w, r, sessionID)
+		if r.Method == http.MethodPut {
+			s.handleSetRegisters(w, r, sessionID)
+		} else {
+			s.handleGetRegisters(w, r, sessionID)
+		}
 	case "memory":
-		s.handleGetMemory(w, r, sessionID)
+		if r.Method == http.MethodPost {
+			s.handleWriteMemory(w, r, sessionID)
+		} else {
+			s.handleGetMemory(w, r, sessionID)
+		}
+	case "memory-map":
+		s.handleGetMemoryMap(w, r, sessionID)
 	case "disassembly":
 		s.handleGetDisassembly(w, r, sessionID)
 	case "console":
@@ -360,6 +380,28 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// writeRuntimeError reports an execution error. Typed program faults are the client's
+// program misbehaving rather than the server, so they map to 422 with the fault details;
+// anything else is a 500.
+func writeRuntimeError(w http.ResponseWriter, prefix string, err error) {
+	fault, ok := vm.AsRuntimeError(err)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", prefix, err))
+		return
+	}
+	status := http.StatusUnprocessableEntity
+	writeJSON(w, status, ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: fmt.Sprintf("%s: %v", prefix, err),
+		Code:    status,
+		Fault: &FaultInfo{
+			Category: fault.Category().String(),
+			PC:       fault.FaultPC(),
+			Address:  fault.FaultAddress(),
+		},
+	})
+}
+
 func readJSON(r *http.Request, v interface{}) error {
 	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1024*1024)) // 1MB limit
 	return decoder.Decode(v)
@@ -24,7 +24,8 @@ type Session struct {
 	ID        string
 	Service   *service.DebuggerService
 	CreatedAt time.Time
-	TempDir   string // Temporary directory for filesystem operations (cleaned up on destroy)
+	TempDir   string         // Temporary directory for filesystem operations (cleaned up on destroy)
+	Console   *ConsoleBuffer // Captured console output, whether or not it is also streamed
 }
 
 // SessionManager manages multiple emulator sessions
@@ -69,6 +70,28 @@ func (sm *SessionManager) CreateSession(opts SessionCreateRequest) (*Session, er
 		machine.FilesystemRoot = tempDir
 	}
 
+	// Bounded pre-step history for step-back from the GUI
+	machine.EnableHistory(vm.DefaultHistoryCapacity)
+
+	// Deterministic SWI_GET_RANDOM for reproducible runs (e.g. grading)
+	if opts.Seed != nil {
+		machine.SetRandomSeed(*opts.Seed)
+	}
+	machine.InstructionLimit = opts.MaxInstructions
+	machine.SyscallLimits = vm.SyscallLimits{
+		MaxCalls:        opts.MaxSyscalls,
+		MaxFileOpens:    opts.MaxFileOpens,
+		MaxBytesWritten: opts.MaxBytesWritten,
+	}
+	machine.SetProgramArguments(opts.Args)
+	if err := machine.SetEnvironment(opts.Env); err != nil {
+		return nil, err
+	}
+
+	// Always capture console output for /console; streaming is added below
+	console := &ConsoleBuffer{}
+	machine.AddOutputWriter(console)
+
 	// Set up output broadcasting if broadcaster is available
 	if sm.broadcaster != nil {
 		outputWriter := NewEventWriter(sm.broadcaster, sessionID, "stdout")
@@ -97,6 +120,7 @@ func (sm *SessionManager) CreateSession(opts SessionCreateRequest) (*Session, er
 		Service:   debugService,
 		CreatedAt: time.Now(),
 		TempDir:   tempDir,
+		Console:   console,
 	}
 
 	sm.mu.Lock()
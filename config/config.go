@@ -14,12 +14,13 @@ import (
 type Config struct {
 	// Execution settings
 	Execution struct {
-		MaxCycles      uint64 `toml:"max_cycles"`
-		StackSize      uint   `toml:"stack_size"`
-		DefaultEntry   string `toml:"default_entry"`
-		EnableTrace    bool   `toml:"enable_trace"`
-		EnableMemTrace bool   `toml:"enable_mem_trace"`
-		EnableStats    bool   `toml:"enable_stats"`
+		MaxCycles       uint64 `toml:"max_cycles"`
+		MaxInstructions uint64 `toml:"max_instructions"` // 0 = unlimited
+		StackSize       uint   `toml:"stack_size"`
+		DefaultEntry    string `toml:"default_entry"`
+		EnableTrace     bool   `toml:"enable_trace"`
+		EnableMemTrace  bool   `toml:"enable_mem_trace"`
+		EnableStats     bool   `toml:"enable_stats"`
 	} `toml:"execution"`
 
 	// Debugger settings
@@ -63,6 +64,7 @@ func DefaultConfig() *Config {
 
 	// Execution defaults
 	cfg.Execution.MaxCycles = 1000000
+	cfg.Execution.MaxInstructions = 0
 	cfg.Execution.StackSize = 65536 // 64KB
 	cfg.Execution.DefaultEntry = "0x8000"
 	cfg.Execution.EnableTrace = false
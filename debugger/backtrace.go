@@ -0,0 +1,140 @@
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// StackFrame is one frame of a reconstructed call chain
+type StackFrame struct {
+	Number   int
+	Address  uint32 // PC for frame 0, otherwise the return address into the frame
+	Function string // Name of the function containing the frame
+	Entry    uint32 // Start address of the function
+}
+
+// Backtrace reconstructs the call chain from LR and return addresses saved on the stack.
+// A candidate return address is accepted when the instruction before it is a BL to the
+// function of the previous frame (or a BLX register call, whose target cannot be checked).
+// Function boundaries are the program entry point and the targets of the BL instructions
+// in the program, so labels inside a function do not split it.
+func (d *Debugger) Backtrace() []StackFrame {
+	entries := d.functionEntries()
+	symbols := vm.NewSymbolResolver(d.Symbols)
+
+	frame := func(n int, addr, site uint32) StackFrame {
+		entry, ok := functionEntry(entries, site)
+		if !ok {
+			// No known call target before this address: use the nearest label instead
+			_, offset, found := symbols.ResolveAddress(site)
+			if !found {
+				return StackFrame{Number: n, Address: addr, Function: fmt.Sprintf("0x%08X", site), Entry: site}
+			}
+			entry = site - offset
+		}
+		name, offset, found := symbols.ResolveAddress(entry)
+		switch {
+		case !found:
+			name = fmt.Sprintf("0x%08X", entry)
+		case offset != 0:
+			name = fmt.Sprintf("%s+%d", name, offset)
+		}
+		return StackFrame{Number: n, Address: addr, Function: name, Entry: entry}
+	}
+
+	pc := d.VM.CPU.PC
+	frames := []StackFrame{frame(0, pc, pc)}
+
+	// LR is only a candidate for the innermost frame; the rest come from the stack
+	candidates := []uint32{d.VM.CPU.GetLR()}
+	sp := d.VM.CPU.GetSP()
+	for i := uint32(0); i < BacktraceMaxStackWords; i++ {
+		addr := sp + i*4
+		if addr < sp {
+			break
+		}
+		value, err := d.VM.Memory.ReadWord(addr)
+		if err != nil {
+			break
+		}
+		candidates = append(candidates, value)
+	}
+
+	for _, ret := range candidates {
+		if len(frames) >= BacktraceMaxFrames {
+			break
+		}
+		if ret < vm.ARMInstructionSize || ret&vm.AlignMaskWord != 0 {
+			continue
+		}
+		site := ret - vm.ARMInstructionSize
+		target, ok := d.callTarget(site)
+		if !ok {
+			continue
+		}
+		if target != nil && *target != frames[len(frames)-1].Entry {
+			continue
+		}
+		frames = append(frames, frame(len(frames), ret, site))
+	}
+	return frames
+}
+
+// callTarget reports whether addr holds a BL or BLX register instruction. For BL the
+// target is returned; for BLX it is nil.
+func (d *Debugger) callTarget(addr uint32) (*uint32, bool) {
+	if len(d.SourceMap) > 0 && d.isDataWord(addr) {
+		return nil, false
+	}
+	opcode, err := d.VM.Memory.ReadInstruction(addr)
+	if err != nil || vm.ConditionCode(opcode>>vm.ConditionShift) > vm.CondAL {
+		return nil, false
+	}
+	if opcode&vm.BXPatternMask == vm.BLXEncodingBase {
+		return nil, true
+	}
+	if opcode&vm.BranchLinkMask != vm.BranchLinkPattern {
+		return nil, false
+	}
+	offset := opcode & vm.Offset24BitMask
+	if offset&vm.Offset24BitSignBit != 0 {
+		offset |= vm.Offset24BitSignExt
+	}
+	target := addr + vm.PCBranchBase + offset<<2
+	return &target, true
+}
+
+// functionEntries returns the entry point and the targets of every BL instruction in the
+// source map, sorted
+func (d *Debugger) functionEntries() []uint32 {
+	seen := make(map[uint32]bool)
+	if d.VM.EntryPoint != 0 {
+		seen[d.VM.EntryPoint] = true
+	}
+	for addr, line := range d.SourceMap {
+		if strings.HasPrefix(line, "[DATA]") {
+			continue
+		}
+		if target, ok := d.callTarget(addr); ok && target != nil {
+			seen[*target] = true
+		}
+	}
+	entries := make([]uint32, 0, len(seen))
+	for addr := range seen {
+		entries = append(entries, addr)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+	return entries
+}
+
+// functionEntry returns the last entry at or before addr
+func functionEntry(entries []uint32, addr uint32) (uint32, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i] > addr })
+	if i == 0 {
+		return 0, false
+	}
+	return entries[i-1], true
+}
@@ -0,0 +1,172 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// savedBreakpoints is the JSON layout written by save-breakpoints
+type savedBreakpoints struct {
+	Breakpoints []savedBreakpoint `json:"breakpoints"`
+	Watchpoints []savedWatchpoint `json:"watchpoints"`
+}
+
+// savedBreakpoint records a breakpoint by label and offset where one covers its address,
+// so it can be re-resolved after the program changes
+type savedBreakpoint struct {
+	Address     uint32 `json:"address"`
+	Label       string `json:"label,omitempty"`
+	Offset      uint32 `json:"offset,omitempty"`
+	Condition   string `json:"condition,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	Temporary   bool   `json:"temporary,omitempty"`
+	IgnoreCount int    `json:"ignore_count,omitempty"`
+}
+
+// savedWatchpoint records a watchpoint by the command and expression that set it
+type savedWatchpoint struct {
+	Command    string `json:"command"`
+	Expression string `json:"expression"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// SaveBreakpoints writes all breakpoints and watchpoints to a JSON file
+func (d *Debugger) SaveBreakpoints(path string) error {
+	symbols := vm.NewSymbolResolver(d.Symbols)
+	saved := savedBreakpoints{Breakpoints: []savedBreakpoint{}, Watchpoints: []savedWatchpoint{}}
+
+	breakpoints := d.Breakpoints.GetAllBreakpoints()
+	sort.Slice(breakpoints, func(i, j int) bool { return breakpoints[i].ID < breakpoints[j].ID })
+	for _, bp := range breakpoints {
+		entry := savedBreakpoint{
+			Address:     bp.Address,
+			Condition:   bp.Condition,
+			Enabled:     bp.Enabled,
+			Temporary:   bp.Temporary,
+			IgnoreCount: bp.IgnoreCount,
+		}
+		if name, offset, found := symbols.ResolveAddress(bp.Address); found {
+			entry.Label, entry.Offset = name, offset
+		}
+		saved.Breakpoints = append(saved.Breakpoints, entry)
+	}
+
+	watchpoints := d.Watchpoints.GetAllWatchpoints()
+	sort.Slice(watchpoints, func(i, j int) bool { return watchpoints[i].ID < watchpoints[j].ID })
+	for _, wp := range watchpoints {
+		saved.Watchpoints = append(saved.Watchpoints, savedWatchpoint{
+			Command:    watchCommandNames[wp.Type],
+			Expression: wp.Expression,
+			Enabled:    wp.Enabled,
+		})
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode breakpoints: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadBreakpoints restores breakpoints and watchpoints from a file written by
+// SaveBreakpoints, adding them to any already set. Labels are resolved against the
+// current symbol table; entries that no longer resolve are skipped and reported in the
+// returned warnings.
+func (d *Debugger) LoadBreakpoints(path string) ([]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- user-specified breakpoint file
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var saved savedBreakpoints
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid breakpoint file %s: %w", path, err)
+	}
+
+	var warnings []string
+	for _, entry := range saved.Breakpoints {
+		address := entry.Address
+		if entry.Label != "" {
+			base, ok := d.Symbols[entry.Label]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("breakpoint at %s+%d skipped: label no longer exists", entry.Label, entry.Offset))
+				continue
+			}
+			address = base + entry.Offset
+		}
+
+		bp := d.Breakpoints.AddBreakpoint(address, entry.Temporary, entry.Condition)
+		if err := d.Breakpoints.SetIgnoreCount(bp.ID, entry.IgnoreCount); err != nil {
+			return warnings, err
+		}
+		if !entry.Enabled {
+			if err := d.Breakpoints.DisableBreakpoint(bp.ID); err != nil {
+				return warnings, err
+			}
+		}
+	}
+
+	for _, entry := range saved.Watchpoints {
+		wpType, ok := watchTypeForCommand(entry.Command)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("watchpoint %q skipped: unknown command %q", entry.Expression, entry.Command))
+			continue
+		}
+		wp, err := d.newWatchpoint(wpType, entry.Expression)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("watchpoint %q skipped: %v", entry.Expression, err))
+			continue
+		}
+		if !entry.Enabled {
+			if err := d.Watchpoints.DisableWatchpoint(wp.ID); err != nil {
+				return warnings, err
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// watchTypeForCommand maps a watch command name back to its watchpoint type
+func watchTypeForCommand(command string) (WatchType, bool) {
+	for wpType, name := range watchCommandNames {
+		if name == command {
+			return wpType, true
+		}
+	}
+	return 0, false
+}
+
+// cmdSaveBreakpoints saves breakpoints and watchpoints to a file
+func (d *Debugger) cmdSaveBreakpoints(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: save-breakpoints <file>")
+	}
+	if err := d.SaveBreakpoints(args[0]); err != nil {
+		return err
+	}
+	d.Printf("Saved %d breakpoints and %d watchpoints to %s\n", d.Breakpoints.Count(), d.Watchpoints.Count(), args[0])
+	return nil
+}
+
+// cmdLoadBreakpoints restores breakpoints and watchpoints from a file
+func (d *Debugger) cmdLoadBreakpoints(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: load-breakpoints <file>")
+	}
+	warnings, err := d.LoadBreakpoints(args[0])
+	for _, warning := range warnings {
+		d.Printf("Warning: %s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	d.Printf("Loaded breakpoints from %s (%d breakpoints, %d watchpoints set)\n", args[0], d.Breakpoints.Count(), d.Watchpoints.Count())
+	return nil
+}
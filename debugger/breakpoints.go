@@ -2,6 +2,7 @@ package debugger
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -13,6 +14,21 @@ type Breakpoint struct {
 	Temporary bool   // Auto-delete after first hit
 	Condition string // Optional condition expression
 	HitCount  int    // Number of times this breakpoint was hit
+	// IgnoreCount is the number of hits to pass over before stopping; the breakpoint
+	// first stops on hit IgnoreCount+1. Hits only count when Condition is true.
+	IgnoreCount int
+}
+
+// BreakpointSummary is the JSON form of a breakpoint shared by "info breakpoints json" and
+// the API. Every field is always present so tools can rely on the shape.
+type BreakpointSummary struct {
+	ID          int    `json:"id"`
+	Address     uint32 `json:"address"`
+	Condition   string `json:"condition"` // Empty when unconditional
+	Enabled     bool   `json:"enabled"`
+	Temporary   bool   `json:"temporary"`
+	IgnoreCount int    `json:"ignoreCount"`
+	HitCount    int    `json:"hitCount"`
 }
 
 // BreakpointManager manages all breakpoints
@@ -119,6 +135,27 @@ func (bm *BreakpointManager) DisableBreakpoint(id int) error {
 	return fmt.Errorf("breakpoint %d not found", id)
 }
 
+// SetIgnoreCount sets the number of hits to ignore before a breakpoint stops and
+// restarts its hit count, so the next stop is count+1 hits from now
+func (bm *BreakpointManager) SetIgnoreCount(id, count int) error {
+	if count < 0 {
+		return fmt.Errorf("ignore count must not be negative: %d", count)
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for _, bp := range bm.breakpoints {
+		if bp.ID == id {
+			bp.IgnoreCount = count
+			bp.HitCount = 0
+			return nil
+		}
+	}
+
+	return fmt.Errorf("breakpoint %d not found", id)
+}
+
 // GetBreakpoint gets a breakpoint at a specific address
 func (bm *BreakpointManager) GetBreakpoint(address uint32) *Breakpoint {
 	bm.mu.RLock()
@@ -154,6 +191,27 @@ func (bm *BreakpointManager) GetAllBreakpoints() []*Breakpoint {
 	return result
 }
 
+// Summaries returns a snapshot of every breakpoint, ordered by ID
+func (bm *BreakpointManager) Summaries() []BreakpointSummary {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	result := make([]BreakpointSummary, 0, len(bm.breakpoints))
+	for _, bp := range bm.breakpoints {
+		result = append(result, BreakpointSummary{
+			ID:          bp.ID,
+			Address:     bp.Address,
+			Condition:   bp.Condition,
+			Enabled:     bp.Enabled,
+			Temporary:   bp.Temporary,
+			IgnoreCount: bp.IgnoreCount,
+			HitCount:    bp.HitCount,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
 // Clear removes all breakpoints
 func (bm *BreakpointManager) Clear() {
 	bm.mu.Lock()
@@ -180,14 +238,15 @@ func (bm *BreakpointManager) Count() int {
 }
 
 // ProcessHit atomically increments hit count and handles temporary breakpoint deletion
-// Returns a copy of the breakpoint for safe access after the lock is released
-func (bm *BreakpointManager) ProcessHit(address uint32) *Breakpoint {
+// Returns a copy of the breakpoint for safe access after the lock is released, and
+// whether execution should stop (false while the hit is still within IgnoreCount)
+func (bm *BreakpointManager) ProcessHit(address uint32) (*Breakpoint, bool) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
 
 	bp, exists := bm.breakpoints[address]
 	if !exists {
-		return nil
+		return nil, false
 	}
 
 	// Increment hit count
@@ -196,10 +255,14 @@ func (bm *BreakpointManager) ProcessHit(address uint32) *Breakpoint {
 	// Make a copy for return
 	result := *bp
 
+	if bp.HitCount <= bp.IgnoreCount {
+		return &result, false
+	}
+
 	// Delete if temporary
 	if bp.Temporary {
 		delete(bm.breakpoints, address)
 	}
 
-	return &result
+	return &result, true
 }
@@ -0,0 +1,155 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// CallFunction runs the guest function at address with args, returning its R0. The first
+// four arguments go in R0-R3 and the rest on the stack, as the ARM procedure call standard
+// passes them. LR is set to CallReturnAddress and the function runs, without stopping at
+// breakpoints, until it returns there.
+//
+// Afterwards the registers, flags and execution state are restored from before the call,
+// whether or not it returned. Memory the function wrote keeps its new contents.
+func (d *Debugger) CallFunction(address uint32, args []uint32) (uint32, error) {
+	machine := d.VM
+	savedCPU := *machine.CPU
+	savedState, savedError, savedExit, savedBKPT := machine.State, machine.LastError, machine.ExitCode, machine.LastBKPT
+	defer func() {
+		*machine.CPU = savedCPU
+		machine.State, machine.LastError, machine.ExitCode, machine.LastBKPT = savedState, savedError, savedExit, savedBKPT
+	}()
+
+	cpu := machine.CPU
+	for i := 0; i < min(len(args), CallRegisterArgs); i++ {
+		cpu.R[i] = args[i]
+	}
+	if len(args) > CallRegisterArgs {
+		stacked := args[CallRegisterArgs:]
+		sp := cpu.R[vm.ARMRegisterSP] - uint32(len(stacked)*4) // #nosec G115 -- argument count is small
+		for i, arg := range stacked {
+			if err := machine.Memory.WriteWord(sp+uint32(i*4), arg); err != nil { // #nosec G115 -- argument count is small
+				return 0, fmt.Errorf("failed to push argument %d: %w", CallRegisterArgs+i, err)
+			}
+		}
+		cpu.R[vm.ARMRegisterSP] = sp
+	}
+	cpu.R[vm.ARMRegisterLR] = CallReturnAddress
+	cpu.PC = address
+	machine.State = vm.StateRunning
+	machine.LastBKPT = nil
+
+	step := machine.StepFunc()
+	for executed := 0; cpu.PC != CallReturnAddress; executed++ {
+		if executed >= CallMaxInstructions {
+			return 0, fmt.Errorf("function did not return within %d instructions (stopped at PC=0x%08X)", CallMaxInstructions, cpu.PC)
+		}
+		if err := step(); err != nil {
+			if machine.State == vm.StateHalted {
+				return 0, fmt.Errorf("function exited the program with code %d", machine.ExitCode)
+			}
+			return 0, fmt.Errorf("function stopped at PC=0x%08X: %w", cpu.PC, err)
+		}
+		if machine.LastBKPT != nil {
+			return 0, fmt.Errorf("function stopped: %s", machine.LastBKPT)
+		}
+		if machine.State != vm.StateRunning {
+			return 0, fmt.Errorf("function stopped at PC=0x%08X waiting for input", cpu.PC)
+		}
+	}
+	return cpu.R[0], nil
+}
+
+// splitCallArgs splits a call command line into the function and its argument expressions.
+// Arguments are separated by commas outside parentheses, so call f((1+2)*3, x+1) has two;
+// without a comma they are separated by spaces outside parentheses, as in call f 1 2.
+func splitCallArgs(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	nameEnd := strings.IndexAny(line, " \t,(")
+	if nameEnd < 0 {
+		if line == "" {
+			return nil, nil
+		}
+		return []string{line}, nil
+	}
+	fields := []string{line[:nameEnd]}
+	rest := strings.TrimSpace(line[nameEnd:])
+
+	// FUNC(args): drop the parentheses when the one after the name closes at the end
+	if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+		if inner := rest[1 : len(rest)-1]; splitTopLevel(inner, "") != nil {
+			rest = inner
+		}
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+
+	parts := splitTopLevel(rest, ",")
+	if parts == nil {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", rest)
+	}
+	if len(parts) == 1 {
+		parts = splitTopLevel(rest, " \t")
+	}
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			fields = append(fields, part)
+		}
+	}
+	return fields, nil
+}
+
+// splitTopLevel splits s at any of the separators outside parentheses. It returns nil if
+// the parentheses are unbalanced.
+func splitTopLevel(s, separators string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ')':
+			return nil
+		case depth == 0 && strings.ContainsRune(separators, r):
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if depth != 0 {
+		return nil
+	}
+	return append(parts, s[start:])
+}
+
+// cmdCall calls a guest function: call FUNC(arg, ...), call FUNC, arg, ... or call FUNC arg ...
+func (d *Debugger) cmdCall(args []string) error {
+	fields, err := splitCallArgs(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("usage: call <address|label>[(arg, ...)]")
+	}
+
+	address, err := d.ResolveAddress(fields[0])
+	if err != nil {
+		return err
+	}
+	values := make([]uint32, len(fields)-1)
+	for i, arg := range fields[1:] {
+		if values[i], err = d.Evaluator.EvaluateValue(arg, d.VM, d.Symbols); err != nil {
+			return fmt.Errorf("invalid argument %q: %w", arg, err)
+		}
+	}
+
+	result, err := d.CallFunction(address, values)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", fields[0], err)
+	}
+	d.Printf("%s returned %d (0x%08X)\n", fields[0], int32(result), result) // #nosec G115 -- R0 shown as signed
+	return nil
+}
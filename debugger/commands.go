@@ -1,8 +1,12 @@
 package debugger
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -54,7 +58,7 @@ func (d *Debugger) cmdStep(args []string) error {
 // cmdNext steps over function calls (step to next instruction at same level)
 func (d *Debugger) cmdNext(args []string) error {
 	// Read instruction at current PC
-	instr, err := d.VM.Memory.ReadWord(d.VM.CPU.PC)
+	instr, err := d.VM.Memory.ReadInstruction(d.VM.CPU.PC)
 	if err != nil {
 		return fmt.Errorf("failed to read instruction: %w", err)
 	}
@@ -76,17 +80,54 @@ func (d *Debugger) cmdNext(args []string) error {
 	return nil
 }
 
-// cmdFinish steps out of current function
-func (d *Debugger) cmdFinish(args []string) error {
-	d.StepMode = StepOut
+// cmdStepLine executes instructions until the current source line changes
+func (d *Debugger) cmdStepLine(args []string) error {
+	d.StepLineStart = d.VM.CPU.PC
+	d.StepLineLocation = d.AddressLines[d.StepLineStart]
+	d.StepMode = StepLine
 	d.Running = true
 	return nil
 }
 
+// cmdFinish runs until the current function returns to its caller
+func (d *Debugger) cmdFinish(args []string) error {
+	frame, err := d.startStepOut()
+	if err != nil {
+		return err
+	}
+	d.Printf("Run till exit from %s, returning to 0x%08X\n", frame.Function, d.StepOutPC)
+	return nil
+}
+
+// cmdRunTo runs until an address is reached, starting the program if it is not running.
+// The target is one-shot: it is forgotten once reached or when execution stops elsewhere.
+func (d *Debugger) cmdRunTo(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: run-to <address|label|file:line>")
+	}
+
+	address, err := d.ResolveAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	if d.VM.State == vm.StateHalted {
+		if err := d.cmdRun(nil); err != nil {
+			return err
+		}
+	} else {
+		d.VM.State = vm.StateRunning
+	}
+
+	d.startRunTo(address)
+	d.Printf("Running to 0x%08X\n", address)
+	return nil
+}
+
 // cmdBreak sets a breakpoint
 func (d *Debugger) cmdBreak(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: break <address|label> [if <condition>]")
+		return fmt.Errorf("usage: break <address|label|file:line> [ignore <count>] [if <condition>]")
 	}
 
 	// Parse address/label
@@ -94,21 +135,44 @@ func (d *Debugger) cmdBreak(args []string) error {
 	if err != nil {
 		return err
 	}
+	rest := args[1:]
+
+	// Parse ignore count if present
+	ignore := 0
+	if len(rest) > 0 && strings.ToLower(rest[0]) == "ignore" {
+		if len(rest) < 2 {
+			return fmt.Errorf("usage: break <address|label|file:line> ignore <count> [if <condition>]")
+		}
+		ignore, err = strconv.Atoi(rest[1])
+		if err != nil || ignore < 0 {
+			return fmt.Errorf("invalid ignore count: %s", rest[1])
+		}
+		rest = rest[2:]
+	}
 
 	// Parse condition if present
 	var condition string
-	if len(args) > 1 && strings.ToLower(args[1]) == "if" {
-		condition = strings.Join(args[2:], " ")
+	if len(rest) > 0 && strings.ToLower(rest[0]) == "if" {
+		condition = strings.Join(rest[1:], " ")
 	}
 
 	// Add breakpoint
+	// Re-setting an existing breakpoint replaces its ignore count, like its condition
 	bp := d.Breakpoints.AddBreakpoint(address, false, condition)
+	if ignore > 0 || bp.IgnoreCount > 0 {
+		if err := d.Breakpoints.SetIgnoreCount(bp.ID, ignore); err != nil {
+			return err
+		}
+	}
 
+	msg := fmt.Sprintf("Breakpoint %d at 0x%08X", bp.ID, address)
 	if condition != "" {
-		d.Printf("Breakpoint %d at 0x%08X (condition: %s)\n", bp.ID, address, condition)
-	} else {
-		d.Printf("Breakpoint %d at 0x%08X\n", bp.ID, address)
+		msg += fmt.Sprintf(" (condition: %s)", condition)
+	}
+	if ignore > 0 {
+		msg += fmt.Sprintf(" (ignoring next %d hits)", ignore)
 	}
+	d.Println(msg)
 
 	return nil
 }
@@ -116,7 +180,7 @@ func (d *Debugger) cmdBreak(args []string) error {
 // cmdTBreak sets a temporary breakpoint (auto-delete after hit)
 func (d *Debugger) cmdTBreak(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: tbreak <address|label>")
+		return fmt.Errorf("usage: tbreak <address|label|file:line>")
 	}
 
 	address, err := d.ResolveAddress(args[0])
@@ -198,23 +262,87 @@ func (d *Debugger) cmdWatch(args []string) error {
 	}
 
 	expression := strings.Join(args, " ")
-
-	// Parse expression to determine if register or memory
-	isRegister, register, address, err := d.parseWatchExpression(expression)
+	wp, err := d.newWatchpoint(WatchWrite, expression)
 	if err != nil {
 		return err
 	}
 
-	wp := d.Watchpoints.AddWatchpoint(WatchWrite, expression, address, isRegister, register)
+	if wp.IsExpression {
+		d.Printf("Expression watchpoint %d: %s = 0x%08X (checked every step)\n", wp.ID, expression, wp.LastValue)
+	} else if wp.IsRange() {
+		d.Printf("Watchpoint %d: %s (0x%08X-0x%08X)\n", wp.ID, expression, wp.Address, wp.Address+wp.Length-1)
+	} else {
+		d.Printf("Watchpoint %d: %s\n", wp.ID, expression)
+	}
+	return nil
+}
+
+// newWatchpoint adds and initialises a watchpoint of the given type. Computed expressions
+// (write watchpoints only) are watched by value rather than by register or address, and
+// "[start, length]" watches every access to a memory range.
+func (d *Debugger) newWatchpoint(wpType WatchType, expression string) (*Watchpoint, error) {
+	var wp *Watchpoint
+	if start, length, ok := splitWatchRange(expression); ok {
+		address, err := d.Evaluator.EvaluateValue(start, d.VM, d.Symbols)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q: %w", start, err)
+		}
+		size, err := d.Evaluator.EvaluateValue(length, d.VM, d.Symbols)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range length %q: %w", length, err)
+		}
+		if size == 0 || uint64(address)+uint64(size) > 1<<32 {
+			return nil, fmt.Errorf("invalid watch range: 0x%08X with length %d", address, size)
+		}
+		wp = d.Watchpoints.AddRangeWatchpoint(wpType, expression, address, size)
+		d.VM.OnMemoryAccess = d.Watchpoints.RecordAccess
+		return wp, nil
+	}
+	if isComputedWatchExpression(expression) {
+		if wpType != WatchWrite {
+			return nil, fmt.Errorf("%s needs a register or address; use watch for computed expressions", watchCommandNames[wpType])
+		}
+		wp = d.Watchpoints.AddExpressionWatchpoint(expression, func(machine *vm.VM) (uint32, error) {
+			return d.Evaluator.EvaluateValue(expression, machine, d.Symbols)
+		})
+	} else {
+		// Parse expression to determine if register or memory
+		isRegister, register, address, err := d.parseWatchExpression(expression)
+		if err != nil {
+			return nil, err
+		}
+		wp = d.Watchpoints.AddWatchpoint(wpType, expression, address, isRegister, register)
+	}
 
 	// Initialize current value
 	if err := d.Watchpoints.InitializeWatchpoint(wp.ID, d.VM); err != nil {
 		_ = d.Watchpoints.DeleteWatchpoint(wp.ID) // Ignore error on cleanup
-		return err
+		return nil, err
 	}
+	return wp, nil
+}
 
-	d.Printf("Watchpoint %d: %s\n", wp.ID, expression)
-	return nil
+// splitWatchRange splits a "[start, length]" range watch expression
+func splitWatchRange(expr string) (start, length string, ok bool) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "[") || !strings.HasSuffix(expr, "]") {
+		return "", "", false
+	}
+	start, length, ok = strings.Cut(expr[1:len(expr)-1], ",")
+	return strings.TrimSpace(start), strings.TrimSpace(length), ok
+}
+
+// isComputedWatchExpression reports whether a watch expression needs the expression
+// evaluator: it uses an operator, or dereferences a register as in [R4]
+func isComputedWatchExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if strings.ContainsAny(expr, "+-*/%&|^~()<>") {
+		return true
+	}
+	if strings.HasPrefix(expr, "[") && strings.HasSuffix(expr, "]") {
+		return isRegisterName(strings.TrimSpace(expr[1 : len(expr)-1]))
+	}
+	return false
 }
 
 // cmdRWatch sets a read watchpoint
@@ -224,18 +352,11 @@ func (d *Debugger) cmdRWatch(args []string) error {
 	}
 
 	expression := strings.Join(args, " ")
-	isRegister, register, address, err := d.parseWatchExpression(expression)
+	wp, err := d.newWatchpoint(WatchRead, expression)
 	if err != nil {
 		return err
 	}
 
-	wp := d.Watchpoints.AddWatchpoint(WatchRead, expression, address, isRegister, register)
-
-	if err := d.Watchpoints.InitializeWatchpoint(wp.ID, d.VM); err != nil {
-		_ = d.Watchpoints.DeleteWatchpoint(wp.ID) // Ignore error on cleanup
-		return err
-	}
-
 	d.Printf("Read watchpoint %d: %s\n", wp.ID, expression)
 	return nil
 }
@@ -247,18 +368,11 @@ func (d *Debugger) cmdAWatch(args []string) error {
 	}
 
 	expression := strings.Join(args, " ")
-	isRegister, register, address, err := d.parseWatchExpression(expression)
+	wp, err := d.newWatchpoint(WatchReadWrite, expression)
 	if err != nil {
 		return err
 	}
 
-	wp := d.Watchpoints.AddWatchpoint(WatchReadWrite, expression, address, isRegister, register)
-
-	if err := d.Watchpoints.InitializeWatchpoint(wp.ID, d.VM); err != nil {
-		_ = d.Watchpoints.DeleteWatchpoint(wp.ID) // Ignore error on cleanup
-		return err
-	}
-
 	d.Printf("Access watchpoint %d: %s\n", wp.ID, expression)
 	return nil
 }
@@ -313,137 +427,283 @@ func (d *Debugger) cmdPrint(args []string) error {
 		return fmt.Errorf("usage: print <expression>")
 	}
 
+	if strings.EqualFold(args[0], "struct") {
+		return d.printStruct(args[1:])
+	}
+
+	var format byte
+	if spec, found := strings.CutPrefix(args[0], "/"); found {
+		if len(spec) != 1 || !strings.Contains("xduto", spec) {
+			return fmt.Errorf("invalid print format /%s (use /x, /d, /u, /t or /o)", spec)
+		}
+		format = spec[0]
+		args = args[1:]
+		if len(args) == 0 {
+			return fmt.Errorf("usage: print/FMT <expression>")
+		}
+	}
+
 	expression := strings.Join(args, " ")
-	result, err := d.Evaluator.EvaluateExpression(expression, d.VM, d.Symbols)
+	result, typ, err := d.Evaluator.evaluateExpressionTyped(expression, d.VM, d.Symbols)
 	if err != nil {
 		return err
 	}
 
-	if result > uint32(math.MaxInt32) {
-		d.Printf("$%d = 0x%08X (out of int32 range: %d)\n", d.Evaluator.GetValueNumber(), result, result)
-	} else {
-		d.Printf("$%d = 0x%08X (%d)\n", d.Evaluator.GetValueNumber(), result, int32(result))
+	number := d.Evaluator.GetValueNumber()
+	switch {
+	case format != 0:
+		d.Printf("$%d = %s\n", number, formatPrintValue(result, typ, format))
+	case typ.bits != 0:
+		d.Printf("$%d = %s (%s)\n", number, formatPrintValue(result, typ, 'x'), formatPrintValue(result, typ, typ.decimalFormat()))
+	case result > uint32(math.MaxInt32):
+		d.Printf("$%d = 0x%08X (out of int32 range: %d)\n", number, result, result)
+	default:
+		d.Printf("$%d = 0x%08X (%d)\n", number, result, int32(result))
 	}
 	return nil
 }
 
-// cmdExamine examines memory at an address
+// decimalFormat is the x format letter for the type's decimal form
+func (t exprType) decimalFormat() byte {
+	if t.signed {
+		return 'd'
+	}
+	return 'u'
+}
+
+// formatPrintValue formats a print result with an x format letter, at the width of its
+// cast type (a full word when untyped)
+func formatPrintValue(value uint32, typ exprType, format byte) string {
+	size := uint32(4)
+	if typ.bits != 0 {
+		size = uint32(typ.bits / 8) // #nosec G115 -- 8, 16 or 32
+	}
+	if size < 4 {
+		value &= 1<<(size*8) - 1
+	}
+	return formatExamineValue(value, size, format)
+}
+
+// cmdExamine examines memory at an address: x[/nfu] <address>
 func (d *Debugger) cmdExamine(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: x[/nfu] <address>\n  n: count, f: format (x/d/u/o/t/a/c/s), u: unit size (b/h/w)")
+		return fmt.Errorf("usage: x[/nfu] <address>\n  n: count, f: format (x/d/u/o/t/c/s/i), u: unit size (b/h/w)")
 	}
 
-	// Parse format specifier (e.g., "x/8xw")
-	count := 1
-	format := 'x'
-	unit := 'w'
-	addrArg := args[0]
-
+	count, format, unit, addrArg := 1, byte('x'), byte(0), strings.Join(args, " ")
 	if strings.HasPrefix(args[0], "/") {
-		// Parse format
-		formatStr := args[0][1:]
 		if len(args) < 2 {
 			return fmt.Errorf("missing address")
 		}
-		addrArg = args[1]
+		addrArg = strings.Join(args[1:], " ")
 
-		// Parse count
-		i := 0
-		for i < len(formatStr) && formatStr[i] >= '0' && formatStr[i] <= '9' {
-			i++
+		spec := args[0][1:]
+		digits := 0
+		for digits < len(spec) && spec[digits] >= '0' && spec[digits] <= '9' {
+			digits++
 		}
-		if i > 0 {
-			if n, err := strconv.Atoi(formatStr[:i]); err == nil {
-				count = n
+		if digits > 0 {
+			n, err := strconv.Atoi(spec[:digits])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid count: %s", spec[:digits])
 			}
-			formatStr = formatStr[i:]
-		}
-
-		// Parse format character
-		if len(formatStr) > 0 {
-			format = rune(formatStr[0])
-			formatStr = formatStr[1:]
+			count = n
 		}
-
-		// Parse unit size
-		if len(formatStr) > 0 {
-			unit = rune(formatStr[0])
+		// Format and unit letters may come in either order, as in gdb
+		for _, ch := range []byte(spec[digits:]) {
+			switch ch {
+			case 'b', 'h', 'w':
+				unit = ch
+			case 'x', 'd', 'u', 'o', 't', 'c', 's', 'i':
+				format = ch
+			default:
+				return fmt.Errorf("invalid format letter '%c' in /%s", ch, spec)
+			}
 		}
 	}
 
-	// Resolve address
-	address, err := d.ResolveAddress(addrArg)
+	address, err := d.resolveAddressExpression(addrArg)
 	if err != nil {
 		return err
 	}
 
-	// Read and display memory
-	d.Printf("0x%08X:", address)
+	switch format {
+	case 'i':
+		lines, err := d.DisassembleRange(address, count)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			d.Println(d.formatDisassemblyLine(line))
+		}
+		return nil
+	case 's':
+		return d.examineStrings(address, count)
+	}
+
+	if unit == 0 {
+		unit = 'w'
+		if format == 'c' {
+			unit = 'b'
+		}
+	}
+	size, perLine := uint32(4), ExamineWordsPerLine
+	switch unit {
+	case 'b':
+		size, perLine = 1, ExamineBytesPerLine
+	case 'h':
+		size, perLine = 2, ExamineHalfwordsPerLine
+	}
+
 	for i := 0; i < count; i++ {
-		var value uint32
-		var readErr error
-
-		switch unit {
-		case 'b': // byte
-			val, e := d.VM.Memory.ReadByteAt(address)
-			value = uint32(val)
-			readErr = e
-			address++
-		case 'h': // halfword
-			val, e := d.VM.Memory.ReadHalfword(address)
-			value = uint32(val)
-			readErr = e
-			address += 2
-		default: // 'w' - word
-			value, readErr = d.VM.Memory.ReadWord(address)
-			address += 4
-		}
-
-		if readErr != nil {
-			return readErr
-		}
-
-		// Format output
-		switch format {
-		case 'x': // hex
-			d.Printf(" 0x%08X", value)
-		case 'd': // signed decimal
-			d.Printf(" %d", vm.AsInt32(value))
-		case 'u': // unsigned decimal
-			d.Printf(" %d", value)
-		case 'o': // octal
-			d.Printf(" %o", value)
-		case 't': // binary
-			d.Printf(" %b", value)
-		default:
-			d.Printf(" 0x%08X", value)
+		value, err := d.readUnit(address, size)
+		if err != nil {
+			if i > 0 {
+				d.Println()
+			}
+			return err
+		}
+
+		if i%perLine == 0 {
+			if i > 0 {
+				d.Println()
+			}
+			d.Printf("0x%08X:", address)
 		}
+		d.Printf(" %s", formatExamineValue(value, size, format))
+		address += size
 	}
 	d.Println()
+	return nil
+}
+
+// resolveAddressExpression resolves a label, number or file:line like ResolveAddress,
+// and evaluates anything else as an expression, e.g. SP or vals+8
+func (d *Debugger) resolveAddressExpression(arg string) (uint32, error) {
+	address, err := d.ResolveAddress(arg)
+	if err == nil {
+		return address, nil
+	}
+	if value, exprErr := d.Evaluator.EvaluateValue(arg, d.VM, d.Symbols); exprErr == nil {
+		return value, nil
+	}
+	return 0, err
+}
 
+// readUnit reads a byte, halfword or word from memory
+func (d *Debugger) readUnit(address, size uint32) (uint32, error) {
+	switch size {
+	case 1:
+		value, err := d.VM.Memory.ReadByteAt(address)
+		return uint32(value), err
+	case 2:
+		value, err := d.VM.Memory.ReadHalfword(address)
+		return uint32(value), err
+	default:
+		return d.VM.Memory.ReadWord(address)
+	}
+}
+
+// formatExamineValue formats a value read by x. Hex is padded to the unit size, and
+// signed decimal sign-extends from it.
+func formatExamineValue(value, size uint32, format byte) string {
+	bits := size * 8
+	switch format {
+	case 'd':
+		shift := 32 - bits
+		return strconv.Itoa(int(vm.AsInt32(value<<shift) >> shift))
+	case 'u':
+		return strconv.FormatUint(uint64(value), 10)
+	case 'o':
+		return "0" + strconv.FormatUint(uint64(value), 8)
+	case 't':
+		return fmt.Sprintf("%0*b", bits, value)
+	case 'c':
+		return fmt.Sprintf("%d %s", value&0xFF, strconv.QuoteRune(rune(value&0xFF)))
+	default:
+		return fmt.Sprintf("0x%0*X", size*2, value)
+	}
+}
+
+// examineStrings prints count consecutive null-terminated strings starting at address
+func (d *Debugger) examineStrings(address uint32, count int) error {
+	for i := 0; i < count; i++ {
+		start := address
+		var text []byte
+		truncated := false
+		for {
+			b, err := d.VM.Memory.ReadByteAt(address)
+			if err != nil {
+				if len(text) == 0 {
+					return err
+				}
+				break
+			}
+			address++
+			if b == 0 {
+				break
+			}
+			if len(text) == ExamineMaxStringLength {
+				truncated = true
+				continue
+			}
+			text = append(text, b)
+		}
+
+		suffix := ""
+		if truncated {
+			suffix = "..."
+		}
+		d.Printf("0x%08X: %q%s\n", start, text, suffix)
+	}
 	return nil
 }
 
 // cmdInfo displays information about program state
 func (d *Debugger) cmdInfo(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: info <registers|breakpoints|watchpoints|stack>")
+		return fmt.Errorf("usage: info <registers|breakpoints|watchpoints|stack|literals|memory>")
 	}
 
 	switch strings.ToLower(args[0]) {
 	case "registers", "reg", "r":
 		return d.showRegisters()
 	case "breakpoints", "break", "b":
+		if jsonFormat(args[1:]) {
+			return d.printJSON(d.Breakpoints.Summaries())
+		}
 		return d.showBreakpoints()
 	case "watchpoints", "watch", "w":
+		if jsonFormat(args[1:]) {
+			return d.printJSON(d.Watchpoints.Summaries())
+		}
 		return d.showWatchpoints()
 	case "stack", "s":
 		return d.showStack()
+	case "literals", "lit":
+		return d.showLiteralPool()
+	case "memory", "mem", "m":
+		return d.showMemoryMap()
 	default:
 		return fmt.Errorf("unknown info command: %s", args[0])
 	}
 }
 
+// jsonFormat reports whether the arguments after an info topic ask for JSON output
+func jsonFormat(args []string) bool {
+	return len(args) == 1 && strings.EqualFold(args[0], "json")
+}
+
+// printJSON prints value as indented JSON for tools that drive the debugger
+func (d *Debugger) printJSON(value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	d.Println(string(data))
+	return nil
+}
+
 // showRegisters displays all register values
 func (d *Debugger) showRegisters() error {
 	d.Println("Registers:")
@@ -480,6 +740,9 @@ func (d *Debugger) showRegisters() error {
 	} else {
 		flags += "-"
 	}
+	if d.VM.CPU.CPSR.Q {
+		flags += "Q" // Sticky saturation flag, shown only when set
+	}
 	d.Printf("  CPSR = [%s]\n", flags)
 
 	return nil
@@ -510,8 +773,13 @@ func (d *Debugger) showBreakpoints() error {
 			condition = fmt.Sprintf(" if %s", bp.Condition)
 		}
 
-		d.Printf("  %d: 0x%08X %s%s%s (hit %d times)\n",
-			bp.ID, bp.Address, status, temp, condition, bp.HitCount)
+		ignore := ""
+		if bp.IgnoreCount > 0 {
+			ignore = fmt.Sprintf(" ignore %d", bp.IgnoreCount)
+		}
+
+		d.Printf("  %d: 0x%08X %s%s%s%s (hit %d times)\n",
+			bp.ID, bp.Address, status, temp, ignore, condition, bp.HitCount)
 	}
 
 	return nil
@@ -539,6 +807,16 @@ func (d *Debugger) showWatchpoints() error {
 			wpType = "access"
 		}
 
+		if wp.IsExpression {
+			wpType = "expression (checked every step)"
+		}
+
+		if wp.IsRange() {
+			d.Printf("  %d: %s %s %s (0x%08X-0x%08X, hit %d times)\n",
+				wp.ID, wp.Expression, wpType, status, wp.Address, wp.Address+wp.Length-1, wp.HitCount)
+			continue
+		}
+
 		d.Printf("  %d: %s %s %s (hit %d times, last value: 0x%08X)\n",
 			wp.ID, wp.Expression, wpType, status, wp.HitCount, wp.LastValue)
 	}
@@ -546,6 +824,44 @@ func (d *Debugger) showWatchpoints() error {
 	return nil
 }
 
+// showLiteralPool displays literal pool entries in address order
+func (d *Debugger) showLiteralPool() error {
+	if len(d.LiteralPool) == 0 {
+		d.Println("No literal pool entries")
+		return nil
+	}
+
+	addresses := make([]uint32, 0, len(d.LiteralPool))
+	for addr := range d.LiteralPool {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+
+	d.Println("Literal pool:")
+	for _, addr := range addresses {
+		value := d.LiteralPool[addr]
+		d.Printf("  0x%08X: 0x%08X (%d)\n", addr, value, vm.AsInt32(value))
+	}
+
+	return nil
+}
+
+// showMemoryMap lists the memory segments with their permissions and access counts
+func (d *Debugger) showMemoryMap() error {
+	d.Println("Memory map:")
+	d.Printf("  %-10s %-10s %-10s %10s %-4s %10s %10s %10s\n",
+		"Name", "Start", "End", "Size", "Perm", "Reads", "Writes", "Executes")
+	for _, seg := range d.VM.Memory.Segments {
+		end := seg.Start + seg.Size - 1
+		d.Printf("  %-10s 0x%08X 0x%08X %10d %-4s %10d %10d %10d\n",
+			seg.Name, seg.Start, end, seg.Size, seg.Permissions, seg.Reads, seg.Writes, seg.Executes)
+	}
+
+	used, blocks := d.VM.Memory.HeapInUse()
+	d.Printf("Heap: %d bytes in %d blocks\n", used, blocks)
+	return nil
+}
+
 // showStack displays stack contents
 func (d *Debugger) showStack() error {
 	sp := d.VM.CPU.GetSP()
@@ -570,12 +886,13 @@ func (d *Debugger) showStack() error {
 
 // cmdBacktrace shows the call stack
 func (d *Debugger) cmdBacktrace(args []string) error {
+	frames := d.Backtrace()
 	d.Println("Call stack:")
-	d.Printf("  #0  PC=0x%08X\n", d.VM.CPU.PC)
-
-	// Simple backtrace - would need call stack tracking for full implementation
-	if d.VM.CPU.GetLR() != 0xFFFFFFFF {
-		d.Printf("  #1  LR=0x%08X\n", d.VM.CPU.GetLR())
+	for _, f := range frames {
+		d.Printf("  #%-2d 0x%08X in %s\n", f.Number, f.Address, f.Function)
+	}
+	if len(frames) == BacktraceMaxFrames {
+		d.Println("  (more frames not shown)")
 	}
 
 	return nil
@@ -583,6 +900,9 @@ func (d *Debugger) cmdBacktrace(args []string) error {
 
 // cmdList shows source code around current PC
 func (d *Debugger) cmdList(args []string) error {
+	if len(args) > 0 {
+		return d.cmdDisassemble(args)
+	}
 	pc := d.VM.CPU.PC
 
 	// Show current instruction
@@ -603,62 +923,415 @@ func (d *Debugger) cmdList(args []string) error {
 	return nil
 }
 
-// cmdSet modifies register or memory values
-func (d *Debugger) cmdSet(args []string) error {
-	if len(args) < 3 {
-		return fmt.Errorf("usage: set <register|*address> = <value>")
+// cmdDisassemble lists decoded instructions from an address (default PC), marking the PC
+// with "=>" and breakpoints with "*"
+func (d *Debugger) cmdDisassemble(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: disas [address|label] [count]")
+	}
+	start := d.VM.CPU.PC
+	count := DisassemblyDefaultCount
+	if len(args) > 0 {
+		address, err := d.ResolveAddress(args[0])
+		if err != nil {
+			return err
+		}
+		start = address
+	}
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count: %s", args[1])
+		}
+		count = n
+	}
+
+	lines, err := d.DisassembleRange(start, count)
+	if err != nil {
+		return err
 	}
+	for _, line := range lines {
+		d.Println(d.formatDisassemblyLine(line))
+	}
+	return nil
+}
 
-	if args[1] != "=" {
-		return fmt.Errorf("usage: set <register|*address> = <value>")
+// cmdExplain describes in plain English what the instruction at an address (default PC)
+// does: the condition it tests, its operands and the flags it would change
+func (d *Debugger) cmdExplain(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: explain [address|label]")
+	}
+	address := d.VM.CPU.PC
+	if len(args) > 0 {
+		resolved, err := d.ResolveAddress(args[0])
+		if err != nil {
+			return err
+		}
+		address = resolved
+	}
+	opcode, err := d.VM.Memory.ReadInstruction(address)
+	if err != nil {
+		return fmt.Errorf("failed to read 0x%08X: %w", address, err)
+	}
+	if d.isDataWord(address) {
+		d.Printf("0x%08X: .word 0x%08X is data, not an instruction\n", address, opcode)
+		return nil
 	}
 
-	target := strings.ToLower(args[0])
-	valueStr := args[2]
+	d.Printf("0x%08X: %s", address, vm.ExplainInstruction(opcode, address, d.symbolLabels()))
+	return nil
+}
 
-	// Parse value
-	value, err := d.Evaluator.EvaluateExpression(valueStr, d.VM, d.Symbols)
+// formatDisassemblyLine renders a listing line, in colour when enabled
+func (d *Debugger) formatDisassemblyLine(line DisassemblyLine) string {
+	pcMark, bpMark, instruction := "  ", " ", line.Text
+	if line.Current {
+		pcMark = d.colorize(ANSIGreen, "=>")
+		instruction = d.colorize(ANSIBold, instruction)
+	}
+	if line.Breakpoint {
+		bpMark = d.colorize(ANSIRed, "*")
+	}
+	location := ""
+	if line.Location != "" {
+		location = "<" + line.Location + ">"
+	}
+	text := fmt.Sprintf("%s%s 0x%08X %-16s %08X  %s", pcMark, bpMark, line.Address, location, line.Opcode, instruction)
+	if line.Comment != "" {
+		text += d.colorize(ANSIDim, "  ; "+line.Comment)
+	}
+	return text
+}
+
+// colorize wraps text in an ANSI colour code when colour output is enabled
+func (d *Debugger) colorize(code, text string) string {
+	if !d.Color {
+		return text
+	}
+	return code + text + ANSIReset
+}
+
+// cmdDumpAsm writes a memory range as reassemblable source, to the console or a file
+func (d *Debugger) cmdDumpAsm(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("usage: dump-asm <start> <end> [file]")
+	}
+
+	start, err := d.ResolveAddress(args[0])
+	if err != nil {
+		return err
+	}
+	end, err := d.ResolveAddress(args[1])
 	if err != nil {
 		return err
 	}
 
-	// Check if memory dereference
-	if strings.HasPrefix(target, "*") {
-		addrStr := target[1:]
-		address, err := d.ResolveAddress(addrStr)
+	if len(args) == 3 {
+		file, err := os.Create(args[2]) // #nosec G304 -- user-specified output file
 		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", args[2], err)
+		}
+		if err := d.DumpAssembly(file, start, end); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
 			return err
 		}
+		d.Printf("Wrote disassembly of 0x%08X-0x%08X to %s\n", start, end, args[2])
+		return nil
+	}
 
-		if err := d.VM.Memory.WriteWord(address, value); err != nil {
+	var sb strings.Builder
+	if err := d.DumpAssembly(&sb, start, end); err != nil {
+		return err
+	}
+	d.Printf("%s", sb.String())
+	return nil
+}
+
+// setUsage describes the assignment syntax of the set command
+const setUsage = "usage: set <register|CPSR|CPSR.N/Z/C/V|[address]|byte [address]> = <expression>"
+
+// cmdSet assigns the value of an expression to a register, the CPSR or one of its flags,
+// or a memory word or byte
+func (d *Debugger) cmdSet(args []string) error {
+	target, valueStr, found := strings.Cut(strings.Join(args, " "), "=")
+	target, valueStr = strings.TrimSpace(target), strings.TrimSpace(valueStr)
+	if !found || target == "" || valueStr == "" || strings.HasPrefix(valueStr, "=") {
+		return fmt.Errorf("%s", setUsage)
+	}
+
+	// Parse value
+	value, err := d.Evaluator.EvaluateExpression(valueStr, d.VM, d.Symbols)
+	if err != nil {
+		return err
+	}
+
+	// Memory: [expr] or *expr, optionally preceded by byte or word
+	size, sized := 4, false
+	if sizeName, rest, ok := strings.Cut(target, " "); ok {
+		switch strings.ToLower(sizeName) {
+		case "byte":
+			size, sized = 1, true
+		case "word":
+			sized = true
+		}
+		if sized {
+			target = strings.TrimSpace(rest)
+		}
+	}
+	if addrStr, ok := memoryTarget(target); ok {
+		address, err := d.Evaluator.EvaluateValue(addrStr, d.VM, d.Symbols)
+		if err != nil {
 			return err
 		}
+		return d.setMemory(address, value, size)
+	}
+	if sized {
+		return fmt.Errorf("cannot assign to %s: a size needs a memory target such as [address]", target)
+	}
 
-		d.Printf("Memory 0x%08X set to 0x%08X\n", address, value)
+	lower := strings.ToLower(target)
+
+	// CPSR and its condition flags
+	if lower == "cpsr" {
+		d.VM.CPU.CPSR.FromUint32(value)
+		d.Printf("CPSR set to 0x%08X\n", d.VM.CPU.CPSR.ToUint32())
 		return nil
 	}
+	if flag, ok := strings.CutPrefix(lower, "cpsr."); ok {
+		return d.setFlag(flag, value)
+	}
 
 	// Parse register
 	register := -1
-	if target == "pc" || target == "r15" {
+	if lower == "pc" || lower == "r15" {
 		register = 15
-	} else if target == "sp" || target == "r13" {
+	} else if lower == "sp" || lower == "r13" {
 		register = 13
-	} else if target == "lr" || target == "r14" {
+	} else if lower == "lr" || lower == "r14" {
 		register = 14
-	} else if strings.HasPrefix(target, "r") {
-		_, err := fmt.Sscanf(target, "r%d", &register)
-		if err != nil || register < 0 || register > 14 {
+	} else if strings.HasPrefix(lower, "r") {
+		_, err := fmt.Sscanf(lower, "r%d", &register)
+		if err != nil || register < 0 || register > 14 || fmt.Sprintf("r%d", register) != lower {
 			return fmt.Errorf("invalid register: %s", target)
 		}
 	} else {
-		return fmt.Errorf("invalid target: %s", target)
+		return fmt.Errorf("cannot assign to %s: %s", target, setUsage)
+	}
+
+	// The PC must point at an instruction the CPU could fetch
+	if register == 15 {
+		if value%vm.ARMInstructionSize != 0 {
+			return fmt.Errorf("cannot set PC to 0x%08X: not word aligned", value)
+		}
+		if err := d.VM.Memory.CheckExecutePermission(value); err != nil {
+			return fmt.Errorf("cannot set PC to 0x%08X: %w", value, err)
+		}
 	}
 
 	// Set register value
 	d.VM.CPU.SetRegister(register, value)
-	d.Printf("Register %s set to 0x%08X\n", target, value)
+	d.Printf("Register %s set to 0x%08X\n", lower, value)
+
+	return nil
+}
+
+// memoryTarget returns the address expression of a [expr] or *expr assignment target
+func memoryTarget(target string) (string, bool) {
+	if strings.HasPrefix(target, "[") && strings.HasSuffix(target, "]") {
+		return target[1 : len(target)-1], true
+	}
+	if addr, ok := strings.CutPrefix(target, "*"); ok {
+		return addr, true
+	}
+	return "", false
+}
+
+// setMemory writes value to memory as a word or a byte
+func (d *Debugger) setMemory(address, value uint32, size int) error {
+	if size == 1 {
+		if value > 0xFF {
+			return fmt.Errorf("value 0x%X does not fit in a byte", value)
+		}
+		if err := d.VM.Memory.WriteByteAt(address, byte(value)); err != nil {
+			return err
+		}
+		d.Printf("Memory 0x%08X set to 0x%02X\n", address, value)
+		return nil
+	}
+
+	if err := d.VM.Memory.WriteWord(address, value); err != nil {
+		return err
+	}
+	d.Printf("Memory 0x%08X set to 0x%08X\n", address, value)
+	return nil
+}
+
+// maxSearchMatches limits how many matching addresses search lists
+const maxSearchMatches = 100
+
+// cmdFill writes a value repeatedly over a memory range, as bytes (the default),
+// halfwords or words
+func (d *Debugger) cmdFill(args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return fmt.Errorf("usage: fill <address> <length> <value> [byte|half|word]")
+	}
+	address, length, value, size, err := d.memoryPatternArgs(args)
+	if err != nil {
+		return err
+	}
 
+	for offset := uint32(0); offset < length; offset += size {
+		if err := d.writeUnit(address+offset, value, size); err != nil {
+			if offset > 0 {
+				return fmt.Errorf("fill stopped after %d bytes: %w", offset, err)
+			}
+			return err
+		}
+	}
+	d.Printf("Filled 0x%08X-0x%08X (%d bytes) with 0x%0*X\n", address, address+length-1, length, size*2, value)
+	return nil
+}
+
+// cmdSearch lists the addresses in a memory range holding a byte, halfword or word
+// value, stored in the memory's byte order and at any byte offset
+func (d *Debugger) cmdSearch(args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return fmt.Errorf("usage: search <address> <length> <value> [byte|half|word]")
+	}
+	address, length, value, size, err := d.memoryPatternArgs(args)
+	if err != nil {
+		return err
+	}
+
+	pattern := make([]byte, size)
+	for i := range pattern {
+		shift := uint32(i) * 8 // #nosec G115 -- i is below 4
+		if !d.VM.Memory.LittleEndian {
+			shift = (size - 1 - uint32(i)) * 8 // #nosec G115 -- i is below 4
+		}
+		pattern[i] = byte(value >> shift) // #nosec G115 -- byte extraction
+	}
+
+	// Scan a byte at a time through a window of the last size bytes, since the length is
+	// user-supplied and may be far larger than the mapped memory. Only the matches printed
+	// are kept.
+	var matches []uint32
+	count := 0
+	window := make([]byte, 0, size)
+	for offset := uint32(0); offset < length; offset++ {
+		b, err := d.VM.Memory.ReadByteAt(address + offset)
+		if err != nil {
+			return err
+		}
+		if len(window) == cap(window) {
+			window = append(window[:0], window[1:]...)
+		}
+		window = append(window, b)
+		if bytes.Equal(window, pattern) {
+			if count < maxSearchMatches {
+				matches = append(matches, address+offset+1-size)
+			}
+			count++
+		}
+	}
+
+	if count == 0 {
+		d.Printf("0x%0*X not found in 0x%08X-0x%08X\n", size*2, value, address, address+length-1)
+		return nil
+	}
+	d.Printf("Found 0x%0*X at %d address(es) in 0x%08X-0x%08X:\n", size*2, value, count, address, address+length-1)
+	symbols := vm.NewSymbolResolver(d.Symbols)
+	for _, match := range matches {
+		// Only name labels inside the searched range; the nearest one before it may be far away
+		name, offset, found := symbols.ResolveAddress(match)
+		switch {
+		case found && offset == 0:
+			d.Printf("  0x%08X <%s>\n", match, name)
+		case found && match-offset >= address:
+			d.Printf("  0x%08X <%s+%d>\n", match, name, offset)
+		default:
+			d.Printf("  0x%08X\n", match)
+		}
+	}
+	if count > len(matches) {
+		d.Printf("  ... and %d more\n", count-len(matches))
+	}
+	return nil
+}
+
+// memoryPatternArgs parses the address, length, value and optional unit size shared by
+// fill and search. The length is in bytes and must be a whole number of units.
+func (d *Debugger) memoryPatternArgs(args []string) (address, length, value, size uint32, err error) {
+	if address, err = d.resolveAddressExpression(args[0]); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if length, err = d.Evaluator.EvaluateValue(args[1], d.VM, d.Symbols); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if value, err = d.Evaluator.EvaluateValue(args[2], d.VM, d.Symbols); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	size = 1
+	if len(args) == 4 {
+		switch strings.ToLower(args[3]) {
+		case "b", "byte", "1":
+			size = 1
+		case "h", "half", "halfword", "2":
+			size = 2
+		case "w", "word", "4":
+			size = 4
+		default:
+			return 0, 0, 0, 0, fmt.Errorf("invalid size %q (use byte, half or word)", args[3])
+		}
+	}
+
+	switch {
+	case length == 0 || length%size != 0:
+		return 0, 0, 0, 0, fmt.Errorf("length %d must be a non-zero multiple of the %d-byte unit", length, size)
+	case uint64(address)+uint64(length) > math.MaxUint32+1:
+		return 0, 0, 0, 0, fmt.Errorf("range 0x%08X+%d runs past the end of memory", address, length)
+	case size < 4 && value>>(size*8) != 0:
+		return 0, 0, 0, 0, fmt.Errorf("value 0x%X does not fit in %d byte(s)", value, size)
+	}
+	return address, length, value, size, nil
+}
+
+// writeUnit writes a byte, halfword or word to memory
+func (d *Debugger) writeUnit(address, value, size uint32) error {
+	switch size {
+	case 1:
+		return d.VM.Memory.WriteByteAt(address, byte(value)) // #nosec G115 -- checked to fit by the caller
+	case 2:
+		return d.VM.Memory.WriteHalfword(address, uint16(value)) // #nosec G115 -- checked to fit by the caller
+	default:
+		return d.VM.Memory.WriteWord(address, value)
+	}
+}
+
+// setFlag sets one of the N, Z, C and V condition flags to 0 or 1
+func (d *Debugger) setFlag(flag string, value uint32) error {
+	if value > 1 {
+		return fmt.Errorf("flag value must be 0 or 1, got %d", value)
+	}
+	cpsr := &d.VM.CPU.CPSR
+	set := value == 1
+	switch flag {
+	case "n":
+		cpsr.N = set
+	case "z":
+		cpsr.Z = set
+	case "c":
+		cpsr.C = set
+	case "v":
+		cpsr.V = set
+	default:
+		return fmt.Errorf("invalid CPSR flag: %s (expected N, Z, C or V)", strings.ToUpper(flag))
+	}
+	d.Printf("CPSR.%s set to %d\n", strings.ToUpper(flag), value)
 	return nil
 }
 
@@ -694,7 +1367,10 @@ func (d *Debugger) cmdHelp(args []string) error {
 	d.Println("  continue (c)      - Continue execution")
 	d.Println("  step (s, si)      - Execute single instruction")
 	d.Println("  next (n)          - Step over function calls")
-	d.Println("  finish (fin)      - Step out of current function")
+	d.Println("  step-line (sl)    - Execute until the source line changes")
+	d.Println("  finish (fin)      - Run until the current function returns")
+	d.Println("  run-to (until) <addr> - Run until an address is reached (one-shot)")
+	d.Println("  call <f>(args)    - Call a function and print R0, then restore the registers")
 	d.Println()
 	d.Println("Breakpoints:")
 	d.Println("  break (b) <addr>  - Set breakpoint")
@@ -702,24 +1378,37 @@ func (d *Debugger) cmdHelp(args []string) error {
 	d.Println("  delete (d) [id]   - Delete breakpoint(s)")
 	d.Println("  enable <id>       - Enable breakpoint")
 	d.Println("  disable <id>      - Disable breakpoint")
+	d.Println("  save-breakpoints <file> - Save breakpoints and watchpoints as JSON")
+	d.Println("  load-breakpoints <file> - Restore saved breakpoints and watchpoints")
 	d.Println()
 	d.Println("Watchpoints:")
-	d.Println("  watch (w) <expr>  - Watch for writes")
+	d.Println("  watch (w) <expr>  - Watch for writes, or for changes in a computed expression")
+	d.Println("  watch [addr, len] - Watch for writes anywhere in a memory range (also rwatch/awatch)")
 	d.Println("  rwatch <expr>     - Watch for reads")
 	d.Println("  awatch <expr>     - Watch for access")
 	d.Println()
 	d.Println("Inspection:")
 	d.Println("  print (p) <expr>  - Evaluate expression")
+	d.Println("  print/FMT <expr>  - Print as x (hex), d, u, t (binary) or o (octal)")
+	d.Println("  print struct <name> at <addr> - Decode memory with a struct layout")
+	d.Println("  struct <name> <field:type>... - Define a struct layout")
 	d.Println("  x[/nfu] <addr>    - Examine memory")
 	d.Println("  info (i) <what>   - Show information")
 	d.Println("  backtrace (bt)    - Show call stack")
-	d.Println("  list (l)          - List source code")
+	d.Println("  list (l) [a] [n]  - List source code, or disassemble n instructions from a")
+	d.Println("  disas [a] [n]     - Disassemble n instructions from a (default PC)")
+	d.Println("  dump-asm <s> <e>  - Disassemble range as reassemblable source")
+	d.Println("  explain [addr]    - Describe what the instruction at addr (default PC) does")
+	d.Println("  search <a> <len> <val> [size] - List addresses in a range holding a byte/half/word value")
 	d.Println()
 	d.Println("Modification:")
 	d.Println("  set <var> = <val> - Modify register/memory")
+	d.Println("  fill <a> <len> <val> [size] - Write a byte/half/word value over a range")
 	d.Println()
 	d.Println("Control:")
 	d.Println("  reset             - Reset VM")
+	d.Println("  save-state <file> - Save registers, memory and open files as JSON")
+	d.Println("  load-state <file> - Restore a state saved with save-state")
 	d.Println("  help (h, ?)       - Show this help")
 	d.Println()
 	d.Println("TUI Only:")
@@ -735,12 +1424,25 @@ func (d *Debugger) cmdHelp(args []string) error {
 // showCommandHelp shows detailed help for a specific command
 func (d *Debugger) showCommandHelp(cmd string) error {
 	helpText := map[string]string{
-		"break": "break <address|label> [if <condition>]\n  Set a breakpoint at the specified address or label.\n  Optional condition will be evaluated each time.",
-		"step":  "step\n  Execute a single instruction.",
-		"next":  "next\n  Step over function calls (execute until next instruction at same level).",
-		"print": "print <expression>\n  Evaluate and print an expression.\n  Expressions can include registers, memory, symbols, and arithmetic.",
-		"x":     "x[/nfu] <address>\n  Examine memory.\n  n: count, f: format (x/d/u/o/t), u: unit (b/h/w)",
-		"info":  "info <registers|breakpoints|watchpoints|stack>\n  Display information about program state.",
+		"break":            "break <address|label|file:line> [ignore <count>] [if <condition>]\n  Set a breakpoint at the specified address, label or source line (e.g. prog.s:42).\n  Optional condition will be evaluated each time.\n  With ignore N, the first N hits are passed over and execution stops on hit N+1\n  (only hits where the condition is true are counted).",
+		"step":             "step\n  Execute a single instruction.",
+		"next":             "next\n  Step over function calls (execute until next instruction at same level).",
+		"call":             "call <address|label>[(arg, ...)]\n  Call a guest function: arguments go in R0-R3, then on the stack, and LR is set to a\n  return address that stops the call. Prints R0 once the function returns, then restores\n  the registers and flags. Breakpoints are not checked; memory writes are kept.\n  Gives up after 1000000 instructions. Example: call add(2, 3)",
+		"step-line":        "step-line\n  Execute instructions until the current source line changes.\n  Stops early at breakpoints, watchpoints or program exit.",
+		"print":            "print <expression>\n  Evaluate and print an expression.\n  Expressions can include registers, memory, symbols, and arithmetic.\n  Casts (int8) (uint8) (int16) (uint16) (int32) (uint32) and signed()/unsigned() set how the result is shown.\nprint/FMT <expression>\n  Print in one format: x (hex), d (signed), u (unsigned), t (binary) or o (octal).\nprint struct <name> at <address>\n  Decode memory at address with a layout defined by struct.",
+		"set":              "set <target> = <expression>\n  Assign the value of an expression. Targets: R0-R15, SP, LR, PC, CPSR, CPSR.N/Z/C/V\n  (0 or 1), and memory as [address] or *address (a word) or byte [address].\n  PC must be word aligned and point at executable memory. Example: set [R1+4] = R0 << 2",
+		"struct":           "struct [name [field:type[N][@offset]...]]\n  Define a struct layout for print struct, show one layout, or list them all.\n  Types: u8, i8, u16, i16, u32, i32, ptr, char (byte, half and word also work).\n  Fields follow each other without padding unless given an @offset.\n  Example: struct point x:i32 y:i32 name:char[8]",
+		"x":                "x[/nfu] <address>\n  Examine memory, e.g. x/4xw SP, x/2i PC, x/s msg.\n  n: count, f: format (x hex, d decimal, u unsigned, o octal, t binary, c char,\n  s string, i instruction), u: unit (b byte, h halfword, w word)",
+		"dump-asm":         "dump-asm <start> <end> [file]\n  Disassemble [start, end) into source the assembler can re-ingest.\n  Labels come from the symbol table; data and unsupported encodings become .word directives.",
+		"explain":          "explain [address|label]\n  Describe the instruction at address (default PC) in plain English: the condition\n  it tests, what it does with its operands and which flags it would change.",
+		"fill":             "fill <address> <length> <value> [byte|half|word]\n  Write value repeatedly over length bytes starting at address, as bytes (default),\n  halfwords or words. Writes go through the normal memory checks, so read-only\n  memory is rejected. Example: fill buffer 64 0xAA",
+		"search":           "search <address> <length> <value> [byte|half|word]\n  List the addresses in the length bytes from address that hold value, as a byte\n  (default), halfword or word in memory byte order. Example: search buffer 64 0xDEADBEEF word",
+		"info":             "info <registers|breakpoints|watchpoints|stack|literals|memory>\n  Display information about program state.\ninfo breakpoints json, info watchpoints json\n  List breakpoints or watchpoints as JSON for tools: id, address or expression, condition,\n  enabled state, hit count, and for watchpoints the kind, type and last value.",
+		"save-breakpoints": "save-breakpoints <file>\n  Write breakpoints and watchpoints, with their conditions and enabled state, to a JSON file.\n  Breakpoints are recorded by label (plus offset) where possible.",
+		"load-breakpoints": "load-breakpoints <file>\n  Restore breakpoints and watchpoints saved with save-breakpoints, re-resolving labels\n  against the loaded program. Entries that no longer resolve are skipped with a warning.",
+		"save-state":       "save-state <file>\n  Write the whole machine state to a JSON file: registers, CPSR and SPSR, memory, heap\n  allocations, the timer and open files. Use it at a breakpoint to reproduce a bug later.",
+		"load-state":       "load-state <file>\n  Restore a state written by save-state. Open files are reopened inside the filesystem\n  root; if anything cannot be restored the VM is left unchanged.",
+		"backtrace":        "backtrace\n  Show the call chain reconstructed from LR and return addresses saved on the stack.",
 	}
 
 	if help, exists := helpText[cmd]; exists {
@@ -32,6 +32,9 @@ const (
 
 	// MemoryDisplayBytesPerRow is the number of bytes displayed per row (same as columns)
 	MemoryDisplayBytesPerRow = 16
+
+	// WatchViewMaxRows is the tallest the Watch panel grows, including its border
+	WatchViewMaxRows = 10
 )
 
 // Stack Display Constants
@@ -44,6 +47,12 @@ const (
 
 	// StackInspectionMaxOffset is the maximum byte offset when inspecting stack in debugger commands
 	StackInspectionMaxOffset = 16
+
+	// BacktraceMaxStackWords is how many words above SP the backtrace searches for return addresses
+	BacktraceMaxStackWords = 4096
+
+	// BacktraceMaxFrames limits the depth of a backtrace (deep recursion is truncated)
+	BacktraceMaxFrames = 64
 )
 
 // Register Display Constants
@@ -55,3 +64,49 @@ const (
 	// RegisterGroupSize is the number of registers displayed per row
 	RegisterGroupSize = 5
 )
+
+// Examine Command Constants
+const (
+	// ExamineWordsPerLine, ExamineHalfwordsPerLine and ExamineBytesPerLine set how many
+	// values x prints on each line for each unit size
+	ExamineWordsPerLine     = 4
+	ExamineHalfwordsPerLine = 8
+	ExamineBytesPerLine     = 8
+
+	// ExamineMaxStringLength is the longest string x/s prints before truncating it
+	ExamineMaxStringLength = 200
+
+	// StructMaxArrayLength is the most elements a struct field array may declare
+	StructMaxArrayLength = 1024
+
+	// StructPointerLabelRange is how far past a symbol a ptr field may point and still be
+	// shown with that symbol's name
+	StructPointerLabelRange = 256
+)
+
+// Disassembly Listing Constants
+const (
+	// DisassemblyDefaultCount is the number of instructions disas lists without a count
+	DisassemblyDefaultCount = 10
+
+	// ANSI escape sequences used by the CLI when colour is enabled
+	ANSIReset = "\033[0m"
+	ANSIBold  = "\033[1m"
+	ANSIDim   = "\033[2m"
+	ANSIRed   = "\033[31m"
+	ANSIGreen = "\033[32m"
+)
+
+// Inferior Call Constants
+const (
+	// CallReturnAddress is placed in LR by call; the function has returned when PC reaches
+	// it. Nothing is mapped there, so it cannot be a real return address.
+	CallReturnAddress = 0xFFFFFFFC
+
+	// CallMaxInstructions is how many instructions a called function may run before call
+	// gives up on it returning
+	CallMaxInstructions = 1_000_000
+
+	// CallRegisterArgs is how many arguments are passed in R0-R3; the rest go on the stack
+	CallRegisterArgs = 4
+)
@@ -1,7 +1,10 @@
 package debugger
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -27,8 +30,14 @@ type Debugger struct {
 	// Execution control
 	Running           bool
 	StepMode          StepMode
-	StepOverCallDepth int    // Track call depth for step over
-	StepOverPC        uint32 // PC to return to after step over
+	StepOverCallDepth int            // Track call depth for step over
+	StepOverPC        uint32         // PC to return to after step over
+	StepLineStart     uint32         // Address of the source line being stepped by step-line
+	StepLineLocation  SourceLocation // File and line being stepped by step-line, if known
+	StepOutPC         uint32         // Return address that finish runs to
+	StepOutSP         uint32         // SP when finish started; the return must be at this depth or shallower
+	RunToPC           uint32         // Target address of run-to
+	runToStart        uint64         // Instruction count when run-to started, so the starting PC is skipped
 
 	// Symbol table (for label/symbol resolution)
 	Symbols map[string]uint32
@@ -36,14 +45,30 @@ type Debugger struct {
 	// Source code mapping (address -> source line)
 	SourceMap map[uint32]string
 
+	// Source line index (file -> line number -> lowest address) for file:line locations
+	LineIndex map[string]map[int]uint32
+
+	// Source file and line of each instruction (address -> location), used by step-line
+	AddressLines map[uint32]SourceLocation
+
+	// Literal pool entries emitted by the assembler (address -> value)
+	LiteralPool map[uint32]uint32
+
+	// Struct layouts declared with the struct command, by name
+	Structs map[string]*StructLayout
+
 	// Last command (for repeat on empty input)
 	LastCommand string
 
 	// Output buffer
 	Output strings.Builder
 
+	// Color enables ANSI colour in disassembly listings (set by the CLI for terminals)
+	Color bool
+
 	// Mutex for thread-safe access to execution state
-	// Protects: Running, StepMode, StepOverCallDepth, StepOverPC, and VM state during execution
+	// Protects: Running, StepMode, StepOverCallDepth, StepOverPC, StepLineStart, StepLineLocation,
+	// StepOutPC, StepOutSP, RunToPC and VM state during execution
 	mu sync.Mutex
 }
 
@@ -55,6 +80,8 @@ const (
 	StepSingle                 // Step one instruction
 	StepOver                   // Step over function calls
 	StepOut                    // Step out of current function
+	StepLine                   // Step until the source line changes
+	StepRunTo                  // Run until a target address (run-to)
 )
 
 // NewDebugger creates a new debugger instance
@@ -69,6 +96,9 @@ func NewDebugger(machine *vm.VM) *Debugger {
 		StepMode:    StepNone,
 		Symbols:     make(map[string]uint32),
 		SourceMap:   make(map[uint32]string),
+		LineIndex:   make(map[string]map[int]uint32),
+		LiteralPool: make(map[uint32]uint32),
+		Structs:     make(map[string]*StructLayout),
 	}
 }
 
@@ -110,13 +140,43 @@ func (d *Debugger) LoadSourceMap(sourceMap map[uint32]string) {
 	d.SourceMap = sourceMap
 }
 
-// ResolveAddress resolves a label to an address, or parses a numeric address
+// LoadLineIndex loads the source line to address index used by file:line locations
+func (d *Debugger) LoadLineIndex(index map[string]map[int]uint32) {
+	d.LineIndex = index
+}
+
+// SourceLocation is the source file and line an instruction was assembled from
+type SourceLocation struct {
+	File string
+	Line int
+}
+
+// LoadAddressLines loads the source file and line of each instruction address
+func (d *Debugger) LoadAddressLines(lines map[uint32]SourceLocation) {
+	d.AddressLines = lines
+}
+
+// LoadLiteralPool loads the literal pool entries for display
+func (d *Debugger) LoadLiteralPool(literals map[uint32]uint32) {
+	d.LiteralPool = literals
+}
+
+// ResolveAddress resolves a label or file:line location to an address, or parses a numeric address
 func (d *Debugger) ResolveAddress(addrStr string) (uint32, error) {
 	// Try to resolve as symbol first
 	if addr, exists := d.Symbols[addrStr]; exists {
 		return addr, nil
 	}
 
+	// Source location (file.s:42)
+	if idx := strings.LastIndex(addrStr, ":"); idx > 0 {
+		line, err := strconv.Atoi(addrStr[idx+1:])
+		if err != nil || line <= 0 {
+			return 0, fmt.Errorf("invalid source location: %s (expected file:line)", addrStr)
+		}
+		return d.resolveSourceLine(addrStr[:idx], line)
+	}
+
 	// Try to parse as numeric address
 	var addr uint32
 	if strings.HasPrefix(addrStr, "0x") || strings.HasPrefix(addrStr, "0X") {
@@ -134,6 +194,29 @@ func (d *Debugger) ResolveAddress(addrStr string) (uint32, error) {
 	return addr, nil
 }
 
+// resolveSourceLine returns the lowest address generated by a source line.
+// The file may be given with or without a directory; only the base name has to match.
+func (d *Debugger) resolveSourceLine(file string, line int) (uint32, error) {
+	lines, exists := d.LineIndex[file]
+	if !exists {
+		for name, candidate := range d.LineIndex {
+			if filepath.Base(name) == filepath.Base(file) {
+				lines, exists = candidate, true
+				break
+			}
+		}
+	}
+	if !exists {
+		return 0, fmt.Errorf("no source file %s in program", file)
+	}
+
+	addr, exists := lines[line]
+	if !exists {
+		return 0, fmt.Errorf("no code at %s:%d", file, line)
+	}
+	return addr, nil
+}
+
 // ExecuteCommand processes and executes a debugger command
 func (d *Debugger) ExecuteCommand(cmdLine string) error {
 	// Trim whitespace
@@ -159,6 +242,18 @@ func (d *Debugger) ExecuteCommand(cmdLine string) error {
 	cmd := strings.ToLower(parts[0])
 	args := parts[1:]
 
+	// gdb writes the format straight after the command, as in x/4xw and print/x
+	if spec, found := strings.CutPrefix(cmd, "x/"); found {
+		cmd = "x"
+		args = append([]string{"/" + spec}, args...)
+	}
+	for _, name := range []string{"print", "p"} {
+		if spec, found := strings.CutPrefix(cmd, name+"/"); found {
+			cmd = name
+			args = append([]string{"/" + spec}, args...)
+		}
+	}
+
 	// Execute command
 	return d.handleCommand(cmd, args)
 }
@@ -177,6 +272,12 @@ func (d *Debugger) handleCommand(cmd string, args []string) error {
 		return d.cmdNext(args)
 	case "finish", "fin":
 		return d.cmdFinish(args)
+	case "step-line", "sl":
+		return d.cmdStepLine(args)
+	case "run-to", "until":
+		return d.cmdRunTo(args)
+	case "call":
+		return d.cmdCall(args)
 
 	// Breakpoints
 	case "break", "b":
@@ -189,6 +290,14 @@ func (d *Debugger) handleCommand(cmd string, args []string) error {
 		return d.cmdEnable(args)
 	case "disable":
 		return d.cmdDisable(args)
+	case "save-breakpoints":
+		return d.cmdSaveBreakpoints(args)
+	case "load-breakpoints":
+		return d.cmdLoadBreakpoints(args)
+	case "save-state":
+		return d.cmdSaveState(args)
+	case "load-state":
+		return d.cmdLoadState(args)
 
 	// Watchpoints
 	case "watch", "w":
@@ -203,16 +312,28 @@ func (d *Debugger) handleCommand(cmd string, args []string) error {
 		return d.cmdPrint(args)
 	case "x":
 		return d.cmdExamine(args)
+	case "struct", "typedef":
+		return d.cmdStruct(args)
 	case "info", "i":
 		return d.cmdInfo(args)
 	case "backtrace", "bt", "where":
 		return d.cmdBacktrace(args)
 	case "list", "l":
 		return d.cmdList(args)
+	case "disas", "disassemble":
+		return d.cmdDisassemble(args)
+	case "dump-asm":
+		return d.cmdDumpAsm(args)
+	case "explain":
+		return d.cmdExplain(args)
+	case "search":
+		return d.cmdSearch(args)
 
 	// State modification
 	case "set":
 		return d.cmdSet(args)
+	case "fill":
+		return d.cmdFill(args)
 
 	// Program control
 	case "load":
@@ -249,13 +370,45 @@ func (d *Debugger) ShouldBreak() (bool, string) {
 			return true, "step over complete"
 		}
 
+	case StepLine:
+		// Keep going while still on the starting line. The starting address itself is
+		// skipped so a breakpoint there does not immediately re-trigger.
+		if pc == d.StepLineStart {
+			d.mu.Unlock()
+			return false, ""
+		}
+		// Addresses without a source location (unmapped code) remain part of the current
+		// line. One from a different file or line is a new line, including one reached by a
+		// branch; instructions sharing the starting line, such as a .rept body, do not stop.
+		// Without locations, any address in the source map starts a new line.
+		newLine := false
+		if len(d.AddressLines) > 0 {
+			location, exists := d.AddressLines[pc]
+			newLine = exists && location != d.StepLineLocation
+		} else {
+			_, newLine = d.SourceMap[pc]
+		}
+		if newLine {
+			d.StepMode = StepNone
+			d.mu.Unlock()
+			return true, "step line complete"
+		}
+
 	case StepOut:
-		// NOTE: StepOut is not fully implemented. Proper step-out would require:
-		// 1. Recording the current LR value when step-out is initiated
-		// 2. Running until PC equals the recorded LR value
-		// OR maintaining a call stack by monitoring BL/BX instructions.
-		// Currently, this falls through and execution continues until a breakpoint is hit.
-		// Users should set a breakpoint at the expected return location as a workaround.
+		// A recursive call returning to the same address does so with a deeper (lower) SP
+		if pc == d.StepOutPC && d.VM.CPU.GetSP() >= d.StepOutSP {
+			d.StepMode = StepNone
+			d.mu.Unlock()
+			return true, "finish complete"
+		}
+
+	case StepRunTo:
+		// Leaving the starting PC first means run-to the current address goes round a loop once
+		if pc == d.RunToPC && d.VM.CPU.Instructions != d.runToStart {
+			d.StepMode = StepNone
+			d.mu.Unlock()
+			return true, "run-to complete"
+		}
 	}
 	d.mu.Unlock()
 
@@ -276,21 +429,43 @@ func (d *Debugger) ShouldBreak() (bool, string) {
 			}
 		}
 
-		// Process hit atomically (increments count and handles temporary deletion)
-		hitBp := d.Breakpoints.ProcessHit(pc)
-		if hitBp != nil {
+		// Process hit atomically (increments count, applies the ignore count and handles temporary deletion)
+		if hitBp, stop := d.Breakpoints.ProcessHit(pc); stop {
 			return true, fmt.Sprintf("breakpoint %d", hitBp.ID)
 		}
 	}
 
 	// Check watchpoints
 	if wp, changed := d.Watchpoints.CheckWatchpoints(d.VM); wp != nil && changed {
+		if wp.IsExpression {
+			return true, fmt.Sprintf("expression watchpoint %d: %s changed 0x%08X -> 0x%08X",
+				wp.ID, wp.Expression, wp.PreviousValue, wp.LastValue)
+		}
+		if wp.IsRange() {
+			access := "read"
+			if wp.LastAccessWrite {
+				access = "write"
+			}
+			return true, fmt.Sprintf("watchpoint %d: %s %s at 0x%08X (%d bytes)",
+				wp.ID, wp.Expression, access, wp.LastAccess, wp.LastAccessSize)
+		}
 		return true, fmt.Sprintf("watchpoint %d: %s", wp.ID, wp.Expression)
 	}
 
 	return false, ""
 }
 
+// UndefinedStop reports whether err is an undefined instruction and, if so, describes the
+// opcode and PC it stopped at and why the opcode is undefined. Runs stop on it as on a
+// breakpoint rather than reporting a generic runtime error.
+func UndefinedStop(err error) (string, bool) {
+	var undef *vm.UndefinedInstructionError
+	if !errors.As(err, &undef) {
+		return "", false
+	}
+	return fmt.Sprintf("undefined instruction 0x%08X at PC=0x%08X\n  %s", undef.Opcode, undef.FaultPC(), undef.Explain()), true
+}
+
 // GetOutput returns and clears the output buffer
 func (d *Debugger) GetOutput() string {
 	output := d.Output.String()
@@ -314,7 +489,7 @@ func (d *Debugger) SetStepOver() {
 	defer d.mu.Unlock()
 
 	// Read instruction at current PC
-	instr, err := d.VM.Memory.ReadWord(d.VM.CPU.PC)
+	instr, err := d.VM.Memory.ReadInstruction(d.VM.CPU.PC)
 	if err != nil {
 		// If we can't read the instruction, fall back to single step
 		d.StepMode = StepSingle
@@ -339,9 +514,50 @@ func (d *Debugger) SetStepOver() {
 }
 
 // SetStepOut configures the debugger to step out of the current function (thread-safe)
-func (d *Debugger) SetStepOut() {
+func (d *Debugger) SetStepOut() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	_, err := d.startStepOut()
+	return err
+}
+
+// SetRunTo configures the debugger to run until address is reached (thread-safe)
+func (d *Debugger) SetRunTo(address uint32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.startRunTo(address)
+}
+
+// CancelRunTo drops a run-to target that was not reached, e.g. because the program
+// exited or stopped elsewhere first (thread-safe)
+func (d *Debugger) CancelRunTo() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.StepMode == StepRunTo {
+		d.StepMode = StepNone
+	}
+}
+
+// startRunTo sets the one-shot target for run-to. Unlike tbreak it does not touch the
+// breakpoint list, so a user breakpoint at the same address is left as it was.
+func (d *Debugger) startRunTo(address uint32) {
+	d.RunToPC = address
+	d.runToStart = d.VM.CPU.Instructions
+	d.StepMode = StepRunTo
+	d.Running = true
+}
+
+// startStepOut sets the return target for finish from the caller frame of the backtrace,
+// which is LR in a leaf function or the return address saved on the stack otherwise.
+// It returns the current frame.
+func (d *Debugger) startStepOut() (StackFrame, error) {
+	frames := d.Backtrace()
+	if len(frames) < 2 {
+		return frames[0], fmt.Errorf("\"finish\" not meaningful in the outermost frame")
+	}
+	d.StepOutPC = frames[1].Address
+	d.StepOutSP = d.VM.CPU.GetSP()
 	d.StepMode = StepOut
 	d.Running = true
+	return frames[0], nil
 }
@@ -0,0 +1,177 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// DumpAssembly writes the memory range [start, end) as assembler source that the parser can
+// re-ingest: instructions are disassembled with labels from the symbol table, and words that
+// are data (or cannot be expressed as an instruction) are emitted as .word directives.
+func (d *Debugger) DumpAssembly(w io.Writer, start, end uint32) error {
+	start &^= vm.AlignMaskWord
+	if end <= start {
+		return fmt.Errorf("invalid range: end 0x%08X must be after start 0x%08X", end, start)
+	}
+
+	// Labels inside the range, so every name referenced in the output is also defined by it
+	labels := make(map[uint32][]string)
+	for name, addr := range d.Symbols {
+		if addr >= start && addr < end {
+			labels[addr] = append(labels[addr], name)
+		}
+	}
+	branchLabels := make(map[uint32]string, len(labels))
+	for addr, names := range labels {
+		sort.Strings(names)
+		branchLabels[addr] = names[0]
+	}
+
+	symbols := d.symbolLabels()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "; Disassembly of 0x%08X-0x%08X\n", start, end)
+	fmt.Fprintf(&sb, "\t.org 0x%X\n", start)
+
+	for addr := start; addr < end && addr >= start; addr += 4 {
+		word, err := d.VM.Memory.ReadWord(addr)
+		if err != nil {
+			return fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+		}
+
+		for _, name := range labels[addr] {
+			fmt.Fprintf(&sb, "%s:\n", name)
+		}
+
+		if d.isDataWord(addr) {
+			fmt.Fprintf(&sb, "\t.word 0x%08X\n", word)
+			continue
+		}
+
+		// Instructions are little-endian even when data is not; .word keeps the data byte order
+		opcode, err := d.VM.Memory.ReadInstruction(addr)
+		if err != nil {
+			return fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+		}
+		text, ok := vm.Disassemble(opcode, addr, branchLabels)
+		if !ok {
+			fmt.Fprintf(&sb, "\t.word 0x%08X\t; %s\n", word, text)
+			continue
+		}
+		if comment, isLiteral := vm.LiteralComment(opcode, addr, d.VM.Memory, symbols); isLiteral {
+			fmt.Fprintf(&sb, "\t%s\t; %s\n", text, comment)
+			continue
+		}
+		fmt.Fprintf(&sb, "\t%s\n", text)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// isDataWord reports whether the word at addr holds data rather than code. The source map
+// marks code and [DATA] directives when available; otherwise executed addresses recorded by
+// code coverage are treated as code. Literal pool entries are always data.
+func (d *Debugger) isDataWord(addr uint32) bool {
+	if _, exists := d.LiteralPool[addr]; exists {
+		return true
+	}
+	if len(d.SourceMap) > 0 {
+		line, exists := d.SourceMap[addr]
+		return !exists || strings.HasPrefix(line, "[DATA]")
+	}
+	if cov := d.VM.CodeCoverage; cov != nil && len(cov.GetExecutedAddresses()) > 0 {
+		return cov.GetEntry(addr) == nil
+	}
+	return false
+}
+
+// symbolLabels maps each symbol address to its name, the alphabetically first if several
+// share an address
+func (d *Debugger) symbolLabels() map[uint32]string {
+	labels := make(map[uint32]string, len(d.Symbols))
+	for name, addr := range d.Symbols {
+		if existing, ok := labels[addr]; !ok || name < existing {
+			labels[addr] = name
+		}
+	}
+	return labels
+}
+
+// DisassemblyLine is one decoded word of a disassembly listing
+type DisassemblyLine struct {
+	Address    uint32
+	Opcode     uint32
+	Location   string // Nearest label and offset, e.g. "loop+4" (empty without symbols)
+	Text       string // Mnemonic and operands, with branch targets named by label
+	Comment    string // Branch target or literal value annotation
+	Current    bool   // The PC is at this address
+	Breakpoint bool   // A breakpoint is set at this address
+}
+
+// DisassembleRange decodes count words from start with the shared disassembler, naming
+// branch targets and locations from the symbol table. Reading stops at unmapped memory.
+func (d *Debugger) DisassembleRange(start uint32, count int) ([]DisassemblyLine, error) {
+	start &^= vm.AlignMaskWord
+	symbols := vm.NewSymbolResolver(d.Symbols)
+	labels := d.symbolLabels()
+
+	var lines []DisassemblyLine
+	for i, addr := 0, start; i < count && addr >= start; i, addr = i+1, addr+4 {
+		opcode, err := d.VM.Memory.ReadInstruction(addr)
+		if err != nil {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+			}
+			break
+		}
+
+		line := DisassemblyLine{
+			Address:    addr,
+			Opcode:     opcode,
+			Current:    addr == d.VM.CPU.PC,
+			Breakpoint: d.Breakpoints.GetBreakpoint(addr) != nil,
+		}
+		if symbols.HasSymbols() {
+			if _, _, found := symbols.ResolveAddress(addr); found {
+				line.Location = symbols.FormatAddressCompact(addr)
+			}
+		}
+
+		switch {
+		case d.isDataWord(addr):
+			word, _ := d.VM.Memory.ReadWord(addr)
+			line.Text = fmt.Sprintf(".word 0x%08X", word)
+		default:
+			line.Text, _ = vm.Disassemble(opcode, addr, labels)
+			if comment, isLiteral := vm.LiteralComment(opcode, addr, d.VM.Memory, labels); isLiteral {
+				line.Comment = comment
+			} else if target, isBranch := branchTarget(opcode, addr); isBranch {
+				if _, named := labels[target]; !named {
+					if _, _, found := symbols.ResolveAddress(target); found {
+						line.Comment = symbols.FormatAddressCompact(target)
+					}
+				}
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// branchTarget returns the destination of a B or BL instruction
+func branchTarget(opcode, addr uint32) (uint32, bool) {
+	// cccc 101L oooo oooo oooo oooo oooo oooo
+	if opcode&0x0E000000 != 0x0A000000 {
+		return 0, false
+	}
+	offset := opcode & vm.Offset24BitMask
+	if offset&vm.Offset24BitSignBit != 0 {
+		offset |= vm.Offset24BitSignExt
+	}
+	return addr + vm.PCBranchBase + offset<<2, true
+}
@@ -15,6 +15,36 @@ type ExprParser struct {
 	vm      *vm.VM
 	symbols map[string]uint32
 	eval    *ExpressionEvaluator
+	typ     exprType // Set by the last cast or signed()/unsigned() evaluated
+}
+
+// exprType is how print shows a result: its width in bits and whether it is signed. The
+// zero value is an untyped 32-bit word.
+type exprType struct {
+	bits   int
+	signed bool
+}
+
+// exprCastTypes are the C-style casts accepted in expressions, as in (int8)R0
+var exprCastTypes = map[string]exprType{
+	"int8":   {bits: 8, signed: true},
+	"uint8":  {bits: 8},
+	"int16":  {bits: 16, signed: true},
+	"uint16": {bits: 16},
+	"int32":  {bits: 32, signed: true},
+	"uint32": {bits: 32},
+}
+
+// convert truncates value to the type's width, sign-extending it when the type is signed
+func (t exprType) convert(value uint32) uint32 {
+	if t.bits == 0 || t.bits >= 32 {
+		return value
+	}
+	shift := 32 - t.bits
+	if t.signed {
+		return uint32(vm.AsInt32(value<<shift) >> shift) // #nosec G115 -- two's complement reinterpretation
+	}
+	return value << shift >> shift
 }
 
 // NewExprParser creates a new expression parser
@@ -127,6 +157,9 @@ func (p *ExprParser) parsePrimary() (uint32, error) {
 
 	case ExprTokenSymbol:
 		p.advance()
+		if p.currentToken().Type == ExprTokenLParen {
+			return p.parseFunction(tok.Value)
+		}
 		if addr, exists := p.symbols[tok.Value]; exists {
 			return addr, nil
 		}
@@ -143,6 +176,16 @@ func (p *ExprParser) parsePrimary() (uint32, error) {
 		return p.eval.GetValue(num)
 
 	case ExprTokenLParen:
+		if typ, ok := p.castAhead(); ok {
+			p.pos += 3 // consume ( type )
+			value, err := p.parsePrimary()
+			if err != nil {
+				return 0, err
+			}
+			p.typ = typ
+			return typ.convert(value), nil
+		}
+
 		// Parenthesized expression
 		p.advance() // consume (
 		result, err := p.parseExpression(0)
@@ -210,6 +253,54 @@ func (p *ExprParser) parsePrimary() (uint32, error) {
 	}
 }
 
+// castAhead reports whether the tokens at the current position are a cast such as
+// (int8) followed by its operand, so that a symbol in parentheses still reads as one
+func (p *ExprParser) castAhead() (exprType, bool) {
+	if p.pos+3 >= len(p.tokens) {
+		return exprType{}, false
+	}
+	name, closing, operand := p.tokens[p.pos+1], p.tokens[p.pos+2], p.tokens[p.pos+3]
+	typ, ok := exprCastTypes[strings.ToLower(name.Value)]
+	if name.Type != ExprTokenSymbol || !ok || closing.Type != ExprTokenRParen {
+		return exprType{}, false
+	}
+	switch operand.Type {
+	case ExprTokenEOF, ExprTokenRParen, ExprTokenRBracket:
+		return exprType{}, false
+	case ExprTokenOperator:
+		return typ, operand.Value == "*"
+	}
+	return typ, true
+}
+
+// parseFunction parses a call of one of the display functions, with the current token
+// at its opening parenthesis: signed(expr) and unsigned(expr) leave the value alone but
+// make print show it as a signed or unsigned 32-bit number
+func (p *ExprParser) parseFunction(name string) (uint32, error) {
+	var typ exprType
+	switch strings.ToLower(name) {
+	case "signed":
+		typ = exprType{bits: 32, signed: true}
+	case "unsigned":
+		typ = exprType{bits: 32}
+	default:
+		return 0, fmt.Errorf("unknown function: %s", name)
+	}
+
+	p.advance() // consume (
+	value, err := p.parseExpression(0)
+	if err != nil {
+		return 0, err
+	}
+	if p.currentToken().Type != ExprTokenRParen {
+		return 0, fmt.Errorf("expected ')' after %s argument, got %s", name, p.currentToken().Value)
+	}
+	p.advance() // consume )
+
+	p.typ = typ
+	return value, nil
+}
+
 // parseNumberValue parses a number string to uint32
 func (p *ExprParser) parseNumberValue(s string) (uint32, error) {
 	s = strings.TrimSpace(s)
@@ -28,9 +28,16 @@ func NewExpressionEvaluator() *ExpressionEvaluator {
 
 // EvaluateExpression evaluates an expression and returns the result
 func (e *ExpressionEvaluator) EvaluateExpression(expr string, machine *vm.VM, symbols map[string]uint32) (uint32, error) {
-	result, err := e.evaluate(expr, machine, symbols)
+	result, _, err := e.evaluateExpressionTyped(expr, machine, symbols)
+	return result, err
+}
+
+// evaluateExpressionTyped is EvaluateExpression that also returns the type set by any cast or
+// signed()/unsigned() call, for print to format the result with
+func (e *ExpressionEvaluator) evaluateExpressionTyped(expr string, machine *vm.VM, symbols map[string]uint32) (uint32, exprType, error) {
+	result, typ, err := e.evaluateWithType(expr, machine, symbols)
 	if err != nil {
-		return 0, err
+		return 0, exprType{}, err
 	}
 
 	// Store in history
@@ -45,7 +52,7 @@ func (e *ExpressionEvaluator) EvaluateExpression(expr string, machine *vm.VM, sy
 
 	e.valueNumber = len(e.valueHistory)
 
-	return result, nil
+	return result, typ, nil
 }
 
 // Evaluate evaluates an expression and returns a boolean result (for conditions).
@@ -61,6 +68,12 @@ func (e *ExpressionEvaluator) Evaluate(expr string, machine *vm.VM, symbols map[
 	return result != 0, nil
 }
 
+// EvaluateValue evaluates an expression to a number without storing it in value
+// history, for expression watchpoints that are re-evaluated every step
+func (e *ExpressionEvaluator) EvaluateValue(expr string, machine *vm.VM, symbols map[string]uint32) (uint32, error) {
+	return e.evaluate(expr, machine, symbols)
+}
+
 // GetValueNumber returns the current value number
 func (e *ExpressionEvaluator) GetValueNumber() int {
 	return e.valueNumber
@@ -77,11 +90,17 @@ func (e *ExpressionEvaluator) GetValue(number int) (uint32, error) {
 
 // evaluate is the main evaluation logic
 func (e *ExpressionEvaluator) evaluate(expr string, machine *vm.VM, symbols map[string]uint32) (uint32, error) {
+	result, _, err := e.evaluateWithType(expr, machine, symbols)
+	return result, err
+}
+
+// evaluateWithType evaluates expr and returns the type its display casts gave it
+func (e *ExpressionEvaluator) evaluateWithType(expr string, machine *vm.VM, symbols map[string]uint32) (uint32, exprType, error) {
 	expr = strings.TrimSpace(expr)
 
 	// Handle empty expression
 	if expr == "" {
-		return 0, fmt.Errorf("empty expression")
+		return 0, exprType{}, fmt.Errorf("empty expression")
 	}
 
 	// Use the new tokenizer and parser
@@ -90,16 +109,16 @@ func (e *ExpressionEvaluator) evaluate(expr string, machine *vm.VM, symbols map[
 
 	// Check for lexer errors (empty token list or only EOF)
 	if len(tokens) == 0 || (len(tokens) == 1 && tokens[0].Type == ExprTokenEOF) {
-		return 0, fmt.Errorf("invalid expression: %s", expr)
+		return 0, exprType{}, fmt.Errorf("invalid expression: %s", expr)
 	}
 
 	parser := NewExprParser(tokens, machine, symbols, e)
 	result, err := parser.Parse()
 	if err != nil {
-		return 0, fmt.Errorf("failed to evaluate expression: %w", err)
+		return 0, exprType{}, fmt.Errorf("failed to evaluate expression: %w", err)
 	}
 
-	return result, nil
+	return result, parser.typ, nil
 }
 
 // Reset clears the value history
@@ -0,0 +1,588 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// GDB remote serial protocol constants
+const (
+	// gdbRegisterCount is the number of registers in gdb's default ARM 'g' packet:
+	// r0-r15, f0-f7 (FPA, 12 bytes each), fps, cpsr
+	gdbRegisterCount = 26
+
+	// gdbFPARegisterFirst and gdbFPARegisterLast bound the FPA registers (not emulated)
+	gdbFPARegisterFirst = 16
+	gdbFPARegisterLast  = 23
+
+	// gdbFPSRegister is the FPA status register (not emulated)
+	gdbFPSRegister = 24
+
+	// gdbCPSRRegister is the register number gdb uses for CPSR
+	gdbCPSRRegister = 25
+
+	// gdbFPARegisterBytes is the size of an FPA register in the 'g' packet
+	gdbFPARegisterBytes = 12
+
+	// gdbInterruptByte is the out-of-band byte gdb sends to halt a running target (Ctrl-C)
+	gdbInterruptByte = 0x03
+
+	// gdbInterruptCheckInterval is how many instructions run between interrupt checks during continue
+	gdbInterruptCheckInterval = 1000
+
+	// gdbMaxPacketSize is advertised via qSupported and bounds incoming packets
+	gdbMaxPacketSize = 4096
+)
+
+// Signal numbers reported in stop replies
+const (
+	gdbSignalInt  = 2  // SIGINT - interrupted by client
+	gdbSignalTrap = 5  // SIGTRAP - breakpoint or step complete
+	gdbSignalSegv = 11 // SIGSEGV - VM runtime error
+)
+
+// GDBStub serves the gdb remote serial protocol over TCP, driving a Debugger
+type GDBStub struct {
+	dbg       *Debugger
+	listener  net.Listener
+	interrupt atomic.Bool
+	exited    bool
+}
+
+// NewGDBStub creates a gdb stub for the given debugger
+func NewGDBStub(dbg *Debugger) *GDBStub {
+	return &GDBStub{dbg: dbg}
+}
+
+// Listen opens the TCP listener (e.g. "localhost:1234" or "127.0.0.1:0")
+func (s *GDBStub) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	s.listener = listener
+	return nil
+}
+
+// Addr returns the listener address, or nil if not listening
+func (s *GDBStub) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close closes the listener
+func (s *GDBStub) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// Serve accepts a single gdb connection and handles it until the client detaches or disconnects
+func (s *GDBStub) Serve() error {
+	if s.listener == nil {
+		return fmt.Errorf("gdb stub is not listening")
+	}
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return fmt.Errorf("failed to accept gdb connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }() // Ignore close error on teardown
+
+	return s.handleConnection(conn)
+}
+
+// RunGDBServer listens on the given port and serves one gdb session
+func RunGDBServer(dbg *Debugger, port int) error {
+	stub := NewGDBStub(dbg)
+	if err := stub.Listen(fmt.Sprintf("localhost:%d", port)); err != nil {
+		return err
+	}
+	defer func() { _ = stub.Close() }() // Ignore close error on teardown
+
+	fmt.Printf("Waiting for gdb connection on %s (target remote %s)\n", stub.Addr(), stub.Addr())
+	return stub.Serve()
+}
+
+// handleConnection runs the packet loop for one client
+func (s *GDBStub) handleConnection(conn net.Conn) error {
+	packets := make(chan string)
+	readErr := make(chan error, 1)
+	finished := make(chan struct{})
+	defer close(finished)
+
+	// Reader goroutine: strips framing and handles out-of-band bytes so that
+	// an interrupt can be seen while a continue is in progress
+	go func() {
+		defer close(packets)
+		reader := bufio.NewReader(conn)
+		for {
+			packet, err := s.readPacket(reader, conn)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case packets <- packet:
+			case <-finished:
+				return
+			}
+		}
+	}()
+
+	for packet := range packets {
+		reply, done := s.handlePacket(packet)
+		if packet != "k" { // Kill expects no reply
+			if err := writePacket(conn, reply); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+
+	if err := <-readErr; err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// readPacket reads the next "$data#cs" packet, acknowledging it with '+' or '-'
+func (s *GDBStub) readPacket(reader *bufio.Reader, w io.Writer) (string, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch b {
+		case '+', '-':
+			// Acknowledgements from the client; retransmission is not needed over TCP
+			continue
+		case gdbInterruptByte:
+			s.interrupt.Store(true)
+			continue
+		case '$':
+		default:
+			continue // Ignore noise between packets
+		}
+
+		var data []byte
+		for {
+			c, err := reader.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			if c == '#' {
+				break
+			}
+			if len(data) >= gdbMaxPacketSize {
+				return "", fmt.Errorf("gdb packet exceeds %d bytes", gdbMaxPacketSize)
+			}
+			data = append(data, c)
+		}
+
+		csHex := make([]byte, 2)
+		if _, err := io.ReadFull(reader, csHex); err != nil {
+			return "", err
+		}
+		want, err := strconv.ParseUint(string(csHex), 16, 8)
+		if err != nil || byte(want) != gdbChecksum(data) {
+			if _, err := w.Write([]byte{'-'}); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if _, err := w.Write([]byte{'+'}); err != nil {
+			return "", err
+		}
+		return string(gdbUnescape(data)), nil
+	}
+}
+
+// writePacket frames and sends a reply packet
+func writePacket(w io.Writer, data string) error {
+	framed := fmt.Sprintf("$%s#%02x", data, gdbChecksum([]byte(data)))
+	if _, err := io.WriteString(w, framed); err != nil {
+		return fmt.Errorf("failed to send gdb packet: %w", err)
+	}
+	return nil
+}
+
+// gdbChecksum computes the modulo-256 sum of packet data
+func gdbChecksum(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return sum
+}
+
+// gdbUnescape removes '}' escapes (next byte XOR 0x20) from packet data
+func gdbUnescape(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '}' && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^0x20)
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+// handlePacket dispatches a packet and returns the reply; done reports the session should end
+func (s *GDBStub) handlePacket(packet string) (reply string, done bool) {
+	if packet == "" {
+		return "", false
+	}
+
+	switch packet[0] {
+	case '?':
+		return s.stopReply(gdbSignalTrap), false
+	case 'g':
+		return s.readRegisters(), false
+	case 'G':
+		return s.writeRegisters(packet[1:]), false
+	case 'p':
+		return s.readRegister(packet[1:]), false
+	case 'P':
+		return s.writeRegister(packet[1:]), false
+	case 'm':
+		return s.readMemory(packet[1:]), false
+	case 'M':
+		return s.writeMemory(packet[1:]), false
+	case 's':
+		return s.step(packet[1:]), false
+	case 'c':
+		return s.cont(packet[1:]), false
+	case 'Z', 'z':
+		return s.breakpoint(packet), false
+	case 'H':
+		return "OK", false
+	case 'D':
+		return "OK", true
+	case 'k':
+		return "", true
+	case 'q':
+		return s.query(packet), false
+	default:
+		return "", false // Empty reply means "unsupported"
+	}
+}
+
+// query handles general 'q' packets
+func (s *GDBStub) query(packet string) string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return fmt.Sprintf("PacketSize=%x", gdbMaxPacketSize)
+	case packet == "qAttached":
+		return "1"
+	case packet == "qC":
+		return "QC1"
+	case packet == "qfThreadInfo":
+		return "m1"
+	case packet == "qsThreadInfo":
+		return "l"
+	default:
+		return ""
+	}
+}
+
+// stopReply builds the reply describing why the target stopped
+func (s *GDBStub) stopReply(signal int) string {
+	if s.exited {
+		return fmt.Sprintf("W%02x", byte(s.dbg.VM.ExitCode)) // #nosec G115 -- gdb exit status is 8 bits
+	}
+	return fmt.Sprintf("S%02x", signal)
+}
+
+// appendRegister hex-encodes a 32-bit register in target byte order
+func (s *GDBStub) appendRegister(sb *strings.Builder, value uint32) {
+	var buf [4]byte
+	if s.dbg.VM.Memory.LittleEndian {
+		buf = [4]byte{byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)} // #nosec G115 -- byte extraction
+	} else {
+		buf = [4]byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)} // #nosec G115 -- byte extraction
+	}
+	sb.WriteString(hex.EncodeToString(buf[:]))
+}
+
+// decodeRegister parses an 8-digit hex register value in target byte order
+func (s *GDBStub) decodeRegister(text string) (uint32, error) {
+	buf, err := hex.DecodeString(text)
+	if err != nil || len(buf) != 4 {
+		return 0, fmt.Errorf("invalid register value: %s", text)
+	}
+	if s.dbg.VM.Memory.LittleEndian {
+		return uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24, nil
+	}
+	return uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3]), nil
+}
+
+// registerValue returns the value gdb expects for a register number
+func (s *GDBStub) registerValue(n int) uint32 {
+	cpu := s.dbg.VM.CPU
+	switch {
+	case n < vm.ARMRegisterPC:
+		return cpu.R[n]
+	case n == vm.ARMRegisterPC:
+		return cpu.PC // gdb expects the address of the current instruction, not PC+8
+	case n == gdbCPSRRegister:
+		return cpu.CPSR.ToUint32()
+	default:
+		return 0
+	}
+}
+
+// setRegisterValue writes a register by gdb register number (FPA registers are ignored)
+func (s *GDBStub) setRegisterValue(n int, value uint32) {
+	cpu := s.dbg.VM.CPU
+	switch {
+	case n < vm.ARMRegisterPC:
+		cpu.R[n] = value
+	case n == vm.ARMRegisterPC:
+		cpu.PC = value
+	case n == gdbCPSRRegister:
+		cpu.CPSR.FromUint32(value)
+	}
+}
+
+// readRegisters handles 'g'
+func (s *GDBStub) readRegisters() string {
+	var sb strings.Builder
+	for n := 0; n < gdbRegisterCount; n++ {
+		if n >= gdbFPARegisterFirst && n <= gdbFPARegisterLast {
+			sb.WriteString(strings.Repeat("00", gdbFPARegisterBytes))
+			continue
+		}
+		s.appendRegister(&sb, s.registerValue(n))
+	}
+	return sb.String()
+}
+
+// writeRegisters handles 'G'
+func (s *GDBStub) writeRegisters(data string) string {
+	pos := 0
+	for n := 0; n < gdbRegisterCount && pos < len(data); n++ {
+		if n >= gdbFPARegisterFirst && n <= gdbFPARegisterLast {
+			pos += gdbFPARegisterBytes * 2
+			continue
+		}
+		if pos+8 > len(data) {
+			return "E01"
+		}
+		value, err := s.decodeRegister(data[pos : pos+8])
+		if err != nil {
+			return "E01"
+		}
+		if n != gdbFPSRegister {
+			s.setRegisterValue(n, value)
+		}
+		pos += 8
+	}
+	return "OK"
+}
+
+// readRegister handles 'p n'
+func (s *GDBStub) readRegister(args string) string {
+	n, err := strconv.ParseUint(args, 16, 8)
+	if err != nil || n >= gdbRegisterCount {
+		return "E01"
+	}
+	if n >= gdbFPARegisterFirst && n <= gdbFPARegisterLast {
+		return strings.Repeat("00", gdbFPARegisterBytes)
+	}
+	var sb strings.Builder
+	s.appendRegister(&sb, s.registerValue(int(n)))
+	return sb.String()
+}
+
+// writeRegister handles 'P n=value'
+func (s *GDBStub) writeRegister(args string) string {
+	regStr, valueStr, ok := strings.Cut(args, "=")
+	if !ok {
+		return "E01"
+	}
+	n, err := strconv.ParseUint(regStr, 16, 8)
+	if err != nil || n >= gdbRegisterCount {
+		return "E01"
+	}
+	if n >= gdbFPARegisterFirst && n <= gdbFPSRegister {
+		return "OK" // FPA registers are not emulated
+	}
+	value, err := s.decodeRegister(valueStr)
+	if err != nil {
+		return "E01"
+	}
+	s.setRegisterValue(int(n), value)
+	return "OK"
+}
+
+// parseAddrLen parses "addr,length"
+func parseAddrLen(args string) (uint32, uint32, error) {
+	addrStr, lenStr, ok := strings.Cut(args, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed address/length: %s", args)
+	}
+	addr, err := strconv.ParseUint(addrStr, 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid address: %s", addrStr)
+	}
+	length, err := strconv.ParseUint(lenStr, 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid length: %s", lenStr)
+	}
+	return uint32(addr), uint32(length), nil
+}
+
+// readMemory handles 'm addr,length'
+func (s *GDBStub) readMemory(args string) string {
+	addr, length, err := parseAddrLen(args)
+	if err != nil || length > gdbMaxPacketSize/2 {
+		return "E01"
+	}
+	data, err := s.dbg.VM.Memory.GetBytes(addr, length)
+	if err != nil {
+		return "E0e" // EFAULT
+	}
+	return hex.EncodeToString(data)
+}
+
+// writeMemory handles 'M addr,length:XX...'
+func (s *GDBStub) writeMemory(args string) string {
+	header, payload, ok := strings.Cut(args, ":")
+	if !ok {
+		return "E01"
+	}
+	addr, length, err := parseAddrLen(header)
+	if err != nil {
+		return "E01"
+	}
+	data, err := hex.DecodeString(payload)
+	if err != nil || uint32(len(data)) != length { // #nosec G115 -- payload bounded by gdbMaxPacketSize
+		return "E01"
+	}
+	for i, b := range data {
+		if err := s.dbg.VM.Memory.WriteByteAt(addr+uint32(i), b); err != nil { // #nosec G115 -- i bounded by packet size
+			return "E0e" // EFAULT
+		}
+	}
+	return "OK"
+}
+
+// breakpoint handles 'Z0,addr,kind' and 'z0,addr,kind' (software breakpoints only)
+func (s *GDBStub) breakpoint(packet string) string {
+	parts := strings.Split(packet[1:], ",")
+	if len(parts) < 2 || parts[0] != "0" {
+		return "" // Only software breakpoints are supported
+	}
+	addr, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return "E01"
+	}
+
+	if packet[0] == 'Z' {
+		s.dbg.Breakpoints.AddBreakpoint(uint32(addr), false, "")
+		return "OK"
+	}
+	if err := s.dbg.Breakpoints.DeleteBreakpointAt(uint32(addr)); err != nil {
+		return "E01"
+	}
+	return "OK"
+}
+
+// resumeAt applies the optional resume address of 's'/'c' packets
+func (s *GDBStub) resumeAt(args string) error {
+	if args == "" {
+		return nil
+	}
+	addr, err := strconv.ParseUint(args, 16, 32)
+	if err != nil {
+		return fmt.Errorf("invalid resume address: %s", args)
+	}
+	s.dbg.VM.CPU.PC = uint32(addr)
+	return nil
+}
+
+// execute runs one instruction; stop reports whether execution can't continue
+func (s *GDBStub) execute() (stop bool, signal int) {
+	if err := s.dbg.VM.Step(); err != nil {
+		if s.dbg.VM.State == vm.StateHalted {
+			s.exited = true
+			return true, gdbSignalTrap
+		}
+		return true, gdbSignalSegv
+	}
+	if s.dbg.VM.State == vm.StateBreakpoint {
+		return true, gdbSignalTrap // SWI breakpoint
+	}
+	return false, 0
+}
+
+// step handles 's [addr]'
+func (s *GDBStub) step(args string) string {
+	if s.exited {
+		return s.stopReply(gdbSignalTrap)
+	}
+	if err := s.resumeAt(args); err != nil {
+		return "E01"
+	}
+
+	s.dbg.VM.State = vm.StateBreakpoint
+	_, signal := s.execute()
+	if signal == 0 {
+		signal = gdbSignalTrap
+	}
+	return s.stopReply(signal)
+}
+
+// cont handles 'c [addr]', running until a breakpoint, exit, error or client interrupt
+func (s *GDBStub) cont(args string) string {
+	if s.exited {
+		return s.stopReply(gdbSignalTrap)
+	}
+	if err := s.resumeAt(args); err != nil {
+		return "E01"
+	}
+
+	s.interrupt.Store(false)
+	s.dbg.SetStepMode(StepNone)
+	s.dbg.VM.State = vm.StateRunning
+	defer func() {
+		if s.dbg.VM.State == vm.StateRunning {
+			s.dbg.VM.State = vm.StateBreakpoint
+		}
+	}()
+
+	for count := 0; ; count++ {
+		// Skip the check on the first instruction so we can resume from a breakpoint
+		if count > 0 {
+			if shouldBreak, _ := s.dbg.ShouldBreak(); shouldBreak {
+				return s.stopReply(gdbSignalTrap)
+			}
+			if count%gdbInterruptCheckInterval == 0 && s.interrupt.Load() {
+				return s.stopReply(gdbSignalInt)
+			}
+		}
+
+		if stop, signal := s.execute(); stop {
+			return s.stopReply(signal)
+		}
+	}
+}
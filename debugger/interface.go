@@ -12,6 +12,7 @@ import (
 // RunCLI runs the command-line debugger interface
 func RunCLI(dbg *Debugger) error {
 	scanner := bufio.NewScanner(os.Stdin)
+	dbg.Color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
 
 	for {
 		// Print prompt
@@ -61,11 +62,21 @@ func RunCLI(dbg *Debugger) error {
 						fmt.Printf("Program exited with code %d\n", dbg.VM.ExitCode)
 						break
 					}
-					fmt.Printf("Runtime error: %v\n", err)
+					if msg, ok := UndefinedStop(err); ok {
+						fmt.Printf("Stopped: %s\n", msg)
+					} else {
+						fmt.Printf("Runtime error: %v\n", err)
+					}
 					dbg.Running = false
 					break
 				}
 
+				if trap := dbg.VM.LastBKPT; trap != nil {
+					dbg.Running = false
+					fmt.Printf("Stopped: %s\n", trap)
+					break
+				}
+
 				// For single-step mode, check if we should break after execution
 				if dbg.StepMode == StepSingle {
 					if shouldBreak, reason := dbg.ShouldBreak(); shouldBreak {
@@ -120,3 +131,9 @@ func RunTUI(dbg *Debugger) error {
 	tui := NewTUI(dbg)
 	return tui.Run()
 }
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,44 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+)
+
+// cmdSaveState writes the whole machine state to a file with vm.ExportState
+func (d *Debugger) cmdSaveState(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: save-state <file>")
+	}
+	f, err := os.OpenFile(args[0], os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 -- user-specified state file
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", args[0], err)
+	}
+	if err := d.VM.ExportState(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[0], err)
+	}
+	d.Printf("Saved machine state at PC=0x%08X to %s\n", d.VM.CPU.PC, args[0])
+	return nil
+}
+
+// cmdLoadState restores the machine state saved by save-state. Breakpoints and
+// watchpoints are not part of the state and are left as they are.
+func (d *Debugger) cmdLoadState(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: load-state <file>")
+	}
+	f, err := os.Open(args[0]) // #nosec G304 -- user-specified state file
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+	defer f.Close()
+	if err := d.VM.ImportState(f); err != nil {
+		return fmt.Errorf("failed to load %s: %w", args[0], err)
+	}
+	d.Printf("Loaded machine state from %s, PC=0x%08X\n", args[0], d.VM.CPU.PC)
+	return nil
+}
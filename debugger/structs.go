@@ -0,0 +1,266 @@
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// StructField is one field of a struct layout
+type StructField struct {
+	Name   string
+	Type   string // Element type: u8, i8, u16, i16, u32, i32, ptr or char
+	Count  int    // Number of elements; 1 unless declared as an array, e.g. u32[4]
+	Offset uint32 // Byte offset from the start of the struct
+}
+
+// Size returns the number of bytes the field occupies
+func (f StructField) Size() uint32 {
+	return structTypeSizes[f.Type] * uint32(f.Count) // #nosec G115 -- Count is bounded by StructMaxArrayLength
+}
+
+// StructLayout is a named memory layout declared with the struct command
+type StructLayout struct {
+	Name   string
+	Fields []StructField
+}
+
+// Size returns the number of bytes the layout covers, up to the end of its last field
+func (s *StructLayout) Size() uint32 {
+	var size uint32
+	for _, f := range s.Fields {
+		if end := f.Offset + f.Size(); end > size {
+			size = end
+		}
+	}
+	return size
+}
+
+// structTypeSizes gives the size in bytes of each field element type
+var structTypeSizes = map[string]uint32{
+	"u8": 1, "i8": 1, "char": 1,
+	"u16": 2, "i16": 2,
+	"u32": 4, "i32": 4, "ptr": 4,
+}
+
+// structTypeAliases maps the assembler's directive names onto field types
+var structTypeAliases = map[string]string{
+	"byte": "u8", "half": "u16", "hword": "u16", "word": "u32",
+}
+
+// DefineStruct parses field declarations of the form name:type[N][@offset] and records the
+// layout under name, replacing any earlier one. Fields without an offset follow the
+// previous field with no padding, as consecutive .byte/.half/.word directives do.
+func (d *Debugger) DefineStruct(name string, declarations []string) (*StructLayout, error) {
+	if len(declarations) == 0 {
+		return nil, fmt.Errorf("struct %s has no fields", name)
+	}
+
+	layout := &StructLayout{Name: name}
+	seen := make(map[string]bool)
+	var next uint32
+	for _, decl := range declarations {
+		field, err := parseStructField(decl, next)
+		if err != nil {
+			return nil, err
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("duplicate field %s in struct %s", field.Name, name)
+		}
+		seen[field.Name] = true
+		layout.Fields = append(layout.Fields, field)
+		next = field.Offset + field.Size()
+	}
+
+	if d.Structs == nil {
+		d.Structs = make(map[string]*StructLayout)
+	}
+	d.Structs[name] = layout
+	return layout, nil
+}
+
+// parseStructField parses one name:type[N][@offset] declaration; next is the offset used
+// when none is given
+func parseStructField(decl string, next uint32) (StructField, error) {
+	name, spec, found := strings.Cut(decl, ":")
+	if !found || name == "" || spec == "" {
+		return StructField{}, fmt.Errorf("invalid field %q (expected name:type)", decl)
+	}
+	field := StructField{Name: name, Count: 1, Offset: next}
+
+	if typeSpec, offset, found := strings.Cut(spec, "@"); found {
+		value, err := strconv.ParseUint(offset, 0, 32)
+		if err != nil {
+			return StructField{}, fmt.Errorf("invalid offset in field %q", decl)
+		}
+		field.Offset = uint32(value)
+		spec = typeSpec
+	}
+
+	if open := strings.IndexByte(spec, '['); open >= 0 {
+		if !strings.HasSuffix(spec, "]") {
+			return StructField{}, fmt.Errorf("invalid array length in field %q", decl)
+		}
+		count, err := strconv.Atoi(spec[open+1 : len(spec)-1])
+		if err != nil || count <= 0 || count > StructMaxArrayLength {
+			return StructField{}, fmt.Errorf("invalid array length in field %q (1-%d)", decl, StructMaxArrayLength)
+		}
+		field.Count = count
+		spec = spec[:open]
+	}
+
+	field.Type = strings.ToLower(spec)
+	if alias, ok := structTypeAliases[field.Type]; ok {
+		field.Type = alias
+	}
+	if _, ok := structTypeSizes[field.Type]; !ok {
+		return StructField{}, fmt.Errorf("unknown type %q in field %q (use u8, i8, u16, i16, u32, i32, ptr or char)", spec, decl)
+	}
+	return field, nil
+}
+
+// FormatStruct reads the layout from memory at address and returns one line per field
+func (d *Debugger) FormatStruct(layout *StructLayout, address uint32) ([]string, error) {
+	symbols := vm.NewSymbolResolver(d.Symbols)
+	lines := make([]string, 0, len(layout.Fields))
+	for _, field := range layout.Fields {
+		value, err := d.formatField(field, address+field.Offset, symbols)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s", field.Name, value))
+	}
+	return lines, nil
+}
+
+// formatField reads and formats one field; arrays are shown as {a, b, ...} and char
+// arrays as a string ending at the first NUL
+func (d *Debugger) formatField(field StructField, address uint32, symbols *vm.SymbolResolver) (string, error) {
+	size := structTypeSizes[field.Type]
+
+	if field.Type == "char" && field.Count > 1 {
+		var text []byte
+		for i := 0; i < field.Count; i++ {
+			b, err := d.VM.Memory.ReadByteAt(address + uint32(i)) // #nosec G115 -- i is bounded by StructMaxArrayLength
+			if err != nil {
+				return "", err
+			}
+			if b == 0 {
+				break
+			}
+			text = append(text, b)
+		}
+		return strconv.Quote(string(text)), nil
+	}
+
+	values := make([]string, field.Count)
+	for i := range values {
+		value, err := d.readUnit(address+uint32(i)*size, size) // #nosec G115 -- i is bounded by StructMaxArrayLength
+		if err != nil {
+			return "", err
+		}
+		values[i] = formatFieldValue(field.Type, value, size, symbols)
+	}
+	if field.Count == 1 {
+		return values[0], nil
+	}
+	return "{" + strings.Join(values, ", ") + "}", nil
+}
+
+// formatFieldValue formats a single element: hex and decimal for numbers, hex and the
+// nearest label for pointers, and the quoted character for char
+func formatFieldValue(fieldType string, value, size uint32, symbols *vm.SymbolResolver) string {
+	switch fieldType {
+	case "ptr":
+		if symbols.HasSymbols() {
+			// Only label pointers close to a symbol; anything further is unlikely to point into it
+			if _, offset, found := symbols.ResolveAddress(value); found && offset < StructPointerLabelRange {
+				return fmt.Sprintf("0x%08X <%s>", value, symbols.FormatAddressCompact(value))
+			}
+		}
+		return fmt.Sprintf("0x%08X", value)
+	case "char":
+		return fmt.Sprintf("%d %s", value, strconv.QuoteRune(rune(value)))
+	case "i8", "i16", "i32":
+		return fmt.Sprintf("%s (%s)", formatExamineValue(value, size, 'x'), formatExamineValue(value, size, 'd'))
+	default:
+		return fmt.Sprintf("%s (%d)", formatExamineValue(value, size, 'x'), value)
+	}
+}
+
+// cmdStruct defines a struct layout (struct NAME field:type ...), shows one (struct NAME)
+// or lists them all (struct)
+func (d *Debugger) cmdStruct(args []string) error {
+	if len(args) == 0 {
+		if len(d.Structs) == 0 {
+			d.Println("No structs defined")
+			return nil
+		}
+		names := make([]string, 0, len(d.Structs))
+		for name := range d.Structs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			d.Printf("%s (%d bytes)\n", name, d.Structs[name].Size())
+		}
+		return nil
+	}
+
+	name := args[0]
+	if len(args) == 1 {
+		layout, ok := d.Structs[name]
+		if !ok {
+			return fmt.Errorf("no struct named %s", name)
+		}
+		d.showStructLayout(layout)
+		return nil
+	}
+
+	layout, err := d.DefineStruct(name, args[1:])
+	if err != nil {
+		return err
+	}
+	d.showStructLayout(layout)
+	return nil
+}
+
+// showStructLayout prints each field's offset, type and name
+func (d *Debugger) showStructLayout(layout *StructLayout) {
+	d.Printf("struct %s (%d bytes):\n", layout.Name, layout.Size())
+	for _, f := range layout.Fields {
+		fieldType := f.Type
+		if f.Count > 1 {
+			fieldType = fmt.Sprintf("%s[%d]", f.Type, f.Count)
+		}
+		d.Printf("  +%-4d %-9s %s\n", f.Offset, fieldType, f.Name)
+	}
+}
+
+// printStruct handles print struct NAME at ADDR
+func (d *Debugger) printStruct(args []string) error {
+	if len(args) < 3 || !strings.EqualFold(args[1], "at") {
+		return fmt.Errorf("usage: print struct <name> at <address>")
+	}
+	layout, ok := d.Structs[args[0]]
+	if !ok {
+		return fmt.Errorf("no struct named %s", args[0])
+	}
+	address, err := d.resolveAddressExpression(strings.Join(args[2:], " "))
+	if err != nil {
+		return err
+	}
+
+	lines, err := d.FormatStruct(layout, address)
+	if err != nil {
+		return err
+	}
+	d.Printf("%s at 0x%08X:\n", layout.Name, address)
+	for _, line := range lines {
+		d.Printf("  %s\n", line)
+	}
+	return nil
+}
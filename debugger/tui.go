@@ -3,6 +3,7 @@ package debugger
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -39,6 +40,7 @@ type TUI struct {
 	StackView       *tview.TextView
 	DisassemblyView *tview.TextView
 	BreakpointsView *tview.TextView
+	WatchView       *tview.TextView   // Watch expressions, re-evaluated on every refresh
 	StatusView      *tview.TextView   // Status messages (breakpoints, stepping, errors)
 	OutputView      *tview.TextView   // Program output only
 	CommandInput    *tview.InputField // Debugger command input
@@ -57,6 +59,16 @@ type TUI struct {
 	MemoryAddress  uint32
 	StackAddress   uint32
 	Running        bool
+	Watches        []string // Expressions shown in the Watch panel
+	MemoryFollow   string   // Register (or expression) the Memory view tracks; empty to follow writes
+
+	// Memory editor: while MemoryEditing, keys in the Memory view move a cursor and
+	// typed hex digits overwrite the byte (or word) under it
+	MemoryEditing    bool
+	MemoryCursor     uint32
+	memoryEditWord   bool
+	memoryEditDigits string
+	memoryViewStart  uint32 // First address shown by the last UpdateMemoryView
 
 	// Source code cache
 	SourceLines []string
@@ -204,6 +216,7 @@ func (t *TUI) initializeViews() {
 		SetScrollable(true).
 		SetWrap(false)
 	t.MemoryView.SetBorder(true).SetTitle(" Memory ")
+	t.MemoryView.SetInputCapture(t.handleMemoryKey)
 
 	// Stack View
 	t.StackView = tview.NewTextView().
@@ -226,6 +239,13 @@ func (t *TUI) initializeViews() {
 		SetWrap(false)
 	t.BreakpointsView.SetBorder(true).SetTitle(" Breakpoints/Watchpoints ")
 
+	// Watch View
+	t.WatchView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	t.WatchView.SetBorder(true).SetTitle(" Watch ")
+
 	// Status View - for debugger messages
 	t.StatusView = tview.NewTextView().
 		SetDynamicColors(true).
@@ -275,6 +295,7 @@ func (t *TUI) buildLayout() {
 	t.RightPanel = tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(rightTop, 0, 3, false).
+		AddItem(t.WatchView, 3, 0, false).      // Resized to its contents by UpdateWatchView
 		AddItem(t.BreakpointsView, 4, 0, false) // Start with minimal height, updated dynamically
 
 	// Main content: Left and Right panels
@@ -305,6 +326,7 @@ func (t *TUI) initFocusChain() {
 		t.DisassemblyView,
 		t.MemoryView,
 		t.StackView,
+		t.WatchView,
 		t.BreakpointsView,
 		t.OutputView,
 		t.ProgramInput,
@@ -401,8 +423,11 @@ func (t *TUI) executeCommand(cmd string) {
 		return
 	}
 
-	// Execute command
-	err := t.Debugger.ExecuteCommand(cmd)
+	// Execute command, unless it only changes what the TUI displays
+	handled, err := t.executeViewCommand(cmd)
+	if !handled {
+		err = t.Debugger.ExecuteCommand(cmd)
+	}
 
 	// Get output
 	output := t.Debugger.GetOutput()
@@ -427,6 +452,208 @@ func (t *TUI) executeCommand(cmd string) {
 	}
 }
 
+// executeViewCommand handles the TUI-only commands "watch add|del|clear",
+// "mem follow REG|off" and "set mem ADDR VALUE [size]". It reports false for any other command.
+func (t *TUI) executeViewCommand(cmd string) (bool, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 {
+		return false, nil
+	}
+	verb, sub := strings.ToLower(fields[0]), strings.ToLower(fields[1])
+	arg := strings.TrimSpace(strings.Join(fields[2:], " "))
+
+	switch {
+	case verb == "watch" && sub == "add":
+		if arg == "" {
+			return true, fmt.Errorf("usage: watch add EXPR")
+		}
+		t.Watches = append(t.Watches, arg)
+		t.Debugger.Printf("Watch %d: %s\n", len(t.Watches), arg)
+
+	case verb == "watch" && sub == "del":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(t.Watches) {
+			return true, fmt.Errorf("no watch %q", arg)
+		}
+		t.Watches = append(t.Watches[:n-1], t.Watches[n:]...)
+		t.Debugger.Printf("Deleted watch %d\n", n)
+
+	case verb == "watch" && sub == "clear":
+		t.Watches = nil
+		t.Debugger.Println("Deleted all watches")
+
+	case verb == "mem" && sub == "follow":
+		switch strings.ToLower(arg) {
+		case "":
+			return true, fmt.Errorf("usage: mem follow REG|off")
+		case "off":
+			t.MemoryFollow = ""
+			t.Debugger.Println("Memory view follows writes")
+		default:
+			if _, err := t.Debugger.Evaluator.EvaluateValue(arg, t.Debugger.VM, t.Debugger.Symbols); err != nil {
+				return true, fmt.Errorf("cannot follow %s: %w", arg, err)
+			}
+			t.MemoryFollow = arg
+			t.Debugger.Printf("Memory view follows %s\n", arg)
+		}
+
+	case verb == "set" && sub == "mem":
+		return true, t.setMemory(fields[2:])
+
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// setMemory implements "set mem ADDR VALUE [size]"; size is byte, half or word (default word)
+func (t *TUI) setMemory(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("usage: set mem ADDR VALUE [byte|half|word]")
+	}
+	addr, err := t.Debugger.ResolveAddress(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := t.Debugger.Evaluator.EvaluateValue(args[1], t.Debugger.VM, t.Debugger.Symbols)
+	if err != nil {
+		return err
+	}
+	size := 4
+	if len(args) == 3 {
+		switch strings.ToLower(args[2]) {
+		case "b", "byte", "1":
+			size = 1
+		case "h", "half", "halfword", "2":
+			size = 2
+		case "w", "word", "4":
+			size = 4
+		default:
+			return fmt.Errorf("invalid size %q (use byte, half or word)", args[2])
+		}
+	}
+
+	if err := t.writeMemory(addr, value, size); err != nil {
+		return err
+	}
+	if t.MemoryFollow == "" {
+		t.MemoryAddress = addr &^ (MemoryDisplayBytesPerRow - 1)
+	}
+	t.Debugger.Printf("Memory 0x%08X set to 0x%0*X\n", addr, size*2, value)
+	return nil
+}
+
+// writeMemory stores a 1, 2 or 4 byte value through Memory, so segment permissions and
+// alignment are enforced, and highlights the bytes written in the Memory view
+func (t *TUI) writeMemory(addr, value uint32, size int) error {
+	if size < 4 && value>>(uint(size)*8) != 0 {
+		return fmt.Errorf("value 0x%X does not fit in %d byte(s)", value, size)
+	}
+
+	var err error
+	switch size {
+	case 1:
+		err = t.Debugger.VM.Memory.WriteByteAt(addr, byte(value)) // #nosec G115 -- checked to fit above
+	case 2:
+		err = t.Debugger.VM.Memory.WriteHalfword(addr, uint16(value)) // #nosec G115 -- checked to fit above
+	default:
+		err = t.Debugger.VM.Memory.WriteWord(addr, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.stateMu.Lock()
+	t.RecentWrites = make(map[uint32]bool)
+	for i := 0; i < size; i++ {
+		t.RecentWrites[addr+uint32(i)] = true // #nosec G115 -- i < 4
+	}
+	t.stateMu.Unlock()
+	return nil
+}
+
+// handleMemoryKey implements the Memory view's edit mode. "e" starts editing at the top of
+// the view; arrows move the cursor, "w" toggles between byte and word editing, hex digits
+// overwrite the value under the cursor and Esc leaves edit mode.
+func (t *TUI) handleMemoryKey(event *tcell.EventKey) *tcell.EventKey {
+	if !t.MemoryEditing {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'e' {
+			t.MemoryEditing = true
+			t.MemoryCursor = t.memoryViewStart
+			t.memoryEditWord = false
+			t.memoryEditDigits = ""
+			t.UpdateMemoryView()
+			return nil
+		}
+		return event
+	}
+
+	step := t.memoryEditSize()
+	switch event.Key() {
+	case tcell.KeyEscape:
+		t.MemoryEditing = false
+	case tcell.KeyLeft:
+		t.moveMemoryCursor(-int64(step))
+	case tcell.KeyRight:
+		t.moveMemoryCursor(int64(step))
+	case tcell.KeyUp:
+		t.moveMemoryCursor(-MemoryDisplayBytesPerRow)
+	case tcell.KeyDown:
+		t.moveMemoryCursor(MemoryDisplayBytesPerRow)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if n := len(t.memoryEditDigits); n > 0 {
+			t.memoryEditDigits = t.memoryEditDigits[:n-1]
+		}
+	case tcell.KeyRune:
+		r := event.Rune()
+		switch {
+		case r == 'w':
+			t.memoryEditWord = !t.memoryEditWord
+			t.memoryEditDigits = ""
+			if t.memoryEditWord {
+				t.MemoryCursor &^= 3
+			}
+		case strings.ContainsRune("0123456789abcdefABCDEF", r):
+			t.memoryEditDigits += string(r)
+			if len(t.memoryEditDigits) == step*2 {
+				value, _ := strconv.ParseUint(t.memoryEditDigits, 16, 32) // digits are validated above
+				t.memoryEditDigits = ""
+				if err := t.writeMemory(t.MemoryCursor, uint32(value), step); err != nil {
+					t.WriteStatus(fmt.Sprintf("[red]Error:[white] %v\n", err))
+				} else {
+					t.moveMemoryCursor(int64(step))
+				}
+			}
+		default:
+			return nil
+		}
+	default:
+		return event
+	}
+	t.UpdateMemoryView()
+	return nil
+}
+
+// memoryEditSize returns the number of bytes each edit overwrites
+func (t *TUI) memoryEditSize() int {
+	if t.memoryEditWord {
+		return 4
+	}
+	return 1
+}
+
+// moveMemoryCursor moves the edit cursor by delta bytes, scrolling the Memory view (and
+// leaving follow mode) when the cursor leaves the visible rows
+func (t *TUI) moveMemoryCursor(delta int64) {
+	t.memoryEditDigits = ""
+	t.MemoryCursor = uint32(int64(t.MemoryCursor) + delta) // #nosec G115 -- address arithmetic wraps like the CPU
+	visible := uint32(MemoryDisplayRows * MemoryDisplayBytesPerRow)
+	if t.MemoryCursor-t.memoryViewStart >= visible {
+		t.MemoryFollow = ""
+		t.MemoryAddress = t.MemoryCursor &^ (MemoryDisplayBytesPerRow - 1)
+	}
+}
+
 // executeUntilBreak runs the VM until a breakpoint is hit or the program halts
 func (t *TUI) executeUntilBreak() {
 	// Run execution in the background to keep TUI responsive
@@ -466,7 +693,11 @@ func (t *TUI) executeUntilBreak() {
 				}
 				t.Debugger.SetRunning(false)
 				t.App.QueueUpdateDraw(func() {
-					t.WriteStatus(fmt.Sprintf("[red]Runtime error:[white] %v\n", err))
+					if msg, ok := UndefinedStop(err); ok {
+						t.WriteStatus(fmt.Sprintf("[yellow]Stopped:[white] %s\n", tview.Escape(msg)))
+					} else {
+						t.WriteStatus(fmt.Sprintf("[red]Runtime error:[white] %v\n", err))
+					}
 					t.DetectRegisterChanges()
 					t.DetectMemoryWrites()
 					t.RefreshAll()
@@ -478,6 +709,15 @@ func (t *TUI) executeUntilBreak() {
 			t.DetectRegisterChanges()
 			t.DetectMemoryWrites()
 
+			if trap := t.Debugger.VM.LastBKPT; trap != nil {
+				t.Debugger.SetRunning(false)
+				t.App.QueueUpdateDraw(func() {
+					t.WriteStatus(fmt.Sprintf("[yellow]Stopped:[white] %s\n", trap))
+					t.RefreshAll()
+				})
+				break
+			}
+
 			// For single-step mode, check if we should break after execution
 			if t.Debugger.GetStepMode() == StepSingle {
 				if shouldBreak, reason := t.Debugger.ShouldBreak(); shouldBreak {
@@ -576,6 +816,7 @@ func (t *TUI) RefreshAll() {
 	t.UpdateStackView()
 	t.UpdateDisassemblyView()
 	t.UpdateBreakpointsView()
+	t.UpdateWatchView()
 	t.scrollPCIntoView() // Auto-scroll to keep PC visible
 	// Note: App.Draw() is not called here - caller must use QueueUpdateDraw
 }
@@ -744,22 +985,14 @@ func (t *TUI) UpdateRegisterView() {
 	} else {
 		flags += "v"
 	}
-
-	// Calculate CPSR value manually
-	cpsrValue := uint32(0)
-	if cpu.CPSR.N {
-		cpsrValue |= 0x80000000
-	}
-	if cpu.CPSR.Z {
-		cpsrValue |= 0x40000000
-	}
-	if cpu.CPSR.C {
-		cpsrValue |= 0x20000000
-	}
-	if cpu.CPSR.V {
-		cpsrValue |= 0x10000000
+	if cpu.CPSR.Q {
+		flags += "[yellow]Q[white]"
+	} else {
+		flags += "q"
 	}
 
+	cpsrValue := cpu.CPSR.ToUint32()
+
 	// Blank line separator
 	lines = append(lines, "")
 
@@ -790,11 +1023,28 @@ func (t *TUI) UpdateMemoryView() {
 	}
 	t.stateMu.RUnlock()
 
-	// Use current memory address or PC if not set
+	// Use the followed register, else the current memory address, else PC
 	addr := t.MemoryAddress
 	if addr == 0 {
 		addr = t.Debugger.VM.CPU.PC
 	}
+	title := " Memory "
+	if t.MemoryFollow != "" {
+		if value, err := t.Debugger.Evaluator.EvaluateValue(t.MemoryFollow, t.Debugger.VM, t.Debugger.Symbols); err == nil {
+			addr = value &^ (MemoryDisplayBytesPerRow - 1)
+		}
+		title = fmt.Sprintf(" Memory (following %s) ", t.MemoryFollow)
+	}
+	if t.MemoryEditing {
+		unit := "byte"
+		if t.memoryEditWord {
+			unit = "word"
+		}
+		title = fmt.Sprintf(" Memory (editing %s at %08X: %s_) ", unit, t.MemoryCursor, t.memoryEditDigits)
+	}
+	t.MemoryView.SetTitle(title)
+	t.memoryViewStart = addr
+	cursorEnd := t.MemoryCursor + uint32(t.memoryEditSize()) // #nosec G115 -- 1 or 4
 
 	var lines []string
 	lines = append(lines, fmt.Sprintf("[yellow]Address: %08X (Lines end with .)[white]", addr))
@@ -830,8 +1080,10 @@ func (t *TUI) UpdateMemoryView() {
 				if col > 0 {
 					hexPart += " "
 				}
-				// Highlight recently written bytes in green
-				if recentWrites[byteAddr] {
+				// Show the edit cursor reversed, and recently written bytes in green
+				if t.MemoryEditing && byteAddr >= t.MemoryCursor && byteAddr < cursorEnd {
+					hexPart += fmt.Sprintf("[black:yellow]%02X[white:-]", b)
+				} else if recentWrites[byteAddr] {
 					hexPart += fmt.Sprintf("[green]%02X[white]", b)
 				} else {
 					hexPart += fmt.Sprintf("%02X", b)
@@ -938,7 +1190,7 @@ func (t *TUI) UpdateDisassemblyView() {
 		}
 
 		// Read instruction
-		instr, err := t.Debugger.VM.Memory.ReadWord(addr)
+		instr, err := t.Debugger.VM.Memory.ReadInstruction(addr)
 		if err != nil {
 			continue // Skip invalid addresses
 		}
@@ -972,7 +1224,7 @@ func (t *TUI) UpdateDisassemblyView() {
 		addr := pc + offset
 
 		// Read instruction
-		instr, err := t.Debugger.VM.Memory.ReadWord(addr)
+		instr, err := t.Debugger.VM.Memory.ReadInstruction(addr)
 		if err != nil {
 			continue // Skip invalid addresses
 		}
@@ -1034,8 +1286,12 @@ func (t *TUI) UpdateBreakpointsView() {
 				line += fmt.Sprintf(" if %s", bp.Condition)
 			}
 
-			// Add hit count
-			line += fmt.Sprintf(" (hits: %d)", bp.HitCount)
+			// Add hit count, and the ignore count if one is set
+			if bp.IgnoreCount > 0 {
+				line += fmt.Sprintf(" (hits: %d, ignore %d)", bp.HitCount, bp.IgnoreCount)
+			} else {
+				line += fmt.Sprintf(" (hits: %d)", bp.HitCount)
+			}
 
 			lines = append(lines, line)
 		}
@@ -1056,6 +1312,9 @@ func (t *TUI) UpdateBreakpointsView() {
 			} else if wp.Type == WatchReadWrite {
 				typeStr = "awatch"
 			}
+			if wp.IsExpression {
+				typeStr = "watch expr"
+			}
 
 			line := fmt.Sprintf("  %d: %s %s = 0x%08X", wp.ID, typeStr, wp.Expression, wp.LastValue)
 			lines = append(lines, line)
@@ -1079,6 +1338,29 @@ func (t *TUI) UpdateBreakpointsView() {
 	t.RightPanel.ResizeItem(t.BreakpointsView, height, 0)
 }
 
+// UpdateWatchView re-evaluates the watch expressions and resizes the panel to fit them
+func (t *TUI) UpdateWatchView() {
+	var lines []string
+	if len(t.Watches) == 0 {
+		lines = append(lines, "[yellow]No watches (watch add EXPR)[white]")
+	}
+	for i, expr := range t.Watches {
+		value, err := t.Debugger.Evaluator.EvaluateValue(expr, t.Debugger.VM, t.Debugger.Symbols)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("  %d: %s = [red]%s[white]", i+1, tview.Escape(expr), tview.Escape(err.Error())))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %d: %s = 0x%08X (%d)", i+1, tview.Escape(expr), value, int32(value))) // #nosec G115 -- signed view of the value
+	}
+	t.WatchView.SetText(strings.Join(lines, "\n"))
+
+	height := len(lines) + 2 // Add 2 for border
+	if height > WatchViewMaxRows {
+		height = WatchViewMaxRows
+	}
+	t.RightPanel.ResizeItem(t.WatchView, height, 0)
+}
+
 // buildReverseSymbolMap builds a reverse lookup map from address to symbol name
 func (t *TUI) buildReverseSymbolMap() {
 	t.reverseSymbolMap = make(map[uint32]string, len(t.Debugger.Symbols))
@@ -1,11 +1,13 @@
 package debugger
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/lookbusy1344/arm-emulator/vm"
+	"github.com/rivo/tview"
 )
 
 // TestExecuteCommandAsync tests that executeCommand doesn't block
@@ -107,3 +109,184 @@ func TestHandleProgramInputEchoing(t *testing.T) {
 		t.Error("ProgramInput should be cleared after handling input")
 	}
 }
+
+// newTestTUI creates a TUI on a simulation screen
+func newTestTUI(t *testing.T) *TUI {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to init simulation screen: %v", err)
+	}
+	t.Cleanup(screen.Fini)
+	return NewTUIWithScreen(NewDebugger(vm.NewVM()), screen)
+}
+
+// TestWatchAdd tests that watch expressions are listed with their current values
+func TestWatchAdd(t *testing.T) {
+	tui := newTestTUI(t)
+	tui.Debugger.Symbols = map[string]uint32{"buffer": 0x20000}
+	tui.Debugger.VM.CPU.R[2] = 40
+
+	for _, cmd := range []string{"watch add r2 + 2", "watch add buffer", "watch add nosuch"} {
+		if handled, err := tui.executeViewCommand(cmd); !handled || err != nil {
+			t.Fatalf("%q: handled=%v err=%v", cmd, handled, err)
+		}
+	}
+	tui.RefreshAll()
+
+	text := tui.WatchView.GetText(true)
+	for _, want := range []string{"1: r2 + 2 = 0x0000002A (42)", "2: buffer = 0x00020000", "3: nosuch = "} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected watch panel to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	// Values follow the machine state on the next refresh
+	tui.Debugger.VM.CPU.R[2] = 0
+	tui.UpdateWatchView()
+	if text := tui.WatchView.GetText(true); !strings.Contains(text, "r2 + 2 = 0x00000002 (2)") {
+		t.Errorf("expected the watch to be re-evaluated, got:\n%s", text)
+	}
+
+	if _, err := tui.executeViewCommand("watch del 1"); err != nil || len(tui.Watches) != 2 || tui.Watches[0] != "buffer" {
+		t.Errorf("watch del 1 failed: %v, watches %v", err, tui.Watches)
+	}
+	if handled, _ := tui.executeViewCommand("watch r0"); handled {
+		t.Error("a plain watch command should be left to the debugger as a watchpoint")
+	}
+}
+
+// TestMemoryFollow tests that the Memory view tracks a register
+func TestMemoryFollow(t *testing.T) {
+	tui := newTestTUI(t)
+	tui.MemoryAddress = 0x8000
+	tui.Debugger.VM.CPU.R[4] = 0x20013
+
+	if _, err := tui.executeViewCommand("mem follow r4"); err != nil {
+		t.Fatalf("mem follow failed: %v", err)
+	}
+	tui.UpdateMemoryView()
+	if text := tui.MemoryView.GetText(true); !strings.Contains(text, "Address: 00020010") {
+		t.Errorf("expected the view at R4's row, got:\n%s", text)
+	}
+
+	tui.Debugger.VM.CPU.R[4] = 0x20100
+	tui.UpdateMemoryView()
+	if text := tui.MemoryView.GetText(true); !strings.Contains(text, "Address: 00020100") {
+		t.Errorf("expected the view to move with R4, got:\n%s", text)
+	}
+
+	if _, err := tui.executeViewCommand("mem follow off"); err != nil {
+		t.Fatalf("mem follow off failed: %v", err)
+	}
+	tui.UpdateMemoryView()
+	if text := tui.MemoryView.GetText(true); !strings.Contains(text, "Address: 00008000") {
+		t.Errorf("expected the view back at the memory address, got:\n%s", text)
+	}
+
+	if _, err := tui.executeViewCommand("mem follow r99"); err == nil {
+		t.Error("expected an error for an unknown register")
+	}
+}
+
+// TestSetMem tests the set mem command writes through Memory and highlights the change
+func TestSetMem(t *testing.T) {
+	tui := newTestTUI(t)
+	tui.Debugger.Symbols = map[string]uint32{"buffer": 0x20008}
+	mem := tui.Debugger.VM.Memory
+
+	if _, err := tui.executeViewCommand("set mem buffer 0x11223344"); err != nil {
+		t.Fatalf("set mem failed: %v", err)
+	}
+	if w, _ := mem.ReadWord(0x20008); w != 0x11223344 {
+		t.Errorf("expected the word at buffer to be set, got 0x%08X", w)
+	}
+	if _, err := tui.executeViewCommand("set mem 0x20003 0xAB byte"); err != nil {
+		t.Fatalf("set mem byte failed: %v", err)
+	}
+	if b, _ := mem.ReadByteAt(0x20003); b != 0xAB {
+		t.Errorf("expected byte 0xAB, got 0x%02X", b)
+	}
+	if tui.MemoryAddress != 0x20000 || !tui.RecentWrites[0x20003] || tui.RecentWrites[0x20008] {
+		t.Errorf("expected only the byte write highlighted at 0x20000, got address 0x%08X writes %v", tui.MemoryAddress, tui.RecentWrites)
+	}
+
+	for _, cmd := range []string{"set mem 0x20000 0x100 byte", "set mem 0x20000 1 quad", "set mem 0x20000", "set mem nowhere 1"} {
+		if _, err := tui.executeViewCommand(cmd); err == nil {
+			t.Errorf("%q: expected an error", cmd)
+		}
+	}
+
+	// Permission checks apply
+	for _, seg := range mem.Segments {
+		if seg.Name == "data" {
+			seg.Permissions = vm.PermRead
+		}
+	}
+	if _, err := tui.executeViewCommand("set mem 0x20000 1"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+}
+
+// TestMemoryEditorKeys tests editing a byte from the keyboard and the rendered highlight
+func TestMemoryEditorKeys(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("failed to init simulation screen: %v", err)
+	}
+	defer screen.Fini()
+	tui := NewTUIWithScreen(NewDebugger(vm.NewVM()), screen)
+	tui.MemoryAddress = 0x20000
+	tui.UpdateMemoryView()
+
+	handler := tui.MemoryView.InputHandler()
+	press := func(key tcell.Key, r rune) {
+		handler(tcell.NewEventKey(key, r, tcell.ModNone), func(tview.Primitive) {})
+	}
+	press(tcell.KeyRune, 'e')
+	press(tcell.KeyRight, 0)
+	press(tcell.KeyRune, '4')
+	if b, _ := tui.Debugger.VM.Memory.ReadByteAt(0x20001); b != 0 {
+		t.Fatalf("expected no write after one digit, got 0x%02X", b)
+	}
+	press(tcell.KeyRune, '2')
+
+	if b, _ := tui.Debugger.VM.Memory.ReadByteAt(0x20001); b != 0x42 {
+		t.Fatalf("expected 0x42 at 0x20001, got 0x%02X", b)
+	}
+	if tui.MemoryCursor != 0x20002 {
+		t.Errorf("expected the cursor to advance to 0x20002, got 0x%08X", tui.MemoryCursor)
+	}
+
+	// Row 0 is drawn at y=2 (border, header); byte N starts at x = 1 + 10 + 3*N
+	tui.MemoryView.SetRect(0, 0, 70, 20)
+	tui.MemoryView.Draw(screen)
+	screen.Show()
+	cell := func(x, y int) (rune, tcell.Color, tcell.Color) {
+		r, _, style, _ := screen.GetContent(x, y)
+		fg, bg, _ := style.Decompose()
+		return r, fg, bg
+	}
+	if r, fg, _ := cell(14, 2); r != '4' || fg != tcell.ColorGreen {
+		t.Errorf("expected the edited byte drawn in green, got %q fg=%v", r, fg)
+	}
+	if _, _, bg := cell(17, 2); bg != tcell.ColorYellow {
+		t.Errorf("expected the cursor on the next byte, got bg=%v", bg)
+	}
+	if _, fg, _ := cell(11, 2); fg == tcell.ColorGreen {
+		t.Error("expected the untouched byte not to be highlighted")
+	}
+
+	// Word mode overwrites four bytes; Esc leaves edit mode
+	press(tcell.KeyRune, 'w')
+	for _, r := range "DEADBEEF" {
+		press(tcell.KeyRune, r)
+	}
+	if w, _ := tui.Debugger.VM.Memory.ReadWord(0x20000); w != 0xDEADBEEF {
+		t.Errorf("expected 0xDEADBEEF at the aligned cursor, got 0x%08X", w)
+	}
+	press(tcell.KeyEscape, 0)
+	if tui.MemoryEditing {
+		t.Error("expected Esc to leave edit mode")
+	}
+}
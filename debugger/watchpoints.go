@@ -2,16 +2,17 @@ package debugger
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // WatchType represents the type of watchpoint
-// NOTE: The current implementation can only detect value changes, not specific
-// read/write operations. All watchpoint types behave the same way - they trigger
-// when the monitored value differs from its previous value. True read-only or
-// write-only tracking would require integration with the VM's memory access layer.
+// NOTE: Register and single-address watchpoints can only detect value changes, not
+// specific read/write operations, so all types behave the same way for them - they
+// trigger when the monitored value differs from its previous value. Range watchpoints
+// are driven by the VM's memory access hook and do honour the type.
 type WatchType int
 
 const (
@@ -20,6 +21,13 @@ const (
 	WatchReadWrite                  // Trigger on read or write (value change detection)
 )
 
+// watchCommandNames maps each watchpoint type to the command that sets it
+var watchCommandNames = map[WatchType]string{
+	WatchWrite:     "watch",
+	WatchRead:      "rwatch",
+	WatchReadWrite: "awatch",
+}
+
 // Watchpoint represents a watchpoint for monitoring memory or register changes
 type Watchpoint struct {
 	ID         int
@@ -31,6 +39,79 @@ type Watchpoint struct {
 	Enabled    bool
 	LastValue  uint32 // Last known value
 	HitCount   int
+
+	// Range watchpoints cover Length bytes from Address and trigger on any access that
+	// overlaps them, as selected by Type. LastAccess describes the access that hit.
+	Length          uint32
+	LastAccess      uint32
+	LastAccessSize  uint32
+	LastAccessWrite bool
+
+	// Expression watchpoints re-evaluate Expression after every step (slower than
+	// register or address watchpoints) and track the value before the last change
+	IsExpression  bool
+	PreviousValue uint32
+	evaluate      func(machine *vm.VM) (uint32, error)
+}
+
+// WatchpointSummary is the JSON form of a watchpoint shared by "info watchpoints json" and
+// the API. Kind is "register", "memory", "range" or "expression"; Type is "read", "write"
+// or "readwrite", as accepted when adding a watchpoint through the API. Address is 0 for
+// register and expression watchpoints, and Length is 0 except for ranges.
+type WatchpointSummary struct {
+	ID         int    `json:"id"`
+	Expression string `json:"expression"`
+	Kind       string `json:"kind"`
+	Type       string `json:"type"`
+	Address    uint32 `json:"address"`
+	Length     uint32 `json:"length"`
+	Enabled    bool   `json:"enabled"`
+	HitCount   int    `json:"hitCount"`
+	LastValue  uint32 `json:"lastValue"`
+}
+
+// watchTypeNames are the Type strings of WatchpointSummary
+var watchTypeNames = map[WatchType]string{
+	WatchWrite:     "write",
+	WatchRead:      "read",
+	WatchReadWrite: "readwrite",
+}
+
+// kind names what the watchpoint monitors, for WatchpointSummary
+func (wp *Watchpoint) kind() string {
+	switch {
+	case wp.IsExpression:
+		return "expression"
+	case wp.IsRange():
+		return "range"
+	case wp.IsRegister:
+		return "register"
+	default:
+		return "memory"
+	}
+}
+
+// IsRange reports whether the watchpoint covers a memory range
+func (wp *Watchpoint) IsRange() bool {
+	return wp.Length > 0
+}
+
+// overlaps reports whether an access of size bytes at address touches the watched range
+func (wp *Watchpoint) overlaps(address, size uint32) bool {
+	start, end := uint64(wp.Address), uint64(wp.Address)+uint64(wp.Length)
+	return uint64(address) < end && start < uint64(address)+uint64(size)
+}
+
+// value reads the watched register, memory word or expression
+func (wp *Watchpoint) value(machine *vm.VM) (uint32, error) {
+	switch {
+	case wp.IsExpression:
+		return wp.evaluate(machine)
+	case wp.IsRegister:
+		return machine.CPU.GetRegister(wp.Register), nil
+	default:
+		return machine.Memory.ReadWord(wp.Address)
+	}
 }
 
 // WatchpointManager manages all watchpoints
@@ -38,6 +119,7 @@ type WatchpointManager struct {
 	mu          sync.RWMutex
 	watchpoints map[int]*Watchpoint
 	nextID      int
+	pendingHit  *Watchpoint // Range watchpoint hit by the last access, reported by CheckWatchpoints
 }
 
 // NewWatchpointManager creates a new watchpoint manager
@@ -71,6 +153,73 @@ func (wm *WatchpointManager) AddWatchpoint(wpType WatchType, expression string,
 	return wp
 }
 
+// AddExpressionWatchpoint adds a watchpoint on the value of an expression such as
+// "R1+R2" or "[R4]", computed by evaluate after every step
+func (wm *WatchpointManager) AddExpressionWatchpoint(expression string, evaluate func(machine *vm.VM) (uint32, error)) *Watchpoint {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	wp := &Watchpoint{
+		ID:           wm.nextID,
+		Type:         WatchWrite,
+		Expression:   expression,
+		Enabled:      true,
+		IsExpression: true,
+		evaluate:     evaluate,
+	}
+
+	wm.watchpoints[wp.ID] = wp
+	wm.nextID++
+
+	return wp
+}
+
+// AddRangeWatchpoint adds a watchpoint on the length bytes starting at address
+func (wm *WatchpointManager) AddRangeWatchpoint(wpType WatchType, expression string, address, length uint32) *Watchpoint {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	wp := &Watchpoint{
+		ID:         wm.nextID,
+		Type:       wpType,
+		Expression: expression,
+		Address:    address,
+		Length:     length,
+		Enabled:    true,
+	}
+
+	wm.watchpoints[wp.ID] = wp
+	wm.nextID++
+
+	return wp
+}
+
+// RecordAccess is the VM memory access hook for range watchpoints. Of the enabled range
+// watchpoints the access overlaps and whose type matches, the one with the lowest ID is
+// hit and reported by the next CheckWatchpoints.
+func (wm *WatchpointManager) RecordAccess(address, size uint32, write bool) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if wm.pendingHit != nil {
+		return
+	}
+	for _, wp := range wm.watchpoints {
+		if !wp.Enabled || !wp.IsRange() || !wp.overlaps(address, size) {
+			continue
+		}
+		if (write && wp.Type == WatchRead) || (!write && wp.Type == WatchWrite) {
+			continue
+		}
+		if wm.pendingHit == nil || wp.ID < wm.pendingHit.ID {
+			wm.pendingHit = wp
+		}
+	}
+	if wp := wm.pendingHit; wp != nil {
+		wp.LastAccess, wp.LastAccessSize, wp.LastAccessWrite = address, size, write
+	}
+}
+
 // DeleteWatchpoint removes a watchpoint by ID
 func (wm *WatchpointManager) DeleteWatchpoint(id int) error {
 	wm.mu.Lock()
@@ -80,6 +229,9 @@ func (wm *WatchpointManager) DeleteWatchpoint(id int) error {
 		return fmt.Errorf("watchpoint %d not found", id)
 	}
 
+	if wm.pendingHit != nil && wm.pendingHit.ID == id {
+		wm.pendingHit = nil
+	}
 	delete(wm.watchpoints, id)
 	return nil
 }
@@ -133,37 +285,62 @@ func (wm *WatchpointManager) GetAllWatchpoints() []*Watchpoint {
 	return result
 }
 
-// CheckWatchpoints checks all watchpoints and returns the first that has changed
-// NOTE: This implementation uses value change detection, not true read/write tracking.
-// The watchpoint Type field is currently not enforced - all types behave the same way,
-// triggering when the monitored value differs from its previous value.
+// Summaries returns a snapshot of every watchpoint, ordered by ID
+func (wm *WatchpointManager) Summaries() []WatchpointSummary {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	result := make([]WatchpointSummary, 0, len(wm.watchpoints))
+	for _, wp := range wm.watchpoints {
+		summary := WatchpointSummary{
+			ID:         wp.ID,
+			Expression: wp.Expression,
+			Kind:       wp.kind(),
+			Type:       watchTypeNames[wp.Type],
+			Length:     wp.Length,
+			Enabled:    wp.Enabled,
+			HitCount:   wp.HitCount,
+			LastValue:  wp.LastValue,
+		}
+		if !wp.IsRegister && !wp.IsExpression {
+			summary.Address = wp.Address
+		}
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// CheckWatchpoints checks all watchpoints and returns the first that has changed, or a
+// range watchpoint hit by an access since the last check
+// NOTE: Except for range watchpoints this uses value change detection, not true
+// read/write tracking. The watchpoint Type field is not enforced for them - all types
+// behave the same way, triggering when the monitored value differs from its previous value.
 func (wm *WatchpointManager) CheckWatchpoints(machine *vm.VM) (*Watchpoint, bool) {
 	wm.mu.Lock()
 	defer wm.mu.Unlock()
 
+	if wp := wm.pendingHit; wp != nil {
+		wm.pendingHit = nil
+		wp.HitCount++
+		return wp, true
+	}
+
 	for _, wp := range wm.watchpoints {
-		if !wp.Enabled {
+		if !wp.Enabled || wp.IsRange() {
 			continue
 		}
 
-		var currentValue uint32
-		var err error
-
-		if wp.IsRegister {
-			// Check register value
-			currentValue = machine.CPU.GetRegister(wp.Register)
-		} else {
-			// Check memory value
-			currentValue, err = machine.Memory.ReadWord(wp.Address)
-			if err != nil {
-				// Skip if memory read fails
-				continue
-			}
+		currentValue, err := wp.value(machine)
+		if err != nil {
+			// Skip if memory read or expression evaluation fails
+			continue
 		}
 
 		// Check if value has changed
 		if currentValue != wp.LastValue {
 			wp.HitCount++
+			wp.PreviousValue = wp.LastValue
 			wp.LastValue = currentValue
 			return wp, true
 		}
@@ -181,16 +358,15 @@ func (wm *WatchpointManager) InitializeWatchpoint(id int, machine *vm.VM) error
 	if !exists {
 		return fmt.Errorf("watchpoint %d not found", id)
 	}
+	if wp.IsRange() {
+		return nil
+	}
 
-	if wp.IsRegister {
-		wp.LastValue = machine.CPU.GetRegister(wp.Register)
-	} else {
-		value, err := machine.Memory.ReadWord(wp.Address)
-		if err != nil {
-			return fmt.Errorf("failed to initialize watchpoint: %w", err)
-		}
-		wp.LastValue = value
+	value, err := wp.value(machine)
+	if err != nil {
+		return fmt.Errorf("failed to initialize watchpoint: %w", err)
 	}
+	wp.LastValue = value
 
 	return nil
 }
@@ -201,6 +377,7 @@ func (wm *WatchpointManager) Clear() {
 	defer wm.mu.Unlock()
 
 	wm.watchpoints = make(map[int]*Watchpoint)
+	wm.pendingHit = nil
 }
 
 // Count returns the number of watchpoints
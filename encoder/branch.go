@@ -22,9 +22,8 @@ func (e *Encoder) encodeBranch(inst *parser.Instruction, cond uint32) (uint32, e
 		return e.encodeBX(inst, cond)
 	}
 	if mnemonic == "BLX" {
-		// Check if operand is a register (BLX Rm) or label (BLX label)
-		operand := strings.TrimSpace(inst.Operands[0])
-		if strings.HasPrefix(strings.ToUpper(operand), "R") {
+		// Check if operand is a register (BLX Rm, including LR/SP/IP) or label (BLX label)
+		if _, err := e.parseRegister(inst.Operands[0]); err == nil {
 			return e.encodeBLX(inst, cond)
 		}
 		// Otherwise fall through to handle as branch with link
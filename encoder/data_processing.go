@@ -331,9 +331,9 @@ func (e *Encoder) encodeADR(inst *parser.Instruction, cond uint32) (uint32, erro
 		return 0, err
 	}
 
-	// Get target address from label
+	// Get target address from label (or label expression such as table+8)
 	labelStr := strings.TrimSpace(inst.Operands[1])
-	targetAddr, err := e.symbolTable.Get(labelStr)
+	targetAddr, err := e.evaluateExpression(labelStr)
 	if err != nil {
 		return 0, fmt.Errorf("ADR: label %s not found: %w", labelStr, err)
 	}
@@ -357,7 +357,7 @@ func (e *Encoder) encodeADR(inst *parser.Instruction, cond uint32) (uint32, erro
 	// Check if offset can be encoded as ARM immediate
 	rotated, ok := e.encodeImmediate(absOffset)
 	if !ok {
-		return 0, fmt.Errorf("ADR: offset %d cannot be encoded as ARM immediate", offset)
+		return 0, fmt.Errorf("ADR: offset %d from PC to %s cannot be encoded as a rotated 8-bit immediate (use LDR Rd, =%s instead)", offset, labelStr, labelStr)
 	}
 
 	// Encode as: ADD/SUB Rd, PC, #offset
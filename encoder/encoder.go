@@ -14,12 +14,18 @@ import (
 type Encoder struct {
 	symbolTable       *parser.SymbolTable
 	currentAddr       uint32
-	LiteralPool       map[uint32]uint32 // address -> value for literal pool (exported)
-	LiteralPoolStart  uint32            // Start address for literal pool (set externally)
-	LiteralPoolLocs   []uint32          // Addresses of .ltorg directives (multiple pools)
-	LiteralPoolCounts []int             // Expected literal counts for each pool (from parser)
-	pendingLiterals   map[uint32]uint32 // value -> preferred address mapping for dedup
-	PoolWarnings      []string          // Warnings about pool capacity issues
+	LiteralPool       map[uint32]uint32            // address -> value for literal pool (exported)
+	LiteralPoolStart  uint32                       // Start address for literal pool (set externally)
+	LiteralPoolLocs   []uint32                     // Addresses of .ltorg directives and automatic pools (multiple pools)
+	LiteralPoolCounts []int                        // Expected literal counts for each pool (from parser)
+	poolEntries       map[uint32]map[uint32]uint32 // pool start -> value -> literal address (dedup within a pool)
+	poolFill          map[uint32]int               // pool start -> number of literals placed
+	PoolWarnings      []string                     // Warnings about pool capacity issues
+
+	// Optimize enables the -O1 peephole: no-op arithmetic such as ADD Rd, Rd, #0 becomes NOP,
+	// and the LDR Rd, =const to MOV/MVN fold (always applied) is reported in Optimizations
+	Optimize      bool
+	Optimizations []string // Rewrites made by the peephole, one line each
 }
 
 // NewEncoder creates a new encoder instance
@@ -29,7 +35,8 @@ func NewEncoder(symbolTable *parser.SymbolTable) *Encoder {
 		LiteralPool:       make(map[uint32]uint32),
 		LiteralPoolLocs:   make([]uint32, 0),
 		LiteralPoolCounts: make([]int, 0),
-		pendingLiterals:   make(map[uint32]uint32),
+		poolEntries:       make(map[uint32]map[uint32]uint32),
+		poolFill:          make(map[uint32]int),
 		PoolWarnings:      make([]string, 0),
 	}
 }
@@ -44,6 +51,16 @@ func (e *Encoder) EncodeInstruction(inst *parser.Instruction, address uint32) (u
 
 	mnemonic := strings.ToUpper(inst.Mnemonic)
 
+	// The parser rejects misplaced suffixes, but instructions can also be built directly
+	if inst.SetFlags && !parser.AcceptsSetFlags(mnemonic) {
+		return 0, NewEncodingError(inst, fmt.Sprintf("%s does not accept the S suffix", mnemonic))
+	}
+
+	if e.Optimize && e.isNoOp(inst, mnemonic) {
+		e.noteOptimization(inst, "NOP")
+		return e.encodeNOP(uint32(vm.CondAL)), nil
+	}
+
 	// Route to appropriate encoder based on instruction type
 	var encoded uint32
 	var err error
@@ -71,6 +88,18 @@ func (e *Encoder) EncodeInstruction(inst *parser.Instruction, address uint32) (u
 	case "MUL", "MLA":
 		encoded, err = e.encodeMultiply(inst, cond)
 
+	// Saturating arithmetic
+	case "QADD", "QSUB":
+		encoded, err = e.encodeSaturating(inst, cond)
+
+	// Division
+	case "SDIV", "UDIV":
+		encoded, err = e.encodeDivide(inst, cond)
+
+	// PSR transfer
+	case "MRS", "MSR":
+		encoded, err = e.encodePSRTransfer(inst, cond)
+
 	// Load/Store multiple
 	case "LDM", "STM", "LDMIA", "LDMIB", "LDMDA", "LDMDB":
 		encoded, err = e.encodeLoadStoreMultiple(inst, cond, false)
@@ -92,6 +121,10 @@ func (e *Encoder) EncodeInstruction(inst *parser.Instruction, address uint32) (u
 	case "SWI", "SVC": // SVC is ARM7+ name for SWI
 		encoded, err = e.encodeSWI(inst, cond)
 
+	// Software breakpoint
+	case "BKPT":
+		encoded, err = e.encodeBKPT(inst)
+
 	// ADR pseudo-instruction
 	case "ADR":
 		encoded, err = e.encodeADR(inst, cond)
@@ -208,6 +241,15 @@ func (e *Encoder) parseImmediate(imm string) (uint32, error) {
 		return uint32(charLiteral[0]), nil
 	}
 
+	// Constant expressions such as (1<<4) or label_end-label_start
+	if parser.IsExpression(imm) {
+		value, err := parser.EvaluateExpression(imm, e.lookupSymbol)
+		if err != nil {
+			return 0, fmt.Errorf("invalid immediate expression %s: %w", imm, err)
+		}
+		return value, nil
+	}
+
 	// Handle negative numbers
 	negative := false
 	if strings.HasPrefix(imm, "-") {
@@ -253,6 +295,14 @@ func (e *Encoder) parseImmediate(imm string) (uint32, error) {
 	return result, nil
 }
 
+// lookupSymbol resolves a defined symbol for expression evaluation
+func (e *Encoder) lookupSymbol(name string) (uint32, error) {
+	if sym, exists := e.symbolTable.Lookup(name); exists && sym.Defined {
+		return sym.Value, nil
+	}
+	return 0, fmt.Errorf("undefined symbol: %q", name)
+}
+
 // encodeImmediate encodes an 8-bit immediate value with 4-bit rotation
 // Returns encoded value and success flag
 func (e *Encoder) encodeImmediate(value uint32) (uint32, bool) {
@@ -327,65 +377,34 @@ func (e *Encoder) parseShift(shift string) (shiftType, shiftAmount uint32, shift
 	}
 }
 
-// evaluateExpression evaluates a constant expression with support for multiple operators.
-// Examples: "label+12", "symbol-4", "buffer+255-17"
-// Uses right-to-left scanning to maintain correct left-to-right evaluation order.
+// evaluateExpression evaluates a constant expression such as "label+12" or "table+4*8".
 // Returns the evaluated value or an error if the expression is invalid
 func (e *Encoder) evaluateExpression(expr string) (uint32, error) {
 	expr = strings.TrimSpace(expr)
-
-	// Scan right-to-left to find the last + or - operator
-	// (skip position 0 to allow for potential leading minus sign)
-	// This ensures left-to-right evaluation when we recurse on the left side
-	lastOpPos := -1
-	lastOp := byte(0)
-
-	for i := len(expr) - 1; i >= 1; i-- {
-		if expr[i] == '+' || expr[i] == '-' {
-			lastOpPos = i
-			lastOp = expr[i]
-			break
-		}
-	}
-
-	if lastOpPos > 0 {
-		left := strings.TrimSpace(expr[:lastOpPos])
-		right := strings.TrimSpace(expr[lastOpPos+1:])
-
-		// Recursively evaluate left side (may contain more operators)
-		leftVal, err := e.evaluateExpression(left)
-		if err != nil {
-			return 0, err
-		}
-
-		// Evaluate right side as a single term
-		rightVal, err := e.evaluateTerm(right)
-		if err != nil {
-			return 0, err
-		}
-
-		// Perform operation
-		if lastOp == '+' {
-			return leftVal + rightVal, nil
-		}
-		return leftVal - rightVal, nil
+	if parser.IsExpression(expr) {
+		return parser.EvaluateExpression(expr, e.lookupSymbol)
 	}
-
-	// No operator found, evaluate as single term
 	return e.evaluateTerm(expr)
-} // evaluateTerm evaluates a single term (symbol or number)
+}
+
+// evaluateTerm evaluates a single term (symbol or number)
 func (e *Encoder) evaluateTerm(term string) (uint32, error) {
 	term = strings.TrimSpace(term)
 
-	// Try to resolve as symbol first
-	if sym, exists := e.symbolTable.Lookup(term); exists && sym.Defined {
-		return sym.Value, nil
+	// A term that starts like a name is a symbol reference, never a number
+	if term != "" && isSymbolStart(term[0]) {
+		return e.lookupSymbol(term)
 	}
 
 	// Otherwise parse as immediate number
 	return e.parseImmediate(term)
 }
 
+// isSymbolStart reports whether ch can begin a label or constant name
+func isSymbolStart(ch byte) bool {
+	return ch == '_' || ch == '.' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
 // ValidatePoolCapacity checks if actual literal pool usage matches expected capacity
 // This method should be called after encoding all instructions
 func (e *Encoder) ValidatePoolCapacity() {
@@ -393,28 +412,6 @@ func (e *Encoder) ValidatePoolCapacity() {
 		return
 	}
 
-	// Count actual literals in each pool region
-	actualCounts := make(map[uint32]int) // pool location -> count of literals in that region
-
-	for addr := range e.LiteralPool {
-		// Find which pool this literal belongs to
-		for i, poolLoc := range e.LiteralPoolLocs {
-			if i+1 < len(e.LiteralPoolLocs) {
-				// Check if literal is between this pool and the next
-				if addr >= poolLoc && addr < e.LiteralPoolLocs[i+1] {
-					actualCounts[poolLoc]++
-					break
-				}
-			} else {
-				// Last pool - all remaining literals belong to it
-				if addr >= poolLoc {
-					actualCounts[poolLoc]++
-					break
-				}
-			}
-		}
-	}
-
 	// Check each pool against expected capacity
 	for i, poolLoc := range e.LiteralPoolLocs {
 		expectedCount := parser.EstimatedLiteralsPerPool
@@ -422,7 +419,7 @@ func (e *Encoder) ValidatePoolCapacity() {
 			expectedCount = e.LiteralPoolCounts[i]
 		}
 
-		actualCount := actualCounts[poolLoc]
+		actualCount := e.poolFill[poolLoc]
 
 		// Warn if actual count exceeds expected
 		if actualCount > expectedCount {
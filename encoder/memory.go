@@ -25,7 +25,10 @@ func (e *Encoder) encodeMemory(inst *parser.Instruction, cond uint32) (uint32, e
 
 	// Check for pseudo-instruction: LDR Rd, =value or =label
 	// The parser might give us "=" and "label" as separate operands or "=label" as one
-	if strings.HasPrefix(inst.Operands[1], "=") {
+	if strings.HasPrefix(inst.Operands[1], "=") && mnemonic != "LDR" {
+		return 0, fmt.Errorf("%s cannot take a literal operand %s (only LDR Rd, =value is supported)", mnemonic, inst.Operands[1])
+	}
+	if strings.HasPrefix(inst.Operands[1], "=") && inst.Operands[1] != "=" {
 		return e.encodeLDRPseudo(inst, cond, rd)
 	}
 
@@ -130,6 +133,9 @@ func (e *Encoder) encodeAddressingMode(cond, lBit, bBit, rd uint32, addrMode str
 		if strings.HasPrefix(offsetStr, "-") {
 			uBit = 0 // Subtract
 			offsetStr = strings.TrimPrefix(offsetStr, "-")
+		} else if strings.HasPrefix(offsetStr, "#-") {
+			uBit = 0 // Subtract (#-imm form)
+			offsetStr = "#" + strings.TrimPrefix(offsetStr, "#-")
 		} else {
 			offsetStr = strings.TrimPrefix(offsetStr, "+") // Remove optional +
 		}
@@ -205,6 +211,9 @@ func (e *Encoder) encodeLDRPseudo(inst *parser.Instruction, cond, rd uint32) (ui
 	// Try to encode as MOV Rd, #value if it fits
 	if encoded, ok := e.encodeImmediate(value); ok {
 		// Can use MOV
+		if e.Optimize {
+			e.noteOptimization(inst, fmt.Sprintf("MOV R%d, #0x%X", rd, value))
+		}
 		instruction := (cond << ConditionShift) | (1 << TypeShift25) | (opMOV << OpcodeShift) | (rd << RdShift) | encoded
 		return instruction, nil
 	}
@@ -212,49 +221,39 @@ func (e *Encoder) encodeLDRPseudo(inst *parser.Instruction, cond, rd uint32) (ui
 	// Try MVN (move not) if ~value fits
 	if encoded, ok := e.encodeImmediate(^value); ok {
 		// Can use MVN
+		if e.Optimize {
+			e.noteOptimization(inst, fmt.Sprintf("MVN R%d, #0x%X", rd, ^value))
+		}
 		instruction := (cond << ConditionShift) | (1 << TypeShift25) | (opMVN << OpcodeShift) | (rd << RdShift) | encoded
 		return instruction, nil
 	}
 
 	// Need to use literal pool - generate PC-relative LDR
-	// Check if this value already exists in the literal pool (deduplication)
-	var literalAddr uint32
-	var found bool
-	for addr, val := range e.LiteralPool {
-		if val == value {
-			literalAddr = addr
-			found = true
-			break
-		}
-	}
+	// Literals go in the first .ltorg pool after this instruction, or the pool at the end
+	// of the program if no .ltorg follows. Each pool starts fresh, so a value already
+	// emitted in an earlier pool is placed again rather than referenced backwards.
+	pool := e.literalPoolFor(e.currentAddr)
+	literalAddr, found := e.poolEntries[pool][value]
 
 	if !found {
-		// Find the nearest literal pool location that's within ±MaxOffset12Bit bytes
-		pc := e.currentAddr + vm.ARMPipelineOffset // PC = current instruction + pipeline offset
-		literalAddr = e.findNearestLiteralPoolLocation(pc, value)
-
-		if literalAddr == 0 {
-			// No suitable pool found - this shouldn't happen if .ltorg is properly placed
-			// Fall back to old behavior
-			if e.LiteralPoolStart > 0 {
-				poolSize, err := vm.SafeIntToUint32(len(e.LiteralPool) * WordSize)
-				if err != nil {
-					return 0, fmt.Errorf("literal pool too large: %v", err)
-				}
-				literalAddr = e.LiteralPoolStart + poolSize
-			} else {
-				poolSize, err := vm.SafeIntToUint32(len(e.LiteralPool) * WordSize)
-				if err != nil {
-					return 0, fmt.Errorf("literal pool too large: %v", err)
-				}
-				literalOffset := LiteralPoolOffset + poolSize
-				literalAddr = (e.currentAddr & LiteralPoolAlignmentMask) + literalOffset
-			}
+		poolSize, err := vm.SafeIntToUint32(e.poolFill[pool] * WordSize)
+		if err != nil {
+			return 0, fmt.Errorf("literal pool too large: %v", err)
+		}
+		if pool != 0 {
+			literalAddr = pool + poolSize
+		} else {
+			// No pool location known - fall back to the next 4KB boundary
+			literalAddr = (e.currentAddr & LiteralPoolAlignmentMask) + LiteralPoolOffset + poolSize
 		}
 
 		// Store value in literal pool
 		e.LiteralPool[literalAddr] = value
-		e.pendingLiterals[value] = literalAddr
+		if e.poolEntries[pool] == nil {
+			e.poolEntries[pool] = make(map[uint32]uint32)
+		}
+		e.poolEntries[pool][value] = literalAddr
+		e.poolFill[pool]++
 	}
 
 	// Calculate PC-relative offset
@@ -272,7 +271,8 @@ func (e *Encoder) encodeLDRPseudo(inst *parser.Instruction, cond, rd uint32) (ui
 		absOffset = -absOffset
 	}
 	if absOffset > MaxOffset12Bit {
-		return 0, fmt.Errorf("literal pool offset too large: %d bytes (max %d) - literal at 0x%08X, PC=0x%08X", absOffset, MaxOffset12Bit, literalAddr, pc)
+		return 0, fmt.Errorf("literal pool offset too large: %d bytes (max %d) - literal at 0x%08X, PC=0x%08X; "+
+			"place a .ltorg after an unconditional branch within 4KB of this load", absOffset, MaxOffset12Bit, literalAddr, pc)
 	}
 
 	if offset < 0 {
@@ -350,10 +350,10 @@ func (e *Encoder) encodeMemoryHalfword(inst *parser.Instruction, cond, rd, lBit
 
 		offsetStr := strings.TrimSpace(parts[1])
 		if strings.HasPrefix(offsetStr, "#") || isNumeric(offsetStr) {
-			// Check if offset is negative
-			if strings.HasPrefix(offsetStr, "-") {
+			// Check if offset is negative (-imm or #-imm)
+			if strings.HasPrefix(offsetStr, "-") || strings.HasPrefix(offsetStr, "#-") {
 				uBit = 0 // Subtract
-				offsetStr = strings.TrimPrefix(offsetStr, "-")
+				offsetStr = strings.Replace(offsetStr, "-", "", 1)
 			}
 			offsetVal, err := e.parseImmediate(offsetStr)
 			if err != nil {
@@ -382,10 +382,10 @@ func (e *Encoder) encodeMemoryHalfword(inst *parser.Instruction, cond, rd, lBit
 		if len(parts) > 1 {
 			offsetStr := strings.TrimSpace(parts[1])
 			if strings.HasPrefix(offsetStr, "#") || isNumeric(offsetStr) {
-				// Check if offset is negative
-				if strings.HasPrefix(offsetStr, "-") {
+				// Check if offset is negative (-imm or #-imm)
+				if strings.HasPrefix(offsetStr, "-") || strings.HasPrefix(offsetStr, "#-") {
 					uBit = 0 // Subtract
-					offsetStr = strings.TrimPrefix(offsetStr, "-")
+					offsetStr = strings.Replace(offsetStr, "-", "", 1)
 				}
 				offsetVal, err := e.parseImmediate(offsetStr)
 				if err != nil {
@@ -437,6 +437,7 @@ func (e *Encoder) encodeMemoryHalfword(inst *parser.Instruction, cond, rd, lBit
 			(hBit << HalfwordHBitShift) |
 			(sBit << HalfwordSBitShift) |
 			(1 << HalfwordBit7) | // Always 1 for halfword
+			(1 << Bit4) | // Always 1 for halfword
 			offset // Rm in lower 4 bits
 	} else {
 		// Immediate offset: split into high (bits[11:8]) and low (bits[3:0])
@@ -461,6 +462,7 @@ func (e *Encoder) encodeMemoryHalfword(inst *parser.Instruction, cond, rd, lBit
 			(offsetHigh << RsShift) |
 			(1 << HalfwordBit7) | // Always 1 for halfword misc
 			(hBit << HalfwordHBitShift) |
+			(1 << Bit4) | // Always 1 for halfword misc
 			(sBit << HalfwordSBitShift) |
 			offsetLow
 	}
@@ -468,85 +470,14 @@ func (e *Encoder) encodeMemoryHalfword(inst *parser.Instruction, cond, rd, lBit
 	return opcode, nil
 }
 
-// findNearestLiteralPoolLocation finds the nearest literal pool location within ±MaxOffset12Bit bytes
-// Returns 0 if no suitable location is found
-func (e *Encoder) findNearestLiteralPoolLocation(pc uint32, value uint32) uint32 {
-	// If no .ltorg directives specified, return 0 to use fallback behavior
-	if len(e.LiteralPoolLocs) == 0 {
-		return 0
-	}
-
-	// Check if this value already has a pending location
-	if addr, ok := e.pendingLiterals[value]; ok {
-		// Verify it's still within range
-		if addr > pc {
-			if addr-pc > MaxOffset12Bit {
-				// Out of range, need to find a new location
-				delete(e.pendingLiterals, value)
-			} else {
-				return addr
-			}
-		} else {
-			if pc-addr > MaxOffset12Bit {
-				// Out of range, need to find a new location
-				delete(e.pendingLiterals, value)
-			} else {
-				return addr
-			}
-		}
-	}
-
-	// Find nearest pool location within ±MaxOffset12Bit bytes
-	var bestAddr uint32
-	var bestDistance uint32 = vm.Address32BitMax
-
+// literalPoolFor returns the start address of the pool that serves an instruction at addr:
+// the first .ltorg location after it, otherwise the end-of-program pool (LiteralPoolStart).
+// Returns 0 if neither is known.
+func (e *Encoder) literalPoolFor(addr uint32) uint32 {
 	for _, poolLoc := range e.LiteralPoolLocs {
-		var distance uint32
-		if poolLoc >= pc {
-			// Pool is at or after PC - forward reference
-			distance = poolLoc - pc
-			if distance <= MaxOffset12Bit && distance < bestDistance {
-				// Count how many literals are already assigned to this pool
-				literalsAtPool := e.countLiteralsAtPool(poolLoc)
-				// Calculate where this literal would go
-				// #nosec G115 -- literalsAtPool is bounded by pool capacity, safe conversion
-				candidateAddr := poolLoc + uint32(literalsAtPool)*WordSize
-				// Check if it's still within range from PC
-				if candidateAddr >= pc && candidateAddr-pc <= MaxOffset12Bit {
-					bestAddr = candidateAddr
-					bestDistance = distance
-				}
-			}
-		} else {
-			// Pool is before PC - backward reference
-			distance = pc - poolLoc
-			if distance <= MaxOffset12Bit && distance < bestDistance {
-				// For backward references, we need to be more careful
-				// Count existing literals
-				literalsAtPool := e.countLiteralsAtPool(poolLoc)
-				// #nosec G115 -- literalsAtPool is bounded by pool capacity, safe conversion
-				candidateAddr := poolLoc + uint32(literalsAtPool)*WordSize
-				// Check distance from PC to candidate address
-				if candidateAddr <= pc && pc-candidateAddr <= MaxOffset12Bit {
-					bestAddr = candidateAddr
-					bestDistance = distance
-				}
-			}
-		}
-	}
-
-	return bestAddr
-}
-
-// countLiteralsAtPool counts how many literals are already assigned to start at or near a pool location
-func (e *Encoder) countLiteralsAtPool(poolLoc uint32) int {
-	count := 0
-	// Check all assigned literals to see how many are in this pool region
-	// Literals within N bytes of the pool location are considered part of the same pool
-	for addr := range e.LiteralPool {
-		if addr >= poolLoc && addr < poolLoc+parser.LiteralPoolRangeBytes {
-			count++
+		if poolLoc > addr {
+			return poolLoc
 		}
 	}
-	return count
+	return e.LiteralPoolStart
 }
@@ -0,0 +1,42 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// noOpImmediateMnemonics are the data processing instructions for which Rd, Rd, #0 leaves
+// Rd unchanged
+var noOpImmediateMnemonics = map[string]bool{
+	"ADD": true, "SUB": true, "ORR": true, "EOR": true, "BIC": true,
+}
+
+// isNoOp reports whether inst is an ADD/SUB/ORR/EOR/BIC of #0 into its own source
+// register. These are only no-ops without the S bit, and never for PC, where the write
+// acts as a branch.
+func (e *Encoder) isNoOp(inst *parser.Instruction, mnemonic string) bool {
+	if !noOpImmediateMnemonics[mnemonic] || inst.SetFlags || len(inst.Operands) != 3 {
+		return false
+	}
+	rd, err := e.parseRegister(inst.Operands[0])
+	if err != nil || rd == 15 {
+		return false
+	}
+	if rn, err := e.parseRegister(inst.Operands[1]); err != nil || rn != rd {
+		return false
+	}
+	if !strings.HasPrefix(strings.TrimSpace(inst.Operands[2]), "#") {
+		return false
+	}
+	value, err := e.parseImmediate(inst.Operands[2])
+	return err == nil && value == 0
+}
+
+// noteOptimization records a rewrite made by the -O1 peephole for verbose reporting
+func (e *Encoder) noteOptimization(inst *parser.Instruction, replacement string) {
+	mnemonic := strings.ToUpper(inst.Mnemonic + inst.Condition)
+	e.Optimizations = append(e.Optimizations, fmt.Sprintf("0x%08X: %s %s -> %s",
+		e.currentAddr, mnemonic, strings.Join(inst.Operands, ", "), replacement))
+}
@@ -85,6 +85,177 @@ func (e *Encoder) encodeMultiply(inst *parser.Instruction, cond uint32) (uint32,
 	return 0, fmt.Errorf("unknown multiply instruction: %s", mnemonic)
 }
 
+// encodeSaturating encodes QADD and QSUB instructions
+func (e *Encoder) encodeSaturating(inst *parser.Instruction, cond uint32) (uint32, error) {
+	mnemonic := strings.ToUpper(inst.Mnemonic)
+
+	if len(inst.Operands) < 3 {
+		return 0, fmt.Errorf("%s requires 3 operands, got %d", mnemonic, len(inst.Operands))
+	}
+	if inst.SetFlags {
+		return 0, fmt.Errorf("%s does not support the S suffix", mnemonic)
+	}
+
+	rd, err := e.parseRegister(inst.Operands[0])
+	if err != nil {
+		return 0, err
+	}
+
+	rm, err := e.parseRegister(inst.Operands[1])
+	if err != nil {
+		return 0, err
+	}
+
+	rn, err := e.parseRegister(inst.Operands[2])
+	if err != nil {
+		return 0, err
+	}
+
+	pattern := uint32(vm.QADDPattern)
+	if mnemonic == "QSUB" {
+		pattern = vm.QSUBPattern
+	}
+
+	// Format: cccc 0001 0op0 nnnn dddd 0000 0101 mmmm
+	return (cond << ConditionShift) | pattern | (rn << RnShift) | (rd << RdShift) | rm, nil
+}
+
+// encodePSRTransfer encodes MRS Rd, PSR and MSR PSR_fields, Rm|#imm, where PSR is CPSR or
+// SPSR. MSR fields are any of c, x, s and f (flg is accepted for f and all for fc); a bare
+// CPSR or SPSR means fc, as in GNU as.
+func (e *Encoder) encodePSRTransfer(inst *parser.Instruction, cond uint32) (uint32, error) {
+	mnemonic := strings.ToUpper(inst.Mnemonic)
+	if len(inst.Operands) != 2 {
+		return 0, fmt.Errorf("%s requires 2 operands, got %d", mnemonic, len(inst.Operands))
+	}
+
+	if mnemonic == "MRS" {
+		rd, err := e.parseRegister(inst.Operands[0])
+		if err != nil {
+			return 0, err
+		}
+		if rd == RegisterPC {
+			return 0, fmt.Errorf("MRS cannot use PC as the destination")
+		}
+		spsr, fields, err := parsePSR(inst.Operands[1])
+		if err != nil {
+			return 0, err
+		}
+		if fields != "" {
+			return 0, fmt.Errorf("MRS reads the whole PSR; remove the _%s field suffix", fields)
+		}
+
+		// Format: cccc 0001 0R00 1111 dddd 0000 0000 0000
+		return (cond << ConditionShift) | vm.MRSPattern | (spsr << vm.PSRSPSRBit) | (rd << RdShift), nil
+	}
+
+	spsr, fields, err := parsePSR(inst.Operands[0])
+	if err != nil {
+		return 0, err
+	}
+	mask, err := psrFieldMask(fields)
+	if err != nil {
+		return 0, err
+	}
+	instruction := (cond << ConditionShift) | (spsr << vm.PSRSPSRBit) | (mask << vm.PSRFieldShift) | vm.PSRFieldSBO
+
+	source := strings.TrimSpace(inst.Operands[1])
+	if strings.HasPrefix(source, "#") {
+		value, err := e.parseImmediate(source)
+		if err != nil {
+			return 0, err
+		}
+		encoded, ok := e.encodeImmediate(value)
+		if !ok {
+			return 0, fmt.Errorf("immediate value 0x%08X cannot be encoded as a rotated 8-bit value", value)
+		}
+		// Format: cccc 0011 0R10 ffff 1111 rrrr iiii iiii
+		return instruction | vm.MSRImmPattern | encoded, nil
+	}
+
+	rm, err := e.parseRegister(source)
+	if err != nil {
+		return 0, err
+	}
+	if rm == RegisterPC {
+		return 0, fmt.Errorf("MSR cannot use PC as the source")
+	}
+	// Format: cccc 0001 0R10 ffff 1111 0000 0000 mmmm
+	return instruction | vm.MSRRegPattern | rm, nil
+}
+
+// parsePSR splits a PSR operand such as CPSR or SPSR_f into the SPSR bit and its field suffix
+func parsePSR(operand string) (spsr uint32, fields string, err error) {
+	name, fields, _ := strings.Cut(strings.TrimSpace(operand), "_")
+	switch strings.ToUpper(name) {
+	case "CPSR":
+		return 0, fields, nil
+	case "SPSR":
+		return 1, fields, nil
+	}
+	return 0, "", fmt.Errorf("expected CPSR or SPSR, got %q", operand)
+}
+
+// psrFieldMask converts an MSR field suffix into the 4-bit field mask in bits 19-16
+func psrFieldMask(fields string) (uint32, error) {
+	switch strings.ToLower(fields) {
+	case "":
+		return vm.PSRFieldFlags | 1, nil // fc
+	case "flg":
+		return vm.PSRFieldFlags, nil
+	case "all":
+		return vm.PSRFieldFlags | 1, nil
+	}
+
+	var mask uint32
+	for _, field := range strings.ToLower(fields) {
+		bit := strings.IndexRune("cxsf", field)
+		if bit < 0 {
+			return 0, fmt.Errorf("invalid PSR field %q (use c, x, s or f)", field)
+		}
+		if mask&(1<<bit) != 0 {
+			return 0, fmt.Errorf("PSR field %q given twice", field)
+		}
+		mask |= 1 << bit
+	}
+	return mask, nil
+}
+
+// encodeDivide encodes SDIV and UDIV instructions
+func (e *Encoder) encodeDivide(inst *parser.Instruction, cond uint32) (uint32, error) {
+	mnemonic := strings.ToUpper(inst.Mnemonic)
+
+	if len(inst.Operands) < 3 {
+		return 0, fmt.Errorf("%s requires 3 operands, got %d", mnemonic, len(inst.Operands))
+	}
+	if inst.SetFlags {
+		return 0, fmt.Errorf("%s does not support the S suffix", mnemonic)
+	}
+
+	rd, err := e.parseRegister(inst.Operands[0])
+	if err != nil {
+		return 0, err
+	}
+
+	rn, err := e.parseRegister(inst.Operands[1])
+	if err != nil {
+		return 0, err
+	}
+
+	rm, err := e.parseRegister(inst.Operands[2])
+	if err != nil {
+		return 0, err
+	}
+
+	pattern := uint32(vm.SDIVPattern)
+	if mnemonic == "UDIV" {
+		pattern = vm.UDIVPattern
+	}
+
+	// Format: cccc 0111 0u11 dddd 1111 mmmm 0001 nnnn
+	return (cond << ConditionShift) | pattern | (rd << RnShift) | (rm << RsShift) | rn, nil
+}
+
 // encodeLoadStoreMultiple encodes LDM/STM instructions
 func (e *Encoder) encodeLoadStoreMultiple(inst *parser.Instruction, cond uint32, isStore bool) (uint32, error) {
 	if len(inst.Operands) < 2 {
@@ -297,3 +468,37 @@ func (e *Encoder) encodeSWI(inst *parser.Instruction, cond uint32) (uint32, erro
 
 	return instruction, nil
 }
+
+// encodeBKPT encodes a software breakpoint instruction
+// ARM BKPT instruction format (32 bits):
+//
+//	1110 0001 0010 iiii iiii iiii 0111 iiii
+//
+// The 16-bit immediate is split: bits 15-4 go in bits 19-8, bits 3-0 in bits 3-0.
+// BKPT is unconditional, so a condition suffix is rejected.
+func (e *Encoder) encodeBKPT(inst *parser.Instruction) (uint32, error) {
+	if inst.Condition != "" && strings.ToUpper(inst.Condition) != "AL" {
+		return 0, fmt.Errorf("BKPT cannot be conditional")
+	}
+
+	imm := uint32(0)
+	if len(inst.Operands) > 1 {
+		return 0, fmt.Errorf("BKPT requires at most 1 operand, got %d", len(inst.Operands))
+	}
+	if len(inst.Operands) == 1 {
+		var err error
+		imm, err = e.parseImmediate(inst.Operands[0])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if imm > vm.Mask16Bit {
+		return 0, fmt.Errorf("BKPT immediate too large: 0x%X (max 0x%X)", imm, vm.Mask16Bit)
+	}
+
+	instruction := (uint32(vm.CondAL) << ConditionShift) | vm.BKPTPattern |
+		((imm >> 4) << 8) | (imm & vm.Mask4Bit)
+
+	return instruction, nil
+}
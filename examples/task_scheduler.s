@@ -12,7 +12,8 @@
 ; then prints summaries and exits. Context = R0-R12, LR, CPSR (simplified; CPSR ignored).
 ;
 ; Layout:
-;   task_stacks: individual downward-growing stacks
+;   task stacks: individual downward-growing stacks at the bottom of the stack
+;                segment, so SP stays inside it and the stack guard can stay on
 ;   tcb array: {sp, entry, state}
 ;
 ; NOTE: This stresses multiple register save/restore patterns and indirect branches.
@@ -41,17 +42,17 @@ _start:
 init_tasks:
         STMFD   SP!, {R4-R8, LR}
         LDR     R4, =tcb
-        LDR     R5, =task_stacks
+        LDR     R5, =TASK_STACKS
         MOV     R6, #0
 init_loop:
         CMP     R6, #NUM_TASKS
         BGE     init_done
-        ; Calculate: task_stacks + (task_index + 1) * STACK_SIZE
+        ; Calculate: TASK_STACKS + (task_index + 1) * STACK_SIZE
         ; Each task's SP points to the top of its allocated stack region
         ADD     R7, R6, #1           ; task_index + 1
         LDR     R8, =STACK_SIZE
         MUL     R7, R8, R7           ; STACK_SIZE * (task_index + 1)
-        ADD     R7, R5, R7           ; task_stacks + offset = top of this task's stack
+        ADD     R7, R5, R7           ; TASK_STACKS + offset = top of this task's stack
         ; Write initial SP
         STR     R7, [R4]          ; tcb[i].sp
         ; Entry function pointer
@@ -211,17 +212,12 @@ print_summaries:
 
 .equ STACK_SIZE, 128
 .equ TCB_SIZE, 36          ; NUM_TASKS * 12 = 3 * 12
-.equ STACKS_SIZE, 384      ; NUM_TASKS * STACK_SIZE = 3 * 128
+.equ TASK_STACKS, 0x00040000 ; Bottom of the stack segment, far below the scheduler's SP
         .align 4
 
 ; TCB array: NUM_TASKS * 12 bytes (sp, entry, state)
 tcb:    .space  TCB_SIZE
 
-; Stacks (simple blob). Provide contiguous region.
-        .align 4
- task_stacks:
-        .space  STACKS_SIZE
-
 ; Task private data
         .align 4
  t0_char:       .byte   'A'
@@ -1,5 +1,5 @@
 ; test_get_arguments.s - Demonstrates GET_ARGUMENTS syscall (0x32)
-; Shows: Retrieving program argument count
+; Shows: Retrieving program argument count (pass arguments with -args "a b")
 
         .org    0x8000          ; Program starts at address 0x8000
 
@@ -11,7 +11,7 @@ _start:
         ; Get program arguments
         SWI     #0x32           ; GET_ARGUMENTS syscall
         ; R0 now contains argc
-        ; R1 now contains argv pointer (currently 0 in implementation)
+        ; R1 now contains argv pointer (0 when there are no arguments)
 
         MOV     R4, R0          ; Save argc
         MOV     R5, R1          ; Save argv pointer
@@ -3,16 +3,28 @@ package loader
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/lookbusy1344/arm-emulator/encoder"
 	"github.com/lookbusy1344/arm-emulator/parser"
 	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
+// Options controls how a program is encoded as it is loaded
+type Options struct {
+	Optimize bool // Enable the encoder's -O1 peephole optimizations
+}
+
 // LoadProgramIntoVM loads a parsed assembly program into the VM's memory.
 // It creates necessary memory segments, processes data directives, encodes instructions,
 // and sets up the entry point.
 func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint32) error {
+	return LoadProgramIntoVMWithOptions(machine, program, entryPoint, Options{})
+}
+
+// LoadProgramIntoVMWithOptions is LoadProgramIntoVM with encoding options. Rewrites made
+// by the optimizer are recorded in program.Optimizations.
+func LoadProgramIntoVMWithOptions(machine *vm.VM, program *parser.Program, entryPoint uint32, opts Options) error {
 	// Ensure memory segment exists for the entry point
 	// Check if entry point falls outside standard segments
 	if entryPoint < vm.CodeSegmentStart {
@@ -21,8 +33,130 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 		machine.Memory.AddSegment("low-memory", 0, segmentSize, vm.PermRead|vm.PermWrite|vm.PermExecute)
 	}
 
-	// Create encoder
+	image, err := Assemble(program, entryPoint, opts)
+	if err != nil {
+		return err
+	}
+
+	// Data is written in source order, so later directives win where they overlap
+	for _, data := range image.Data {
+		if err := writeData(machine, data); err != nil {
+			return err
+		}
+	}
+
+	// .bss holds no stored contents; clear it so a reload starts from zero
+	for i := uint32(0); i < program.BSSSize; i++ {
+		if err := machine.Memory.WriteByteUnsafe(program.BSSStart+i, 0); err != nil {
+			return fmt.Errorf(".bss clear failed at 0x%08X: %w", program.BSSStart+i, err)
+		}
+	}
+
+	for _, inst := range image.Instructions {
+		if err := machine.Memory.WriteInstructionUnsafe(inst.Address, inst.Opcode); err != nil {
+			return fmt.Errorf("failed to write instruction at 0x%08X: %w", inst.Address, err)
+		}
+	}
+
+	// Write any literal pool values generated during encoding
+	for addr, value := range image.LiteralPool {
+		if err := machine.Memory.WriteWordUnsafe(addr, value); err != nil {
+			return fmt.Errorf("failed to write literal at 0x%08X: %w", addr, err)
+		}
+	}
+
+	if len(image.PoolWarnings) > 0 && os.Getenv("ARM_WARN_POOLS") != "" {
+		for _, warning := range image.PoolWarnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	// Set PC to entry point and save entry point for debugger resets
+	machine.CPU.PC = entryPoint
+	machine.EntryPoint = entryPoint
+
+	return nil
+}
+
+// writeData stores one data directive's bytes or words in memory
+func writeData(machine *vm.VM, data DataWrite) error {
+	if data.Words != nil {
+		for i, word := range data.Words {
+			addr := data.Address + uint32(i)*4 // #nosec G115 -- directive sizes are far below 4GB
+			if err := machine.Memory.WriteWordUnsafe(addr, word); err != nil {
+				return fmt.Errorf("%s write failed at 0x%08X: %w", data.Directive, addr, err)
+			}
+		}
+		return nil
+	}
+	for i, b := range data.Bytes {
+		addr := data.Address + uint32(i) // #nosec G115 -- directive sizes are far below 4GB
+		if err := machine.Memory.WriteByteUnsafe(addr, b); err != nil {
+			return fmt.Errorf("%s write failed at 0x%08X: %w", data.Directive, addr, err)
+		}
+	}
+	return nil
+}
+
+// alignPadding returns the number of bytes an alignment directive skips from its address,
+// using the resolved alignment the parser left in its first argument
+func alignPadding(directive *parser.Directive) uint32 {
+	if len(directive.Args) == 0 {
+		return 0
+	}
+	value, err := strconv.ParseUint(directive.Args[0], 10, 32)
+	if err != nil {
+		return 0
+	}
+	align := uint32(value) // #nosec G115 -- parsed as 32 bits
+	if directive.Name == ".align" {
+		if align >= 32 {
+			return 0
+		}
+		align = 1 << align
+	}
+	if align == 0 || directive.Address%align == 0 {
+		return 0
+	}
+	return align - directive.Address%align
+}
+
+// Image is an assembled program, ready to be written to memory
+type Image struct {
+	Instructions  []EncodedInstruction
+	Data          []DataWrite       // Initialised data, in source order
+	LiteralPool   map[uint32]uint32 // Literal pool words (address -> value)
+	PoolWarnings  []string          // Literal pool capacity warnings
+	Optimizations []string          // Peephole rewrites made with Options.Optimize
+}
+
+// EncodedInstruction is one instruction's address and machine code
+type EncodedInstruction struct {
+	Address     uint32
+	Opcode      uint32
+	Instruction *parser.Instruction
+}
+
+// DataWrite is the initialised contents of one data directive. .word values are kept as
+// words so they are stored in the VM's byte order; everything else is bytes.
+type DataWrite struct {
+	Address   uint32
+	Directive string
+	Words     []uint32
+	Bytes     []byte
+}
+
+// Assemble encodes a parsed program without touching a VM: it evaluates data directives,
+// encodes every instruction and places the literal pools. LoadProgramIntoVM writes the
+// result to memory. program.LiteralPool and program.Optimizations are filled in.
+func Assemble(program *parser.Program, entryPoint uint32, opts Options) (*Image, error) {
+	image := &Image{}
+
+	// Create encoder with the literal pool locations recorded for .ltorg directives
 	enc := encoder.NewEncoder(program.SymbolTable)
+	enc.LiteralPoolLocs = program.LiteralPoolLocs
+	enc.LiteralPoolCounts = program.LiteralPoolCounts
+	enc.Optimize = opts.Optimize
 
 	// Track the maximum address used for literal pool placement
 	maxAddr := entryPoint
@@ -40,49 +174,54 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 		}
 	}
 
-	// Process data directives using parser-calculated addresses
+	// Process data directives using parser-calculated addresses. Only .text counts towards
+	// the fallback literal pool position, which must stay within LDR range of the code.
 	for _, directive := range program.Directives {
 		dataAddr := directive.Address
+		inText := directive.Section != parser.SectionData && directive.Section != parser.SectionBSS
 
 		switch directive.Name {
 		case ".org":
 			// .org directive is handled at parse time, skip it here
 			continue
 
-		case ".align":
-			// Alignment is already handled by parser in directive.Address
-			continue
-
-		case ".balign":
-			// Alignment is already handled by parser in directive.Address
-			continue
+		case ".align", ".balign":
+			// The parser has moved past the padding; fill it unless it is in .bss, which
+			// has no stored contents
+			if padding := alignPadding(directive); padding > 0 && directive.Section != parser.SectionBSS {
+				fill := byte(0)
+				if len(directive.Args) > 1 {
+					if v, err := strconv.ParseUint(directive.Args[1], 10, 8); err == nil {
+						fill = byte(v)
+					}
+				}
+				bytes := make([]byte, padding)
+				for i := range bytes {
+					bytes[i] = fill
+				}
+				image.Data = append(image.Data, DataWrite{Address: dataAddr, Directive: directive.Name, Bytes: bytes})
+			}
 
 		case ".word":
-			// Write 32-bit words
+			// 32-bit words
+			data := DataWrite{Address: dataAddr, Directive: directive.Name, Words: []uint32{}}
 			for _, arg := range directive.Args {
-				var value uint32
-				// Try to parse as a number first
-				if _, err := fmt.Sscanf(arg, "0x%x", &value); err != nil {
-					if _, err := fmt.Sscanf(arg, "%d", &value); err != nil {
-						// Not a number, try to look up as a symbol (label)
-						symValue, symErr := program.SymbolTable.Get(arg)
-						if symErr != nil {
-							return fmt.Errorf("invalid .word value %q: %w", arg, symErr)
-						}
-						value = symValue
-					}
-				}
-				if err := machine.Memory.WriteWordUnsafe(dataAddr, value); err != nil {
-					return err
+				// Numbers, labels, constants and expressions such as table+8
+				value, err := parser.EvaluateExpression(arg, program.SymbolTable.Get)
+				if err != nil {
+					return nil, fmt.Errorf("invalid .word value %q: %w", arg, err)
 				}
+				data.Words = append(data.Words, value)
 				dataAddr += 4
 			}
-			if dataAddr > maxAddr {
+			image.Data = append(image.Data, data)
+			if inText && dataAddr > maxAddr {
 				maxAddr = dataAddr
 			}
 
 		case ".byte":
-			// Write bytes
+			// Bytes
+			data := DataWrite{Address: dataAddr, Directive: directive.Name}
 			for _, arg := range directive.Args {
 				var value uint32
 				// Check for character literal: 'A', '\n', '\x41', '\123'
@@ -95,28 +234,29 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 						// Escape sequence: '\n', '\x41', '\123'
 						b, _, err := parser.ParseEscapeChar(charContent)
 						if err != nil {
-							return fmt.Errorf("invalid .byte escape sequence: %s", arg)
+							return nil, fmt.Errorf("invalid .byte escape sequence: %s", arg)
 						}
 						value = uint32(b)
 					} else {
-						return fmt.Errorf("invalid .byte character literal: %s", arg)
+						return nil, fmt.Errorf("invalid .byte character literal: %s", arg)
 					}
-				} else if _, err := fmt.Sscanf(arg, "0x%x", &value); err != nil {
-					if _, err := fmt.Sscanf(arg, "%d", &value); err != nil {
-						return fmt.Errorf("invalid .byte value: %s", arg)
+				} else {
+					v, err := parser.EvaluateExpression(arg, program.SymbolTable.Get)
+					if err != nil {
+						return nil, fmt.Errorf("invalid .byte value: %s", arg)
 					}
+					value = v
 				}
-				if err := machine.Memory.WriteByteUnsafe(dataAddr, byte(value)); err != nil { // #nosec G115 -- intentional truncation: .byte directive accepts 0-255
-					return err
-				}
+				data.Bytes = append(data.Bytes, byte(value)) // #nosec G115 -- intentional truncation: .byte directive accepts 0-255
 				dataAddr++
 			}
-			if dataAddr > maxAddr {
+			image.Data = append(image.Data, data)
+			if inText && dataAddr > maxAddr {
 				maxAddr = dataAddr
 			}
 
 		case ".ascii":
-			// Write string without null terminator
+			// String without null terminator
 			if len(directive.Args) > 0 {
 				str := directive.Args[0]
 				// Remove quotes (parser may have already removed them)
@@ -125,42 +265,38 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 				}
 				// Process escape sequences
 				processedStr := parser.ProcessEscapeSequences(str)
-				// Write string bytes
-				for i := 0; i < len(processedStr); i++ {
-					if err := machine.Memory.WriteByteUnsafe(dataAddr, processedStr[i]); err != nil {
-						return fmt.Errorf(".ascii write failed at 0x%08X: %w", dataAddr, err)
-					}
-					dataAddr++
-				}
+				image.Data = append(image.Data, DataWrite{Address: dataAddr, Directive: directive.Name, Bytes: []byte(processedStr)})
+				dataAddr += uint32(len(processedStr)) // #nosec G115 -- string lengths are far below 4GB
 			}
-			if dataAddr > maxAddr {
+			if inText && dataAddr > maxAddr {
 				maxAddr = dataAddr
 			}
 
 		case ".asciz", ".string":
-			// Write null-terminated string
+			// Null-terminated string
 			if len(directive.Args) > 0 {
 				str := directive.Args[0]
 				// Remove quotes
 				if len(str) >= 2 && (str[0] == '"' || str[0] == '\'') {
 					str = str[1 : len(str)-1]
 				}
-				// Process escape sequences
+				// Process escape sequences and add the null terminator
 				processedStr := parser.ProcessEscapeSequences(str)
-				// Write string bytes
-				for i := 0; i < len(processedStr); i++ {
-					if err := machine.Memory.WriteByteUnsafe(dataAddr, processedStr[i]); err != nil {
-						return err
-					}
-					dataAddr++
-				}
-				// Write null terminator
-				if err := machine.Memory.WriteByteUnsafe(dataAddr, 0); err != nil {
-					return err
-				}
-				dataAddr++
+				bytes := append([]byte(processedStr), 0)
+				image.Data = append(image.Data, DataWrite{Address: dataAddr, Directive: directive.Name, Bytes: bytes})
+				dataAddr += uint32(len(bytes)) // #nosec G115 -- string lengths are far below 4GB
 			}
-			if dataAddr > maxAddr {
+			if inText && dataAddr > maxAddr {
+				maxAddr = dataAddr
+			}
+
+		case ".incbin":
+			// The parser has already read the file
+			if len(directive.Data) > 0 {
+				image.Data = append(image.Data, DataWrite{Address: dataAddr, Directive: directive.Name, Bytes: directive.Data})
+				dataAddr += uint32(len(directive.Data)) // #nosec G115 -- bounded by parser.MaxIncbinSize
+			}
+			if inText && dataAddr > maxAddr {
 				maxAddr = dataAddr
 			}
 
@@ -174,12 +310,12 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 					}
 				}
 				endAddr := dataAddr + size
-				if endAddr > maxAddr {
+				if inText && endAddr > maxAddr {
 					maxAddr = endAddr
 				}
 			}
 
-		case ".ltorg":
+		case ".ltorg", ".pool":
 			// Literal pool directive - space will be reserved during encoding
 			// The parser has already recorded this location in program.LiteralPoolLocs
 			// We don't know yet how many literals will be placed here, so we can't
@@ -194,40 +330,42 @@ func LoadProgramIntoVM(machine *vm.VM, program *parser.Program, entryPoint uint3
 	literalPoolStart := (maxAddr + 3) & ^uint32(3)
 	enc.LiteralPoolStart = literalPoolStart
 
-	// Second pass: encode and write instructions
+	// Second pass: encode instructions
 	for _, inst := range program.Instructions {
 		addr := addressMap[inst]
 
-		// Encode instruction
 		opcode, err := enc.EncodeInstruction(inst, addr)
 		if err != nil {
-			return fmt.Errorf("failed to encode instruction at 0x%08X (%s): %w", addr, inst.Mnemonic, err)
-		}
-
-		// Write to memory
-		if err := machine.Memory.WriteWordUnsafe(addr, opcode); err != nil {
-			return fmt.Errorf("failed to write instruction at 0x%08X: %w", addr, err)
-		}
-	}
-
-	// Write any literal pool values generated during encoding
-	for addr, value := range enc.LiteralPool {
-		if err := machine.Memory.WriteWordUnsafe(addr, value); err != nil {
-			return fmt.Errorf("failed to write literal at 0x%08X: %w", addr, err)
+			return nil, fmt.Errorf("failed to encode instruction at 0x%08X (%s): %w", addr, inst.Mnemonic, err)
 		}
+		image.Instructions = append(image.Instructions, EncodedInstruction{Address: addr, Opcode: opcode, Instruction: inst})
 	}
 
 	// Validate literal pool capacity and collect warnings
 	enc.ValidatePoolCapacity()
-	if enc.HasPoolWarnings() && os.Getenv("ARM_WARN_POOLS") != "" {
-		for _, warning := range enc.GetPoolWarnings() {
-			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
-		}
-	}
+	image.LiteralPool = enc.LiteralPool
+	image.PoolWarnings = enc.GetPoolWarnings()
+	image.Optimizations = enc.Optimizations
 
-	// Set PC to entry point and save entry point for debugger resets
-	machine.CPU.PC = entryPoint
-	machine.EntryPoint = entryPoint
+	program.LiteralPool = enc.LiteralPool
+	program.Optimizations = enc.Optimizations
 
-	return nil
+	return image, nil
+}
+
+// LineIndex maps each source file and line to the lowest address of the instructions
+// assembled from it, for file:line breakpoint and run-to locations
+func LineIndex(program *parser.Program) map[string]map[int]uint32 {
+	index := make(map[string]map[int]uint32)
+	for _, inst := range program.Instructions {
+		lines := index[inst.Pos.Filename]
+		if lines == nil {
+			lines = make(map[int]uint32)
+			index[inst.Pos.Filename] = lines
+		}
+		if addr, exists := lines[inst.Pos.Line]; !exists || inst.Address < addr {
+			lines[inst.Pos.Line] = inst.Address
+		}
+	}
+	return index
 }
@@ -0,0 +1,224 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// RunOptions configures a headless run. The zero value runs with the emulator's defaults,
+// empty input and a random source seeded with 0 so runs are reproducible.
+type RunOptions struct {
+	Input           string           // Bytes available to the program on stdin
+	MaxCycles       uint64           // Cycle limit (0 = vm.DefaultMaxCycles)
+	MaxInstructions uint64           // Instruction limit (0 = unlimited)
+	StackSize       uint32           // Stack size in bytes (0 = vm.StackSegmentSize)
+	Seed            int64            // Seed for SWI_GET_RANDOM
+	FilesystemRoot  string           // Sandbox root for file syscalls (empty = current directory)
+	BigEndian       bool             // Store data big-endian
+	Optimize        bool             // Enable the encoder's -O1 peephole optimizations
+	Args            []string         // Arguments returned by SWI_GET_ARGUMENTS
+	Env             []string         // KEY=VALUE variables returned by SWI_GET_ENVIRONMENT
+	Defines         []string         // SYMBOL[=VALUE] defines for conditional assembly (RunFile only)
+	SyscallLimits   vm.SyscallLimits // Caps on SWI use (zero = unlimited)
+	Timeout         time.Duration    // Wall-clock limit (0 = none); exceeding it is a *vm.TimeoutError
+	Clock           func() time.Time // Time reported by SWI_GET_TIME and SWI_GET_DATETIME (nil = host clock)
+	NoStackGuard    bool             // Allow SP to leave the stack segment (guarded by vm.DefaultStackGuard), like -stack-guard=false
+	UninitCheck     vm.UninitCheck   // Warn (to stderr) or halt on reads of registers never written
+
+	// Permissions to enforce on named segments once the program is loaded, e.g.
+	// "code": vm.PermRead|vm.PermExecute to fault on self-modifying code
+	SegmentPermissions map[string]vm.MemoryPermission
+}
+
+// RunResult is the observable outcome of running a program to completion
+type RunResult struct {
+	Output       string                             // Everything written to the console
+	Registers    [vm.ARMGeneralRegisterCount]uint32 // Final R0-R14
+	PC           uint32
+	CPSR         uint32
+	ExitCode     int32
+	Instructions uint64
+	Cycles       uint64
+	Err          error // Runtime error that stopped the program, nil for a normal exit
+}
+
+// RunFile parses the assembly file at path and runs it with RunProgram
+func RunFile(path string, opts RunOptions) (*RunResult, error) {
+	parseOpts := parser.DefaultParseFileOptions()
+	parseOpts.Defines = opts.Defines
+	program, _, err := parser.ParseFile(path, parseOpts)
+	if err != nil {
+		return nil, err
+	}
+	return RunProgram(program, opts)
+}
+
+// RunProgram loads program into a fresh VM and runs it until it exits or faults, the way
+// the command line does without -debug. The entry point is _start, then the .org address,
+// then vm.CodeSegmentStart. The returned error covers setup only; a runtime fault is
+// reported in RunResult.Err.
+func RunProgram(program *parser.Program, opts RunOptions) (*RunResult, error) {
+	machine := vm.NewVM()
+	if opts.MaxCycles != 0 {
+		machine.CycleLimit = opts.MaxCycles
+	}
+	machine.InstructionLimit = opts.MaxInstructions
+	machine.SyscallLimits = opts.SyscallLimits
+	machine.Memory.LittleEndian = !opts.BigEndian
+	machine.SetRandomSeed(opts.Seed)
+	machine.Clock = opts.Clock
+	machine.StackGuard = vm.DefaultStackGuard && !opts.NoStackGuard
+	machine.UninitCheck = opts.UninitCheck
+	machine.SetProgramArguments(opts.Args)
+	if err := machine.SetEnvironment(opts.Env); err != nil {
+		return nil, err
+	}
+
+	root := opts.FilesystemRoot
+	if root == "" {
+		root = "."
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve filesystem root: %w", err)
+	}
+	machine.FilesystemRoot = absRoot
+
+	var output bytes.Buffer
+	machine.OutputWriter = &output
+	machine.SetStdinReader(strings.NewReader(opts.Input))
+
+	stackSize := opts.StackSize
+	if stackSize == 0 {
+		stackSize = vm.StackSegmentSize
+	}
+	if err := machine.InitializeStack(vm.StackSegmentStart + stackSize); err != nil {
+		return nil, fmt.Errorf("failed to initialize stack: %w", err)
+	}
+
+	entry := uint32(vm.CodeSegmentStart)
+	if start, exists := program.SymbolTable.Lookup("_start"); exists && start.Defined {
+		entry = start.Value
+	} else if program.OriginSet {
+		entry = program.Origin
+	}
+	if err := LoadProgramIntoVMWithOptions(machine, program, entry, Options{Optimize: opts.Optimize}); err != nil {
+		return nil, err
+	}
+	for name, perms := range opts.SegmentPermissions {
+		if err := machine.Memory.SetSegmentPermissions(name, perms); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	result := &RunResult{}
+	machine.State = vm.StateRunning
+	step := machine.ContextStep(ctx, machine.StepFunc())
+	for machine.State == vm.StateRunning {
+		if err := step(); err != nil {
+			if machine.State != vm.StateHalted {
+				result.Err = err
+			}
+			break
+		}
+	}
+
+	result.Output = output.String()
+	result.Registers = machine.CPU.R
+	result.PC = machine.CPU.PC
+	result.CPSR = machine.CPU.CPSR.ToUint32()
+	result.ExitCode = machine.ExitCode
+	result.Instructions = machine.CPU.Instructions
+	result.Cycles = machine.CPU.Cycles
+	return result, nil
+}
+
+// RunDifference is one way two run results disagree
+type RunDifference struct {
+	Field string // "output", "R0".."R14", "PC", "CPSR", "exit code", "instructions" or "error"
+	A, B  string // The two values, formatted for display
+}
+
+// String formats the difference as "field: a != b"
+func (d RunDifference) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.A, d.B)
+}
+
+// Diff compares r with other and returns their differences in display order: console
+// output, registers, exit code, instruction count and runtime error. Cycle counts are
+// not compared since they follow from the instructions executed.
+func (r *RunResult) Diff(other *RunResult) []RunDifference {
+	var diffs []RunDifference
+
+	if r.Output != other.Output {
+		a, b := firstDifferentLine(r.Output, other.Output)
+		diffs = append(diffs, RunDifference{Field: "output", A: a, B: b})
+	}
+	for i := range r.Registers {
+		if r.Registers[i] != other.Registers[i] {
+			diffs = append(diffs, RunDifference{
+				Field: fmt.Sprintf("R%d", i),
+				A:     fmt.Sprintf("0x%08X", r.Registers[i]),
+				B:     fmt.Sprintf("0x%08X", other.Registers[i]),
+			})
+		}
+	}
+	if r.PC != other.PC {
+		diffs = append(diffs, RunDifference{Field: "PC", A: fmt.Sprintf("0x%08X", r.PC), B: fmt.Sprintf("0x%08X", other.PC)})
+	}
+	if r.CPSR != other.CPSR {
+		diffs = append(diffs, RunDifference{Field: "CPSR", A: fmt.Sprintf("0x%08X", r.CPSR), B: fmt.Sprintf("0x%08X", other.CPSR)})
+	}
+	if r.ExitCode != other.ExitCode {
+		diffs = append(diffs, RunDifference{Field: "exit code", A: fmt.Sprint(r.ExitCode), B: fmt.Sprint(other.ExitCode)})
+	}
+	if r.Instructions != other.Instructions {
+		diffs = append(diffs, RunDifference{Field: "instructions", A: fmt.Sprint(r.Instructions), B: fmt.Sprint(other.Instructions)})
+	}
+	if errText(r.Err) != errText(other.Err) {
+		diffs = append(diffs, RunDifference{Field: "error", A: errText(r.Err), B: errText(other.Err)})
+	}
+	return diffs
+}
+
+// firstDifferentLine returns the first line at which a and b differ, quoted and prefixed
+// with its 1-based line number
+func firstDifferentLine(a, b string) (string, string) {
+	linesA := strings.Split(a, "\n")
+	linesB := strings.Split(b, "\n")
+	for i := 0; ; i++ {
+		var lineA, lineB string
+		okA, okB := i < len(linesA), i < len(linesB)
+		if okA {
+			lineA = linesA[i]
+		}
+		if okB {
+			lineB = linesB[i]
+		}
+		if lineA != lineB || okA != okB {
+			return fmt.Sprintf("line %d %q", i+1, lineA), fmt.Sprintf("line %d %q", i+1, lineB)
+		}
+	}
+}
+
+// errText formats a runtime error for comparison, "none" for a normal exit
+func errText(err error) string {
+	if err == nil {
+		return "none"
+	}
+	return err.Error()
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -30,6 +37,9 @@ var (
 	Date    = "unknown" // Build date
 )
 
+// maxStackSize bounds -stack-size so the stack top cannot overflow
+const maxStackSize = 0x10000000 // 256MB reasonable maximum
+
 func main() {
 	// Command-line flags
 	var (
@@ -37,32 +47,59 @@ func main() {
 		showHelp    = flag.Bool("help", false, "Show help information")
 		debugMode   = flag.Bool("debug", false, "Start in debugger mode")
 		tuiMode     = flag.Bool("tui", false, "Use TUI (Text User Interface) debugger")
+		gdbPort     = flag.Int("gdb", 0, "Serve the gdb remote protocol on this TCP port")
 		apiServer   = flag.Bool("api-server", false, "Start HTTP API server mode")
 		apiPort     = flag.Int("port", 8080, "API server port (used with -api-server)")
 		maxCycles   = flag.Uint64("max-cycles", 1000000, "Maximum CPU cycles before halt")
+		maxInstrs   = flag.Uint64("max-instructions", 0, "Maximum instructions executed before halt (0 = unlimited)")
+		maxSyscalls = flag.Uint64("max-syscalls", 0, "Maximum SWI calls before halt (0 = unlimited)")
+		maxOpens    = flag.Uint64("max-file-opens", 0, "Maximum SWI_OPEN, DUMP_REGION and LOAD_REGION calls; later ones fail (0 = unlimited)")
+		maxWritten  = flag.Uint64("max-bytes-written", 0, "Maximum bytes written to console and files; later writes fail (0 = unlimited)")
+		timeout     = flag.Duration("timeout", 0, "Abort a run after this much wall-clock time, e.g. 5s (0 = no limit)")
 		stackSize   = flag.Uint("stack-size", vm.StackSegmentSize, "Stack size in bytes")
+		registers   = flag.Int("registers", vm.ARMTotalRegisterCount, "Only allow R0 to R(N-1) plus SP, LR and PC, for teaching reduced register sets")
+		uninitMode  = flag.String("uninit", "off", "Report reads of registers the program never wrote: off, warn, or halt at the first")
 		entryPoint  = flag.String("entry", "0x8000", "Entry point address (hex or decimal)")
 		verboseMode = flag.Bool("verbose", false, "Verbose output")
 		fsRoot      = flag.String("fsroot", "", "Restrict file operations to this directory (default: current directory)")
+		allowFSRoot = flag.Bool("allow-fsroot-change", false, "Allow SWI 0x37 to narrow the filesystem root to a subdirectory")
+		randomSeed  = flag.Int64("seed", 0, "Seed SWI_GET_RANDOM for reproducible runs (default: time-seeded)")
+		bigEndian   = flag.Bool("big-endian", false, "Store data in big-endian byte order (instructions stay little-endian)")
+		optimize    = flag.Bool("O1", false, "Fold no-op arithmetic into NOP and report LDR =const folds")
+		programArgs = flag.String("args", "", "Space-separated arguments returned to the program by SWI_GET_ARGUMENTS")
+		regDiff     = flag.Bool("reg-diff", false, "Log each instruction's PC and register changes to stderr (direct run only)")
+		outputFile  = flag.String("output-file", "", "Write the program's console output to this file instead of stdout (direct run only)")
+		recordInput = flag.String("record-input", "", "Save everything read from stdin by the program to this file (direct run only)")
+		replayInput = flag.String("replay-input", "", "Feed the program's stdin from a file saved with -record-input (direct run only)")
 
 		// Tracing and statistics flags
 		enableTrace    = flag.Bool("trace", false, "Enable execution trace")
 		traceFile      = flag.String("trace-file", "", "Trace output file (default: trace.log in log dir)")
 		traceFilter    = flag.String("trace-filter", "", "Filter trace by registers (comma-separated, e.g., R0,R1,PC)")
+		traceFormat    = flag.String("trace-format", "text", "Execution trace format (text, csv, jsonl)")
+		traceColumns   = flag.String("trace-columns", "", "Execution trace columns in order (pc,opcode,mnemonic,regs,cpsr,cycles)")
+		replayTrace    = flag.String("replay-trace", "", "Re-run the program against a recorded -trace file and report the first divergence")
+		diffFile       = flag.String("diff", "", "Run this second program with the same input and report how its run differs")
 		enableMemTrace = flag.Bool("mem-trace", false, "Enable memory access trace")
 		memTraceFile   = flag.String("mem-trace-file", "", "Memory trace output file (default: memtrace.log)")
+		heatmapFile    = flag.String("heatmap-file", "", "Write a memory access heatmap (.ppm for an image, otherwise CSV)")
+		heatmapBlock   = flag.Uint("heatmap-block", vm.DefaultHeatmapBlockSize, "Heatmap bucket size in bytes")
 		enableStats    = flag.Bool("stats", false, "Enable performance statistics")
 		statsFile      = flag.String("stats-file", "", "Statistics output file (default: stats.json)")
 		statsFormat    = flag.String("stats-format", "json", "Statistics format (json, csv, html)")
+		enableProfile  = flag.Bool("profile", false, "Enable call-graph function profiling")
+		profileFile    = flag.String("profile-file", "", "Profile output file (default: profile.json)")
 
 		// Additional diagnostic modes (Phase 11)
 		enableCoverage      = flag.Bool("coverage", false, "Enable code coverage tracking")
 		coverageFile        = flag.String("coverage-file", "", "Coverage output file (default: coverage.txt)")
 		coverageFormat      = flag.String("coverage-format", "text", "Coverage format (text, json)")
+		coverageFailUnder   = flag.Float64("coverage-fail-under", 0, "Exit with a failure code if coverage is below PCT percent (implies -coverage)")
 		enableStackTrace    = flag.Bool("stack-trace", false, "Enable stack operation tracing")
 		stackTraceFile      = flag.String("stack-trace-file", "", "Stack trace output file (default: stack_trace.txt)")
 		stackTraceFormat    = flag.String("stack-trace-format", "text", "Stack trace format (text, json)")
-		stackGuard          = flag.Bool("stack-guard", false, "Halt execution if stack overflows into heap segment")
+		stackGuard          = flag.Bool("stack-guard", vm.DefaultStackGuard, "Halt execution if SP leaves the stack segment (-stack-guard=false to allow relocating SP)")
+		reportLeaks         = flag.Bool("report-leaks", false, "Report heap blocks allocated but never freed at exit")
 		enableFlagTrace     = flag.Bool("flag-trace", false, "Enable CPSR flag change tracing")
 		flagTraceFile       = flag.String("flag-trace-file", "", "Flag trace output file (default: flag_trace.txt)")
 		flagTraceFormat     = flag.String("flag-trace-format", "text", "Flag trace format (text, json)")
@@ -71,10 +108,23 @@ func main() {
 		registerTraceFormat = flag.String("register-trace-format", "text", "Register trace format (text, json)")
 
 		// Symbol dump options
-		dumpSymbols = flag.Bool("dump-symbols", false, "Dump symbol table and exit")
-		symbolsFile = flag.String("symbols-file", "", "Symbol dump output file (default: stdout)")
+		dumpSymbols  = flag.Bool("dump-symbols", false, "Dump symbol table and exit")
+		symbolsFile  = flag.String("symbols-file", "", "Symbol dump output file (default: stdout)")
+		symbolsFmt   = flag.String("symbols-format", "text", "Symbol dump format (text, csv, markdown)")
+		dumpLiterals = flag.Bool("dump-literals", false, "Dump literal pool entries and exit")
+		xref         = flag.Bool("xref", false, "Print where each symbol is defined and used, then exit")
+		dumpAST      = flag.Bool("dump-ast", false, "Print the parsed instructions and directives as JSON, then exit")
+		disasm       = flag.Bool("disasm", false, "Print the assembled program as address, opcode and mnemonic, then exit")
+		listingFile  = flag.String("listing", "", "Write an assembler listing (source with addresses and opcodes, plus symbols) to file")
 	)
 
+	var envVars stringList
+	flag.Var(&envVars, "env", "KEY=VALUE variable returned by SWI_GET_ENVIRONMENT (repeatable)")
+	var segmentPerms stringList
+	flag.Var(&segmentPerms, "segment-perms", "SEGMENT=PERMS permissions to enforce after loading, e.g. code=rx (repeatable)")
+	var defines stringList
+	flag.Var(&defines, "D", "SYMBOL[=VALUE] define for .if/.ifdef, also usable as a constant (repeatable)")
+
 	flag.Parse()
 
 	// Show version
@@ -148,19 +198,80 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Get assembly file from arguments
+	// Get assembly file from arguments; "-" reads the program from stdin
 	asmFile := flag.Arg(0)
-	if _, err := os.Stat(asmFile); os.IsNotExist(err) {
+	var stdinSource []byte
+	if asmFile == stdinArg {
+		if *diffFile != "" {
+			fmt.Fprintf(os.Stderr, "Error: -diff cannot be used with a program read from stdin\n")
+			os.Exit(1)
+		}
+		if *debugMode && !*tuiMode {
+			fmt.Fprintf(os.Stderr, "Error: the CLI debugger reads commands from stdin, so the program must be a file\n")
+			os.Exit(1)
+		}
+		var err error
+		stdinSource, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading program from stdin: %v\n", err)
+			os.Exit(1)
+		}
+		asmFile = stdinName
+	} else if _, err := os.Stat(asmFile); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", asmFile)
 		os.Exit(1)
 	}
 
+	segmentPermissions, err := parseSegmentPermissions(segmentPerms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	uninitCheck, err := vm.ParseUninitCheck(*uninitMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -uninit: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Compare two runs instead of a normal run
+	if *diffFile != "" {
+		if *stackSize > maxStackSize {
+			fmt.Fprintf(os.Stderr, "Error: stack size %d exceeds maximum allowed %d\n", *stackSize, maxStackSize)
+			os.Exit(2)
+		}
+		os.Exit(runDiff(asmFile, *diffFile, loader.RunOptions{
+			MaxCycles:          *maxCycles,
+			MaxInstructions:    *maxInstrs,
+			StackSize:          uint32(*stackSize), // #nosec G115 -- validated against maxStackSize above
+			Seed:               *randomSeed,
+			FilesystemRoot:     *fsRoot,
+			BigEndian:          *bigEndian,
+			Optimize:           *optimize,
+			Args:               strings.Fields(*programArgs),
+			Env:                envVars,
+			Defines:            defines,
+			SyscallLimits:      vm.SyscallLimits{MaxCalls: *maxSyscalls, MaxFileOpens: *maxOpens, MaxBytesWritten: *maxWritten},
+			Timeout:            *timeout,
+			SegmentPermissions: segmentPermissions,
+			NoStackGuard:       !*stackGuard,
+			UninitCheck:        uninitCheck,
+		}))
+	}
+
 	// Parse assembly file (with preprocessing for .include, .ifdef, etc.)
 	if *verboseMode {
 		fmt.Printf("Loading and parsing assembly file: %s\n", asmFile)
 	}
 
-	program, _, err := parser.ParseFileSimple(asmFile)
+	parseOpts := parser.DefaultParseFileOptions()
+	parseOpts.Defines = defines
+	var program *parser.Program
+	if stdinSource != nil {
+		program, _, err = parser.ParseReader(bytes.NewReader(stdinSource), stdinName, parseOpts)
+	} else {
+		program, _, err = parser.ParseFile(asmFile, parseOpts)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Parse error:\n%v\n", err)
 		os.Exit(1)
@@ -171,9 +282,42 @@ func main() {
 			len(program.Instructions), len(program.Directives))
 	}
 
+	// The AST is the parser's output, so it is printed before anything is encoded
+	if *dumpAST {
+		if err := parser.NewAST(program).ExportJSON(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing AST: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Print the cross-reference before loading, so undefined symbols are listed rather
+	// than stopping the encoder
+	if *xref {
+		if undefined := writeXRef(os.Stdout, program.SymbolTable); undefined > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Create VM instance
 	machine := vm.NewVM()
 	machine.CycleLimit = *maxCycles
+	machine.InstructionLimit = *maxInstrs
+	machine.SyscallLimits = vm.SyscallLimits{MaxCalls: *maxSyscalls, MaxFileOpens: *maxOpens, MaxBytesWritten: *maxWritten}
+	machine.Memory.LittleEndian = !*bigEndian
+	if err := machine.SetRegisterCount(*registers); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -registers: %v\n", err)
+		os.Exit(1)
+	}
+	machine.UninitCheck = uninitCheck
+
+	// Only seed the random source when -seed was given, so 0 is a valid seed
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			machine.SetRandomSeed(*randomSeed)
+		}
+	})
 
 	// Configure filesystem root for sandboxing
 	filesystemRoot := *fsRoot
@@ -193,6 +337,12 @@ func main() {
 		os.Exit(1)
 	}
 	machine.FilesystemRoot = absRoot
+	machine.AllowFSRootChange = *allowFSRoot
+	machine.SetProgramArguments(strings.Fields(*programArgs))
+	if err := machine.SetEnvironment(envVars); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	if *verboseMode {
 		fmt.Printf("Filesystem root: %s\n", absRoot)
@@ -200,7 +350,6 @@ func main() {
 
 	// Initialize stack
 	// Validate stack size to prevent integer overflow
-	const maxStackSize = 0x10000000 // 256MB reasonable maximum
 	if *stackSize > maxStackSize {
 		fmt.Fprintf(os.Stderr, "Error: stack size %d exceeds maximum allowed %d\n", *stackSize, maxStackSize)
 		os.Exit(1)
@@ -239,11 +388,23 @@ func main() {
 		fmt.Println("Loading program into memory...")
 	}
 
-	err = loader.LoadProgramIntoVM(machine, program, entryAddr)
+	err = loader.LoadProgramIntoVMWithOptions(machine, program, entryAddr, loader.Options{Optimize: *optimize})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading program: %v\n", err)
 		os.Exit(1)
 	}
+	for name, perms := range segmentPermissions {
+		if err := machine.Memory.SetSegmentPermissions(name, perms); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *verboseMode && *optimize {
+		fmt.Printf("Optimizations: %d\n", len(program.Optimizations))
+		for _, opt := range program.Optimizations {
+			fmt.Printf("  %s\n", opt)
+		}
+	}
 
 	// Create symbol table for debugger
 	symbols := make(map[string]uint32)
@@ -255,16 +416,20 @@ func main() {
 		}
 	}
 
-	// Build source map (address -> source line)
+	// Build source map (address -> source line), line index (file:line -> lowest address)
+	// and each address's file and line
+	lineIndex := loader.LineIndex(program)
+	addressLines := make(map[uint32]debugger.SourceLocation, len(program.Instructions))
 	for _, inst := range program.Instructions {
 		// Map every instruction's address to its raw source line
 		sourceMap[inst.Address] = inst.RawLine
+		addressLines[inst.Address] = debugger.SourceLocation{File: inst.Pos.Filename, Line: inst.Pos.Line}
 	}
 
 	// Add data directives to source map (prefixed with [DATA] for TUI differentiation)
 	for _, dir := range program.Directives {
 		// Include directives that generate data in memory
-		if dir.Name == ".word" || dir.Name == ".byte" || dir.Name == ".ascii" || dir.Name == ".asciz" || dir.Name == ".space" {
+		if dir.Name == ".word" || dir.Name == ".byte" || dir.Name == ".ascii" || dir.Name == ".asciz" || dir.Name == ".space" || dir.Name == ".incbin" {
 			// Prefix with [DATA] so TUI can display these in a different color
 			sourceMap[dir.Address] = "[DATA]" + dir.RawLine
 		}
@@ -279,19 +444,58 @@ func main() {
 
 	// Handle symbol dump if requested
 	if *dumpSymbols {
-		if err := dumpSymbolTable(program.SymbolTable, *symbolsFile); err != nil {
+		if err := dumpSymbolTable(program.SymbolTable, *symbolsFile, *symbolsFmt); err != nil {
 			fmt.Fprintf(os.Stderr, "Error dumping symbols: %v\n", err)
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
 
+	// Handle literal pool dump if requested
+	if *dumpLiterals {
+		dumpLiteralPool(program)
+		os.Exit(0)
+	}
+
+	// Handle disassembly listing if requested
+	if *disasm {
+		if err := disassembleProgram(os.Stdout, machine, program, symbols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error disassembling program: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Write the listing file if requested; the program still runs afterwards
+	if *listingFile != "" {
+		source := stdinSource
+		if source == nil {
+			source, err = os.ReadFile(asmFile) // #nosec G304 -- user-specified assembly file
+		}
+		if err == nil {
+			err = writeListing(*listingFile, machine, program, filepath.Base(asmFile), source)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing listing: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Replay a recorded execution trace instead of a normal run
+	if *replayTrace != "" {
+		os.Exit(runTraceReplay(machine, *replayTrace, *traceFilter, symbols))
+	}
+
 	// Setup tracing and statistics (Phase 10)
 	if *enableTrace {
 		// Determine trace file path
 		tracePath := *traceFile
 		if tracePath == "" {
-			tracePath = filepath.Join(config.GetLogPath(), "trace.log")
+			ext := "log"
+			if *traceFormat == vm.TraceFormatCSV || *traceFormat == vm.TraceFormatJSONL {
+				ext = *traceFormat
+			}
+			tracePath = filepath.Join(config.GetLogPath(), "trace."+ext)
 		}
 
 		traceWriter, err := os.Create(tracePath) // #nosec G304 -- user-specified trace output path
@@ -306,6 +510,14 @@ func main() {
 		}()
 
 		machine.ExecutionTrace = vm.NewExecutionTrace(traceWriter)
+		if err := machine.ExecutionTrace.SetFormat(*traceFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := machine.ExecutionTrace.SetColumns(*traceColumns); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		machine.ExecutionTrace.LoadSymbols(symbols)
 		machine.ExecutionTrace.Start()
 
@@ -347,6 +559,18 @@ func main() {
 		}
 	}
 
+	if *heatmapFile != "" {
+		if *heatmapBlock == 0 || *heatmapBlock > math.MaxUint32 {
+			fmt.Fprintf(os.Stderr, "Error: -heatmap-block must be between 1 and %d\n", uint32(math.MaxUint32))
+			os.Exit(1)
+		}
+		machine.MemoryHeatmap = vm.NewMemoryHeatmap(uint32(*heatmapBlock)) // #nosec G115 -- range checked above
+
+		if *verboseMode {
+			fmt.Printf("Memory heatmap enabled: %s\n", *heatmapFile)
+		}
+	}
+
 	if *enableStats {
 		machine.Statistics = vm.NewPerformanceStatistics()
 		machine.Statistics.Start()
@@ -356,8 +580,21 @@ func main() {
 		}
 	}
 
+	if *enableProfile {
+		machine.Profiler = vm.NewProfiler()
+		machine.Profiler.LoadSymbols(symbols)
+
+		if *verboseMode {
+			fmt.Println("Function profiling enabled")
+		}
+	}
+
 	// Setup additional diagnostic modes (Phase 11)
-	if *enableCoverage {
+	if *coverageFailUnder < 0 || *coverageFailUnder > 100 {
+		fmt.Fprintf(os.Stderr, "Error: -coverage-fail-under must be between 0 and 100, got %g\n", *coverageFailUnder)
+		os.Exit(1)
+	}
+	if *enableCoverage || *coverageFailUnder > 0 {
 		// Determine coverage file path
 		covPath := *coverageFile
 		if covPath == "" {
@@ -380,14 +617,21 @@ func main() {
 		}()
 
 		machine.CodeCoverage = vm.NewCodeCoverage(covWriter)
-		// Set code range based on program size
-		if len(program.Instructions) > 0 {
-			codeStart := entryAddr
-			// Safe conversion: instruction count is bounded by memory size and parser limits
-			codeEnd := entryAddr + uint32(len(program.Instructions)*4) // #nosec G115 -- overflow requires >1B instructions; memory exhausts first
+		// Measure coverage against the assembled instructions, spanning the lowest to the
+		// highest, so data and .org gaps are not counted as code
+		machine.CodeCoverage.LoadSymbols(symbols)
+		sourceLines := make(map[uint32]string, len(program.Instructions))
+		codeAddrs := make([]uint32, 0, len(program.Instructions))
+		for _, inst := range program.Instructions {
+			sourceLines[inst.Address] = fmt.Sprintf("%s:%d: %s", inst.Pos.Filename, inst.Pos.Line, strings.TrimSpace(inst.RawLine))
+			codeAddrs = append(codeAddrs, inst.Address)
+		}
+		if len(codeAddrs) > 0 {
+			codeStart, codeEnd := slices.Min(codeAddrs), slices.Max(codeAddrs)+4
 			machine.CodeCoverage.SetCodeRange(codeStart, codeEnd)
+			machine.CodeCoverage.SetCodeAddresses(codeAddrs)
 		}
-		machine.CodeCoverage.LoadSymbols(symbols)
+		machine.CodeCoverage.LoadSourceLines(sourceLines)
 		machine.CodeCoverage.Start()
 
 		if *verboseMode {
@@ -395,48 +639,39 @@ func main() {
 		}
 	}
 
-	// Stack guard requires stack trace (even without output file)
-	if *enableStackTrace || *stackGuard {
-		var stWriter *os.File
-		var stPath string
+	// The stack guard is checked by the VM after every instruction, with or without a stack trace
+	machine.StackGuard = *stackGuard
+	if *verboseMode && !*stackGuard {
+		fmt.Println("Stack guard disabled: SP may leave the stack segment")
+	}
 
-		if *enableStackTrace {
-			// Determine stack trace file path
-			stPath = *stackTraceFile
-			if stPath == "" {
-				ext := "txt"
-				if *stackTraceFormat == "json" {
-					ext = "json"
-				}
-				stPath = filepath.Join(config.GetLogPath(), "stack_trace."+ext)
+	if *enableStackTrace {
+		// Determine stack trace file path
+		stPath := *stackTraceFile
+		if stPath == "" {
+			ext := "txt"
+			if *stackTraceFormat == "json" {
+				ext = "json"
 			}
+			stPath = filepath.Join(config.GetLogPath(), "stack_trace."+ext)
+		}
 
-			var err error
-			stWriter, err = os.Create(stPath) // #nosec G304 -- user-specified stack trace output path
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error creating stack trace file: %v\n", err)
-				os.Exit(1)
-			}
-			defer func() {
-				if err := stWriter.Close(); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to close stack trace file: %v\n", err)
-				}
-			}()
+		stWriter, err := os.Create(stPath) // #nosec G304 -- user-specified stack trace output path
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating stack trace file: %v\n", err)
+			os.Exit(1)
 		}
+		defer func() {
+			if err := stWriter.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close stack trace file: %v\n", err)
+			}
+		}()
 
 		machine.StackTrace = vm.NewStackTrace(stWriter, stackTop, vm.StackSegmentStart)
 		machine.StackTrace.LoadSymbols(symbols)
 		machine.StackTrace.Start(stackTop)
 
-		// Enable halt on overflow if stack guard is enabled
-		if *stackGuard {
-			machine.StackTrace.HaltOnOverflow = true
-			if *verboseMode {
-				fmt.Println("Stack guard enabled: execution will halt if SP enters heap segment")
-			}
-		}
-
-		if *verboseMode && *enableStackTrace {
+		if *verboseMode {
 			fmt.Printf("Stack trace enabled: %s\n", stPath)
 		}
 	}
@@ -504,11 +739,27 @@ func main() {
 	}
 
 	// Run in appropriate mode
-	if *debugMode || *tuiMode {
+	if *gdbPort > 0 {
+		// Serve a single gdb remote session
+		dbg := debugger.NewDebugger(machine)
+		dbg.LoadSymbols(symbols)
+		dbg.LoadSourceMap(sourceMap)
+		dbg.LoadLineIndex(lineIndex)
+		dbg.LoadAddressLines(addressLines)
+		dbg.LoadLiteralPool(program.LiteralPool)
+
+		if err := debugger.RunGDBServer(dbg, *gdbPort); err != nil {
+			fmt.Fprintf(os.Stderr, "gdb server error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *debugMode || *tuiMode {
 		// Start debugger
 		dbg := debugger.NewDebugger(machine)
 		dbg.LoadSymbols(symbols)
 		dbg.LoadSourceMap(sourceMap)
+		dbg.LoadLineIndex(lineIndex)
+		dbg.LoadAddressLines(addressLines)
+		dbg.LoadLiteralPool(program.LiteralPool)
 
 		if *tuiMode {
 			// Start TUI interface
@@ -529,23 +780,97 @@ func main() {
 		}
 	} else {
 		// Direct execution mode
+		if *outputFile != "" {
+			// Only console syscalls go to the file; emulator messages stay on stdout and stderr.
+			// Writes to a regular file are synced as they happen, so nothing is lost on os.Exit.
+			outputWriter, err := os.Create(*outputFile) // #nosec G304 -- user-specified output path
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() {
+				if err := outputWriter.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", err)
+				}
+			}()
+			machine.OutputWriter = outputWriter
+		}
+
+		// Save or replay the program's stdin so an interactive session can be reproduced
+		switch {
+		case *recordInput != "" && *replayInput != "":
+			fmt.Fprintf(os.Stderr, "Error: -record-input and -replay-input cannot be used together\n")
+			os.Exit(1)
+		case *recordInput != "":
+			// Bytes are written as they are read, so a session cut short is still saved
+			recording, err := os.Create(*recordInput) // #nosec G304 -- user-specified recording path
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating input recording: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() {
+				if err := recording.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to close input recording: %v\n", err)
+				}
+			}()
+			machine.SetStdinReader(io.TeeReader(os.Stdin, recording))
+		case *replayInput != "":
+			replay, err := os.Open(*replayInput) // #nosec G304 -- user-specified recording path
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error opening input recording: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = replay.Close() }()
+			machine.SetStdinReader(replay)
+		}
+
 		if *verboseMode {
 			fmt.Println("\nStarting execution...")
 			fmt.Println("----------------------------------------")
 		}
 
-		// Run until halt
+		// Run until halt, or until the wall-clock timeout passes
+		ctx := context.Background()
+		var watchdog *time.Timer
+		if *timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *timeout)
+			defer cancel()
+			// The context is only checked between instructions, so a program blocked in a
+			// host read (such as stdin) is stopped by this watchdog instead
+			watchdog = time.AfterFunc(*timeout+timeoutGrace, func() {
+				fmt.Fprintf(os.Stderr, "\nRuntime error: wall-clock timeout of %v exceeded while blocked in a host operation\n", *timeout)
+				os.Exit(exitRuntimeError)
+			})
+		}
 		machine.State = vm.StateRunning
+		step := machine.ContextStep(ctx, machine.StepFunc())
+		if *regDiff {
+			step = registerDiffStep(machine, step, os.Stderr)
+		}
 		for machine.State == vm.StateRunning {
-			if err := machine.Step(); err != nil {
+			if err := step(); err != nil {
 				if machine.State == vm.StateHalted {
 					// Normal exit
 					break
 				}
-				fmt.Fprintf(os.Stderr, "\nRuntime error at PC=0x%08X: %v\n", machine.CPU.PC, err)
-				os.Exit(1)
+				if fault, ok := vm.AsRuntimeError(err); ok {
+					fmt.Fprintf(os.Stderr, "\nRuntime error (%s) at PC=0x%08X: %v\n", fault.Category(), fault.FaultPC(), err)
+				} else {
+					fmt.Fprintf(os.Stderr, "\nRuntime error at PC=0x%08X: %v\n", machine.CPU.PC, err)
+				}
+				os.Exit(runtimeExitCode(err))
 			}
 		}
+		if watchdog != nil {
+			watchdog.Stop()
+		}
+
+		// A BKPT has no debugger to drop into, so report it and stop
+		bkpt := machine.LastBKPT
+		if bkpt != nil {
+			fmt.Fprintf(os.Stderr, "\nStopped: %s\n", bkpt)
+		}
 
 		if *verboseMode {
 			fmt.Println("\n----------------------------------------")
@@ -574,6 +899,14 @@ func main() {
 			}
 		}
 
+		if machine.MemoryHeatmap != nil {
+			if err := writeHeatmap(machine.MemoryHeatmap, *heatmapFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing memory heatmap: %v\n", err)
+			} else if *verboseMode {
+				fmt.Printf("Memory heatmap written (%d blocks)\n", len(machine.MemoryHeatmap.Buckets()))
+			}
+		}
+
 		if machine.Statistics != nil {
 			// Determine stats file path
 			statPath := *statsFile
@@ -622,6 +955,35 @@ func main() {
 			}
 		}
 
+		if machine.Profiler != nil {
+			profPath := *profileFile
+			if profPath == "" {
+				profPath = filepath.Join(config.GetLogPath(), "profile.json")
+			}
+
+			profWriter, err := os.Create(profPath) // #nosec G304 -- user-specified profile output path
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating profile file: %v\n", err)
+			} else {
+				defer func() {
+					if err := profWriter.Close(); err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: failed to close profile file: %v\n", err)
+					}
+				}()
+
+				if err := machine.Profiler.ExportJSON(profWriter); err != nil {
+					fmt.Fprintf(os.Stderr, "Error exporting profile: %v\n", err)
+				} else if *verboseMode {
+					fmt.Printf("Profile exported: %s\n", profPath)
+				}
+			}
+
+			if *verboseMode {
+				fmt.Println()
+				fmt.Println(machine.Profiler.String())
+			}
+		}
+
 		// Flush additional diagnostic modes (Phase 11)
 		if machine.CodeCoverage != nil {
 			switch *coverageFormat {
@@ -699,14 +1061,77 @@ func main() {
 			}
 		}
 
+		if *reportLeaks {
+			if err := machine.WriteLeakReport(os.Stderr, symbols); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing leak report: %v\n", err)
+			}
+		}
+
+		if bkpt != nil {
+			os.Exit(1)
+		}
+		if *coverageFailUnder > 0 {
+			if coverage := machine.CodeCoverage.GetCoverage(); coverage < *coverageFailUnder {
+				fmt.Fprintf(os.Stderr, "Coverage %.2f%% is below the required %.2f%% (not executed: %d)\n",
+					coverage, *coverageFailUnder, len(machine.CodeCoverage.GetUnexecutedAddresses()))
+				os.Exit(exitCoverageFailed)
+			}
+		}
 		os.Exit(int(machine.ExitCode))
 	}
 }
 
+// registerDiffStep wraps step so that each instruction that changes a register or flag logs
+// its address and the changes to w, e.g. "0x00008004: R0: 5 -> 15". Instructions that
+// change nothing, such as a NOP, are not logged.
+func registerDiffStep(machine *vm.VM, step func() error, w io.Writer) func() error {
+	return func() error {
+		var before, after vm.RegisterSnapshot
+		before.Capture(machine.CPU)
+		err := step()
+		after.Capture(machine.CPU)
+		if changes := after.Diff(&before); changes != "" {
+			fmt.Fprintf(w, "0x%08X: %s\n", before.R[vm.ARMRegisterPC], changes)
+		}
+		return err
+	}
+}
+
+// parseSegmentPermissions parses -segment-perms values of the form SEGMENT=PERMS
+func parseSegmentPermissions(specs []string) (map[string]vm.MemoryPermission, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]vm.MemoryPermission, len(specs))
+	for _, spec := range specs {
+		name, perms, found := strings.Cut(spec, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid -segment-perms %q (use SEGMENT=PERMS, e.g. code=rx)", spec)
+		}
+		p, err := vm.ParsePermissions(perms)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = p
+	}
+	return result, nil
+}
+
+// stringList is a flag.Value collecting every use of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func printHelp() {
 	fmt.Printf(`ARM2 Emulator %s
 
 Usage: arm-emulator [options] <assembly-file>
+       arm-emulator [options] -      (read the program from stdin)
        arm-emulator -api-server [-port N]
 
 Options:
@@ -716,34 +1141,70 @@ Options:
   -port N            API server port (default: 8080, used with -api-server)
   -debug             Start in debugger mode (CLI)
   -tui               Start in TUI debugger mode
+  -gdb PORT          Serve the gdb remote protocol on PORT (target remote :PORT)
   -max-cycles N      Set maximum CPU cycles (default: 1000000)
+  -max-instructions N Halt with an error after N instructions (default: 0, unlimited)
+  -max-syscalls N    Halt with an error after N SWI calls (default: 0, unlimited)
+  -max-file-opens N  Fail SWI_OPEN and the region SWIs after N opens (default: 0, unlimited)
+  -max-bytes-written N Fail console and file writes past N bytes (default: 0, unlimited)
+  -timeout D         Abort a run after wall-clock duration D, e.g. 500ms or 5s (default: 0, no limit)
   -stack-size N      Set stack size in bytes (default: %d)
+  -registers N       Reject instructions using registers above R(N-1) other than SP, LR and PC (default: 16)
+  -uninit MODE       Report reads of registers never written: off, warn (to stderr) or halt (default: off)
   -entry ADDR        Set entry point address (default: 0x8000)
   -verbose           Enable verbose output
   -fsroot DIR        Restrict file operations to directory (default: current directory)
+  -allow-fsroot-change  Allow SWI 0x37 to narrow the filesystem root at runtime
+  -seed N            Seed SWI_GET_RANDOM for reproducible runs (default: time-seeded)
+  -big-endian        Big-endian data byte order (instruction fetch stays little-endian)
+  -segment-perms S=P Enforce permissions P (r, w, x) on segment S after loading, e.g. code=rx (repeatable)
+  -O1                Fold no-op arithmetic into NOP (rewrites listed with -verbose)
+  -args "A B"        Arguments returned to the program by SWI_GET_ARGUMENTS (space-separated)
+  -env KEY=VALUE     Variable returned by SWI_GET_ENVIRONMENT (repeatable; the host environment is never exposed)
+  -D SYM[=VAL]       Define SYM (default value 1) for .if/.ifdef and as an assembler constant (repeatable)
+  -reg-diff          Log each instruction's PC and changed registers to stderr
+  -output-file FILE  Write program console output to FILE instead of stdout (direct run only)
+  -record-input FILE Save everything the program reads from stdin to FILE (direct run only)
+  -replay-input FILE Feed the program's stdin from FILE, e.g. one saved with -record-input
 
 Symbol Options:
   -dump-symbols      Dump symbol table and exit
   -symbols-file FILE Symbol dump output file (default: stdout)
+  -symbols-format F  Symbol dump format: text, csv, markdown (default: text)
+  -dump-literals     Dump literal pool entries and exit
+  -xref              Print where each symbol is defined and used, then exit (1 if any are undefined)
+  -dump-ast          Print the parsed instructions and directives as JSON, then exit
+  -disasm            List address, opcode and disassembly of the assembled program and exit
+  -listing FILE      Write source annotated with addresses and opcodes, plus symbols, to FILE
 
 Tracing & Performance Options:
   -trace             Enable execution trace
   -trace-file FILE   Trace output file (default: trace.log in log dir)
   -trace-filter REGS Filter trace by registers (e.g., R0,R1,PC)
+  -trace-format FMT  Trace format: text, csv, jsonl (default: text)
+  -trace-columns C   Trace columns in order: pc,opcode,mnemonic,regs,cpsr,cycles
+  -replay-trace FILE Re-run against a recorded trace and report the first divergence
+  -diff FILE         Run FILE too with the same stdin and seed and report differences
   -mem-trace         Enable memory access trace
   -mem-trace-file F  Memory trace file (default: memtrace.log)
+  -heatmap-file FILE Memory access heatmap: .ppm image, otherwise CSV
+  -heatmap-block N   Heatmap bucket size in bytes (default: 16)
   -stats             Enable performance statistics
   -stats-file FILE   Statistics output file (default: stats.json)
   -stats-format FMT  Statistics format: json, csv, html (default: json)
+  -profile           Enable call-graph function profiling
+  -profile-file FILE Profile output file (default: profile.json)
 
 Diagnostic Modes:
   -coverage          Enable code coverage tracking
   -coverage-file F   Coverage output file (default: coverage.txt)
   -coverage-format   Coverage format: text, json (default: text)
+  -coverage-fail-under PCT Exit with code 125 if coverage is below PCT percent (implies -coverage)
   -stack-trace       Enable stack operation tracing
   -stack-trace-file  Stack trace file (default: stack_trace.txt)
   -stack-trace-format Stack trace format: text, json (default: text)
-  -stack-guard       Halt execution if stack overflows into heap segment
+  -stack-guard       Halt if SP overflows or underflows the stack (default on; -stack-guard=false to disable)
+  -report-leaks      Report unfreed heap blocks and their allocating PC at exit
   -flag-trace        Enable CPSR flag change tracing
   -flag-trace-file   Flag trace file (default: flag_trace.txt)
   -flag-trace-format Flag trace format: text, json (default: text)
@@ -765,6 +1226,9 @@ Examples:
   # Run with TUI debugger
   arm-emulator -tui examples/bubble_sort.s
 
+  # Debug with gdb (then in gdb: target remote :1234)
+  arm-emulator -gdb 1234 examples/fibonacci.s
+
   # Run with custom settings
   arm-emulator -max-cycles 5000000 -entry 0x10000 program.s
 
@@ -795,6 +1259,16 @@ Examples:
   # Dump symbol table
   arm-emulator -dump-symbols program.s
   arm-emulator -dump-symbols -symbols-file symbols.txt program.s
+  arm-emulator -dump-symbols -symbols-format csv program.s
+
+  # Show where LDR Rd, =value constants were placed
+  arm-emulator -dump-literals program.s
+
+  # Show the machine code without running the program
+  arm-emulator -disasm program.s
+
+  # Write a listing of source lines with their addresses and opcodes
+  arm-emulator -listing program.lst program.s
 
   # Restrict file operations to a specific directory
   arm-emulator -fsroot /tmp/sandbox program.s
@@ -814,8 +1288,445 @@ For more information, see the README.md file.
 `, Version, vm.StackSegmentSize)
 }
 
-// dumpSymbolTable outputs the symbol table in a readable format
-func dumpSymbolTable(st *parser.SymbolTable, filename string) error {
+// dumpLiteralPool prints each literal pool entry, noting which .ltorg pool holds it
+func dumpLiteralPool(program *parser.Program) {
+	if len(program.LiteralPool) == 0 {
+		fmt.Println("No literal pool entries")
+		return
+	}
+
+	addresses := make([]uint32, 0, len(program.LiteralPool))
+	for addr := range program.LiteralPool {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+
+	fmt.Println("Literal Pool")
+	fmt.Println("============")
+	fmt.Println()
+	fmt.Printf("%-12s %-12s %s\n", "Address", "Value", "Pool")
+	fmt.Println("----------------------------------------")
+
+	for _, addr := range addresses {
+		// Literals at or after a .ltorg belong to the last pool starting at or below them;
+		// anything past every .ltorg pool sits in the pool at the end of the program
+		pool := "end of program"
+		for i := len(program.LiteralPoolLocs) - 1; i >= 0; i-- {
+			loc := program.LiteralPoolLocs[i]
+			if addr >= loc && i < len(program.LiteralPoolCounts) && addr < loc+uint32(program.LiteralPoolCounts[i]*4) { // #nosec G115 -- pool counts are small
+				pool = fmt.Sprintf(".ltorg at 0x%08X", loc)
+				if i < len(program.LiteralPoolAuto) && program.LiteralPoolAuto[i] {
+					pool = fmt.Sprintf("automatic pool at 0x%08X", loc)
+				}
+				break
+			}
+		}
+		fmt.Printf("0x%08X   0x%08X   %s\n", addr, program.LiteralPool[addr], pool)
+	}
+}
+
+// listingItem is one line group of a -disasm listing
+type listingItem struct {
+	address uint32
+	kind    string // "inst", "word", "bytes", "space" or "literal"
+	size    uint32
+}
+
+// directiveListingItem describes the data emitted by a directive; ok is false for
+// directives that emit nothing
+func directiveListingItem(dir *parser.Directive) (item listingItem, ok bool) {
+	switch dir.Name {
+	case ".word":
+		return listingItem{dir.Address, "word", uint32(len(dir.Args) * 4)}, true // #nosec G115 -- arg count is small
+	case ".byte":
+		return listingItem{dir.Address, "bytes", uint32(len(dir.Args))}, true // #nosec G115 -- arg count is small
+	case ".ascii", ".asciz", ".string":
+		if len(dir.Args) == 0 {
+			return item, false
+		}
+		str := dir.Args[0]
+		if len(str) >= 2 && (str[0] == '"' || str[0] == '\'') {
+			str = str[1 : len(str)-1]
+		}
+		size := uint32(len(parser.ProcessEscapeSequences(str))) // #nosec G115 -- string length is small
+		if dir.Name != ".ascii" {
+			size++ // Null terminator
+		}
+		return listingItem{dir.Address, "bytes", size}, true
+	case ".incbin":
+		if len(dir.Data) > 0 {
+			return listingItem{dir.Address, "bytes", uint32(len(dir.Data))}, true // #nosec G115 -- bounded by parser.MaxIncbinSize
+		}
+	case ".space", ".skip":
+		if len(dir.Args) > 0 {
+			size, err := strconv.ParseUint(dir.Args[0], 10, 32)
+			if err == nil && size > 0 {
+				return listingItem{dir.Address, "space", uint32(size)}, true
+			}
+		}
+	}
+	return item, false
+}
+
+// disassembleProgram writes a listing of every loaded instruction and data directive:
+// address, opcode (or data bytes) and the disassembled mnemonic or data directive
+func disassembleProgram(w io.Writer, machine *vm.VM, program *parser.Program, symbols map[string]uint32) error {
+	items := make([]listingItem, 0, len(program.Instructions)+len(program.Directives)+len(program.LiteralPool))
+	for _, inst := range program.Instructions {
+		items = append(items, listingItem{inst.Address, "inst", 4})
+	}
+	for _, dir := range program.Directives {
+		if item, ok := directiveListingItem(dir); ok {
+			items = append(items, item)
+		}
+	}
+	for addr := range program.LiteralPool {
+		items = append(items, listingItem{addr, "literal", 4})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].address < items[j].address })
+
+	// First label (alphabetically) at each address names branch targets
+	labels := make(map[uint32][]string)
+	for name, addr := range symbols {
+		labels[addr] = append(labels[addr], name)
+	}
+	branchLabels := make(map[uint32]string, len(labels))
+	for addr, names := range labels {
+		sort.Strings(names)
+		branchLabels[addr] = names[0]
+	}
+
+	bw := bufio.NewWriter(w)
+	printed := make(map[uint32]bool)
+	printLabels := func(addr uint32) {
+		if printed[addr] {
+			return
+		}
+		printed[addr] = true
+		for _, name := range labels[addr] {
+			fmt.Fprintf(bw, "%s:\n", name)
+		}
+	}
+
+	for _, item := range items {
+		printLabels(item.address)
+		switch item.kind {
+		case "inst":
+			opcode, err := machine.Memory.ReadInstruction(item.address)
+			if err != nil {
+				return fmt.Errorf("failed to read 0x%08X: %w", item.address, err)
+			}
+			text, _ := vm.Disassemble(opcode, item.address, branchLabels)
+			if comment, isLiteral := vm.LiteralComment(opcode, item.address, machine.Memory, branchLabels); isLiteral {
+				text += "\t; " + comment
+			}
+			fmt.Fprintf(bw, "0x%08X  %08X     %s\n", item.address, opcode, text)
+
+		case "word", "literal":
+			for addr := item.address; addr < item.address+item.size; addr += 4 {
+				value, err := machine.Memory.ReadWord(addr)
+				if err != nil {
+					return fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+				}
+				comment := ""
+				if item.kind == "literal" {
+					comment = "\t; literal pool"
+				}
+				fmt.Fprintf(bw, "0x%08X  %08X     .word 0x%08X%s\n", addr, value, value, comment)
+			}
+
+		case "bytes":
+			// Four bytes per line, matching the width of a word
+			for addr := item.address; addr < item.address+item.size; addr += 4 {
+				n := min(4, item.address+item.size-addr)
+				data, err := machine.Memory.GetBytes(addr, n)
+				if err != nil {
+					return fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+				}
+				hex := make([]string, len(data))
+				values := make([]string, len(data))
+				for i, b := range data {
+					hex[i] = fmt.Sprintf("%02X", b)
+					values[i] = fmt.Sprintf("0x%02X", b)
+				}
+				fmt.Fprintf(bw, "0x%08X  %-11s  .byte %s\n", addr, strings.Join(hex, " "), strings.Join(values, ", "))
+			}
+
+		case "space":
+			fmt.Fprintf(bw, "0x%08X  %-11s  .space %d\n", item.address, "", item.size)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// listingCode returns the address and hex code column for each output line of an item
+func listingCode(machine *vm.VM, item listingItem) ([][2]string, error) {
+	var rows [][2]string
+	addRow := func(addr uint32, code string) {
+		rows = append(rows, [2]string{fmt.Sprintf("0x%08X", addr), code})
+	}
+	switch item.kind {
+	case "inst":
+		opcode, err := machine.Memory.ReadInstruction(item.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read 0x%08X: %w", item.address, err)
+		}
+		addRow(item.address, fmt.Sprintf("%08X", opcode))
+	case "word", "literal":
+		for addr := item.address; addr < item.address+item.size; addr += 4 {
+			value, err := machine.Memory.ReadWord(addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+			}
+			addRow(addr, fmt.Sprintf("%08X", value))
+		}
+	case "bytes":
+		for addr := item.address; addr < item.address+item.size; addr += 4 {
+			data, err := machine.Memory.GetBytes(addr, min(4, item.address+item.size-addr))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read 0x%08X: %w", addr, err)
+			}
+			hex := make([]string, len(data))
+			for i, b := range data {
+				hex[i] = fmt.Sprintf("%02X", b)
+			}
+			addRow(addr, strings.Join(hex, " "))
+		}
+	case "space":
+		addRow(item.address, "")
+	}
+	return rows, nil
+}
+
+// writeListing writes an assembler listing to filename: every line of content, the source
+// named sourceName, with the address and code it produced, then the literal pool and the
+// symbol table. Code from included files is not listed.
+func writeListing(filename string, machine *vm.VM, program *parser.Program, sourceName string, content []byte) error {
+	sourceLines := strings.Split(string(content), "\n")
+
+	itemsByLine := make(map[int][]listingItem)
+	for _, inst := range program.Instructions {
+		if inst.Pos.Filename == sourceName {
+			itemsByLine[inst.Pos.Line] = append(itemsByLine[inst.Pos.Line], listingItem{inst.Address, "inst", 4})
+		}
+	}
+	for _, dir := range program.Directives {
+		if item, ok := directiveListingItem(dir); ok && dir.Pos.Filename == sourceName {
+			itemsByLine[dir.Pos.Line] = append(itemsByLine[dir.Pos.Line], item)
+		}
+	}
+
+	f, err := os.Create(filename) // #nosec G304 -- user-specified listing output path
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	writeRow := func(line, address, code, source string) {
+		fmt.Fprintln(bw, strings.TrimRight(fmt.Sprintf("%5s  %-10s  %-11s  %s", line, address, code, source), " "))
+	}
+
+	writeRow("Line", "Address", "Code", "Source")
+	for i, source := range sourceLines {
+		lineNum := strconv.Itoa(i + 1)
+		source = strings.TrimRight(source, "\r")
+		items := itemsByLine[i+1]
+		if i == len(sourceLines)-1 && source == "" && len(items) == 0 {
+			break // Trailing newline
+		}
+		sort.SliceStable(items, func(a, b int) bool { return items[a].address < items[b].address })
+
+		var rows [][2]string
+		for _, item := range items {
+			itemRows, err := listingCode(machine, item)
+			if err != nil {
+				_ = f.Close()
+				return err
+			}
+			rows = append(rows, itemRows...)
+		}
+		if len(rows) == 0 {
+			writeRow(lineNum, "", "", source)
+			continue
+		}
+		// Source text goes on the first row; further code from the same line follows it
+		writeRow(lineNum, rows[0][0], rows[0][1], source)
+		for _, row := range rows[1:] {
+			writeRow("", row[0], row[1], "")
+		}
+	}
+
+	if len(program.LiteralPool) > 0 {
+		addrs := make([]uint32, 0, len(program.LiteralPool))
+		for addr := range program.LiteralPool {
+			addrs = append(addrs, addr)
+		}
+		sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "Literal Pool")
+		fmt.Fprintln(bw, "============")
+		for _, addr := range addrs {
+			writeRow("", fmt.Sprintf("0x%08X", addr), fmt.Sprintf("%08X", program.LiteralPool[addr]), "")
+		}
+	}
+
+	fmt.Fprintln(bw)
+	writeSymbolTable(bw, program.SymbolTable)
+
+	err = bw.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runTraceReplay replays the trace in filename against the loaded program and returns
+// the exit status: 0 if every entry matched, 1 on divergence or error
+func runTraceReplay(machine *vm.VM, filename, filter string, symbols map[string]uint32) int {
+	f, err := os.Open(filename) // #nosec G304 -- user-specified trace file path
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening trace: %v\n", err)
+		return 1
+	}
+	entries, err := vm.ParseExecutionTrace(f, symbols)
+	_ = f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading trace: %v\n", err)
+		return 1
+	}
+
+	replay := vm.NewTraceReplay(entries)
+	if filter != "" {
+		replay.FilterRegs = strings.Split(filter, ",")
+	}
+	// Only the comparison is reported, not the program's own output
+	machine.OutputWriter = io.Discard
+
+	divergence, err := replay.Run(machine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Replay failed after %d matching entries: %v\n", replay.Matched, err)
+		return 1
+	}
+	if divergence != nil {
+		fmt.Fprintf(os.Stderr, "Replay diverged at %s\n", divergence)
+		return 1
+	}
+	fmt.Printf("Replay matched all %d trace entries\n", replay.Matched)
+	return 0
+}
+
+// runDiff runs fileA and fileB with the same options and stdin and prints how the second
+// run differs. It returns the exit status in the style of diff(1): 0 if the runs match,
+// 1 if they differ, 2 if either program could not be run.
+func runDiff(fileA, fileB string, opts loader.RunOptions) int {
+	// Both programs see the same input, so read piped stdin once up front
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 2
+		}
+		opts.Input = string(input)
+	}
+
+	results := make([]*loader.RunResult, 2)
+	for i, file := range []string{fileA, fileB} {
+		result, err := loader.RunFile(file, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running %s:\n%v\n", file, err)
+			return 2
+		}
+		results[i] = result
+	}
+
+	diffs := results[0].Diff(results[1])
+	if len(diffs) == 0 {
+		fmt.Printf("Runs of %s and %s are identical\n", fileA, fileB)
+		return 0
+	}
+	fmt.Printf("Runs of %s and %s differ:\n", fileA, fileB)
+	for _, d := range diffs {
+		fmt.Printf("  %s\n", d)
+	}
+	return 1
+}
+
+// writeHeatmap exports the heatmap to filename: a PPM image for .ppm, otherwise CSV
+func writeHeatmap(heatmap *vm.MemoryHeatmap, filename string) error {
+	f, err := os.Create(filename) // #nosec G304 -- user-specified heatmap output path
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".ppm") {
+		err = heatmap.ExportPPM(f)
+	} else {
+		err = heatmap.ExportCSV(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Exit codes for runtime faults, following the shell convention of 128 + signal number
+const (
+	exitRuntimeError = 1
+	exitUndefined    = 132 // SIGILL
+	exitStackFault   = 134 // SIGABRT
+	exitAlignment    = 135 // SIGBUS
+	exitMemoryFault  = 139 // SIGSEGV
+)
+
+// exitCoverageFailed is the exit code when coverage is below -coverage-fail-under. It sits
+// outside 0-124, the range programs exit with in practice, and below the 128 + signal fault codes
+const exitCoverageFailed = 125
+
+// stdinArg is the assembly-file argument that reads the program from stdin, and stdinName
+// is the filename it is given in errors and listings
+const (
+	stdinArg  = "-"
+	stdinName = "<stdin>"
+)
+
+// timeoutGrace is how long after -timeout the watchdog waits for the run loop to notice
+// the deadline before ending a run that is blocked in a host operation
+const timeoutGrace = 500 * time.Millisecond
+
+// runtimeExitCode maps the category of a runtime fault to the process exit code
+func runtimeExitCode(err error) int {
+	fault, ok := vm.AsRuntimeError(err)
+	if !ok {
+		return exitRuntimeError
+	}
+	switch fault.Category() {
+	case vm.CategoryUndefinedInstruction:
+		return exitUndefined
+	case vm.CategoryStack:
+		return exitStackFault
+	case vm.CategoryAlignment:
+		return exitAlignment
+	case vm.CategoryMemoryFault:
+		return exitMemoryFault
+	default:
+		return exitRuntimeError
+	}
+}
+
+// Symbol dump formats accepted by -symbols-format
+const (
+	symbolsFormatText     = "text"
+	symbolsFormatCSV      = "csv"
+	symbolsFormatMarkdown = "markdown"
+)
+
+// dumpSymbolTable outputs the symbol table in the given format (text, csv or markdown)
+func dumpSymbolTable(st *parser.SymbolTable, filename, format string) error {
+	switch format {
+	case symbolsFormatText, symbolsFormatCSV, symbolsFormatMarkdown:
+	default:
+		return fmt.Errorf("unknown symbols format %q (use text, csv or markdown)", format)
+	}
+
 	var writer *os.File
 	var err error
 
@@ -833,39 +1744,30 @@ func dumpSymbolTable(st *parser.SymbolTable, filename string) error {
 		}()
 	}
 
-	allSymbols := st.GetAllSymbols()
-	if len(allSymbols) == 0 {
-		_, _ = fmt.Fprintln(writer, "No symbols defined")
-		return nil
+	switch format {
+	case symbolsFormatCSV:
+		return writeSymbolTableCSV(writer, st)
+	case symbolsFormatMarkdown:
+		writeSymbolTableMarkdown(writer, st)
+	default:
+		writeSymbolTable(writer, st)
 	}
+	return nil
+}
 
-	// Print header
-	_, _ = fmt.Fprintln(writer, "Symbol Table")
-	_, _ = fmt.Fprintln(writer, "============")
-	_, _ = fmt.Fprintln(writer)
-	_, _ = fmt.Fprintf(writer, "%-30s %-12s %-10s %s\n", "Name", "Type", "Address", "Status")
-	_, _ = fmt.Fprintln(writer, "--------------------------------------------------------------------------------")
+// symbolEntry is one row of a symbol table dump
+type symbolEntry struct {
+	name    string
+	symType string
+	value   uint32
+	status  string
+}
 
-	// Sort symbols by address for easier reading
-	type symbolEntry struct {
-		name   string
-		symbol *parser.Symbol
-	}
+// sortedSymbols returns the symbol table sorted by address, then name
+func sortedSymbols(st *parser.SymbolTable) []symbolEntry {
+	allSymbols := st.GetAllSymbols()
 	entries := make([]symbolEntry, 0, len(allSymbols))
 	for name, sym := range allSymbols {
-		entries = append(entries, symbolEntry{name, sym})
-	}
-
-	// Sort by address using O(n log n) algorithm
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].symbol.Value < entries[j].symbol.Value
-	})
-
-	// Print each symbol
-	for _, entry := range entries {
-		name := entry.name
-		sym := entry.symbol
-
 		var symType string
 		switch sym.Type {
 		case parser.SymbolLabel:
@@ -882,12 +1784,107 @@ func dumpSymbolTable(st *parser.SymbolTable, filename string) error {
 		if !sym.Defined {
 			status = "Undefined"
 		}
+		entries = append(entries, symbolEntry{name, symType, sym.Value, status})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value != entries[j].value {
+			return entries[i].value < entries[j].value
+		}
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
 
-		_, _ = fmt.Fprintf(writer, "%-30s %-12s 0x%08X %s\n", name, symType, sym.Value, status)
+// writeSymbolTable writes the symbol table, sorted by address, to writer
+func writeSymbolTable(writer io.Writer, st *parser.SymbolTable) {
+	entries := sortedSymbols(st)
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(writer, "No symbols defined")
+		return
 	}
 
+	// Print header
+	_, _ = fmt.Fprintln(writer, "Symbol Table")
+	_, _ = fmt.Fprintln(writer, "============")
 	_, _ = fmt.Fprintln(writer)
-	_, _ = fmt.Fprintf(writer, "Total symbols: %d\n", len(allSymbols))
+	_, _ = fmt.Fprintf(writer, "%-30s %-12s %-10s %s\n", "Name", "Type", "Address", "Status")
+	_, _ = fmt.Fprintln(writer, "--------------------------------------------------------------------------------")
 
-	return nil
+	for _, entry := range entries {
+		_, _ = fmt.Fprintf(writer, "%-30s %-12s 0x%08X %s\n", entry.name, entry.symType, entry.value, entry.status)
+	}
+
+	_, _ = fmt.Fprintln(writer)
+	_, _ = fmt.Fprintf(writer, "Total symbols: %d\n", len(entries))
+}
+
+// writeSymbolTableCSV writes the symbol table as CSV with a header row
+func writeSymbolTableCSV(writer io.Writer, st *parser.SymbolTable) error {
+	cw := csv.NewWriter(writer)
+	if err := cw.Write([]string{"name", "type", "address", "status"}); err != nil {
+		return err
+	}
+	for _, entry := range sortedSymbols(st) {
+		row := []string{entry.name, entry.symType, fmt.Sprintf("0x%08X", entry.value), entry.status}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeSymbolTableMarkdown writes the symbol table as a GitHub-flavoured Markdown table
+func writeSymbolTableMarkdown(writer io.Writer, st *parser.SymbolTable) {
+	_, _ = fmt.Fprintln(writer, "| Name | Type | Address | Status |")
+	_, _ = fmt.Fprintln(writer, "|------|------|---------|--------|")
+	for _, entry := range sortedSymbols(st) {
+		// Escape pipes so unusual names cannot break the table
+		name := strings.ReplaceAll(entry.name, "|", "\\|")
+		_, _ = fmt.Fprintf(writer, "| `%s` | %s | `0x%08X` | %s |\n", name, entry.symType, entry.value, entry.status)
+	}
+}
+
+// writeXRef writes each symbol with its definition and every use, sorted by name, and
+// returns how many used symbols are undefined
+func writeXRef(writer io.Writer, st *parser.SymbolTable) int {
+	names := make([]string, 0)
+	for name, sym := range st.GetAllSymbols() {
+		if sym.Defined {
+			names = append(names, name)
+		}
+	}
+	undefined := st.UndefinedUses()
+	names = append(names, undefined...)
+	sort.Strings(names)
+
+	_, _ = fmt.Fprintln(writer, "Cross-Reference")
+	_, _ = fmt.Fprintln(writer, "===============")
+	_, _ = fmt.Fprintln(writer)
+	_, _ = fmt.Fprintf(writer, "%-30s %-20s %s\n", "Name", "Defined", "Used")
+	_, _ = fmt.Fprintln(writer, "--------------------------------------------------------------------------------")
+
+	location := func(pos parser.Position) string {
+		return fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+	}
+	for _, name := range names {
+		defined := "UNDEFINED"
+		if sym, ok := st.Lookup(name); ok && sym.Defined {
+			defined = location(sym.Pos)
+		}
+		uses := st.Uses(name)
+		used := make([]string, len(uses))
+		for i, pos := range uses {
+			used[i] = location(pos)
+		}
+		if len(used) == 0 {
+			used = append(used, "(unused)")
+		}
+		_, _ = fmt.Fprintf(writer, "%-30s %-20s %s\n", name, defined, strings.Join(used, ", "))
+	}
+
+	_, _ = fmt.Fprintln(writer)
+	_, _ = fmt.Fprintf(writer, "Total symbols: %d, undefined: %d\n", len(names), len(undefined))
+	return len(undefined)
 }
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// AST is the machine-readable structure of a parsed program, for external tools such as
+// linters and editors. It is what -dump-ast and the API's /ast endpoint return.
+type AST struct {
+	Instructions []ASTInstruction `json:"instructions"`
+	Directives   []ASTDirective   `json:"directives"`
+}
+
+// ASTInstruction is one parsed instruction. Mnemonic is the base mnemonic, with the
+// condition and S suffix given separately.
+type ASTInstruction struct {
+	Label     string   `json:"label,omitempty"`
+	Mnemonic  string   `json:"mnemonic"`
+	Condition string   `json:"condition,omitempty"`
+	SetFlags  bool     `json:"setFlags,omitempty"`
+	Operands  []string `json:"operands"`
+	Address   uint32   `json:"address"`
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+	Column    int      `json:"column"`
+}
+
+// ASTDirective is one parsed directive. Args are as the parser leaves them, so sizes and
+// alignments that were expressions are already resolved.
+type ASTDirective struct {
+	Label   string   `json:"label,omitempty"`
+	Name    string   `json:"name"`
+	Args    []string `json:"args"`
+	Address uint32   `json:"address"`
+	Section string   `json:"section,omitempty"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Column  int      `json:"column"`
+}
+
+// NewAST builds the AST of a parsed program, keeping the source order of each list
+func NewAST(program *Program) *AST {
+	ast := &AST{
+		Instructions: make([]ASTInstruction, 0, len(program.Instructions)),
+		Directives:   make([]ASTDirective, 0, len(program.Directives)),
+	}
+	for _, inst := range program.Instructions {
+		operands := inst.Operands
+		if operands == nil {
+			operands = []string{}
+		}
+		ast.Instructions = append(ast.Instructions, ASTInstruction{
+			Label:     inst.Label,
+			Mnemonic:  inst.Mnemonic,
+			Condition: inst.Condition,
+			SetFlags:  inst.SetFlags,
+			Operands:  operands,
+			Address:   inst.Address,
+			File:      inst.Pos.Filename,
+			Line:      inst.Pos.Line,
+			Column:    inst.Pos.Column,
+		})
+	}
+	for _, dir := range program.Directives {
+		args := dir.Args
+		if args == nil {
+			args = []string{}
+		}
+		ast.Directives = append(ast.Directives, ASTDirective{
+			Label:   dir.Label,
+			Name:    dir.Name,
+			Args:    args,
+			Address: dir.Address,
+			Section: dir.Section,
+			File:    dir.Pos.Filename,
+			Line:    dir.Pos.Line,
+			Column:  dir.Pos.Column,
+		})
+	}
+	return ast
+}
+
+// ExportJSON writes the AST as indented JSON
+func (a *AST) ExportJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false) // Keep names such as <stdin> readable
+	return encoder.Encode(a)
+}
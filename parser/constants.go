@@ -9,6 +9,11 @@ const (
 	// LiteralPoolRangeBytes defines the address range (in bytes) for grouping literals into the same pool.
 	// Literals within this range are considered part of the same pool section.
 	LiteralPoolRangeBytes = 1024
+
+	// AutoPoolDistanceBytes is how far behind an unconditional branch the oldest LDR Rd, =value
+	// without a pool may be before a pool is placed after the branch. It is half the 4KB
+	// reach of the load, leaving room for the pool's own entries.
+	AutoPoolDistanceBytes = 2048
 )
 
 // Macro Processing Constants
@@ -16,4 +21,26 @@ const (
 	// MaxMacroNestingDepth is the maximum depth for nested macro expansions.
 	// Prevents infinite recursion in macro processing.
 	MaxMacroNestingDepth = 100
+
+	// MaxRepeatExpansions is the maximum number of lines .rept blocks and macro invocations
+	// may produce in one source, counting nested ones. Prevents memory blowups from large
+	// counts or macros that invoke each other many times.
+	MaxRepeatExpansions = 100000
+)
+
+// Binary Inclusion Constants
+const (
+	// MaxIncbinSize is the largest number of bytes one .incbin may embed
+	MaxIncbinSize = 1 << 20
+)
+
+// Section Constants
+const (
+	SectionText = ".text"
+	SectionData = ".data"
+	SectionBSS  = ".bss"
+
+	// DataSectionStart is the base address of .data, the start of the VM's data segment.
+	// .bss follows .data, word aligned.
+	DataSectionStart = 0x00020000
 )
@@ -42,7 +42,7 @@ const (
 func (e *Error) Error() string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("%s: error: %s\n", e.Pos, e.Message))
+	sb.WriteString(fmt.Sprintf("%s: %s\n", e.Pos, e.Message))
 
 	if e.Context != "" {
 		sb.WriteString(fmt.Sprintf("    %s\n", e.Context))
@@ -0,0 +1,291 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sourceLine is a line of expanded source and the 1-based input line it came from
+type sourceLine struct {
+	text string
+	line int
+}
+
+// expandSource is the pre-expansion stage run before lexing. It records .macro/.endm
+// definitions in the macro table, replaces macro invocations with their bodies and
+// repeats .rept/.endr blocks, expanding nested blocks in turn. It returns the expanded
+// lines, or nil if the input has nothing to expand.
+func (p *Parser) expandSource(input, filename string) []sourceLine {
+	lower := strings.ToLower(input)
+	if !strings.Contains(lower, ".macro") && !strings.Contains(lower, ".endm") &&
+		!strings.Contains(lower, ".rept") && !strings.Contains(lower, ".endr") {
+		return nil
+	}
+
+	raw := strings.Split(input, "\n")
+	lines := make([]sourceLine, len(raw))
+	for i, text := range raw {
+		lines[i] = sourceLine{text: text, line: i + 1}
+	}
+
+	expander := &blockExpander{parser: p, filename: filename}
+	return expander.expand(lines)
+}
+
+// blockExpander holds the state of one expandSource call
+type blockExpander struct {
+	parser   *Parser
+	filename string
+	counter  int // Value of the next \@, unique to each macro expansion or .rept iteration
+	expanded int // Lines produced by .rept and macro invocations so far, bounded by MaxRepeatExpansions
+}
+
+// expand expands every block in lines, reporting errors against the parser
+func (e *blockExpander) expand(lines []sourceLine) []sourceLine {
+	result := make([]sourceLine, 0, len(lines))
+	inComment := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		pos := Position{Filename: e.filename, Line: line.line, Column: 1}
+		fields := strings.Fields(stripComments(line.text, &inComment))
+		if len(fields) == 0 {
+			result = append(result, line)
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case ".macro":
+			end := e.findEnd(lines, i, ".macro", ".endm")
+			if end < 0 {
+				e.error(pos, ".macro without matching .endm")
+				return result
+			}
+			e.defineMacro(fields[1:], lines[i+1:end], pos)
+			i = end
+
+		case ".rept":
+			end := e.findEnd(lines, i, ".rept", ".endr")
+			if end < 0 {
+				e.error(pos, ".rept without matching .endr")
+				return result
+			}
+			result = append(result, e.repeat(fields[1:], lines[i+1:end], pos)...)
+			i = end
+
+		case ".endm":
+			e.error(pos, ".endm without matching .macro")
+		case ".endr":
+			e.error(pos, ".endr without matching .rept")
+
+		default:
+			label, name, args := splitInvocation(stripComments(line.text, new(bool)))
+			macro, ok := e.parser.macroTable.Lookup(name)
+			if !ok {
+				result = append(result, line)
+				continue
+			}
+			if label != "" {
+				result = append(result, sourceLine{text: label, line: line.line})
+			}
+			result = append(result, e.invoke(macro, args, pos)...)
+		}
+	}
+
+	return result
+}
+
+// findEnd returns the index of the line closing the block opened at lines[start], allowing
+// nested blocks of the same kind, or -1 if it is never closed
+func (e *blockExpander) findEnd(lines []sourceLine, start int, open, close string) int {
+	depth := 0
+	inComment := false
+	for i := start; i < len(lines); i++ {
+		fields := strings.Fields(stripComments(lines[i].text, &inComment))
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// defineMacro records a .macro NAME [param[, param...]] definition
+func (e *blockExpander) defineMacro(header []string, body []sourceLine, pos Position) {
+	if len(header) == 0 {
+		e.error(pos, ".macro requires a name")
+		return
+	}
+
+	var params []string
+	for _, field := range header[1:] {
+		for _, param := range strings.Split(field, ",") {
+			if param = strings.TrimSpace(param); param != "" {
+				params = append(params, param)
+			}
+		}
+	}
+
+	macro := &Macro{Name: strings.TrimSuffix(header[0], ","), Parameters: params, Pos: pos}
+	for _, line := range body {
+		macro.Body = append(macro.Body, line.text)
+	}
+	if err := e.parser.macroTable.Define(macro); err != nil {
+		e.error(pos, err.Error())
+	}
+}
+
+// invoke expands a macro call, then the blocks inside its body. Body lines keep the input
+// line they were defined on.
+func (e *blockExpander) invoke(macro *Macro, args []string, pos Position) []sourceLine {
+	// The call stays on the expander's stack while the body is expanded, so a macro that
+	// invokes itself is caught
+	me := e.parser.macroExpander
+	if err := me.push(macro.Name, pos); err != nil {
+		e.error(pos, err.Error())
+		return nil
+	}
+	defer me.pop()
+
+	body, err := e.parser.macroTable.Expand(macro.Name, args, pos)
+	if err != nil {
+		e.error(pos, err.Error())
+		return nil
+	}
+
+	// Nested invocations multiply, so each one is charged against the shared budget
+	if !e.charge(len(body), pos) {
+		return nil
+	}
+
+	lines := make([]sourceLine, len(body))
+	for i, text := range body {
+		lines[i] = sourceLine{text: text, line: macro.Pos.Line + 1 + i}
+	}
+	substituteCounter(lines, strconv.Itoa(e.counter))
+	e.counter++
+	return e.expand(lines)
+}
+
+// repeat expands a .rept COUNT block COUNT times
+func (e *blockExpander) repeat(args []string, body []sourceLine, pos Position) []sourceLine {
+	if len(args) != 1 {
+		e.error(pos, ".rept requires a repeat count")
+		return nil
+	}
+	count, err := strconv.ParseInt(args[0], 0, 64)
+	if err != nil || count < 0 {
+		e.error(pos, fmt.Sprintf("invalid .rept count: %s", args[0]))
+		return nil
+	}
+
+	// Nested repeats add to the total as they expand, so it is checked on every iteration
+	if count*int64(len(body)) > int64(MaxRepeatExpansions-e.expanded) {
+		e.charge(MaxRepeatExpansions+1, pos)
+		return nil
+	}
+
+	var result []sourceLine
+	for n := int64(0); n < count; n++ {
+		if !e.charge(len(body), pos) {
+			return result
+		}
+		lines := make([]sourceLine, len(body))
+		copy(lines, body)
+		substituteCounter(lines, strconv.Itoa(e.counter))
+		e.counter++
+		result = append(result, e.expand(lines)...)
+	}
+	return result
+}
+
+// charge adds lines to the expansion total, reporting at pos and returning false once it
+// exceeds MaxRepeatExpansions. The error is reported only once per source.
+func (e *blockExpander) charge(lines int, pos Position) bool {
+	if e.expanded > MaxRepeatExpansions {
+		return false
+	}
+	if e.expanded += lines; e.expanded > MaxRepeatExpansions {
+		e.error(pos, fmt.Sprintf(".rept and macro expansion produces more than %d lines", MaxRepeatExpansions))
+		return false
+	}
+	return true
+}
+
+// error reports an expansion error at pos
+func (e *blockExpander) error(pos Position, message string) {
+	e.parser.errors.AddError(NewError(pos, ErrorMacroExpansion, message))
+}
+
+// substituteCounter replaces \@ with value in lines, except inside nested .rept and .macro
+// blocks, which substitute their own counter when they are expanded
+func substituteCounter(lines []sourceLine, value string) {
+	depth := 0
+	inComment := false
+	for i := range lines {
+		fields := strings.Fields(stripComments(lines[i].text, &inComment))
+		if len(fields) > 0 {
+			switch strings.ToLower(fields[0]) {
+			case ".rept", ".macro":
+				depth++
+			case ".endr", ".endm":
+				depth--
+			}
+		}
+		if depth == 0 {
+			lines[i].text = strings.ReplaceAll(lines[i].text, "\\@", value)
+		}
+	}
+}
+
+// splitInvocation splits a line of code into an optional leading "label:", the first word
+// and its comma-separated arguments. Commas inside brackets, braces or quotes do not
+// separate arguments.
+func splitInvocation(code string) (label, name string, args []string) {
+	code = strings.TrimSpace(code)
+	if first, rest, found := strings.Cut(code, ":"); found && first != "" && !strings.ContainsAny(first, " \t\"'[{") {
+		label = first + ":"
+		code = strings.TrimSpace(rest)
+	}
+
+	name, rest, _ := strings.Cut(code, " ")
+	if tab := strings.IndexByte(name, '\t'); tab >= 0 {
+		name, rest = name[:tab], name[tab+1:]+" "+rest
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return label, name, nil
+	}
+
+	depth := 0
+	var quote rune
+	start := 0
+	for i, ch := range rest {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[' || ch == '{' || ch == '(':
+			depth++
+		case ch == ']' || ch == '}' || ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(rest[start:i]))
+			start = i + 1
+		}
+	}
+	args = append(args, strings.TrimSpace(rest[start:]))
+	return label, name, args
+}
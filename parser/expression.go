@@ -0,0 +1,275 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// EvaluateExpression evaluates a constant integer expression such as "(BUFSIZE*2)|1".
+// Supported: numbers (decimal, 0x, 0b, 0o), character literals ('A', '\n'), symbol
+// names resolved through lookup, parentheses, unary - + ~ !, and the binary operators
+// * / % + - << >> < <= > >= == != & ^ | && || with C precedence. Comparisons and
+// logical operators yield 1 for true and 0 for false.
+// Intermediate results must fit in 32 bits (signed or unsigned); anything larger is an
+// overflow error, as are division by zero and shifts of 32 or more.
+func EvaluateExpression(expr string, lookup func(name string) (uint32, error)) (uint32, error) {
+	e := &exprEvaluator{input: expr, lookup: lookup}
+	value, err := e.parseBinary(0)
+	if err != nil {
+		return 0, err
+	}
+	e.skipSpace()
+	if e.pos < len(e.input) {
+		return 0, fmt.Errorf("unexpected %q in expression %q", e.input[e.pos:], expr)
+	}
+	return uint32(value), nil // #nosec G115 -- range checked by checkRange
+}
+
+// IsExpression reports whether s contains operators or parentheses and so needs
+// EvaluateExpression rather than a plain number or symbol lookup. A single leading
+// minus sign (a negative literal) does not count.
+func IsExpression(s string) bool {
+	return strings.ContainsAny(strings.TrimPrefix(strings.TrimSpace(s), "-"), "+-*/%<>&|^~()")
+}
+
+// binaryLevels lists the binary operators from lowest to highest precedence
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+const (
+	exprMin = math.MinInt32
+	exprMax = math.MaxUint32
+)
+
+type exprEvaluator struct {
+	input  string
+	pos    int
+	lookup func(name string) (uint32, error)
+}
+
+func (e *exprEvaluator) skipSpace() {
+	for e.pos < len(e.input) && (e.input[e.pos] == ' ' || e.input[e.pos] == '\t') {
+		e.pos++
+	}
+}
+
+// matchOp consumes one of the operators if it comes next
+func (e *exprEvaluator) matchOp(ops []string) (string, bool) {
+	e.skipSpace()
+	for _, op := range ops {
+		rest := e.input[e.pos:]
+		if strings.HasPrefix(rest, op) {
+			// A lone & or | must not swallow the first half of && or ||
+			if (op == "&" || op == "|") && len(rest) > 1 && rest[1] == op[0] {
+				continue
+			}
+			e.pos += len(op)
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// parseBinary parses a chain of operators at the given precedence level
+func (e *exprEvaluator) parseBinary(level int) (int64, error) {
+	if level == len(binaryLevels) {
+		return e.parseUnary()
+	}
+	left, err := e.parseBinary(level + 1)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op, ok := e.matchOp(binaryLevels[level])
+		if !ok {
+			return left, nil
+		}
+		right, err := e.parseBinary(level + 1)
+		if err != nil {
+			return 0, err
+		}
+		if left, err = e.apply(op, left, right); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// apply evaluates a binary operator; bitwise operators and >> work on the 32-bit pattern
+func (e *exprEvaluator) apply(op string, a, b int64) (int64, error) {
+	ua, ub := uint32(a), uint32(b) // #nosec G115 -- operands are range checked 32-bit values
+	var result int64
+	switch op {
+	case "||":
+		result = boolValue(a != 0 || b != 0)
+	case "&&":
+		result = boolValue(a != 0 && b != 0)
+	case "==":
+		result = boolValue(ua == ub)
+	case "!=":
+		result = boolValue(ua != ub)
+	case "<":
+		result = boolValue(a < b)
+	case "<=":
+		result = boolValue(a <= b)
+	case ">":
+		result = boolValue(a > b)
+	case ">=":
+		result = boolValue(a >= b)
+	case "|":
+		result = int64(ua | ub)
+	case "^":
+		result = int64(ua ^ ub)
+	case "&":
+		result = int64(ua & ub)
+	case "<<", ">>":
+		if b < 0 || b > 31 {
+			return 0, fmt.Errorf("shift amount %d out of range in expression %q", b, e.input)
+		}
+		if op == ">>" {
+			result = int64(ua >> b)
+		} else {
+			result = a << b
+		}
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+		if a != 0 && result/a != b {
+			return 0, fmt.Errorf("overflow in expression %q", e.input)
+		}
+	case "/", "%":
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero in expression %q", e.input)
+		}
+		if op == "/" {
+			result = a / b
+		} else {
+			result = a % b
+		}
+	}
+	return e.checkRange(result)
+}
+
+// boolValue converts a comparison result to 1 or 0
+func boolValue(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (e *exprEvaluator) checkRange(v int64) (int64, error) {
+	if v < exprMin || v > exprMax {
+		return 0, fmt.Errorf("overflow in expression %q: result does not fit in 32 bits", e.input)
+	}
+	return v, nil
+}
+
+func (e *exprEvaluator) parseUnary() (int64, error) {
+	op, ok := e.matchOp([]string{"-", "+", "~", "!"})
+	if !ok {
+		return e.parsePrimary()
+	}
+	value, err := e.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	switch op {
+	case "-":
+		return e.checkRange(-value)
+	case "~":
+		return int64(^uint32(value)), nil // #nosec G115 -- range checked 32-bit value
+	case "!":
+		return boolValue(value == 0), nil
+	}
+	return value, nil
+}
+
+func (e *exprEvaluator) parsePrimary() (int64, error) {
+	e.skipSpace()
+	if e.pos < len(e.input) {
+		switch e.input[e.pos] {
+		case '\'':
+			return e.parseCharLiteral()
+		case '(':
+			e.pos++
+			value, err := e.parseBinary(0)
+			if err != nil {
+				return 0, err
+			}
+			if _, ok := e.matchOp([]string{")"}); !ok {
+				return 0, fmt.Errorf("missing ')' in expression %q", e.input)
+			}
+			return value, nil
+		}
+	}
+
+	start := e.pos
+	for e.pos < len(e.input) && isExprWordChar(rune(e.input[e.pos])) {
+		e.pos++
+	}
+	word := e.input[start:e.pos]
+	if word == "" {
+		if e.pos >= len(e.input) {
+			return 0, fmt.Errorf("incomplete expression %q", e.input)
+		}
+		return 0, fmt.Errorf("unexpected %q in expression %q", e.input[e.pos:], e.input)
+	}
+
+	var value uint32
+	var err error
+	if unicode.IsDigit(rune(word[0])) {
+		if value, err = parseNumber(word); err != nil {
+			return 0, fmt.Errorf("invalid number %q in expression", word)
+		}
+	} else if e.lookup == nil {
+		return 0, fmt.Errorf("undefined symbol: %q", word)
+	} else if value, err = e.lookup(word); err != nil {
+		return 0, err
+	}
+	return int64(value), nil
+}
+
+// parseCharLiteral parses 'c' or an escape such as '\n' at the current position
+func (e *exprEvaluator) parseCharLiteral() (int64, error) {
+	body := e.input[e.pos+1:]
+	value, consumed := byte(0), 1
+	if strings.HasPrefix(body, "\\") {
+		b, n, err := ParseEscapeChar(body)
+		if err != nil {
+			return 0, err
+		}
+		value, consumed = b, n
+	} else if body != "" {
+		value = body[0]
+	}
+	if len(body) <= consumed || body[consumed] != '\'' {
+		return 0, fmt.Errorf("invalid character literal in expression %q", e.input)
+	}
+	e.pos += consumed + 2
+	return int64(value), nil
+}
+
+// isExprWordChar reports whether r can appear in a number or symbol name
+func isExprWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// joinExpression rebuilds an expression from the tokens of one directive argument
+func joinExpression(parts []string) string {
+	return strings.Join(parts, " ")
+}
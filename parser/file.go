@@ -1,13 +1,17 @@
 package parser
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // ParseFileOptions configures file parsing behavior
 type ParseFileOptions struct {
-	// Defines are symbols to define for conditional assembly (.ifdef/.ifndef)
+	// Defines are SYMBOL or SYMBOL=VALUE specs (see ParseDefine). Each symbol can be
+	// tested by .if/.ifdef/.ifndef and is also defined as an assembler constant.
 	Defines []string
 	// EnablePreprocessor enables .include and conditional directives (default: true)
 	EnablePreprocessor bool
@@ -23,7 +27,7 @@ func DefaultParseFileOptions() ParseFileOptions {
 // ParseFile reads and parses an assembly file with preprocessing support.
 // This is the recommended entry point for parsing files, handling:
 // - File reading
-// - Preprocessing (.include, .ifdef, .ifndef, .else, .endif)
+// - Preprocessing (.include, .if, .ifdef, .ifndef, .else, .endif)
 // - Parsing
 //
 // Returns the parsed program or an error. Check parser.Errors() for additional warnings.
@@ -33,18 +37,44 @@ func ParseFile(filePath string, opts ParseFileOptions) (*Program, *Parser, error
 	if err != nil {
 		return nil, nil, err
 	}
+	return parseSource(string(content), filepath.Base(filePath), filepath.Dir(filePath), opts)
+}
+
+// ParseReader reads and parses assembly source from r in the same way as ParseFile.
+// filename names the source in errors and positions (e.g. "<stdin>"), and .include and
+// .incbin paths are resolved against the current directory.
+func ParseReader(r io.Reader, filename string, opts ParseFileOptions) (*Program, *Parser, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseSource(string(content), filename, ".", opts)
+}
+
+// parseSource preprocesses and parses source, resolving included files against baseDir
+func parseSource(source, filename, baseDir string, opts ParseFileOptions) (*Program, *Parser, error) {
+	type define struct {
+		name  string
+		value uint32
+	}
+	defines := make([]define, 0, len(opts.Defines))
+	for _, spec := range opts.Defines {
+		name, value, err := ParseDefine(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		defines = append(defines, define{name, value})
+	}
 
-	filename := filepath.Base(filePath)
-	source := string(content)
+	var lineMap []Position
 
 	// Apply preprocessing if enabled
 	if opts.EnablePreprocessor {
-		baseDir := filepath.Dir(filePath)
 		pp := NewPreprocessor(baseDir)
 
 		// Apply defines
-		for _, def := range opts.Defines {
-			pp.Define(def)
+		for _, def := range defines {
+			pp.DefineValue(def.name, def.value)
 		}
 
 		// Process content (handles .include, .ifdef, etc.)
@@ -54,16 +84,26 @@ func ParseFile(filePath string, opts ParseFileOptions) (*Program, *Parser, error
 		}
 
 		// Check for preprocessor errors
-		if len(pp.Errors().Errors) > 0 {
+		if pp.Errors().HasErrors() {
 			// Preprocessor errors are fatal
-			return nil, nil, pp.Errors().Errors[0]
+			return nil, nil, pp.Errors()
 		}
 
 		source = processed
+		lineMap = pp.LineMap()
 	}
 
 	// Parse the (possibly preprocessed) source
 	p := NewParser(source, filename)
+	p.SetBaseDir(baseDir)
+	if lineMap != nil {
+		p.SetLineMap(lineMap)
+	}
+	for _, def := range defines {
+		if err := p.DefineConstant(def.name, def.value); err != nil {
+			return nil, p, err
+		}
+	}
 	program, err := p.Parse()
 	if err != nil {
 		return nil, p, err
@@ -76,3 +116,19 @@ func ParseFile(filePath string, opts ParseFileOptions) (*Program, *Parser, error
 func ParseFileSimple(filePath string) (*Program, *Parser, error) {
 	return ParseFile(filePath, DefaultParseFileOptions())
 }
+
+// confinedPath resolves filename against baseDir, rejecting paths that escape it
+func confinedPath(baseDir, filename string) (string, error) {
+	absPath, err := filepath.Abs(filepath.Join(baseDir, filename))
+	if err != nil {
+		return "", err
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(absPath, absBase+string(filepath.Separator)) && absPath != absBase {
+		return "", fmt.Errorf("path escapes the source directory: %s", filename)
+	}
+	return absPath, nil
+}
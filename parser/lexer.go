@@ -42,6 +42,8 @@ const (
 	TokenLShift    // <<
 	TokenRShift    // >>
 	TokenEqual     // =
+	TokenLParen    // (
+	TokenRParen    // )
 
 	// Directives
 	TokenDirective // .org, .equ, .word, etc.
@@ -78,6 +80,8 @@ var tokenNames = map[TokenType]string{
 	TokenLShift:     "<<",
 	TokenRShift:     ">>",
 	TokenEqual:      "=",
+	TokenLParen:     "(",
+	TokenRParen:     ")",
 	TokenDirective:  "DIRECTIVE",
 	TokenCondition:  "CONDITION",
 }
@@ -91,9 +95,10 @@ func (t TokenType) String() string {
 
 // Token represents a lexical token
 type Token struct {
-	Type    TokenType
-	Literal string
-	Pos     Position
+	Type      TokenType
+	Literal   string
+	Pos       Position
+	EndColumn int // Column just past the token's last character (unset for newlines, comments and EOF)
 }
 
 func (t Token) String() string {
@@ -254,6 +259,29 @@ func (l *Lexer) readNumber() string {
 	return l.input[start : l.pos-1]
 }
 
+// readNumericLabelRef completes a numeric local label reference such as 1f or 2b after its
+// digits have been read. "0b" is read as an empty binary literal by readNumber, so it is
+// recognised here as a backward reference to label 0.
+func (l *Lexer) readNumericLabelRef(number string) (string, bool) {
+	if number == "0b" {
+		if isIdentifierChar(l.ch) {
+			return "", false
+		}
+		return "0b", true
+	}
+	for _, ch := range number {
+		if !unicode.IsDigit(ch) {
+			return "", false
+		}
+	}
+	if (l.ch != 'f' && l.ch != 'b') || isIdentifierChar(l.peekChar()) {
+		return "", false
+	}
+	suffix := string(l.ch)
+	l.readChar()
+	return number + suffix, true
+}
+
 // isHexDigit returns true if the character is a hex digit
 func isHexDigit(ch rune) bool {
 	return unicode.IsDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
@@ -296,6 +324,23 @@ func (l *Lexer) readString(quote rune) string {
 	return l.input[start : l.pos-1]
 }
 
+// restOfLineBlank reports whether only whitespace or a comment follows on the current line
+func (l *Lexer) restOfLineBlank() bool {
+	for i := l.pos - 1; i < len(l.input); i++ {
+		switch c := l.input[i]; c {
+		case ' ', '\t':
+			continue
+		case '\n', '\r', ';', '@':
+			return true
+		case '/':
+			return i+1 < len(l.input) && (l.input[i+1] == '/' || l.input[i+1] == '*')
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
 	l.skipWhitespace()
@@ -317,7 +362,7 @@ func (l *Lexer) NextToken() Token {
 		}
 		l.readChar()
 		l.line++
-		l.column = 0
+		l.column = 1 // readChar has already read the first character of the new line
 		return tok
 
 	case ';', '@':
@@ -334,8 +379,20 @@ func (l *Lexer) NextToken() Token {
 		} else if l.peekChar() == '*' {
 			l.readChar() // consume /
 			l.readChar() // consume *
-			tok.Type = TokenComment
-			tok.Literal = l.skipBlockComment()
+			startLine := l.line
+			comment := l.skipBlockComment()
+			switch {
+			case l.line != startLine:
+				// A comment spanning lines ends the statement, like the newlines it contains
+				tok.Type = TokenNewline
+				tok.Literal = "\n"
+			case l.restOfLineBlank():
+				tok.Type = TokenComment
+				tok.Literal = comment
+			default:
+				// Code follows on the same line, so the comment is just whitespace
+				return l.NextToken()
+			}
 			return tok
 		} else {
 			tok.Type = TokenSlash
@@ -453,6 +510,16 @@ func (l *Lexer) NextToken() Token {
 		tok.Literal = "="
 		l.readChar()
 
+	case '(':
+		tok.Type = TokenLParen
+		tok.Literal = "("
+		l.readChar()
+
+	case ')':
+		tok.Type = TokenRParen
+		tok.Literal = ")"
+		l.readChar()
+
 	case '"', '\'':
 		quote := l.ch
 		l.readChar() // consume opening quote
@@ -498,6 +565,12 @@ func (l *Lexer) NextToken() Token {
 			tok.Type = TokenNumber
 			tok.Literal = number
 
+			// Numeric local label reference (1f, 1b): decimal digits directly followed by f/b
+			if ref, ok := l.readNumericLabelRef(number); ok {
+				tok.Type = TokenIdentifier
+				tok.Literal = ref
+			}
+
 		} else {
 			l.errors.AddError(NewError(pos, ErrorSyntax, fmt.Sprintf("unexpected character: %q", l.ch)))
 			l.readChar()
@@ -505,6 +578,7 @@ func (l *Lexer) NextToken() Token {
 		}
 	}
 
+	tok.EndColumn = l.column
 	return tok
 }
 
@@ -116,28 +116,39 @@ func NewMacroExpander(macroTable *MacroTable) *MacroExpander {
 
 // Expand expands a macro call, checking for recursion
 func (me *MacroExpander) Expand(name string, args []string, pos Position) ([]string, error) {
+	if err := me.push(name, pos); err != nil {
+		return nil, err
+	}
+	defer me.pop()
+
+	return me.macroTable.Expand(name, args, pos)
+}
+
+// push records a call to name, failing if it is too deep or name is already being expanded
+func (me *MacroExpander) push(name string, pos Position) error {
 	// Check recursion depth
 	if me.expansionDepth >= me.maxDepth {
-		return nil, fmt.Errorf("macro expansion too deep (possible recursion) at %s: %s",
+		return fmt.Errorf("macro expansion too deep (possible recursion) at %s: %s",
 			pos, strings.Join(me.callStack, " -> "))
 	}
 
 	// Check for direct recursion
 	for _, caller := range me.callStack {
 		if caller == name {
-			return nil, fmt.Errorf("recursive macro call detected at %s: %s -> %s",
+			return fmt.Errorf("recursive macro call detected at %s: %s -> %s",
 				pos, strings.Join(me.callStack, " -> "), name)
 		}
 	}
 
 	me.expansionDepth++
 	me.callStack = append(me.callStack, name)
-	defer func() {
-		me.expansionDepth--
-		me.callStack = me.callStack[:len(me.callStack)-1]
-	}()
+	return nil
+}
 
-	return me.macroTable.Expand(name, args, pos)
+// pop ends the innermost call recorded by push
+func (me *MacroExpander) pop() {
+	me.expansionDepth--
+	me.callStack = me.callStack[:len(me.callStack)-1]
 }
 
 // Reset resets the expander state
@@ -0,0 +1,126 @@
+package parser
+
+import "fmt"
+
+// mnemonicSuffixes records the suffixes an instruction accepts
+type mnemonicSuffixes struct {
+	setFlags    bool // S, to update the condition flags
+	conditional bool // A condition code
+}
+
+var (
+	dataProcessing = mnemonicSuffixes{setFlags: true, conditional: true}
+	conditionalOp  = mnemonicSuffixes{conditional: true}
+)
+
+// instructionSuffixes lists every instruction mnemonic and the suffixes it accepts.
+// Comparisons always set the flags, so an S on them is accepted and changes nothing.
+var instructionSuffixes = map[string]mnemonicSuffixes{
+	"MOV": dataProcessing, "MVN": dataProcessing,
+	"ADD": dataProcessing, "ADC": dataProcessing, "SUB": dataProcessing, "SBC": dataProcessing,
+	"RSB": dataProcessing, "RSC": dataProcessing,
+	"AND": dataProcessing, "ORR": dataProcessing, "EOR": dataProcessing, "BIC": dataProcessing,
+	"CMP": dataProcessing, "CMN": dataProcessing, "TST": dataProcessing, "TEQ": dataProcessing,
+	"MUL": dataProcessing, "MLA": dataProcessing,
+
+	"LDR": conditionalOp, "STR": conditionalOp, "LDRB": conditionalOp, "STRB": conditionalOp,
+	"LDRH": conditionalOp, "STRH": conditionalOp,
+	"LDM": conditionalOp, "STM": conditionalOp,
+	"LDMIA": conditionalOp, "LDMIB": conditionalOp, "LDMDA": conditionalOp, "LDMDB": conditionalOp,
+	"STMIA": conditionalOp, "STMIB": conditionalOp, "STMDA": conditionalOp, "STMDB": conditionalOp,
+	"LDMFD": conditionalOp, "LDMFA": conditionalOp, "LDMEA": conditionalOp, "LDMED": conditionalOp, // Load Multiple aliases (FD=Full Descending, etc.)
+	"STMFD": conditionalOp, "STMFA": conditionalOp, "STMEA": conditionalOp, "STMED": conditionalOp, // Store Multiple aliases
+	"PUSH": conditionalOp, "POP": conditionalOp, "NOP": conditionalOp,
+	"B": conditionalOp, "BL": conditionalOp, "BX": conditionalOp, "BLX": conditionalOp,
+	"QADD": conditionalOp, "QSUB": conditionalOp, // Saturating arithmetic
+	"SDIV": conditionalOp, "UDIV": conditionalOp, // Division (ARMv7 extension)
+	"MRS": conditionalOp, "MSR": conditionalOp, // PSR transfer
+	"SWI": conditionalOp, "SVC": conditionalOp, // SVC is ARM7+ name for SWI (Supervisor Call)
+	"ADR":  conditionalOp,
+	"BKPT": {}, // Software breakpoint, always unconditional
+}
+
+// conditionSuffixes are the condition codes accepted after a mnemonic
+var conditionSuffixes = map[string]bool{
+	"EQ": true, "NE": true, "CS": true, "HS": true, "CC": true, "LO": true, "MI": true, "PL": true,
+	"VS": true, "VC": true, "HI": true, "LS": true, "GE": true, "LT": true, "GT": true, "LE": true, "AL": true,
+}
+
+// infixRoots are the mnemonics that take the condition before their size or addressing
+// mode in the pre-UAL syntax, e.g. LDREQB for LDRBEQ and STMNEFD for STMFDNE
+var infixRoots = []string{"LDR", "STR", "LDM", "STM"}
+
+// AcceptsSetFlags reports whether the S suffix is valid on the base mnemonic
+func AcceptsSetFlags(mnemonic string) bool {
+	return instructionSuffixes[mnemonic].setFlags
+}
+
+// parseInstructionMnemonic splits an upper-case mnemonic into its base instruction,
+// condition code and S flag. The condition and S may come in either order (ADDEQS or
+// ADDSEQ). Where the letters can be read more than one way, the first valid reading wins,
+// so BLS is B with the LS condition rather than BL with S. A mnemonic that names a known
+// instruction only with suffixes it does not accept is an error; one that is not an
+// instruction at all is returned unchanged for the encoder to reject.
+func parseInstructionMnemonic(mnemonic string) (condition string, setFlags bool, baseMnemonic string, err error) {
+	if _, ok := instructionSuffixes[mnemonic]; ok {
+		return "", false, mnemonic, nil
+	}
+
+	// Longest base first, e.g. LDRH+I is tried (and rejected) before LDR+HI
+	for n := len(mnemonic) - 1; n > 0; n-- {
+		base := mnemonic[:n]
+		suffixes, ok := instructionSuffixes[base]
+		if !ok {
+			continue
+		}
+		cond, s, ok := splitSuffix(mnemonic[n:])
+		if !ok {
+			continue
+		}
+		if s && !suffixes.setFlags {
+			if err == nil {
+				err = fmt.Errorf("%s does not accept the S suffix", base)
+			}
+			continue
+		}
+		if cond != "" && !suffixes.conditional {
+			if err == nil {
+				err = fmt.Errorf("%s cannot be conditional", base)
+			}
+			continue
+		}
+		return cond, s, base, nil
+	}
+
+	for _, root := range infixRoots {
+		if len(mnemonic) > len(root)+2 && mnemonic[:len(root)] == root {
+			cond, tail := mnemonic[len(root):len(root)+2], mnemonic[len(root)+2:]
+			if _, ok := instructionSuffixes[root+tail]; ok && conditionSuffixes[cond] {
+				return cond, false, root + tail, nil
+			}
+		}
+	}
+
+	if err != nil {
+		return "", false, mnemonic, fmt.Errorf("invalid mnemonic %s: %w", mnemonic, err)
+	}
+	return "", false, mnemonic, nil
+}
+
+// splitSuffix splits what follows a base mnemonic into a condition and S flag, accepting
+// "", S, a condition, or a condition and S in either order
+func splitSuffix(suffix string) (condition string, setFlags bool, ok bool) {
+	switch {
+	case suffix == "":
+		return "", false, true
+	case suffix == "S":
+		return "", true, true
+	case conditionSuffixes[suffix]:
+		return suffix, false, true
+	case len(suffix) == 3 && suffix[2] == 'S' && conditionSuffixes[suffix[:2]]:
+		return suffix[:2], true, true
+	case len(suffix) == 3 && suffix[0] == 'S' && conditionSuffixes[suffix[1:]]:
+		return suffix[1:], true, true
+	}
+	return "", false, false
+}
@@ -2,9 +2,15 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"math"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // Instruction represents a parsed ARM instruction
@@ -16,11 +22,30 @@ type Instruction struct {
 	Operands   []string
 	Comment    string
 	Pos        Position
+	EndColumn  int // Column just past the last operand, so Pos.Column to EndColumn spans the instruction
 	RawLine    string
 	EncodedLen int    // Length in bytes (4 for ARM instructions)
 	Address    uint32 // Address where this instruction should be placed
 }
 
+// Columns returns the 1-based column range [start, end) of the instruction within
+// RawLine, from the mnemonic to the end of the last operand. A macro body line whose
+// arguments were substituted has no columns in RawLine, so it spans the whole line instead.
+func (i *Instruction) Columns() (start, end int) {
+	line := i.RawLine
+	if i.Pos.Column >= 1 && i.EndColumn > i.Pos.Column && i.EndColumn-1 <= len(line) {
+		fields := strings.Fields(line[i.Pos.Column-1 : i.EndColumn-1])
+		if len(fields) > 0 {
+			if _, _, base, err := parseInstructionMnemonic(strings.ToUpper(fields[0])); err == nil && base == i.Mnemonic {
+				return i.Pos.Column, i.EndColumn
+			}
+		}
+	}
+
+	trimmed := strings.TrimRight(line, " \t\r")
+	return len(trimmed) - len(strings.TrimLeft(trimmed, " \t")) + 1, len(trimmed) + 1
+}
+
 // Directive represents an assembler directive
 type Directive struct {
 	Name    string
@@ -30,6 +55,8 @@ type Directive struct {
 	Label   string // Optional label before directive
 	Comment string
 	Address uint32 // Address where this directive's data should be placed
+	Section string // Section the directive was assembled in (.text, .data or .bss)
+	Data    []byte // Bytes embedded by .incbin
 }
 
 // Program represents a parsed assembly program
@@ -38,20 +65,28 @@ type Program struct {
 	Directives         []*Directive
 	SymbolTable        *SymbolTable
 	MacroTable         *MacroTable
-	Origin             uint32         // Current assembly address (.org)
-	OriginSet          bool           // Whether .org directive was explicitly used
-	LiteralPoolLocs    []uint32       // Addresses where .ltorg directives appear
-	LiteralPoolCounts  []int          // Number of unique literals needed for each pool
-	LiteralPoolIndices map[uint32]int // Maps pool address to index in LiteralPoolCounts
+	Origin             uint32            // Current assembly address (.org)
+	OriginSet          bool              // Whether .org directive was explicitly used
+	LiteralPoolLocs    []uint32          // Addresses of literal pools: .ltorg/.pool directives and automatic pools
+	LiteralPoolAuto    []bool            // Whether each pool in LiteralPoolLocs was placed automatically after a branch
+	LiteralPoolCounts  []int             // Number of unique literals needed for each pool
+	LiteralPoolIndices map[uint32]int    // Maps pool address to index in LiteralPoolCounts
+	LiteralPool        map[uint32]uint32 // Emitted literals (address -> value), filled in by the loader
+	Optimizations      []string          // Peephole rewrites made by the loader with -O1
+	DataSize           uint32            // Bytes assembled into .data, which starts at DataSectionStart
+	BSSStart           uint32            // Address of the zero-initialised .bss section
+	BSSSize            uint32            // Size of .bss in bytes; its contents are not stored
 }
 
 // Parser parses ARM assembly language
 type Parser struct {
 	lexer          *Lexer
+	input          string // Source as given, before macro and .rept expansion
 	tokens         []Token
 	pos            int
 	currentToken   Token
 	peekToken      Token
+	prevEnd        int // EndColumn of the token before currentToken
 	errors         *ErrorList
 	symbolTable    *SymbolTable
 	macroTable     *MacroTable
@@ -59,15 +94,24 @@ type Parser struct {
 	macroExpander  *MacroExpander
 	preprocessor   *Preprocessor
 	currentAddress uint32
-	originSet      bool     // Track if .org directive has been encountered
-	inputLines     []string // Cached split lines for getRawLineFromInput
+	originSet      bool                // Track if .org directive has been encountered
+	section        string              // Current section (.text, .data or .bss)
+	sectionAddrs   map[string]uint32   // Location counters of the inactive sections
+	sectionsUsed   bool                // Whether .data or .bss appeared
+	bssLabels      []string            // Labels defined in .bss, relocated once .data is sized
+	bssNumLabels   [][2]int            // Numeric labels defined in .bss as (label, index)
+	regAliases     map[string]string   // Register aliases from NAME .req REG, by name
+	inputLines     []string            // Cached split lines for getRawLineFromInput
+	rawLines       map[Position]string // Source text by original file and line, set by SetLineMap
+	baseDir        string              // Directory .incbin files are read from, set by SetBaseDir
+	literalPending bool                // An LDR Rd, =value has appeared since the last literal pool
+	literalFrom    uint32              // Address of the oldest such LDR
 }
 
 // NewParser creates a new parser
 func NewParser(input, filename string) *Parser {
-	lexer := NewLexer(input, filename)
 	p := &Parser{
-		lexer:          lexer,
+		input:          input,
 		tokens:         make([]Token, 0),
 		pos:            0,
 		errors:         &ErrorList{},
@@ -79,9 +123,41 @@ func NewParser(input, filename string) *Parser {
 	p.macroExpander = NewMacroExpander(p.macroTable)
 	p.preprocessor = NewPreprocessor("")
 
-	// Tokenize all input
+	// Expand macros and .rept blocks, then tokenize the result
+	expanded := p.expandSource(input, filename)
+	lexInput := input
+	if expanded != nil {
+		texts := make([]string, len(expanded))
+		for i, line := range expanded {
+			texts[i] = line.text
+		}
+		lexInput = strings.Join(texts, "\n")
+	}
+	lexer := NewLexer(lexInput, filename)
+	p.lexer = lexer
 	p.tokens = lexer.TokenizeAll()
 
+	// Positions name the input line each expanded line came from
+	if expanded != nil {
+		mapPos := func(pos *Position) {
+			if pos.Line >= 1 && pos.Line <= len(expanded) {
+				pos.Line = expanded[pos.Line-1].line
+			}
+		}
+		raw := strings.Split(input, "\n")
+		for i := range p.tokens {
+			tok := &p.tokens[i]
+			// Argument substitution moves columns, so they no longer index the line named
+			if line := tok.Pos.Line; line >= 1 && line <= len(expanded) && expanded[line-1].text != raw[expanded[line-1].line-1] {
+				tok.EndColumn = 0
+			}
+			mapPos(&tok.Pos)
+		}
+		for _, err := range lexer.Errors().Errors {
+			mapPos(&err.Pos)
+		}
+	}
+
 	// Merge lexer errors
 	for _, err := range lexer.Errors().Errors {
 		p.errors.AddError(err)
@@ -94,8 +170,50 @@ func NewParser(input, filename string) *Parser {
 	return p
 }
 
+// SetLineMap maps positions in the parsed input back to the original source, using the
+// Preprocessor's LineMap, so that errors and source maps name the file and line the code
+// came from. It must be called before Parse.
+func (p *Parser) SetLineMap(lineMap []Position) {
+	lines := p.SourceLines()
+	p.rawLines = make(map[Position]string, len(lineMap))
+	for i, orig := range lineMap {
+		if i < len(lines) {
+			p.rawLines[Position{Filename: orig.Filename, Line: orig.Line}] = lines[i]
+		}
+	}
+
+	mapPos := func(pos *Position) {
+		if pos.Line >= 1 && pos.Line <= len(lineMap) {
+			orig := lineMap[pos.Line-1]
+			pos.Filename, pos.Line = orig.Filename, orig.Line
+		}
+	}
+	for i := range p.tokens {
+		mapPos(&p.tokens[i].Pos)
+	}
+	mapPos(&p.currentToken.Pos)
+	mapPos(&p.peekToken.Pos)
+	for _, err := range p.errors.Errors {
+		mapPos(&err.Pos)
+	}
+}
+
+// SetBaseDir sets the directory .incbin paths are resolved against; files outside it
+// cannot be embedded. Without one, .incbin is an error, so source that does not come
+// from a file (such as an API upload) cannot read the host filesystem.
+func (p *Parser) SetBaseDir(dir string) {
+	p.baseDir = dir
+}
+
+// DefineConstant defines a constant before parsing, as if by .equ, for symbols given on
+// the command line with -D. It must be called before Parse.
+func (p *Parser) DefineConstant(name string, value uint32) error {
+	return p.symbolTable.Define(name, SymbolConstant, value, Position{Filename: "<command line>"})
+}
+
 // nextToken advances to the next token
 func (p *Parser) nextToken() {
+	p.prevEnd = p.currentToken.EndColumn
 	p.currentToken = p.peekToken
 	if p.pos < len(p.tokens) {
 		p.peekToken = p.tokens[p.pos]
@@ -128,6 +246,7 @@ func (p *Parser) Parse() (*Program, error) {
 	if err != nil {
 		return nil, err
 	}
+	p.placeBSS(program)
 
 	// Check for errors before second pass
 	if p.errors.HasErrors() {
@@ -150,6 +269,9 @@ func (p *Parser) Parse() (*Program, error) {
 		p.adjustAddressesForDynamicPools(program)
 	}
 
+	// Replace numeric local label references (1f, 1b) with their final addresses
+	p.resolveNumericLabelRefs(program)
+
 	// Second pass: generate final instructions (would happen during execution)
 	// For now, we've collected all the parsed instructions
 
@@ -163,6 +285,8 @@ func (p *Parser) Parse() (*Program, error) {
 // firstPass performs the first pass of two-pass assembly
 func (p *Parser) firstPass(program *Program) error {
 	p.currentAddress = 0
+	p.section = SectionText
+	p.sectionAddrs = map[string]uint32{SectionData: DataSectionStart}
 
 	for p.currentToken.Type != TokenEOF {
 		p.skipNewlines()
@@ -175,13 +299,16 @@ func (p *Parser) firstPass(program *Program) error {
 		var label string
 		if p.currentToken.Type == TokenIdentifier && p.peekToken.Type == TokenColon {
 			label = p.currentToken.Literal
+			labelPos := p.currentToken.Pos
 			p.nextToken() // consume identifier
 			p.nextToken() // consume colon
 
 			// Define label in symbol table at current address
-			err := p.symbolTable.Define(label, SymbolLabel, p.currentAddress, p.currentToken.Pos)
+			err := p.symbolTable.Define(label, SymbolLabel, p.currentAddress, labelPos)
 			if err != nil {
-				p.errors.AddError(NewError(p.currentToken.Pos, ErrorDuplicateLabel, err.Error()))
+				p.errors.AddError(NewError(labelPos, ErrorDuplicateLabel, err.Error()))
+			} else if p.section == SectionBSS {
+				p.bssLabels = append(p.bssLabels, label)
 			}
 
 			// NOTE: Don't skip newlines here. The lexer has already skipped horizontal whitespace.
@@ -189,6 +316,20 @@ func (p *Parser) firstPass(program *Program) error {
 			// If the label is standalone, currentToken will be a newline, which will be handled
 			// by the skipNewlines() at the end of the loop. This prevents the bug where standalone
 			// labels cause the next line's label to be consumed as an instruction mnemonic.
+		} else if p.currentToken.Type == TokenNumber && p.peekToken.Type == TokenColon {
+			// Numeric local label (1:, 2:): kept out of the global symbol table and
+			// referenced as 1f/1b, resolved after addresses are final
+			num, err := strconv.Atoi(p.currentToken.Literal)
+			if err != nil {
+				p.errors.AddError(NewError(p.currentToken.Pos, ErrorSyntax, fmt.Sprintf("invalid numeric label: %s", p.currentToken.Literal)))
+			} else {
+				if p.section == SectionBSS {
+					p.bssNumLabels = append(p.bssNumLabels, [2]int{num, len(p.numericLabels.labels[num])})
+				}
+				p.numericLabels.Define(num, p.currentAddress, p.currentToken.Pos)
+			}
+			p.nextToken() // consume number
+			p.nextToken() // consume colon
 		}
 
 		// After processing label, check what comes next
@@ -196,27 +337,35 @@ func (p *Parser) firstPass(program *Program) error {
 			break
 		}
 
-		// Check for directive
-		if p.currentToken.Type == TokenDirective {
+		// Check for a register alias (NAME .req REG) or directive
+		if p.currentToken.Type == TokenIdentifier && p.peekToken.Type == TokenDirective &&
+			strings.EqualFold(p.peekToken.Literal, ".req") {
+			p.parseRegisterAlias()
+		} else if p.currentToken.Type == TokenDirective {
 			directive := p.parseDirective()
 			if directive != nil {
 				directive.Label = label
-				directive.Address = p.currentAddress                          // Record address before processing
-				directive.RawLine = p.getRawLineFromInput(directive.Pos.Line) // Capture raw source line
+				directive.Address = p.currentAddress // Record address before processing
+				directive.Section = p.section
+				directive.RawLine = p.rawLine(directive.Pos) // Capture raw source line
 				program.Directives = append(program.Directives, directive)
 				p.handleDirective(directive, program)
 			}
 		} else if p.currentToken.Type == TokenIdentifier {
 			// Parse instruction
 			inst := p.parseInstruction()
-			if inst != nil {
+			if inst != nil && p.section != SectionText {
+				p.errors.AddError(NewError(inst.Pos, ErrorSyntax,
+					fmt.Sprintf("instruction %s is not allowed in %s", inst.Mnemonic, p.section)))
+			} else if inst != nil {
 				inst.Label = label
-				inst.EncodedLen = 4                                 // ARM instructions are 4 bytes
-				inst.Address = p.currentAddress                     // Record address
-				inst.RawLine = p.getRawLineFromInput(inst.Pos.Line) // Capture raw source line
+				inst.EncodedLen = 4                // ARM instructions are 4 bytes
+				inst.Address = p.currentAddress    // Record address
+				inst.RawLine = p.rawLine(inst.Pos) // Capture raw source line
 				program.Instructions = append(program.Instructions, inst)
 				// Safe: EncodedLen is always 4 for ARM instructions
 				p.currentAddress += uint32(inst.EncodedLen) // #nosec G115 -- EncodedLen is always 4
+				p.trackLiteralLoads(inst, program)
 			}
 		} else if p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenComment {
 			// Skip unknown tokens (but not newlines/comments)
@@ -245,27 +394,35 @@ func (p *Parser) parseDirective() *Directive {
 
 	p.nextToken() // consume directive name
 
-	// Parse arguments
+	// Parse arguments: tokens up to each comma form one argument, so that
+	// expressions such as "label + 4" or "(1 << 4) | 1" stay together
+	var parts []string
+	firstExpr, usesSymbols := directiveExprArgs[directive.Name]
 	for p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF && p.currentToken.Type != TokenComment {
 		if p.currentToken.Type == TokenComma {
+			if len(parts) > 0 {
+				directive.Args = append(directive.Args, joinExpression(parts))
+				parts = nil
+			}
 			p.nextToken()
 			continue
 		}
+		if usesSymbols && len(directive.Args) >= firstExpr &&
+			p.currentToken.Type == TokenIdentifier && isSymbolUse(p.currentToken.Literal) {
+			p.symbolTable.AddUse(p.currentToken.Literal, p.currentToken.Pos)
+		}
 
 		arg := p.currentToken.Literal
-
-		// Handle negative numbers by combining minus sign with following number
-		if p.currentToken.Type == TokenMinus && p.peekToken.Type == TokenNumber {
-			p.nextToken() // consume minus
-			arg = "-" + p.currentToken.Literal
-		} else if p.currentToken.Type == TokenString {
+		if p.currentToken.Type == TokenString {
 			// Preserve quotes for character literals
 			arg = "'" + p.currentToken.Literal + "'"
 		}
-
-		directive.Args = append(directive.Args, arg)
+		parts = append(parts, arg)
 		p.nextToken()
 	}
+	if len(parts) > 0 {
+		directive.Args = append(directive.Args, joinExpression(parts))
+	}
 
 	// Consume comment if present
 	if p.currentToken.Type == TokenComment {
@@ -278,32 +435,45 @@ func (p *Parser) parseDirective() *Directive {
 
 // handleDirective processes directives that affect assembly state
 func (p *Parser) handleDirective(d *Directive, program *Program) {
+	if p.section == SectionBSS {
+		switch d.Name {
+		case ".word", ".half", ".byte", ".ascii", ".asciz", ".string", ".incbin":
+			p.errors.AddError(NewError(d.Pos, ErrorSyntax,
+				fmt.Sprintf("%s is not allowed in .bss, which holds no data (use .space)", d.Name)))
+			return
+		}
+	}
+
 	switch d.Name {
-	case ".text":
-		// Text section - if currentAddress is 0 and origin not set,
-		// this is the first section, so set origin to 0.
-		// If currentAddress > 0 (data came first), keep the current address.
-		if !p.originSet {
-			if p.currentAddress == 0 {
-				// First section is .text
-				p.currentAddress = 0
-			}
-			// else: data came first, keep current address which has data
-			program.Origin = p.currentAddress
-			program.OriginSet = true
-			p.originSet = true
+	case ".text", ".data", ".bss":
+		p.switchSection(d.Name, program)
+
+	case ".section":
+		if len(d.Args) == 0 {
+			p.errors.AddError(NewError(d.Pos, ErrorSyntax, ".section requires a section name"))
+			return
+		}
+		switch name := d.Args[0]; name {
+		case SectionText, SectionData, SectionBSS:
+			p.switchSection(name, program)
+		default:
+			p.errors.AddError(NewError(d.Pos, ErrorSyntax,
+				fmt.Sprintf("unsupported section %s (expected .text, .data or .bss)", name)))
 		}
-		// If .text appears after data, currentAddress is already positioned after data
 
-	case ".data":
-		// Data section - if origin isn't set yet and currentAddress is 0,
-		// set origin to 0 for data segment
-		if !p.originSet && p.currentAddress == 0 {
-			program.Origin = 0
-			program.OriginSet = true
-			p.originSet = true
+	case ".req":
+		p.errors.AddError(NewError(d.Pos, ErrorSyntax, ".req needs a name: NAME .req REGISTER"))
+
+	case ".unreq":
+		if len(d.Args) != 1 {
+			p.errors.AddError(NewError(d.Pos, ErrorSyntax, ".unreq requires a register alias name"))
+			return
 		}
-		// Continue at current address (interleaved with code if that's the layout)
+		if _, ok := p.regAliases[d.Args[0]]; !ok {
+			p.errors.AddError(NewError(d.Pos, ErrorSyntax, fmt.Sprintf("%s is not a register alias", d.Args[0])))
+			return
+		}
+		delete(p.regAliases, d.Args[0])
 
 	case ".global":
 		// Global symbol declaration - mark symbol as global (exported)
@@ -313,7 +483,7 @@ func (p *Parser) handleDirective(d *Directive, program *Program) {
 	case ".org":
 		// Set origin address
 		if len(d.Args) > 0 {
-			if addr, err := parseNumber(d.Args[0]); err == nil {
+			if addr, err := p.evaluateArg(d.Args[0]); err == nil {
 				p.currentAddress = addr
 				// Set program origin if this is the first .org directive
 				if !p.originSet {
@@ -327,15 +497,22 @@ func (p *Parser) handleDirective(d *Directive, program *Program) {
 		}
 
 	case ".equ", ".set":
-		// Define constant
+		// Define constant: .equ NAME, expression (.set may redefine an existing constant)
 		if len(d.Args) >= 2 {
 			name := d.Args[0]
-			if value, err := parseNumber(d.Args[1]); err == nil {
-				if err := p.symbolTable.Define(name, SymbolConstant, value, d.Pos); err != nil {
-					p.errors.AddError(NewError(d.Pos, ErrorDuplicateLabel, err.Error()))
-				}
+			expr := d.Args[1]
+			value, err := p.evaluateArg(expr)
+			if err != nil {
+				p.errors.AddError(NewError(d.Pos, ErrorSyntax, fmt.Sprintf("invalid constant value: %s: %v", expr, err)))
+				return
+			}
+			if d.Name == ".set" {
+				err = p.symbolTable.Set(name, value, d.Pos)
 			} else {
-				p.errors.AddError(NewError(d.Pos, ErrorSyntax, fmt.Sprintf("invalid constant value: %s", d.Args[1])))
+				err = p.symbolTable.Define(name, SymbolConstant, value, d.Pos)
+			}
+			if err != nil {
+				p.errors.AddError(NewError(d.Pos, ErrorDuplicateLabel, err.Error()))
 			}
 		}
 
@@ -367,30 +544,38 @@ func (p *Parser) handleDirective(d *Directive, program *Program) {
 			}
 		}
 
+	case ".incbin":
+		data, err := p.readIncbin(d)
+		if err != nil {
+			p.errors.AddError(NewError(d.Pos, ErrorFileIO, err.Error()))
+			return
+		}
+		d.Data = data
+		p.currentAddress += uint32(len(data)) // #nosec G115 -- bounded by MaxIncbinSize
+
 	case ".space", ".skip":
 		// Reserve specified number of bytes
 		if len(d.Args) > 0 {
-			var size uint32
-			var err error
-
-			// Try to parse as number first
-			size, err = parseNumber(d.Args[0])
+			// The size may be a number, a constant or an expression (e.g. BUFSIZE*2)
+			expr := d.Args[0]
+			size, err := p.evaluateArg(expr)
 			if err != nil {
-				// If not a number, try to resolve as symbol (e.g., .equ constant)
-				size, err = p.symbolTable.Get(d.Args[0])
-				if err != nil {
-					p.errors.AddError(NewError(d.Pos, ErrorInvalidOperand,
-						fmt.Sprintf("invalid size for .space: %s", d.Args[0])))
-					return
-				}
+				p.errors.AddError(NewError(d.Pos, ErrorInvalidOperand,
+					fmt.Sprintf("invalid size for .space: %s", expr)))
+				return
 			}
+			// Leave the resolved size for the loader
+			d.Args = []string{strconv.FormatUint(uint64(size), 10)}
 			p.currentAddress += size
 		}
 
 	case ".align":
 		// Align to power of 2 (e.g., .align 2 means align to 2^2 = 4 bytes)
 		if len(d.Args) > 0 {
-			if alignPower, err := parseNumber(d.Args[0]); err == nil {
+			if alignPower, err := p.evaluateArg(d.Args[0]); err == nil {
+				if !p.resolveAlignFill(d, alignPower) {
+					return
+				}
 				alignBytes := uint32(1 << alignPower) // 2^alignPower
 				mask := alignBytes - 1
 				p.currentAddress = (p.currentAddress + mask) & ^mask
@@ -400,28 +585,255 @@ func (p *Parser) handleDirective(d *Directive, program *Program) {
 	case ".balign":
 		// Align to specified boundary
 		if len(d.Args) > 0 {
-			if align, err := parseNumber(d.Args[0]); err == nil {
+			if align, err := p.evaluateArg(d.Args[0]); err == nil && align > 0 {
+				if !p.resolveAlignFill(d, align) {
+					return
+				}
 				if p.currentAddress%align != 0 {
 					p.currentAddress += align - (p.currentAddress % align)
 				}
 			}
 		}
 
-	case ".ltorg":
+	case ".ltorg", ".pool":
 		// Literal pool directive - mark this location for literal pool emission
-		// Align to 4-byte boundary
-		if p.currentAddress%4 != 0 {
-			p.currentAddress += 4 - (p.currentAddress % 4)
+		p.placeLiteralPool(program, false)
+	}
+}
+
+// placeLiteralPool records a literal pool at the current address, word aligned
+func (p *Parser) placeLiteralPool(program *Program, auto bool) {
+	if p.currentAddress%4 != 0 {
+		p.currentAddress += 4 - (p.currentAddress % 4)
+	}
+	program.LiteralPoolLocs = append(program.LiteralPoolLocs, p.currentAddress)
+	program.LiteralPoolAuto = append(program.LiteralPoolAuto, auto)
+	p.literalPending = false
+
+	// Reserve space for the literal pool
+	// We reserve a reasonable fixed amount (16 literals = 64 bytes) for each .ltorg
+	// This is a conservative estimate that handles typical usage while not being excessive
+	// The encoder will place actual literals within this space
+	p.currentAddress += EstimatedLiteralsPerPool * 4
+}
+
+// trackLiteralLoads places a literal pool straight after an unconditional branch once the
+// oldest LDR Rd, =value still waiting for a pool is AutoPoolDistanceBytes behind it. Code
+// never falls through into the pool, and large functions stay within the 4KB reach of a
+// PC-relative load without needing a .ltorg.
+func (p *Parser) trackLiteralLoads(inst *Instruction, program *Program) {
+	if isLiteralLoad(inst) {
+		if !p.literalPending {
+			p.literalPending, p.literalFrom = true, inst.Address
+		}
+		return
+	}
+	if p.literalPending && isUnconditionalBranch(inst) && inst.Address-p.literalFrom >= AutoPoolDistanceBytes {
+		p.placeLiteralPool(program, true)
+	}
+}
+
+// isLiteralLoad reports whether inst is an LDR Rd, =value pseudo-instruction
+func isLiteralLoad(inst *Instruction) bool {
+	return inst.Mnemonic == "LDR" && len(inst.Operands) >= 2 && strings.HasPrefix(strings.TrimSpace(inst.Operands[1]), "=")
+}
+
+// isUnconditionalBranch reports whether execution never continues with the instruction
+// after inst: B, BX, or a write to PC by MOV, LDR, POP or LDM, all with condition AL
+func isUnconditionalBranch(inst *Instruction) bool {
+	if inst.Condition != "" && inst.Condition != "AL" {
+		return false
+	}
+	writesPC := func(operand string) bool {
+		reg := strings.ToUpper(strings.TrimSpace(operand))
+		return reg == "PC" || reg == "R15"
+	}
+	switch inst.Mnemonic {
+	case "B", "BX":
+		return true
+	case "MOV", "LDR":
+		return len(inst.Operands) > 0 && writesPC(inst.Operands[0])
+	case "POP", "LDM", "LDMIA", "LDMFD", "LDMIB", "LDMED", "LDMDA", "LDMFA", "LDMDB", "LDMEA":
+		if len(inst.Operands) == 0 {
+			return false
+		}
+		list := inst.Operands[len(inst.Operands)-1]
+		for _, reg := range strings.Split(strings.Trim(list, "{}^ "), ",") {
+			if writesPC(reg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveAlignFill evaluates the optional fill byte of .align/.balign (default 0) and
+// leaves the resolved alignment and fill as the arguments for the loader, which writes
+// the fill into the padding. It reports false after recording an error.
+func (p *Parser) resolveAlignFill(d *Directive, align uint32) bool {
+	var fill uint32
+	if len(d.Args) > 1 {
+		value, err := p.evaluateArg(d.Args[1])
+		if err != nil || value > 0xFF {
+			p.errors.AddError(NewError(d.Pos, ErrorInvalidOperand,
+				fmt.Sprintf("invalid fill value for %s: %s (must be 0-255)", d.Name, d.Args[1])))
+			return false
+		}
+		fill = value
+	}
+	d.Args = []string{strconv.FormatUint(uint64(align), 10), strconv.FormatUint(uint64(fill), 10)}
+	return true
+}
+
+// readIncbin reads the bytes embedded by .incbin "file"[, skip[, count]], where skip
+// bytes are dropped from the start of the file and count limits how many follow
+func (p *Parser) readIncbin(d *Directive) ([]byte, error) {
+	if len(d.Args) == 0 || len(d.Args) > 3 {
+		return nil, fmt.Errorf(".incbin requires a file name, with an optional skip and count")
+	}
+	name := d.Args[0]
+	if len(name) < 2 || name[0] != '\'' || name[len(name)-1] != '\'' {
+		return nil, fmt.Errorf(".incbin file name must be a quoted string, got %s", name)
+	}
+	name = name[1 : len(name)-1]
+	if p.baseDir == "" {
+		return nil, fmt.Errorf(".incbin %q needs a source file to resolve against", name)
+	}
+
+	path, err := confinedPath(p.baseDir, name)
+	if err != nil {
+		return nil, fmt.Errorf(".incbin: %w", err)
+	}
+	file, err := os.Open(path) // #nosec G304 -- confined to the source directory
+	if err != nil {
+		return nil, fmt.Errorf(".incbin: failed to read %s: %w", name, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf(".incbin: failed to read %s: %w", name, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf(".incbin: %s is not a regular file", name)
+	}
+
+	// Work out the range from the file size, so nothing is read until it passes the limit
+	size := info.Size()
+	var skip, count uint32
+	if len(d.Args) > 1 {
+		if skip, err = p.evaluateArg(d.Args[1]); err != nil {
+			return nil, fmt.Errorf("invalid .incbin skip: %s", d.Args[1])
+		}
+		if int64(skip) > size {
+			return nil, fmt.Errorf(".incbin skip %d is beyond the end of %s (%d bytes)", skip, name, size)
+		}
+	}
+	length := size - int64(skip)
+	if len(d.Args) > 2 {
+		if count, err = p.evaluateArg(d.Args[2]); err != nil {
+			return nil, fmt.Errorf("invalid .incbin count: %s", d.Args[2])
+		}
+		if int64(count) > length {
+			return nil, fmt.Errorf(".incbin count %d is beyond the end of %s (%d bytes after skip)", count, name, length)
+		}
+		length = int64(count)
+	}
+	if length > MaxIncbinSize {
+		return nil, fmt.Errorf(".incbin %s is %d bytes, more than the %d allowed", name, length, MaxIncbinSize)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(io.NewSectionReader(file, int64(skip), length), data); err != nil {
+		return nil, fmt.Errorf(".incbin: failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// switchSection saves the location counter of the current section and continues at the
+// counter of the named one. .text keeps the program origin; .data starts at
+// DataSectionStart and .bss is assembled from offset 0 and placed after .data by placeBSS.
+func (p *Parser) switchSection(name string, program *Program) {
+	if name != SectionText {
+		p.sectionsUsed = true
+	}
+	if name != p.section {
+		p.sectionAddrs[p.section] = p.currentAddress
+		p.section = name
+		p.currentAddress = p.sectionAddrs[name]
+	}
+	if name == SectionText && !p.originSet {
+		program.Origin = p.currentAddress
+		program.OriginSet = true
+		p.originSet = true
+	}
+}
+
+// placeBSS records the section sizes and moves everything defined in .bss to follow .data,
+// reporting an error if they do not fit in the data segment
+func (p *Parser) placeBSS(program *Program) {
+	if !p.sectionsUsed {
+		return
+	}
+	p.sectionAddrs[p.section] = p.currentAddress
+	dataEnd := p.sectionAddrs[SectionData]
+	program.DataSize = dataEnd - DataSectionStart
+	program.BSSStart = (dataEnd + 3) &^ 3
+	program.BSSSize = p.sectionAddrs[SectionBSS]
+
+	// Anything past the data segment would land in the heap and alias its allocations
+	const dataLimit = uint64(DataSectionStart + vm.DataSegmentSize)
+	if end := uint64(DataSectionStart) + uint64(program.DataSize); end > dataLimit {
+		p.errors.AddError(NewError(sectionOverflowPos(program, SectionData, 0, dataLimit), ErrorInvalidDirective,
+			fmt.Sprintf(".data section is %d bytes, more than the %d-byte data segment", program.DataSize, vm.DataSegmentSize)))
+		return
+	}
+	if end := uint64(program.BSSStart) + uint64(program.BSSSize); end > dataLimit {
+		p.errors.AddError(NewError(sectionOverflowPos(program, SectionBSS, program.BSSStart, dataLimit), ErrorInvalidDirective,
+			fmt.Sprintf(".data and .bss sections need %d bytes, more than the %d-byte data segment", end-DataSectionStart, vm.DataSegmentSize)))
+		return
+	}
+
+	base := program.BSSStart
+	for _, name := range p.bssLabels {
+		if sym, ok := p.symbolTable.Lookup(name); ok {
+			sym.Value += base
+		}
+	}
+	for _, ref := range p.bssNumLabels {
+		p.numericLabels.labels[ref[0]][ref[1]] += base
+	}
+	for _, d := range program.Directives {
+		if d.Section == SectionBSS {
+			d.Address += base
 		}
-		// Record this location as a literal pool position
-		program.LiteralPoolLocs = append(program.LiteralPoolLocs, p.currentAddress)
+	}
+}
 
-		// Reserve space for the literal pool
-		// We reserve a reasonable fixed amount (16 literals = 64 bytes) for each .ltorg
-		// This is a conservative estimate that handles typical usage while not being excessive
-		// The encoder will place actual literals within this space
-		p.currentAddress += EstimatedLiteralsPerPool * 4
+// sectionOverflowPos returns the position of the directive in section that runs past limit:
+// the last one starting below it once offset is added, or the first if none does
+func sectionOverflowPos(program *Program, section string, offset uint32, limit uint64) Position {
+	var pos Position
+	found := false
+	for _, d := range program.Directives {
+		if d.Section != section {
+			continue
+		}
+		if !found || uint64(d.Address)+uint64(offset) < limit {
+			pos = d.Pos
+			found = true
+		}
 	}
+	return pos
+}
+
+// inDataSections reports whether addr lies in .data or .bss, which literal pools never move
+func (p *Parser) inDataSections(program *Program, addr uint32) bool {
+	return p.sectionsUsed && addr >= DataSectionStart && addr < program.BSSStart+program.BSSSize
+}
+
+// evaluateArg evaluates a directive argument using the constants and labels defined so far
+func (p *Parser) evaluateArg(arg string) (uint32, error) {
+	return EvaluateExpression(arg, p.symbolTable.Get)
 }
 
 // parseInstruction parses an ARM instruction
@@ -436,10 +848,17 @@ func (p *Parser) parseInstruction() *Instruction {
 	// Parse mnemonic (might include condition and S flag)
 	mnemonic := strings.ToUpper(p.currentToken.Literal)
 
-	// Check for condition code suffix
-	inst.Condition, inst.SetFlags, inst.Mnemonic = parseInstructionMnemonic(mnemonic)
+	// Split off the condition code and S suffixes
+	var err error
+	inst.Condition, inst.SetFlags, inst.Mnemonic, err = parseInstructionMnemonic(mnemonic)
+	if err != nil {
+		p.errors.AddError(NewError(inst.Pos, ErrorInvalidInstruction, err.Error()))
+	}
 
+	inst.EndColumn = p.currentToken.EndColumn
 	p.nextToken() // consume mnemonic
+	p.substituteRegisterAliases()
+	p.recordOperandUses()
 
 	// Parse operands
 	for p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF && p.currentToken.Type != TokenComment {
@@ -454,6 +873,19 @@ func (p *Parser) parseInstruction() *Instruction {
 			break
 		}
 	}
+	if len(inst.Operands) > 0 {
+		inst.EndColumn = p.prevEnd
+	}
+
+	// Anything else on the line is an error. Skip to the end of the line so the
+	// leftover tokens are not parsed as another instruction.
+	if p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF && p.currentToken.Type != TokenComment {
+		p.errors.AddError(NewError(p.currentToken.Pos, ErrorSyntax,
+			fmt.Sprintf("unexpected %q after operands of %s", p.currentToken.Literal, inst.Mnemonic)))
+		for p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF && p.currentToken.Type != TokenComment {
+			p.nextToken()
+		}
+	}
 
 	// Consume comment if present
 	if p.currentToken.Type == TokenComment {
@@ -464,6 +896,101 @@ func (p *Parser) parseInstruction() *Instruction {
 	return inst
 }
 
+// parseRegisterAlias parses NAME .req REGISTER, which lets later lines use NAME
+// wherever the register is valid. REGISTER may itself be an alias.
+func (p *Parser) parseRegisterAlias() {
+	name, pos := p.currentToken.Literal, p.currentToken.Pos
+	p.nextToken() // consume name
+	p.nextToken() // consume .req
+
+	reg := p.currentToken.Literal
+	switch {
+	case p.currentToken.Type == TokenRegister:
+	case p.currentToken.Type == TokenIdentifier && p.regAliases[reg] != "":
+		reg = p.regAliases[reg]
+	default:
+		p.errors.AddError(NewError(pos, ErrorSyntax, fmt.Sprintf(".req %s needs a register, got %q", name, reg)))
+		reg = ""
+	}
+	if reg != "" {
+		if existing, ok := p.regAliases[name]; ok && existing != reg {
+			p.errors.AddError(NewError(pos, ErrorDuplicateLabel,
+				fmt.Sprintf("register alias %s is already %s (use .unreq first)", name, existing)))
+		} else {
+			if p.regAliases == nil {
+				p.regAliases = make(map[string]string)
+			}
+			p.regAliases[name] = reg
+		}
+	}
+
+	for p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF {
+		p.nextToken()
+	}
+}
+
+// substituteRegisterAliases replaces register aliases in the rest of the current line
+// with the registers they name, so operand parsing sees ordinary register tokens
+func (p *Parser) substituteRegisterAliases() {
+	if len(p.regAliases) == 0 {
+		return
+	}
+	substitute := func(tok *Token) bool {
+		if tok.Type == TokenNewline || tok.Type == TokenEOF {
+			return false
+		}
+		if reg, ok := p.regAliases[tok.Literal]; ok && tok.Type == TokenIdentifier {
+			tok.Type, tok.Literal = TokenRegister, reg
+		}
+		return true
+	}
+	if !substitute(&p.currentToken) || !substitute(&p.peekToken) {
+		return
+	}
+	for i := p.pos; i < len(p.tokens) && substitute(&p.tokens[i]); i++ {
+	}
+}
+
+// operandKeywords are identifiers that may appear in operands without naming a symbol
+var operandKeywords = map[string]bool{
+	"LSL": true, "LSR": true, "ASR": true, "ROR": true, "RRX": true, "ASL": true,
+}
+
+// recordOperandUses records each symbol named in the rest of the current line's
+// operands as a use, for the cross-reference
+func (p *Parser) recordOperandUses() {
+	record := func(tok Token) bool {
+		if tok.Type == TokenNewline || tok.Type == TokenEOF || tok.Type == TokenComment {
+			return false
+		}
+		if tok.Type == TokenIdentifier && isSymbolUse(tok.Literal) {
+			upper := strings.ToUpper(tok.Literal)
+			if !operandKeywords[upper] && !strings.HasPrefix(upper, "CPSR") && !strings.HasPrefix(upper, "SPSR") {
+				p.symbolTable.AddUse(tok.Literal, tok.Pos)
+			}
+		}
+		return true
+	}
+	if !record(p.currentToken) || !record(p.peekToken) {
+		return
+	}
+	for i := p.pos; i < len(p.tokens) && record(p.tokens[i]); i++ {
+	}
+}
+
+// isSymbolUse reports whether an identifier token can name a symbol; numeric local
+// label references such as 1f are resolved separately
+func isSymbolUse(name string) bool {
+	return name != "" && !unicode.IsDigit(rune(name[0]))
+}
+
+// directiveExprArgs gives, for directives whose arguments are expressions that may name
+// symbols, the index of the first such argument
+var directiveExprArgs = map[string]int{
+	".word": 0, ".half": 0, ".byte": 0, ".space": 0, ".skip": 0,
+	".org": 0, ".align": 0, ".balign": 0, ".equ": 1, ".set": 1,
+}
+
 // parseOperand parses a single operand by dispatching to type-specific parsers
 func (p *Parser) parseOperand() string {
 	switch p.currentToken.Type {
@@ -484,18 +1011,14 @@ func (p *Parser) parseOperand() string {
 	}
 }
 
-// parseImmediateOperand parses immediate values: #123, #-45, #'A'
+// parseImmediateOperand parses immediate values: #123, #-45, #'A', #(4*8+1)
+// Expressions are kept as text for the encoder to evaluate once all symbols are known.
 func (p *Parser) parseImmediateOperand() string {
 	var parts []string
 	parts = append(parts, "#")
 	p.nextToken()
 
-	if p.currentToken.Type == TokenNumber || p.currentToken.Type == TokenIdentifier ||
-		p.currentToken.Type == TokenMinus || p.currentToken.Type == TokenString {
-		if p.currentToken.Type == TokenMinus {
-			parts = append(parts, "-")
-			p.nextToken()
-		}
+	for isExpressionToken(p.currentToken.Type) {
 		if p.currentToken.Type == TokenString {
 			parts = append(parts, "'"+p.currentToken.Literal+"'")
 		} else {
@@ -506,10 +1029,23 @@ func (p *Parser) parseImmediateOperand() string {
 	return strings.Join(parts, "")
 }
 
+// isExpressionToken reports whether a token can be part of a constant expression
+func isExpressionToken(t TokenType) bool {
+	switch t {
+	case TokenNumber, TokenIdentifier, TokenString,
+		TokenPlus, TokenMinus, TokenStar, TokenSlash, TokenPercent,
+		TokenAmpersand, TokenPipe, TokenCaret, TokenTilde,
+		TokenLShift, TokenRShift, TokenLParen, TokenRParen:
+		return true
+	}
+	return false
+}
+
 // parseMemoryOperand parses memory addresses: [Rn], [Rn, #offset], [Rn, Rm, LSL #2]
 func (p *Parser) parseMemoryOperand() string {
 	var parts []string
 	parts = append(parts, "[")
+	open := p.currentToken.Pos
 	p.nextToken()
 
 	for p.currentToken.Type != TokenRBracket && p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF {
@@ -536,6 +1072,8 @@ func (p *Parser) parseMemoryOperand() string {
 			parts = append(parts, "!")
 			p.nextToken()
 		}
+	} else {
+		p.errors.AddError(NewError(open, ErrorSyntax, "missing ']' in memory operand"))
 	}
 	return strings.Join(parts, "")
 }
@@ -544,6 +1082,7 @@ func (p *Parser) parseMemoryOperand() string {
 func (p *Parser) parseRegisterListOperand() string {
 	var parts []string
 	parts = append(parts, "{")
+	open := p.currentToken.Pos
 	p.nextToken()
 
 	for p.currentToken.Type != TokenRBrace && p.currentToken.Type != TokenNewline && p.currentToken.Type != TokenEOF {
@@ -561,6 +1100,8 @@ func (p *Parser) parseRegisterListOperand() string {
 	if p.currentToken.Type == TokenRBrace {
 		parts = append(parts, "}")
 		p.nextToken()
+	} else {
+		p.errors.AddError(NewError(open, ErrorSyntax, "missing '}' in register list"))
 	}
 	return strings.Join(parts, "")
 }
@@ -571,21 +1112,14 @@ func (p *Parser) parsePseudoOperand() string {
 	parts = append(parts, "=")
 	p.nextToken()
 
-	if p.currentToken.Type == TokenIdentifier || p.currentToken.Type == TokenNumber {
-		parts = append(parts, p.currentToken.Literal)
-		p.nextToken()
-
-		// Parse arithmetic expressions: =label+12, =label-4
-		for p.currentToken.Type == TokenPlus || p.currentToken.Type == TokenMinus {
+	// Arithmetic expressions: =label+12, =label-4, =(BASE<<8)|1
+	for isExpressionToken(p.currentToken.Type) {
+		if p.currentToken.Type == TokenString {
+			parts = append(parts, "'"+p.currentToken.Literal+"'")
+		} else {
 			parts = append(parts, p.currentToken.Literal)
-			p.nextToken()
-			if p.currentToken.Type == TokenNumber || p.currentToken.Type == TokenIdentifier {
-				parts = append(parts, p.currentToken.Literal)
-				p.nextToken()
-			} else {
-				break
-			}
 		}
+		p.nextToken()
 	}
 	return strings.Join(parts, "")
 }
@@ -633,60 +1167,6 @@ func isShiftOperator(s string) bool {
 	return false
 }
 
-// parseInstructionMnemonic parses the mnemonic and extracts condition and S flag
-func parseInstructionMnemonic(mnemonic string) (condition string, setFlags bool, baseMnemonic string) {
-	// List of condition codes
-	conditions := []string{"EQ", "NE", "CS", "HS", "CC", "LO", "MI", "PL", "VS", "VC", "HI", "LS", "GE", "LT", "GT", "LE", "AL"}
-
-	// Check if mnemonic ends with S (set flags)
-	if len(mnemonic) > 1 && mnemonic[len(mnemonic)-1] == 'S' {
-		// Check if it's really a set flags indicator (not part of the instruction like BLS)
-		possibleBase := mnemonic[:len(mnemonic)-1]
-		if !isInstructionName(mnemonic) && isInstructionName(possibleBase) {
-			setFlags = true
-			mnemonic = possibleBase
-		}
-	}
-
-	// Check for condition code
-	for _, cond := range conditions {
-		if strings.HasSuffix(mnemonic, cond) {
-			possibleBase := mnemonic[:len(mnemonic)-len(cond)]
-			if isInstructionName(possibleBase) {
-				condition = cond
-				baseMnemonic = possibleBase
-				return
-			}
-		}
-	}
-
-	return "", setFlags, mnemonic
-}
-
-// isInstructionName checks if a string is a valid instruction name
-func isInstructionName(s string) bool {
-	instructions := []string{
-		"MOV", "MVN", "ADD", "ADC", "SUB", "SBC", "RSB", "RSC",
-		"AND", "ORR", "EOR", "BIC", "CMP", "CMN", "TST", "TEQ",
-		"LDR", "STR", "LDRB", "STRB", "LDRH", "STRH",
-		"LDM", "STM", "LDMIA", "LDMIB", "LDMDA", "LDMDB",
-		"STMIA", "STMIB", "STMDA", "STMDB",
-		"LDMFD", "LDMFA", "LDMEA", "LDMED", // Load Multiple aliases (FD=Full Descending, etc.)
-		"STMFD", "STMFA", "STMEA", "STMED", // Store Multiple aliases
-		"PUSH", "POP", "NOP",
-		"B", "BL", "BX",
-		"MUL", "MLA",
-		"SWI", "SVC", // SVC is ARM7+ name for SWI (Supervisor Call)
-	}
-
-	for _, inst := range instructions {
-		if s == inst {
-			return true
-		}
-	}
-	return false
-}
-
 // parseNumber parses a number in various formats (decimal, hex, binary, octal)
 func parseNumber(s string) (uint32, error) {
 	s = strings.TrimSpace(s)
@@ -790,6 +1270,9 @@ func (p *Parser) adjustAddressesForDynamicPools(program *Program) {
 	if len(originalPoolLocs) > 0 {
 		// Helper function to determine adjustment for a given ORIGINAL address
 		getAdjustmentForAddress := func(addr uint32) int32 {
+			if p.inDataSections(program, addr) {
+				return 0
+			}
 			// Find the last pool that this address comes after
 			for i := len(originalPoolLocs) - 1; i >= 0; i-- {
 				poolEndLoc := originalPoolLocs[i] + uint32(estimatedBytes) // #nosec G115 -- estimatedBytes = EstimatedLiteralsPerPool*4, a small constant
@@ -829,6 +1312,57 @@ func (p *Parser) adjustAddressesForDynamicPools(program *Program) {
 			symbol.Value = applySignedOffset(symbol.Value, adjustment)
 			program.SymbolTable.symbols[name] = symbol
 		}
+
+		// Same for numeric local labels
+		p.numericLabels.adjustAddresses(func(addr uint32) uint32 {
+			return applySignedOffset(addr, getAdjustmentForAddress(addr))
+		})
+	}
+}
+
+// numericLabelRefPattern matches numeric local label references such as 1f or 12b
+var numericLabelRefPattern = regexp.MustCompile(`(^|[^0-9A-Za-z_.])([0-9]+)([fb])\b`)
+
+// resolveNumericLabelRefs replaces 1f/1b references in instruction operands and .word
+// arguments with the address of the nearest matching numeric label in that direction
+// from the referencing address
+func (p *Parser) resolveNumericLabelRefs(program *Program) {
+	resolve := func(operand string, addr uint32, pos Position) string {
+		return numericLabelRefPattern.ReplaceAllStringFunc(operand, func(match string) string {
+			parts := numericLabelRefPattern.FindStringSubmatch(match)
+			num, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return match
+			}
+
+			var target uint32
+			var found bool
+			if parts[3] == "f" {
+				target, found = p.numericLabels.LookupForward(num, addr)
+			} else {
+				target, found = p.numericLabels.LookupBackward(num, addr)
+			}
+			if !found {
+				p.errors.AddError(NewError(pos, ErrorUndefinedLabel,
+					fmt.Sprintf("undefined numeric label reference: %s%s", parts[2], parts[3])))
+				return match
+			}
+			return fmt.Sprintf("%s0x%X", parts[1], target)
+		})
+	}
+
+	for _, inst := range program.Instructions {
+		for i, operand := range inst.Operands {
+			inst.Operands[i] = resolve(operand, inst.Address, inst.Pos)
+		}
+	}
+	for _, dir := range program.Directives {
+		if dir.Name != ".word" {
+			continue
+		}
+		for i, arg := range dir.Args {
+			dir.Args[i] = resolve(arg, dir.Address, dir.Pos)
+		}
 	}
 }
 
@@ -892,23 +1426,38 @@ func (p *Parser) countLiteralsPerPool(program *Program) {
 	}
 }
 
-// getRawLineFromInput extracts the raw source line for a given line number
-func (p *Parser) getRawLineFromInput(lineNum int) string {
-	if p.lexer == nil || p.lexer.input == "" {
-		return ""
+// SourceLines returns the parsed (preprocessed) source split into lines; Pos.Line
+// values index into it, starting at 1. Lines produced by macro and .rept expansion report
+// the line they were written on.
+func (p *Parser) SourceLines() []string {
+	if p.input == "" {
+		return nil
 	}
 
 	// Cache split lines on first access
 	if p.inputLines == nil {
-		p.inputLines = strings.Split(p.lexer.input, "\n")
+		p.inputLines = strings.Split(p.input, "\n")
 	}
+	return p.inputLines
+}
 
-	if lineNum < 1 || lineNum > len(p.inputLines) {
+// rawLine returns the source text of the line at pos
+func (p *Parser) rawLine(pos Position) string {
+	if p.rawLines != nil {
+		return p.rawLines[Position{Filename: pos.Filename, Line: pos.Line}]
+	}
+	return p.getRawLineFromInput(pos.Line)
+}
+
+// getRawLineFromInput extracts the raw source line for a given line number
+func (p *Parser) getRawLineFromInput(lineNum int) string {
+	lines := p.SourceLines()
+	if lineNum < 1 || lineNum > len(lines) {
 		return ""
 	}
 
 	// Line numbers are 1-based
-	return p.inputLines[lineNum-1]
+	return lines[lineNum-1]
 }
 
 // Errors returns the error list
@@ -3,8 +3,8 @@ package parser
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"unicode"
 )
 
 const (
@@ -16,12 +16,14 @@ const (
 type Preprocessor struct {
 	// Track included files to detect circular includes
 	includeStack []string
-	// Track defined symbols for conditional assembly
-	defines map[string]bool
+	// Symbols visible to conditional assembly: -D defines and .equ/.set constants seen so far
+	defines map[string]uint32
 	// Base directory for resolving relative includes
 	baseDir string
 	// Error list
 	errors *ErrorList
+	// Original position of each line of the last output (index 0 is output line 1)
+	lineMap []Position
 }
 
 // NewPreprocessor creates a new preprocessor
@@ -31,15 +33,20 @@ func NewPreprocessor(baseDir string) *Preprocessor {
 	}
 	return &Preprocessor{
 		includeStack: make([]string, 0),
-		defines:      make(map[string]bool),
+		defines:      make(map[string]uint32),
 		baseDir:      baseDir,
 		errors:       &ErrorList{},
 	}
 }
 
-// Define defines a symbol for conditional assembly
+// Define defines a symbol for conditional assembly with the value 1
 func (p *Preprocessor) Define(symbol string) {
-	p.defines[symbol] = true
+	p.defines[symbol] = 1
+}
+
+// DefineValue defines a symbol for conditional assembly with the given value
+func (p *Preprocessor) DefineValue(symbol string, value uint32) {
+	p.defines[symbol] = value
 }
 
 // Undefine removes a symbol definition
@@ -49,42 +56,102 @@ func (p *Preprocessor) Undefine(symbol string) {
 
 // IsDefined checks if a symbol is defined
 func (p *Preprocessor) IsDefined(symbol string) bool {
-	return p.defines[symbol]
+	_, ok := p.defines[symbol]
+	return ok
 }
 
-// ProcessFile processes a file with includes and conditionals
-func (p *Preprocessor) ProcessFile(filename string) (string, error) {
-	// Check include depth to prevent DoS
-	if len(p.includeStack) >= MaxIncludeDepth {
-		return "", fmt.Errorf("include depth exceeds maximum (%d)", MaxIncludeDepth)
+// ParseDefine splits a command-line define of the form SYM or SYM=VALUE. VALUE is a
+// constant expression and defaults to 1.
+func ParseDefine(spec string) (string, uint32, error) {
+	name, valueText, hasValue := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if name == "" || unicode.IsDigit(rune(name[0])) || strings.IndexFunc(name, func(r rune) bool { return !isIdentifierChar(r) }) >= 0 {
+		return "", 0, fmt.Errorf("invalid define %q: expected SYMBOL or SYMBOL=VALUE", spec)
 	}
+	if !hasValue {
+		return name, 1, nil
+	}
+	value, err := EvaluateExpression(strings.TrimSpace(valueText), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid value for define %s: %w", name, err)
+	}
+	return name, value, nil
+}
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(filepath.Join(p.baseDir, filename))
+// lookup resolves a symbol in an .if expression
+func (p *Preprocessor) lookup(name string) (uint32, error) {
+	if value, ok := p.defines[name]; ok {
+		return value, nil
+	}
+	return 0, fmt.Errorf("undefined symbol %q (.if can only use -D defines and earlier .equ/.set constants)", name)
+}
+
+// evaluateIf evaluates the condition of an .if directive, reporting invalid expressions
+// and treating them as false
+func (p *Preprocessor) evaluateIf(expr string, pos Position) bool {
+	if expr == "" {
+		p.errors.AddError(NewError(pos, ErrorSyntax, ".if requires an expression"))
+		return false
+	}
+	value, err := EvaluateExpression(expr, p.lookup)
 	if err != nil {
-		return "", err
+		p.errors.AddError(NewError(pos, ErrorSyntax, fmt.Sprintf("invalid .if expression: %v", err)))
+		return false
+	}
+	return value != 0
+}
+
+// recordConstant remembers the value of an .equ or .set line so later conditionals can
+// test it. Constants whose value depends on labels cannot be known yet and are left to
+// the parser.
+func (p *Preprocessor) recordConstant(line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || (fields[0] != ".equ" && fields[0] != ".set") {
+		return
+	}
+	name, expr, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, fields[0])), ",")
+	if !ok {
+		return
 	}
+	if value, err := EvaluateExpression(strings.TrimSpace(expr), p.lookup); err == nil {
+		p.defines[strings.TrimSpace(name)] = value
+	}
+}
 
-	// Validate path stays within base directory
-	absBase, err := filepath.Abs(p.baseDir)
+// ProcessFile processes a file with includes and conditionals
+func (p *Preprocessor) ProcessFile(filename string) (string, error) {
+	lines, positions, err := p.includeFile(filename)
 	if err != nil {
 		return "", err
 	}
-	if !strings.HasPrefix(absPath, absBase+string(filepath.Separator)) && absPath != absBase {
-		return "", fmt.Errorf("include path escapes base directory: %s", filename)
+	p.lineMap = positions
+	return strings.Join(lines, "\n"), nil
+}
+
+// includeFile reads and processes a file, returning its output lines and their positions
+func (p *Preprocessor) includeFile(filename string) ([]string, []Position, error) {
+	// Check include depth to prevent DoS
+	if len(p.includeStack) >= MaxIncludeDepth {
+		return nil, nil, fmt.Errorf("include depth exceeds maximum (%d)", MaxIncludeDepth)
+	}
+
+	// Resolve the path, which must stay within the base directory
+	absPath, err := confinedPath(p.baseDir, filename)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Check for circular includes
 	for _, included := range p.includeStack {
 		if included == absPath {
-			return "", fmt.Errorf("circular include detected: %s", absPath)
+			return nil, nil, fmt.Errorf("circular include detected: %s", absPath)
 		}
 	}
 
 	// Read file
 	content, err := os.ReadFile(absPath) // #nosec G304,G703 -- assembler intentionally reads user-specified include files
 	if err != nil {
-		return "", fmt.Errorf("failed to read file %s: %w", filename, err)
+		return nil, nil, fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
 
 	// Push onto include stack
@@ -94,21 +161,39 @@ func (p *Preprocessor) ProcessFile(filename string) (string, error) {
 	}()
 
 	// Process the content
-	return p.ProcessContent(string(content), filename)
+	lines, positions := p.processContent(string(content), filename)
+	return lines, positions, nil
 }
 
 // ProcessContent processes content with includes and conditionals
 func (p *Preprocessor) ProcessContent(content, filename string) (string, error) {
+	lines, positions := p.processContent(content, filename)
+	p.lineMap = positions
+	return strings.Join(lines, "\n"), nil
+}
+
+// LineMap returns the original file and line of each line output by the last call to
+// ProcessContent or ProcessFile (index 0 is output line 1). Included files and removed
+// conditional blocks mean output line numbers differ from the source.
+func (p *Preprocessor) LineMap() []Position {
+	return p.lineMap
+}
+
+// processContent returns the output lines of content and the original position of each
+func (p *Preprocessor) processContent(content, filename string) ([]string, []Position) {
 	lines := strings.Split(content, "\n")
 	result := make([]string, 0, len(lines))
+	positions := make([]Position, 0, len(lines))
 
 	// State for conditional assembly
 	conditionalStack := make([]bool, 0) // Stack of condition states
 	skip := false                       // Whether we're currently skipping lines
+	inComment := false                  // Whether a /* */ comment continues from an earlier line
 
 	for lineNum, line := range lines {
 		pos := Position{Filename: filename, Line: lineNum + 1, Column: 1}
-		trimmed := strings.TrimSpace(line)
+		// Directives are recognised on the code alone, so ones inside comments are ignored
+		trimmed := strings.TrimSpace(stripComments(line, &inComment))
 
 		// Handle preprocessor directives
 		if strings.HasPrefix(trimmed, ".include") {
@@ -124,13 +209,14 @@ func (p *Preprocessor) ProcessContent(content, filename string) (string, error)
 			}
 
 			// Process included file
-			includedContent, err := p.ProcessFile(includeFile)
+			includedLines, includedPositions, err := p.includeFile(includeFile)
 			if err != nil {
 				p.errors.AddError(NewError(pos, ErrorFileIO, fmt.Sprintf("failed to include %s: %v", includeFile, err)))
 				continue
 			}
 
-			result = append(result, includedContent)
+			result = append(result, includedLines...)
+			positions = append(positions, includedPositions...)
 
 		} else if strings.HasPrefix(trimmed, ".ifdef") {
 			// .ifdef SYMBOL
@@ -156,11 +242,11 @@ func (p *Preprocessor) ProcessContent(content, filename string) (string, error)
 			conditionalStack = append(conditionalStack, skip)
 			skip = !condition
 
-		} else if strings.HasPrefix(trimmed, ".if") && !strings.HasPrefix(trimmed, ".ifdef") && !strings.HasPrefix(trimmed, ".ifndef") {
-			// .if expression (not implemented yet, just skip)
-			p.errors.AddError(NewError(pos, ErrorSyntax, ".if directive not yet implemented"))
+		} else if trimmed == ".if" || strings.HasPrefix(trimmed, ".if ") || strings.HasPrefix(trimmed, ".if\t") {
+			// .if EXPRESSION - true when the expression is non-zero; not evaluated while skipping
+			condition := !skip && p.evaluateIf(strings.TrimSpace(trimmed[len(".if"):]), pos)
 			conditionalStack = append(conditionalStack, skip)
-			skip = true
+			skip = !condition
 
 		} else if strings.HasPrefix(trimmed, ".else") {
 			// .else - flip the skip state
@@ -183,7 +269,9 @@ func (p *Preprocessor) ProcessContent(content, filename string) (string, error)
 		} else {
 			// Regular line - include if not skipping
 			if !skip {
+				p.recordConstant(trimmed)
 				result = append(result, line)
+				positions = append(positions, pos)
 			}
 		}
 	}
@@ -197,7 +285,45 @@ func (p *Preprocessor) ProcessContent(content, filename string) (string, error)
 		))
 	}
 
-	return strings.Join(result, "\n"), nil
+	return result, positions
+}
+
+// stripComments returns line with its ;, @, // and /* */ comments removed. inBlock carries
+// an unterminated /* comment over to the next line. Comment markers inside string and
+// character literals are kept.
+func stripComments(line string, inBlock *bool) string {
+	var code strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case *inBlock:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				*inBlock = false
+				i++
+				code.WriteByte(' ')
+			}
+		case quote != 0:
+			code.WriteByte(c)
+			if c == '\\' && i+1 < len(line) {
+				i++
+				code.WriteByte(line[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			code.WriteByte(c)
+		case c == ';' || c == '@' || (c == '/' && i+1 < len(line) && line[i+1] == '/'):
+			return code.String()
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			*inBlock = true
+			i++
+		default:
+			code.WriteByte(c)
+		}
+	}
+	return code.String()
 }
 
 // parseIncludeDirective parses a .include directive and returns the filename
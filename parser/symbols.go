@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"sort"
 )
 
 // SymbolType represents the type of a symbol
@@ -29,6 +30,8 @@ type SymbolTable struct {
 	symbols map[string]*Symbol
 	// Relocation entries for forward references
 	relocations []*Relocation
+	// Positions each name is used from, including names that are never defined
+	uses map[string][]Position
 }
 
 // Relocation represents a location that needs address resolution
@@ -54,6 +57,7 @@ func NewSymbolTable() *SymbolTable {
 	return &SymbolTable{
 		symbols:     make(map[string]*Symbol),
 		relocations: make([]*Relocation, 0),
+		uses:        make(map[string][]Position),
 	}
 }
 
@@ -64,6 +68,7 @@ func (st *SymbolTable) Define(name string, symType SymbolType, value uint32, pos
 			return fmt.Errorf("symbol %q already defined at %s", name, sym.Pos)
 		}
 		// Update forward reference with actual value
+		sym.Type = symType
 		sym.Value = value
 		sym.Defined = true
 		sym.Pos = pos
@@ -81,6 +86,20 @@ func (st *SymbolTable) Define(name string, symType SymbolType, value uint32, pos
 	return nil
 }
 
+// Set defines a constant, or changes the value of one already defined by .set or .equ.
+// Labels cannot be redefined.
+func (st *SymbolTable) Set(name string, value uint32, pos Position) error {
+	if sym, exists := st.symbols[name]; exists && sym.Defined {
+		if sym.Type != SymbolConstant {
+			return fmt.Errorf("symbol %q already defined at %s", name, sym.Pos)
+		}
+		sym.Value = value
+		sym.Pos = pos
+		return nil
+	}
+	return st.Define(name, SymbolConstant, value, pos)
+}
+
 // UpdateAddress updates the address of an existing symbol
 func (st *SymbolTable) UpdateAddress(name string, value uint32) error {
 	sym, exists := st.symbols[name]
@@ -108,6 +127,29 @@ func (st *SymbolTable) Reference(name string, pos Position) {
 	}
 }
 
+// AddUse records that name is used at pos, for cross-referencing. Unlike Reference it
+// does not create a symbol, so a use of a name that is never defined is not an error here.
+func (st *SymbolTable) AddUse(name string, pos Position) {
+	st.uses[name] = append(st.uses[name], pos)
+}
+
+// Uses returns the positions name is used from, in source order
+func (st *SymbolTable) Uses(name string) []Position {
+	return st.uses[name]
+}
+
+// UndefinedUses returns the names that are used but never defined, sorted
+func (st *SymbolTable) UndefinedUses() []string {
+	names := make([]string, 0)
+	for name := range st.uses {
+		if sym, exists := st.symbols[name]; !exists || !sym.Defined {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Lookup looks up a symbol by name
 func (st *SymbolTable) Lookup(name string) (*Symbol, bool) {
 	sym, exists := st.symbols[name]
@@ -183,6 +225,7 @@ func (st *SymbolTable) GetAllSymbols() map[string]*Symbol {
 func (st *SymbolTable) Clear() {
 	st.symbols = make(map[string]*Symbol)
 	st.relocations = make([]*Relocation, 0)
+	st.uses = make(map[string][]Position)
 }
 
 // NumericLabelTable manages numeric labels (1:, 2:, etc.) with forward/backward references
@@ -243,6 +286,15 @@ func (nlt *NumericLabelTable) LookupForward(num int, currentAddr uint32) (uint32
 	return 0, false
 }
 
+// adjustAddresses rewrites every label address through adjust (used when literal pools are resized)
+func (nlt *NumericLabelTable) adjustAddresses(adjust func(uint32) uint32) {
+	for num, addresses := range nlt.labels {
+		for i, addr := range addresses {
+			nlt.labels[num][i] = adjust(addr)
+		}
+	}
+}
+
 // Clear clears all numeric labels
 func (nlt *NumericLabelTable) Clear() {
 	nlt.labels = make(map[int][]uint32)
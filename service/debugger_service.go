@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lookbusy1344/arm-emulator/debugger"
@@ -78,6 +80,10 @@ type DebuggerService struct {
 	stdinPipeReader *io.PipeReader
 	stdinPipeWriter *io.PipeWriter
 	stdinBuffer     strings.Builder // Buffer for stdin sent before execution starts
+
+	// Set while the guest is blocked reading stdin. Tracked through OnStateChange because
+	// the run goroutine writes vm.State without holding mu.
+	waitingForInput atomic.Bool
 }
 
 // NewDebuggerService creates a new debugger service
@@ -85,8 +91,9 @@ func NewDebuggerService(machine *vm.VM) *DebuggerService {
 	// Setup stdin pipe for guest program input (GUI)
 	stdinReader, stdinWriter := io.Pipe()
 	machine.SetStdinReader(stdinReader)
+	machine.StackGuard = vm.DefaultStackGuard
 
-	return &DebuggerService{
+	s := &DebuggerService{
 		vm:              machine,
 		debugger:        debugger.NewDebugger(machine),
 		symbols:         make(map[string]uint32),
@@ -95,6 +102,16 @@ func NewDebuggerService(machine *vm.VM) *DebuggerService {
 		stdinPipeReader: stdinReader,
 		stdinPipeWriter: stdinWriter,
 	}
+
+	// Chain onto any existing callback (the API uses it to broadcast state changes)
+	notify := machine.OnStateChange
+	machine.OnStateChange = func(state vm.ExecutionState) {
+		s.waitingForInput.Store(state == vm.StateWaitingForInput)
+		if notify != nil {
+			notify(state)
+		}
+	}
+	return s
 }
 
 // GetVM returns the underlying VM (for testing)
@@ -130,12 +147,15 @@ func (s *DebuggerService) LoadProgram(program *parser.Program, entryPoint uint32
 	// Build source map with line numbers
 	s.sourceMap = nil
 	s.sourceMapByAddr = make(map[uint32]string)
+	addressLines := make(map[uint32]debugger.SourceLocation, len(program.Instructions))
 	for _, inst := range program.Instructions {
+		addressLines[inst.Address] = debugger.SourceLocation{File: inst.Pos.Filename, Line: inst.Pos.Line}
 		entry := SourceMapEntry{
 			Address:    inst.Address,
 			LineNumber: inst.Pos.Line,
 			Line:       inst.RawLine,
 		}
+		entry.StartCol, entry.EndCol = inst.Columns()
 		s.sourceMap = append(s.sourceMap, entry)
 		s.sourceMapByAddr[inst.Address] = inst.RawLine
 	}
@@ -143,7 +163,7 @@ func (s *DebuggerService) LoadProgram(program *parser.Program, entryPoint uint32
 	// but kept in sourceMapByAddr for debugger display
 	for _, dir := range program.Directives {
 		if dir.Name == ".word" || dir.Name == ".byte" || dir.Name == ".ascii" ||
-			dir.Name == ".asciz" || dir.Name == ".space" {
+			dir.Name == ".asciz" || dir.Name == ".space" || dir.Name == ".incbin" {
 			s.sourceMapByAddr[dir.Address] = "[DATA]" + dir.RawLine
 		}
 	}
@@ -159,11 +179,14 @@ func (s *DebuggerService) LoadProgram(program *parser.Program, entryPoint uint32
 	// Load into debugger
 	s.debugger.LoadSymbols(s.symbols)
 	s.debugger.LoadSourceMap(s.sourceMapByAddr)
+	s.debugger.LoadLineIndex(loader.LineIndex(program))
+	s.debugger.LoadAddressLines(addressLines)
 
 	// Load into VM memory
 	if err := loader.LoadProgramIntoVM(s.vm, program, entryPoint); err != nil {
 		return err
 	}
+	s.debugger.LoadLiteralPool(program.LiteralPool)
 
 	// Initialize stack pointer only if not already set (preserve InitializeStack value)
 	// Stack grows downward from top of stack segment
@@ -204,6 +227,43 @@ func (s *DebuggerService) GetRegisterState() RegisterState {
 	}
 }
 
+// SetRegisters applies a register update. A new PC must be word aligned and point into
+// executable memory; nothing is changed if validation fails.
+func (s *DebuggerService) SetRegisters(update RegisterUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.debugger.Running || s.vm.State == vm.StateRunning {
+		return fmt.Errorf("cannot set registers while the program is running")
+	}
+
+	if pc := update.Registers[vm.ARMRegisterPC]; pc != nil {
+		if *pc&vm.AlignMaskWord != 0 {
+			return fmt.Errorf("PC 0x%08X is not word aligned", *pc)
+		}
+		if err := s.vm.Memory.CheckExecutePermission(*pc); err != nil {
+			return fmt.Errorf("invalid PC: %w", err)
+		}
+	}
+
+	for reg, value := range update.Registers {
+		if value != nil {
+			s.vm.CPU.SetRegister(reg, *value)
+		}
+	}
+	setFlag := func(flag *bool, value *bool) {
+		if value != nil {
+			*flag = *value
+		}
+	}
+	setFlag(&s.vm.CPU.CPSR.N, update.N)
+	setFlag(&s.vm.CPU.CPSR.Z, update.Z)
+	setFlag(&s.vm.CPU.CPSR.C, update.C)
+	setFlag(&s.vm.CPU.CPSR.V, update.V)
+
+	return nil
+}
+
 // Step executes a single instruction
 func (s *DebuggerService) Step() error {
 	s.mu.Lock()
@@ -214,6 +274,18 @@ func (s *DebuggerService) Step() error {
 	return s.vm.Step()
 }
 
+// StepBack undoes the most recent step using the VM's execution history.
+// It is refused while the program is running so the history cannot change underneath it.
+func (s *DebuggerService) StepBack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.debugger.Running || s.vm.State == vm.StateRunning {
+		return fmt.Errorf("cannot step back while the program is running")
+	}
+	return s.vm.StepBack()
+}
+
 // Continue runs until breakpoint or halt
 func (s *DebuggerService) Continue() error {
 	s.mu.Lock()
@@ -225,6 +297,22 @@ func (s *DebuggerService) Continue() error {
 	return nil
 }
 
+// RunTo sets a one-shot target for the next RunUntilHalt and marks execution as running.
+// The target is dropped when it is reached or when the run stops for any other reason.
+func (s *DebuggerService) RunTo(address uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.debugger == nil || s.program == nil {
+		return fmt.Errorf("no program loaded")
+	}
+
+	s.debugger.SetRunTo(address)
+	s.vm.State = vm.StateRunning
+
+	return nil
+}
+
 // Pause signals execution to stop and transitions to halted.
 //
 // When State == StateRunning a goroutine is executing vm.Step() without holding s.mu,
@@ -249,8 +337,12 @@ func (s *DebuggerService) Reset() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Full VM reset: clears all registers (PC=0), memory, and execution state
+	// Full VM reset: clears all registers (PC=0), memory, and execution state.
+	// Program arguments and environment are session configuration, so they survive the reset.
+	args, env := s.vm.ProgramArguments, s.vm.Environment
 	s.vm.Reset()
+	s.vm.SetProgramArguments(args)
+	s.vm.Environment = env
 
 	// Reset stdin reader to prevent hangs when stdin was redirected by GUI
 	// This ensures clean stdin state for the next program
@@ -300,12 +392,30 @@ func (s *DebuggerService) ResetToEntryPoint() error {
 }
 
 // GetExecutionState returns current execution state
+// While a run is in progress vm.State is owned by the goroutine inside vm.Step(), so
+// the state is derived from Running and waitingForInput instead.
 func (s *DebuggerService) GetExecutionState() ExecutionState {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.debugger.Running {
+		if s.waitingForInput.Load() {
+			return StateWaitingForInput
+		}
+		return StateRunning
+	}
 	return VMStateToExecution(s.vm.State)
 }
 
+// GetLastError returns the error that stopped the VM, or "" if it is not in the error state
+func (s *DebuggerService) GetLastError() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.debugger.Running || s.vm.State != vm.StateError || s.vm.LastError == nil {
+		return ""
+	}
+	return s.vm.LastError.Error()
+}
+
 // AddBreakpoint adds a breakpoint at the specified address
 func (s *DebuggerService) AddBreakpoint(address uint32) error {
 	s.mu.Lock()
@@ -338,15 +448,7 @@ func (s *DebuggerService) GetBreakpoints() []BreakpointInfo {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	bps := s.debugger.Breakpoints.GetAllBreakpoints()
-	result := make([]BreakpointInfo, len(bps))
-	for i, bp := range bps {
-		result[i] = BreakpointInfo{
-			Address: bp.Address,
-			Enabled: bp.Enabled,
-		}
-	}
-	return result
+	return s.debugger.Breakpoints.Summaries()
 }
 
 // ClearAllBreakpoints removes all breakpoints
@@ -378,11 +480,69 @@ func (s *DebuggerService) GetMemory(address uint32, size uint32) ([]byte, error)
 	return data, nil
 }
 
+// WriteMemory writes bytes starting at address, honouring segment permissions and bounds.
+// Writing stops at the first failing byte; the number of bytes written is returned.
+func (s *DebuggerService) WriteMemory(address uint32, data []byte) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.debugger.Running || s.vm.State == vm.StateRunning {
+		return 0, fmt.Errorf("cannot write memory while the program is running")
+	}
+	size, err := vm.SafeIntToUint32(len(data))
+	if err != nil || (size > 0 && address > vm.Address32BitMax-(size-1)) {
+		return 0, fmt.Errorf("write of %d bytes at 0x%08X exceeds the address space", len(data), address)
+	}
+
+	for i := uint32(0); i < size; i++ {
+		if err := s.vm.Memory.WriteByteAt(address+i, data[i]); err != nil {
+			return i, err
+		}
+	}
+
+	s.vm.LastMemoryWrite = address
+	s.vm.LastMemoryWriteSize = size
+	s.vm.HasMemoryWrite = true
+	return size, nil
+}
+
+// GetMemoryMap returns the memory segments with their permissions and access counts
+func (s *DebuggerService) GetMemoryMap() []MemorySegmentInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.vm == nil {
+		return []MemorySegmentInfo{}
+	}
+
+	result := make([]MemorySegmentInfo, len(s.vm.Memory.Segments))
+	for i, seg := range s.vm.Memory.Segments {
+		result[i] = MemorySegmentInfo{
+			Name:        seg.Name,
+			Start:       seg.Start,
+			Size:        seg.Size,
+			Permissions: seg.Permissions.String(),
+			Reads:       seg.Reads,
+			Writes:      seg.Writes,
+			Executes:    seg.Executes,
+		}
+		if seg.Name == "heap" {
+			result[i].Used, result[i].Blocks = s.vm.Memory.HeapInUse()
+		}
+	}
+	return result
+}
+
 // GetLastMemoryWrite returns the address of the last memory write and clears the flag
+// Nothing is reported while a run is in progress, since the VM updates these fields unlocked.
 func (s *DebuggerService) GetLastMemoryWrite() MemoryWriteInfo {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.debugger.Running {
+		return MemoryWriteInfo{}
+	}
+
 	result := MemoryWriteInfo{
 		Address:  s.vm.LastMemoryWrite,
 		Size:     s.vm.LastMemoryWriteSize,
@@ -457,11 +617,29 @@ func (s *DebuggerService) GetSymbolForAddress(addr uint32) string {
 // This handles the race where Pause() is called between Continue() setting Running=true
 // and this function starting execution.
 func (s *DebuggerService) RunUntilHalt() error {
+	return s.RunUntilHaltContext(context.Background())
+}
+
+// RunUntilHaltContext is RunUntilHalt with a context checked between instructions. Once
+// ctx is done the run stops in the error state; a passed deadline is a *vm.TimeoutError.
+func (s *DebuggerService) RunUntilHaltContext(ctx context.Context) error {
 	serviceLog.Println("RunUntilHalt() called")
+
+	// A run-to target only lasts for this run, whether or not it was reached
+	defer func() {
+		s.mu.Lock()
+		s.debugger.CancelRunTo()
+		s.mu.Unlock()
+	}()
+
 	s.mu.Lock()
 	// Check if already paused before we started (handles race with Pause())
 	if !s.debugger.Running {
 		serviceLog.Println("RunUntilHalt() - already paused, exiting early")
+		// SetRunning(true) may have marked the VM running before the pause arrived
+		if s.vm.State == vm.StateRunning {
+			s.vm.State = vm.StateHalted
+		}
 		s.mu.Unlock()
 		return nil
 	}
@@ -502,6 +680,13 @@ func (s *DebuggerService) RunUntilHalt() error {
 			break
 		}
 
+		if err := s.vm.CheckContext(ctx); err != nil {
+			serviceLog.Printf("Run stopped: %v", err)
+			s.debugger.Running = false
+			s.mu.Unlock()
+			return err
+		}
+
 		// Check breakpoints
 		if shouldBreak, _ := s.debugger.ShouldBreak(); shouldBreak {
 			serviceLog.Println("Breakpoint hit")
@@ -524,6 +709,7 @@ func (s *DebuggerService) RunUntilHalt() error {
 		// Reacquire lock to check state
 		s.mu.Lock()
 		halted := s.vm.State == vm.StateHalted
+		trapped := s.vm.LastBKPT != nil
 		s.mu.Unlock()
 
 		if stepCount == 0 {
@@ -547,6 +733,14 @@ func (s *DebuggerService) RunUntilHalt() error {
 			break
 		}
 
+		if trapped {
+			serviceLog.Printf("BKPT hit at PC=0x%08X", pc)
+			s.mu.Lock()
+			s.debugger.Running = false
+			s.mu.Unlock()
+			break
+		}
+
 		// Periodically yield to allow GUI to query state
 		stepCount++
 		if stepCount >= stepsBeforeYield {
@@ -639,7 +833,7 @@ func (s *DebuggerService) GetDisassembly(startAddr uint32, count int) []Disassem
 
 	for i := 0; i < count; i++ {
 		// Read instruction from memory
-		opcode, err := s.vm.Memory.ReadWord(addr)
+		opcode, err := s.vm.Memory.ReadInstruction(addr)
 		if err != nil {
 			// Memory read error - return what we have so far (truncated result)
 			break
@@ -648,10 +842,12 @@ func (s *DebuggerService) GetDisassembly(startAddr uint32, count int) []Disassem
 		// Get symbol at this address if any (use unsafe version since we already hold RLock)
 		symbol := s.getSymbolForAddressUnsafe(addr)
 
-		// Get mnemonic from source map if available
+		// Get mnemonic from source map if available, otherwise decode the word
 		mnemonic := ""
 		if sourceLine, ok := s.sourceMapByAddr[addr]; ok {
 			mnemonic = sourceLine
+		} else {
+			mnemonic, _ = vm.Disassemble(opcode, addr, nil)
 		}
 
 		line := DisassemblyLine{
@@ -768,7 +964,12 @@ func (s *DebuggerService) StepOver() error {
 	// Use debugger's SetStepOver to configure mode
 	s.debugger.SetStepOver()
 
-	// Execute until step completes
+	return s.runStepUnsafe()
+}
+
+// runStepUnsafe executes until the step mode set on the debugger completes, a breakpoint
+// is hit or the program stops. Caller must hold s.mu.
+func (s *DebuggerService) runStepUnsafe() error {
 	for s.debugger.Running {
 		// Check if we should break
 		if s.debugger.StepMode != debugger.StepSingle {
@@ -814,9 +1015,11 @@ func (s *DebuggerService) StepOut() error {
 	}
 
 	// Use debugger's public method instead of accessing fields directly
-	s.debugger.SetStepOut()
+	if err := s.debugger.SetStepOut(); err != nil {
+		return err
+	}
 
-	return nil
+	return s.runStepUnsafe()
 }
 
 // AddWatchpoint adds a watchpoint at the specified address
@@ -870,28 +1073,7 @@ func (s *DebuggerService) GetWatchpoints() []WatchpointInfo {
 		return []WatchpointInfo{}
 	}
 
-	wps := s.debugger.Watchpoints.GetAllWatchpoints()
-	result := make([]WatchpointInfo, len(wps))
-	for i, wp := range wps {
-		// Convert debugger.WatchType to string
-		var wpType string
-		switch wp.Type {
-		case debugger.WatchRead:
-			wpType = "read"
-		case debugger.WatchWrite:
-			wpType = "write"
-		case debugger.WatchReadWrite:
-			wpType = "readwrite"
-		}
-
-		result[i] = WatchpointInfo{
-			ID:      wp.ID,
-			Address: wp.Address,
-			Type:    wpType,
-			Enabled: wp.Enabled,
-		}
-	}
-	return result
+	return s.debugger.Watchpoints.Summaries()
 }
 
 // ExecuteCommand executes a debugger command and returns output
@@ -935,8 +1117,8 @@ func (s *DebuggerService) SendInput(input string) error {
 	// If not running and not waiting, buffer the input for later (batch stdin pattern)
 	s.mu.RLock()
 	running := s.debugger.Running
-	waiting := s.vm.State == vm.StateWaitingForInput
 	s.mu.RUnlock()
+	waiting := s.waitingForInput.Load()
 
 	if !running && !waiting {
 		s.mu.Lock()
@@ -951,10 +1133,10 @@ func (s *DebuggerService) SendInput(input string) error {
 	// NOTE: No mutex lock for pipe write! io.Pipe is already thread-safe.
 	// Taking a lock here causes deadlock when RunUntilHalt holds the lock while blocked on stdin read.
 
-	// Echo the input to the output window so the user can see what they typed
-	// Use RLock to safely access OutputWriter
+	// Echo the input to the output window (and any capture sinks) so the user can see
+	// what they typed. Use RLock to safely access OutputWriter
 	s.mu.RLock()
-	outputWriter := s.vm.OutputWriter
+	outputWriter := s.vm.Output()
 	s.mu.RUnlock()
 
 	if outputWriter != nil {
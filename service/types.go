@@ -1,6 +1,9 @@
 package service
 
-import "github.com/lookbusy1344/arm-emulator/vm"
+import (
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
 
 // RegisterState represents a snapshot of CPU registers
 type RegisterState struct {
@@ -10,6 +13,13 @@ type RegisterState struct {
 	Cycles    uint64
 }
 
+// RegisterUpdate lists register and flag values to change; nil entries are left unchanged.
+// Registers[15] is the PC.
+type RegisterUpdate struct {
+	Registers  [16]*uint32
+	N, Z, C, V *bool
+}
+
 // CPSRState represents CPSR flags for serialization
 type CPSRState struct {
 	N bool // Negative
@@ -18,19 +28,25 @@ type CPSRState struct {
 	V bool // Overflow
 }
 
-// BreakpointInfo represents a breakpoint for UI display
-type BreakpointInfo struct {
-	Address   uint32 `json:"address"`
-	Enabled   bool   `json:"enabled"`
-	Condition string `json:"condition"` // Expression that must evaluate to true
-}
+// BreakpointInfo represents a breakpoint for UI display. It is the same shape the
+// debugger's "info breakpoints json" prints.
+type BreakpointInfo = debugger.BreakpointSummary
 
-// WatchpointInfo represents a watchpoint for UI display
-type WatchpointInfo struct {
-	ID      int    `json:"id"`
-	Address uint32 `json:"address"`
-	Type    string `json:"type"` // "read", "write", "readwrite"
-	Enabled bool   `json:"enabled"`
+// WatchpointInfo represents a watchpoint for UI display. It is the same shape the
+// debugger's "info watchpoints json" prints.
+type WatchpointInfo = debugger.WatchpointSummary
+
+// MemorySegmentInfo describes a memory segment for the memory map
+type MemorySegmentInfo struct {
+	Name        string `json:"name"`
+	Start       uint32 `json:"start"`
+	Size        uint32 `json:"size"`
+	Permissions string `json:"permissions"` // "rwx" style, '-' for each permission missing
+	Reads       uint64 `json:"reads"`
+	Writes      uint64 `json:"writes"`
+	Executes    uint64 `json:"executes"` // Instruction fetches
+	Used        uint32 `json:"used"`     // Bytes allocated; tracked for the heap only
+	Blocks      int    `json:"blocks"`   // Allocated blocks; tracked for the heap only
 }
 
 // MemoryRegion represents a contiguous memory region
@@ -96,4 +112,6 @@ type SourceMapEntry struct {
 	Address    uint32 `json:"address"`
 	LineNumber int    `json:"lineNumber"` // 1-based source file line number
 	Line       string `json:"line"`       // Source code text
+	StartCol   int    `json:"startCol"`   // 1-based column of the mnemonic within Line
+	EndCol     int    `json:"endCol"`     // Column just past the last operand (exclusive)
 }
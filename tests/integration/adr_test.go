@@ -3,6 +3,10 @@ package integration_test
 import (
 	"strings"
 	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // TestADRBasic tests basic ADR instruction (forward reference)
@@ -133,3 +137,43 @@ data:	.word 0xCAFEBABE
 		t.Errorf("Expected output to contain 'cafebabe', got: %q", stdout)
 	}
 }
+
+// TestADRRegisterEqualsLabelAddress checks at runtime that ADR yields exactly the label address
+func TestADRRegisterEqualsLabelAddress(t *testing.T) {
+	source := `
+		.org 0x8000
+_start:	ADR R0, target      ; target is 8 bytes ahead: ADD R0, PC, #0
+		ADR R1, _start      ; backward: SUB R1, PC, #8
+target:	MOV R2, #0
+		SWI #0x00
+`
+
+	p := parser.NewParser(source, "adr_runtime.s")
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := machine.Step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+
+	target, _ := program.SymbolTable.Get("target")
+	start, _ := program.SymbolTable.Get("_start")
+	if target != 0x8008 {
+		t.Fatalf("expected target label 8 bytes ahead at 0x8008, got 0x%08X", target)
+	}
+	if machine.CPU.R[0] != target {
+		t.Errorf("ADR R0, target: got 0x%08X, want 0x%08X", machine.CPU.R[0], target)
+	}
+	if machine.CPU.R[1] != start {
+		t.Errorf("ADR R1, _start: got 0x%08X, want 0x%08X", machine.CPU.R[1], start)
+	}
+}
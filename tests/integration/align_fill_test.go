@@ -0,0 +1,75 @@
+package integration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestAlignFill_WritesPadding tests that .balign and .align write their fill byte into
+// every padding byte, that the default fill is zero and that data after them is untouched
+func TestAlignFill_WritesPadding(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	MOV R0, #0
+	SWI #0x00
+first:
+	.byte 0x11
+	.balign 4, 0xFF
+second:
+	.byte 0x22
+	.align 3, 0xA5
+third:
+	.byte 0x33
+	.balign 4
+fourth:
+	.word 0x44444444
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	machine := vm.NewVM()
+	// Leave non-zero junk where the default-filled padding goes
+	for addr := uint32(0x8011); addr < 0x8014; addr++ {
+		if err := machine.Memory.WriteByteUnsafe(addr, 0xEE); err != nil {
+			t.Fatalf("setup write failed: %v", err)
+		}
+	}
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	want := map[uint32]byte{
+		0x8008: 0x11, 0x8009: 0xFF, 0x800A: 0xFF, 0x800B: 0xFF, // .balign 4, 0xFF
+		0x800C: 0x22, 0x800D: 0xA5, 0x800E: 0xA5, 0x800F: 0xA5, // .align 3, 0xA5
+		0x8010: 0x33, 0x8011: 0x00, 0x8012: 0x00, 0x8013: 0x00, // .balign 4
+		0x8014: 0x44,
+	}
+	for addr, w := range want {
+		got, err := machine.Memory.ReadByteAt(addr)
+		if err != nil {
+			t.Fatalf("read at 0x%08X failed: %v", addr, err)
+		}
+		if got != w {
+			t.Errorf("byte at 0x%08X: expected 0x%02X, got 0x%02X", addr, w, got)
+		}
+	}
+	if third := program.SymbolTable.GetAllSymbols()["third"].Value; third != 0x8010 {
+		t.Errorf("expected third at 0x00008010, got 0x%08X", third)
+	}
+}
+
+// TestAlignFill_InvalidFill tests that a fill value that is not a byte is rejected
+func TestAlignFill_InvalidFill(t *testing.T) {
+	for _, source := range []string{".byte 1\n.balign 4, 0x100\n", ".byte 1\n.align 2, missing\n"} {
+		_, err := parser.NewParser(source, "test.s").Parse()
+		if err == nil || !strings.Contains(err.Error(), "invalid fill value") {
+			t.Errorf("%q: expected an invalid fill value error, got %v", source, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// largeFunction builds a function of blocks that each load a literal and then run past
+// pad instructions to a branch, so the loads are spread over far more than 4KB
+func largeFunction(blocks, pad int) string {
+	var sb strings.Builder
+	sb.WriteString("\t.org 0x8000\n_start:\n\tMOV R9, #0\n")
+	for b := 0; b < blocks; b++ {
+		fmt.Fprintf(&sb, "\tLDR R0, =0x%08X\n\tADD R9, R9, R0\n", 0x11110000+b)
+		for i := 0; i < pad; i++ {
+			sb.WriteString("\tADD R1, R1, #1\n")
+		}
+		fmt.Fprintf(&sb, "\tB block%d\nblock%d:\n", b, b)
+	}
+	sb.WriteString("\tMOV R0, R9\n\tSWI #0x00\n")
+	return sb.String()
+}
+
+func TestAutomaticLiteralPools_LargeFunction(t *testing.T) {
+	const blocks = 12
+	source := largeFunction(blocks, 180)
+
+	machine, program := loadLtorgProgram(t, source)
+	if len(program.LiteralPoolLocs) < 2 {
+		t.Fatalf("expected several automatic pools across the function, got %v", program.LiteralPoolLocs)
+	}
+	for i, loc := range program.LiteralPoolLocs {
+		if !program.LiteralPoolAuto[i] {
+			t.Errorf("pool at 0x%08X should be marked automatic", loc)
+		}
+		// Each pool follows a branch, so execution never runs into it
+		opcode, err := machine.Memory.ReadWord(loc - 4)
+		if err != nil || opcode&0x0F000000 != 0x0A000000 {
+			t.Errorf("expected a B before the pool at 0x%08X, got 0x%08X (err %v)", loc, opcode, err)
+		}
+	}
+
+	// Every literal load reaches its pool with a 12-bit offset and reads the right value
+	for _, inst := range program.Instructions {
+		if inst.Mnemonic != "LDR" || !strings.HasPrefix(inst.Operands[1], "=") {
+			continue
+		}
+		opcode, err := machine.Memory.ReadWord(inst.Address)
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		target := inst.Address + 8 + opcode&0xFFF
+		if opcode&(1<<23) == 0 {
+			target = inst.Address + 8 - opcode&0xFFF
+		}
+		value, ok := program.LiteralPool[target]
+		if !ok || fmt.Sprintf("=0x%08X", value) != inst.Operands[1] {
+			t.Errorf("load at 0x%08X reads 0x%08X from 0x%08X, want %s", inst.Address, value, target, inst.Operands[1])
+		}
+	}
+
+	fresh, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	result, err := loader.RunProgram(fresh, loader.RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Err != nil {
+		t.Fatalf("run failed: %v", result.Err)
+	}
+	want := uint32(0)
+	for b := 0; b < blocks; b++ {
+		want += 0x11110000 + uint32(b)
+	}
+	if result.Registers[0] != want {
+		t.Errorf("expected R0=0x%08X, got 0x%08X", want, result.Registers[0])
+	}
+}
+
+func TestAutomaticLiteralPools_NotNeeded(t *testing.T) {
+	// A short function keeps its literals in the pool at the end of the program
+	program, err := parser.NewParser(largeFunction(3, 10), "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(program.LiteralPoolLocs) != 0 {
+		t.Errorf("expected no automatic pools, got %v", program.LiteralPoolLocs)
+	}
+}
+
+func TestPoolDirective(t *testing.T) {
+	source := `
+.org 0x8000
+
+main:
+    LDR R0, =0x12345678
+    B   after_pool
+    .pool
+
+after_pool:
+    SWI #0x00
+`
+	machine, program := loadLtorgProgram(t, source)
+	if len(program.LiteralPoolLocs) != 1 || program.LiteralPoolAuto[0] {
+		t.Fatalf("expected one .pool location, got %v (auto %v)", program.LiteralPoolLocs, program.LiteralPoolAuto)
+	}
+	poolLoc := program.LiteralPoolLocs[0]
+	if word, err := machine.Memory.ReadWord(poolLoc); err != nil || word != 0x12345678 {
+		t.Errorf("expected the literal at .pool 0x%08X, got 0x%08X (err %v)", poolLoc, word, err)
+	}
+}
+
+func TestLiteralPoolOutOfRange_SuggestsLtorg(t *testing.T) {
+	// Without a branch there is nowhere to put a pool, so the load is out of range
+	source := "\t.org 0x8000\n_start:\n\tLDR R0, =0x12345678\n" +
+		strings.Repeat("\tADD R1, R1, #1\n", 1100) + "\tSWI #0x00\n"
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	_, err = loader.RunProgram(program, loader.RunOptions{})
+	if err == nil {
+		t.Fatal("expected the literal load to be out of range")
+	}
+	if !strings.Contains(err.Error(), "literal pool offset too large") || !strings.Contains(err.Error(), "place a .ltorg") {
+		t.Errorf("expected an out-of-range error suggesting .ltorg, got: %v", err)
+	}
+}
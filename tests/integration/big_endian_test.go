@@ -0,0 +1,57 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestBigEndianProgram runs a program with big-endian data: .word is stored most significant
+// byte first and LDRB sees that order, while the code itself still executes
+func TestBigEndianProgram(t *testing.T) {
+	source := `
+		.org 0x8000
+_start:	LDR R1, =value
+		LDR R2, [R1]
+		LDRB R3, [R1]
+		LDRH R4, [R1]
+		MOV R0, #0
+		SWI #0x00
+value:	.word 0x12345678
+	`
+
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	machine := vm.NewVM()
+	machine.Memory.LittleEndian = false
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	valueAddr, err := program.SymbolTable.Get("value")
+	if err != nil {
+		t.Fatalf("missing symbol: %v", err)
+	}
+	if b, _ := machine.Memory.ReadByteAt(valueAddr); b != 0x12 {
+		t.Errorf(".word: expected first byte 0x12, got 0x%02X", b)
+	}
+
+	if err := machine.Run(); err != nil && machine.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if machine.CPU.R[2] != 0x12345678 {
+		t.Errorf("LDR: expected 0x12345678, got 0x%08X", machine.CPU.R[2])
+	}
+	if machine.CPU.R[3] != 0x12 {
+		t.Errorf("LDRB: expected 0x12, got 0x%02X", machine.CPU.R[3])
+	}
+	if machine.CPU.R[4] != 0x1234 {
+		t.Errorf("LDRH: expected 0x1234, got 0x%04X", machine.CPU.R[4])
+	}
+}
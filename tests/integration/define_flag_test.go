@@ -0,0 +1,42 @@
+package integration_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDefineFlag tests that -D selects an .if block and that its value is usable as a
+// constant
+func TestDefineFlag(t *testing.T) {
+	progPath := createTestProgram(t, `
+        .org 0x8000
+_start:
+.if LEVEL > 1
+        MOV R0, #LEVEL
+.else
+        MOV R0, #9
+.endif
+        SWI #0x00
+`)
+	defer os.Remove(progPath)
+
+	for _, tt := range []struct {
+		define   string
+		wantExit int
+	}{
+		{"LEVEL=3", 3},
+		{"LEVEL=1", 9},
+	} {
+		_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-D", tt.define)
+		if exitCode != tt.wantExit {
+			t.Errorf("-D %s: expected exit code %d, got %d\nStderr: %s", tt.define, tt.wantExit, exitCode, stderr)
+		}
+	}
+
+	// Without the define the condition cannot be evaluated
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath)
+	if exitCode != 1 || !strings.Contains(stderr, "undefined symbol \"LEVEL\"") {
+		t.Errorf("Expected an undefined symbol error without -D, got exit %d\nStderr: %s", exitCode, stderr)
+	}
+}
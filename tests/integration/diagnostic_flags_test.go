@@ -1,9 +1,11 @@
 package integration_test
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -131,6 +133,69 @@ start:
 	t.Logf("Memory trace generated %d entries", len(lines))
 }
 
+// TestHeatmapFlag tests the --heatmap-file and --heatmap-block flags
+func TestHeatmapFlag(t *testing.T) {
+	code := `.org 0x8000
+start:
+    MOV R2, #5
+outer:
+    LDR R1, =array
+    MOV R3, #8
+inner:
+    LDR R0, [R1]
+    ADD R0, R0, #1
+    STR R0, [R1], #4
+    SUBS R3, R3, #1
+    BNE inner
+    SUBS R2, R2, #1
+    BNE outer
+    MOV R0, #0
+    SWI #0x00
+    .balign 32
+array:
+    .space 32
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	heatmapPath := filepath.Join(t.TempDir(), "heat.csv")
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath,
+		"--heatmap-file", heatmapPath,
+		"--heatmap-block", "32")
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	data, err := os.ReadFile(heatmapPath) // #nosec G304 -- test temp file
+	if err != nil {
+		t.Fatalf("Failed to read heatmap file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "address,reads,writes" {
+		t.Fatalf("Unexpected CSV header: %q", lines[0])
+	}
+
+	// The 8-word array fills one 32-byte block, with each word read and written 5 times;
+	// the literal pool word is only read once per outer pass
+	hottest := ""
+	hottestCount := 0
+	for _, line := range lines[1:] {
+		var addr uint32
+		var reads, writes int
+		if _, err := fmt.Sscanf(line, "0x%X,%d,%d", &addr, &reads, &writes); err != nil {
+			t.Fatalf("Malformed CSV row %q: %v", line, err)
+		}
+		if reads+writes > hottestCount {
+			hottest, hottestCount = line, reads+writes
+		}
+	}
+	if !strings.HasSuffix(hottest, ",40,40") {
+		t.Errorf("Expected hottest block to be the array with 40 reads and 40 writes, got %q\n%s", hottest, data)
+	}
+}
+
 // TestCoverageFlag tests the --coverage flag
 func TestCoverageFlag(t *testing.T) {
 	code := `.org 0x8000
@@ -198,6 +263,88 @@ skip:
 	t.Logf("Coverage output generated successfully")
 }
 
+// TestCoverageFailUnderFlag tests that --coverage-fail-under reports the dead branch and
+// fails only when coverage is below the threshold
+func TestCoverageFailUnderFlag(t *testing.T) {
+	code := `.org 0x8000
+start:
+    MOV R0, #1
+    CMP R0, #1
+    BEQ done
+dead:
+    MOV R1, #2
+done:
+    MOV R0, #0
+    SWI #0x00
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+	coveragePath := filepath.Join(t.TempDir(), "coverage.txt")
+
+	// 5 of the 6 instructions run
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath,
+		"--coverage-fail-under", "90",
+		"--coverage-file", coveragePath)
+	if exitCode != 125 {
+		t.Errorf("Expected exit code 125, got %d\nStderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "Coverage 83.33% is below the required 90.00%") {
+		t.Errorf("Expected a coverage failure message, got: %s", stderr)
+	}
+
+	coverageData, err := os.ReadFile(coveragePath)
+	if err != nil {
+		t.Fatalf("Failed to read coverage file: %v", err)
+	}
+	if !regexp.MustCompile(`0x0000800C \[dead\]  \S+:7: MOV R1, #2`).Match(coverageData) {
+		t.Errorf("Expected the dead branch to be reported, got:\n%s", coverageData)
+	}
+
+	_, stderr, exitCode = runEmulatorWithFlags(t, progPath,
+		"--coverage-fail-under", "80",
+		"--coverage-file", coveragePath)
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0 above the threshold, got %d\nStderr: %s", exitCode, stderr)
+	}
+}
+
+// TestCoverageFailUnderSkipsData tests that coverage counts only assembled instructions,
+// not literal data or the gap before a later .org block
+func TestCoverageFailUnderSkipsData(t *testing.T) {
+	code := `.org 0x8000
+start:
+    BL sub
+    MOV R0, #0
+    SWI #0x00
+table:
+    .word 1, 2, 3, 4
+.org 0x8100
+sub:
+    MOV R1, #1
+    MOV PC, LR
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+	coveragePath := filepath.Join(t.TempDir(), "coverage.txt")
+
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath,
+		"--coverage-fail-under", "100",
+		"--coverage-file", coveragePath)
+	if exitCode != 0 {
+		t.Errorf("Expected every instruction to count as covered, got exit code %d\nStderr: %s", exitCode, stderr)
+	}
+
+	coverageData, err := os.ReadFile(coveragePath)
+	if err != nil {
+		t.Fatalf("Failed to read coverage file: %v", err)
+	}
+	if !strings.Contains(string(coverageData), "Total Instructions:   5\n") {
+		t.Errorf("Expected 5 instructions in the report, got:\n%s", coverageData)
+	}
+}
+
 // TestCoverageFlagJSON tests the --coverage flag with JSON format
 func TestCoverageFlagJSON(t *testing.T) {
 	code := `.org 0x8000
@@ -578,3 +725,66 @@ start:
 		}
 	}
 }
+
+// TestRegDiffFlag tests that --reg-diff logs each instruction's register changes to stderr
+func TestRegDiffFlag(t *testing.T) {
+	code := `.org 0x8000
+start:
+    MOV R0, #5
+    ADD R0, R0, #10
+    SUBS R1, R0, #15
+    MOV R0, #0
+    SWI #0x00
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "--reg-diff")
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+	if strings.Contains(stdout, "0x00008000") {
+		t.Errorf("Expected the diff to stay off stdout, got %q", stdout)
+	}
+
+	for _, want := range []string{
+		"0x00008000: R0: 0 -> 5\n",
+		"0x00008004: R0: 5 -> 15\n",
+		"0x00008008: CPSR: ---- -> -ZC-\n",
+		"0x0000800C: R0: 15 -> 0\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q in stderr:\n%s", want, stderr)
+		}
+	}
+}
+
+// TestRegDiffFlagSkipsUnchanged tests that --reg-diff logs nothing for an instruction that
+// leaves every register and flag as it was
+func TestRegDiffFlagSkipsUnchanged(t *testing.T) {
+	code := `.org 0x8000
+start:
+    MOV R0, #5
+    MOV R0, R0
+    NOP
+    MOV R0, #0
+    SWI #0x00
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "--reg-diff")
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+	for _, unchanged := range []string{"0x00008004", "0x00008008"} {
+		if strings.Contains(stderr, unchanged) {
+			t.Errorf("Expected no line for %s, got:\n%s", unchanged, stderr)
+		}
+	}
+	if !strings.Contains(stderr, "0x0000800C: R0: 5 -> 0\n") {
+		t.Errorf("Expected the later change to be logged, got:\n%s", stderr)
+	}
+}
@@ -0,0 +1,83 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDisasmFlag_Fibonacci(t *testing.T) {
+	progPath := filepath.Join("..", "..", "examples", "fibonacci.s")
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-disasm")
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	lines := strings.Split(stdout, "\n")
+	want := []string{
+		"main:",
+		"0x00008000  E3A00C81     MOV R0, #0x8100",
+		"0x00008004  EB000033     BL print_string",
+		"0x00008008  EB000038     BL read_int",
+		"0x0000800C  E1A04000     MOV R4, R0",
+		"0x00008010  E3540000     CMP R4, #0",
+		"0x00008014  DA000027     BLE error_invalid",
+	}
+	if len(lines) < len(want) {
+		t.Fatalf("Listing too short:\n%s", stdout)
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d: expected %q, got %q", i+1, line, lines[i])
+		}
+	}
+
+	// Data directives and literal pool entries are listed as data
+	for _, fragment := range []string{
+		"comma_space:\n0x00008145  2C 20 00     .byte 0x2C, 0x20, 0x00\n",
+		"0x00008194  00008130     .word 0x00008130\t; literal pool\n",
+	} {
+		if !strings.Contains(stdout, fragment) {
+			t.Errorf("Expected listing to contain %q", fragment)
+		}
+	}
+
+	// The program is not run, so its prompt is never printed
+	if strings.Contains(stdout, "How many Fibonacci") {
+		t.Error("Program should not execute with -disasm")
+	}
+}
+
+func TestDisasmFlag_WordsAndSpace(t *testing.T) {
+	code := `.org 0x8000
+_start:
+    LDR R0, =table
+    SWI #0x00
+table:
+    .word 0xDEADBEEF, table
+buffer:
+    .space 8
+`
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-disasm")
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	want := `_start:
+0x00008000  E59F0010     LDR R0, [PC, #0x10]	; =0x00008008 (table)
+0x00008004  EF000000     SWI #0
+table:
+0x00008008  DEADBEEF     .word 0xDEADBEEF
+0x0000800C  00008008     .word 0x00008008
+buffer:
+0x00008010               .space 8
+0x00008018  00008008     .word 0x00008008	; literal pool
+`
+	if stdout != want {
+		t.Errorf("Unexpected listing:\n%s\nwant:\n%s", stdout, want)
+	}
+}
@@ -0,0 +1,69 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestEquConstantsInProgram uses .equ/.set constants as immediates and as a .space size
+func TestEquConstantsInProgram(t *testing.T) {
+	program := `
+		.equ BUFSIZE, 16
+		.equ WORDS, BUFSIZE/4
+		.set BIAS, 1
+		.set BIAS, BIAS*2
+		.org 0x8000
+_start:	LDR R1, =buffer
+		LDR R2, =after
+		SUB R2, R2, R1		; R2 = bytes reserved by .space
+		MOV R0, #WORDS
+		ADD R0, R0, #BIAS
+		ADD R0, R0, R2		; 4 + 2 + 32
+		SWI #0x00
+buffer:	.space BUFSIZE*2
+after:	.word 0
+	`
+
+	_, _, exitCode, err := runAssembly(t, program)
+	if err != nil {
+		t.Fatalf("Failed to run program: %v", err)
+	}
+	if exitCode != 38 {
+		t.Errorf("Expected exit code 38, got %d", exitCode)
+	}
+}
+
+// TestDirectiveExpressionValues checks the loader writes evaluated .word and .byte expressions
+func TestDirectiveExpressionValues(t *testing.T) {
+	source := `
+		.equ BASE, 0x10
+		.org 0x8000
+_start:	SWI #0x00
+table:	.word table + 8, (BASE << 4) | 1, -BASE
+bytes:	.byte BASE * 2, 'a' - 32
+	`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	for i, want := range []uint32{0x800C, 0x101, 0xFFFFFFF0} {
+		addr := uint32(0x8004 + 4*i) // #nosec G115 -- small test offset
+		if got, _ := machine.Memory.ReadWord(addr); got != want {
+			t.Errorf(".word %d: got 0x%X, want 0x%X", i, got, want)
+		}
+	}
+	for i, want := range []byte{0x20, 'A'} {
+		addr := uint32(0x8010 + i) // #nosec G115 -- small test offset
+		if got, _ := machine.Memory.ReadByteAt(addr); got != want {
+			t.Errorf(".byte %d: got 0x%X, want 0x%X", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package integration_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+)
+
+// updateGoldens rewrites expected_outputs/ from the current emulator instead of comparing:
+//
+//	go test ./tests/integration -run TestExampleGoldens -update
+var updateGoldens = flag.Bool("update", false, "regenerate expected_outputs/ golden files for TestExampleGoldens")
+
+// goldenSeed and goldenClock make SWI_GET_RANDOM, SWI_GET_TIME and SWI_GET_DATETIME
+// return the same values on every run
+const goldenSeed = 42
+
+var goldenClock = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// goldenInputs is the stdin given to examples that read input; the rest get none
+var goldenInputs = map[string]string{
+	"bubble_sort":           "7\n5\n1\n4\n2\n8\n3\n6\n",
+	"calculator":            "15\n+\n7\nq\n",
+	"celsius_to_fahrenheit": "25\n",
+	"factorial":             "5\n",
+	"fibonacci":             "10\n",
+	"gcd":                   "48\n18\n",
+	"string_copy_manual":    "ARM assembly\n",
+	"string_reverse":        "Hello World\n",
+	"times_table":           "7\n",
+}
+
+// TestExampleGoldens runs every program in examples/ through loader.RunFile with a fixed
+// seed, clock and input, and compares its console output with expected_outputs/<name>.txt
+func TestExampleGoldens(t *testing.T) {
+	examples, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) == 0 {
+		t.Skip("no example programs found")
+	}
+
+	for _, path := range examples {
+		name := strings.TrimSuffix(filepath.Base(path), ".s")
+		t.Run(name, func(t *testing.T) {
+			result, err := loader.RunFile(path, loader.RunOptions{
+				Input:          goldenInputs[name],
+				Seed:           goldenSeed,
+				Clock:          func() time.Time { return goldenClock },
+				FilesystemRoot: t.TempDir(),
+			})
+			if err != nil {
+				t.Fatalf("failed to load %s: %v", filepath.Base(path), err)
+			}
+			if result.Err != nil {
+				t.Fatalf("runtime error: %v", result.Err)
+			}
+
+			goldenPath := filepath.Join("expected_outputs", name+".txt")
+			if *updateGoldens {
+				if err := os.WriteFile(goldenPath, []byte(result.Output), 0o600); err != nil {
+					t.Fatalf("failed to write %s: %v", goldenPath, err)
+				}
+				return
+			}
+
+			expectedBytes, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden output %s (run with -update to create it): %v", goldenPath, err)
+			}
+			expected := string(expectedBytes)
+
+			if result.Output != expected {
+				t.Errorf("output mismatch with %s (run with -update to accept)\nExpected (%d bytes):\n%q\nGot (%d bytes):\n%q",
+					goldenPath, len(expected), expected, len(result.Output), result.Output)
+			}
+		})
+	}
+}
+
+// TestRunOptions_Clock checks that a fixed clock is what SWI_GET_TIME reports
+func TestRunOptions_Clock(t *testing.T) {
+	source := `
+_start:
+	SWI #0x30
+	SWI #0x03
+	MOV R0, #0
+	SWI #0x00
+`
+	clock := time.UnixMilli(1000)
+	result := runSource(t, source, loader.RunOptions{Clock: func() time.Time { return clock }})
+	if result.Err != nil {
+		t.Fatalf("runtime error: %v", result.Err)
+	}
+	if result.Output != "1000" {
+		t.Errorf("expected GET_TIME to print 1000, got %q", result.Output)
+	}
+}
@@ -0,0 +1,106 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// writeIncbinProgram writes source and a binary file next to it, returning the source path
+func writeIncbinProgram(t *testing.T, source string, blob []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "table.bin"), blob, 0o600); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	path := filepath.Join(dir, "prog.s")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+	return path
+}
+
+// TestIncbin_EmbedsBytes tests that .incbin places the file's bytes at the current
+// address and advances the location counter past them
+func TestIncbin_EmbedsBytes(t *testing.T) {
+	blob := []byte{0x01, 0x02, 0x03, 0xFE, 0xFF}
+	path := writeIncbinProgram(t, `
+	.org 0x8000
+_start:
+	LDR R1, =table
+	LDRB R0, [R1, #3]
+	SWI #0x00
+table:
+	.incbin "table.bin"
+after:
+	.byte 0x55
+`, blob)
+
+	program, _, err := parser.ParseFileSimple(path)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	table := program.SymbolTable.GetAllSymbols()["table"].Value
+	after := program.SymbolTable.GetAllSymbols()["after"].Value
+	if table != 0x800C || after != table+uint32(len(blob)) {
+		t.Fatalf("expected table at 0x0000800C and after at table+%d, got 0x%08X and 0x%08X", len(blob), table, after)
+	}
+
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	for i, want := range append(blob, 0x55) {
+		got, err := machine.Memory.ReadByteAt(table + uint32(i))
+		if err != nil {
+			t.Fatalf("read at table+%d failed: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("expected 0x%02X at table+%d, got 0x%02X", want, i, got)
+		}
+	}
+
+	// The literal pool for LDR R1, =table must not overlap the embedded bytes
+	result, err := loader.RunFile(path, loader.RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.ExitCode != 0xFE {
+		t.Errorf("expected exit code 0xFE from the fourth byte, got 0x%X", result.ExitCode)
+	}
+}
+
+// TestIncbin_SkipAndCount tests the optional skip and count arguments
+func TestIncbin_SkipAndCount(t *testing.T) {
+	path := writeIncbinProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+table:
+	.incbin "table.bin", 1, 2
+after:
+`, []byte{0x10, 0x20, 0x30, 0x40})
+
+	program, _, err := parser.ParseFileSimple(path)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	table := program.SymbolTable.GetAllSymbols()["table"].Value
+	if after := program.SymbolTable.GetAllSymbols()["after"].Value; after != table+2 {
+		t.Errorf("expected after at table+2, got table+%d", after-table)
+	}
+
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	for i, want := range []byte{0x20, 0x30} {
+		if got, _ := machine.Memory.ReadByteAt(table + uint32(i)); got != want {
+			t.Errorf("expected 0x%02X at table+%d, got 0x%02X", want, i, got)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package integration_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMaxInstructionsFlag(t *testing.T) {
+	code := `
+        .org 0x8000
+main:
+        MOV R0, #0
+loop:
+        ADD R0, R0, #1
+        B loop
+`
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-max-instructions", "50")
+	if exitCode == 0 {
+		t.Fatalf("Expected a non-zero exit code for an infinite loop\nStdout: %s\nStderr: %s", stdout, stderr)
+	}
+	if output := stdout + stderr; !strings.Contains(output, "instruction limit exceeded (50 instructions)") {
+		t.Errorf("Expected instruction limit error, got:\nStdout: %s\nStderr: %s", stdout, stderr)
+	}
+}
@@ -0,0 +1,61 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// ldrLabelProgram loads the addresses of strings defined after the code, one of them
+// too far away for MOV, and sums the bytes of "Hi!" as its exit code
+const ldrLabelProgram = `
+        .org 0x8000
+_start:
+        LDR R1, =message
+        MOV R0, #0
+loop:   LDRB R2, [R1], #1
+        CMP R2, #0
+        ADDNE R0, R0, R2
+        BNE loop
+        LDR R3, =far
+        LDR R4, =message + 1
+        SWI #0x00
+message: .asciz "Hi!"
+        .data
+far:    .asciz "far away"
+`
+
+func TestLDRLabel_LoadsAddressOfForwardLabel(t *testing.T) {
+	program, err := parser.NewParser(ldrLabelProgram, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if err := machine.Run(); machine.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	message, _ := program.SymbolTable.Lookup("message")
+	far, _ := program.SymbolTable.Lookup("far")
+	if machine.CPU.R[3] != far.Value || far.Value != parser.DataSectionStart {
+		t.Errorf("expected R3 = address of far (0x%08X), got 0x%08X", far.Value, machine.CPU.R[3])
+	}
+	if machine.CPU.R[4] != message.Value+1 {
+		t.Errorf("expected R4 = message+1 (0x%08X), got 0x%08X", message.Value+1, machine.CPU.R[4])
+	}
+
+	// The loaded address holds the string
+	for i, want := range []byte("Hi!\x00") {
+		if got, _ := machine.Memory.ReadByteAt(message.Value + uint32(i)); got != want { // #nosec G115 -- small test offset
+			t.Errorf("byte %d at message: got 0x%02X, want 0x%02X", i, got, want)
+		}
+	}
+	if want := int32('H' + 'i' + '!'); machine.ExitCode != want {
+		t.Errorf("expected exit code %d from the string bytes, got %d", want, machine.ExitCode)
+	}
+}
@@ -0,0 +1,34 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// TestLineIndex tests that each source line maps to the lowest address assembled from it
+func TestLineIndex(t *testing.T) {
+	program, err := parser.NewParser(`	.org 0x8000
+_start:
+	MOV R0, #0
+	.rept 3
+	ADD R0, R0, #1
+	.endr
+	SWI #0x00
+`, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	index := loader.LineIndex(program)
+	want := map[int]uint32{3: 0x8000, 5: 0x8004, 7: 0x8010}
+	if len(index) != 1 || len(index["test.s"]) != len(want) {
+		t.Fatalf("expected %d lines of test.s, got %v", len(want), index)
+	}
+	for line, addr := range want {
+		if got := index["test.s"][line]; got != addr {
+			t.Errorf("line %d: expected 0x%X, got 0x%X", line, addr, got)
+		}
+	}
+}
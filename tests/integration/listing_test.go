@@ -0,0 +1,50 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListingFlag(t *testing.T) {
+	code := `; listing test
+        .org 0x8000
+main:
+        MOV R0, #5      ; five
+        LDR R1, =msg
+        SWI #0
+msg:    .asciz "Hello"
+`
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	listPath := filepath.Join(t.TempDir(), "program.lst")
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-listing", listPath)
+	// The program still runs after the listing is written and exits with R0
+	if exitCode != 5 {
+		t.Fatalf("Expected exit code 5, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	data, err := os.ReadFile(listPath) // #nosec G304 -- test temp file
+	if err != nil {
+		t.Fatalf("Failed to read listing: %v", err)
+	}
+	listing := string(data)
+
+	for _, line := range []string{
+		" Line  Address     Code         Source",
+		"    1                           ; listing test",
+		"    3                           main:",
+		"    4  0x00008000  E3A00005             MOV R0, #5      ; five",
+		"    6  0x00008008  EF000000             SWI #0",
+		"    7  0x0000800C  48 65 6C 6C  msg:    .asciz \"Hello\"",
+		"       0x00008010  6F 00",
+		"       0x00008014  0000800C",
+		"main                           Label        0x00008000 Defined",
+	} {
+		if !strings.Contains(listing, line+"\n") {
+			t.Errorf("Expected listing to contain %q, got:\n%s", line, listing)
+		}
+	}
+}
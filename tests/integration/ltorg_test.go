@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/loader"
 	"github.com/lookbusy1344/arm-emulator/parser"
 	"github.com/lookbusy1344/arm-emulator/vm"
 )
@@ -822,3 +823,98 @@ section3:
 
 	t.Logf("✓ Address adjustment test passed")
 }
+
+// loadLtorgProgram parses and loads source, returning the VM and program
+func loadLtorgProgram(t *testing.T, source string) (*vm.VM, *parser.Program) {
+	t.Helper()
+
+	p := parser.NewParser(source, "test_ltorg_pools.s")
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	return machine, program
+}
+
+func TestLtorgDirective_EmitsPendingLiterals(t *testing.T) {
+	source := `
+.org 0x8000
+
+main:
+    LDR R0, =0x12345678
+    B   after_pool
+    .ltorg
+
+after_pool:
+    LDR R1, =0xCAFEBABE
+    MOV R0, #0
+    SWI #0x00
+`
+
+	machine, program := loadLtorgProgram(t, source)
+	poolLoc := program.LiteralPoolLocs[0]
+
+	// The first literal must be emitted at the .ltorg, not at the end of the program
+	if value, ok := program.LiteralPool[poolLoc]; !ok || value != 0x12345678 {
+		t.Fatalf("Expected 0x12345678 at .ltorg 0x%08X, pool is %v", poolLoc, program.LiteralPool)
+	}
+	if word, err := machine.Memory.ReadWord(poolLoc); err != nil || word != 0x12345678 {
+		t.Errorf("Expected literal in memory at 0x%08X, got 0x%08X (err %v)", poolLoc, word, err)
+	}
+
+	// The literal after the .ltorg goes into a fresh pool after the code
+	for addr, value := range program.LiteralPool {
+		if value == 0xCAFEBABE && addr <= poolLoc {
+			t.Errorf("Literal after .ltorg placed at 0x%08X, expected after 0x%08X", addr, poolLoc)
+		}
+	}
+	if len(program.LiteralPool) != 2 {
+		t.Errorf("Expected 2 literals, got %d: %v", len(program.LiteralPool), program.LiteralPool)
+	}
+}
+
+func TestLtorgDirective_SeparatePoolsForRepeatedValue(t *testing.T) {
+	source := `
+.org 0x8000
+
+main:
+    LDR R0, =0xDEADBEEF
+    B   second
+    .ltorg
+
+second:
+    LDR R1, =0xDEADBEEF
+    B   done
+    .ltorg
+
+done:
+    SWI #0x00
+`
+
+	machine, program := loadLtorgProgram(t, source)
+	if len(program.LiteralPoolLocs) != 2 {
+		t.Fatalf("Expected 2 pools, got %d", len(program.LiteralPoolLocs))
+	}
+
+	// The same value is emitted once per pool rather than shared across the .ltorg
+	for i, poolLoc := range program.LiteralPoolLocs {
+		if program.LiteralPool[poolLoc] != 0xDEADBEEF {
+			t.Errorf("Pool %d at 0x%08X: expected 0xDEADBEEF, got 0x%08X", i, poolLoc, program.LiteralPool[poolLoc])
+		}
+	}
+
+	// Both loads see the value when run
+	for i := 0; i < 4; i++ {
+		if err := machine.Step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+	if machine.CPU.R[0] != 0xDEADBEEF || machine.CPU.R[1] != 0xDEADBEEF {
+		t.Errorf("Expected R0=R1=0xDEADBEEF, got R0=0x%08X R1=0x%08X", machine.CPU.R[0], machine.CPU.R[1])
+	}
+}
@@ -0,0 +1,76 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputFileFlag tests that --output-file captures console output and leaves stdout clean
+func TestOutputFileFlag(t *testing.T) {
+	code := `.org 0x8000
+start:
+    LDR R0, =msg
+    SWI #0x02
+    MOV R0, #'!'
+    SWI #0x01
+    MOV R0, #0
+    SWI #0x00
+msg:
+    .asciz "Hello"
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	outputPath := filepath.Join(t.TempDir(), "out.txt")
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "--output-file", outputPath)
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+	if strings.Contains(stdout, "Hello") {
+		t.Errorf("Expected program output to be kept off stdout, got %q", stdout)
+	}
+
+	data, err := os.ReadFile(outputPath) // #nosec G304 -- test temp file
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(data) != "Hello!" {
+		t.Errorf("Expected output file to contain %q, got %q", "Hello!", data)
+	}
+}
+
+// TestOutputFileFlag_RuntimeError tests that a fault is still reported on stderr and output
+// written before it is kept
+func TestOutputFileFlag_RuntimeError(t *testing.T) {
+	code := `.org 0x8000
+start:
+    LDR R0, =msg
+    SWI #0x02
+    LDR R1, =0xFFFFFFF0
+    LDR R0, [R1]
+    SWI #0x00
+msg:
+    .asciz "Hello"
+`
+
+	progPath := createTestProgram(t, code)
+	defer os.Remove(progPath)
+
+	outputPath := filepath.Join(t.TempDir(), "out.txt")
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "--output-file", outputPath)
+
+	if exitCode == 0 || !strings.Contains(stderr, "Runtime error") {
+		t.Errorf("Expected a runtime error on stderr, got exit code %d and %q", exitCode, stderr)
+	}
+	data, err := os.ReadFile(outputPath) // #nosec G304 -- test temp file
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(data) != "Hello" {
+		t.Errorf("Expected output file to contain %q, got %q", "Hello", data)
+	}
+}
@@ -0,0 +1,60 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+)
+
+// TestPSRTransfer_SetCarry reads the CPSR, sets the C bit and writes the flags back
+func TestPSRTransfer_SetCarry(t *testing.T) {
+	source := `
+_start:
+	MOVS R0, #0             ; Z set, C clear
+	MRS R1, CPSR
+	ORR R1, R1, #0x20000000 ; C
+	MSR CPSR_f, R1
+	MOV R2, #0
+	ADC R2, R2, #0          ; R2 = carry
+	MOV R0, #0
+	SWI #0x00
+`
+	result := runSource(t, source, loader.RunOptions{})
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Registers[2] != 1 {
+		t.Errorf("expected the carry to be set after MSR, got R2=%d", result.Registers[2])
+	}
+	if result.Registers[1]&0x40000000 == 0 {
+		t.Errorf("expected MRS to read the Z flag, got R1=0x%08X", result.Registers[1])
+	}
+}
+
+// TestPSRTransfer_ControlFieldLeavesFlags tests that an MSR without the f field does not
+// change the condition flags
+func TestPSRTransfer_ControlFieldLeavesFlags(t *testing.T) {
+	source := `
+_start:
+	MOVS R0, #0             ; Z set
+	MOV R1, #0xF0000000
+	MSR CPSR_c, R1
+	MOVEQ R2, #1
+	MOVNE R2, #2
+	MSR CPSR_f, #0x80000000 ; N only
+	MOVEQ R3, #1
+	MOVMI R3, #2
+	MOV R0, #0
+	SWI #0x00
+`
+	result := runSource(t, source, loader.RunOptions{})
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Registers[2] != 1 {
+		t.Errorf("expected MSR CPSR_c to leave Z set, got R2=%d", result.Registers[2])
+	}
+	if result.Registers[3] != 2 {
+		t.Errorf("expected MSR CPSR_f, #imm to set N and clear Z, got R3=%d", result.Registers[3])
+	}
+}
@@ -0,0 +1,48 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReplayInput_ReproducesSession tests that input saved with -record-input replays
+// with -replay-input to give the same output, without anything on stdin
+func TestReplayInput_ReproducesSession(t *testing.T) {
+	path := createTestProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x06        ; READ_INT
+	MOV R4, R0
+	SWI #0x06        ; READ_INT
+	ADD R0, R4, R0
+	SWI #0x03        ; WRITE_INT
+	SWI #0x07        ; WRITE_NEWLINE
+	MOV R0, #0
+	SWI #0x00
+`)
+	recording := filepath.Join(t.TempDir(), "input.rec")
+	const input = "40\n2\n"
+
+	recorded, stderr, exitCode := runEmulatorInput(t, input, "-record-input", recording, path)
+	if exitCode != 0 || recorded != "42\n" {
+		t.Fatalf("recording run: expected output %q and exit 0, got %q exit %d (stderr %q)", "42\n", recorded, exitCode, stderr)
+	}
+	saved, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatalf("failed to read the recording: %v", err)
+	}
+	if string(saved) != input {
+		t.Errorf("expected the recording to hold %q, got %q", input, saved)
+	}
+
+	replayed, stderr, exitCode := runEmulatorInput(t, "", "-replay-input", recording, path)
+	if exitCode != 0 || replayed != recorded {
+		t.Errorf("replay run: expected output %q and exit 0, got %q exit %d (stderr %q)", recorded, replayed, exitCode, stderr)
+	}
+
+	_, _, exitCode = runEmulatorInput(t, "", "-record-input", recording, "-replay-input", recording, path)
+	if exitCode == 0 {
+		t.Error("expected -record-input with -replay-input to be rejected")
+	}
+}
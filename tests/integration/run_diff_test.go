@@ -0,0 +1,134 @@
+package integration_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+const diffProgram = `
+	.org 0x8000
+_start:
+	SWI #0x06         ; READ_INT
+	MOV R4, R0
+	ADD R2, R4, #1
+	SWI #0x03         ; WRITE_INT
+	SWI #0x07
+	MOV R0, #0
+	SWI #0x00
+`
+
+// runSource parses and runs source headlessly, failing the test on setup errors
+func runSource(t *testing.T, source string, opts loader.RunOptions) *loader.RunResult {
+	t.Helper()
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	result, err := loader.RunProgram(program, opts)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	return result
+}
+
+func TestRunDiff_SingleDifferingRegister(t *testing.T) {
+	opts := loader.RunOptions{Input: "41\n"}
+	a := runSource(t, diffProgram, opts)
+	b := runSource(t, strings.Replace(diffProgram, "ADD R2, R4, #1", "ADD R2, R4, #2", 1), opts)
+
+	if a.Output != "41\n" || a.Err != nil {
+		t.Fatalf("unexpected first run: output %q err %v", a.Output, a.Err)
+	}
+
+	diffs := a.Diff(b)
+	if len(diffs) != 1 {
+		t.Fatalf("expected exactly one difference, got %v", diffs)
+	}
+	if got := diffs[0].String(); got != "R2: 0x0000002A != 0x0000002B" {
+		t.Errorf("unexpected difference %q", got)
+	}
+}
+
+func TestRunDiff_IdenticalRunsAndOutputDifference(t *testing.T) {
+	opts := loader.RunOptions{Input: "7\n"}
+	a := runSource(t, diffProgram, opts)
+	if diffs := a.Diff(runSource(t, diffProgram, opts)); len(diffs) != 0 {
+		t.Errorf("expected identical runs, got %v", diffs)
+	}
+
+	// Overwriting R0 before WRITE_INT changes the output; the extra instruction also moves
+	// the exit PC and adds to the instruction count
+	b := runSource(t, strings.Replace(diffProgram, "MOV R4, R0", "MOV R4, R0\n\tMOV R0, #8", 1), opts)
+	diffs := a.Diff(b)
+	if len(diffs) == 0 || diffs[0].Field != "output" || diffs[0].B != `line 1 "8"` {
+		t.Fatalf("expected the output difference first, got %v", diffs)
+	}
+	fields := make([]string, len(diffs))
+	for i, d := range diffs {
+		fields[i] = d.Field
+	}
+	if got := strings.Join(fields, ","); got != "output,PC,instructions" {
+		t.Errorf("expected output, PC and instruction count to differ, got %s", got)
+	}
+}
+
+// relocatedStackProgram moves SP to STACK for a push and pop, then restores it
+const relocatedStackProgram = `
+	.org 0x8000
+_start:
+	MOV R5, SP
+	LDR SP, =STACK
+	PUSH {R5}
+	POP {R5}
+	MOV SP, R5
+	MOV R0, #0
+	SWI #0x00
+`
+
+// TestDiffFlag_HonoursStackGuard tests that -diff runs use -stack-guard like a direct run
+func TestDiffFlag_HonoursStackGuard(t *testing.T) {
+	// Both programs finish with the same state; only the first leaves the stack segment
+	progA := createTestProgram(t, strings.Replace(relocatedStackProgram, "STACK", "0x00021000", 1))
+	defer os.Remove(progA)
+	progB := createTestProgram(t, strings.Replace(relocatedStackProgram, "STACK", "0x00041000", 1))
+	defer os.Remove(progB)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progA, "-diff", progB)
+	if exitCode != 1 || !strings.Contains(stdout, "error:") {
+		t.Errorf("expected the guard to fault the first run, got exit code %d\nStdout: %s\nStderr: %s", exitCode, stdout, stderr)
+	}
+
+	stdout, stderr, exitCode = runEmulatorWithFlags(t, progA, "-stack-guard=false", "-diff", progB)
+	if exitCode != 0 || !strings.Contains(stdout, "identical") {
+		t.Errorf("expected identical runs with -stack-guard=false, got exit code %d\nStdout: %s\nStderr: %s", exitCode, stdout, stderr)
+	}
+}
+
+// TestDiffFlag_HonoursUninit tests that -diff runs use -uninit like a direct run
+func TestDiffFlag_HonoursUninit(t *testing.T) {
+	// Both programs set R0 to 0; only the first reads a register nothing wrote
+	source := `
+	.org 0x8000
+_start:
+	MOV R0, R7
+	SWI #0x00
+`
+	progA := createTestProgram(t, source)
+	defer os.Remove(progA)
+	progB := createTestProgram(t, strings.Replace(source, "MOV R0, R7", "MOV R0, #0", 1))
+	defer os.Remove(progB)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progA, "-diff", progB)
+	if exitCode != 0 || !strings.Contains(stdout, "identical") {
+		t.Errorf("expected identical runs without -uninit, got exit code %d\nStdout: %s\nStderr: %s", exitCode, stdout, stderr)
+	}
+
+	stdout, stderr, exitCode = runEmulatorWithFlags(t, progA, "-uninit", "halt", "-diff", progB)
+	if exitCode != 1 || !strings.Contains(stdout, "uninitialized read of R7") {
+		t.Errorf("expected -uninit halt to stop the first run, got exit code %d\nStdout: %s\nStderr: %s", exitCode, stdout, stderr)
+	}
+}
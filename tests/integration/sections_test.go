@@ -0,0 +1,75 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// sectionsProgram declares its data before and after the code; the exit code is the sum
+// of the .data words plus the (zeroed) .bss words
+const sectionsProgram = `
+.bss
+scratch:    .space 8
+.data
+values:     .word 10, 20
+.text
+_start:
+	LDR R1, =values
+	LDR R2, [R1]
+	LDR R3, [R1, #4]
+	ADD R0, R2, R3
+	LDR R1, =scratch
+	LDR R2, [R1]
+	LDR R3, [R1, #4]
+	ADD R0, R0, R2
+	ADD R0, R0, R3
+	LDR R1, =extra
+	LDRB R2, [R1]
+	ADD R0, R0, R2
+	SWI #0x00
+.data
+extra:      .byte 12
+`
+
+func TestSections_RunMixedOrder(t *testing.T) {
+	_, _, exitCode, err := runAssembly(t, sectionsProgram)
+	if err != nil {
+		t.Fatalf("Failed to run program: %v", err)
+	}
+	if exitCode != 42 {
+		t.Errorf("Expected exit code 42, got %d", exitCode)
+	}
+}
+
+func TestSections_LoaderClearsBSS(t *testing.T) {
+	program, err := parser.NewParser(sectionsProgram, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	machine := vm.NewVM()
+
+	// Leave stale values where .bss will be placed, as an earlier run would
+	for i := uint32(0); i < program.BSSSize; i += 4 {
+		if err := machine.Memory.WriteWordUnsafe(program.BSSStart+i, 0xDEADBEEF); err != nil {
+			t.Fatalf("setup write failed: %v", err)
+		}
+	}
+	if err := loader.LoadProgramIntoVM(machine, program, 0); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if program.BSSStart != parser.DataSectionStart+12 || program.BSSSize != 8 {
+		t.Fatalf("unexpected .bss placement 0x%X+%d", program.BSSStart, program.BSSSize)
+	}
+	for i := uint32(0); i < program.BSSSize; i += 4 {
+		if got, _ := machine.Memory.ReadWord(program.BSSStart + i); got != 0 {
+			t.Errorf(".bss word at 0x%X not cleared: 0x%X", program.BSSStart+i, got)
+		}
+	}
+	if got, _ := machine.Memory.ReadWord(parser.DataSectionStart + 4); got != 20 {
+		t.Errorf("expected values[1] = 20 in the data segment, got %d", got)
+	}
+}
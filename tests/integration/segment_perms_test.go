@@ -0,0 +1,59 @@
+package integration_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+const selfModifyingProgram = `.org 0x8000
+_start:
+	ADR R1, target
+	LDR R0, =0xE3A00007     ; MOV R0, #7
+	STR R0, [R1]
+target:
+	MOV R0, #0
+	SWI #0x00
+`
+
+// TestSegmentPermissions_ReadOnlyCode tests that a store into code made read-only faults
+// with the store's PC and target address, while the default permissions allow it
+func TestSegmentPermissions_ReadOnlyCode(t *testing.T) {
+	result := runSource(t, selfModifyingProgram, loader.RunOptions{})
+	if result.Err != nil || result.ExitCode != 7 {
+		t.Fatalf("expected the patched instruction to exit with 7, got %d (err %v)", result.ExitCode, result.Err)
+	}
+
+	result = runSource(t, selfModifyingProgram, loader.RunOptions{
+		SegmentPermissions: map[string]vm.MemoryPermission{"code": vm.PermRead | vm.PermExecute},
+	})
+	fault, ok := vm.AsRuntimeError(result.Err)
+	if !ok {
+		t.Fatalf("expected a runtime fault, got %v", result.Err)
+	}
+	if fault.FaultPC() != 0x8008 || fault.FaultAddress() != 0x800C {
+		t.Errorf("expected a fault at PC=0x8008 for 0x800C, got PC=0x%08X address=0x%08X", fault.FaultPC(), fault.FaultAddress())
+	}
+}
+
+// TestSegmentPermsFlag tests the --segment-perms flag
+func TestSegmentPermsFlag(t *testing.T) {
+	progPath := createTestProgram(t, selfModifyingProgram)
+	defer os.Remove(progPath)
+
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "--segment-perms", "code=r-x")
+	if exitCode != 139 {
+		t.Errorf("expected exit code 139, got %d\nStderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "write permission denied for segment 'code' at 0x0000800C") {
+		t.Errorf("expected a write permission fault, got: %s", stderr)
+	}
+
+	_, stderr, exitCode = runEmulatorWithFlags(t, progPath, "--segment-perms", "rom=r")
+	if exitCode != 1 || !strings.Contains(stderr, `unknown memory segment "rom"`) {
+		t.Errorf("expected an unknown segment error, got exit %d: %s", exitCode, stderr)
+	}
+}
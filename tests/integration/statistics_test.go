@@ -0,0 +1,119 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runWithStatistics assembles and runs source with performance statistics enabled
+func runWithStatistics(t *testing.T, source string) *vm.PerformanceStatistics {
+	t.Helper()
+	program, err := parser.NewParser(source, "stats.s").Parse()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	machine.Statistics = vm.NewPerformanceStatistics()
+	machine.Statistics.Start()
+	if err := machine.Run(); err != nil && machine.State != vm.StateHalted {
+		t.Fatalf("Run error: %v", err)
+	}
+	return machine.Statistics
+}
+
+// TestStatistics_InstructionHistogram tests that executed instructions are tallied by
+// mnemonic and loads/stores by addressing mode
+func TestStatistics_InstructionHistogram(t *testing.T) {
+	stats := runWithStatistics(t, `
+		.org 0x8000
+_start:
+		MOV R0, #1
+		MOV R1, #2
+		ADD R2, R0, R1
+		ADD R2, R2, R2
+		CMP R0, #1
+		MOVEQ R3, #3        ; executed, counted as MOV
+		MOVNE R4, #4        ; skipped, not counted
+		LDR R5, =value      ; [PC, #imm]
+		STR R2, [R5]
+		LDR R6, [R5]
+		LDR R6, [R5, #0]
+		STR R2, [R5, #4]!
+		LDR R7, [R5], #-4
+		LDRB R8, [R5, R0, LSL #2]
+		MOV R0, #0
+		SWI #0x00
+value:	.word 0, 0
+`)
+
+	wantCounts := map[string]uint64{"MOV": 4, "ADD": 2, "CMP": 1, "LDR": 4, "STR": 2, "LDRB": 1, "SWI": 1}
+	for mnemonic, want := range wantCounts {
+		if got := stats.InstructionCounts[mnemonic]; got != want {
+			t.Errorf("%s: expected %d executions, got %d", mnemonic, want, got)
+		}
+	}
+	if len(stats.InstructionCounts) != len(wantCounts) {
+		t.Errorf("unexpected mnemonics in histogram: %v", stats.InstructionCounts)
+	}
+	if stats.TotalInstructions != 15 {
+		t.Errorf("expected 15 executed instructions, got %d", stats.TotalInstructions)
+	}
+
+	wantModes := map[string]map[string]uint64{
+		"LDR":  {"[PC, #imm]": 1, "[Rn]": 2, "[Rn], #imm": 1}, // [R5, #0] is encoded as [R5]
+		"STR":  {"[Rn]": 1, "[Rn, #imm]!": 1},
+		"LDRB": {"[Rn, Rm, shift]": 1},
+	}
+	for mnemonic, modes := range wantModes {
+		for mode, want := range modes {
+			if got := stats.AddressingModeCounts[mnemonic][mode]; got != want {
+				t.Errorf("%s %s: expected %d, got %d", mnemonic, mode, want, got)
+			}
+		}
+	}
+
+	var jsonOut bytes.Buffer
+	if err := stats.ExportJSON(&jsonOut); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var data struct {
+		InstructionCounts map[string]uint64            `json:"instruction_counts"`
+		AddressingModes   map[string]map[string]uint64 `json:"addressing_modes"`
+	}
+	if err := json.Unmarshal(jsonOut.Bytes(), &data); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if data.InstructionCounts["MOV"] != 4 || data.InstructionCounts["ADD"] != 2 {
+		t.Errorf("expected 4 MOV and 2 ADD in JSON, got %v", data.InstructionCounts)
+	}
+	if data.AddressingModes["STR"]["[Rn, #imm]!"] != 1 {
+		t.Errorf("expected the STR pre-indexed store in JSON, got %v", data.AddressingModes)
+	}
+
+	var csvOut bytes.Buffer
+	if err := stats.ExportCSV(&csvOut); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	for _, want := range []string{"MOV,4", "ADD,2", "Instruction,Addressing Mode,Count", "LDR,[Rn],2", `LDRB,"[Rn, Rm, shift]",1`} {
+		if !strings.Contains(csvOut.String(), want) {
+			t.Errorf("CSV missing %q:\n%s", want, csvOut.String())
+		}
+	}
+
+	var htmlOut bytes.Buffer
+	if err := stats.ExportHTML(&htmlOut); err != nil {
+		t.Fatalf("ExportHTML failed: %v", err)
+	}
+	if !strings.Contains(htmlOut.String(), "Load/Store Addressing Modes") || !strings.Contains(htmlOut.String(), "[Rn], #imm") {
+		t.Errorf("HTML missing the addressing mode table:\n%s", htmlOut.String())
+	}
+}
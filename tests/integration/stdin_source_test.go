@@ -0,0 +1,72 @@
+package integration_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+const stdinProgram = `
+_start:
+	MOV R0, #42
+	SWI #0x03       ; WRITE_INT
+	SWI #0x07       ; WRITE_NEWLINE
+	MOV R0, #0
+	SWI #0x00
+`
+
+// TestParseReader_Runs tests that a program supplied through an io.Reader assembles and runs
+func TestParseReader_Runs(t *testing.T) {
+	program, _, err := parser.ParseReader(strings.NewReader(stdinProgram), "<stdin>", parser.DefaultParseFileOptions())
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	result, err := loader.RunProgram(program, loader.RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Err != nil || result.Output != "42\n" {
+		t.Errorf("expected output %q, got %q (err %v)", "42\n", result.Output, result.Err)
+	}
+}
+
+// runEmulatorInput runs the emulator with args, feeding input to its stdin
+func runEmulatorInput(t *testing.T, input string, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+	cmd := exec.Command(filepath.Join("..", "..", "arm-emulator"), args...)
+	cmd.Stdin = strings.NewReader(input)
+	var outBuf, errBuf strings.Builder
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatalf("Failed to run emulator: %v", err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return outBuf.String(), errBuf.String(), exitCode
+}
+
+// TestStdinSource_CLI tests that "-" assembles and runs the program from stdin, and that
+// parse errors name <stdin>
+func TestStdinSource_CLI(t *testing.T) {
+	stdout, stderr, exitCode := runEmulatorInput(t, stdinProgram, "-")
+	if exitCode != 0 || stdout != "42\n" {
+		t.Errorf("expected output %q and exit 0, got %q exit %d (stderr %q)", "42\n", stdout, exitCode, stderr)
+	}
+
+	_, stderr, exitCode = runEmulatorInput(t, "_start:\n_start:\n", "-")
+	if exitCode == 0 || !strings.Contains(stderr, "<stdin>:2") {
+		t.Errorf("expected a parse error naming <stdin>:2, got exit %d stderr %q", exitCode, stderr)
+	}
+
+	_, stderr, exitCode = runEmulatorInput(t, stdinProgram, "-debug", "-")
+	if exitCode == 0 || !strings.Contains(stderr, "CLI debugger") {
+		t.Errorf("expected -debug with stdin to be rejected, got exit %d stderr %q", exitCode, stderr)
+	}
+}
@@ -0,0 +1,92 @@
+package integration_test
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"testing"
+)
+
+const symbolsFormatProgram = `
+        .org 0x8000
+        .equ BUFSIZE, 64
+main:
+        MOV R0, #0
+        BL helper
+        SWI #0x00
+helper:
+        MOV PC, LR
+data:   .word 1, 2
+`
+
+func dumpSymbols(t *testing.T, format string) string {
+	t.Helper()
+	progPath := createTestProgram(t, symbolsFormatProgram)
+	defer os.Remove(progPath)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-dump-symbols", "-symbols-format", format)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+	return stdout
+}
+
+func TestSymbolsFormat_Text(t *testing.T) {
+	stdout := dumpSymbols(t, "text")
+	if !strings.Contains(stdout, "Symbol Table") || !strings.Contains(stdout, "Total symbols: 4") {
+		t.Errorf("Unexpected text dump:\n%s", stdout)
+	}
+}
+
+func TestSymbolsFormat_CSV(t *testing.T) {
+	stdout := dumpSymbols(t, "csv")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v\n%s", err, stdout)
+	}
+
+	want := [][]string{
+		{"name", "type", "address", "status"},
+		{"BUFSIZE", "Constant", "0x00000040", "Defined"},
+		{"main", "Label", "0x00008000", "Defined"},
+		{"helper", "Label", "0x0000800C", "Defined"},
+		{"data", "Label", "0x00008010", "Defined"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d rows including header, got %d:\n%s", len(want), len(records), stdout)
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d: expected %v, got %v", i, want[i], records[i])
+		}
+	}
+}
+
+func TestSymbolsFormat_Markdown(t *testing.T) {
+	stdout := dumpSymbols(t, "markdown")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected header, separator and 4 rows, got %d lines:\n%s", len(lines), stdout)
+	}
+	if lines[0] != "| Name | Type | Address | Status |" || lines[1] != "|------|------|---------|--------|" {
+		t.Errorf("Unexpected table header:\n%s", stdout)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "|") || !strings.HasSuffix(line, "|") || strings.Count(line, "|") != 5 {
+			t.Errorf("Malformed table row %q", line)
+		}
+	}
+	if lines[3] != "| `main` | Label | `0x00008000` | Defined |" {
+		t.Errorf("Unexpected row for main: %q", lines[3])
+	}
+}
+
+func TestSymbolsFormat_Unknown(t *testing.T) {
+	progPath := createTestProgram(t, symbolsFormatProgram)
+	defer os.Remove(progPath)
+
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-dump-symbols", "-symbols-format", "xml")
+	if exitCode == 0 || !strings.Contains(stderr, "unknown symbols format") {
+		t.Errorf("Expected unknown format error, got exit %d, stderr: %s", exitCode, stderr)
+	}
+}
@@ -0,0 +1,151 @@
+package integration_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestSyscallLimits_BytesWritten(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	LDR R0, =hello
+	SWI #0x02         ; 5 bytes, within the budget
+	MOV R4, R0
+	LDR R0, =world
+	SWI #0x02         ; Would reach 10 bytes of 7: refused
+	MOV R5, R0
+	MOV R0, #1
+	LDR R1, =world
+	MOV R2, #3
+	SWI #0x13         ; File writes are charged too; 8 bytes is refused
+	MOV R6, R0
+	MOV R0, #'!'
+	SWI #0x01         ; 6 bytes, still fits
+	MOV R0, #0
+	SWI #0x00
+hello:
+	.asciz "Hello"
+world:
+	.asciz "World"
+`
+	result := runSource(t, source, loader.RunOptions{SyscallLimits: vm.SyscallLimits{MaxBytesWritten: 7}})
+	if result.Err != nil {
+		t.Fatalf("unexpected runtime error: %v", result.Err)
+	}
+	if result.Output != "Hello!" {
+		t.Errorf("expected output %q, got %q", "Hello!", result.Output)
+	}
+	if result.Registers[5] != vm.SyscallErrorGeneral || result.Registers[6] != vm.SyscallErrorGeneral {
+		t.Errorf("expected refused writes to return 0x%08X, got R5=0x%08X R6=0x%08X",
+			uint32(vm.SyscallErrorGeneral), result.Registers[5], result.Registers[6])
+	}
+	if result.Registers[4] == vm.SyscallErrorGeneral {
+		t.Error("expected the first write to succeed")
+	}
+}
+
+func TestSyscallLimits_FileOpens(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	LDR R0, =name
+	MOV R1, #1
+	SWI #0x10         ; First open succeeds
+	MOV R4, R0
+	SWI #0x11
+	LDR R0, =name
+	MOV R1, #0
+	SWI #0x10         ; Second open is refused
+	MOV R5, R0
+	MOV R0, #0
+	SWI #0x00
+name:
+	.asciz "limits.txt"
+`
+	result := runSource(t, source, loader.RunOptions{
+		FilesystemRoot: t.TempDir(),
+		SyscallLimits:  vm.SyscallLimits{MaxFileOpens: 1},
+	})
+	if result.Err != nil {
+		t.Fatalf("unexpected runtime error: %v", result.Err)
+	}
+	if result.Registers[4] == vm.SyscallErrorGeneral {
+		t.Error("expected the first open to succeed")
+	}
+	if result.Registers[5] != vm.SyscallErrorGeneral {
+		t.Errorf("expected the second open to be refused, got R5=0x%08X", result.Registers[5])
+	}
+}
+
+func TestSyscallLimits_MaxCalls(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	MOV R0, #'.'
+loop:
+	SWI #0x01
+	B loop
+`
+	result := runSource(t, source, loader.RunOptions{SyscallLimits: vm.SyscallLimits{MaxCalls: 3}})
+	if !errors.Is(result.Err, vm.ErrSyscallLimit) {
+		t.Fatalf("expected a syscall limit error, got %v", result.Err)
+	}
+	if result.Output != "..." {
+		t.Errorf("expected 3 writes before the limit, got %q", result.Output)
+	}
+
+	// Exit is always allowed, even with the budget spent
+	exits := runSource(t, ".org 0x8000\n_start:\n\tSWI #0x07\n\tMOV R0, #3\n\tSWI #0x00\n",
+		loader.RunOptions{SyscallLimits: vm.SyscallLimits{MaxCalls: 1}})
+	if exits.Err != nil || exits.ExitCode != 3 {
+		t.Errorf("expected a clean exit with code 3, got %d and %v", exits.ExitCode, exits.Err)
+	}
+}
+
+func TestSyscallLimits_RegionSWIs(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	LDR R0, =data
+	MOV R1, #8
+	LDR R2, =name
+	SWI #0x17         ; Dump 8 bytes: counts as an open and as 8 bytes written
+	MOV R4, R0
+	LDR R0, =data
+	MOV R1, #8
+	LDR R2, =name
+	SWI #0x17         ; Would reach 16 bytes of 12: refused
+	MOV R5, R0
+	LDR R0, =data
+	MOV R1, #8
+	LDR R2, =name
+	SWI #0x18         ; Load is the third open of 2: refused
+	MOV R6, R0
+	MOV R0, #0
+	SWI #0x00
+data:
+	.word 0x11223344, 0x55667788
+name:
+	.asciz "region.bin"
+`
+	result := runSource(t, source, loader.RunOptions{
+		FilesystemRoot: t.TempDir(),
+		SyscallLimits:  vm.SyscallLimits{MaxFileOpens: 2, MaxBytesWritten: 12},
+	})
+	if result.Err != nil {
+		t.Fatalf("unexpected runtime error: %v", result.Err)
+	}
+	if result.Registers[4] != 8 {
+		t.Errorf("expected the first dump to write 8 bytes, got R4=0x%08X", result.Registers[4])
+	}
+	if result.Registers[5] != vm.SyscallErrorGeneral {
+		t.Errorf("expected the second dump to be refused by the write limit, got R5=0x%08X", result.Registers[5])
+	}
+	if result.Registers[6] != vm.SyscallErrorGeneral {
+		t.Errorf("expected the load to be refused by the open limit, got R6=0x%08X", result.Registers[6])
+	}
+}
@@ -718,3 +718,41 @@ msg:
 		t.Errorf("expected assertion failure message, got %v", err)
 	}
 }
+
+// Test SET_TIMER (0x50) and IRQ_RETURN (0x51): the handler runs and the loop resumes
+func TestSyscall_TimerInterrupt(t *testing.T) {
+	code := `
+		.org 0x8000
+_start:
+		MOV R4, #0        ; ticks, counted by the handler
+		MOV R5, #0        ; loop iterations
+		MOV R0, #40       ; period in cycles
+		LDR R1, =tick
+		SWI #0x50         ; SET_TIMER
+loop:
+		ADD R5, R5, #1
+		CMP R4, #3
+		BLT loop
+		MOV R0, #0
+		SWI #0x50         ; stop the timer
+		MOV R0, R5
+		SWI #0x03         ; WRITE_INT iterations
+		SWI #0x07
+		MOV R0, R4
+		SWI #0x00         ; exit with the tick count
+tick:
+		ADD R4, R4, #1
+		CMP R4, #100      ; clobber the flags; IRQ_RETURN restores them
+		SWI #0x51         ; IRQ_RETURN
+`
+	stdout, _, exitCode, err := runAssembly(t, code)
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if exitCode != 3 {
+		t.Errorf("expected three interrupts, got exit code %d", exitCode)
+	}
+	if stdout == "" || stdout == "0\n" {
+		t.Errorf("expected the loop to keep running between interrupts, got %q", stdout)
+	}
+}
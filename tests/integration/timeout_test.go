@@ -0,0 +1,46 @@
+package integration_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+const infiniteLoop = `
+        .org 0x8000
+_start:
+        B _start
+`
+
+// TestRunTimeout tests that RunOptions.Timeout stops an infinite loop with a TimeoutError
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	result := runSource(t, infiniteLoop, loader.RunOptions{MaxCycles: math.MaxUint64, Timeout: 50 * time.Millisecond})
+	var timeoutErr *vm.TimeoutError
+	if !errors.As(result.Err, &timeoutErr) {
+		t.Fatalf("expected a *vm.TimeoutError, got %v", result.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("run took %v to time out", elapsed)
+	}
+}
+
+// TestTimeoutFlag tests that -timeout ends a run that no cycle limit would stop
+func TestTimeoutFlag(t *testing.T) {
+	progPath := createTestProgram(t, infiniteLoop)
+	defer os.Remove(progPath)
+
+	_, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-max-cycles", "0", "-timeout", "100ms")
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "wall-clock timeout exceeded") {
+		t.Errorf("Expected a timeout error, got stderr: %s", stderr)
+	}
+}
@@ -0,0 +1,50 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const traceReplayProgram = `
+        .org 0x8000
+main:
+        MOV R0, #0
+        MOV R1, #5
+loop:
+        ADD R0, R0, R1
+        SUBS R1, R1, #1
+        BNE loop
+        SWI #0x00
+`
+
+func TestReplayTraceFlag(t *testing.T) {
+	progPath := createTestProgram(t, traceReplayProgram)
+	defer os.Remove(progPath)
+
+	tracePath := filepath.Join(t.TempDir(), "trace.log")
+	if _, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-trace", "-trace-file", tracePath); exitCode != 15 {
+		t.Fatalf("Expected exit code 15 from the recording run, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-replay-trace", tracePath)
+	if exitCode != 0 {
+		t.Fatalf("Expected replay to match, got exit %d\nStderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Replay matched all 16 trace entries") {
+		t.Errorf("Unexpected replay output: %s", stdout)
+	}
+
+	// Replaying against a changed program reports where it first diverges
+	changedPath := createTestProgram(t, strings.Replace(traceReplayProgram, "MOV R1, #5", "MOV R1, #4", 1))
+	defer os.Remove(changedPath)
+
+	_, stderr, exitCode = runEmulatorWithFlags(t, changedPath, "-replay-trace", tracePath)
+	if exitCode != 1 {
+		t.Fatalf("Expected divergence exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "Replay diverged at entry 2 (sequence 2) at 0x00008004: R1: expected 0x00000005, got 0x00000004") {
+		t.Errorf("Unexpected divergence report: %s", stderr)
+	}
+}
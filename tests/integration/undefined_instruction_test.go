@@ -0,0 +1,27 @@
+package integration_test
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestUndefinedInstruction_DirectMode tests that running into an undefined instruction
+// prints its opcode and exits with the SIGILL-style code
+func TestUndefinedInstruction_DirectMode(t *testing.T) {
+	path := createTestProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	.word 0xE7F000F0
+	SWI #0x00
+`)
+	_, stderr, exitCode := runEmulatorWithFlags(t, path)
+	if exitCode != 132 {
+		t.Errorf("expected exit code 132, got %d (stderr %q)", exitCode, stderr)
+	}
+	for _, want := range []string{"undefined-instruction", "PC=0x00008004", "0xE7F000F0"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in stderr, got %q", want, stderr)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestXRefFlag tests that -xref lists the definition and both uses of a label
+func TestXRefFlag(t *testing.T) {
+	progPath := createTestProgram(t, `
+        .org 0x8000
+main:
+        MOV R0, #3
+loop:
+        SUBS R0, R0, #1
+        BNE loop
+        LDR R1, =loop
+        SWI #0x00
+`)
+	defer os.Remove(progPath)
+
+	stdout, stderr, exitCode := runEmulatorWithFlags(t, progPath, "-xref")
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nStderr: %s", exitCode, stderr)
+	}
+
+	name := regexp.QuoteMeta(filepath.Base(progPath))
+	loopRow := regexp.MustCompile(`(?m)^loop\s+` + name + `:5\s+` + name + `:7, ` + name + `:8$`)
+	if !loopRow.MatchString(stdout) {
+		t.Errorf("Expected loop defined on line 5 and used on lines 7 and 8:\n%s", stdout)
+	}
+	if !regexp.MustCompile(`(?m)^main\s+` + name + `:3\s+\(unused\)$`).MatchString(stdout) {
+		t.Errorf("Expected main to be listed as unused:\n%s", stdout)
+	}
+}
+
+// TestXRefFlag_Undefined tests that -xref flags undefined symbols and fails
+func TestXRefFlag_Undefined(t *testing.T) {
+	progPath := createTestProgram(t, `
+        .org 0x8000
+main:
+        BL missing
+        SWI #0x00
+`)
+	defer os.Remove(progPath)
+
+	stdout, _, exitCode := runEmulatorWithFlags(t, progPath, "-xref")
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an undefined symbol, got %d", exitCode)
+	}
+	if !regexp.MustCompile(`(?m)^missing\s+UNDEFINED\s+\S+:4$`).MatchString(stdout) {
+		t.Errorf("Expected missing to be flagged as undefined:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "undefined: 1") {
+		t.Errorf("Expected the summary to count one undefined symbol:\n%s", stdout)
+	}
+}
@@ -2,14 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lookbusy1344/arm-emulator/api"
+	"github.com/lookbusy1344/arm-emulator/vm"
 )
 
 // testServer creates a test server for testing
@@ -239,6 +243,128 @@ func TestStepExecution(t *testing.T) {
 	}
 }
 
+// TestStepRuntimeFault tests a program fault is reported as 422 with its category
+func TestStepRuntimeFault(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, `
+	.org 0x8000
+	LDR R1, =0x20001
+	LDR R0, [R1]
+	SWI #0
+	`)
+
+	postRegisters(t, server, sessionID, "step")
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/step", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var response api.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Fault == nil {
+		t.Fatalf("Expected fault details, got %+v", response)
+	}
+	if response.Fault.Category != "alignment" || response.Fault.PC != 0x8004 || response.Fault.Address != 0x20001 {
+		t.Errorf("Unexpected fault details: %+v", *response.Fault)
+	}
+}
+
+// postRegisters posts to a stepping endpoint and decodes the returned registers
+func postRegisters(t *testing.T, server *api.Server, sessionID, action string) (int, api.RegistersResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/%s", sessionID, action), nil)
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+
+	var response api.RegistersResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+// TestStepBack tests undoing a step restores the intermediate register state
+func TestStepBack(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	program := `
+	.org 0x8000
+	MOV R0, #42
+	ADDS R1, R0, #100
+	SWI #0
+	`
+	loadProgram(t, server, sessionID, program)
+
+	// Nothing to undo before the first step
+	if code, _ := postRegisters(t, server, sessionID, "stepback"); code != http.StatusConflict {
+		t.Errorf("Expected status 409 with empty history, got %d", code)
+	}
+
+	code, afterFirst := postRegisters(t, server, sessionID, "step")
+	if code != http.StatusOK {
+		t.Fatalf("First step failed: %d", code)
+	}
+	if code, _ := postRegisters(t, server, sessionID, "step"); code != http.StatusOK {
+		t.Fatalf("Second step failed: %d", code)
+	}
+
+	code, restored := postRegisters(t, server, sessionID, "stepback")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if restored != afterFirst {
+		t.Errorf("Registers after step back %+v do not match intermediate state %+v", restored, afterFirst)
+	}
+	if restored.R0 != 42 || restored.R1 != 0 || restored.PC != 0x8004 {
+		t.Errorf("Expected R0=42 R1=0 PC=0x8004, got R0=%d R1=%d PC=0x%X", restored.R0, restored.R1, restored.PC)
+	}
+
+	// Stepping forward again re-executes the undone instruction
+	if _, again := postRegisters(t, server, sessionID, "step"); again.R1 != 142 {
+		t.Errorf("Expected R1=142 after re-stepping, got %d", again.R1)
+	}
+}
+
+// TestStepBackWhileRunning tests that step back is refused during a run
+func TestStepBackWhileRunning(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	loadProgram(t, server, sessionID, ".org 0x8000\nloop: B loop")
+
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	time.Sleep(20 * time.Millisecond)
+
+	if code, _ := postRegisters(t, server, sessionID, "stepback"); code != http.StatusConflict {
+		t.Errorf("Expected status 409 while running, got %d", code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/stop", sessionID), nil)
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	time.Sleep(20 * time.Millisecond)
+
+	// Once stopped, the steps recorded during the run can be undone
+	if code, regs := postRegisters(t, server, sessionID, "stepback"); code != http.StatusOK || regs.PC != 0x8000 {
+		t.Errorf("Expected step back to 0x8000 after stop, got %d PC=0x%X", code, regs.PC)
+	}
+}
+
 // TestGetRegisters tests getting register state
 func TestGetRegisters(t *testing.T) {
 	server := testServer()
@@ -266,6 +392,94 @@ func TestGetRegisters(t *testing.T) {
 	}
 }
 
+// putRegisters sends a register update and returns the recorder
+func putRegisters(t *testing.T, server *api.Server, sessionID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut,
+		fmt.Sprintf("/api/v1/session/%s/registers", sessionID), bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+// TestSetRegisters tests writing registers and reading them back
+func TestSetRegisters(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nMOV R0, #1\nMOV R1, #2\nSWI #0")
+
+	w := putRegisters(t, server, sessionID, `{"r0": 99, "pc": 32772, "sp": 262000, "cpsr": {"z": true}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet,
+		fmt.Sprintf("/api/v1/session/%s/registers", sessionID), nil)
+	rw := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rw, req)
+
+	var regs api.RegistersResponse
+	if err := json.NewDecoder(rw.Body).Decode(&regs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if regs.R0 != 99 || regs.PC != 0x8004 || regs.SP != 262000 || !regs.CPSR.Z {
+		t.Errorf("Expected R0=99 PC=0x8004 SP=262000 Z=true, got %+v", regs)
+	}
+
+	// Omitted registers and flags are unchanged
+	w = putRegisters(t, server, sessionID, `{"r1": 7}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated api.RegistersResponse
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if updated.R0 != 99 || updated.R1 != 7 || updated.PC != 0x8004 || !updated.CPSR.Z {
+		t.Errorf("Expected partial update to keep other registers, got %+v", updated)
+	}
+}
+
+// TestSetRegistersRejected tests that invalid register updates return 400 and change nothing
+func TestSetRegistersRejected(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nMOV R0, #1\nSWI #0")
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unmapped PC", `{"r0": 5, "pc": 2097152}`},
+		{"non-executable PC", `{"pc": 131072}`},
+		{"misaligned PC", `{"pc": 32770}`},
+		{"alias conflict", `{"r15": 32768, "pc": 32772}`},
+		{"invalid JSON", `{"r0": "zero"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := putRegisters(t, server, sessionID, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet,
+		fmt.Sprintf("/api/v1/session/%s/registers", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	var regs api.RegistersResponse
+	if err := json.NewDecoder(w.Body).Decode(&regs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if regs.R0 != 0 || regs.PC != 0x8000 {
+		t.Errorf("Rejected updates should not change registers, got R0=%d PC=0x%X", regs.R0, regs.PC)
+	}
+}
+
 // TestGetMemory tests reading memory
 func TestGetMemory(t *testing.T) {
 	server := testServer()
@@ -316,6 +530,89 @@ func TestGetMemoryTooLarge(t *testing.T) {
 	}
 }
 
+// writeMemory posts a memory write request and returns the recorder
+func writeMemory(t *testing.T, server *api.Server, sessionID string, reqBody api.MemoryWriteRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/memory", sessionID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+// TestWriteMemory tests writing a word and reading it back
+func TestWriteMemory(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	tests := []struct {
+		name    string
+		request api.MemoryWriteRequest
+	}{
+		{"base64", api.MemoryWriteRequest{Address: 0x20000, Data: []byte{0x78, 0x56, 0x34, 0x12}}},
+		{"hex", api.MemoryWriteRequest{Address: 0x20010, Hex: "78563412"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := writeMemory(t, server, sessionID, tt.request)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var written api.MemoryWriteResponse
+			if err := json.NewDecoder(w.Body).Decode(&written); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if written.BytesWritten != 4 || written.Address != tt.request.Address {
+				t.Errorf("Expected 4 bytes written at 0x%X, got %+v", tt.request.Address, written)
+			}
+
+			req := httptest.NewRequest(http.MethodGet,
+				fmt.Sprintf("/api/v1/session/%s/memory?address=%d&length=4", sessionID, tt.request.Address), nil)
+			rw := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rw, req)
+
+			var read api.MemoryResponse
+			if err := json.NewDecoder(rw.Body).Decode(&read); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if got := uint32(read.Data[0]) | uint32(read.Data[1])<<8 | uint32(read.Data[2])<<16 | uint32(read.Data[3])<<24; got != 0x12345678 {
+				t.Errorf("Expected word 0x12345678, got 0x%08X", got)
+			}
+		})
+	}
+}
+
+// TestWriteMemoryRejected tests that invalid writes return 400
+func TestWriteMemoryRejected(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	tests := []struct {
+		name    string
+		request api.MemoryWriteRequest
+	}{
+		{"unmapped address", api.MemoryWriteRequest{Address: 0x00200000, Hex: "01020304"}},
+		{"past end of segment", api.MemoryWriteRequest{Address: 0x0004FFFE, Hex: "01020304"}},
+		{"address space overflow", api.MemoryWriteRequest{Address: 0xFFFFFFFE, Hex: "01020304"}},
+		{"too large", api.MemoryWriteRequest{Address: 0x20000, Data: make([]byte, 64*1024+1)}},
+		{"invalid hex", api.MemoryWriteRequest{Address: 0x20000, Hex: "xyz"}},
+		{"empty", api.MemoryWriteRequest{Address: 0x20000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := writeMemory(t, server, sessionID, tt.request); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
 // TestBreakpoints tests breakpoint management
 func TestBreakpoints(t *testing.T) {
 	server := testServer()
@@ -485,6 +782,53 @@ func TestSourceMap(t *testing.T) {
 	}
 }
 
+// TestSourceMapColumns tests that source map entries give the instruction's column range
+func TestSourceMapColumns(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nloop:   SUBS R0, R0, #1 ; count down\n        BNE loop\n")
+
+	req := httptest.NewRequest(http.MethodGet,
+		fmt.Sprintf("/api/v1/session/%s/sourcemap", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		SourceMap []struct {
+			Address    uint32 `json:"address"`
+			LineNumber int    `json:"lineNumber"`
+			Line       string `json:"line"`
+			StartCol   int    `json:"startCol"`
+			EndCol     int    `json:"endCol"`
+		} `json:"sourceMap"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.SourceMap) != 2 {
+		t.Fatalf("Expected 2 source map entries, got %d", len(response.SourceMap))
+	}
+
+	want := []string{"SUBS R0, R0, #1", "BNE loop"}
+	for i, entry := range response.SourceMap {
+		if entry.LineNumber != i+2 {
+			t.Errorf("Entry %d: expected line number %d, got %d", i, i+2, entry.LineNumber)
+		}
+		if entry.StartCol < 1 || entry.EndCol > len(entry.Line)+1 || entry.StartCol >= entry.EndCol {
+			t.Fatalf("Entry %d: invalid column range [%d, %d) for %q", i, entry.StartCol, entry.EndCol, entry.Line)
+		}
+		if got := entry.Line[entry.StartCol-1 : entry.EndCol-1]; got != want[i] {
+			t.Errorf("Entry %d: expected columns to span %q, got %q", i, want[i], got)
+		}
+	}
+	if response.SourceMap[0].StartCol != 9 {
+		t.Errorf("Expected the instruction after the label to start at column 9, got %d", response.SourceMap[0].StartCol)
+	}
+}
+
 // TestReset tests VM reset
 func TestReset(t *testing.T) {
 	server := testServer()
@@ -995,6 +1339,73 @@ func TestWatchpoints(t *testing.T) {
 	}
 }
 
+// TestBreakpointAndWatchpointDetails tests that the list endpoints return the same
+// fields as the debugger's "info breakpoints json" and "info watchpoints json"
+func TestBreakpointAndWatchpointDetails(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, `
+		.org 0x8000
+		MOV R0, #1
+		MOV R1, #2
+		ADD R2, R0, R1
+	`)
+
+	session, _ := server.GetSession(sessionID)
+	if _, err := session.Service.ExecuteCommand("break 0x8004 if R0 == 1"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	body, _ := json.Marshal(api.WatchpointRequest{Address: 0x20000, Type: "write"})
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/watchpoint", sessionID), bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	getJSON := func(path string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet,
+			fmt.Sprintf("/api/v1/session/%s/%s", sessionID, path), nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", path, w.Code)
+		}
+		var response map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode %s: %v", path, err)
+		}
+		return response
+	}
+
+	details, _ := getJSON("breakpoints")["details"].([]any)
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 breakpoint in details, got %v", details)
+	}
+	wantBreakpoint := map[string]any{
+		"id": float64(1), "address": float64(0x8004), "condition": "R0 == 1", "enabled": true,
+		"temporary": false, "ignoreCount": float64(0), "hitCount": float64(0),
+	}
+	if got := details[0].(map[string]any); fmt.Sprint(got) != fmt.Sprint(wantBreakpoint) {
+		t.Errorf("Expected breakpoint %v, got %v", wantBreakpoint, got)
+	}
+
+	watchpoints, _ := getJSON("watchpoints")["watchpoints"].([]any)
+	if len(watchpoints) != 1 {
+		t.Fatalf("Expected 1 watchpoint, got %v", watchpoints)
+	}
+	wantWatchpoint := map[string]any{
+		"id": float64(1), "expression": "[0x00020000]", "kind": "memory", "type": "write",
+		"address": float64(0x20000), "length": float64(0), "enabled": true,
+		"hitCount": float64(0), "lastValue": float64(0),
+	}
+	if got := watchpoints[0].(map[string]any); fmt.Sprint(got) != fmt.Sprint(wantWatchpoint) {
+		t.Errorf("Expected watchpoint %v, got %v", wantWatchpoint, got)
+	}
+}
+
 // TestExecutionTrace tests trace management
 func TestExecutionTrace(t *testing.T) {
 	server := testServer()
@@ -1676,3 +2087,604 @@ data_area:
 		t.Errorf("Expected WriteSize=4 for STR, got %d", status.WriteSize)
 	}
 }
+
+// doubleIntProgram reads an integer, prints it doubled and exits
+const doubleIntProgram = `
+	.org 0x8000
+main:
+	SWI #0x06    ; READ_INT
+	ADD R0, R0, R0
+	MOV R1, #10
+	SWI #0x03    ; WRITE_INT
+	SWI #0x07    ; WRITE_NEWLINE
+	SWI #0       ; EXIT
+`
+
+func postStdin(t *testing.T, server *api.Server, sessionID, data string) {
+	t.Helper()
+	body, _ := json.Marshal(api.StdinRequest{Data: data})
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/stdin", sessionID), bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to send stdin: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func postRun(t *testing.T, server *api.Server, sessionID string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for run, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// waitForState polls the session status until it reports state, failing after a timeout.
+// It returns the matching status.
+func waitForState(t *testing.T, server *api.Server, sessionID, state string) api.SessionStatusResponse {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	var status api.SessionStatusResponse
+	for time.Now().Before(deadline) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/session/%s", sessionID), nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode status: %v", err)
+		}
+		if status.State == state {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for state %q, last state %q", state, status.State)
+	return status
+}
+
+func getConsoleOutput(t *testing.T, server *api.Server, sessionID string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet,
+		fmt.Sprintf("/api/v1/session/%s/console", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	var response api.ConsoleOutputResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode console response: %v", err)
+	}
+	return response.Output
+}
+
+// TestStdinBeforeRun tests that stdin sent before run is buffered and consumed by READ_INT
+func TestStdinBeforeRun(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, doubleIntProgram)
+
+	// Input sent in several pieces is appended in order
+	postStdin(t, server, sessionID, "2")
+	postStdin(t, server, sessionID, "1\n")
+	postRun(t, server, sessionID)
+
+	// Wait for program to complete
+	time.Sleep(100 * time.Millisecond)
+	waitForState(t, server, sessionID, "halted")
+
+	if output := getConsoleOutput(t, server, sessionID); output != "42\n" {
+		t.Errorf("Expected output %q, got %q", "42\n", output)
+	}
+}
+
+// TestStdinWhileWaiting tests that a program blocked on a read reports waiting_for_input
+// and resumes when stdin arrives
+func TestStdinWhileWaiting(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, doubleIntProgram)
+
+	postRun(t, server, sessionID)
+	waitForState(t, server, sessionID, "waiting_for_input")
+
+	postStdin(t, server, sessionID, "50")
+	time.Sleep(100 * time.Millisecond)
+	waitForState(t, server, sessionID, "halted")
+
+	// Interactive input is echoed to the console before the program's output
+	if output := getConsoleOutput(t, server, sessionID); output != "50\n100\n" {
+		t.Errorf("Expected output %q, got %q", "50\n100\n", output)
+	}
+}
+
+// TestSessionMaxInstructions tests that a session's instruction limit stops an infinite loop
+func TestSessionMaxInstructions(t *testing.T) {
+	server := testServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/session",
+		bytes.NewReader([]byte(`{"maxInstructions": 50}`)))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create session: %d %s", w.Code, w.Body.String())
+	}
+	var created api.SessionCreateResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode session response: %v", err)
+	}
+
+	loadProgram(t, server, created.SessionID, ".org 0x8000\nmain:\n  B main\n")
+	postRun(t, server, created.SessionID)
+	waitForState(t, server, created.SessionID, "error")
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/session/%s", created.SessionID), nil)
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	var status api.SessionStatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if !strings.Contains(status.Error, "instruction limit exceeded") {
+		t.Errorf("Expected instruction limit error, got %q", status.Error)
+	}
+	if status.Cycles != 50 {
+		t.Errorf("Expected 50 cycles before the limit, got %d", status.Cycles)
+	}
+}
+
+// TestSessionArgs tests that a session's args reach the program through SWI_GET_ARGUMENTS
+func TestSessionArgs(t *testing.T) {
+	server := testServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/session",
+		bytes.NewReader([]byte(`{"args": ["first", "second"]}`)))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create session: %d %s", w.Code, w.Body.String())
+	}
+	var created api.SessionCreateResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode session response: %v", err)
+	}
+
+	// Print argv[1]
+	program := ".org 0x8000\nmain:\n  SWI #0x32\n  LDR R0, [R1, #4]\n  SWI #0x02\n  MOV R0, #0\n  SWI #0x00\n"
+	loadProgram(t, server, created.SessionID, program)
+	postRun(t, server, created.SessionID)
+	waitForState(t, server, created.SessionID, "halted")
+
+	if out := getConsoleOutput(t, server, created.SessionID); out != "second" {
+		t.Errorf("Expected output %q, got %q", "second", out)
+	}
+}
+
+// TestSessionEnv tests that a session's env reaches the program and bad entries are rejected
+func TestSessionEnv(t *testing.T) {
+	server := testServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/session",
+		bytes.NewReader([]byte(`{"env": ["NOEQUALS"]}`)))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid variable, got %d %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/session",
+		bytes.NewReader([]byte(`{"env": ["USER=student", "LEVEL=2"]}`)))
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create session: %d %s", w.Code, w.Body.String())
+	}
+	var created api.SessionCreateResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode session response: %v", err)
+	}
+
+	// Print envp[1]
+	program := ".org 0x8000\nmain:\n  SWI #0x33\n  LDR R0, [R0, #4]\n  SWI #0x02\n  MOV R0, #0\n  SWI #0x00\n"
+	loadProgram(t, server, created.SessionID, program)
+	postRun(t, server, created.SessionID)
+	waitForState(t, server, created.SessionID, "halted")
+
+	if out := getConsoleOutput(t, server, created.SessionID); out != "LEVEL=2" {
+		t.Errorf("Expected output %q, got %q", "LEVEL=2", out)
+	}
+}
+
+// postAssemble sends source to the assemble endpoint and decodes the response
+func postAssemble(t *testing.T, server *api.Server, source string) (int, api.AssembleResponse) {
+	t.Helper()
+	body, _ := json.Marshal(api.AssembleRequest{Source: source})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assemble", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	var response api.AssembleResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return w.Code, response
+}
+
+// TestAssemble tests assembling without a session
+func TestAssemble(t *testing.T) {
+	server := testServer()
+
+	code, response := postAssemble(t, server, `.org 0x8000
+_start:
+    MOV R0, #42
+    LDR R1, =0x12345678
+    SWI #0x00
+value: .word 0x01020304
+msg:   .asciz "hi"
+`)
+	if code != http.StatusOK || !response.Success {
+		t.Fatalf("Expected success, got %d: %+v", code, response.Errors)
+	}
+
+	if len(response.Instructions) != 3 {
+		t.Fatalf("Expected 3 instructions, got %d", len(response.Instructions))
+	}
+	mov := response.Instructions[0]
+	if mov.Address != 0x8000 || mov.Opcode != 0xE3A0002A || mov.Line != 3 || mov.Source != "MOV R0, #42" {
+		t.Errorf("Unexpected MOV R0, #42: %+v", mov)
+	}
+	if response.EntryPoint != 0x8000 || response.Symbols["value"] != 0x800C {
+		t.Errorf("Unexpected entry point 0x%X or symbols %v", response.EntryPoint, response.Symbols)
+	}
+
+	if len(response.Data) != 2 ||
+		!bytes.Equal(response.Data[0].Bytes, []byte{4, 3, 2, 1}) ||
+		!bytes.Equal(response.Data[1].Bytes, []byte("hi\x00")) {
+		t.Errorf("Unexpected data: %+v", response.Data)
+	}
+	if len(response.Literals) != 1 || response.Literals[0].Value != 0x12345678 {
+		t.Errorf("Expected the LDR literal in the pool, got %+v", response.Literals)
+	}
+
+	// No session was created
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/session", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	var list map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode session list: %v", err)
+	}
+	if sessions, _ := list["sessions"].([]interface{}); len(sessions) != 0 {
+		t.Errorf("Expected no sessions, got %d", len(sessions))
+	}
+}
+
+// TestAssembleErrors tests that parse and encoding errors report their line
+func TestAssembleErrors(t *testing.T) {
+	server := testServer()
+
+	code, response := postAssemble(t, server, "_start:\n    MOV R0, #1\n    FOO R1\n")
+	if code != http.StatusBadRequest || response.Success || len(response.Errors) == 0 {
+		t.Fatalf("Expected a parse error, got %d: %+v", code, response)
+	}
+
+	code, response = postAssemble(t, server, "_start:\n    MOV R0, #1\n    MOV R1, #0x101\n")
+	if code != http.StatusBadRequest || len(response.Errors) != 1 {
+		t.Fatalf("Expected one encoding error, got %d: %+v", code, response)
+	}
+	if e := response.Errors[0]; e.Line != 3 || !strings.Contains(e.Message, "0x101") {
+		t.Errorf("Expected an error on line 3, got %+v", e)
+	}
+}
+
+// TestAST tests that the AST endpoint returns the parsed structure with source positions
+func TestAST(t *testing.T) {
+	server := testServer()
+
+	post := func(source string) (int, api.ASTResponse) {
+		body, _ := json.Marshal(api.ASTRequest{Source: source})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ast", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		var response api.ASTResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	code, response := post(".org 0x8000\n_start:\n    MOV R0, #1\nloop: SUBS R0, R0, #1\n    BNE loop\nvalue: .word 7\n")
+	if code != http.StatusOK || !response.Success {
+		t.Fatalf("Expected success, got %d: %+v", code, response.Errors)
+	}
+	if len(response.Instructions) != 3 {
+		t.Fatalf("Expected 3 instructions, got %d", len(response.Instructions))
+	}
+	subs := response.Instructions[1]
+	if subs.Label != "loop" || subs.Mnemonic != "SUB" || !subs.SetFlags || subs.Address != 0x8004 || subs.Line != 4 {
+		t.Errorf("Unexpected SUBS: %+v", subs)
+	}
+	if len(response.Directives) != 2 || response.Directives[1].Name != ".word" || response.Directives[1].Address != 0x800C {
+		t.Errorf("Unexpected directives: %+v", response.Directives)
+	}
+
+	code, response = post("_start:\n_start:\n")
+	if code != http.StatusBadRequest || response.Success || len(response.Errors) == 0 || response.Errors[0].Line != 2 {
+		t.Errorf("Expected a parse error on line 2, got %d: %+v", code, response)
+	}
+}
+
+const runToProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	MOV R1, #2
+middle:
+	ADD R2, R0, R1
+	MOV R0, #0
+	SWI #0
+`
+
+// postRunTo sends a run-to request and returns the recorder
+func postRunTo(t *testing.T, server *api.Server, sessionID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run-to", sessionID), bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+// TestRunToLabel tests that run-to stops at a mid-program label without leaving a breakpoint
+func TestRunToLabel(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, runToProgram)
+
+	if w := postRunTo(t, server, sessionID, `{"label":"middle"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if status := waitForState(t, server, sessionID, "breakpoint"); status.PC != 0x8008 {
+		t.Errorf("Expected to stop at 0x00008008, got 0x%08X", status.PC)
+	}
+
+	session, _ := server.GetSession(sessionID)
+	if bps := session.Service.GetBreakpoints(); len(bps) != 0 {
+		t.Errorf("Expected no breakpoints to be left behind, got %+v", bps)
+	}
+
+	// Running on reaches the end rather than stopping at the old target
+	postRun(t, server, sessionID)
+	waitForState(t, server, sessionID, "halted")
+}
+
+// TestRunToUnreached tests that a target that is never reached lets the program exit normally
+func TestRunToUnreached(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, runToProgram)
+
+	if w := postRunTo(t, server, sessionID, `{"address":36864}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	waitForState(t, server, sessionID, "halted")
+
+	// After the exit, run-to starts again from the entry point
+	postRunTo(t, server, sessionID, `{"label":"middle"}`)
+	if status := waitForState(t, server, sessionID, "breakpoint"); status.PC != 0x8008 {
+		t.Errorf("Expected to stop at 0x00008008, got 0x%08X", status.PC)
+	}
+
+	if w := postRunTo(t, server, sessionID, `{"label":"nowhere"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown label, got %d", w.Code)
+	}
+}
+
+// TestGetMemoryMap tests that the memory map lists the segments with their permissions
+// and counts instruction fetches after a run
+func TestGetMemoryMap(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, runToProgram)
+	postRun(t, server, sessionID)
+	waitForState(t, server, sessionID, "halted")
+
+	req := httptest.NewRequest(http.MethodGet,
+		fmt.Sprintf("/api/v1/session/%s/memory-map", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response api.MemoryMapResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	permissions := make(map[string]string)
+	for _, seg := range response.Segments {
+		permissions[seg.Name] = seg.Permissions
+		if seg.Name == "code" && seg.Executes != 5 {
+			t.Errorf("Expected 5 instruction fetches from code, got %d", seg.Executes)
+		}
+	}
+	for name, want := range map[string]string{"code": "rwx", "data": "rw-", "stack": "rw-"} {
+		if permissions[name] != want {
+			t.Errorf("Expected %s segment with permissions %q, got %q", name, want, permissions[name])
+		}
+	}
+}
+
+// postRunWait issues a synchronous run and decodes the final result
+func postRunWait(t *testing.T, server *api.Server, sessionID string) api.RunResultResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run?wait=true", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for run, got %d: %s", w.Code, w.Body.String())
+	}
+	var result api.RunResultResponse
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode run result: %v", err)
+	}
+	return result
+}
+
+// TestRunWait tests that ?wait=true returns the final state without polling
+func TestRunWait(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	program := `
+	.org 0x8000
+main:
+	LDR R0, =message
+	SWI #0x02    ; WRITE_STRING syscall
+	MOV R1, #42
+	MOV R0, #3
+	SWI #0       ; EXIT syscall
+
+message:
+	.asciz "done"
+	`
+	loadProgram(t, server, sessionID, program)
+
+	result := postRunWait(t, server, sessionID)
+	if result.State != "halted" {
+		t.Errorf("Expected state halted, got %q", result.State)
+	}
+	if result.Registers == nil || result.Registers.R1 != 42 {
+		t.Errorf("Expected R1 = 42 in the result, got %+v", result.Registers)
+	}
+	if result.Output != "done" {
+		t.Errorf("Expected output %q, got %q", "done", result.Output)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", result.ExitCode)
+	}
+	if result.Error != "" {
+		t.Errorf("Expected no error, got %q", result.Error)
+	}
+}
+
+// TestRunWaitBreakpoint tests that a synchronous run returns when a breakpoint is hit
+func TestRunWaitBreakpoint(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  MOV R0, #1\n  MOV R0, #2\n  SWI #0\n")
+
+	session, _ := server.GetSession(sessionID)
+	if err := session.Service.AddBreakpoint(0x8004); err != nil {
+		t.Fatalf("Failed to add breakpoint: %v", err)
+	}
+
+	result := postRunWait(t, server, sessionID)
+	if result.State != "breakpoint" {
+		t.Errorf("Expected state breakpoint, got %q", result.State)
+	}
+	if result.Registers == nil || result.Registers.PC != 0x8004 || result.Registers.R0 != 1 {
+		t.Errorf("Expected to stop at 0x00008004 with R0 = 1, got %+v", result.Registers)
+	}
+}
+
+// TestRunWaitInvalid tests that an unparsable wait parameter is rejected
+func TestRunWaitInvalid(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  SWI #0\n")
+
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run?wait=maybe", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRunWaitClientGone tests that abandoning a synchronous run stops the program
+func TestRunWaitClientGone(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  B main\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run?wait=true", sessionID), nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	waitForState(t, server, sessionID, "halted")
+}
+
+// TestRunTimeout tests that ?timeout stops an infinite loop in the error state
+func TestRunTimeout(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  B main\n")
+	session, _ := server.GetSession(sessionID)
+	session.Service.GetVM().CycleLimit = 0
+
+	req := httptest.NewRequest(http.MethodPost,
+		fmt.Sprintf("/api/v1/session/%s/run?wait=true&timeout=100ms", sessionID), nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for run, got %d: %s", w.Code, w.Body.String())
+	}
+	var result api.RunResultResponse
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode run result: %v", err)
+	}
+	if result.State != "error" || !strings.Contains(result.Error, "wall-clock timeout exceeded") {
+		t.Errorf("Expected a timeout error, got state %q error %q", result.State, result.Error)
+	}
+
+	var timeoutErr *vm.TimeoutError
+	if !errors.As(session.Service.GetVM().LastError, &timeoutErr) {
+		t.Errorf("Expected LastError to be a *vm.TimeoutError, got %v", session.Service.GetVM().LastError)
+	}
+}
+
+// TestRunTimeoutInvalid tests that a malformed or non-positive timeout is rejected
+func TestRunTimeoutInvalid(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  SWI #0\n")
+
+	for _, value := range []string{"soon", "0s", "-1s"} {
+		req := httptest.NewRequest(http.MethodPost,
+			fmt.Sprintf("/api/v1/session/%s/run?timeout=%s", sessionID, value), nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("timeout=%s: expected status 400, got %d: %s", value, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestConsoleCaptureIndependentOfOutputWriter tests that /console keeps capturing output
+// when the session's OutputWriter is replaced
+func TestConsoleCaptureIndependentOfOutputWriter(t *testing.T) {
+	server := testServer()
+	sessionID := createTestSession(t, server)
+
+	session, _ := server.GetSession(sessionID)
+	var live bytes.Buffer
+	session.Service.GetVM().OutputWriter = &live
+
+	loadProgram(t, server, sessionID, ".org 0x8000\nmain:\n  LDR R0, =msg\n  SWI #0x02\n  MOV R0, #0\n  SWI #0\nmsg: .asciz \"both\"\n")
+	postRunWait(t, server, sessionID)
+
+	if got := getConsoleOutput(t, server, sessionID); got != "both" {
+		t.Errorf("Expected /console to capture %q, got %q", "both", got)
+	}
+	if live.String() != "both" {
+		t.Errorf("Expected the replacement writer to receive %q, got %q", "both", live.String())
+	}
+}
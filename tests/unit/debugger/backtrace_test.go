@@ -0,0 +1,141 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// loadBacktraceProgram loads source with a stack, which run resets SP to
+func loadBacktraceProgram(t *testing.T, source string) *debugger.Debugger {
+	t.Helper()
+	dbg := loadDebugProgram(t, source)
+	dbg.VM.StackTop = vm.StackSegmentStart + vm.StackSegmentSize
+	return dbg
+}
+
+// Line 9 is the return in leaf, reached via _start -> outer -> middle -> leaf
+const backtraceProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #3
+	BL outer
+	SWI #0
+leaf:
+	ADD R0, R0, #1
+	MOV PC, LR
+middle:
+	PUSH {R4, LR}
+	MOV R4, #0
+again:
+	ADD R4, R4, #1
+	CMP R4, #2
+	BNE again
+	BL leaf
+	POP {R4, PC}
+outer:
+	PUSH {LR}
+	BL helper
+	BL middle
+	POP {PC}
+helper:
+	MOV PC, LR
+`
+
+func TestBacktrace_NestedCalls(t *testing.T) {
+	dbg := loadBacktraceProgram(t, backtraceProgram)
+
+	if err := dbg.ExecuteCommand("break test.s:9"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); !strings.HasPrefix(reason, "breakpoint") {
+		t.Fatalf("expected breakpoint stop, got %q", reason)
+	}
+
+	frames := dbg.Backtrace()
+	// The local label "again" does not split middle, and the finished call to helper
+	// leaves nothing behind
+	want := []struct {
+		function string
+		address  uint32
+	}{
+		{"leaf", 0x8010},
+		{"middle", 0x802C},
+		{"outer", 0x803C},
+		{"_start", 0x8008},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("expected %d frames, got %d: %+v", len(want), len(frames), frames)
+	}
+	for i, w := range want {
+		if frames[i].Number != i || frames[i].Function != w.function || frames[i].Address != w.address {
+			t.Errorf("frame %d: expected %s at 0x%08X, got %+v", i, w.function, w.address, frames[i])
+		}
+	}
+
+	dbg.GetOutput()
+	if err := dbg.ExecuteCommand("bt"); err != nil {
+		t.Fatalf("bt failed: %v", err)
+	}
+	output := dbg.GetOutput()
+	for _, line := range []string{
+		"  #0  0x00008010 in leaf",
+		"  #1  0x0000802C in middle",
+		"  #3  0x00008008 in _start",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected %q in backtrace output:\n%s", line, output)
+		}
+	}
+}
+
+func TestBacktrace_Recursion(t *testing.T) {
+	// count calls itself until R0 reaches 0
+	dbg := loadBacktraceProgram(t, `
+	.org 0x8000
+main:
+	MOV R0, #3
+	BL count
+	SWI #0
+count:
+	CMP R0, #0
+	MOVEQ PC, LR
+	PUSH {LR}
+	SUB R0, R0, #1
+	BL count
+	POP {PC}
+`)
+
+	// The fourth call is the base case
+	if err := dbg.ExecuteCommand("break count ignore 3"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); !strings.HasPrefix(reason, "breakpoint") {
+		t.Fatalf("expected breakpoint stop, got %q", reason)
+	}
+	if dbg.VM.CPU.R[0] != 0 {
+		t.Fatalf("expected to stop in the base case, R0=%d", dbg.VM.CPU.R[0])
+	}
+
+	// Four activations of count (R0 = 3, 2, 1, 0) below main
+	frames := dbg.Backtrace()
+	if len(frames) != 5 {
+		t.Fatalf("expected 5 frames, got %d: %+v", len(frames), frames)
+	}
+	for i := 0; i < 4; i++ {
+		if frames[i].Function != "count" {
+			t.Errorf("frame %d: expected count, got %+v", i, frames[i])
+		}
+	}
+	if frames[4].Function != "main" || frames[4].Address != 0x8008 {
+		t.Errorf("expected outermost frame in main at 0x00008008, got %+v", frames[4])
+	}
+}
@@ -0,0 +1,147 @@
+package debugger_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+)
+
+const breakpointFileProgram = `
+        .org 0x8000
+main:
+        MOV R0, #0
+loop:
+        ADD R0, R0, #1
+        CMP R0, #5
+        BNE loop
+done:
+        SWI #0x00
+counter: .word 0
+`
+
+// The same program with an extra instruction before loop and without the done label
+const breakpointFileEditedProgram = `
+        .org 0x8000
+main:
+        MOV R0, #0
+        MOV R1, #0
+loop:
+        ADD R0, R0, #1
+        CMP R0, #5
+        BNE loop
+        SWI #0x00
+counter: .word 0
+`
+
+func TestBreakpointFile_RoundTrip(t *testing.T) {
+	dbg := loadDebugProgram(t, breakpointFileProgram)
+	for _, cmd := range []string{
+		"break loop if R0 == 3",
+		"break done",
+		"disable 2",
+		"break 0x8008 ignore 2",
+		"watch [counter]",
+		"awatch R1",
+		"watch R1+R2",
+	} {
+		if err := dbg.ExecuteCommand(cmd); err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+	}
+	if err := dbg.Watchpoints.DisableWatchpoint(2); err != nil {
+		t.Fatalf("disable watchpoint: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bps.json")
+	if err := dbg.ExecuteCommand("save-breakpoints " + path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	dbg.Breakpoints.Clear()
+	dbg.Watchpoints.Clear()
+
+	if err := dbg.ExecuteCommand("load-breakpoints " + path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if dbg.Breakpoints.Count() != 3 || dbg.Watchpoints.Count() != 3 {
+		t.Fatalf("expected 3 breakpoints and 3 watchpoints, got %d and %d", dbg.Breakpoints.Count(), dbg.Watchpoints.Count())
+	}
+
+	if bp := dbg.Breakpoints.GetBreakpoint(0x8004); bp == nil || bp.Condition != "R0 == 3" || !bp.Enabled {
+		t.Errorf("expected the conditional breakpoint at loop, got %+v", bp)
+	}
+	if bp := dbg.Breakpoints.GetBreakpoint(0x8010); bp == nil || bp.Enabled {
+		t.Errorf("expected a disabled breakpoint at done, got %+v", bp)
+	}
+	if bp := dbg.Breakpoints.GetBreakpoint(0x8008); bp == nil || bp.IgnoreCount != 2 {
+		t.Errorf("expected the ignore count to be restored, got %+v", bp)
+	}
+
+	for _, wp := range dbg.Watchpoints.GetAllWatchpoints() {
+		switch wp.Expression {
+		case "[counter]":
+			if wp.Type != debugger.WatchWrite || wp.Address != dbg.Symbols["counter"] || !wp.Enabled {
+				t.Errorf("unexpected counter watchpoint %+v", wp)
+			}
+		case "R1":
+			if wp.Type != debugger.WatchReadWrite || !wp.IsRegister || wp.Enabled {
+				t.Errorf("expected a disabled access watchpoint on R1, got %+v", wp)
+			}
+		case "R1+R2":
+			if !wp.IsExpression {
+				t.Errorf("expected an expression watchpoint, got %+v", wp)
+			}
+		default:
+			t.Errorf("unexpected watchpoint %q", wp.Expression)
+		}
+	}
+}
+
+func TestBreakpointFile_ReresolvesLabels(t *testing.T) {
+	dbg := loadDebugProgram(t, breakpointFileProgram)
+	for _, cmd := range []string{"break loop if R0 == 3", "break done", "watch [counter]"} {
+		if err := dbg.ExecuteCommand(cmd); err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "bps.json")
+	if err := dbg.SaveBreakpoints(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	// Load into the edited program, where loop and counter have moved and done is gone
+	edited := loadDebugProgram(t, breakpointFileEditedProgram)
+	warnings, err := edited.LoadBreakpoints(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "done") {
+		t.Errorf("expected one warning about done, got %v", warnings)
+	}
+
+	loop := edited.Symbols["loop"]
+	if loop != 0x8008 {
+		t.Fatalf("test program changed: loop at 0x%X", loop)
+	}
+	if bp := edited.Breakpoints.GetBreakpoint(loop); bp == nil || bp.Condition != "R0 == 3" {
+		t.Errorf("expected the breakpoint to follow loop to 0x%X, got %+v", loop, bp)
+	}
+	if edited.Breakpoints.Count() != 1 {
+		t.Errorf("expected only the loop breakpoint, got %d", edited.Breakpoints.Count())
+	}
+	wps := edited.Watchpoints.GetAllWatchpoints()
+	if len(wps) != 1 || wps[0].Address != edited.Symbols["counter"] {
+		t.Errorf("expected the counter watchpoint at 0x%X, got %+v", edited.Symbols["counter"], wps)
+	}
+}
+
+func TestBreakpointFile_Errors(t *testing.T) {
+	dbg := loadDebugProgram(t, breakpointFileProgram)
+	if err := dbg.ExecuteCommand("load-breakpoints " + filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if err := dbg.ExecuteCommand("save-breakpoints"); err == nil {
+		t.Error("expected a usage error")
+	}
+}
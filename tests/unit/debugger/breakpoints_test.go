@@ -1,6 +1,7 @@
 package debugger_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/debugger"
@@ -235,3 +236,181 @@ func TestBreakpoint_HitCount(t *testing.T) {
 		t.Errorf("Hit count = %d, want 2", bp.HitCount)
 	}
 }
+
+func TestBreakAtSourceLine(t *testing.T) {
+	// Line numbers: 1 is blank, 2 is .org, 3 is the label
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	MOV R1, #2
+
+	ADD R2, R0, R1
+	SWI #0
+`)
+
+	if err := dbg.ExecuteCommand("break test.s:7"); err != nil {
+		t.Fatalf("break test.s:7 failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); !strings.HasPrefix(reason, "breakpoint") {
+		t.Fatalf("expected breakpoint stop, got %q", reason)
+	}
+
+	if dbg.VM.CPU.PC != 0x8008 {
+		t.Errorf("expected stop at 0x8008, got 0x%08X", dbg.VM.CPU.PC)
+	}
+	if source := dbg.SourceMap[dbg.VM.CPU.PC]; source != "\tADD R2, R0, R1" {
+		t.Errorf("expected stop on ADD line, got %q", source)
+	}
+	if dbg.VM.CPU.R[2] != 0 {
+		t.Errorf("breakpoint line should not have executed, R2=%d", dbg.VM.CPU.R[2])
+	}
+}
+
+func TestBreakAtSourceLineWithDirectory(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	SWI #0
+`)
+
+	if err := dbg.ExecuteCommand("tbreak examples/test.s:5"); err != nil {
+		t.Fatalf("tbreak with directory failed: %v", err)
+	}
+	if bp := dbg.Breakpoints.GetBreakpoint(0x8004); bp == nil {
+		t.Error("expected breakpoint at 0x8004 for line 5")
+	}
+}
+
+func TestBreakAtSourceLineErrors(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #1
+
+	SWI #0
+`)
+
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"test.s:5", "no code at test.s:5"},
+		{"test.s:3", "no code at test.s:3"}, // label-only line
+		{"other.s:4", "no source file other.s"},
+		{"test.s:abc", "invalid source location"},
+	}
+	for _, tt := range tests {
+		err := dbg.ExecuteCommand("break " + tt.location)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("break %s: expected error containing %q, got %v", tt.location, tt.want, err)
+		}
+	}
+}
+
+func TestBreakpointManager_IgnoreCount(t *testing.T) {
+	bm := debugger.NewBreakpointManager()
+	bp := bm.AddBreakpoint(0x8000, true, "")
+	if err := bm.SetIgnoreCount(bp.ID, 2); err != nil {
+		t.Fatalf("SetIgnoreCount failed: %v", err)
+	}
+
+	for hit := 1; hit <= 2; hit++ {
+		if _, stop := bm.ProcessHit(0x8000); stop {
+			t.Errorf("hit %d should be ignored", hit)
+		}
+	}
+	hitBp, stop := bm.ProcessHit(0x8000)
+	if !stop || hitBp.HitCount != 3 {
+		t.Errorf("expected stop on hit 3, got stop=%v hits=%d", stop, hitBp.HitCount)
+	}
+
+	// Temporary breakpoints are only removed once they actually stop
+	if bm.HasBreakpoint(0x8000) {
+		t.Error("temporary breakpoint should be deleted after it stops")
+	}
+
+	if err := bm.SetIgnoreCount(bp.ID, -1); err == nil {
+		t.Error("expected error for negative ignore count")
+	}
+	if err := bm.SetIgnoreCount(99, 1); err == nil {
+		t.Error("expected error for unknown breakpoint")
+	}
+}
+
+const ignoreCountLoop = `
+	.org 0x8000
+_start:
+	MOV R0, #0
+loop:
+	ADD R0, R0, #1
+	CMP R0, #10
+	BNE loop
+	SWI #0
+`
+
+func TestBreakIgnoreCount_StopsOnCorrectIteration(t *testing.T) {
+	dbg := loadDebugProgram(t, ignoreCountLoop)
+
+	if err := dbg.ExecuteCommand("break loop ignore 4"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "ignoring next 4 hits") {
+		t.Errorf("unexpected break output: %q", out)
+	}
+
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "breakpoint 1" {
+		t.Fatalf("expected breakpoint 1, got %q", reason)
+	}
+
+	// Fifth arrival at loop: four increments have already happened
+	if dbg.VM.CPU.R[0] != 4 {
+		t.Errorf("expected to stop on the 5th iteration (R0=4), got R0=%d", dbg.VM.CPU.R[0])
+	}
+	if bp := dbg.Breakpoints.GetBreakpoint(dbg.VM.CPU.PC); bp == nil || bp.HitCount != 5 {
+		t.Errorf("expected hit count 5, got %+v", bp)
+	}
+
+	if err := dbg.ExecuteCommand("info breakpoints"); err != nil {
+		t.Fatalf("info breakpoints failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "enabled ignore 4 (hit 5 times)") {
+		t.Errorf("expected ignore count in breakpoint list: %q", out)
+	}
+}
+
+func TestBreakIgnoreCount_WithCondition(t *testing.T) {
+	dbg := loadDebugProgram(t, ignoreCountLoop)
+
+	// Hits only count while the condition holds: R0 is odd on hits with R0 = 1, 3, 5 ...
+	// so the third counted hit has R0=5
+	if err := dbg.ExecuteCommand("break loop ignore 2 if R0 & 1"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "breakpoint 1" {
+		t.Fatalf("expected breakpoint 1, got %q", reason)
+	}
+	if dbg.VM.CPU.R[0] != 5 {
+		t.Errorf("expected R0=5, got %d", dbg.VM.CPU.R[0])
+	}
+}
+
+func TestBreakIgnoreCount_InvalidArguments(t *testing.T) {
+	dbg := loadDebugProgram(t, ignoreCountLoop)
+
+	for _, cmd := range []string{"break loop ignore", "break loop ignore x", "break loop ignore -1"} {
+		if err := dbg.ExecuteCommand(cmd); err == nil {
+			t.Errorf("%q: expected error", cmd)
+		}
+	}
+}
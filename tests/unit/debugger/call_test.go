@@ -0,0 +1,127 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const callProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #7
+	SWI #0
+add:
+	ADD R0, R0, R1
+	MOV PC, LR
+sum6:
+	PUSH {R4, LR}
+	ADD R0, R0, R1
+	ADD R0, R0, R2
+	ADD R0, R0, R3
+	LDR R4, [SP, #8]
+	ADD R0, R0, R4
+	LDR R4, [SP, #12]
+	ADD R0, R0, R4
+	POP {R4, PC}
+spin:
+	B spin
+quit:
+	SWI #0
+`
+
+func TestCall_AddRestoresState(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	cpu := dbg.VM.CPU
+	cpu.R[0], cpu.R[1], cpu.R[13], cpu.R[14] = 11, 22, 0x50000, 0x1234
+	cpu.CPSR.Z = true
+	before := *cpu
+
+	if err := dbg.ExecuteCommand("call add(2, 3)"); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "add returned 5 (0x00000005)") {
+		t.Errorf("expected the result, got %q", out)
+	}
+	if *cpu != before {
+		t.Errorf("expected the registers to be restored, got %+v, want %+v", *cpu, before)
+	}
+}
+
+func TestCall_StackArguments(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	dbg.VM.CPU.R[13] = 0x50000
+
+	result, err := dbg.CallFunction(dbg.Symbols["sum6"], []uint32{1, 2, 3, 4, 5, 6})
+	if err != nil {
+		t.Fatalf("CallFunction failed: %v", err)
+	}
+	if result != 21 {
+		t.Errorf("expected 21, got %d", result)
+	}
+	if dbg.VM.CPU.R[13] != 0x50000 {
+		t.Errorf("expected SP to be restored, got 0x%08X", dbg.VM.CPU.R[13])
+	}
+}
+
+func TestCall_FunctionThatNeverReturns(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	pc := dbg.VM.CPU.PC
+
+	_, err := dbg.CallFunction(dbg.Symbols["spin"], nil)
+	if err == nil || !strings.Contains(err.Error(), "did not return within") {
+		t.Fatalf("expected the call to give up, got %v", err)
+	}
+	if dbg.VM.CPU.PC != pc {
+		t.Errorf("expected PC to be restored to 0x%08X, got 0x%08X", pc, dbg.VM.CPU.PC)
+	}
+}
+
+func TestCall_FunctionThatExits(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	state := dbg.VM.State
+
+	err := dbg.ExecuteCommand("call quit 9")
+	if err == nil || !strings.Contains(err.Error(), "exited the program with code 9") {
+		t.Fatalf("expected the exit to be reported, got %v", err)
+	}
+	if dbg.VM.State != state || dbg.VM.ExitCode != 0 {
+		t.Errorf("expected the execution state to be restored, got %v with exit code %d", dbg.VM.State, dbg.VM.ExitCode)
+	}
+}
+
+func TestCall_Usage(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	for _, cmd := range []string{"call", "call nowhere(1)", "call add(1, bogus)"} {
+		if err := dbg.ExecuteCommand(cmd); err == nil {
+			t.Errorf("expected %q to fail", cmd)
+		}
+	}
+}
+
+func TestCall_ParenthesizedArguments(t *testing.T) {
+	dbg := loadDebugProgram(t, callProgram)
+	dbg.VM.CPU.R[2] = 4
+
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"call add((1+2)*3, R2+1)", "add returned 14 "},
+		{"call add ( (1 + 2) * 3 , 1 )", "add returned 10 "},
+		{"call add, (1+2)*3, (R2)", "add returned 13 "},
+		{"call add (1+2)*3 2", "add returned 11 "},
+	}
+	for _, tt := range tests {
+		if err := dbg.ExecuteCommand(tt.cmd); err != nil {
+			t.Errorf("%q failed: %v", tt.cmd, err)
+			continue
+		}
+		if out := dbg.GetOutput(); !strings.Contains(out, tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.cmd, tt.want, out)
+		}
+	}
+
+	if err := dbg.ExecuteCommand("call add((1+2, 3)"); err == nil || !strings.Contains(err.Error(), "unbalanced") {
+		t.Errorf("expected unbalanced parentheses to be rejected, got %v", err)
+	}
+}
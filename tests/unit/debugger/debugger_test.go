@@ -286,6 +286,50 @@ func TestInfoRegisters(t *testing.T) {
 	}
 }
 
+// TestInfoRegistersQFlag tests that info registers shows the sticky Q flag when set
+func TestInfoRegistersQFlag(t *testing.T) {
+	machine := vm.NewVM()
+	dbg := debugger.NewDebugger(machine)
+
+	if err := dbg.ExecuteCommand("info registers"); err != nil {
+		t.Fatalf("Failed to execute info registers: %v", err)
+	}
+	if output := dbg.GetOutput(); !strings.Contains(output, "CPSR = [----]") {
+		t.Errorf("Expected clear flags without Q, got:\n%s", output)
+	}
+
+	machine.CPU.CPSR.Q = true
+	if err := dbg.ExecuteCommand("info registers"); err != nil {
+		t.Fatalf("Failed to execute info registers: %v", err)
+	}
+	if output := dbg.GetOutput(); !strings.Contains(output, "CPSR = [----Q]") {
+		t.Errorf("Expected Q flag in CPSR line, got:\n%s", output)
+	}
+}
+
+// TestInfoLiterals tests the info literals command
+func TestInfoLiterals(t *testing.T) {
+	dbg := debugger.NewDebugger(vm.NewVM())
+
+	if err := dbg.ExecuteCommand("info literals"); err != nil {
+		t.Fatalf("Failed to execute info literals: %v", err)
+	}
+	if output := dbg.GetOutput(); !strings.Contains(output, "No literal pool entries") {
+		t.Errorf("Expected empty pool message, got:\n%s", output)
+	}
+
+	dbg.LoadLiteralPool(map[uint32]uint32{0x8020: 0xDEADBEEF, 0x8010: 0x12345678})
+	if err := dbg.ExecuteCommand("info lit"); err != nil {
+		t.Fatalf("Failed to execute info lit: %v", err)
+	}
+	output := dbg.GetOutput()
+	first := strings.Index(output, "0x00008010: 0x12345678")
+	second := strings.Index(output, "0x00008020: 0xDEADBEEF")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("Expected entries in address order, got:\n%s", output)
+	}
+}
+
 // TestInfoBreakpoints tests the info breakpoints command
 func TestInfoBreakpoints(t *testing.T) {
 	machine := vm.NewVM()
@@ -0,0 +1,100 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const disasProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	BL helper
+	LDR R1, =0x12345678
+	SWI #0
+helper:
+	ADD R0, R0, #1
+	MOV PC, LR
+`
+
+func TestDisas_AnnotatesBranchesPCAndBreakpoints(t *testing.T) {
+	dbg := loadDebugProgram(t, disasProgram)
+	if err := dbg.ExecuteCommand("break helper"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+	dbg.GetOutput()
+
+	if err := dbg.ExecuteCommand("disas _start 6"); err != nil {
+		t.Fatalf("disas failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(dbg.GetOutput(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+
+	if !strings.HasPrefix(lines[0], "=>") || !strings.Contains(lines[0], "<_start>") || !strings.HasSuffix(lines[0], "MOV R0, #1") {
+		t.Errorf("expected the PC marker on the first instruction, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "EB000001") || !strings.HasSuffix(lines[1], "BL helper") {
+		t.Errorf("expected the BL to name its target, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "; =0x12345678") {
+		t.Errorf("expected the literal value, got %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[4], "  * 0x00008010 <helper>") {
+		t.Errorf("expected a breakpoint marker on helper, got %q", lines[4])
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "\033[") {
+			t.Errorf("expected no colour codes by default, got %q", line)
+		}
+	}
+}
+
+func TestDisas_ResolvesLiteralLabels(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	LDR R0, =message
+	SWI #0
+message:
+	.asciz "hi"
+`)
+
+	if err := dbg.ExecuteCommand("disas _start 1"); err != nil {
+		t.Fatalf("disas failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	if !strings.Contains(out, "LDR R0, [PC, #") || !strings.Contains(out, "; =0x00008008 (message)") {
+		t.Errorf("expected the literal to resolve to message, got %q", out)
+	}
+}
+
+func TestDisas_ListWithAddressAndCount(t *testing.T) {
+	dbg := loadDebugProgram(t, disasProgram)
+
+	if err := dbg.ExecuteCommand("list helper 2"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	if !strings.Contains(out, "ADD R0, R0, #1") || !strings.Contains(out, "MOV PC, LR") || strings.Count(out, "\n") != 2 {
+		t.Errorf("unexpected listing:\n%s", out)
+	}
+
+	if err := dbg.ExecuteCommand("disas helper 0"); err == nil {
+		t.Error("expected an error for a zero count")
+	}
+}
+
+func TestDisas_Color(t *testing.T) {
+	dbg := loadDebugProgram(t, disasProgram)
+	dbg.Color = true
+
+	if err := dbg.ExecuteCommand("disas"); err != nil {
+		t.Fatalf("disas failed: %v", err)
+	}
+	first := strings.SplitN(dbg.GetOutput(), "\n", 2)[0]
+	if !strings.HasPrefix(first, "\033[32m=>\033[0m") {
+		t.Errorf("expected a coloured PC marker, got %q", first)
+	}
+}
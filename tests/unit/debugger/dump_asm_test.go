@@ -0,0 +1,165 @@
+package debugger_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+const dumpAsmProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #5
+	MVN R1, #0
+	MOVS R2, R0, LSL #2
+	ADD R3, R0, R1, ASR R2
+	SUBNE R3, R3, #0x100
+	CMP R0, #10
+	TST R0, R1
+	LDR R4, =0x12345678
+	LDR R5, =buffer
+	LDR R6, [R5, #4]
+	STR R6, [R5, #-8]!
+	LDRB R7, [R5], #1
+	STRB R7, [R5, -R0]
+	LDRH R8, [R5, #2]
+	STRH R8, [R5], #-2
+	LDR R9, [R5, R0, LSL #2]
+	PUSH {R4-R7, LR}
+	POP {R4-R7, LR}
+	STMDB R5!, {R0, R1}
+	LDMIA R5, {R2, R3}
+	MUL R10, R0, R1
+	MLA R11, R0, R1, R2
+	QADD R0, R1, R2
+	BL helper
+loop:
+	SUBS R0, R0, #1
+	BNE loop
+	BEQ done
+	B loop
+helper:
+	BX LR
+done:
+	SWI #0x00
+table:
+	.word 0xDEADBEEF, 42
+message:
+	.asciz "hi"
+	.align 2
+buffer:
+	.space 16
+`
+
+// programEnd returns the first address after the program image, including literal pools
+func programEnd(program *parser.Program) uint32 {
+	end := uint32(0)
+	for _, inst := range program.Instructions {
+		end = max(end, inst.Address+4)
+	}
+	for _, dir := range program.Directives {
+		end = max(end, dir.Address+4)
+	}
+	for addr := range program.LiteralPool {
+		end = max(end, addr+4)
+	}
+	return end
+}
+
+func TestDumpAsm_RoundTrip(t *testing.T) {
+	dbg, program := loadDebugProgramWithInfo(t, dumpAsmProgram)
+	start := uint32(0x8000)
+	end := programEnd(program)
+
+	var out bytes.Buffer
+	if err := dbg.DumpAssembly(&out, start, end); err != nil {
+		t.Fatalf("DumpAssembly failed: %v", err)
+	}
+	dumped := out.String()
+
+	for _, want := range []string{"_start:\n", "\tBL helper\n", "\tBNE loop\n", "\tPUSH {R4-R7, LR}\n", "\tSTR R6, [R5, #-8]!\n", "\t.word 0xDEADBEEF\n", "; =0x12345678"} {
+		if !strings.Contains(dumped, want) {
+			t.Errorf("expected dump to contain %q:\n%s", want, dumped)
+		}
+	}
+
+	// Reassemble and compare the encoded bytes
+	reparsed, err := parser.NewParser(dumped, "dump.s").Parse()
+	if err != nil {
+		t.Fatalf("reparse failed: %v\n%s", err, dumped)
+	}
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, reparsed, start); err != nil {
+		t.Fatalf("reassembly failed: %v\n%s", err, dumped)
+	}
+
+	original, err := dbg.VM.Memory.GetBytes(start, end-start)
+	if err != nil {
+		t.Fatalf("GetBytes failed: %v", err)
+	}
+	rebuilt, err := machine.Memory.GetBytes(start, end-start)
+	if err != nil {
+		t.Fatalf("GetBytes failed: %v", err)
+	}
+	for i := 0; i < len(original); i += 4 {
+		if !bytes.Equal(original[i:i+4], rebuilt[i:i+4]) {
+			t.Errorf("word at 0x%08X differs: original % X, reassembled % X", start+uint32(i), original[i:i+4], rebuilt[i:i+4]) // #nosec G115 -- small test offset
+		}
+	}
+}
+
+func TestDumpAsm_UnsupportedEncodingsBecomeWords(t *testing.T) {
+	machine := vm.NewVM()
+	machine.Memory.WriteWord(0x8000, 0xE0810392) // UMULL R0, R1, R2, R3
+	machine.Memory.WriteWord(0x8004, 0xE1280000) // MSR CPSR_f, R0 without the should-be-one bits
+	machine.Memory.WriteWord(0x8008, 0xE3A00000) // MOV R0, #0
+
+	dbg := debugger.NewDebugger(machine)
+
+	var out bytes.Buffer
+	if err := dbg.DumpAssembly(&out, 0x8000, 0x800C); err != nil {
+		t.Fatalf("DumpAssembly failed: %v", err)
+	}
+	dumped := out.String()
+
+	for _, want := range []string{"\t.word 0xE0810392\t; UMULL R0, R1, R2, R3\n", "\t.word 0xE1280000\t; MSR CPSR_f, R0\n", "\tMOV R0, #0\n"} {
+		if !strings.Contains(dumped, want) {
+			t.Errorf("expected dump to contain %q:\n%s", want, dumped)
+		}
+	}
+}
+
+func TestDumpAsmCommand(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	SWI #0
+`)
+
+	path := filepath.Join(t.TempDir(), "out.s")
+	if err := dbg.ExecuteCommand("dump-asm _start 0x8008 " + path); err != nil {
+		t.Fatalf("dump-asm failed: %v", err)
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- test temp file
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(data), "\tMOV R0, #1\n\tSWI #0\n") {
+		t.Errorf("unexpected dump output:\n%s", data)
+	}
+
+	if err := dbg.ExecuteCommand("dump-asm 0x8008 0x8000"); err == nil {
+		t.Error("expected error for empty range")
+	}
+	if err := dbg.ExecuteCommand("dump-asm 0x8000"); err == nil {
+		t.Error("expected usage error")
+	}
+}
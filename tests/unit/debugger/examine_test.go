@@ -0,0 +1,94 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const examineProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #1
+	ADD R1, R0, #2
+	SWI #0
+msg:
+	.asciz "Hi\n"
+vals:
+	.word 0x12345678, 0xDEADBEEF, 1, -2, 5
+`
+
+// examine runs an x command and returns its output
+func examine(t *testing.T, command string) string {
+	t.Helper()
+	dbg := loadDebugProgram(t, examineProgram)
+	if err := dbg.ExecuteCommand(command); err != nil {
+		t.Fatalf("%s failed: %v", command, err)
+	}
+	return dbg.GetOutput()
+}
+
+func TestExamine_HexWords(t *testing.T) {
+	want := "0x00008010: 0x12345678 0xDEADBEEF 0x00000001 0xFFFFFFFE\n"
+	if out := examine(t, "x/4xw vals"); out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestExamine_WrapsLines(t *testing.T) {
+	want := "0x00008010: 305419896 -559038737 1 -2\n0x00008020: 5\n"
+	if out := examine(t, "x/5dw vals"); out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestExamine_Units(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"x/3xh vals", "0x00008010: 0x5678 0x1234 0xBEEF\n"},
+		{"x/2xb vals", "0x00008010: 0x78 0x56\n"},
+		{"x/hx vals", "0x00008010: 0x5678\n"},    // Unit and format in either order
+		{"x/2dh vals+12", "0x0000801C: -2 -1\n"}, // Address expressions are evaluated
+		{"x/2tb vals", "0x00008010: 01111000 01010110\n"},
+		{"x/3c msg", "0x0000800C: 72 'H' 105 'i' 10 '\\n'\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if out := examine(t, tt.command); out != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestExamine_Instructions(t *testing.T) {
+	out := examine(t, "x/2i _start")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out)
+	}
+	if !strings.Contains(lines[0], "0x00008000") || !strings.Contains(lines[0], "MOV R0, #1") {
+		t.Errorf("unexpected first line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "0x00008004") || !strings.Contains(lines[1], "ADD R1, R0, #2") {
+		t.Errorf("unexpected second line %q", lines[1])
+	}
+}
+
+func TestExamine_String(t *testing.T) {
+	want := "0x0000800C: \"Hi\\n\"\n"
+	if out := examine(t, "x/s msg"); out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestExamine_InvalidFormat(t *testing.T) {
+	dbg := loadDebugProgram(t, examineProgram)
+	if err := dbg.ExecuteCommand("x/4q vals"); err == nil || !strings.Contains(err.Error(), "invalid format letter 'q'") {
+		t.Errorf("expected an invalid format error, got %v", err)
+	}
+	if err := dbg.ExecuteCommand("x/0x vals"); err == nil {
+		t.Error("expected a zero count to be rejected")
+	}
+}
@@ -0,0 +1,55 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExplainCommand tests that explain describes the instruction at PC or a label, and
+// that data words are not explained as instructions
+func TestExplainCommand(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	CMP R0, #0
+check:
+	ADDEQS R0, R1, #1
+	SWI #0x00
+value:
+	.word 0x02910001
+`)
+
+	if err := dbg.ExecuteCommand("explain check"); err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	for _, want := range []string{
+		"0x00008004: ADDEQS R0, R1, #1",
+		"Condition: EQ: runs only if Z is set",
+		"Operation: R0 = R1 + 1",
+		"R1 (operand 1), the value 1 (operand 2)",
+		"Flags:     S suffix sets N and Z",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	if err := dbg.ExecuteCommand("explain"); err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "0x00008000: CMP R0, #0") || !strings.Contains(out, "always sets") {
+		t.Errorf("expected the CMP at PC to be explained, got:\n%s", out)
+	}
+
+	if err := dbg.ExecuteCommand("explain value"); err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "is data, not an instruction") {
+		t.Errorf("expected the data word not to be explained, got:\n%s", out)
+	}
+
+	if err := dbg.ExecuteCommand("explain 1 2"); err == nil {
+		t.Error("expected an error for too many arguments")
+	}
+}
@@ -0,0 +1,149 @@
+package debugger_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runToBreakpoint sets a breakpoint, runs to it and fails unless it is hit
+func runToBreakpoint(t *testing.T, dbg *debugger.Debugger, breakCmd string) {
+	t.Helper()
+	if err := dbg.ExecuteCommand(breakCmd); err != nil {
+		t.Fatalf("%s failed: %v", breakCmd, err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); !strings.HasPrefix(reason, "breakpoint") {
+		t.Fatalf("expected breakpoint stop, got %q", reason)
+	}
+	dbg.Breakpoints.Clear()
+}
+
+func TestFinish_LeafFunction(t *testing.T) {
+	dbg := loadBacktraceProgram(t, backtraceProgram)
+	runToBreakpoint(t, dbg, "break leaf")
+
+	if err := dbg.ExecuteCommand("finish"); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "Run till exit from leaf") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if reason := runDebugger(t, dbg); reason != "finish complete" {
+		t.Fatalf("expected finish complete, got %q", reason)
+	}
+
+	// The instruction after BL leaf in middle
+	if want := dbg.Symbols["middle"] + 24; dbg.VM.CPU.PC != want {
+		t.Errorf("expected to stop at 0x%08X, got 0x%08X", want, dbg.VM.CPU.PC)
+	}
+	if dbg.VM.CPU.R[0] != 4 {
+		t.Errorf("expected leaf to have run, R0=%d", dbg.VM.CPU.R[0])
+	}
+}
+
+func TestFinish_UsesSavedReturnAddress(t *testing.T) {
+	dbg := loadBacktraceProgram(t, backtraceProgram)
+
+	// After BL helper returns, LR points into outer rather than back to _start
+	runToBreakpoint(t, dbg, "break middle")
+	if err := dbg.ExecuteCommand("finish"); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	runDebugger(t, dbg)
+	if err := dbg.ExecuteCommand("finish"); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "finish complete" {
+		t.Fatalf("expected finish complete, got %q", reason)
+	}
+
+	if want := dbg.Symbols["_start"] + 8; dbg.VM.CPU.PC != want {
+		t.Errorf("expected to return after BL outer at 0x%08X, got 0x%08X", want, dbg.VM.CPU.PC)
+	}
+}
+
+func TestFinish_Recursion(t *testing.T) {
+	dbg := loadBacktraceProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #4
+	BL fact
+	SWI #0
+fact:
+	PUSH {R4, LR}
+	MOV R4, R0
+	CMP R0, #1
+	MOVLE R0, #1
+	BLE done
+	SUB R0, R0, #1
+	BL fact
+after:
+	MUL R0, R4, R0
+done:
+	POP {R4, PC}
+`)
+
+	// Stop in fact(2), called from fact(3)
+	runToBreakpoint(t, dbg, "break fact ignore 2")
+	if err := dbg.ExecuteCommand("finish"); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "finish complete" {
+		t.Fatalf("expected finish complete, got %q", reason)
+	}
+
+	// fact(1) also returns to "after", but one frame deeper; it must not stop the finish
+	if dbg.VM.CPU.PC != dbg.Symbols["after"] {
+		t.Fatalf("expected to stop at after, got 0x%08X", dbg.VM.CPU.PC)
+	}
+	if dbg.VM.CPU.R[0] != 2 || dbg.VM.CPU.R[4] != 3 {
+		t.Errorf("expected to be back in fact(3) with fact(2)=2, got R0=%d R4=%d", dbg.VM.CPU.R[0], dbg.VM.CPU.R[4])
+	}
+}
+
+func TestFinish_OutermostFrame(t *testing.T) {
+	dbg := loadBacktraceProgram(t, backtraceProgram)
+	dbg.VM.CPU.PC = dbg.Symbols["_start"]
+
+	if err := dbg.ExecuteCommand("finish"); err == nil || !strings.Contains(err.Error(), "outermost frame") {
+		t.Errorf("expected an outermost frame error, got %v", err)
+	}
+	if dbg.Running {
+		t.Error("finish should not start running without a caller")
+	}
+}
+
+func TestFinish_NeverReturnsHitsInstructionLimit(t *testing.T) {
+	dbg := loadBacktraceProgram(t, `
+	.org 0x8000
+_start:
+	BL forever
+	SWI #0
+forever:
+	B forever
+`)
+	runToBreakpoint(t, dbg, "break forever")
+	dbg.VM.InstructionLimit = dbg.VM.CPU.Instructions + 100
+
+	if err := dbg.ExecuteCommand("finish"); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	var err error
+	for dbg.Running {
+		if shouldBreak, reason := dbg.ShouldBreak(); shouldBreak {
+			t.Fatalf("unexpected stop: %s", reason)
+		}
+		if err = dbg.VM.Step(); err != nil {
+			dbg.Running = false
+		}
+	}
+	if !errors.Is(err, vm.ErrInstructionLimit) {
+		t.Errorf("expected the instruction limit to stop the finish, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package debugger_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// rspClient is a minimal gdb remote serial protocol client for tests
+type rspClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *rspClient) send(data string) {
+	c.t.Helper()
+	var sum byte
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	if _, err := fmt.Fprintf(c.conn, "$%s#%02x", data, sum); err != nil {
+		c.t.Fatalf("send failed: %v", err)
+	}
+	ack, err := c.reader.ReadByte()
+	if err != nil || ack != '+' {
+		c.t.Fatalf("expected '+' ack, got %q (err %v)", ack, err)
+	}
+}
+
+func (c *rspClient) receive() string {
+	c.t.Helper()
+	if _, err := c.reader.ReadString('$'); err != nil {
+		c.t.Fatalf("receive failed: %v", err)
+	}
+	body, err := c.reader.ReadString('#')
+	if err != nil {
+		c.t.Fatalf("receive failed: %v", err)
+	}
+	cs := make([]byte, 2)
+	if _, err := c.reader.Read(cs); err != nil {
+		c.t.Fatalf("checksum read failed: %v", err)
+	}
+	if _, err := c.conn.Write([]byte{'+'}); err != nil {
+		c.t.Fatalf("ack failed: %v", err)
+	}
+	return strings.TrimSuffix(body, "#")
+}
+
+func (c *rspClient) request(data string) string {
+	c.t.Helper()
+	c.send(data)
+	return c.receive()
+}
+
+// startGDBStub loads a small program and connects a client to a stub on a random port
+func startGDBStub(t *testing.T) (*rspClient, *vm.VM) {
+	t.Helper()
+
+	machine := vm.NewVM()
+	machine.CPU.PC = 0x8000
+	machine.Memory.WriteWord(0x8000, 0xE3A00005) // MOV R0, #5
+	machine.Memory.WriteWord(0x8004, 0xE2801001) // ADD R1, R0, #1
+	machine.Memory.WriteWord(0x8008, 0xE3A02007) // MOV R2, #7
+	machine.Memory.WriteWord(0x800C, 0xEF000000) // SWI #0 (exit)
+
+	stub := debugger.NewGDBStub(debugger.NewDebugger(machine))
+	if err := stub.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = stub.Close() })
+
+	go func() { _ = stub.Serve() }()
+
+	conn, err := net.DialTimeout("tcp", stub.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &rspClient{t: t, conn: conn, reader: bufio.NewReader(conn)}, machine
+}
+
+func TestGDBStub_ReadRegistersAndStep(t *testing.T) {
+	client, machine := startGDBStub(t)
+
+	if reply := client.request("?"); reply != "S05" {
+		t.Errorf("expected halt reason S05, got %q", reply)
+	}
+
+	regs := client.request("g")
+	// 16 core regs + 8 FPA regs + fps + cpsr, hex encoded
+	if len(regs) != (16*4+8*12+4+4)*2 {
+		t.Fatalf("unexpected 'g' reply length %d", len(regs))
+	}
+	if pc := regs[15*8 : 16*8]; pc != "00800000" {
+		t.Errorf("expected PC 00800000 (little-endian 0x8000), got %s", pc)
+	}
+
+	if reply := client.request("s"); reply != "S05" {
+		t.Errorf("expected S05 after step, got %q", reply)
+	}
+	if machine.CPU.R[0] != 5 || machine.CPU.PC != 0x8004 {
+		t.Errorf("expected R0=5 PC=0x8004 after step, got R0=%d PC=0x%X", machine.CPU.R[0], machine.CPU.PC)
+	}
+
+	regs = client.request("g")
+	if r0 := regs[0:8]; r0 != "05000000" {
+		t.Errorf("expected R0 05000000, got %s", r0)
+	}
+}
+
+func TestGDBStub_BreakpointAndContinue(t *testing.T) {
+	client, machine := startGDBStub(t)
+
+	if reply := client.request("Z0,8008,4"); reply != "OK" {
+		t.Fatalf("expected OK for Z0, got %q", reply)
+	}
+	if reply := client.request("c"); reply != "S05" {
+		t.Fatalf("expected S05 at breakpoint, got %q", reply)
+	}
+	if machine.CPU.PC != 0x8008 || machine.CPU.R[1] != 6 {
+		t.Errorf("expected stop at 0x8008 with R1=6, got PC=0x%X R1=%d", machine.CPU.PC, machine.CPU.R[1])
+	}
+
+	if reply := client.request("z0,8008,4"); reply != "OK" {
+		t.Fatalf("expected OK for z0, got %q", reply)
+	}
+	if reply := client.request("c"); reply != "W05" { // exit code comes from R0
+		t.Errorf("expected W05 on exit, got %q", reply)
+	}
+}
+
+func TestGDBStub_Memory(t *testing.T) {
+	client, machine := startGDBStub(t)
+
+	if reply := client.request("m8000,4"); reply != "0500a0e3" {
+		t.Errorf("expected MOV R0,#5 bytes 0500a0e3, got %q", reply)
+	}
+
+	if reply := client.request("M20000,4:efbeadde"); reply != "OK" {
+		t.Fatalf("expected OK for M, got %q", reply)
+	}
+	value, err := machine.Memory.ReadWord(0x20000)
+	if err != nil || value != 0xDEADBEEF {
+		t.Errorf("expected 0xDEADBEEF at 0x20000, got 0x%08X (err %v)", value, err)
+	}
+
+	if reply := client.request("m10,4"); !strings.HasPrefix(reply, "E") {
+		t.Errorf("expected error reading unmapped memory, got %q", reply)
+	}
+}
+
+func TestGDBStub_WriteRegister(t *testing.T) {
+	client, machine := startGDBStub(t)
+
+	if reply := client.request("P3=78563412"); reply != "OK" {
+		t.Fatalf("expected OK for P, got %q", reply)
+	}
+	if machine.CPU.R[3] != 0x12345678 {
+		t.Errorf("expected R3=0x12345678, got 0x%08X", machine.CPU.R[3])
+	}
+	if reply := client.request("p3"); reply != "78563412" {
+		t.Errorf("expected p3 78563412, got %q", reply)
+	}
+}
@@ -0,0 +1,93 @@
+package debugger_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/loader"
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// loadDebugProgram assembles source into a fresh VM and returns a debugger with its
+// source map and line index loaded, as main.go does
+func loadDebugProgram(t *testing.T, source string) *debugger.Debugger {
+	t.Helper()
+	dbg, _ := loadDebugProgramWithInfo(t, source)
+	return dbg
+}
+
+// loadDebugProgramWithInfo is loadDebugProgram that also loads labels, data directives and
+// the literal pool, returning the parsed program for inspection
+func loadDebugProgramWithInfo(t *testing.T, source string) (*debugger.Debugger, *parser.Program) {
+	t.Helper()
+
+	p := parser.NewParser(source, "test.s")
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	machine := vm.NewVM()
+	if err := loader.LoadProgramIntoVM(machine, program, 0x8000); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	dbg := debugger.NewDebugger(machine)
+	symbols := make(map[string]uint32)
+	for name, symbol := range program.SymbolTable.GetAllSymbols() {
+		if symbol.Type == parser.SymbolLabel {
+			symbols[name] = symbol.Value
+		}
+	}
+	dbg.LoadSymbols(symbols)
+	dbg.LoadLiteralPool(program.LiteralPool)
+
+	sourceMap := make(map[uint32]string)
+	addressLines := make(map[uint32]debugger.SourceLocation)
+	for _, inst := range program.Instructions {
+		sourceMap[inst.Address] = inst.RawLine
+		addressLines[inst.Address] = debugger.SourceLocation{File: inst.Pos.Filename, Line: inst.Pos.Line}
+	}
+	for _, dir := range program.Directives {
+		switch dir.Name {
+		case ".word", ".byte", ".ascii", ".asciz", ".space":
+			sourceMap[dir.Address] = "[DATA]" + dir.RawLine
+		}
+	}
+	dbg.LoadSourceMap(sourceMap)
+	dbg.LoadLineIndex(loader.LineIndex(program))
+	dbg.LoadAddressLines(addressLines)
+	return dbg, program
+}
+
+// runDebugger drives the debugger the way the command-line loop does, returning the stop reason
+func runDebugger(t *testing.T, dbg *debugger.Debugger) string {
+	t.Helper()
+
+	for i := 0; dbg.Running; i++ {
+		if i > 1000 {
+			t.Fatal("debugger did not stop")
+		}
+		if dbg.StepMode != debugger.StepSingle {
+			if shouldBreak, reason := dbg.ShouldBreak(); shouldBreak {
+				dbg.Running = false
+				return reason
+			}
+		}
+		if err := dbg.VM.Step(); err != nil {
+			dbg.Running = false
+			if dbg.VM.State == vm.StateHalted {
+				return "exited"
+			}
+			t.Fatalf("runtime error: %v", err)
+		}
+		if dbg.StepMode == debugger.StepSingle {
+			if shouldBreak, reason := dbg.ShouldBreak(); shouldBreak {
+				dbg.Running = false
+				return reason
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package debugger_test
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const infoJSONProgram = `
+.org 0x8000
+_start:
+	MOV R0, #5
+loop:
+	STR R0, [R1]
+	SWI #0x00
+value:
+	.word 0
+`
+
+// decodeJSONOutput runs command and decodes its output as a JSON array of objects
+func decodeJSONOutput(t *testing.T, run func(string) (string, error), command string) []map[string]any {
+	t.Helper()
+	out, err := run(command)
+	if err != nil {
+		t.Fatalf("%s failed: %v", command, err)
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("%s printed invalid JSON: %v\n%s", command, err, out)
+	}
+	return entries
+}
+
+// expectFields checks that entry holds exactly the expected keys and values
+func expectFields(t *testing.T, entry map[string]any, want map[string]any) {
+	t.Helper()
+	if len(entry) != len(want) {
+		t.Errorf("expected %d fields, got %d: %v", len(want), len(entry), entry)
+	}
+	for key, value := range want {
+		got, ok := entry[key]
+		if !ok {
+			t.Errorf("missing field %q in %v", key, entry)
+			continue
+		}
+		if got != value {
+			t.Errorf("%s = %v (%T), want %v (%T)", key, got, got, value, value)
+		}
+	}
+}
+
+func TestInfoBreakpointsJSON(t *testing.T) {
+	dbg := loadDebugProgram(t, infoJSONProgram)
+	run := func(command string) (string, error) {
+		err := dbg.ExecuteCommand(command)
+		return dbg.GetOutput(), err
+	}
+	if _, err := run("break loop if R0 == 5"); err != nil {
+		t.Fatalf("break failed: %v", err)
+	}
+
+	entries := decodeJSONOutput(t, run, "info breakpoints json")
+	if len(entries) != 1 {
+		t.Fatalf("expected one breakpoint, got %v", entries)
+	}
+	expectFields(t, entries[0], map[string]any{
+		"id":          float64(1),
+		"address":     float64(0x8004),
+		"condition":   "R0 == 5",
+		"enabled":     true,
+		"temporary":   false,
+		"ignoreCount": float64(0),
+		"hitCount":    float64(0),
+	})
+
+	// An empty list is still a JSON array
+	if _, err := run("delete 1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if out, _ := run("info b json"); out != "[]\n" {
+		t.Errorf("expected an empty array, got %q", out)
+	}
+}
+
+func TestInfoWatchpointsJSON(t *testing.T) {
+	dbg := loadDebugProgram(t, infoJSONProgram)
+	run := func(command string) (string, error) {
+		err := dbg.ExecuteCommand(command)
+		return dbg.GetOutput(), err
+	}
+	if _, err := run("watch [value]"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if _, err := run("watch R2"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	entries := decodeJSONOutput(t, run, "info watchpoints json")
+	if len(entries) != 2 {
+		t.Fatalf("expected two watchpoints, got %v", entries)
+	}
+	expectFields(t, entries[0], map[string]any{
+		"id":         float64(1),
+		"expression": "[value]",
+		"kind":       "memory",
+		"type":       "write",
+		"address":    float64(0x800C),
+		"length":     float64(0),
+		"enabled":    true,
+		"hitCount":   float64(0),
+		"lastValue":  float64(0),
+	})
+	if entries[1]["kind"] != "register" || entries[1]["address"] != float64(0) {
+		t.Errorf("expected a register watchpoint with no address, got %v", entries[1])
+	}
+}
@@ -0,0 +1,159 @@
+package debugger_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestFillAndSearch tests that fill writes a repeated value through Memory and that
+// search reports every address holding it
+func TestFillAndSearch(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+`)
+	dbg.Symbols["buffer"] = 0x20000
+
+	if err := dbg.ExecuteCommand("fill buffer 4 0xAA"); err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "Filled 0x00020000-0x00020003 (4 bytes) with 0xAA") {
+		t.Errorf("unexpected fill output: %q", out)
+	}
+	for addr := uint32(0x20000); addr < 0x20004; addr++ {
+		if b, _ := dbg.VM.Memory.ReadByteAt(addr); b != 0xAA {
+			t.Errorf("expected 0xAA at 0x%08X, got 0x%02X", addr, b)
+		}
+	}
+	if b, _ := dbg.VM.Memory.ReadByteAt(0x20004); b != 0 {
+		t.Errorf("fill wrote past the end of the range: 0x%02X", b)
+	}
+
+	if err := dbg.ExecuteCommand("search 0x20000 16 0xAA"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	for _, want := range []string{
+		"Found 0xAA at 4 address(es) in 0x00020000-0x0002000F:",
+		"  0x00020000 <buffer>\n",
+		"  0x00020001 <buffer+1>\n",
+		"  0x00020002 <buffer+2>\n",
+		"  0x00020003 <buffer+3>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in search output:\n%s", want, out)
+		}
+	}
+
+	// A halfword pattern matches at any byte offset, overlapping matches included
+	if err := dbg.ExecuteCommand("search buffer 8 0xAAAA half"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "at 3 address(es)") {
+		t.Errorf("expected three overlapping halfword matches, got:\n%s", out)
+	}
+
+	if err := dbg.ExecuteCommand("search buffer 8 0x55"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "0x55 not found") {
+		t.Errorf("expected no match for 0x55, got:\n%s", out)
+	}
+}
+
+// TestFillWords tests fill with a word unit, stored in memory byte order
+func TestFillWords(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+`)
+
+	if err := dbg.ExecuteCommand("fill 0x20000 8 0xDEADBEEF word"); err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	for _, addr := range []uint32{0x20000, 0x20004} {
+		if w, _ := dbg.VM.Memory.ReadWord(addr); w != 0xDEADBEEF {
+			t.Errorf("expected 0xDEADBEEF at 0x%08X, got 0x%08X", addr, w)
+		}
+	}
+	dbg.GetOutput()
+
+	if err := dbg.ExecuteCommand("search 0x20000 8 0xDEADBEEF word"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	if !strings.Contains(out, "  0x00020000\n") || !strings.Contains(out, "  0x00020004\n") {
+		t.Errorf("expected both words to be found, got:\n%s", out)
+	}
+}
+
+// TestFillAndSearch_Errors tests argument checking and that fill goes through the
+// memory permission checks
+func TestFillAndSearch_Errors(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+`)
+	dbg.VM.Memory.MakeCodeReadOnly()
+
+	for _, cmd := range []string{
+		"fill 0x20000 4",            // missing value
+		"fill 0x20000 0 0xAA",       // empty range
+		"fill 0x20000 6 0 word",     // not a whole number of words
+		"fill 0x20000 4 0x100",      // value too big for a byte
+		"fill 0x20000 4 1 quad",     // unknown size
+		"fill 0xFFFFFFFF 2 0",       // runs past the end of memory
+		"fill _start 4 0",           // read-only code segment
+		"search 0x20000 4",          // missing pattern
+		"search 0x0FFFFFFC 16 0xAA", // unmapped memory
+	} {
+		if err := dbg.ExecuteCommand(cmd); err == nil {
+			t.Errorf("expected %q to fail", cmd)
+		}
+	}
+	if w, _ := dbg.VM.Memory.ReadWord(0x8000); w != 0xEF000000 {
+		t.Errorf("fill changed read-only code: 0x%08X", w)
+	}
+}
+
+// TestSearchHugeLength tests that a search length far past the mapped memory fails at the
+// first unmapped byte without allocating a buffer for the whole range
+func TestSearchHugeLength(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+`)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := dbg.ExecuteCommand("search 0x20000 0xFFF00000 0xAA")
+	runtime.ReadMemStats(&after)
+	if err == nil || !strings.Contains(err.Error(), "not mapped") {
+		t.Errorf("expected the search to stop at unmapped memory, got %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("search allocated %d bytes", allocated)
+	}
+}
+
+// TestSearchManyMatches tests that search prints the first matches and counts the rest
+func TestSearchManyMatches(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	SWI #0x00
+`)
+
+	if err := dbg.ExecuteCommand("search 0x20000 0x100 0"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	if !strings.Contains(out, "Found 0x00 at 256 address(es)") || !strings.Contains(out, "  ... and 156 more\n") {
+		t.Errorf("expected 256 matches with the rest summarised, got:\n%s", out)
+	}
+}
@@ -0,0 +1,54 @@
+package debugger_test
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestInfoMemory_ListsSegments(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV SP, #0x50000
+	MOV R0, #16
+	SWI #0x20
+	STR R0, [SP, #-4]!
+	MOV R0, #0
+	SWI #0
+`)
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" {
+		t.Fatalf("expected the program to exit, got %q", reason)
+	}
+
+	dbg.GetOutput()
+	if err := dbg.ExecuteCommand("info memory"); err != nil {
+		t.Fatalf("info memory failed: %v", err)
+	}
+	out := dbg.GetOutput()
+
+	for _, want := range []string{
+		`code +0x00008000 0x00017FFF +65536 rwx +\d+ +\d+ +6\n`,
+		`data +0x00020000 0x0002FFFF +65536 rw- `,
+		`stack +0x00040000 0x0004FFFF +65536 rw- +0 +1 +0\n`,
+		`Heap: 16 bytes in 1 blocks`,
+	} {
+		if !regexp.MustCompile(want).MatchString(out) {
+			t.Errorf("expected output to match %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestInfoMemory_ReadOnlyCode(t *testing.T) {
+	dbg := loadDebugProgram(t, "\t.org 0x8000\n_start:\n\tSWI #0\n")
+	dbg.VM.Memory.MakeCodeReadOnly()
+
+	if err := dbg.ExecuteCommand("info memory"); err != nil {
+		t.Fatalf("info memory failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !regexp.MustCompile(`code +0x00008000 0x00017FFF +65536 r-x `).MatchString(out) {
+		t.Errorf("expected a read-only code segment, got:\n%s", out)
+	}
+}
@@ -0,0 +1,125 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// printWith runs a print command with R0 = 0xFFFFFFF6 (-10) and returns its output
+func printWith(t *testing.T, command string) string {
+	t.Helper()
+	dbg := debugger.NewDebugger(vm.NewVM())
+	dbg.VM.CPU.R[0] = 0xFFFFFFF6
+	if err := dbg.ExecuteCommand(command); err != nil {
+		t.Fatalf("%s failed: %v", command, err)
+	}
+	return dbg.GetOutput()
+}
+
+func TestPrint_SignedAndUnsigned(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"print signed(R0)", "$1 = 0xFFFFFFF6 (-10)\n"},
+		{"print unsigned(R0)", "$1 = 0xFFFFFFF6 (4294967286)\n"},
+		{"print signed(R0 + 20)", "$1 = 0x0000000A (10)\n"},
+		{"print (int32)R0", "$1 = 0xFFFFFFF6 (-10)\n"},
+		{"print (int8)R0", "$1 = 0xF6 (-10)\n"},
+		{"print (uint8)R0", "$1 = 0xF6 (246)\n"},
+		{"print (int16)R0", "$1 = 0xFFF6 (-10)\n"},
+		{"print (uint16)R0", "$1 = 0xFFF6 (65526)\n"},
+		// Untyped results keep the existing format
+		{"print R0", "$1 = 0xFFFFFFF6 (out of int32 range: 4294967286)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if out := printWith(t, tt.command); out != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestPrint_FormatSpecifiers(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"print/x R0", "$1 = 0xFFFFFFF6\n"},
+		{"print/d R0", "$1 = -10\n"},
+		{"print/u R0", "$1 = 4294967286\n"},
+		{"print/t R0", "$1 = 11111111111111111111111111110110\n"},
+		{"print/o R0", "$1 = 037777777766\n"},
+		{"p/d R0", "$1 = -10\n"},
+		{"print /d R0", "$1 = -10\n"},
+		{"print/x (int8)R0", "$1 = 0xF6\n"},
+		{"print/t (uint8)R0", "$1 = 11110110\n"},
+		{"print/u (int8)R0", "$1 = 246\n"},
+		{"print/d (uint16)R0", "$1 = -10\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if out := printWith(t, tt.command); out != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestPrint_CastResultIsUsable(t *testing.T) {
+	eval := debugger.NewExpressionEvaluator()
+	machine := vm.NewVM()
+	machine.CPU.R[0] = 0x1234FFF6
+
+	tests := []struct {
+		expr string
+		want uint32
+	}{
+		{"(int8)R0", 0xFFFFFFF6},
+		{"(uint8)R0", 0xF6},
+		{"(int16)R0 + 10", 0},
+		{"signed(R0)", 0x1234FFF6},
+	}
+	for _, tt := range tests {
+		got, err := eval.EvaluateExpression(tt.expr, machine, map[string]uint32{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = 0x%08X, want 0x%08X", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestPrint_ParenthesizedSymbolIsNotACast(t *testing.T) {
+	eval := debugger.NewExpressionEvaluator()
+	symbols := map[string]uint32{"int8": 0x100}
+
+	got, err := eval.EvaluateExpression("(int8) + 1", vm.NewVM(), symbols)
+	if err != nil {
+		t.Fatalf("EvaluateExpression() error = %v", err)
+	}
+	if got != 0x101 {
+		t.Errorf("expected label int8 + 1 = 0x101, got 0x%X", got)
+	}
+}
+
+func TestPrint_Errors(t *testing.T) {
+	dbg := debugger.NewDebugger(vm.NewVM())
+	for _, command := range []string{"print/z R0", "print/x", "print sqrt(R0)", "print signed(R0"} {
+		err := dbg.ExecuteCommand(command)
+		if err == nil {
+			t.Errorf("%s: expected an error", command)
+			continue
+		}
+		if command == "print/z R0" && !strings.Contains(err.Error(), "invalid print format") {
+			t.Errorf("%s: unexpected error %v", command, err)
+		}
+	}
+}
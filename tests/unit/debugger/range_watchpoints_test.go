@@ -0,0 +1,145 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestRangeWatchpoint_Boundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		address uint32
+		size    uint32
+		hit     bool
+	}{
+		{"word just before", 0x0FFC, 4, false},
+		{"byte just after", 0x1010, 1, false},
+		{"word overlapping the start", 0x0FFE, 4, true},
+		{"first byte", 0x1000, 1, true},
+		{"middle word", 0x1008, 4, true},
+		{"last byte", 0x100F, 1, true},
+		{"halfword overlapping the end", 0x100F, 2, true},
+	}
+	machine := vm.NewVM()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := debugger.NewWatchpointManager()
+			wp := wm.AddRangeWatchpoint(debugger.WatchWrite, "[0x1000, 16]", 0x1000, 16)
+
+			wm.RecordAccess(tt.address, tt.size, true)
+			hit, changed := wm.CheckWatchpoints(machine)
+			if (hit != nil && changed) != tt.hit {
+				t.Fatalf("write of %d bytes at 0x%04X: expected hit=%v, got %v", tt.size, tt.address, tt.hit, hit)
+			}
+			if tt.hit && (wp.HitCount != 1 || wp.LastAccess != tt.address || wp.LastAccessSize != tt.size) {
+				t.Errorf("unexpected hit details: %+v", wp)
+			}
+		})
+	}
+}
+
+func TestRangeWatchpoint_AccessType(t *testing.T) {
+	machine := vm.NewVM()
+	tests := []struct {
+		wpType      debugger.WatchType
+		read, write bool
+	}{
+		{debugger.WatchWrite, false, true},
+		{debugger.WatchRead, true, false},
+		{debugger.WatchReadWrite, true, true},
+	}
+	for _, tt := range tests {
+		wm := debugger.NewWatchpointManager()
+		wm.AddRangeWatchpoint(tt.wpType, "[0x1000, 16]", 0x1000, 16)
+
+		wm.RecordAccess(0x1004, 4, false)
+		if hit, _ := wm.CheckWatchpoints(machine); (hit != nil) != tt.read {
+			t.Errorf("type %d: expected read hit=%v", tt.wpType, tt.read)
+		}
+		wm.RecordAccess(0x1004, 4, true)
+		if hit, _ := wm.CheckWatchpoints(machine); (hit != nil) != tt.write {
+			t.Errorf("type %d: expected write hit=%v", tt.wpType, tt.write)
+		}
+	}
+}
+
+const rangeWatchProgram = `
+	.org 0x8000
+_start:
+	LDR R0, =buffer
+	MOV R1, #0xAA
+	STR R1, [R0, #-4]
+	STR R1, [R0, #16]
+	STRB R1, [R0, #7]
+	LDR R2, [R0, #12]
+	ADD R3, R0, #12
+	STMIA R3, {R1, R2}
+	SWI #0
+	.data
+before:	.word 0
+buffer:	.space 16
+after:	.word 0
+`
+
+// runRangeWatch runs to the next stop and returns the reason and the stopping PC
+func runRangeWatch(t *testing.T, dbg *debugger.Debugger, cmd string) (string, uint32) {
+	t.Helper()
+	if err := dbg.ExecuteCommand(cmd); err != nil {
+		t.Fatalf("%s failed: %v", cmd, err)
+	}
+	reason := runDebugger(t, dbg)
+	return reason, dbg.VM.CPU.PC
+}
+
+func TestRangeWatchpoint_StopsOnStoresIntoRange(t *testing.T) {
+	dbg := loadDebugProgram(t, rangeWatchProgram)
+	if err := dbg.ExecuteCommand("watch [buffer, 16]"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	// The stores just outside the buffer are ignored; the byte store into the middle stops
+	reason, pc := runRangeWatch(t, dbg, "run")
+	if want := "watchpoint 1: [buffer, 16] write at 0x"; !strings.HasPrefix(reason, want) || !strings.HasSuffix(reason, "(1 bytes)") {
+		t.Fatalf("expected a 1-byte write hit, got %q", reason)
+	}
+	if pc != 0x8014 {
+		t.Errorf("expected to stop after STRB at 0x8014, got 0x%08X", pc)
+	}
+
+	// The load is not a write; STMIA writes its first word to the last four bytes
+	reason, pc = runRangeWatch(t, dbg, "continue")
+	if !strings.Contains(reason, "(4 bytes)") || pc != 0x8020 {
+		t.Errorf("expected the STMIA write to stop at 0x8020, got %q at 0x%08X", reason, pc)
+	}
+
+	if reason, _ = runRangeWatch(t, dbg, "continue"); reason != "exited" {
+		t.Errorf("expected the program to exit, got %q", reason)
+	}
+}
+
+func TestRangeWatchpoint_ReadWatch(t *testing.T) {
+	dbg := loadDebugProgram(t, rangeWatchProgram)
+	if err := dbg.ExecuteCommand("rwatch [buffer + 12, 4]"); err != nil {
+		t.Fatalf("rwatch failed: %v", err)
+	}
+
+	reason, pc := runRangeWatch(t, dbg, "run")
+	if !strings.Contains(reason, "read at") || pc != 0x8018 {
+		t.Errorf("expected the LDR to stop at 0x8018, got %q at 0x%08X", reason, pc)
+	}
+}
+
+func TestRangeWatchpoint_InvalidRange(t *testing.T) {
+	dbg := loadDebugProgram(t, rangeWatchProgram)
+	for _, expr := range []string{"[buffer, 0]", "[0xFFFFFFF0, 32]", "[nowhere, 4]"} {
+		if err := dbg.ExecuteCommand("watch " + expr); err == nil {
+			t.Errorf("expected an error for %s", expr)
+		}
+	}
+	if dbg.Watchpoints.Count() != 0 {
+		t.Errorf("expected no watchpoints, got %d", dbg.Watchpoints.Count())
+	}
+}
@@ -0,0 +1,87 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const runToProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #0
+loop:
+	ADD R0, R0, #1
+	CMP R0, #3
+	BNE loop
+done:
+	MOV R1, R0
+	SWI #0
+`
+
+func TestRunTo_StopsAtLabel(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+
+	if err := dbg.ExecuteCommand("run-to done"); err != nil {
+		t.Fatalf("run-to failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "Running to 0x00008010") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if reason := runDebugger(t, dbg); reason != "run-to complete" {
+		t.Fatalf("expected run-to complete, got %q", reason)
+	}
+	if pc := dbg.VM.CPU.PC; pc != dbg.Symbols["done"] {
+		t.Errorf("expected to stop at done (0x%08X), got 0x%08X", dbg.Symbols["done"], pc)
+	}
+	if dbg.VM.CPU.R[0] != 3 || dbg.Breakpoints.Count() != 0 {
+		t.Errorf("expected the loop to finish without adding breakpoints, R0=%d breakpoints=%d",
+			dbg.VM.CPU.R[0], dbg.Breakpoints.Count())
+	}
+}
+
+func TestRunTo_CurrentAddressGoesRoundLoop(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+	runToBreakpoint(t, dbg, "break loop")
+
+	if err := dbg.ExecuteCommand("run-to loop"); err != nil {
+		t.Fatalf("run-to failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "run-to complete" {
+		t.Fatalf("expected run-to complete, got %q", reason)
+	}
+	if dbg.VM.CPU.R[0] != 1 {
+		t.Errorf("expected one iteration, R0=%d", dbg.VM.CPU.R[0])
+	}
+}
+
+func TestRunTo_UnreachedTargetExitsNormally(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+
+	if err := dbg.ExecuteCommand("run-to 0x9000"); err != nil {
+		t.Fatalf("run-to failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" {
+		t.Fatalf("expected the program to exit, got %q", reason)
+	}
+	if dbg.VM.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", dbg.VM.ExitCode)
+	}
+
+	// A later run does not stop at the stale target
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" {
+		t.Errorf("expected the rerun to exit, got %q", reason)
+	}
+}
+
+func TestRunTo_Usage(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+	if err := dbg.ExecuteCommand("run-to"); err == nil {
+		t.Error("expected a usage error without an address")
+	}
+	if err := dbg.ExecuteCommand("run-to nowhere"); err == nil {
+		t.Error("expected an error for an unknown label")
+	}
+}
@@ -0,0 +1,102 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestSetAssignments tests assigning expressions to registers, flags and memory and
+// reading them back
+func TestSetAssignments(t *testing.T) {
+	machine := vm.NewVM()
+	dbg := debugger.NewDebugger(machine)
+	dbg.Symbols["buffer"] = vm.DataSegmentStart
+
+	for _, cmd := range []string{
+		"set R1 = buffer",
+		"set R0 = 40 + 2",
+		"set [R1] = R0 * 2",
+		"set [R1 + 4] = 0xDEADBEEF",
+		"set byte [R1+8] = 0xFF",
+		"set *buffer+12 = 7",
+		"set CPSR.Z = 1",
+		"set cpsr.c=1",
+		"set PC = 0x8004",
+	} {
+		if err := dbg.ExecuteCommand(cmd); err != nil {
+			t.Fatalf("%q failed: %v", cmd, err)
+		}
+	}
+
+	if machine.CPU.R[0] != 42 || machine.CPU.R[1] != vm.DataSegmentStart {
+		t.Errorf("expected R0=42 and R1=0x%X, got R0=%d R1=0x%X", vm.DataSegmentStart, machine.CPU.R[0], machine.CPU.R[1])
+	}
+	if machine.CPU.PC != 0x8004 {
+		t.Errorf("expected PC=0x8004, got 0x%08X", machine.CPU.PC)
+	}
+	if !machine.CPU.CPSR.Z || !machine.CPU.CPSR.C || machine.CPU.CPSR.N {
+		t.Errorf("expected Z and C set, got %+v", machine.CPU.CPSR)
+	}
+
+	words := map[uint32]uint32{0: 84, 4: 0xDEADBEEF, 8: 0xFF, 12: 7}
+	for offset, want := range words {
+		got, err := machine.Memory.ReadWord(vm.DataSegmentStart + offset)
+		if err != nil || got != want {
+			t.Errorf("word at buffer+%d: expected 0x%X, got 0x%X (%v)", offset, want, got, err)
+		}
+	}
+
+	// Values read back through print match what was written
+	dbg.GetOutput()
+	if err := dbg.ExecuteCommand("print [R1 + 4]"); err != nil {
+		t.Fatalf("print failed: %v", err)
+	}
+	if output := dbg.GetOutput(); !strings.Contains(strings.ToUpper(output), "0XDEADBEEF") {
+		t.Errorf("expected print to read back 0xDEADBEEF, got %q", output)
+	}
+}
+
+// TestSetAssignmentErrors tests that invalid targets and values are rejected without
+// changing state
+func TestSetAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		cmd     string
+		wantErr string
+	}{
+		{"set R0", "usage"},
+		{"set R0 == 1", "usage"},
+		{"set 42 = 1", "cannot assign to 42"},
+		{"set buffer = 1", "cannot assign to buffer"},
+		{"set R16 = 1", "invalid register"},
+		{"set R1x = 1", "invalid register"},
+		{"set byte R0 = 1", "needs a memory target"},
+		{"set byte [0x20000] = 0x100", "does not fit in a byte"},
+		{"set CPSR.Q = 1", "invalid CPSR flag"},
+		{"set CPSR.N = 2", "must be 0 or 1"},
+		{"set PC = 0x8002", "not word aligned"},
+		{"set PC = 0x10000000", "cannot set PC"},
+		{"set [0x10000000] = 1", "0x10000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			machine := vm.NewVM()
+			machine.CPU.PC = 0x8000
+			dbg := debugger.NewDebugger(machine)
+			dbg.Symbols["buffer"] = vm.DataSegmentStart
+
+			err := dbg.ExecuteCommand(tt.cmd)
+			if err == nil {
+				t.Fatalf("expected %q to fail", tt.cmd)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if machine.CPU.PC != 0x8000 || machine.CPU.R[0] != 0 || machine.CPU.CPSR.N {
+				t.Errorf("state changed by a rejected assignment: PC=0x%08X R0=%d", machine.CPU.PC, machine.CPU.R[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package debugger_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStateFile_SaveAtBreakpointAndRestore(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+	runToBreakpoint(t, dbg, "break loop if R0 == 2")
+	savedPC, savedR0 := dbg.VM.CPU.PC, dbg.VM.CPU.R[0]
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := dbg.ExecuteCommand("save-state " + path); err != nil {
+		t.Fatalf("save-state failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "Saved machine state at PC=0x00008004") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// Run to the end, then go back to the breakpoint
+	if err := dbg.ExecuteCommand("continue"); err != nil {
+		t.Fatalf("continue failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" {
+		t.Fatalf("expected the program to exit, got %q", reason)
+	}
+	if err := dbg.ExecuteCommand("load-state " + path); err != nil {
+		t.Fatalf("load-state failed: %v", err)
+	}
+	if dbg.VM.CPU.PC != savedPC || dbg.VM.CPU.R[0] != savedR0 || dbg.VM.CPU.R[1] != 0 {
+		t.Errorf("expected PC=0x%08X R0=%d R1=0, got PC=0x%08X R0=%d R1=%d",
+			savedPC, savedR0, dbg.VM.CPU.PC, dbg.VM.CPU.R[0], dbg.VM.CPU.R[1])
+	}
+
+	// Execution carries on from the restored state
+	if err := dbg.ExecuteCommand("continue"); err != nil {
+		t.Fatalf("continue failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" || dbg.VM.CPU.R[1] != 3 {
+		t.Errorf("expected to exit with R1=3, got %q with R1=%d", reason, dbg.VM.CPU.R[1])
+	}
+}
+
+func TestStateFile_Errors(t *testing.T) {
+	dbg := loadDebugProgram(t, runToProgram)
+	for _, command := range []string{"save-state", "load-state", "load-state " + filepath.Join(t.TempDir(), "missing.json")} {
+		if err := dbg.ExecuteCommand(command); err == nil {
+			t.Errorf("%s: expected an error", command)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package debugger_test
+
+import (
+	"testing"
+)
+
+func TestStepLine_LiteralLoad(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	LDR R0, =0x12345678
+	MOV R1, #1
+	SWI #0
+`)
+
+	if err := dbg.ExecuteCommand("step-line"); err != nil {
+		t.Fatalf("step-line failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "step line complete" {
+		t.Fatalf("expected step line complete, got %q", reason)
+	}
+
+	if dbg.VM.CPU.R[0] != 0x12345678 {
+		t.Errorf("expected R0=0x12345678, got 0x%08X", dbg.VM.CPU.R[0])
+	}
+	if dbg.SourceMap[dbg.VM.CPU.PC] != "\tMOV R1, #1" {
+		t.Errorf("expected to stop on MOV line, stopped at 0x%08X (%q)", dbg.VM.CPU.PC, dbg.SourceMap[dbg.VM.CPU.PC])
+	}
+}
+
+func TestStepLine_MultipleInstructionsPerLine(t *testing.T) {
+	// The .rept body is one source line assembled to three instructions, and every
+	// instruction has a source map entry
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #0
+	.rept 3
+	ADD R0, R0, #1
+	.endr
+	MOV R1, #2
+	SWI #0
+`)
+	start := dbg.VM.CPU.PC
+	for addr := start; addr < start+20; addr += 4 {
+		if _, exists := dbg.SourceMap[addr]; !exists {
+			t.Fatalf("expected a source map entry at 0x%08X", addr)
+		}
+	}
+
+	// First onto the .rept line, then over all three of its instructions
+	for _, want := range []uint32{start + 4, start + 16} {
+		if err := dbg.ExecuteCommand("sl"); err != nil {
+			t.Fatalf("sl failed: %v", err)
+		}
+		if reason := runDebugger(t, dbg); reason != "step line complete" {
+			t.Fatalf("expected step line complete, got %q", reason)
+		}
+		if dbg.VM.CPU.PC != want {
+			t.Errorf("expected to stop at 0x%08X, got 0x%08X", want, dbg.VM.CPU.PC)
+		}
+	}
+	if dbg.VM.CPU.R[0] != 3 || dbg.VM.CPU.R[1] != 0 {
+		t.Errorf("expected R0=3 R1=0 on the MOV line, got R0=%d R1=%d", dbg.VM.CPU.R[0], dbg.VM.CPU.R[1])
+	}
+}
+
+func TestStepLine_BranchLeavesLine(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	B target
+	MOV R0, #1
+target:
+	MOV R0, #2
+	SWI #0
+`)
+
+	startPC := dbg.VM.CPU.PC
+	if err := dbg.ExecuteCommand("step-line"); err != nil {
+		t.Fatalf("step-line failed: %v", err)
+	}
+	runDebugger(t, dbg)
+
+	if dbg.VM.CPU.PC != startPC+8 {
+		t.Errorf("expected stop at branch target 0x%08X, got 0x%08X", startPC+8, dbg.VM.CPU.PC)
+	}
+	if dbg.VM.CPU.R[0] != 0 {
+		t.Errorf("skipped line should not have executed, R0=%d", dbg.VM.CPU.R[0])
+	}
+}
+
+func TestStepLine_StopsOnExit(t *testing.T) {
+	dbg := loadDebugProgram(t, `
+	.org 0x8000
+_start:
+	MOV R0, #0
+	SWI #0
+`)
+
+	if err := dbg.ExecuteCommand("step-line"); err != nil {
+		t.Fatalf("step-line failed: %v", err)
+	}
+	runDebugger(t, dbg)
+
+	if err := dbg.ExecuteCommand("step-line"); err != nil {
+		t.Fatalf("step-line failed: %v", err)
+	}
+	if reason := runDebugger(t, dbg); reason != "exited" {
+		t.Errorf("expected program exit, got %q", reason)
+	}
+}
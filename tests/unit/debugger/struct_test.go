@@ -0,0 +1,131 @@
+package debugger_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const structProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #0
+	SWI #0
+point:
+	.word 10, -3
+record:
+	.word msg
+	.byte 1, 2, 7, 255
+	.ascii "ab\0\0"
+msg:
+	.asciz "Hi"
+`
+
+func TestStruct_PrintTwoFields(t *testing.T) {
+	dbg := loadDebugProgram(t, structProgram)
+	if err := dbg.ExecuteCommand("struct point x:i32 y:i32"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	dbg.GetOutput()
+
+	if err := dbg.ExecuteCommand("print struct point at point"); err != nil {
+		t.Fatalf("print struct failed: %v", err)
+	}
+	want := "point at 0x00008008:\n  x = 0x0000000A (10)\n  y = 0xFFFFFFFD (-3)\n"
+	if out := dbg.GetOutput(); out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestStruct_FieldTypes(t *testing.T) {
+	dbg := loadDebugProgram(t, structProgram)
+	if err := dbg.ExecuteCommand("struct rec name:ptr len:half flags:u8[2] tag:char[4]"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	dbg.GetOutput()
+
+	if err := dbg.ExecuteCommand("p struct rec at record"); err != nil {
+		t.Fatalf("print struct failed: %v", err)
+	}
+	out := dbg.GetOutput()
+	for _, want := range []string{
+		"name = 0x0000801C <msg>",
+		"len = 0x0201 (513)",
+		"flags = {0x07 (7), 0xFF (255)}",
+		`tag = "ab"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestStruct_ExplicitOffset(t *testing.T) {
+	dbg := loadDebugProgram(t, structProgram)
+	if err := dbg.ExecuteCommand("typedef pair second:i32@4 first:u32@0"); err != nil {
+		t.Fatalf("typedef failed: %v", err)
+	}
+	layout := dbg.Structs["pair"]
+	if layout == nil || layout.Size() != 8 {
+		t.Fatalf("expected an 8-byte layout, got %+v", layout)
+	}
+	dbg.GetOutput()
+
+	// The address may be any expression
+	if err := dbg.ExecuteCommand("print struct pair at point+0"); err != nil {
+		t.Fatalf("print struct failed: %v", err)
+	}
+	want := "pair at 0x00008008:\n  second = 0xFFFFFFFD (-3)\n  first = 0x0000000A (10)\n"
+	if out := dbg.GetOutput(); out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestStruct_ListAndShow(t *testing.T) {
+	dbg := loadDebugProgram(t, structProgram)
+	if err := dbg.ExecuteCommand("struct"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "No structs defined") {
+		t.Errorf("expected no structs, got %q", out)
+	}
+
+	if err := dbg.ExecuteCommand("struct point x:i32 y:i32"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	dbg.GetOutput()
+	if err := dbg.ExecuteCommand("struct"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "point (8 bytes)") {
+		t.Errorf("expected point in list, got %q", out)
+	}
+	if err := dbg.ExecuteCommand("struct point"); err != nil {
+		t.Fatalf("struct point failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "+4") || !strings.Contains(out, "y") {
+		t.Errorf("expected field y at +4, got %q", out)
+	}
+}
+
+func TestStruct_Errors(t *testing.T) {
+	dbg := loadDebugProgram(t, structProgram)
+	for _, cmd := range []string{
+		"struct bad x",
+		"struct bad x:float",
+		"struct bad x:u8[0]",
+		"struct bad x:u8 x:u16",
+		"struct missing",
+		"print struct missing at point",
+	} {
+		if err := dbg.ExecuteCommand(cmd); err == nil {
+			t.Errorf("expected %q to fail", cmd)
+		}
+	}
+
+	if err := dbg.ExecuteCommand("struct point x:i32 y:i32"); err != nil {
+		t.Fatalf("struct failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("print struct point point"); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package debugger_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/debugger"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestUndefinedStop tests that an undefined instruction is described with its opcode, PC
+// and the reason it is undefined, and that other errors are not
+func TestUndefinedStop(t *testing.T) {
+	machine := vm.NewVM()
+	machine.State = vm.StateRunning
+	machine.CPU.PC = 0x8000
+	if err := machine.Memory.WriteWordUnsafe(0x8004, 0xE7F000F0); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := machine.Memory.WriteWordUnsafe(0x8000, 0xE1A00000); err != nil { // MOV R0, R0
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := machine.Step(); err != nil {
+		t.Fatalf("unexpected error on the first instruction: %v", err)
+	}
+	err := machine.Step()
+	if err == nil {
+		t.Fatal("expected the undefined instruction to stop execution")
+	}
+
+	msg, ok := debugger.UndefinedStop(err)
+	if !ok {
+		t.Fatalf("expected an undefined instruction stop, got %v", err)
+	}
+	for _, want := range []string{"undefined instruction 0xE7F000F0 at PC=0x00008004", "cond=AL", "undefined instruction space"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in the stop message, got %q", want, msg)
+		}
+	}
+
+	if _, ok := debugger.UndefinedStop(errors.New("memory fault")); ok {
+		t.Error("expected other errors not to be reported as undefined instructions")
+	}
+}
@@ -1,6 +1,7 @@
 package debugger_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/debugger"
@@ -246,3 +247,133 @@ func TestWatchpoint_Types(t *testing.T) {
 		t.Error("Wrong type for access watchpoint")
 	}
 }
+
+const watchProgram = `
+	.org 0x8000
+_start:
+	MOV R0, #1          ; 0x8000 changes R0
+	MOV R1, #2          ; 0x8004
+	MOV R0, #1          ; 0x8008 same value, no change
+	ADD R0, R0, #4      ; 0x800C changes R0
+	MOV R2, #3          ; 0x8010
+	LDR R4, =buffer     ; 0x8014
+	STR R0, [R4]        ; 0x8018 changes [R4]
+	SWI #0              ; 0x801C
+buffer:
+	.word 0
+`
+
+// runToStops continues until the program exits, returning the PC and reason of each stop
+func runToStops(t *testing.T, dbg *debugger.Debugger) ([]uint32, []string) {
+	t.Helper()
+	var pcs []uint32
+	var reasons []string
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for {
+		reason := runDebugger(t, dbg)
+		if reason == "exited" {
+			return pcs, reasons
+		}
+		pcs = append(pcs, dbg.VM.CPU.PC)
+		reasons = append(reasons, reason)
+		if err := dbg.ExecuteCommand("continue"); err != nil {
+			t.Fatalf("continue failed: %v", err)
+		}
+	}
+}
+
+func TestWatch_RegisterBreaksOnlyWhenChanged(t *testing.T) {
+	dbg := loadDebugProgram(t, watchProgram)
+	if err := dbg.ExecuteCommand("watch R0"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	pcs, _ := runToStops(t, dbg)
+	want := []uint32{0x8004, 0x8010}
+	if len(pcs) != len(want) {
+		t.Fatalf("expected stops after 0x8000 and 0x800C, got %X", pcs)
+	}
+	for i := range want {
+		if pcs[i] != want[i] {
+			t.Errorf("stop %d: expected PC=0x%X, got 0x%X", i, want[i], pcs[i])
+		}
+	}
+}
+
+func TestWatch_ComputedExpression(t *testing.T) {
+	dbg := loadDebugProgram(t, watchProgram)
+	if err := dbg.ExecuteCommand("watch R1+R2"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "Expression watchpoint 1: R1+R2 = 0x00000000") {
+		t.Errorf("unexpected watch output: %q", out)
+	}
+
+	wp := dbg.Watchpoints.GetWatchpoint(1)
+	if wp == nil || !wp.IsExpression {
+		t.Fatalf("expected an expression watchpoint, got %+v", wp)
+	}
+
+	pcs, reasons := runToStops(t, dbg)
+	if len(pcs) != 2 || pcs[0] != 0x8008 || pcs[1] != 0x8014 {
+		t.Fatalf("expected stops at 0x8008 and 0x8014, got %X", pcs)
+	}
+	if want := "expression watchpoint 1: R1+R2 changed 0x00000002 -> 0x00000005"; reasons[1] != want {
+		t.Errorf("expected reason %q, got %q", want, reasons[1])
+	}
+
+	if err := dbg.ExecuteCommand("info watchpoints"); err != nil {
+		t.Fatalf("info watchpoints failed: %v", err)
+	}
+	if out := dbg.GetOutput(); !strings.Contains(out, "R1+R2 expression (checked every step) enabled (hit 2 times") {
+		t.Errorf("expected expression watchpoint to be marked in list: %q", out)
+	}
+}
+
+func TestWatch_RegisterDereference(t *testing.T) {
+	dbg := loadDebugProgram(t, watchProgram)
+
+	// [R4] can only be evaluated once R4 points at mapped memory
+	if err := dbg.ExecuteCommand("watch [R4]"); err == nil {
+		t.Error("expected error evaluating [R4] while R4 is 0")
+	}
+
+	if err := dbg.ExecuteCommand("tbreak 0x8018"); err != nil {
+		t.Fatalf("tbreak failed: %v", err)
+	}
+	if err := dbg.ExecuteCommand("run"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	runDebugger(t, dbg)
+	if err := dbg.ExecuteCommand("watch [R4]"); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	if err := dbg.ExecuteCommand("continue"); err != nil {
+		t.Fatalf("continue failed: %v", err)
+	}
+	reason := runDebugger(t, dbg)
+	if dbg.VM.CPU.PC != 0x801C {
+		t.Errorf("expected stop after the STR at 0x8018, got PC=0x%X", dbg.VM.CPU.PC)
+	}
+	if want := "expression watchpoint 2: [R4] changed 0x00000000 -> 0x00000005"; reason != want {
+		t.Errorf("expected reason %q, got %q", want, reason)
+	}
+}
+
+func TestWatch_ComputedExpressionRejectedForReadWatch(t *testing.T) {
+	dbg := loadDebugProgram(t, watchProgram)
+	for _, cmd := range []string{"rwatch R1+R2", "awatch [R4]"} {
+		if err := dbg.ExecuteCommand(cmd); err == nil {
+			t.Errorf("%q: expected error", cmd)
+		}
+	}
+	if err := dbg.ExecuteCommand("watch R1+"); err == nil {
+		t.Error("expected error for invalid expression")
+	}
+	if dbg.Watchpoints.Count() != 0 {
+		t.Errorf("expected no watchpoints, got %d", dbg.Watchpoints.Count())
+	}
+}
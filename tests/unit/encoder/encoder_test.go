@@ -1,6 +1,7 @@
 package encoder_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/encoder"
@@ -472,6 +473,32 @@ func TestEncodeBranch(t *testing.T) {
 	}
 }
 
+// TestEncodeBranchExchange tests BX and BLX register forms, including named registers
+func TestEncodeBranchExchange(t *testing.T) {
+	enc := newTestEncoder()
+
+	tests := []struct {
+		mnemonic string
+		operand  string
+		expected uint32
+	}{
+		{"BX", "LR", 0xE12FFF1E},
+		{"BX", "R3", 0xE12FFF13},
+		{"BLX", "R2", 0xE12FFF32},
+		{"BLX", "LR", 0xE12FFF3E},
+		{"BLX", "r7", 0xE12FFF37},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mnemonic+" "+tt.operand, func(t *testing.T) {
+			result := encodeInstruction(t, enc, tt.mnemonic, []string{tt.operand}, 0x8000)
+			if result != tt.expected {
+				t.Errorf("got 0x%08X, want 0x%08X", result, tt.expected)
+			}
+		})
+	}
+}
+
 // TestEncodeMemory tests load/store instruction encoding
 func TestEncodeMemory(t *testing.T) {
 	enc := newTestEncoder()
@@ -526,8 +553,7 @@ func TestEncodeMemoryAddressingModes(t *testing.T) {
 	}{
 		{"[Rn]", []string{"R0", "[R1]"}, false},
 		{"[Rn, #offset]", []string{"R0", "[R1, #4]"}, false},
-		// Note: [Rn, #-offset] format with #-N is currently not handled correctly
-		// The encoder expects the format [Rn, -#offset] or handles negative offsets differently
+		{"[Rn, #-offset]", []string{"R0", "[R1, #-4]"}, false},
 		{"[Rn, -#offset]", []string{"R0", "[R1, -#4]"}, false},
 		{"[Rn, Rm]", []string{"R0", "[R1, R2]"}, false},
 		{"[Rn, #offset]!", []string{"R0", "[R1, #4]!"}, false},
@@ -551,6 +577,38 @@ func TestEncodeMemoryAddressingModes(t *testing.T) {
 	}
 }
 
+// TestEncodeMemoryExactEncodings checks negative offsets and halfword transfers against the
+// encodings the VM decodes
+func TestEncodeMemoryExactEncodings(t *testing.T) {
+	enc := newTestEncoder()
+
+	tests := []struct {
+		mnemonic string
+		operands []string
+		want     uint32
+	}{
+		{"LDR", []string{"R0", "[R1, #-4]"}, 0xE5110004},
+		{"LDR", []string{"R0", "[R1, -#4]"}, 0xE5110004},
+		{"LDRH", []string{"R0", "[R1, #4]"}, 0xE1D100B4},
+		{"LDRH", []string{"R0", "[R1, #-4]"}, 0xE15100B4},
+		{"LDRH", []string{"R0", "[R1, R2]"}, 0xE19100B2},
+		{"STRH", []string{"R0", "[R1]"}, 0xE1C100B0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mnemonic+" "+strings.Join(tt.operands, ", "), func(t *testing.T) {
+			inst := &parser.Instruction{Mnemonic: tt.mnemonic, Operands: tt.operands}
+			result, err := enc.EncodeInstruction(inst, 0)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("got 0x%08X, want 0x%08X", result, tt.want)
+			}
+		})
+	}
+}
+
 // TestEncodeMultiply tests multiply instruction encoding
 func TestEncodeMultiply(t *testing.T) {
 	enc := newTestEncoder()
@@ -674,6 +732,48 @@ func TestEncodeSWI_VMDetection(t *testing.T) {
 	}
 }
 
+// TestEncodeBKPT tests software breakpoint encoding and the VM's view of the immediate
+func TestEncodeBKPT(t *testing.T) {
+	enc := newTestEncoder()
+
+	tests := []struct {
+		name     string
+		operands []string
+		want     uint32
+		wantImm  uint16
+	}{
+		{"BKPT", nil, 0xE1200070, 0},
+		{"BKPT #0", []string{"#0"}, 0xE1200070, 0},
+		{"BKPT #0x1234", []string{"#0x1234"}, 0xE1212374, 0x1234},
+		{"BKPT #0xFFFF", []string{"#0xFFFF"}, 0xE12FFF7F, 0xFFFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &parser.Instruction{Mnemonic: "BKPT", Operands: tt.operands}
+			encoded, err := enc.EncodeInstruction(inst, 0)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if encoded != tt.want {
+				t.Errorf("got 0x%08X, want 0x%08X", encoded, tt.want)
+			}
+			if got := vm.BKPTImmediate(encoded); got != tt.wantImm {
+				t.Errorf("extracted immediate: got 0x%X, want 0x%X", got, tt.wantImm)
+			}
+		})
+	}
+
+	for _, inst := range []*parser.Instruction{
+		{Mnemonic: "BKPT", Operands: []string{"#0x10000"}},
+		{Mnemonic: "BKPT", Condition: "EQ", Operands: []string{"#1"}},
+	} {
+		if _, err := enc.EncodeInstruction(inst, 0); err == nil {
+			t.Errorf("expected error for BKPT%s %v", inst.Condition, inst.Operands)
+		}
+	}
+}
+
 // TestEncodeNOP tests NOP encoding
 func TestEncodeNOP(t *testing.T) {
 	enc := newTestEncoder()
@@ -696,6 +796,98 @@ func TestEncodeNOP(t *testing.T) {
 	}
 }
 
+// TestEncodeSaturating tests QADD/QSUB encoding
+func TestEncodeSaturating(t *testing.T) {
+	enc := newTestEncoder()
+
+	tests := []struct {
+		name     string
+		mnemonic string
+		operands []string
+		expected uint32
+	}{
+		{"QADD R0, R1, R2", "QADD", []string{"R0", "R1", "R2"}, 0xE1020051},
+		{"QSUB R3, R4, R5", "QSUB", []string{"R3", "R4", "R5"}, 0xE1253054},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := encodeInstruction(t, enc, tt.mnemonic, tt.operands, 0)
+			if result != tt.expected {
+				t.Errorf("got 0x%08X, want 0x%08X", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestEncodeDivide tests SDIV/UDIV encoding
+func TestEncodeDivide(t *testing.T) {
+	enc := newTestEncoder()
+
+	tests := []struct {
+		name     string
+		mnemonic string
+		operands []string
+		expected uint32
+	}{
+		{"SDIV R0, R1, R2", "SDIV", []string{"R0", "R1", "R2"}, 0xE710F211},
+		{"UDIV R3, R4, R5", "UDIV", []string{"R3", "R4", "R5"}, 0xE733F514},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := encodeInstruction(t, enc, tt.mnemonic, tt.operands, 0)
+			if result != tt.expected {
+				t.Errorf("got 0x%08X, want 0x%08X", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestEncodeADR tests ADR as ADD/SUB Rd, PC, #offset
+func TestEncodeADR(t *testing.T) {
+	enc := newTestEncoderWithSymbols(map[string]uint32{
+		"ahead8":  0x8008, // PC (0x8008) + 0
+		"ahead16": 0x8010, // PC + 8
+		"behind":  0x7FF0, // PC - 0x18
+		"table":   0x8100,
+	})
+
+	tests := []struct {
+		name     string
+		operands []string
+		expected uint32
+	}{
+		{"ADR R0, ahead8", []string{"R0", "ahead8"}, 0xE28F0000},   // ADD R0, PC, #0
+		{"ADR R1, ahead16", []string{"R1", "ahead16"}, 0xE28F1008}, // ADD R1, PC, #8
+		{"ADR R2, behind", []string{"R2", "behind"}, 0xE24F2018},   // SUB R2, PC, #0x18
+		{"ADR R3, table+8", []string{"R3", "table+8"}, 0xE28F3F40}, // ADD R3, PC, #0x100
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := encodeInstruction(t, enc, "ADR", tt.operands, 0x8000)
+			if result != tt.expected {
+				t.Errorf("got 0x%08X, want 0x%08X", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestEncodeADRUnencodableOffset tests the error when the offset is not a rotated immediate
+func TestEncodeADRUnencodableOffset(t *testing.T) {
+	enc := newTestEncoderWithSymbols(map[string]uint32{"far": 0x8008 + 0x101})
+
+	inst := &parser.Instruction{Mnemonic: "ADR", Operands: []string{"R0", "far"}}
+	_, err := enc.EncodeInstruction(inst, 0x8000)
+	if err == nil {
+		t.Fatal("expected error for unencodable ADR offset")
+	}
+	if !strings.Contains(err.Error(), "rotated 8-bit immediate") || !strings.Contains(err.Error(), "LDR Rd, =far") {
+		t.Errorf("expected clear encoding error, got: %v", err)
+	}
+}
+
 // TestEncodeUnknownInstruction tests handling of unknown mnemonics
 func TestEncodeUnknownInstruction(t *testing.T) {
 	enc := newTestEncoder()
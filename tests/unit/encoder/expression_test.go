@@ -0,0 +1,85 @@
+package encoder_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// TestEncodeImmediateExpressions assembles immediates written as expressions and checks
+// the computed value made it into the instruction
+func TestEncodeImmediateExpressions(t *testing.T) {
+	source := `
+	.equ FLAGS, 3
+	.org 0x8000
+_start:
+	ADD R0, R1, #(1<<4)
+	MOV R0, #(label_end - label_start)
+	MOV R1, #(4*8+1)
+	AND R2, R2, #(FLAGS << 2) | 1
+	MOV R3, #~0xFFFFFF00
+	MOV R4, #(17 % 5) * -1 + 3
+label_start:
+	.word 0, 0, 0
+label_end:
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	enc := encoder.NewEncoder(program.SymbolTable)
+
+	tests := []struct {
+		addr uint32
+		want uint32
+	}{
+		{0x8000, 0xE2810010}, // ADD R0, R1, #16
+		{0x8004, 0xE3A0000C}, // MOV R0, #12
+		{0x8008, 0xE3A01021}, // MOV R1, #33
+		{0x800C, 0xE202200D}, // AND R2, R2, #13
+		{0x8010, 0xE3A030FF}, // MOV R3, #0xFF
+		{0x8014, 0xE3A04001}, // MOV R4, #1
+	}
+	for _, tt := range tests {
+		inst := instructionAt(t, program, tt.addr)
+		got, err := enc.EncodeInstruction(inst, tt.addr)
+		if err != nil {
+			t.Errorf("0x%X %s %v: %v", tt.addr, inst.Mnemonic, inst.Operands, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("0x%X %s %v: got 0x%08X, want 0x%08X", tt.addr, inst.Mnemonic, inst.Operands, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeImmediateExpressionErrors(t *testing.T) {
+	enc := newTestEncoder()
+
+	for _, operand := range []string{
+		"#(0x101 << 1)", // evaluates fine but cannot be encoded as a rotated immediate
+		"#(1 << 32)",    // shift out of range
+		"#(0x10000 * 0x10000)",
+		"#(4 / 0)",
+		"#(1 + 2",
+		"#(undefined_label + 1)",
+	} {
+		inst := &parser.Instruction{Mnemonic: "MOV", Operands: []string{"R0", operand}}
+		if _, err := enc.EncodeInstruction(inst, 0x8000); err == nil {
+			t.Errorf("expected error for MOV R0, %s", operand)
+		}
+	}
+}
+
+// instructionAt returns the parsed instruction at addr
+func instructionAt(t *testing.T, program *parser.Program, addr uint32) *parser.Instruction {
+	t.Helper()
+	for _, inst := range program.Instructions {
+		if inst.Address == addr {
+			return inst
+		}
+	}
+	t.Fatalf("no instruction at 0x%X", addr)
+	return nil
+}
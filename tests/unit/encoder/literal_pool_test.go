@@ -2,6 +2,7 @@ package encoder_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/encoder"
@@ -376,3 +377,65 @@ func TestLiteralPool_Capacity(t *testing.T) {
 		t.Errorf("Expected %d literals in pool, got %d", numValues, len(enc.LiteralPool))
 	}
 }
+
+// TestLiteralPool_LtorgStartsFreshPool tests that literals after a .ltorg go to the next pool
+func TestLiteralPool_LtorgStartsFreshPool(t *testing.T) {
+	enc := encoder.NewEncoder(parser.NewSymbolTable())
+	enc.LiteralPoolLocs = []uint32{0x8010}
+	enc.LiteralPoolStart = 0x8100
+
+	load := func(addr, value uint32) {
+		t.Helper()
+		inst := &parser.Instruction{
+			Mnemonic: "LDR",
+			Operands: []string{"R0", fmt.Sprintf("=0x%08X", value)},
+		}
+		if _, err := enc.EncodeInstruction(inst, addr); err != nil {
+			t.Fatalf("Failed to encode LDR at 0x%X: %v", addr, err)
+		}
+	}
+
+	load(0x8000, 0x12345678) // Before .ltorg - goes in the .ltorg pool
+	load(0x8004, 0xCAFEBABE)
+	load(0x8020, 0x12345678) // After .ltorg - same value, new pool
+
+	expected := map[uint32]uint32{
+		0x8010: 0x12345678,
+		0x8014: 0xCAFEBABE,
+		0x8100: 0x12345678,
+	}
+	if len(enc.LiteralPool) != len(expected) {
+		t.Fatalf("Expected %d literals, got %v", len(expected), enc.LiteralPool)
+	}
+	for addr, value := range expected {
+		if enc.LiteralPool[addr] != value {
+			t.Errorf("Expected 0x%08X at 0x%X, got 0x%08X", value, addr, enc.LiteralPool[addr])
+		}
+	}
+}
+
+// TestLiteralPool_UndefinedSymbol tests that =name reports a missing symbol rather than a bad number
+func TestLiteralPool_UndefinedSymbol(t *testing.T) {
+	enc := encoder.NewEncoder(parser.NewSymbolTable())
+	inst := &parser.Instruction{Mnemonic: "LDR", Operands: []string{"R0", "=mesage"}}
+
+	_, err := enc.EncodeInstruction(inst, 0x8000)
+	if err == nil || !strings.Contains(err.Error(), `undefined symbol: "mesage"`) {
+		t.Errorf("expected an undefined symbol error, got %v", err)
+	}
+}
+
+// TestLiteralPool_OnlyLDR tests that other loads and stores reject =value instead of
+// being encoded as a word load
+func TestLiteralPool_OnlyLDR(t *testing.T) {
+	st := parser.NewSymbolTable()
+	_ = st.Define("message", parser.SymbolLabel, 0x20000, parser.Position{})
+	enc := encoder.NewEncoder(st)
+
+	for _, mnemonic := range []string{"STR", "LDRB", "STRB", "LDRH"} {
+		inst := &parser.Instruction{Mnemonic: mnemonic, Operands: []string{"R0", "=message"}}
+		if _, err := enc.EncodeInstruction(inst, 0x8000); err == nil || !strings.Contains(err.Error(), "cannot take a literal operand") {
+			t.Errorf("%s: expected a literal operand error, got %v", mnemonic, err)
+		}
+	}
+}
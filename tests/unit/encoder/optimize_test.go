@@ -0,0 +1,90 @@
+package encoder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func encodeLines(t *testing.T, enc *encoder.Encoder, lines ...string) []uint32 {
+	t.Helper()
+	var result []uint32
+	for i, line := range lines {
+		fields := strings.SplitN(line, " ", 2)
+		inst := &parser.Instruction{Mnemonic: fields[0], Operands: strings.Split(fields[1], ", ")}
+		opcode, err := enc.EncodeInstruction(inst, 0x8000+uint32(i*4)) // #nosec G115 -- small test offset
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		result = append(result, opcode)
+	}
+	return result
+}
+
+func TestOptimize_LDRConstantFolding(t *testing.T) {
+	enc := encoder.NewEncoder(parser.NewSymbolTable())
+	enc.Optimize = true
+
+	ops := encodeLines(t, enc, "LDR R0, =5", "LDR R1, =0x12345678", "LDR R2, =0xFFFFFFFE")
+	if ops[0] != 0xE3A00005 {
+		t.Errorf("expected LDR R0, =5 to become MOV R0, #5, got 0x%08X", ops[0])
+	}
+	if ops[1]&0x0F7F0000 != 0x051F0000 {
+		t.Errorf("expected LDR R1, =0x12345678 to load from the pool, got 0x%08X", ops[1])
+	}
+	if ops[2] != 0xE3E02001 {
+		t.Errorf("expected LDR R2, =0xFFFFFFFE to become MVN R2, #1, got 0x%08X", ops[2])
+	}
+	if len(enc.LiteralPool) != 1 {
+		t.Errorf("expected only 0x12345678 in the pool, got %v", enc.LiteralPool)
+	}
+	if len(enc.Optimizations) != 2 || !strings.Contains(enc.Optimizations[0], "LDR R0, =5 -> MOV R0, #0x5") {
+		t.Errorf("unexpected optimization report %v", enc.Optimizations)
+	}
+}
+
+func TestOptimize_OffByDefault(t *testing.T) {
+	enc := encoder.NewEncoder(parser.NewSymbolTable())
+
+	ops := encodeLines(t, enc, "LDR R0, =5", "ADD R1, R1, #0")
+	// The MOV fold does not depend on -O1, it is just not reported
+	if ops[0] != 0xE3A00005 || len(enc.LiteralPool) != 0 {
+		t.Errorf("expected MOV R0, #5 without -O1, got 0x%08X", ops[0])
+	}
+	if ops[1] != 0xE2811000 {
+		t.Errorf("expected ADD R1, R1, #0 to be kept, got 0x%08X", ops[1])
+	}
+	if len(enc.Optimizations) != 0 {
+		t.Errorf("expected no optimizations, got %v", enc.Optimizations)
+	}
+}
+
+func TestOptimize_NoOpFolding(t *testing.T) {
+	enc := encoder.NewEncoder(parser.NewSymbolTable())
+	enc.Optimize = true
+
+	ops := encodeLines(t, enc,
+		"ADD R1, R1, #0",
+		"SUB R2, R2, #0x0",
+		"ORR SP, R13, #0",
+		"ADD R1, R2, #0", // Copies R2: not a no-op
+		"ADD PC, PC, #0", // Branches: not a no-op
+		"EOR R3, R3, #1",
+	)
+	for i, want := range []uint32{0xE1A00000, 0xE1A00000, 0xE1A00000, 0xE2821000, 0xE28FF000, 0xE2233001} {
+		if ops[i] != want {
+			t.Errorf("instruction %d: got 0x%08X, want 0x%08X", i, ops[i], want)
+		}
+	}
+
+	// The S bit makes the instruction update flags, so it must stay
+	adds := &parser.Instruction{Mnemonic: "ADD", SetFlags: true, Operands: []string{"R1", "R1", "#0"}}
+	if op, _ := enc.EncodeInstruction(adds, 0x8100); op != 0xE2911000 {
+		t.Errorf("expected ADDS R1, R1, #0 to be kept, got 0x%08X", op)
+	}
+	if len(enc.Optimizations) != 3 {
+		t.Errorf("expected 3 optimizations, got %v", enc.Optimizations)
+	}
+}
@@ -0,0 +1,67 @@
+package encoder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// TestEncodePSRTransfer tests the exact encodings of MRS and MSR
+func TestEncodePSRTransfer(t *testing.T) {
+	tests := []struct {
+		line string
+		want uint32
+	}{
+		{"MRS R0, CPSR", 0xE10F0000},
+		{"MRS R1, SPSR", 0xE14F1000},
+		{"MRSEQ R2, CPSR", 0x010F2000},
+		{"MSR CPSR_f, R0", 0xE128F000},
+		{"MSR CPSR_flg, R0", 0xE128F000},
+		{"MSR CPSR_fc, R3", 0xE129F003},
+		{"MSR CPSR, R2", 0xE129F002},
+		{"MSRNE CPSR_all, R2", 0x1129F002},
+		{"MSR SPSR_f, R4", 0xE168F004},
+		{"MSR CPSR_f, #0xF0000000", 0xE328F4F0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := parseAndEncode(t, tt.line); got != tt.want {
+				t.Errorf("expected 0x%08X, got 0x%08X", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestEncodePSRTransfer_Invalid tests that malformed PSR transfers are rejected
+func TestEncodePSRTransfer_Invalid(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr string
+	}{
+		{"MRS PC, CPSR", "MRS cannot use PC as the destination"},
+		{"MRS R0, CPSR_f", "remove the _f field suffix"},
+		{"MRS R0, R1", "expected CPSR or SPSR"},
+		{"MSR CPSR_q, R0", "invalid PSR field"},
+		{"MSR CPSR_ff, R0", "given twice"},
+		{"MSR CPSR_f, PC", "MSR cannot use PC as the source"},
+		{"MSR CPSR_f, #0x101", "cannot be encoded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			program, err := parser.NewParser(tt.line+"\n", "test.s").Parse()
+			if err != nil {
+				t.Fatalf("parse %q failed: %v", tt.line, err)
+			}
+			_, err = newTestEncoder().EncodeInstruction(program.Instructions[0], 0x8000)
+			if err == nil {
+				t.Fatalf("expected %q to be rejected", tt.line)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package encoder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// parseAndEncode parses a single instruction line and encodes it at 0x8000
+func parseAndEncode(t *testing.T, line string) uint32 {
+	t.Helper()
+	program, err := parser.NewParser(line+"\n", "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse %q failed: %v", line, err)
+	}
+	if len(program.Instructions) != 1 {
+		t.Fatalf("expected one instruction from %q, got %d", line, len(program.Instructions))
+	}
+	result, err := newTestEncoder().EncodeInstruction(program.Instructions[0], 0x8000)
+	if err != nil {
+		t.Fatalf("encode %q failed: %v", line, err)
+	}
+	return result
+}
+
+// TestEncodeSuffixes tests that the condition and S suffixes are accepted in either order
+// and land in the condition field and S bit
+func TestEncodeSuffixes(t *testing.T) {
+	tests := []struct {
+		line     string
+		mnemonic uint32 // Expected opcode bits 21-24 (data processing) or 0 to skip
+		cond     vm.ConditionCode
+		sBit     bool
+	}{
+		{"ADD R0, R1, R2", 0x4, vm.CondAL, false},
+		{"ADDS R0, R1, R2", 0x4, vm.CondAL, true},
+		{"ADDEQ R0, R1, R2", 0x4, vm.CondEQ, false},
+		{"ADDEQS R0, R1, R2", 0x4, vm.CondEQ, true},
+		{"ADDSEQ R0, R1, R2", 0x4, vm.CondEQ, true},
+		{"MOVNES R0, #1", 0xD, vm.CondNE, true},
+		{"MOVSNE R0, #1", 0xD, vm.CondNE, true},
+		{"SUBHSS R0, R0, #1", 0x2, vm.CondCS, true},
+		{"SUBLOS R0, R0, #1", 0x2, vm.CondCC, true},
+		{"RSBMIS R0, R0, #0", 0x3, vm.CondMI, true},
+		{"ANDLSS R0, R0, R1", 0x0, vm.CondLS, true},
+		{"ORRGTS R0, R0, R1", 0xC, vm.CondGT, true},
+		{"EORSLE R0, R0, R1", 0x1, vm.CondLE, true},
+		{"BICVCS R0, R0, R1", 0xE, vm.CondVC, true},
+		{"MVNALS R0, R1", 0xF, vm.CondAL, true},
+		{"ADCCSS R0, R0, R1", 0x5, vm.CondCS, true},
+		{"CMPGE R0, R1", 0xA, vm.CondGE, true},
+		{"CMPGES R0, R1", 0xA, vm.CondGE, true}, // Comparisons always set flags
+		{"TEQNE R0, R1", 0x9, vm.CondNE, true},
+		{"MULEQS R0, R1, R2", 0, vm.CondEQ, true},
+		{"MLASNE R0, R1, R2, R3", 0, vm.CondNE, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			result := parseAndEncode(t, tt.line)
+			if cond := vm.ConditionCode(result >> 28); cond != tt.cond {
+				t.Errorf("expected condition %d, got %d (0x%08X)", tt.cond, cond, result)
+			}
+			if sBit := result&(1<<20) != 0; sBit != tt.sBit {
+				t.Errorf("expected S=%v, got S=%v (0x%08X)", tt.sBit, sBit, result)
+			}
+			if tt.mnemonic != 0 {
+				if opcode := (result >> 21) & 0xF; opcode != tt.mnemonic {
+					t.Errorf("expected opcode 0x%X, got 0x%X (0x%08X)", tt.mnemonic, opcode, result)
+				}
+			}
+		})
+	}
+}
+
+// TestEncodeSuffixes_Exact tests mnemonics whose letters could be read more than one way,
+// and the pre-UAL forms with the condition before a size or addressing mode
+func TestEncodeSuffixes_Exact(t *testing.T) {
+	tests := []struct {
+		line string
+		want uint32
+	}{
+		{"BLS 0x8000", 0x9AFFFFFE},  // B with LS, not BL with S
+		{"BLLS 0x8000", 0x9BFFFFFE}, // BL with LS
+		{"BLE 0x8000", 0xDAFFFFFE},  // B with LE
+		{"BLEQ 0x8000", 0x0BFFFFFE}, // BL with EQ
+		{"BHS 0x8000", 0x2AFFFFFE},  // B with HS
+		{"SWINE #0", 0x1F000000},    // SWI with NE
+		{"BXEQ LR", 0x012FFF1E},     // BX with EQ
+		{"BLCC 0x8000", 0x3BFFFFFE}, // BL with CC
+		{"BCS 0x8000", 0x2AFFFFFE},  // B with CS
+		{"BAL 0x8000", 0xEAFFFFFE},  // B with AL
+		{"BLAL 0x8000", 0xEBFFFFFE}, // BL with AL
+		{"BLLT 0x8000", 0xBBFFFFFE}, // BL with LT
+		{"BLGE 0x8000", 0xABFFFFFE}, // BL with GE
+		{"BLHI 0x8000", 0x8BFFFFFE}, // BL with HI
+		{"STRNEB R0, [R1]", 0x15C10000},
+		{"LDREQB R0, [R1]", 0x05D10000},
+		{"LDRBEQ R0, [R1]", 0x05D10000},
+		{"LDRHI R0, [R1]", 0x85910000}, // LDR with HI, not LDRH
+		{"STRHS R0, [R1]", 0x25810000}, // STR with HS, not STRH with S
+		{"STMNEFD SP!, {R0}", 0x192D0001},
+		{"LDMFDNE SP!, {R0}", 0x18BD0001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := parseAndEncode(t, tt.line); got != tt.want {
+				t.Errorf("expected 0x%08X, got 0x%08X", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestEncodeSuffixes_Invalid tests that suffixes an instruction does not accept are rejected
+func TestEncodeSuffixes_Invalid(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr string
+	}{
+		{"BS 0x8000", "B does not accept the S suffix"},
+		{"BEQS 0x8000", "B does not accept the S suffix"},
+		{"BXS LR", "BX does not accept the S suffix"},
+		{"LDRS R0, [R1]", "LDR does not accept the S suffix"},
+		{"SWIS #0", "SWI does not accept the S suffix"},
+		{"PUSHEQS {R0}", "PUSH does not accept the S suffix"},
+		{"BKPTEQ #0", "BKPT cannot be conditional"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := parser.NewParser(tt.line+"\n", "test.s").Parse()
+			if err == nil {
+				t.Fatalf("expected %q to be rejected", tt.line)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Instructions built without the parser are checked by the encoder
+	inst := &parser.Instruction{Mnemonic: "B", Operands: []string{"0x8000"}, SetFlags: true}
+	if _, err := newTestEncoder().EncodeInstruction(inst, 0x8000); err == nil ||
+		!strings.Contains(err.Error(), "B does not accept the S suffix") {
+		t.Errorf("expected the encoder to reject B with S, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package parser_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// TestAST_ExportJSON tests that the exported JSON holds every instruction and directive
+// with its address and source position
+func TestAST_ExportJSON(t *testing.T) {
+	source := `	.org 0x8000
+_start:
+	MOV R0, #1
+	CMP R0, #2
+done:	MOVEQ R1, #3
+	SWI #0x00
+table:	.word 1, 2
+`
+	program, err := parser.NewParser(source, "prog.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := parser.NewAST(program).ExportJSON(&buf); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	var ast struct {
+		Instructions []struct {
+			Label     string   `json:"label"`
+			Mnemonic  string   `json:"mnemonic"`
+			Condition string   `json:"condition"`
+			Operands  []string `json:"operands"`
+			Address   uint32   `json:"address"`
+			File      string   `json:"file"`
+			Line      int      `json:"line"`
+			Column    int      `json:"column"`
+		} `json:"instructions"`
+		Directives []struct {
+			Label   string   `json:"label"`
+			Name    string   `json:"name"`
+			Args    []string `json:"args"`
+			Address uint32   `json:"address"`
+		} `json:"directives"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &ast); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if len(ast.Instructions) != 4 {
+		t.Fatalf("expected 4 instructions, got %d", len(ast.Instructions))
+	}
+	moveq := ast.Instructions[2]
+	if moveq.Label != "done" || moveq.Mnemonic != "MOV" || moveq.Condition != "EQ" || moveq.Address != 0x8008 {
+		t.Errorf("unexpected MOVEQ entry: %+v", moveq)
+	}
+	if moveq.File != "prog.s" || moveq.Line != 5 || moveq.Column != 7 {
+		t.Errorf("expected MOVEQ at prog.s:5:7, got %s:%d:%d", moveq.File, moveq.Line, moveq.Column)
+	}
+	if len(moveq.Operands) != 2 || moveq.Operands[0] != "R1" || moveq.Operands[1] != "#3" {
+		t.Errorf("unexpected MOVEQ operands %v", moveq.Operands)
+	}
+
+	if len(ast.Directives) != 2 {
+		t.Fatalf("expected 2 directives, got %d", len(ast.Directives))
+	}
+	if table := ast.Directives[1]; table.Label != "table" || table.Name != ".word" || table.Address != 0x8010 || len(table.Args) != 2 {
+		t.Errorf("unexpected .word entry: %+v", table)
+	}
+}
@@ -0,0 +1,189 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+const conditionalSource = `
+.ifndef LEVEL
+.equ LEVEL, 0
+.endif
+
+_start:
+	MOV R0, #1
+.if LEVEL >= 2
+	MOV R1, #2
+.if LEVEL == 3
+	MOV R2, #3
+.endif
+.else
+	MOV R1, #4
+.endif
+.ifdef TRACE
+	MOV R3, #5
+.endif
+	SWI #0x00
+`
+
+// parseWithDefines writes source to a temporary file and parses it with the given defines
+func parseWithDefines(t *testing.T, source string, defines ...string) (*parser.Program, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cond.s")
+	if err := os.WriteFile(path, []byte(source), 0600); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	opts := parser.DefaultParseFileOptions()
+	opts.Defines = defines
+	program, _, err := parser.ParseFile(path, opts)
+	return program, err
+}
+
+// encodeProgram encodes each instruction of program in order
+func encodeProgram(t *testing.T, program *parser.Program) []uint32 {
+	t.Helper()
+	enc := encoder.NewEncoder(program.SymbolTable)
+	words := make([]uint32, 0, len(program.Instructions))
+	for _, inst := range program.Instructions {
+		word, err := enc.EncodeInstruction(inst, inst.Address)
+		if err != nil {
+			t.Fatalf("encode %s: %v", inst.RawLine, err)
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// TestConditionalAssembly_Defines tests that -D style defines select which blocks are
+// assembled and that the excluded instructions are not encoded
+func TestConditionalAssembly_Defines(t *testing.T) {
+	const (
+		movR0  = 0xE3A00001 // MOV R0, #1
+		movR1a = 0xE3A01002 // MOV R1, #2
+		movR2  = 0xE3A02003 // MOV R2, #3
+		movR1b = 0xE3A01004 // MOV R1, #4
+		movR3  = 0xE3A03005 // MOV R3, #5
+		swi    = 0xEF000000 // SWI #0x00
+	)
+	tests := []struct {
+		name    string
+		defines []string
+		want    []uint32
+	}{
+		{"none", nil, []uint32{movR0, movR1b, swi}},
+		{"level 2", []string{"LEVEL=2"}, []uint32{movR0, movR1a, swi}},
+		{"level 3 and trace", []string{"LEVEL=1+2", "TRACE"}, []uint32{movR0, movR1a, movR2, movR3, swi}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := parseWithDefines(t, conditionalSource, tt.defines...)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			got := encodeProgram(t, program)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d instructions, got %d: %08X", len(tt.want), len(got), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("instruction %d: expected 0x%08X, got 0x%08X", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+// TestConditionalAssembly_DefineIsConstant tests that a define can be used as an
+// assembler constant
+func TestConditionalAssembly_DefineIsConstant(t *testing.T) {
+	program, err := parseWithDefines(t, "_start:\n\tMOV R0, #SIZE\n", "SIZE=0x20")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got := encodeProgram(t, program); got[0] != 0xE3A00020 {
+		t.Errorf("expected MOV R0, #0x20 (0xE3A00020), got 0x%08X", got[0])
+	}
+	sym, ok := program.SymbolTable.Lookup("SIZE")
+	if !ok || sym.Type != parser.SymbolConstant {
+		t.Errorf("expected SIZE to be a constant symbol, got %+v", sym)
+	}
+}
+
+// TestConditionalAssembly_Errors tests invalid conditions and defines
+func TestConditionalAssembly_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		defines []string
+		wantErr string
+	}{
+		{"undefined symbol", ".if MISSING\n.endif\n", nil, "undefined symbol"},
+		{"label in condition", "start:\n.if start\n.endif\n", nil, "undefined symbol"},
+		{"missing expression", ".if\n.endif\n", nil, ".if requires an expression"},
+		{"bad expression", ".if 1 +\n.endif\n", nil, "invalid .if expression"},
+		{"unclosed", ".if 1\nMOV R0, #0\n", nil, "unclosed conditional"},
+		{"bad define name", "", []string{"1X"}, "invalid define"},
+		{"bad define value", "", []string{"X=zz"}, "invalid value for define X"},
+		{"define clashes with .equ", ".equ X, 2\n", []string{"X"}, "already defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWithDefines(t, tt.source, tt.defines...)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestPreprocessor_IfSkippedNotEvaluated tests that an .if inside a skipped block is not
+// evaluated, so it may name symbols that do not exist
+func TestPreprocessor_IfSkippedNotEvaluated(t *testing.T) {
+	pp := parser.NewPreprocessor(".")
+	content := ".ifdef FOO\n.if MISSING\n; A\n.endif\n.else\n; B\n.endif\n"
+	result, _ := pp.ProcessContent(content, "test.s")
+	if pp.Errors().HasErrors() {
+		t.Fatalf("unexpected errors: %v", pp.Errors())
+	}
+	if result != "; B\n" {
+		t.Errorf("expected only B, got %q", result)
+	}
+}
+
+// TestParseDefine tests splitting command-line defines
+func TestParseDefine(t *testing.T) {
+	tests := []struct {
+		spec  string
+		name  string
+		value uint32
+	}{
+		{"DEBUG", "DEBUG", 1},
+		{"LEVEL=3", "LEVEL", 3},
+		{"MASK=0xFF & ~0xF", "MASK", 0xF0},
+		{"NEG=-1", "NEG", 0xFFFFFFFF},
+		{"_x.y=0", "_x.y", 0},
+	}
+	for _, tt := range tests {
+		name, value, err := parser.ParseDefine(tt.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if name != tt.name || value != tt.value {
+			t.Errorf("%q: got %s=%d, want %s=%d", tt.spec, name, value, tt.name, tt.value)
+		}
+	}
+	for _, spec := range []string{"", "=1", "A-B", "X=", "X=Y"} {
+		if _, _, err := parser.ParseDefine(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func TestEquDirective_Expressions(t *testing.T) {
+	source := `
+	.equ BUFSIZE, 256
+	.equ DOUBLE, BUFSIZE*2
+	.equ MIXED, BUFSIZE + DOUBLE / 4 - 1
+	.equ NEG, -BUFSIZE
+	.equ NEWLINE, '\n'
+	.set COUNT, 0x10
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want uint32
+	}{
+		{"BUFSIZE", 256},
+		{"DOUBLE", 512},
+		{"MIXED", 256 + 512/4 - 1},
+		{"NEG", 0xFFFFFF00},
+		{"NEWLINE", '\n'},
+		{"COUNT", 0x10},
+	}
+	for _, tt := range tests {
+		sym, ok := program.SymbolTable.Lookup(tt.name)
+		if !ok || !sym.Defined {
+			t.Errorf("%s: not defined", tt.name)
+			continue
+		}
+		if sym.Type != parser.SymbolConstant {
+			t.Errorf("%s: expected SymbolConstant, got %v", tt.name, sym.Type)
+		}
+		if sym.Value != tt.want {
+			t.Errorf("%s: expected 0x%X, got 0x%X", tt.name, tt.want, sym.Value)
+		}
+	}
+}
+
+func TestEquDirective_ImmediateAndSpaceSize(t *testing.T) {
+	source := `
+	.equ BUFSIZE, 16
+	.equ WORDS, BUFSIZE/4
+	.org 0x8000
+_start:
+	MOV R0, #WORDS
+	SWI #0
+buffer:
+	.space BUFSIZE*2
+after:
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if op := instructionAt(t, program, 0x8000).Operands[1]; op != "#WORDS" {
+		t.Errorf("expected immediate operand #WORDS, got %s", op)
+	}
+
+	buffer, _ := program.SymbolTable.Get("buffer")
+	after, _ := program.SymbolTable.Get("after")
+	if after-buffer != 32 {
+		t.Errorf("expected .space BUFSIZE*2 to reserve 32 bytes, got %d", after-buffer)
+	}
+}
+
+func TestEquDirective_Redefinition(t *testing.T) {
+	program, err := parser.NewParser(`
+	.set LIMIT, 1
+	.set LIMIT, LIMIT+1
+`, "test.s").Parse()
+	if err != nil {
+		t.Fatalf(".set redefinition should be allowed: %v", err)
+	}
+	if value, _ := program.SymbolTable.Get("LIMIT"); value != 2 {
+		t.Errorf("expected LIMIT=2, got %d", value)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{".equ twice", ".equ LIMIT, 1\n.equ LIMIT, 2\n"},
+		{".equ after .set", ".set LIMIT, 1\n.equ LIMIT, 2\n"},
+		{".set over a label", "LIMIT:\n.set LIMIT, 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.NewParser(tt.source, "test.s").Parse()
+			if err == nil || !strings.Contains(err.Error(), "already defined") {
+				t.Errorf("expected redefinition error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEquDirective_InvalidExpression(t *testing.T) {
+	for _, source := range []string{
+		".equ X, UNDEFINED*2\n",
+		".equ X, 4/0\n",
+		".equ X, 4*\n",
+	} {
+		if _, err := parser.NewParser(source, "test.s").Parse(); err == nil {
+			t.Errorf("expected error for %q", source)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package parser_test
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -65,9 +68,7 @@ func TestErrorString(t *testing.T) {
 	result := err.Error()
 
 	expectedSubstrings := []string{
-		"test.s:5:10",
-		"error:",
-		"unexpected token",
+		"test.s:5:10: unexpected token\n",
 		"MOV R0 #1",
 	}
 
@@ -86,9 +87,7 @@ func TestErrorStringWithoutContext(t *testing.T) {
 	result := err.Error()
 
 	expectedSubstrings := []string{
-		"test.s:5:10",
-		"error:",
-		"unexpected token",
+		"test.s:5:10: unexpected token\n",
 	}
 
 	for _, substr := range expectedSubstrings {
@@ -341,3 +340,139 @@ func TestErrorListMixedErrorsAndWarnings(t *testing.T) {
 		t.Error("Expected warning output to not contain error message")
 	}
 }
+
+// parseErrors parses source and returns the error list, failing if parsing succeeded
+func parseErrors(t *testing.T, err error) *parser.ErrorList {
+	t.Helper()
+	var list *parser.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("expected an error list, got %v", err)
+	}
+	return list
+}
+
+// TestParse_ReportsAllErrorsWithPositions verifies that errors on different lines are
+// collected together, each with the line and column of the offending token
+func TestParse_ReportsAllErrorsWithPositions(t *testing.T) {
+	source := `	.org 0x8000
+main:
+	MOV R0, #1      ; fine
+	LDR R1, [R2, #4
+loop:	ADD R0, R0, R1
+loop:	SUB R0, R0, #1
+	MOV R0, R1 R2
+`
+	_, err := parser.NewParser(source, "two.s").Parse()
+	list := parseErrors(t, err)
+
+	want := []string{
+		"two.s:4:10: missing ']' in memory operand",
+		`two.s:6:1: symbol "loop" already defined at two.s:5:1`,
+		`two.s:7:13: unexpected "R2" after operands of MOV`,
+	}
+	if len(list.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %d:\n%s", len(want), len(list.Errors), list.Error())
+	}
+	for i, w := range want {
+		if got := strings.TrimSpace(list.Errors[i].Error()); got != w {
+			t.Errorf("error %d: expected %q, got %q", i, w, got)
+		}
+	}
+}
+
+// TestParse_PositionsAfterFirstLine verifies columns are 1-based on every line
+func TestParse_PositionsAfterFirstLine(t *testing.T) {
+	program, err := parser.NewParser("MOV R0, #1\n  ADD R0, R0, #1\n", "cols.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	for i, want := range []parser.Position{{Filename: "cols.s", Line: 1, Column: 1}, {Filename: "cols.s", Line: 2, Column: 3}} {
+		if got := program.Instructions[i].Pos; got != want {
+			t.Errorf("instruction %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+// TestParseFile_PositionsThroughPreprocessor verifies that lines removed by conditionals
+// and lines from included files are reported at their original file and line
+func TestParseFile_PositionsThroughPreprocessor(t *testing.T) {
+	dir := t.TempDir()
+	lib := "helper:\n\tMOV PC, LR\n\tMOV R1, R0\n"
+	if err := os.WriteFile(filepath.Join(dir, "lib.s"), []byte(lib), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mainSrc := `	.org 0x8000
+.ifdef DEBUG
+	MOV R7, #99
+.endif
+main:
+	MOV R0, #1
+	.include "lib.s"
+	LDR R1, [R0
+`
+	mainPath := filepath.Join(dir, "main.s")
+	if err := os.WriteFile(mainPath, []byte(mainSrc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := parser.ParseFileSimple(mainPath)
+	list := parseErrors(t, err)
+	want := []string{
+		"main.s:8:10: missing ']' in memory operand",
+	}
+	if len(list.Errors) != len(want) || strings.TrimSpace(list.Errors[0].Error()) != want[0] {
+		t.Fatalf("expected %q, got:\n%s", want, list.Error())
+	}
+
+	// Without the error, every instruction maps back to its own file and line
+	fixed := strings.Replace(mainSrc, "[R0\n", "[R0]\n", 1)
+	if err := os.WriteFile(mainPath, []byte(fixed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	program, _, err := parser.ParseFileSimple(mainPath)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	wantPos := []struct {
+		file    string
+		line    int
+		rawLine string
+	}{
+		{"main.s", 6, "\tMOV R0, #1"},
+		{"lib.s", 2, "\tMOV PC, LR"},
+		{"lib.s", 3, "\tMOV R1, R0"},
+		{"main.s", 8, "\tLDR R1, [R0]"},
+	}
+	if len(program.Instructions) != len(wantPos) {
+		t.Fatalf("expected %d instructions, got %d", len(wantPos), len(program.Instructions))
+	}
+	for i, w := range wantPos {
+		inst := program.Instructions[i]
+		if inst.Pos.Filename != w.file || inst.Pos.Line != w.line || inst.RawLine != w.rawLine {
+			t.Errorf("instruction %d: expected %s:%d %q, got %s %q", i, w.file, w.line, w.rawLine, inst.Pos, inst.RawLine)
+		}
+	}
+}
+
+// TestParseReader_ErrorsNameSource verifies that source read through ParseReader is
+// reported under the given filename and still honours defines
+func TestParseReader_ErrorsNameSource(t *testing.T) {
+	opts := parser.DefaultParseFileOptions()
+	opts.Defines = []string{"BAD"}
+	source := "_start:\n\tMOV R0, #1\n.ifdef BAD\n_start:\n.endif\n"
+	_, _, err := parser.ParseReader(strings.NewReader(source), "<stdin>", opts)
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if !strings.Contains(err.Error(), "<stdin>:4") {
+		t.Errorf("expected the error to name <stdin>:4, got: %v", err)
+	}
+
+	program, _, err := parser.ParseReader(strings.NewReader(source), "<stdin>", parser.DefaultParseFileOptions())
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(program.Instructions) != 1 || program.Instructions[0].Pos.Filename != "<stdin>" {
+		t.Errorf("expected one instruction from <stdin>, got %+v", program.Instructions)
+	}
+}
@@ -0,0 +1,106 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	symbols := map[string]uint32{"start": 0x8000, "end": 0x8010, "SIZE": 16}
+	lookup := func(name string) (uint32, error) {
+		if v, ok := symbols[name]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("undefined symbol: %q", name)
+	}
+
+	tests := []struct {
+		expr string
+		want uint32
+	}{
+		{"42", 42},
+		{"0x10 + 0b11", 19},
+		{"4*8+1", 33},
+		{"4*(8+1)", 36},
+		{"1<<4", 16},
+		{"0x80 >> 3", 0x10},
+		{"0xF0 & 0x3C", 0x30},
+		{"0xF0 | 0x0F", 0xFF},
+		{"0xFF ^ 0x0F", 0xF0},
+		{"17 % 5", 2},
+		{"20 / 3", 6},
+		{"end - start", 0x10},
+		{"start + SIZE*2", 0x8020},
+		{"1 + 2 << 3", 24}, // + binds tighter than <<
+		{"1 | 2 & 3", 3},   // & binds tighter than |
+		{"-1", 0xFFFFFFFF}, // negative values wrap to their 32-bit pattern
+		{"~0", 0xFFFFFFFF},
+		{"-(SIZE) + 32", 16},
+		{"'A' + 1", 'B'},
+		{"0xFFFFFFFF", 0xFFFFFFFF},
+		{"SIZE == 16", 1},
+		{"SIZE != 16", 0},
+		{"SIZE > 8 && SIZE <= 16", 1},
+		{"SIZE >= 32 || end - start == SIZE", 1},
+		{"1 << 2 < 5", 1}, // << binds tighter than <
+		{"6 & 3 && 1", 1}, // & binds tighter than &&
+		{"-1 < 0", 1},     // ordering comparisons are signed
+		{"!0", 1},
+		{"!SIZE", 0},
+	}
+	for _, tt := range tests {
+		got, err := parser.EvaluateExpression(tt.expr, lookup)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got 0x%X, want 0x%X", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{
+		"", "1 +", "(1", "1)", "4 / 0", "4 % 0", "1 << 32",
+		"0xFFFFFFFF + 1", "0x10000 * 0x10000", "missing", "1 2",
+	} {
+		if _, err := parser.EvaluateExpression(expr, lookup); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestDirectiveExpressions(t *testing.T) {
+	source := `
+	.equ BASE, 0x100
+	.org BASE << 7
+table:
+	.word table + 8, (BASE | 1) * 2
+	.byte BASE >> 4, 'a' - 32
+	.align 1 + 1
+after:
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if program.Origin != 0x8000 {
+		t.Errorf("expected origin 0x8000, got 0x%X", program.Origin)
+	}
+
+	var words []string
+	for _, dir := range program.Directives {
+		if dir.Name == ".word" {
+			words = dir.Args
+		}
+	}
+	if len(words) != 2 || words[0] != "table + 8" || words[1] != "( BASE | 1 ) * 2" {
+		t.Errorf("expected two grouped .word arguments, got %q", words)
+	}
+
+	// 8 bytes of .word + 2 bytes of .byte, aligned to 4
+	if after, _ := program.SymbolTable.Get("after"); after != 0x800C {
+		t.Errorf("expected after=0x800C, got 0x%X", after)
+	}
+}
@@ -0,0 +1,113 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func TestIncbin_Errors(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "src")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), []byte{1, 2, 3}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.bin"), []byte{9}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.bin"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		baseDir string
+		wantErr string
+	}{
+		{"no source directory", `.incbin "data.bin"`, "", "needs a source file"},
+		{"escapes directory", `.incbin "../secret.bin"`, dir, "escapes the source directory"},
+		{"missing file", `.incbin "missing.bin"`, dir, "failed to read missing.bin"},
+		{"unquoted", `.incbin data.bin`, dir, "must be a quoted string"},
+		{"no arguments", `.incbin`, dir, "requires a file name"},
+		{"skip too large", `.incbin "data.bin", 4`, dir, "beyond the end"},
+		{"count too large", `.incbin "data.bin", 1, 3`, dir, "beyond the end"},
+		{"in bss", ".bss\n.incbin \"data.bin\"", dir, "not allowed in .bss"},
+		{"directory", `.incbin "dir.bin"`, dir, "dir.bin is not a regular file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.NewParser(tt.input+"\n", "test.s")
+			p.SetBaseDir(tt.baseDir)
+			_, err := p.Parse()
+			if err == nil {
+				t.Fatalf("expected %q to be rejected", tt.input)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestIncbin_Size(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), []byte("abcdef"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := parser.NewParser(".org 0x8000\n.incbin \"data.bin\", 2\nend:\n", "test.s")
+	p.SetBaseDir(dir)
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got := string(program.Directives[1].Data); got != "cdef" {
+		t.Errorf("expected embedded data %q, got %q", "cdef", got)
+	}
+	if end := program.SymbolTable.GetAllSymbols()["end"].Value; end != 0x8004 {
+		t.Errorf("expected end at 0x00008004, got 0x%08X", end)
+	}
+}
+
+// TestIncbin_LargeFile tests that a file far over MaxIncbinSize is rejected from its size,
+// and that a small skip and count range of it is read on its own
+func TestIncbin_LargeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "huge.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sparse, so the 4GB takes no disk space; only the marker at the end is written
+	const size = 4 << 30
+	if _, err := f.WriteAt([]byte("tail"), size-4); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.NewParser(".org 0x8000\n.incbin \"huge.bin\"\n", "test.s")
+	p.SetBaseDir(dir)
+	if _, err := p.Parse(); err == nil || !strings.Contains(err.Error(), "huge.bin is 4294967296 bytes, more than the 1048576 allowed") {
+		t.Errorf("expected the file to be rejected by size, got %v", err)
+	}
+
+	p = parser.NewParser(".org 0x8000\n.incbin \"huge.bin\", 0xFFFFFFFC, 4\n", "test.s")
+	p.SetBaseDir(dir)
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got := string(program.Directives[1].Data); got != "tail" {
+		t.Errorf("expected embedded data %q, got %q", "tail", got)
+	}
+}
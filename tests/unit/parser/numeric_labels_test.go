@@ -0,0 +1,137 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// instructionAt returns the parsed instruction at addr
+func instructionAt(t *testing.T, program *parser.Program, addr uint32) *parser.Instruction {
+	t.Helper()
+	for _, inst := range program.Instructions {
+		if inst.Address == addr {
+			return inst
+		}
+	}
+	t.Fatalf("no instruction at 0x%X", addr)
+	return nil
+}
+
+func TestNumericLabels_LoopAndForwardSkip(t *testing.T) {
+	source := `
+	.org 0x8000
+_start:
+	MOV R0, #3          ; 0x8000
+1:	SUBS R0, R0, #1     ; 0x8004
+	BNE 1b              ; 0x8008 -> 0x8004
+	B 1f                ; 0x800C -> 0x8014
+	MOV R1, #99         ; 0x8010 (skipped)
+1:
+	SWI #0              ; 0x8014
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if op := instructionAt(t, program, 0x8008).Operands[0]; op != "0x8004" {
+		t.Errorf("BNE 1b: expected target 0x8004, got %s", op)
+	}
+	if op := instructionAt(t, program, 0x800C).Operands[0]; op != "0x8014" {
+		t.Errorf("B 1f: expected target 0x8014, got %s", op)
+	}
+}
+
+func TestNumericLabels_NearestDefinitionWins(t *testing.T) {
+	source := `
+	.org 0x8000
+1:	MOV R0, #0          ; 0x8000
+1:	MOV R0, #1          ; 0x8004
+	B 1b                ; 0x8008 -> 0x8004 (nearest backward)
+	B 1f                ; 0x800C -> 0x8010 (nearest forward)
+1:	MOV R0, #2          ; 0x8010
+1:	B 1b                ; 0x8014 -> 0x8014 (label on the same line)
+2:	B 2f                ; 0x8018 -> 0x801C
+2:	.word 2b            ; 0x801C
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		addr uint32
+		want string
+	}{
+		{0x8008, "0x8004"},
+		{0x800C, "0x8010"},
+		{0x8014, "0x8014"},
+		{0x8018, "0x801C"},
+	}
+	for _, tt := range tests {
+		if op := instructionAt(t, program, tt.addr).Operands[0]; op != tt.want {
+			t.Errorf("instruction at 0x%X: expected %s, got %s", tt.addr, tt.want, op)
+		}
+	}
+
+	for _, dir := range program.Directives {
+		if dir.Name == ".word" && dir.Args[0] != "0x801C" {
+			t.Errorf(".word 2b: expected 0x801C, got %s", dir.Args[0])
+		}
+	}
+}
+
+func TestNumericLabels_NotInSymbolTable(t *testing.T) {
+	source := `
+	.org 0x8000
+main:
+1:	LDR R0, =1b
+	B 1b
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	symbols := program.SymbolTable.GetAllSymbols()
+	if len(symbols) != 1 {
+		t.Errorf("expected only 'main' in the symbol table, got %v", symbols)
+	}
+	if op := instructionAt(t, program, 0x8000).Operands[1]; op != "=0x8000" {
+		t.Errorf("LDR =1b: expected =0x8000, got %s", op)
+	}
+}
+
+func TestNumericLabels_UndefinedReference(t *testing.T) {
+	source := `
+	.org 0x8000
+1:	B 1f
+`
+	_, err := parser.NewParser(source, "test.s").Parse()
+	if err == nil {
+		t.Fatal("expected error for forward reference with no following label")
+	}
+	if !strings.Contains(err.Error(), "undefined numeric label reference: 1f") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNumericLabels_BinaryLiteralsUnaffected(t *testing.T) {
+	source := `
+	.org 0x8000
+	MOV R0, #0b101
+	MOV R1, #0xfb
+`
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if op := instructionAt(t, program, 0x8000).Operands[1]; op != "#0b101" {
+		t.Errorf("expected #0b101, got %s", op)
+	}
+	if op := instructionAt(t, program, 0x8004).Operands[1]; op != "#0xfb" {
+		t.Errorf("expected #0xfb, got %s", op)
+	}
+}
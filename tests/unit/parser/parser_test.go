@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/parser"
@@ -223,6 +224,65 @@ func TestParser_Comments(t *testing.T) {
 	}
 }
 
+func TestParser_MixedCommentStyles(t *testing.T) {
+	input := `; semicolon
+@ at sign
+// double slash
+/* block */
+_start:	MOV R0, #1      ; trailing semicolon
+	MOV R1, #2      @ trailing at
+	MOV R2, #3      // trailing slashes
+	/* leading */ MOV R3, #4
+	/* spans
+	   several
+	   lines */ MOV R4, #5
+	ADD R5, R4, /* inline */ R3 /* trailing block */
+msg:	.asciz "a */ b /* c ; d @ e // f"
+	MOV R6, #';'    ; character literal
+	SWI #0`
+	p := parser.NewParser(input, "test.s")
+
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	want := []struct {
+		mnemonic string
+		operands []string
+		line     int
+	}{
+		{"MOV", []string{"R0", "#1"}, 5},
+		{"MOV", []string{"R1", "#2"}, 6},
+		{"MOV", []string{"R2", "#3"}, 7},
+		{"MOV", []string{"R3", "#4"}, 8},
+		{"MOV", []string{"R4", "#5"}, 11},
+		{"ADD", []string{"R5", "R4", "R3"}, 12},
+		{"MOV", []string{"R6", "#';'"}, 14},
+		{"SWI", []string{"#0"}, 15},
+	}
+	if len(program.Instructions) != len(want) {
+		t.Fatalf("expected %d instructions, got %d", len(want), len(program.Instructions))
+	}
+	for i, w := range want {
+		inst := program.Instructions[i]
+		if inst.Mnemonic != w.mnemonic || strings.Join(inst.Operands, ",") != strings.Join(w.operands, ",") || inst.Pos.Line != w.line {
+			t.Errorf("instruction %d: expected %s %v on line %d, got %s %v on line %d",
+				i, w.mnemonic, w.operands, w.line, inst.Mnemonic, inst.Operands, inst.Pos.Line)
+		}
+	}
+	if c := program.Instructions[5].Comment; !strings.Contains(c, "trailing block") {
+		t.Errorf("expected the trailing block comment to be kept, got %q", c)
+	}
+
+	if len(program.Directives) != 1 || program.Directives[0].Pos.Line != 13 {
+		t.Fatalf("expected .asciz on line 13, got %+v", program.Directives)
+	}
+	if arg := program.Directives[0].Args[0]; !strings.Contains(arg, "a */ b /* c ; d @ e // f") {
+		t.Errorf("expected the string to keep its comment markers, got %q", arg)
+	}
+}
+
 func TestParser_ForwardReference(t *testing.T) {
 	input := `B end
 	MOV R0, #1
@@ -263,8 +323,8 @@ func TestParser_UndefinedLabel(t *testing.T) {
 			break
 		}
 	}
-	// Note: Currently the parser doesn't track label references in operands
-	// This would be added in a future enhancement
+	// Operand uses are recorded with AddUse for the cross-reference rather than as
+	// forward references, so an undefined label does not fail the parse
 	_ = found
 }
 
@@ -356,3 +416,51 @@ SWI #0`
 		t.Errorf("constant 'MAX' not found")
 	}
 }
+
+func TestParser_InstructionColumns(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+	}{
+		{"plain", "MOV R0, #42", 1, 12},
+		{"indented", "\tmov r0, r1", 2, 12},
+		{"label", "loop: ADD R0, R0, #1 ; count", 7, 21},
+		{"label on tab", "start:\tLDREQB R2, [R1, #4]!", 8, 28},
+		{"no operands", "  NOP  ; idle", 3, 6},
+		{"register list", "PUSH {R4-R6, LR}", 1, 17},
+		{"literal", "LDR R0, =0x1234 @ constant", 1, 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := parser.NewParser(tt.input, "test.s").Parse()
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			start, end := program.Instructions[0].Columns()
+			if start != tt.start || end != tt.end {
+				t.Errorf("expected columns [%d, %d), got [%d, %d) (%q)",
+					tt.start, tt.end, start, end, tt.input[start-1:end-1])
+			}
+		})
+	}
+}
+
+func TestParser_InstructionColumnsMacro(t *testing.T) {
+	input := `.macro ZERO reg
+	MOV \reg, #0
+.endm
+main:  ZERO R3   ; clear
+`
+	program, err := parser.NewParser(input, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	inst := program.Instructions[0]
+	// The substituted MOV has no columns of its own in the macro body line
+	start, end := inst.Columns()
+	if start != 2 || end != len(inst.RawLine)+1 {
+		t.Errorf("expected the whole body line, got [%d, %d) of %q", start, end, inst.RawLine)
+	}
+}
@@ -3,6 +3,7 @@ package parser_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/parser"
@@ -163,6 +164,26 @@ func TestPreprocessor_ProcessContent_Comments(t *testing.T) {
 	}
 }
 
+// TestPreprocessor_DirectivesInCommentsIgnored verifies commented-out directives have no effect
+func TestPreprocessor_DirectivesInCommentsIgnored(t *testing.T) {
+	pp := parser.NewPreprocessor(".")
+
+	content := "/* disabled:\n.include \"missing.s\"\n.ifdef NOPE */\nMOV R0, #1\n; .endif\n.ascii \"/*\"\n.ifdef NOPE\nMOV R1, #2\n.endif\n"
+	result, err := pp.ProcessContent(content, "test.s")
+	if err != nil {
+		t.Fatalf("ProcessContent failed: %v", err)
+	}
+	if pp.Errors().HasErrors() {
+		t.Fatalf("unexpected errors: %v", pp.Errors())
+	}
+	if !strings.Contains(result, "MOV R0, #1") || strings.Contains(result, "MOV R1, #2") {
+		t.Errorf("expected only the commented-out directives to be ignored, got:\n%s", result)
+	}
+	if lines := pp.LineMap(); len(lines) < 4 || lines[3].Line != 4 {
+		t.Errorf("expected MOV R0 to map to line 4, got %v", lines)
+	}
+}
+
 // TestPreprocessor_MultipleDefines verifies multiple symbol definitions
 func TestPreprocessor_MultipleDefines(t *testing.T) {
 	pp := parser.NewPreprocessor(".")
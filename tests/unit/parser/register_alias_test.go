@@ -0,0 +1,81 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/encoder"
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func TestRegisterAlias_Encoding(t *testing.T) {
+	input := `
+count   .req R4
+ptr     .req r5
+frame   .req sp
+tmp     .req count
+_start:
+        ADD tmp, count, #1
+        LDR R0, [ptr, #4]
+        STR count, [frame, #-4]!
+        PUSH {count, ptr, LR}
+        .unreq count
+count:  MOV R1, count
+`
+	program, err := parser.NewParser(input, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	wantOperands := [][]string{
+		{"R4", "R4", "#1"},
+		{"R0", "[R5, #4]"},
+		{"R4", "[SP, #-4]!"},
+		{"{R4,R5,LR}"},
+		{"R1", "count"}, // count is an ordinary label again after .unreq
+	}
+	for i, want := range wantOperands {
+		if got := program.Instructions[i].Operands; strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("instruction %d: operands %q, want %q", i, got, want)
+		}
+	}
+
+	enc := encoder.NewEncoder(program.SymbolTable)
+	wantOpcodes := []uint32{
+		0xE2844001, // ADD R4, R4, #1
+		0xE5950004, // LDR R0, [R5, #4]
+		0xE52D4004, // STR R4, [SP, #-4]!
+		0xE92D4030, // PUSH {R4, R5, LR}
+	}
+	for i, want := range wantOpcodes {
+		inst := program.Instructions[i]
+		got, err := enc.EncodeInstruction(inst, inst.Address)
+		if err != nil {
+			t.Fatalf("instruction %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("instruction %d: got 0x%08X, want 0x%08X", i, got, want)
+		}
+	}
+}
+
+func TestRegisterAlias_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"not a register", "x .req 5\n", ".req x needs a register"},
+		{"redefined", "x .req R1\nx .req R2\n", "register alias x is already R1"},
+		{"unknown unreq", ".unreq x\n", "x is not a register alias"},
+		{"missing name", ".req R1\n", ".req needs a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.NewParser(tt.input, "test.s").Parse()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+// parseSource parses source and fails the test on errors
+func parseSource(t *testing.T, source string) *parser.Program {
+	t.Helper()
+	program, err := parser.NewParser(source, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	return program
+}
+
+func TestRept_WordDirective(t *testing.T) {
+	program := parseSource(t, `
+	.org 0x8000
+table:
+	.rept 4
+	.word 0xCAFE
+	.endr
+after:
+	MOV R0, #0
+`)
+
+	var words []*parser.Directive
+	for _, dir := range program.Directives {
+		if dir.Name == ".word" {
+			words = append(words, dir)
+		}
+	}
+	if len(words) != 4 {
+		t.Fatalf("expected 4 .word directives, got %d", len(words))
+	}
+	for i, dir := range words {
+		if want := uint32(0x8000 + 4*i); dir.Address != want {
+			t.Errorf("word %d at 0x%08X, want 0x%08X", i, dir.Address, want)
+		}
+		if dir.Pos.Line != 5 {
+			t.Errorf("word %d reported at line %d, want the .word line 5", i, dir.Pos.Line)
+		}
+	}
+	if after, _ := program.SymbolTable.Lookup("after"); after.Value != 0x8010 {
+		t.Errorf("expected after at 0x8010, got 0x%08X", after.Value)
+	}
+}
+
+func TestRept_CounterMakesUniqueLabels(t *testing.T) {
+	program := parseSource(t, `
+	.org 0x8000
+	.rept 3
+entry\@:
+	ADD R0, R0, #1
+	.endr
+`)
+
+	for i, name := range []string{"entry0", "entry1", "entry2"} {
+		sym, ok := program.SymbolTable.Lookup(name)
+		if !ok || sym.Value != uint32(0x8000+4*i) {
+			t.Errorf("expected %s at 0x%08X, got %+v", name, 0x8000+4*i, sym)
+		}
+	}
+}
+
+func TestRept_InsideMacro(t *testing.T) {
+	program := parseSource(t, `
+	.macro fill reg, count
+	.rept \count
+	ADD \reg, \reg, #1
+	.endr
+	.endm
+
+	.org 0x8000
+_start:
+	fill R1, 2
+	fill R2, 3
+	SWI #0
+`)
+
+	var ops []string
+	for _, inst := range program.Instructions {
+		ops = append(ops, inst.Mnemonic+" "+strings.Join(inst.Operands, ","))
+	}
+	want := "ADD R1,R1,#1|ADD R1,R1,#1|ADD R2,R2,#1|ADD R2,R2,#1|ADD R2,R2,#1|SWI #0"
+	if got := strings.Join(ops, "|"); got != want {
+		t.Errorf("unexpected expansion:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestRept_Errors(t *testing.T) {
+	tests := []struct {
+		name, source, message string
+	}{
+		{"unclosed", ".rept 2\n\tNOP\n", ".rept without matching .endr"},
+		{"stray endr", "\tNOP\n\t.endr\n", ".endr without matching .rept"},
+		{"bad count", ".rept lots\n\tNOP\n.endr\n", "invalid .rept count"},
+		{"too large", ".rept 100001\n\t.word 0\n.endr\n", "more than 100000 lines"},
+		{"nested too large", ".rept 1000\n.rept 1000\n\t.word 0\n.endr\n.endr\n", "more than 100000 lines"},
+		{"nested macro bomb", nestedMacroBomb, "more than 100000 lines"},
+		{"recursive macro", ".macro loop\n\tloop\n.endm\n\tloop\n", "recursive macro call"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.NewParser(tt.source, "test.s").Parse()
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected an error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+// nestedMacroBomb defines m1..m6, each invoking the one before ten times, so m6 expands to
+// a million instructions
+var nestedMacroBomb = func() string {
+	var sb strings.Builder
+	sb.WriteString(".macro m1\n\tNOP\n.endm\n")
+	for level := 2; level <= 6; level++ {
+		fmt.Fprintf(&sb, ".macro m%d\n", level)
+		for range 10 {
+			fmt.Fprintf(&sb, "\tm%d\n", level-1)
+		}
+		sb.WriteString(".endm\n")
+	}
+	sb.WriteString("\tm6\n")
+	return sb.String()
+}()
@@ -0,0 +1,123 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/parser"
+)
+
+func TestSections_MixedOrderPlacement(t *testing.T) {
+	input := `.data
+greeting:   .asciz "hi"
+.bss
+counter:    .space 4
+.text
+_start:
+	LDR R0, =greeting
+	LDR R1, =buffer
+	SWI #0
+.data
+table:      .word 1, 2, 3
+.bss
+buffer:     .space 16
+.text
+done:
+	MOV PC, LR
+`
+	program, err := parser.NewParser(input, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	// .data is laid out in source order at the data segment; .bss follows it word aligned
+	want := map[string]uint32{
+		"_start":   0x0,
+		"done":     0xC,
+		"greeting": parser.DataSectionStart,
+		"table":    parser.DataSectionStart + 3,
+		"counter":  parser.DataSectionStart + 16,
+		"buffer":   parser.DataSectionStart + 20,
+	}
+	for name, addr := range want {
+		sym, ok := program.SymbolTable.Lookup(name)
+		if !ok {
+			t.Errorf("%s not defined", name)
+			continue
+		}
+		if sym.Value != addr {
+			t.Errorf("%s: got 0x%X, want 0x%X", name, sym.Value, addr)
+		}
+	}
+
+	if program.DataSize != 15 || program.BSSStart != parser.DataSectionStart+16 || program.BSSSize != 20 {
+		t.Errorf("unexpected layout: data size %d, bss 0x%X+%d", program.DataSize, program.BSSStart, program.BSSSize)
+	}
+	if !program.OriginSet || program.Origin != 0 {
+		t.Errorf("expected .text to set origin 0, got 0x%X (set %v)", program.Origin, program.OriginSet)
+	}
+
+	for _, d := range program.Directives {
+		if d.Name == ".space" && d.Label == "buffer" && (d.Section != parser.SectionBSS || d.Address != parser.DataSectionStart+20) {
+			t.Errorf("buffer .space recorded in %s at 0x%X", d.Section, d.Address)
+		}
+	}
+}
+
+func TestSections_SectionDirective(t *testing.T) {
+	input := `.org 0x8000
+_start:
+	SWI #0
+.section .data
+value:  .word 7
+.section .text
+next:
+	SWI #0
+`
+	program, err := parser.NewParser(input, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	for name, addr := range map[string]uint32{"value": parser.DataSectionStart, "next": 0x8004} {
+		if sym, _ := program.SymbolTable.Lookup(name); sym == nil || sym.Value != addr {
+			t.Errorf("%s: expected 0x%X, got %+v", name, addr, sym)
+		}
+	}
+	if program.Origin != 0x8000 {
+		t.Errorf("expected .org origin to be kept, got 0x%X", program.Origin)
+	}
+}
+
+func TestSections_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"data in bss", ".bss\nx: .word 1\n", ".word is not allowed in .bss"},
+		{"instruction in data", ".data\n\tMOV R0, #1\n", "instruction MOV is not allowed in .data"},
+		{"instruction in bss", ".bss\n\tSWI #0\n", "instruction SWI is not allowed in .bss"},
+		{"unknown section", ".section .rodata\n", "unsupported section .rodata"},
+		{"data too large", ".data\nbig: .space 0x10001\n", "test.s:2:6: .data section is 65537 bytes, more than the 65536-byte data segment"},
+		{"bss too large", ".data\n.word 1\n.bss\nsmall: .space 4\nbig: .space 0x10000\n", "test.s:5:6: .data and .bss sections need 65544 bytes, more than the 65536-byte data segment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.NewParser(tt.input, "test.s").Parse()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestSections_FillDataSegment tests that .data and .bss may use the whole data segment
+func TestSections_FillDataSegment(t *testing.T) {
+	program, err := parser.NewParser(".data\n.space 0x8000\n.bss\nbuf: .space 0x8000\n", "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if end := program.BSSStart + program.BSSSize; end != parser.DataSectionStart+0x10000 {
+		t.Errorf("expected .bss to end at the data segment end, got 0x%X", end)
+	}
+}
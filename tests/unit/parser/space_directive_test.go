@@ -167,8 +167,8 @@ buffer:
 	}
 
 	// This should work fine - label on line before directive
-	if bufferSym.Value != 0 {
-		t.Errorf("buffer should be at address 0, got 0x%X", bufferSym.Value)
+	if bufferSym.Value != parser.DataSectionStart {
+		t.Errorf("buffer should be at the start of .data, got 0x%X", bufferSym.Value)
 	}
 }
 
@@ -205,9 +205,9 @@ label3:     .space 4`
 	}
 
 	// Verify addresses
-	expectedLabel1 := uint32(0)
-	expectedLabel2 := uint32(4) // After .space 4
-	expectedLabel3 := uint32(4) // Same as label2 (standalone label)
+	expectedLabel1 := uint32(parser.DataSectionStart)
+	expectedLabel2 := expectedLabel1 + 4 // After .space 4
+	expectedLabel3 := expectedLabel1 + 4 // Same as label2 (standalone label)
 
 	t.Logf("DEBUG: label1=0x%X, label2=0x%X, label3=0x%X", label1.Value, label2.Value, label3.Value)
 
@@ -263,3 +263,42 @@ func TestSymbolTable_GetRelocations_Empty(t *testing.T) {
 		t.Errorf("Expected 0 relocations, got %d", len(relocs))
 	}
 }
+
+func TestSymbolTable_Uses(t *testing.T) {
+	input := `	.equ LIMIT, 4
+	.org 0x8000
+loop:
+	CMP R0, #LIMIT
+	MOV R1, R1, LSL #1
+	MSR CPSR_f, R1
+	BNE loop
+	B 1f
+1:	SWI #0
+table:
+	.word loop, missing + 4
+`
+	program, err := parser.NewParser(input, "test.s").Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	st := program.SymbolTable
+
+	uses := st.Uses("loop")
+	if len(uses) != 2 {
+		t.Fatalf("expected loop to be used twice, got %v", uses)
+	}
+	if uses[0].Line != 7 || uses[1].Line != 11 {
+		t.Errorf("expected uses of loop on lines 7 and 11, got %v", uses)
+	}
+	if uses := st.Uses("LIMIT"); len(uses) != 1 || uses[0].Line != 4 {
+		t.Errorf("expected one use of LIMIT on line 4, got %v", uses)
+	}
+	if uses := st.Uses("table"); len(uses) != 0 {
+		t.Errorf("expected a definition not to count as a use, got %v", uses)
+	}
+
+	undefined := st.UndefinedUses()
+	if len(undefined) != 1 || undefined[0] != "missing" {
+		t.Errorf("expected only missing to be undefined, got %v", undefined)
+	}
+}
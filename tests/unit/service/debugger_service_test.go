@@ -21,6 +21,17 @@ func TestNewDebuggerService(t *testing.T) {
 	}
 }
 
+// TestNewDebuggerService_StackGuard tests that the GUI service uses the same stack guard
+// default as the command line
+func TestNewDebuggerService_StackGuard(t *testing.T) {
+	machine := vm.NewVM()
+	service.NewDebuggerService(machine)
+
+	if machine.StackGuard != vm.DefaultStackGuard {
+		t.Errorf("expected StackGuard=%v, got %v", vm.DefaultStackGuard, machine.StackGuard)
+	}
+}
+
 func TestDebuggerService_LoadProgram(t *testing.T) {
 	machine := vm.NewVM()
 	machine.InitializeStack(vm.StackSegmentStart + vm.StackSegmentSize) // Valid stack top
@@ -707,10 +718,14 @@ function:
 		t.Fatalf("Step failed: %v", err)
 	}
 
-	// Now step out should set the debugger to step out mode
+	// Step out runs the rest of the function and stops after the BL
 	err = svc.StepOut()
 	if err != nil {
-		t.Errorf("StepOut failed: %v", err)
+		t.Fatalf("StepOut failed: %v", err)
+	}
+	regs := svc.GetRegisterState()
+	if regs.PC != 0x8004 || regs.Registers[2] != 3 {
+		t.Errorf("expected to return to 0x8004 with R2=3, got PC=0x%08X R2=%d", regs.PC, regs.Registers[2])
 	}
 }
 
@@ -0,0 +1,67 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestBKPT_StopsWithImmediate(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE3A00001) // MOV R0, #1
+	v.Memory.WriteWord(0x8004, 0xE1212374) // BKPT #0x1234
+	v.Memory.WriteWord(0x8008, 0xE3A00002) // MOV R0, #2
+
+	if err := v.Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if v.State != vm.StateBreakpoint {
+		t.Fatalf("expected StateBreakpoint, got %v", v.State)
+	}
+	if v.LastBKPT == nil {
+		t.Fatal("expected LastBKPT to be recorded")
+	}
+	if v.LastBKPT.Immediate != 0x1234 {
+		t.Errorf("expected immediate 0x1234, got 0x%X", v.LastBKPT.Immediate)
+	}
+	if v.LastBKPT.Address != 0x8004 {
+		t.Errorf("expected BKPT address 0x8004, got 0x%08X", v.LastBKPT.Address)
+	}
+	if v.CPU.PC != 0x8008 {
+		t.Errorf("expected PC past the BKPT (0x8008), got 0x%08X", v.CPU.PC)
+	}
+	if v.CPU.R[0] != 1 {
+		t.Errorf("expected R0=1, got %d", v.CPU.R[0])
+	}
+
+	// Resuming continues with the next instruction and clears the trap
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if v.LastBKPT != nil {
+		t.Error("expected LastBKPT to be cleared by the next step")
+	}
+	if v.CPU.R[0] != 2 {
+		t.Errorf("expected R0=2 after resuming, got %d", v.CPU.R[0])
+	}
+}
+
+func TestBKPTImmediate(t *testing.T) {
+	tests := []struct {
+		opcode uint32
+		want   uint16
+	}{
+		{0xE1200070, 0},
+		{0xE120007F, 0xF},
+		{0xE1212374, 0x1234},
+		{0xE12FFF7F, 0xFFFF},
+	}
+	for _, tt := range tests {
+		if got := vm.BKPTImmediate(tt.opcode); got != tt.want {
+			t.Errorf("BKPTImmediate(0x%08X) = 0x%X, want 0x%X", tt.opcode, got, tt.want)
+		}
+	}
+}
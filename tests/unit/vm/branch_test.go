@@ -1,6 +1,7 @@
 package vm_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/vm"
@@ -229,8 +230,8 @@ func TestBX_ConditionalNotTaken(t *testing.T) {
 	}
 }
 
-func TestBX_ClearBit0(t *testing.T) {
-	// BX R2 - verify bit 0 is cleared (ARM/Thumb mode indicator)
+func TestBX_ThumbTargetRejected(t *testing.T) {
+	// BX R2 with bit 0 set would enter Thumb state, which is not emulated
 	v := vm.NewVM()
 	v.CPU.R[2] = 0x8101 // Odd address (bit 0 set)
 	v.CPU.PC = 0x8000
@@ -239,12 +240,80 @@ func TestBX_ClearBit0(t *testing.T) {
 	opcode := uint32(0xE12FFF12)
 	setupCodeWrite(v)
 	v.Memory.WriteWord(0x8000, opcode)
-	v.Step()
+	err := v.Step()
 
-	// PC should have bit 0 cleared (aligned to halfword)
-	expectedPC := uint32(0x8100)
-	if v.CPU.PC != expectedPC {
-		t.Errorf("expected PC=0x%X (bit 0 cleared), got PC=0x%X", expectedPC, v.CPU.PC)
+	if err == nil || !strings.Contains(err.Error(), "Thumb not supported") {
+		t.Fatalf("expected Thumb not supported error, got %v", err)
+	}
+	if v.CPU.PC != 0x8000 {
+		t.Errorf("expected PC to stay at 0x8000, got PC=0x%X", v.CPU.PC)
+	}
+}
+
+func TestBLX_Register(t *testing.T) {
+	// BLX R3 - call the address in R3, setting LR to the next instruction
+	v := vm.NewVM()
+	v.CPU.R[3] = 0x9000
+	v.CPU.PC = 0x8000
+
+	// BLX R3 (E12FFF33)
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE12FFF33)
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+
+	if v.CPU.PC != 0x9000 {
+		t.Errorf("expected PC=0x9000, got PC=0x%X", v.CPU.PC)
+	}
+	if v.CPU.GetLR() != 0x8004 {
+		t.Errorf("expected LR=0x8004, got LR=0x%X", v.CPU.GetLR())
+	}
+}
+
+func TestBLX_ThumbTargetRejected(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.R[3] = 0x9001
+	v.CPU.PC = 0x8000
+	v.CPU.SetLR(0x1234)
+
+	// BLX R3 (E12FFF33)
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE12FFF33)
+	err := v.Step()
+
+	if err == nil || !strings.Contains(err.Error(), "Thumb not supported") {
+		t.Fatalf("expected Thumb not supported error, got %v", err)
+	}
+	if v.CPU.GetLR() != 0x1234 {
+		t.Errorf("expected LR unchanged, got LR=0x%X", v.CPU.GetLR())
+	}
+}
+
+func TestBX_LR_CallAndReturn(t *testing.T) {
+	// BL to a subroutine that returns with BX LR
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEB000001) // BL 0x800C
+	v.Memory.WriteWord(0x8004, 0xE3A01007) // MOV R1, #7
+	v.Memory.WriteWord(0x8008, 0xEF000000) // SWI #0
+	v.Memory.WriteWord(0x800C, 0xE3A00005) // MOV R0, #5
+	v.Memory.WriteWord(0x8010, 0xE12FFF1E) // BX LR
+
+	for i := 0; i < 3; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+	if v.CPU.PC != 0x8004 || v.CPU.GetLR() != 0x8004 {
+		t.Fatalf("expected BX LR to return to 0x8004, got PC=0x%X LR=0x%X", v.CPU.PC, v.CPU.GetLR())
+	}
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if v.CPU.R[0] != 5 || v.CPU.R[1] != 7 {
+		t.Errorf("expected R0=5 and R1=7, got R0=%d R1=%d", v.CPU.R[0], v.CPU.R[1])
 	}
 }
 
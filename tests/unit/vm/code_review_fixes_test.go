@@ -134,6 +134,11 @@ func TestReallocateDataCopy(t *testing.T) {
 		t.Fatal("allocation returned NULL")
 	}
 
+	// Allocate a block straight after it so the reallocation cannot grow in place
+	if _, err := v.Memory.Allocate(16); err != nil {
+		t.Fatalf("blocking allocation failed: %v", err)
+	}
+
 	// Write test data to the allocated memory
 	testData := []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
 	for i, b := range testData {
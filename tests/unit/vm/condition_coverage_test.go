@@ -0,0 +1,95 @@
+package vm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestCodeCoverageConditions(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	program := []uint32{
+		0xE3A02004, // MOV R2, #4
+		0xE2123001, // loop: ANDS R3, R2, #1
+		0x03A00001, // MOVEQ R0, #1 (runs when R2 is even)
+		0xE2522001, // SUBS R2, R2, #1
+		0x1AFFFFFB, // BNE loop
+		0xEF000000, // SWI #0
+	}
+	for i, op := range program {
+		v.Memory.WriteWord(0x8000+uint32(i*4), op) // #nosec G115 -- small test offset
+	}
+
+	var buf bytes.Buffer
+	v.CodeCoverage = vm.NewCodeCoverage(&buf)
+	v.CodeCoverage.SetCodeRange(0x8000, 0x8018)
+	v.CodeCoverage.Start()
+
+	// SWI #0 exit halts the VM and reports the exit code as an error
+	if err := v.Run(); v.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	moveq := v.CodeCoverage.GetConditionEntry(0x8008)
+	if moveq == nil || moveq.TrueCount != 2 || moveq.FalseCount != 2 {
+		t.Errorf("MOVEQ: expected 2 true and 2 false, got %+v", moveq)
+	}
+	bne := v.CodeCoverage.GetConditionEntry(0x8010)
+	if bne == nil || bne.TrueCount != 3 || bne.FalseCount != 1 {
+		t.Errorf("BNE: expected 3 true and 1 false, got %+v", bne)
+	}
+	if entry := v.CodeCoverage.GetConditionEntry(0x8004); entry != nil {
+		t.Errorf("unconditional instruction should not be tracked, got %+v", entry)
+	}
+	if entry := v.CodeCoverage.GetEntry(0x8008); entry == nil || entry.ExecutionCount != 2 {
+		t.Errorf("MOVEQ should count as executed only when its condition passed, got %+v", entry)
+	}
+	if partial := v.CodeCoverage.GetPartialConditions(); len(partial) != 0 {
+		t.Errorf("expected no partially covered conditions, got %+v", partial)
+	}
+
+	if err := v.CodeCoverage.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var data struct {
+		Conditions map[string]vm.ConditionCoverage `json:"conditional_addresses"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if got := data.Conditions["32776"]; got.TrueCount != 2 || got.FalseCount != 2 {
+		t.Errorf("JSON MOVEQ entry: expected 2 true and 2 false, got %+v", got)
+	}
+}
+
+func TestCodeCoveragePartialConditions(t *testing.T) {
+	var buf bytes.Buffer
+	coverage := vm.NewCodeCoverage(&buf)
+	coverage.SetCodeRange(0x8000, 0x8010)
+	coverage.Start()
+
+	coverage.RecordCondition(0x8000, true)
+	coverage.RecordCondition(0x8000, false)
+	coverage.RecordCondition(0x8008, false)
+	coverage.RecordCondition(0x8020, true) // out of range
+
+	partial := coverage.GetPartialConditions()
+	if len(partial) != 1 || partial[0].Address != 0x8008 {
+		t.Fatalf("expected only 0x8008 to be partially covered, got %+v", partial)
+	}
+	if coverage.GetConditionEntry(0x8020) != nil {
+		t.Error("out-of-range condition should not be tracked")
+	}
+
+	if err := coverage.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Partially Covered Conditions:\n-----------------------------\n0x00008008: true      0 times, false      1 times") {
+		t.Errorf("report should list the partially covered condition:\n%s", buf.String())
+	}
+}
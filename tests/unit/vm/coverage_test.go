@@ -232,3 +232,47 @@ func TestCodeCoverageDisabled(t *testing.T) {
 		t.Errorf("Expected 0 executed addresses when disabled, got %d", len(executed))
 	}
 }
+
+func TestCodeCoverageUntestedLines(t *testing.T) {
+	var buf bytes.Buffer
+	coverage := vm.NewCodeCoverage(&buf)
+	coverage.SetCodeRange(0x8000, 0x800C)
+	coverage.LoadSymbols(map[string]uint32{"dead": 0x8004})
+	coverage.LoadSourceLines(map[uint32]string{
+		0x8000: "test.s:1: MOV R0, #0",
+		0x8004: "test.s:3: MOV R1, #1",
+	})
+	coverage.Start()
+	coverage.RecordExecution(0x8000, 1)
+	coverage.RecordExecution(0x8008, 2)
+
+	untested := coverage.GetUntestedLines()
+	want := vm.UntestedLine{Address: 0x8004, Symbol: "dead", Source: "test.s:3: MOV R1, #1"}
+	if len(untested) != 1 || untested[0] != want {
+		t.Fatalf("Expected %+v, got %+v", want, untested)
+	}
+
+	if err := coverage.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "0x00008004 [dead]  test.s:3: MOV R1, #1") {
+		t.Errorf("Expected the untested line in the report, got:\n%s", buf.String())
+	}
+}
+
+func TestCodeCoverageCodeAddresses(t *testing.T) {
+	coverage := vm.NewCodeCoverage(nil)
+	coverage.SetCodeRange(0x8000, 0x8104)
+	coverage.SetCodeAddresses([]uint32{0x8100, 0x8000, 0x8004})
+	coverage.Start()
+	coverage.RecordExecution(0x8000, 1)
+	coverage.RecordExecution(0x8100, 2)
+	coverage.RecordExecution(0x8008, 3) // In range but not an instruction
+
+	if got := coverage.GetCoverage(); got < 66.66 || got > 66.67 {
+		t.Errorf("Expected 66.67%% coverage over 3 instructions, got %.2f%%", got)
+	}
+	if unexecuted := coverage.GetUnexecutedAddresses(); len(unexecuted) != 1 || unexecuted[0] != 0x8004 {
+		t.Errorf("Expected only 0x8004 unexecuted, got %v", unexecuted)
+	}
+}
@@ -2463,3 +2463,99 @@ func TestTEQ_RegisterShift_ROR(t *testing.T) {
 		t.Error("expected Z flag to be clear")
 	}
 }
+
+// ====== Carry/Borrow Boundary Cases ======
+
+// TestArithmeticFlags_Boundaries checks N/Z/C/V for the carry-using arithmetic
+// instructions against the ARM AddWithCarry definition. For subtraction C is NOT
+// borrow, and SBC/RSC subtract NOT(C) as part of the same operation.
+func TestArithmeticFlags_Boundaries(t *testing.T) {
+	const (
+		subs = 0xE0510002 // SUBS R0, R1, R2
+		rsbs = 0xE0710002 // RSBS R0, R1, R2
+		adcs = 0xE0B10002 // ADCS R0, R1, R2
+		sbcs = 0xE0D10002 // SBCS R0, R1, R2
+		rscs = 0xE0F10002 // RSCS R0, R1, R2
+		cmp  = 0xE1510002 // CMP R1, R2
+		keep = 0xDEADBEEF // R0 before the instruction; CMP must leave it alone
+	)
+
+	tests := []struct {
+		name       string
+		opcode     uint32
+		r1, r2     uint32
+		carryIn    bool
+		want       uint32
+		n, z, c, v bool
+	}{
+		{"SUBS 0-0", subs, 0, 0, false, 0, false, true, true, false},
+		{"SUBS 0-0 ignores carry in", subs, 0, 0, true, 0, false, true, true, false},
+		{"SUBS 0-1", subs, 0, 1, true, 0xFFFFFFFF, true, false, false, false},
+		{"SUBS MAX-MAX", subs, 0xFFFFFFFF, 0xFFFFFFFF, false, 0, false, true, true, false},
+		{"SUBS MIN-1", subs, 0x80000000, 1, false, 0x7FFFFFFF, false, false, true, true},
+		{"SUBS 0x7FFFFFFF-(-1)", subs, 0x7FFFFFFF, 0xFFFFFFFF, false, 0x80000000, true, false, false, true},
+
+		{"RSBS 0-0", rsbs, 0, 0, false, 0, false, true, true, false},
+		{"RSBS 0-1", rsbs, 1, 0, true, 0xFFFFFFFF, true, false, false, false},
+		{"RSBS MAX-MAX", rsbs, 0xFFFFFFFF, 0xFFFFFFFF, false, 0, false, true, true, false},
+		{"RSBS 1-MIN", rsbs, 0x80000000, 1, false, 0x80000001, true, false, false, true},
+
+		{"SBCS 0-0 carry set", sbcs, 0, 0, true, 0, false, true, true, false},
+		{"SBCS 0-0 carry clear", sbcs, 0, 0, false, 0xFFFFFFFF, true, false, false, false},
+		{"SBCS 0-1 carry set", sbcs, 0, 1, true, 0xFFFFFFFF, true, false, false, false},
+		{"SBCS 1-0 carry clear", sbcs, 1, 0, false, 0, false, true, true, false},
+		{"SBCS MAX-MAX carry set", sbcs, 0xFFFFFFFF, 0xFFFFFFFF, true, 0, false, true, true, false},
+		{"SBCS MAX-MAX carry clear", sbcs, 0xFFFFFFFF, 0xFFFFFFFF, false, 0xFFFFFFFF, true, false, false, false},
+		{"SBCS 0-MAX carry clear borrows", sbcs, 0, 0xFFFFFFFF, false, 0, false, true, false, false},
+		{"SBCS MIN-0 carry clear", sbcs, 0x80000000, 0, false, 0x7FFFFFFF, false, false, true, true},
+		{"SBCS 0x7FFFFFFF-(-1) carry clear", sbcs, 0x7FFFFFFF, 0xFFFFFFFF, false, 0x7FFFFFFF, false, false, false, false},
+
+		{"RSCS 0-0 carry set", rscs, 0, 0, true, 0, false, true, true, false},
+		{"RSCS 0-0 carry clear", rscs, 0, 0, false, 0xFFFFFFFF, true, false, false, false},
+		{"RSCS MAX-MAX carry set", rscs, 0xFFFFFFFF, 0xFFFFFFFF, true, 0, false, true, true, false},
+		{"RSCS MAX-MAX carry clear", rscs, 0xFFFFFFFF, 0xFFFFFFFF, false, 0xFFFFFFFF, true, false, false, false},
+		{"RSCS 0-MAX carry clear borrows", rscs, 0xFFFFFFFF, 0, false, 0, false, true, false, false},
+		{"RSCS 0x7FFFFFFF-(-1) carry clear", rscs, 0xFFFFFFFF, 0x7FFFFFFF, false, 0x7FFFFFFF, false, false, false, false},
+
+		{"ADCS 0+0 carry clear", adcs, 0, 0, false, 0, false, true, false, false},
+		{"ADCS 0+0 carry set", adcs, 0, 0, true, 1, false, false, false, false},
+		{"ADCS MAX+0 carry set", adcs, 0xFFFFFFFF, 0, true, 0, false, true, true, false},
+		{"ADCS MAX+MAX carry set", adcs, 0xFFFFFFFF, 0xFFFFFFFF, true, 0xFFFFFFFF, true, false, true, false},
+		{"ADCS 0x7FFFFFFF+0 carry set", adcs, 0x7FFFFFFF, 0, true, 0x80000000, true, false, false, true},
+		{"ADCS MIN+(-1) carry set", adcs, 0x80000000, 0xFFFFFFFF, true, 0x80000000, true, false, true, false},
+
+		{"CMP 0,0", cmp, 0, 0, false, keep, false, true, true, false},
+		{"CMP 0,1", cmp, 0, 1, true, keep, true, false, false, false},
+		{"CMP MAX,MAX", cmp, 0xFFFFFFFF, 0xFFFFFFFF, false, keep, false, true, true, false},
+		{"CMP MIN,1", cmp, 0x80000000, 1, false, keep, false, false, true, true},
+		{"CMP 1,MIN", cmp, 1, 0x80000000, true, keep, true, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.CPU.R[0] = keep
+			v.CPU.R[1] = tt.r1
+			v.CPU.R[2] = tt.r2
+			v.CPU.CPSR.C = tt.carryIn
+			v.CPU.PC = 0x8000
+
+			setupCodeWrite(v)
+			if err := v.Memory.WriteWord(0x8000, tt.opcode); err != nil {
+				t.Fatalf("failed to write instruction: %v", err)
+			}
+			if err := v.Step(); err != nil {
+				t.Fatalf("step failed: %v", err)
+			}
+
+			if v.CPU.R[0] != tt.want {
+				t.Errorf("R0 = 0x%08X, want 0x%08X", v.CPU.R[0], tt.want)
+			}
+			flags := v.CPU.CPSR
+			if flags.N != tt.n || flags.Z != tt.z || flags.C != tt.c || flags.V != tt.v {
+				t.Errorf("NZCV = %v%v%v%v, want %v%v%v%v",
+					flags.N, flags.Z, flags.C, flags.V, tt.n, tt.z, tt.c, tt.v)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// newSelfModifyingVM loads a loop that runs the instruction at 0x8000 twice, overwriting
+// it with store (which stores R5 at R4) after the first pass. R2 shows which version ran.
+func newSelfModifyingVM(tb testing.TB, store, r5 uint32) *vm.VM {
+	tb.Helper()
+	program := []uint32{
+		0xE3A02001, // loop: MOV R2, #1
+		0xE2866001, // ADD R6, R6, #1
+		0xE3560002, // CMP R6, #2
+		store,      // (conditional) store of R5 into the first instruction
+		0x1AFFFFFA, // BNE loop
+		0xEF000000, // SWI #0
+	}
+	machine := vm.NewVM()
+	for i, opcode := range program {
+		if err := machine.Memory.WriteInstructionUnsafe(vm.CodeSegmentStart+uint32(i*4), opcode); err != nil { // #nosec G115 -- small index
+			tb.Fatal(err)
+		}
+	}
+	machine.CPU.PC = vm.CodeSegmentStart
+	machine.CPU.R[4] = vm.CodeSegmentStart
+	machine.CPU.R[5] = r5
+	return machine
+}
+
+func TestDecodeCache_SelfModifyingCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		store uint32
+		r5    uint32
+	}{
+		{"word write", 0x15845000, 0xE3A02002},      // STRNE R5, [R4]: MOV R2, #2
+		{"halfword write", 0x11C450B0, 0x2002},      // STRNEH R5, [R4]: low half of MOV R2, #2
+		{"byte write", 0x15C45000, 0x02},            // STRNEB R5, [R4]: immediate byte of MOV R2, #2
+		{"unchanged value", 0x15845000, 0xE3A02001}, // Rewriting the same opcode is harmless
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := newSelfModifyingVM(t, tt.store, tt.r5)
+			if err := machine.Run(); err != nil && machine.State != vm.StateHalted {
+				t.Fatalf("run failed: %v", err)
+			}
+
+			want := uint32(2)
+			if tt.r5 == 0xE3A02001 {
+				want = 1
+			}
+			if machine.CPU.R[2] != want {
+				t.Errorf("expected the rewritten instruction to set R2=%d, got %d", want, machine.CPU.R[2])
+			}
+		})
+	}
+}
+
+func TestDecodeCache_StepBackRestoresCode(t *testing.T) {
+	machine := newSelfModifyingVM(t, 0x15845000, 0xE3A02002)
+	machine.EnableHistory(0)
+	machine.State = vm.StateRunning
+
+	// First pass, up to and including the store that rewrites the loop's first instruction
+	for i := 0; i < 4; i++ {
+		if err := machine.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := machine.StepBack(); err != nil {
+		t.Fatalf("step back failed: %v", err)
+	}
+
+	// Undoing the store brings back MOV R2, #1; skip the store on the way round again
+	machine.CPU.PC = vm.CodeSegmentStart
+	if err := machine.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if machine.CPU.R[2] != 1 {
+		t.Errorf("expected the restored MOV R2, #1 to run, got R2=%d", machine.CPU.R[2])
+	}
+}
+
+func TestDecodeCache_CountsFetches(t *testing.T) {
+	machine := newCountLoopVM(t, 100)
+	runCountLoop(t, machine)
+
+	var code *vm.MemorySegment
+	for _, seg := range machine.Memory.Segments {
+		if seg.Name == "code" {
+			code = seg
+		}
+	}
+	// Cached instructions still count as executed from the segment
+	if code.Executes != machine.CPU.Instructions {
+		t.Errorf("expected %d executes, got %d", machine.CPU.Instructions, code.Executes)
+	}
+}
+
+// BenchmarkRun_InvalidatedEachIteration runs a loop that rewrites its own first instruction
+// on every pass, so that instruction is decoded every time; compare with BenchmarkRun
+func BenchmarkRun_InvalidatedEachIteration(b *testing.B) {
+	program := []uint32{
+		0xE3A02001, // loop: MOV R2, #1
+		0xE5845000, // STR R5, [R4]
+		0xE2800001, // ADD R0, R0, #1
+		0xE1500001, // CMP R0, R1
+		0x1AFFFFFA, // BNE loop
+		0xEF000000, // SWI #0
+	}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		machine := vm.NewVM()
+		machine.CycleLimit = 0
+		for j, opcode := range program {
+			if err := machine.Memory.WriteInstructionUnsafe(vm.CodeSegmentStart+uint32(j*4), opcode); err != nil { // #nosec G115 -- small index
+				b.Fatal(err)
+			}
+		}
+		machine.CPU.PC = vm.CodeSegmentStart
+		machine.CPU.R[1] = 10000
+		machine.CPU.R[4] = vm.CodeSegmentStart
+		machine.CPU.R[5] = program[0]
+		b.StartTimer()
+		runCountLoop(b, machine)
+	}
+}
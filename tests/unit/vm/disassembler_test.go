@@ -0,0 +1,98 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestDisassemble(t *testing.T) {
+	labels := map[uint32]string{0x8010: "loop"}
+
+	tests := []struct {
+		opcode  uint32
+		address uint32
+		want    string
+		wantOK  bool
+	}{
+		{0xE3A00005, 0x8000, "MOV R0, #5", true},
+		{0xE3E01000, 0x8000, "MVN R1, #0", true},
+		{0xE1B02100, 0x8000, "MOVS R2, R0, LSL #2", true},
+		{0xE0803251, 0x8000, "ADD R3, R0, R1, ASR R2", true},
+		{0x12433F40, 0x8000, "SUBNE R3, R3, #0x100", true},
+		{0x12433C01, 0x8000, "SUBNE R3, R3, #0x100", false}, // same value, non-canonical rotation
+		{0xE350000A, 0x8000, "CMP R0, #0xA", true},
+		{0xE5110004, 0x8000, "LDR R0, [R1, #-4]", true},
+		{0xE5B10004, 0x8000, "LDR R0, [R1, #4]!", true},
+		{0xE4F17001, 0x8000, "LDRB R7, [R1], #1", true},
+		{0xE1D100B4, 0x8000, "LDRH R0, [R1, #4]", true},
+		{0xE92D40F0, 0x8000, "PUSH {R4-R7, LR}", true},
+		{0xE8BD40F0, 0x8000, "POP {R4-R7, LR}", true},
+		{0xE8910006, 0x8000, "LDMIA R1, {R1, R2}", true},
+		{0xE0000291, 0x8000, "MUL R0, R1, R2", true},
+		{0xE1020051, 0x8000, "QADD R0, R1, R2", true},
+		{0x1AFFFFFE, 0x8010, "BNE loop", true},
+		{0xEB000000, 0x8000, "BL 0x8008", true},
+		{0xE12FFF1E, 0x8000, "BX LR", true},
+		{0xEF000011, 0x8000, "SWI #0x11", true},
+		{0xE1212374, 0x8000, "BKPT #0x1234", true},
+
+		// Readable, but not expressible in this assembler
+		{0xE0810392, 0x8000, "UMULL R0, R1, R2, R3", false},
+		{0xE10F0000, 0x8000, "MRS R0, CPSR", true},
+		{0xE14F1000, 0x8000, "MRS R1, SPSR", true},
+		{0xE128F000, 0x8000, "MSR CPSR_f, R0", true},
+		{0xE329F003, 0x8000, "MSR CPSR_cf, #3", true},
+		{0xE1280000, 0x8000, "MSR CPSR_f, R0", false}, // should-be-one bits clear
+		{0xE120F000, 0x8000, "MSR CPSR_, R0", false},  // empty field mask
+		{0x01B00001, 0x8000, "MOVEQS R0, R1", false},
+		{0xE1A00061, 0x8000, "MOV R0, R1, RRX", false},
+		{0xE3A00F01, 0x8000, "MOV R0, #4", false}, // non-canonical rotation of #4
+		{0x9AFFFFFE, 0x8000, "BLS 0x8000", false},
+		{0xE1D100D4, 0x8000, "LDRSB R0, [R1, #4]", false},
+		{0x01200070, 0x8000, "BKPTEQ #0", false},
+		{0xF0000000, 0x8000, "UNDEFINED", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, ok := vm.Disassemble(tt.opcode, tt.address, labels)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Disassemble(0x%08X) = %q, %v; want %q, %v", tt.opcode, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLiteralComment(t *testing.T) {
+	v := vm.NewVM()
+	v.Memory.WriteWord(0x8010, 0x8020)
+	v.Memory.WriteWord(0x8014, 0x12345678)
+	labels := map[uint32]string{0x8020: "message"}
+
+	tests := []struct {
+		name    string
+		opcode  uint32
+		address uint32
+		want    string
+		wantOK  bool
+	}{
+		{"label", 0xE59F0008, 0x8000, "=0x00008020 (message)", true},       // LDR R0, [PC, #8]
+		{"constant", 0xE59F1008, 0x8004, "=0x12345678", true},              // LDR R1, [PC, #8]
+		{"negative offset", 0xE51F2004, 0x8010, "=0x12345678", true},       // LDR R2, [PC, #-4]
+		{"conditional", 0x059F0008, 0x8000, "=0x00008020 (message)", true}, // LDREQ R0, [PC, #8]
+		{"other base", 0xE5910008, 0x8000, "", false},                      // LDR R0, [R1, #8]
+		{"byte load", 0xE5DF0008, 0x8000, "", false},                       // LDRB R0, [PC, #8]
+		{"store", 0xE58F0008, 0x8000, "", false},                           // STR R0, [PC, #8]
+		{"unmapped pool", 0xE59F0008, 0x1000, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := vm.LiteralComment(tt.opcode, tt.address, v.Memory, labels)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LiteralComment(0x%08X) = %q, %v; want %q, %v", tt.opcode, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
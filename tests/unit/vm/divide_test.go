@@ -0,0 +1,101 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func runDivide(t *testing.T, opcode, rn, rm uint32) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	v.CPU.R[1] = rn
+	v.CPU.R[2] = rm
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	return v
+}
+
+const (
+	sdivR0R1R2 = 0xE710F211 // SDIV R0, R1, R2
+	udivR0R1R2 = 0xE730F211 // UDIV R0, R1, R2
+)
+
+func TestSDIV_RoundsTowardZero(t *testing.T) {
+	tests := []struct {
+		name   string
+		rn, rm int32
+		want   int32
+	}{
+		{"positive", 7, 2, 3},
+		{"negative dividend", -7, 2, -3},
+		{"negative divisor", 7, -2, -3},
+		{"both negative", -7, -2, 3},
+		{"most negative by -1 wraps", -2147483648, -1, -2147483648},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := runDivide(t, sdivR0R1R2, uint32(tt.rn), uint32(tt.rm)) // #nosec G115 -- test values
+			if got := int32(v.CPU.R[0]); got != tt.want {               // #nosec G115 -- test values
+				t.Errorf("%d / %d: expected %d, got %d", tt.rn, tt.rm, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUDIV_Unsigned(t *testing.T) {
+	v := runDivide(t, udivR0R1R2, 0xFFFFFFF9, 2)
+	if v.CPU.R[0] != 0x7FFFFFFC {
+		t.Errorf("expected R0=0x7FFFFFFC, got 0x%08X", v.CPU.R[0])
+	}
+}
+
+func TestDivide_ByZeroReturnsZero(t *testing.T) {
+	for name, opcode := range map[string]uint32{"SDIV": sdivR0R1R2, "UDIV": udivR0R1R2} {
+		v := vm.NewVM()
+		v.CPU.R[0] = 0x12345678
+		v.CPU.R[1] = 100
+		v.CPU.PC = 0x8000
+		setupCodeWrite(v)
+		v.Memory.WriteWord(0x8000, opcode)
+		if err := v.Step(); err != nil {
+			t.Fatalf("%s: step failed: %v", name, err)
+		}
+		if v.CPU.R[0] != 0 {
+			t.Errorf("%s: expected 0 on division by zero, got 0x%08X", name, v.CPU.R[0])
+		}
+	}
+}
+
+func TestDivide_FlagsUnaffected(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.CPSR.Z = true
+	v.CPU.CPSR.C = true
+	v.CPU.R[1] = 0xFFFFFFFC // -4
+	v.CPU.R[2] = 2
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, sdivR0R1R2)
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if v.CPU.R[0] != 0xFFFFFFFE {
+		t.Errorf("expected R0=-2, got 0x%08X", v.CPU.R[0])
+	}
+	if !v.CPU.CPSR.Z || !v.CPU.CPSR.C || v.CPU.CPSR.N || v.CPU.CPSR.V {
+		t.Error("SDIV must not affect NZCV")
+	}
+}
+
+func TestDivide_Disassembly(t *testing.T) {
+	if got, _ := vm.Disassemble(sdivR0R1R2, 0x8000, nil); got != "SDIV R0, R1, R2" {
+		t.Errorf("unexpected disassembly %q", got)
+	}
+	if got, _ := vm.Disassemble(0x1730F211, 0x8000, nil); got != "UDIVNE R0, R1, R2" {
+		t.Errorf("unexpected disassembly %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package vm_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -589,6 +590,51 @@ func TestStepWithCycleLimit(t *testing.T) {
 	}
 }
 
+// TestRunWithInstructionLimit verifies an infinite loop halts with the instruction limit error
+func TestRunWithInstructionLimit(t *testing.T) {
+	v := vm.NewVM()
+
+	// The skipped MOVEQ still counts as an instruction
+	program := []byte{
+		0x01, 0x00, 0xA0, 0x03, // loop: MOVEQ R0, #1 (little-endian)
+		0xFD, 0xFF, 0xFF, 0xEA, // B loop
+	}
+
+	startAddr := uint32(vm.CodeSegmentStart)
+	if err := v.LoadProgram(program, startAddr); err != nil {
+		t.Fatalf("Failed to load program: %v", err)
+	}
+
+	v.CPU.PC = startAddr
+	v.InstructionLimit = 101
+
+	err := v.Run()
+	if !errors.Is(err, vm.ErrInstructionLimit) {
+		t.Fatalf("Expected ErrInstructionLimit, got %v", err)
+	}
+	if v.State != vm.StateError {
+		t.Errorf("Expected StateError after instruction limit, got %v", v.State)
+	}
+	if v.CPU.Instructions != 101 {
+		t.Errorf("Expected 101 instructions, got %d", v.CPU.Instructions)
+	}
+	if v.CPU.PC != startAddr+4 {
+		t.Errorf("Expected to stop before the branch at 0x%08X, got 0x%08X", startAddr+4, v.CPU.PC)
+	}
+	if v.CPU.R[0] != 0 {
+		t.Errorf("Expected MOVEQ to be skipped, R0 = %d", v.CPU.R[0])
+	}
+	if v.CycleLimit != vm.DefaultMaxCycles {
+		t.Errorf("Expected cycle limit to be unchanged, got %d", v.CycleLimit)
+	}
+
+	// Reset clears the count so the program can run again
+	v.Reset()
+	if v.CPU.Instructions != 0 {
+		t.Errorf("Expected Reset to clear the instruction count, got %d", v.CPU.Instructions)
+	}
+}
+
 // TestStepInErrorState verifies Step fails in error state
 func TestStepInErrorState(t *testing.T) {
 	v := vm.NewVM()
@@ -0,0 +1,60 @@
+package vm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// TestExplainInstruction_ConditionalDataProcessing tests the explanation of ADDEQS R0, R1, #1
+func TestExplainInstruction_ConditionalDataProcessing(t *testing.T) {
+	e := vm.ExplainInstruction(0x02910001, 0x8000, nil)
+
+	if e.Text != "ADDEQS R0, R1, #1" {
+		t.Errorf("expected the disassembly ADDEQS R0, R1, #1, got %q", e.Text)
+	}
+	if !strings.HasPrefix(e.Condition, "EQ") || !strings.Contains(e.Condition, "Z is set") {
+		t.Errorf("expected the condition to explain EQ, got %q", e.Condition)
+	}
+	if e.Operation != "R0 = R1 + 1" {
+		t.Errorf("unexpected operation %q", e.Operation)
+	}
+	for _, want := range []string{"R0 (destination)", "R1 (operand 1)", "the value 1 (operand 2)"} {
+		if !strings.Contains(e.Operands, want) {
+			t.Errorf("expected %q in the operands, got %q", want, e.Operands)
+		}
+	}
+	if !strings.Contains(e.Flags, "S suffix sets N and Z") || !strings.Contains(e.Flags, "V on signed overflow") {
+		t.Errorf("expected the S suffix flag effect, got %q", e.Flags)
+	}
+}
+
+// TestExplainInstruction_Classes tests the operation and flags described for other classes
+func TestExplainInstruction_Classes(t *testing.T) {
+	tests := []struct {
+		name      string
+		opcode    uint32
+		operation string
+		flags     string
+	}{
+		{"mov without S", 0xE3A00001, "R0 = 1", "unchanged (no S suffix)"},
+		{"cmp", 0xE1500102, "compares R0 with (R2 LSL 2)", "always sets N and Z"},
+		{"load with writeback", 0xE5B13004, "loads a word from memory at R1 + 4 into R3, then writes that address back to R1", "unchanged"},
+		{"push", 0xE92D4070, "pushes {R4-R6, LR} onto the stack", "unchanged"},
+		{"branch and link", 0xEBFFFFFE, "calls 0x00008000, saving the return address 0x00008004 in LR", "unchanged"},
+		{"msr flags", 0xE128F000, "writes the flags field of CPSR from R0", "N, Z, C and V are set"},
+		{"undefined", 0xE7F000F0, "undefined instruction: bits [27:25]=011", "executing it stops the program"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := vm.ExplainInstruction(tt.opcode, 0x8000, nil)
+			if !strings.HasPrefix(e.Operation, tt.operation) {
+				t.Errorf("expected operation starting %q, got %q", tt.operation, e.Operation)
+			}
+			if !strings.Contains(e.Flags, tt.flags) {
+				t.Errorf("expected flags containing %q, got %q", tt.flags, e.Flags)
+			}
+		})
+	}
+}
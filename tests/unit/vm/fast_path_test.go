@@ -0,0 +1,112 @@
+package vm_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// countLoop counts R0 up to R1 and exits
+var countLoop = []uint32{
+	0xE3A00000, // MOV R0, #0
+	0xE2800001, // loop: ADD R0, R0, #1
+	0xE1500001, // CMP R0, R1
+	0x1AFFFFFC, // BNE loop
+	0xEF000000, // SWI #0
+}
+
+// newCountLoopVM loads countLoop to count to n
+func newCountLoopVM(tb testing.TB, n uint32) *vm.VM {
+	tb.Helper()
+	machine := vm.NewVM()
+	machine.CycleLimit = 0
+	for i, opcode := range countLoop {
+		if err := machine.Memory.WriteInstructionUnsafe(vm.CodeSegmentStart+uint32(i*4), opcode); err != nil { // #nosec G115 -- small index
+			tb.Fatal(err)
+		}
+	}
+	machine.CPU.PC = vm.CodeSegmentStart
+	machine.CPU.R[1] = n
+	return machine
+}
+
+// runCountLoop runs the VM to its exit
+func runCountLoop(tb testing.TB, machine *vm.VM) {
+	tb.Helper()
+	if err := machine.Run(); err != nil && machine.State != vm.StateHalted {
+		tb.Fatalf("run failed: %v", err)
+	}
+}
+
+// instrument attaches per-step diagnostics so Run takes the instrumented path
+func instrument(machine *vm.VM) {
+	machine.CodeCoverage = vm.NewCodeCoverage(io.Discard)
+	machine.FlagTrace = vm.NewFlagTrace(io.Discard)
+	machine.RegisterTrace = vm.NewRegisterTrace(io.Discard)
+	machine.EnableHistory(0)
+}
+
+func TestRun_FastPathMatchesInstrumented(t *testing.T) {
+	fast := newCountLoopVM(t, 500)
+	runCountLoop(t, fast)
+
+	instrumented := newCountLoopVM(t, 500)
+	instrument(instrumented)
+	runCountLoop(t, instrumented)
+
+	if fast.CPU.R != instrumented.CPU.R || fast.CPU.PC != instrumented.CPU.PC || fast.CPU.CPSR != instrumented.CPU.CPSR {
+		t.Errorf("register state differs: fast R=%v PC=0x%08X CPSR=%+v, instrumented R=%v PC=0x%08X CPSR=%+v",
+			fast.CPU.R, fast.CPU.PC, fast.CPU.CPSR, instrumented.CPU.R, instrumented.CPU.PC, instrumented.CPU.CPSR)
+	}
+	if fast.CPU.Cycles != instrumented.CPU.Cycles || fast.CPU.Instructions != instrumented.CPU.Instructions {
+		t.Errorf("counts differ: fast %d cycles/%d instructions, instrumented %d/%d",
+			fast.CPU.Cycles, fast.CPU.Instructions, instrumented.CPU.Cycles, instrumented.CPU.Instructions)
+	}
+	if fast.State != vm.StateHalted || instrumented.State != vm.StateHalted {
+		t.Errorf("expected both runs to halt, got %v and %v", fast.State, instrumented.State)
+	}
+	if len(fast.InstructionLog) != len(instrumented.InstructionLog) {
+		t.Errorf("instruction logs differ in length: %d and %d", len(fast.InstructionLog), len(instrumented.InstructionLog))
+	}
+
+	// The instrumented run recorded what it executed and can step back
+	if instrumented.History.Len() == 0 {
+		t.Error("expected the instrumented run to record history")
+	}
+}
+
+func TestStep_SelectsPathPerCall(t *testing.T) {
+	machine := newCountLoopVM(t, 3)
+	if err := machine.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Diagnostics attached between steps are seen by the next one
+	machine.CodeCoverage = vm.NewCodeCoverage(io.Discard)
+	if err := machine.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(machine.CodeCoverage.GetExecutedAddresses()); got != 1 {
+		t.Errorf("expected coverage of the second step only, got %d addresses", got)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		machine := newCountLoopVM(b, 10000)
+		b.StartTimer()
+		runCountLoop(b, machine)
+	}
+}
+
+func BenchmarkRun_Instrumented(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		machine := newCountLoopVM(b, 10000)
+		instrument(machine)
+		b.StartTimer()
+		runCountLoop(b, machine)
+	}
+}
@@ -322,3 +322,51 @@ func TestFlagTracePartialChanges(t *testing.T) {
 		t.Error("New flags don't match expected state")
 	}
 }
+
+func TestFlagTraceQFlag(t *testing.T) {
+	var buf bytes.Buffer
+	flagTrace := vm.NewFlagTrace(&buf)
+	flagTrace.Start(vm.CPSR{})
+
+	flagTrace.RecordFlags(1, 0x8000, "QADD R0, R1, R2", vm.CPSR{Q: true})
+
+	entries := flagTrace.GetEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Changed != "Q" {
+		t.Errorf("Expected changed flags 'Q', got '%s'", entries[0].Changed)
+	}
+
+	if err := flagTrace.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "Q flag changes:   1") {
+		t.Errorf("Expected Q change count in report, got:\n%s", output)
+	}
+	if !strings.Contains(output, "----- -> ----Q*") {
+		t.Errorf("Expected highlighted Q flag in trace line, got:\n%s", output)
+	}
+}
+
+func TestFlagTraceQFlagFromExecution(t *testing.T) {
+	var buf bytes.Buffer
+	v := vm.NewVM()
+	v.FlagTrace = vm.NewFlagTrace(&buf)
+	v.FlagTrace.Start(v.CPU.CPSR)
+
+	v.CPU.R[1] = 0x7FFFFFFF
+	v.CPU.R[2] = 1
+	v.CPU.PC = 0x8000
+	v.Memory.WriteWord(0x8000, 0xE1020051) // QADD R0, R1, R2 (saturates)
+
+	if err := v.Step(); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+
+	entries := v.FlagTrace.GetEntries()
+	if len(entries) != 1 || entries[0].Changed != "Q" {
+		t.Fatalf("Expected a single Q change entry, got %+v", entries)
+	}
+}
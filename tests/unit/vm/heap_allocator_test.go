@@ -0,0 +1,177 @@
+package vm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// allocateBlocks allocates n blocks of size bytes and returns their addresses
+func allocateBlocks(t *testing.T, m *vm.Memory, n int, size uint32) []uint32 {
+	t.Helper()
+	addrs := make([]uint32, n)
+	for i := range addrs {
+		addr, err := m.Allocate(size)
+		if err != nil {
+			t.Fatalf("allocation %d failed: %v", i, err)
+		}
+		addrs[i] = addr
+	}
+	return addrs
+}
+
+func TestHeap_CoalescingAfterFragmentation(t *testing.T) {
+	m := vm.NewMemory()
+
+	// Fill the heap with 4KB blocks, leaving less than 4KB at the top
+	const blockSize = 0x1000
+	blocks := allocateBlocks(t, m, vm.HeapSegmentSize/blockSize-1, blockSize)
+
+	// Free every other block: 28KB is free, but in 4KB holes
+	for i := 1; i < len(blocks); i += 2 {
+		if err := m.Free(blocks[i]); err != nil {
+			t.Fatalf("free %d failed: %v", i, err)
+		}
+	}
+	if _, err := m.Allocate(2 * blockSize); err == nil {
+		t.Fatal("expected an 8KB allocation to fail while the heap is fragmented")
+	}
+
+	// Freeing block 2 joins blocks 1-3 into one 12KB hole
+	if err := m.Free(blocks[2]); err != nil {
+		t.Fatalf("free failed: %v", err)
+	}
+	addr, err := m.Allocate(3 * blockSize)
+	if err != nil {
+		t.Fatalf("12KB allocation after coalescing failed: %v", err)
+	}
+	if addr != blocks[1] {
+		t.Errorf("expected the coalesced hole at 0x%08X, got 0x%08X", blocks[1], addr)
+	}
+	if len(m.HeapAllocations) != len(blocks)/2+1 {
+		t.Errorf("expected %d tracked allocations, got %d", len(blocks)/2+1, len(m.HeapAllocations))
+	}
+}
+
+func TestHeap_FreeingEverythingRestoresTheHeap(t *testing.T) {
+	m := vm.NewMemory()
+	blocks := allocateBlocks(t, m, 8, 100)
+
+	// Free out of order so blocks merge from both sides
+	for _, i := range []int{3, 1, 5, 7, 0, 2, 6, 4} {
+		if err := m.Free(blocks[i]); err != nil {
+			t.Fatalf("free %d failed: %v", i, err)
+		}
+	}
+	if m.NextHeapAddress != vm.HeapSegmentStart || len(m.HeapAllocations) != 0 {
+		t.Fatalf("expected an empty heap, next=0x%08X with %d allocations", m.NextHeapAddress, len(m.HeapAllocations))
+	}
+
+	// The whole heap is available as one block again
+	if _, err := m.Allocate(vm.HeapSegmentSize - 4); err != nil {
+		t.Errorf("allocation of the whole heap failed: %v", err)
+	}
+}
+
+func TestHeap_BestFit(t *testing.T) {
+	m := vm.NewMemory()
+	large, _ := m.Allocate(64)
+	_, _ = m.Allocate(4)
+	small, _ := m.Allocate(32)
+	_, _ = m.Allocate(4)
+	_ = m.Free(large)
+	_ = m.Free(small)
+
+	// The 32-byte hole is a better fit than the earlier 64-byte one
+	if addr, _ := m.Allocate(24); addr != small {
+		t.Errorf("expected best fit at 0x%08X, got 0x%08X", small, addr)
+	}
+	// The remainder of a hole stays usable
+	if addr, _ := m.Allocate(40); addr != large {
+		t.Errorf("expected the 64-byte hole at 0x%08X, got 0x%08X", large, addr)
+	}
+	if addr, _ := m.Allocate(24); addr != large+40 {
+		t.Errorf("expected the rest of the 64-byte hole at 0x%08X, got 0x%08X", large+40, addr)
+	}
+}
+
+func TestHeap_Resize(t *testing.T) {
+	m := vm.NewMemory()
+	blocks := allocateBlocks(t, m, 3, 32)
+	_ = m.Free(blocks[1])
+
+	// Grow into the freed neighbour, zeroing the added bytes
+	_ = m.WriteByteAt(blocks[1], 0xAA)
+	if !m.Resize(blocks[0], 48) || m.HeapAllocations[blocks[0]].Size != 48 {
+		t.Fatal("expected to grow into the free block that follows")
+	}
+	if b, _ := m.ReadByteAt(blocks[1]); b != 0 {
+		t.Errorf("expected grown memory to be zeroed, got 0x%02X", b)
+	}
+
+	// Only 16 bytes are left before blocks[2]
+	if m.Resize(blocks[0], 100) {
+		t.Error("expected growth past the next allocation to fail")
+	}
+
+	// Shrinking frees the tail for reuse
+	if !m.Resize(blocks[0], 8) {
+		t.Fatal("expected shrinking to succeed")
+	}
+	if addr, _ := m.Allocate(56); addr != blocks[0]+8 {
+		t.Errorf("expected the released tail at 0x%08X, got 0x%08X", blocks[0]+8, addr)
+	}
+
+	// The last block grows into the unused top of the heap
+	if !m.Resize(blocks[2], 0x1000) || m.NextHeapAddress != blocks[2]+0x1000 {
+		t.Errorf("expected the top block to grow in place, next=0x%08X", m.NextHeapAddress)
+	}
+}
+
+func TestReallocateGrowsInPlace(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000022) // SWI #0x22 (REALLOCATE)
+
+	addr, _ := v.Memory.Allocate(16)
+	_ = v.Memory.WriteByteAt(addr, 0x5A)
+	next, _ := v.Memory.Allocate(64)
+	_, _ = v.Memory.Allocate(4)
+	_ = v.Memory.Free(next)
+
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = addr
+	v.CPU.R[1] = 48
+	if err := v.Step(); err != nil {
+		t.Fatalf("REALLOCATE failed: %v", err)
+	}
+	if v.CPU.R[0] != addr {
+		t.Fatalf("expected the block to stay at 0x%08X, got 0x%08X", addr, v.CPU.R[0])
+	}
+	if b, _ := v.Memory.ReadByteAt(addr); b != 0x5A {
+		t.Errorf("expected contents to be kept, got 0x%02X", b)
+	}
+	if alloc := v.Memory.HeapAllocations[addr]; alloc == nil || alloc.Size != 48 {
+		t.Errorf("expected a tracked 48-byte allocation, got %+v", alloc)
+	}
+}
+
+func TestHeap_FreeListSurvivesImport(t *testing.T) {
+	v := vm.NewVM()
+	blocks := allocateBlocks(t, v.Memory, 3, 32)
+	_ = v.Memory.Free(blocks[1])
+
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	restored := vm.NewVM()
+	if err := restored.ImportState(&buf); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	if addr, _ := restored.Memory.Allocate(32); addr != blocks[1] {
+		t.Errorf("expected the freed hole at 0x%08X to be reused, got 0x%08X", blocks[1], addr)
+	}
+}
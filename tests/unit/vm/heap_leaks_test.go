@@ -0,0 +1,89 @@
+package vm_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runHeapProgram allocates two blocks, then frees the first one
+func runHeapProgram(t *testing.T) (*vm.VM, uint32, uint32) {
+	t.Helper()
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000020) // SWI #0x20 (allocate)
+	v.Memory.WriteWord(0x8004, 0xEF000020) // SWI #0x20 (allocate)
+	v.Memory.WriteWord(0x8008, 0xEF000021) // SWI #0x21 (free)
+
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = 16
+	if err := v.Step(); err != nil {
+		t.Fatalf("first allocate failed: %v", err)
+	}
+	first := v.CPU.R[0]
+
+	v.CPU.R[0] = 48
+	if err := v.Step(); err != nil {
+		t.Fatalf("second allocate failed: %v", err)
+	}
+	second := v.CPU.R[0]
+
+	v.CPU.R[0] = first
+	if err := v.Step(); err != nil {
+		t.Fatalf("free failed: %v", err)
+	}
+	return v, first, second
+}
+
+func TestHeapLeaks_ListsOnlyUnfreedBlock(t *testing.T) {
+	v, _, second := runHeapProgram(t)
+
+	leaks := v.HeapLeaks()
+	if len(leaks) != 1 {
+		t.Fatalf("expected exactly 1 leak, got %d: %+v", len(leaks), leaks)
+	}
+	if leaks[0].Address != second {
+		t.Errorf("expected leak at 0x%08X, got 0x%08X", second, leaks[0].Address)
+	}
+	if leaks[0].Size != 48 {
+		t.Errorf("expected leak size 48, got %d", leaks[0].Size)
+	}
+	if leaks[0].PC != 0x8004 {
+		t.Errorf("expected allocating PC 0x8004, got 0x%08X", leaks[0].PC)
+	}
+}
+
+func TestWriteLeakReport(t *testing.T) {
+	v, first, second := runHeapProgram(t)
+
+	var out bytes.Buffer
+	if err := v.WriteLeakReport(&out, map[string]uint32{"main": 0x8000}); err != nil {
+		t.Fatalf("WriteLeakReport failed: %v", err)
+	}
+	report := out.String()
+
+	if !strings.Contains(report, "1 block(s), 48 byte(s)") {
+		t.Errorf("expected summary line in report:\n%s", report)
+	}
+	if !strings.Contains(report, fmt.Sprintf("0x%08X", second)) || !strings.Contains(report, "main+4") {
+		t.Errorf("expected unfreed block and allocating PC in report:\n%s", report)
+	}
+	if strings.Contains(report, fmt.Sprintf("0x%08X", first)) {
+		t.Errorf("freed block should not be reported:\n%s", report)
+	}
+}
+
+func TestWriteLeakReport_NoLeaks(t *testing.T) {
+	v := vm.NewVM()
+
+	var out bytes.Buffer
+	if err := v.WriteLeakReport(&out, nil); err != nil {
+		t.Fatalf("WriteLeakReport failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "No heap leaks detected") {
+		t.Errorf("unexpected report: %q", out.String())
+	}
+}
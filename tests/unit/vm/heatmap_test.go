@@ -0,0 +1,139 @@
+package vm_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runArrayLoop increments each word of a 16-word array at 0x20000 ten times, then
+// writes once to the heap, with a heatmap of the given block size attached
+func runArrayLoop(t *testing.T, blockSize uint32) *vm.MemoryHeatmap {
+	t.Helper()
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	program := []uint32{
+		0xE3A0200A, // MOV R2, #10
+		0xE3A01802, // outer: MOV R1, #0x20000
+		0xE3A04010, // MOV R4, #16
+		0xE5910000, // inner: LDR R0, [R1]
+		0xE2800001, // ADD R0, R0, #1
+		0xE4810004, // STR R0, [R1], #4
+		0xE2544001, // SUBS R4, R4, #1
+		0x1AFFFFFA, // BNE inner
+		0xE2522001, // SUBS R2, R2, #1
+		0x1AFFFFF6, // BNE outer
+		0xE3A05803, // MOV R5, #0x30000
+		0xE5850000, // STR R0, [R5]
+		0xEF000000, // SWI #0
+	}
+	for i, op := range program {
+		v.Memory.WriteWord(0x8000+uint32(i*4), op) // #nosec G115 -- small test offset
+	}
+
+	v.MemoryHeatmap = vm.NewMemoryHeatmap(blockSize)
+	// SWI #0 exit halts the VM and reports the exit code as an error
+	if err := v.Run(); v.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+	return v.MemoryHeatmap
+}
+
+func TestMemoryHeatmap_HottestBlocksAreTheArray(t *testing.T) {
+	heatmap := runArrayLoop(t, 16)
+
+	buckets := heatmap.Buckets()
+	if len(buckets) != 5 {
+		t.Fatalf("expected 4 array blocks and 1 heap block, got %d: %+v", len(buckets), buckets)
+	}
+
+	hottest := heatmap.Hottest(4)
+	for _, b := range hottest {
+		if b.Address < 0x20000 || b.Address >= 0x20040 {
+			t.Errorf("hot block 0x%08X is outside the array", b.Address)
+		}
+		// 4 words per block, each read and written 10 times
+		if b.Reads != 40 || b.Writes != 40 {
+			t.Errorf("block 0x%08X: expected 40 reads and 40 writes, got %d and %d", b.Address, b.Reads, b.Writes)
+		}
+	}
+
+	coldest := buckets[len(buckets)-1]
+	if coldest.Address != 0x30000 || coldest.Reads != 0 || coldest.Writes != 1 {
+		t.Errorf("expected one write to the heap block, got %+v", coldest)
+	}
+}
+
+func TestMemoryHeatmap_BlockSize(t *testing.T) {
+	heatmap := runArrayLoop(t, 64)
+
+	hottest := heatmap.Hottest(1)
+	if len(hottest) != 1 || hottest[0].Address != 0x20000 || hottest[0].Reads != 160 || hottest[0].Writes != 160 {
+		t.Errorf("expected the whole array in one 64-byte block, got %+v", hottest)
+	}
+
+	h := vm.NewMemoryHeatmap(0)
+	if h.BlockSize != vm.DefaultHeatmapBlockSize {
+		t.Errorf("expected default block size %d, got %d", vm.DefaultHeatmapBlockSize, h.BlockSize)
+	}
+	h.RecordRead(0x1003)
+	h.RecordWrite(0x100F)
+	h.RecordRead(0x1010)
+	if got := h.Buckets(); len(got) != 2 || got[0].Address != 0x1000 || got[0].Total() != 2 || got[1].Address != 0x1010 {
+		t.Errorf("unexpected buckets: %+v", got)
+	}
+}
+
+func TestMemoryHeatmap_ExportCSV(t *testing.T) {
+	heatmap := runArrayLoop(t, 16)
+
+	var buf bytes.Buffer
+	if err := heatmap.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"address,reads,writes",
+		"0x00020000,40,40",
+		"0x00020010,40,40",
+		"0x00020020,40,40",
+		"0x00020030,40,40",
+		"0x00030000,0,1",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestMemoryHeatmap_ExportPPM(t *testing.T) {
+	heatmap := runArrayLoop(t, 16)
+
+	var buf bytes.Buffer
+	if err := heatmap.ExportPPM(&buf); err != nil {
+		t.Fatalf("ExportPPM failed: %v", err)
+	}
+
+	// 0x20000-0x30000 is 4097 blocks, 64 per row
+	header := fmt.Sprintf("P6\n%d %d\n255\n", vm.HeatmapPPMWidth, 65)
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte(header)) {
+		t.Fatalf("unexpected PPM header: %q", data[:min(len(data), 20)])
+	}
+	pixels := data[len(header):]
+	if len(pixels) != vm.HeatmapPPMWidth*65*3 {
+		t.Fatalf("expected %d pixel bytes, got %d", vm.HeatmapPPMWidth*65*3, len(pixels))
+	}
+	if pixels[0] != 255 || pixels[3*3] != 255 {
+		t.Errorf("expected array blocks at full brightness, got %d and %d", pixels[0], pixels[3*3])
+	}
+	if pixels[4*3] != 0 {
+		t.Errorf("expected untouched block to be black, got %d", pixels[4*3])
+	}
+	if heap := pixels[4096*3]; heap != 3 {
+		t.Errorf("expected dim heap block (1/80 of max), got %d", heap)
+	}
+}
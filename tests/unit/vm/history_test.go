@@ -0,0 +1,117 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestStepBack_RestoresMemoryAndRegisters(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE5810000) // STR R0, [R1]
+	v.Memory.WriteWord(0x8004, 0xE5C12001) // STRB R2, [R1, #1]
+	v.EnableHistory(10)
+
+	addr := uint32(vm.DataSegmentStart)
+	v.Memory.WriteWord(addr, 0x11223344)
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = 0xAABBCCDD
+	v.CPU.R[1] = addr
+	v.CPU.R[2] = 0xEE
+
+	for i := 0; i < 2; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+	if word, _ := v.Memory.ReadWord(addr); word != 0xAABBEEDD {
+		t.Fatalf("expected 0xAABBEEDD after both stores, got 0x%08X", word)
+	}
+
+	if err := v.StepBack(); err != nil {
+		t.Fatalf("first step back failed: %v", err)
+	}
+	if word, _ := v.Memory.ReadWord(addr); word != 0xAABBCCDD {
+		t.Errorf("expected 0xAABBCCDD after undoing STRB, got 0x%08X", word)
+	}
+	if v.CPU.PC != 0x8004 {
+		t.Errorf("expected PC=0x8004, got 0x%08X", v.CPU.PC)
+	}
+
+	if err := v.StepBack(); err != nil {
+		t.Fatalf("second step back failed: %v", err)
+	}
+	if word, _ := v.Memory.ReadWord(addr); word != 0x11223344 {
+		t.Errorf("expected original 0x11223344 after undoing STR, got 0x%08X", word)
+	}
+	if v.CPU.PC != 0x8000 || v.CPU.Cycles != 0 {
+		t.Errorf("expected PC=0x8000 cycles=0, got PC=0x%08X cycles=%d", v.CPU.PC, v.CPU.Cycles)
+	}
+	if v.State != vm.StateBreakpoint {
+		t.Errorf("expected paused state after step back, got %v", v.State)
+	}
+
+	if err := v.StepBack(); err == nil {
+		t.Error("expected error with empty history")
+	}
+}
+
+func TestStepBack_RestoresHeap(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000020) // SWI #0x20 (allocate)
+	v.EnableHistory(10)
+
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = 32
+	if err := v.Step(); err != nil {
+		t.Fatalf("allocate failed: %v", err)
+	}
+	if len(v.Memory.HeapAllocations) != 1 {
+		t.Fatalf("expected 1 allocation, got %d", len(v.Memory.HeapAllocations))
+	}
+
+	if err := v.StepBack(); err != nil {
+		t.Fatalf("step back failed: %v", err)
+	}
+	if len(v.Memory.HeapAllocations) != 0 || v.Memory.NextHeapAddress != vm.HeapSegmentStart {
+		t.Errorf("expected heap restored to empty, got %d allocations, next=0x%08X",
+			len(v.Memory.HeapAllocations), v.Memory.NextHeapAddress)
+	}
+	if v.CPU.R[0] != 32 {
+		t.Errorf("expected R0=32 restored, got %d", v.CPU.R[0])
+	}
+}
+
+func TestStepBack_HistoryIsBounded(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	for i := uint32(0); i < 5; i++ {
+		v.Memory.WriteWord(0x8000+i*4, 0xE2800001) // ADD R0, R0, #1
+	}
+	v.EnableHistory(3)
+	v.CPU.PC = 0x8000
+
+	for i := 0; i < 5; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+	if n := v.History.Len(); n != 3 {
+		t.Fatalf("expected 3 recorded steps, got %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := v.StepBack(); err != nil {
+			t.Fatalf("step back %d failed: %v", i, err)
+		}
+	}
+	// The two oldest steps were dropped, so R0 stops at 2
+	if v.CPU.R[0] != 2 || v.CPU.PC != 0x8008 {
+		t.Errorf("expected R0=2 PC=0x8008, got R0=%d PC=0x%08X", v.CPU.R[0], v.CPU.PC)
+	}
+	if err := v.StepBack(); err == nil {
+		t.Error("expected error once history is exhausted")
+	}
+}
@@ -0,0 +1,121 @@
+package vm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// assertPermissionFault checks that err is a protection violation needing access, raised
+// by the instruction at pc for address, and that it stopped the running VM
+func assertPermissionFault(t *testing.T, v *vm.VM, err error, access vm.MemoryPermission, pc, address uint32) {
+	t.Helper()
+	var fault *vm.MemoryFaultError
+	if !errors.As(err, &fault) {
+		t.Fatalf("expected a MemoryFaultError, got %v", err)
+	}
+	if fault.Access != access {
+		t.Errorf("expected a %s violation, got %s", access, fault.Access)
+	}
+	if fault.FaultPC() != pc {
+		t.Errorf("expected fault PC 0x%08X, got 0x%08X", pc, fault.FaultPC())
+	}
+	if fault.FaultAddress() != address {
+		t.Errorf("expected fault address 0x%08X, got 0x%08X", address, fault.FaultAddress())
+	}
+	if v.State != vm.StateError {
+		t.Errorf("expected the VM to stop in StateError, got %v", v.State)
+	}
+}
+
+func TestMemoryProtection_ReadDenied(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.R[1] = 0x20000
+	v.CPU.PC = 0x8000
+	v.State = vm.StateRunning
+	v.Memory.WriteInstructionUnsafe(0x8000, 0xE5910000) // LDR R0, [R1]
+	if err := v.Memory.SetSegmentPermissions("data", vm.PermWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	err := v.Step()
+	assertPermissionFault(t, v, err, vm.PermRead, 0x8000, 0x20000)
+}
+
+func TestMemoryProtection_ByteAndHalfwordWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint32
+	}{
+		{"STRB", 0xE5C10000}, // STRB R0, [R1]
+		{"STRH", 0xE1C100B0}, // STRH R0, [R1]
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.CPU.R[1] = 0x20004
+			v.CPU.PC = 0x8000
+			v.State = vm.StateRunning
+			v.Memory.WriteInstructionUnsafe(0x8000, tt.opcode)
+			if err := v.Memory.SetSegmentPermissions("data", vm.PermRead); err != nil {
+				t.Fatal(err)
+			}
+
+			err := v.Step()
+			assertPermissionFault(t, v, err, vm.PermWrite, 0x8000, 0x20004)
+		})
+	}
+}
+
+func TestMemoryProtection_UnmappedHasNoAccess(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE5810000) // STR R0, [R1] with R1 = 0
+
+	var fault *vm.MemoryFaultError
+	if err := v.Step(); !errors.As(err, &fault) {
+		t.Fatalf("expected a MemoryFaultError, got %v", err)
+	}
+	if fault.Access != vm.PermNone {
+		t.Errorf("expected an unmapped fault to have no Access, got %s", fault.Access)
+	}
+}
+
+func TestMemoryProtection_SetSegmentPermissionsUnknown(t *testing.T) {
+	v := vm.NewVM()
+	if err := v.Memory.SetSegmentPermissions("rom", vm.PermRead); err == nil {
+		t.Error("expected an error for an unknown segment")
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    vm.MemoryPermission
+		wantErr bool
+	}{
+		{"rwx", vm.PermRead | vm.PermWrite | vm.PermExecute, false},
+		{"r-x", vm.PermRead | vm.PermExecute, false},
+		{"rx", vm.PermRead | vm.PermExecute, false},
+		{"w", vm.PermWrite, false},
+		{"---", vm.PermNone, false},
+		{"", vm.PermNone, false},
+		{"rq", 0, true},
+		{"rr", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := vm.ParsePermissions(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePermissions(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePermissions(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -1670,42 +1670,35 @@ func TestSTRH_UnalignedHalfword(t *testing.T) {
 }
 
 func TestMemory_WriteProtection(t *testing.T) {
-	// Test writing to read-only memory segment
-	// This test documents the current behavior (may not have protection)
+	// Test writing to a read-only code segment
 	v := vm.NewVM()
 	v.CPU.R[0] = 0xDEADBEEF
-	v.CPU.R[1] = 0x0 // Try to write to address 0 (typically code segment)
+	v.CPU.R[1] = 0x8100
 	v.CPU.PC = 0x8000
-
-	setupCodeWrite(v)
+	v.State = vm.StateRunning
 
 	// STR R0, [R1]
 	opcode := uint32(0xE5810000) // STR R0, [R1]
-	v.Memory.WriteWord(0x8000, opcode)
+	v.Memory.WriteInstructionUnsafe(0x8000, opcode)
+	v.Memory.MakeCodeReadOnly()
 
-	// Currently the emulator may allow this
-	// This test documents the behavior
-	v.Step()
-	t.Logf("Write to address 0 completed (protection may not be implemented)")
+	err := v.Step()
+	assertPermissionFault(t, v, err, vm.PermWrite, 0x8000, 0x8100)
 }
 
 func TestMemory_ExecuteProtection(t *testing.T) {
-	// Test executing from data segment
-	// ARM2 doesn't have NX protection, so this should work
+	// Test executing from the data segment, which is not executable
 	v := vm.NewVM()
 	v.CPU.PC = 0x20000 // Data area
-
-	setupCodeWrite(v)
+	v.State = vm.StateRunning
 
 	// Write a NOP instruction to data area
 	// MOV R0, R0 (NOP equivalent)
 	opcode := uint32(0xE1A00000)
 	v.Memory.WriteWord(0x20000, opcode)
 
-	v.Step()
-
-	// Should execute without error (ARM2 has no execute protection)
-	t.Logf("Execute from data segment completed (no NX protection in ARM2)")
+	err := v.Step()
+	assertPermissionFault(t, v, err, vm.PermExecute, 0x20000, 0x20000)
 }
 
 func TestMemory_NoReadPermission(t *testing.T) {
@@ -1724,3 +1717,72 @@ func TestMemory_NoReadPermission(t *testing.T) {
 	v.Step()
 	t.Logf("Read from high address: R0=0x%X (may be invalid or wrapped)", v.CPU.R[0])
 }
+
+func TestMemory_ByteOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		little    bool
+		wordBytes [4]byte
+		halfBytes [2]byte
+	}{
+		{"little-endian", true, [4]byte{0x78, 0x56, 0x34, 0x12}, [2]byte{0x34, 0x12}},
+		{"big-endian", false, [4]byte{0x12, 0x34, 0x56, 0x78}, [2]byte{0x12, 0x34}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.Memory.LittleEndian = tt.little
+
+			if err := v.Memory.WriteWord(0x20000, 0x12345678); err != nil {
+				t.Fatalf("WriteWord failed: %v", err)
+			}
+			for i, want := range tt.wordBytes {
+				got, _ := v.Memory.ReadByteAt(0x20000 + uint32(i)) // #nosec G115 -- small test offset
+				if got != want {
+					t.Errorf("word byte %d: got 0x%02X, want 0x%02X", i, got, want)
+				}
+			}
+			if word, _ := v.Memory.ReadWord(0x20000); word != 0x12345678 {
+				t.Errorf("ReadWord: got 0x%08X, want 0x12345678", word)
+			}
+
+			if err := v.Memory.WriteHalfword(0x20010, 0x1234); err != nil {
+				t.Fatalf("WriteHalfword failed: %v", err)
+			}
+			for i, want := range tt.halfBytes {
+				got, _ := v.Memory.ReadByteAt(0x20010 + uint32(i)) // #nosec G115 -- small test offset
+				if got != want {
+					t.Errorf("halfword byte %d: got 0x%02X, want 0x%02X", i, got, want)
+				}
+			}
+			if half, _ := v.Memory.ReadHalfword(0x20010); half != 0x1234 {
+				t.Errorf("ReadHalfword: got 0x%04X, want 0x1234", half)
+			}
+		})
+	}
+}
+
+func TestMemory_BigEndianInstructionsStayLittleEndian(t *testing.T) {
+	v := vm.NewVM()
+	v.Memory.LittleEndian = false
+	v.CPU.PC = 0x8000
+
+	// MOV R0, #5
+	if err := v.Memory.WriteInstructionUnsafe(0x8000, 0xE3A00005); err != nil {
+		t.Fatalf("WriteInstructionUnsafe failed: %v", err)
+	}
+	if b, _ := v.Memory.ReadByteAt(0x8000); b != 0x05 {
+		t.Errorf("expected instruction stored little-endian (first byte 0x05), got 0x%02X", b)
+	}
+	if opcode, _ := v.Memory.ReadInstruction(0x8000); opcode != 0xE3A00005 {
+		t.Errorf("ReadInstruction: got 0x%08X, want 0xE3A00005", opcode)
+	}
+
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if v.CPU.R[0] != 5 {
+		t.Errorf("expected R0=5, got %d", v.CPU.R[0])
+	}
+}
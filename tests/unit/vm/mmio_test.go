@@ -0,0 +1,163 @@
+package vm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// counterDevice returns an incrementing value on every word read
+type counterDevice struct {
+	count   uint32
+	writes  []uint32
+	offsets []uint32
+}
+
+func (c *counterDevice) ReadWord(offset uint32) (uint32, error) {
+	c.count++
+	return c.count, nil
+}
+
+func (c *counterDevice) WriteWord(offset uint32, value uint32) error {
+	c.offsets = append(c.offsets, offset)
+	c.writes = append(c.writes, value)
+	return nil
+}
+
+const counterBase = 0x00200000
+
+func TestMMIO_CounterDeviceReads(t *testing.T) {
+	v := vm.NewVM()
+	dev := &counterDevice{}
+	if err := v.Memory.MapDevice("counter", counterBase, 0x10, vm.PermRead|vm.PermWrite, dev); err != nil {
+		t.Fatalf("MapDevice failed: %v", err)
+	}
+
+	for want := uint32(1); want <= 3; want++ {
+		got, err := v.Memory.ReadWord(counterBase)
+		if err != nil {
+			t.Fatalf("ReadWord failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("read %d: expected %d, got %d", want, want, got)
+		}
+	}
+}
+
+func TestMMIO_CounterDeviceViaLDR(t *testing.T) {
+	v := vm.NewVM()
+	dev := &counterDevice{}
+	if err := v.Memory.MapDevice("counter", counterBase, 0x10, vm.PermRead|vm.PermWrite, dev); err != nil {
+		t.Fatalf("MapDevice failed: %v", err)
+	}
+
+	v.CPU.R[1] = counterBase
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE5910000) // LDR R0, [R1]
+	v.Memory.WriteWord(0x8004, 0xE5912000) // LDR R2, [R1]
+
+	if err := v.Step(); err != nil {
+		t.Fatalf("step 1 failed: %v", err)
+	}
+	if err := v.Step(); err != nil {
+		t.Fatalf("step 2 failed: %v", err)
+	}
+
+	if v.CPU.R[0] != 1 || v.CPU.R[2] != 2 {
+		t.Errorf("expected R0=1 R2=2, got R0=%d R2=%d", v.CPU.R[0], v.CPU.R[2])
+	}
+}
+
+func TestMMIO_SubWordAccess(t *testing.T) {
+	v := vm.NewVM()
+	dev := &counterDevice{count: 0x11223343} // next read returns 0x11223344
+	if err := v.Memory.MapDevice("counter", counterBase, 0x10, vm.PermRead|vm.PermWrite, dev); err != nil {
+		t.Fatalf("MapDevice failed: %v", err)
+	}
+
+	b, err := v.Memory.ReadByteAt(counterBase + 1)
+	if err != nil {
+		t.Fatalf("ReadByteAt failed: %v", err)
+	}
+	if b != 0x33 {
+		t.Errorf("expected byte lane 1 = 0x33, got 0x%02X", b)
+	}
+
+	if err := v.Memory.WriteByteAt(counterBase+2, 0xAB); err != nil {
+		t.Fatalf("WriteByteAt failed: %v", err)
+	}
+	if len(dev.writes) != 1 || dev.writes[0] != 0xAB || dev.offsets[0] != 2 {
+		t.Errorf("expected write of 0xAB at offset 2, got %v at %v", dev.writes, dev.offsets)
+	}
+}
+
+func TestMMIO_PermissionsEnforced(t *testing.T) {
+	v := vm.NewVM()
+	dev := &counterDevice{}
+	if err := v.Memory.MapDevice("rom", counterBase, 0x10, vm.PermRead, dev); err != nil {
+		t.Fatalf("MapDevice failed: %v", err)
+	}
+
+	if err := v.Memory.WriteWord(counterBase, 1); err == nil {
+		t.Error("expected write permission error for read-only device")
+	}
+	if len(dev.writes) != 0 {
+		t.Error("device should not see writes that fail permission checks")
+	}
+}
+
+func TestMMIO_AlignmentEnforced(t *testing.T) {
+	v := vm.NewVM()
+	dev := &counterDevice{}
+	if err := v.Memory.MapDevice("counter", counterBase, 0x10, vm.PermRead|vm.PermWrite, dev); err != nil {
+		t.Fatalf("MapDevice failed: %v", err)
+	}
+
+	if _, err := v.Memory.ReadWord(counterBase + 2); err == nil {
+		t.Error("expected alignment error for unaligned device read")
+	}
+	if dev.count != 0 {
+		t.Error("device should not see reads that fail alignment checks")
+	}
+}
+
+func TestMMIO_OverlapRejected(t *testing.T) {
+	v := vm.NewVM()
+	if err := v.Memory.MapDevice("bad", vm.DataSegmentStart+0x100, 0x10, vm.PermRead, &counterDevice{}); err == nil {
+		t.Error("expected error mapping device over data segment")
+	}
+}
+
+func TestMMIO_UARTWritesToOutput(t *testing.T) {
+	v := vm.NewVM()
+	var out bytes.Buffer
+	v.OutputWriter = &out
+
+	v.CPU.R[0] = 'H'
+	v.CPU.R[1] = vm.UARTBaseAddress
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE5C10000) // STRB R0, [R1]
+	v.Memory.WriteWord(0x8004, 0xE3A00069) // MOV R0, #'i'
+	v.Memory.WriteWord(0x8008, 0xE5810000) // STR R0, [R1]
+
+	for i := 0; i < 3; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	if out.String() != "Hi" {
+		t.Errorf("expected UART output %q, got %q", "Hi", out.String())
+	}
+
+	status, err := v.Memory.ReadWord(vm.UARTBaseAddress + vm.UARTStatusOffset)
+	if err != nil {
+		t.Fatalf("status read failed: %v", err)
+	}
+	if status&vm.UARTStatusTxReady == 0 {
+		t.Error("expected UART status to report transmitter ready")
+	}
+}
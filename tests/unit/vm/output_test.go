@@ -0,0 +1,100 @@
+package vm_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// writeString runs SWI_WRITE_STRING on a string placed in the data segment
+func writeString(t *testing.T, machine *vm.VM, s string) {
+	t.Helper()
+	addr := uint32(vm.DataSegmentStart)
+	for i := 0; i < len(s); i++ {
+		if err := machine.Memory.WriteByteAt(addr+uint32(i), s[i]); err != nil {
+			t.Fatalf("failed to write string: %v", err)
+		}
+	}
+	if err := machine.Memory.WriteByteAt(addr+uint32(len(s)), 0); err != nil {
+		t.Fatalf("failed to write terminator: %v", err)
+	}
+	machine.CPU.SetRegister(0, addr)
+	if err := vm.ExecuteSWI(machine, &vm.Instruction{Opcode: 0xEF000000 | vm.SWI_WRITE_STRING, Type: vm.InstSWI}); err != nil {
+		t.Fatalf("SWI_WRITE_STRING failed: %v", err)
+	}
+}
+
+func TestOutputWriters_FanOut(t *testing.T) {
+	machine := vm.NewVM()
+	var screen, capture bytes.Buffer
+	machine.OutputWriter = &screen
+	machine.AddOutputWriter(&capture)
+
+	writeString(t, machine, "hello")
+
+	if screen.String() != "hello" {
+		t.Errorf("expected OutputWriter to receive %q, got %q", "hello", screen.String())
+	}
+	if capture.String() != "hello" {
+		t.Errorf("expected the sink to receive %q, got %q", "hello", capture.String())
+	}
+}
+
+func TestOutputWriters_TwoSinks(t *testing.T) {
+	machine := vm.NewVM()
+	machine.OutputWriter = nil
+	var first, second bytes.Buffer
+	machine.AddOutputWriter(&first)
+	machine.AddOutputWriter(&second)
+
+	writeString(t, machine, "one")
+	if !machine.RemoveOutputWriter(&first) {
+		t.Fatal("expected the first sink to be registered")
+	}
+	writeString(t, machine, "two")
+
+	if first.String() != "one" {
+		t.Errorf("expected the removed sink to stop at %q, got %q", "one", first.String())
+	}
+	if second.String() != "onetwo" {
+		t.Errorf("expected the remaining sink to receive %q, got %q", "onetwo", second.String())
+	}
+	if machine.RemoveOutputWriter(&first) {
+		t.Error("expected removing an unregistered sink to report false")
+	}
+}
+
+// failingWriter rejects every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("sink full") }
+
+func TestOutputWriters_FailingSinkDoesNotStarveOthers(t *testing.T) {
+	machine := vm.NewVM()
+	var screen bytes.Buffer
+	machine.OutputWriter = &screen
+	machine.AddOutputWriter(failingWriter{})
+
+	writeString(t, machine, "ok")
+
+	if screen.String() != "ok" {
+		t.Errorf("expected OutputWriter to receive %q despite the failing sink, got %q", "ok", screen.String())
+	}
+}
+
+func TestOutputWriters_UART(t *testing.T) {
+	machine := vm.NewVM()
+	var screen, capture bytes.Buffer
+	machine.OutputWriter = &screen
+	machine.AddOutputWriter(&capture)
+
+	if err := machine.Memory.WriteWord(vm.UARTBaseAddress+vm.UARTDataOffset, 'A'); err != nil {
+		t.Fatalf("UART write failed: %v", err)
+	}
+
+	if screen.String() != "A" || capture.String() != "A" {
+		t.Errorf("expected both writers to receive %q, got %q and %q", "A", screen.String(), capture.String())
+	}
+}
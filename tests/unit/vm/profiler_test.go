@@ -0,0 +1,165 @@
+package vm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runProfiled writes the opcodes at 0x8000 and runs them to exit with the profiler enabled
+func runProfiled(t *testing.T, opcodes []uint32, symbols map[string]uint32) *vm.Profiler {
+	t.Helper()
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	for i, op := range opcodes {
+		v.Memory.WriteWord(0x8000+uint32(i)*4, op) // #nosec G115 -- small test program
+	}
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = 3
+	if err := v.CPU.SetSP(vm.StackSegmentStart + vm.StackSegmentSize); err != nil {
+		t.Fatalf("SetSP failed: %v", err)
+	}
+
+	v.Profiler = vm.NewProfiler()
+	v.Profiler.LoadSymbols(symbols)
+
+	_ = v.Run() // Exit syscall halts with an error
+	if v.State != vm.StateHalted {
+		t.Fatalf("program did not exit: state=%v err=%v", v.State, v.LastError)
+	}
+	v.Profiler.Finalize()
+	return v.Profiler
+}
+
+func profileOf(t *testing.T, p *vm.Profiler, name string) *vm.FunctionProfile {
+	t.Helper()
+	fn, ok := p.Function(name)
+	if !ok {
+		t.Fatalf("function %s not profiled", name)
+	}
+	return fn
+}
+
+func edgeOf(t *testing.T, p *vm.Profiler, caller, callee string) *vm.CallEdge {
+	t.Helper()
+	for _, edge := range p.Edges() {
+		if edge.Caller == caller && edge.Callee == callee {
+			return edge
+		}
+	}
+	t.Fatalf("no edge %s -> %s", caller, callee)
+	return nil
+}
+
+func TestProfiler_SubroutineCycleAttribution(t *testing.T) {
+	p := runProfiled(t, []uint32{
+		0xEB000003, // 0x8000 main: BL sub
+		0xEB000002, // 0x8004       BL sub
+		0xE3A00000, // 0x8008       MOV R0, #0
+		0xEF000000, // 0x800C       SWI #0
+		0xE1A00000, // 0x8010       NOP (padding)
+		0xE2811001, // 0x8014 sub:  ADD R1, R1, #1
+		0xE2811001, // 0x8018 loop: ADD R1, R1, #1 (local label stays in sub)
+		0xE12FFF1E, // 0x801C       BX LR
+	}, map[string]uint32{"main": 0x8000, "sub": 0x8014, "loop": 0x8018})
+
+	sub := profileOf(t, p, "sub")
+	if sub.Calls != 2 || sub.SelfCycles != 6 || sub.TotalCycles != 6 {
+		t.Errorf("sub: expected 2 calls, 6 self, 6 total cycles; got %+v", sub)
+	}
+	if _, ok := p.Function("loop"); ok {
+		t.Error("local label inside sub should not be profiled as a function")
+	}
+
+	main := profileOf(t, p, "main")
+	if main.SelfCycles != 3 || main.TotalCycles != 9 {
+		t.Errorf("main: expected 3 self, 9 total cycles; got %+v", main)
+	}
+
+	edge := edgeOf(t, p, "main", "sub")
+	if edge.Calls != 2 || edge.Cycles != 6 {
+		t.Errorf("main -> sub: expected 2 calls, 6 cycles; got %+v", edge)
+	}
+}
+
+func TestProfiler_Recursion(t *testing.T) {
+	p := runProfiled(t, []uint32{
+		0xEB000003, // 0x8000 main: BL rec (R0 = 3)
+		0xEF000000, // 0x8004       SWI #0
+		0xE1A00000, // 0x8008
+		0xE1A00000, // 0x800C
+		0xE1A00000, // 0x8010
+		0xE2500001, // 0x8014 rec:  SUBS R0, R0, #1
+		0xE92D4000, // 0x8018       PUSH {LR}
+		0x1BFFFFFC, // 0x801C       BLNE rec
+		0xE8BD8000, // 0x8020       POP {PC}
+	}, map[string]uint32{"main": 0x8000, "rec": 0x8014})
+
+	rec := profileOf(t, p, "rec")
+	if rec.Calls != 3 || rec.SelfCycles != 12 || rec.TotalCycles != 12 {
+		t.Errorf("rec: expected 3 calls, 12 self, 12 total cycles (not double counted); got %+v", rec)
+	}
+	if edge := edgeOf(t, p, "rec", "rec"); edge.Calls != 2 || edge.Cycles != 8 {
+		t.Errorf("rec -> rec: expected 2 calls, 8 cycles; got %+v", edge)
+	}
+	if edge := edgeOf(t, p, "main", "rec"); edge.Cycles != 12 {
+		t.Errorf("main -> rec: expected 12 cycles; got %+v", edge)
+	}
+}
+
+func TestProfiler_TailCall(t *testing.T) {
+	p := runProfiled(t, []uint32{
+		0xEB000003, // 0x8000 main: BL b
+		0xEB000001, // 0x8004       BL a
+		0xEF000000, // 0x8008       SWI #0
+		0xE1A00000, // 0x800C
+		0xEAFFFFFF, // 0x8010 a:    B b
+		0xE12FFF1E, // 0x8014 b:    BX LR
+	}, map[string]uint32{"main": 0x8000, "a": 0x8010, "b": 0x8014})
+
+	edge := edgeOf(t, p, "a", "b")
+	if !edge.TailCall || edge.Calls != 1 || edge.Cycles != 1 {
+		t.Errorf("a -> b: expected 1 tail call of 1 cycle; got %+v", edge)
+	}
+	if b := profileOf(t, p, "b"); b.Calls != 2 || b.SelfCycles != 2 {
+		t.Errorf("b: expected 2 calls, 2 self cycles; got %+v", b)
+	}
+	// b returned straight to main, so a only accounts for its own branch
+	if a := profileOf(t, p, "a"); a.SelfCycles != 1 {
+		t.Errorf("a: expected 1 self cycle; got %+v", a)
+	}
+}
+
+func TestProfiler_ExportJSON(t *testing.T) {
+	p := runProfiled(t, []uint32{
+		0xEB000000, // 0x8000 main: BL sub
+		0xEF000000, // 0x8004       SWI #0
+		0xE12FFF1E, // 0x8008 sub:  BX LR
+	}, map[string]uint32{"main": 0x8000, "sub": 0x8008})
+
+	var buf bytes.Buffer
+	if err := p.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var data struct {
+		TotalCycles uint64 `json:"total_cycles"`
+		Edges       []struct {
+			Caller string `json:"caller"`
+			Callee string `json:"callee"`
+			Calls  uint64 `json:"calls"`
+			Cycles uint64 `json:"cycles"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if data.TotalCycles != 2 {
+		t.Errorf("expected 2 total cycles, got %d", data.TotalCycles)
+	}
+	if len(data.Edges) != 1 || data.Edges[0].Caller != "main" || data.Edges[0].Callee != "sub" || data.Edges[0].Calls != 1 || data.Edges[0].Cycles != 1 {
+		t.Errorf("unexpected edges: %+v", data.Edges)
+	}
+}
@@ -0,0 +1,34 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestRegisterSnapshotDiff(t *testing.T) {
+	var before, after vm.RegisterSnapshot
+	before.R[0] = 5
+	before.R[vm.SP] = 0x50000
+	before.R[vm.ARMRegisterPC] = 0x8000
+	after = before
+	after.R[0] = 15
+	after.R[vm.SP] = 0x4FFFC
+	after.R[vm.ARMRegisterPC] = 0x8004
+	after.CPSR.Z = true
+
+	want := "R0: 5 -> 15, SP: 0x00050000 -> 0x0004FFFC, CPSR: ---- -> -Z--"
+	if got := after.Diff(&before); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRegisterSnapshotDiff_PCOnly(t *testing.T) {
+	var before, after vm.RegisterSnapshot
+	after.R[vm.ARMRegisterPC] = 4
+	after.CPSR.Q = true // Not one of the NZCV flags shown
+
+	if got := after.Diff(&before); got != "" {
+		t.Errorf("expected no changes, got %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package vm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// newEightRegisterVM creates a VM limited to R0-R7 plus SP, LR and PC
+func newEightRegisterVM(t *testing.T) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	if err := v.SetRegisterCount(8); err != nil {
+		t.Fatalf("SetRegisterCount(8) failed: %v", err)
+	}
+	return v
+}
+
+func TestRegisterCount_RejectsHighRegisters(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint32
+		reg    string
+	}{
+		{"destination", 0xE3A08001, "R8"},       // MOV R8, #1
+		{"second operand", 0xE0810009, "R9"},    // ADD R0, R1, R9
+		{"multiply operand", 0xE0000A91, "R10"}, // MUL R0, R1, R10
+		{"offset register", 0xE791000C, "R12"},  // LDR R0, [R1, R12]
+		{"register list", 0xE92D0110, "R8"},     // STMFD SP!, {R4, R8}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newEightRegisterVM(t)
+			err := stepFault(t, v, tt.opcode)
+
+			fault, ok := vm.AsRuntimeError(err)
+			if !ok || fault.Category() != vm.CategoryUndefinedInstruction {
+				t.Fatalf("expected an undefined instruction fault, got %v", err)
+			}
+			want := tt.reg + " is not available: this machine has only R0-R7 plus SP, LR and PC"
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected %q in %q", want, err.Error())
+			}
+			if v.CPU.PC != 0x8000 {
+				t.Errorf("expected PC to stay on the faulting instruction, got 0x%08X", v.CPU.PC)
+			}
+		})
+	}
+}
+
+func TestRegisterCount_AllowsVisibleAndSpecialRegisters(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint32
+	}{
+		{"R7", 0xE3A07001},             // MOV R7, #1
+		{"SP", 0xE1A0000D},             // MOV R0, SP
+		{"LR", 0xE1A0E000},             // MOV LR, R0
+		{"MOV ignores Rn", 0xE3A00005}, // MOV R0, #5
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newEightRegisterVM(t)
+			v.CPU.PC = 0x8000
+			setupCodeWrite(v)
+			v.Memory.WriteWord(0x8000, tt.opcode)
+			if err := v.Step(); err != nil {
+				t.Fatalf("expected the instruction to run, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRegisterCount_RechecksDecodedInstructions(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE3A08001) // MOV R8, #1
+	if err := v.Step(); err != nil {
+		t.Fatalf("expected R8 to be usable by default, got %v", err)
+	}
+
+	// The instruction is now in the decode cache; lowering the limit must still reject it
+	if err := v.SetRegisterCount(8); err != nil {
+		t.Fatalf("SetRegisterCount(8) failed: %v", err)
+	}
+	v.CPU.PC = 0x8000
+	if err := v.Step(); err == nil || !strings.Contains(err.Error(), "R8 is not available") {
+		t.Errorf("expected R8 to be rejected after limiting registers, got %v", err)
+	}
+}
+
+func TestSetRegisterCount_Range(t *testing.T) {
+	v := vm.NewVM()
+	if got := v.RegisterCount(); got != vm.ARMTotalRegisterCount {
+		t.Errorf("expected default register count %d, got %d", vm.ARMTotalRegisterCount, got)
+	}
+	for _, count := range []int{0, 17} {
+		if err := v.SetRegisterCount(count); err == nil {
+			t.Errorf("expected SetRegisterCount(%d) to fail", count)
+		}
+	}
+	if err := v.SetRegisterCount(1); err != nil {
+		t.Fatalf("SetRegisterCount(1) failed: %v", err)
+	}
+	err := stepFault(t, v, 0xE3A01001) // MOV R1, #1
+	if !strings.Contains(err.Error(), "this machine has only R0 plus SP, LR and PC") {
+		t.Errorf("unexpected error for a one-register machine: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package vm_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// stepFault runs a single instruction at 0x8000 and returns the error Step reports
+func stepFault(t *testing.T, v *vm.VM, opcode uint32) error {
+	t.Helper()
+	v.State = vm.StateRunning
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	err := v.Step()
+	if err == nil {
+		t.Fatal("expected the instruction to fault")
+	}
+	return err
+}
+
+func TestRuntimeError_UnalignedLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  uint32
+		address uint32
+		size    int
+	}{
+		{"word", 0xE5910000, 0x20001, 4},     // LDR R0, [R1]
+		{"halfword", 0xE1D100B0, 0x20003, 2}, // LDRH R0, [R1]
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.CPU.R[1] = tt.address
+			err := stepFault(t, v, tt.opcode)
+
+			var alignErr *vm.AlignmentError
+			if !errors.As(err, &alignErr) {
+				t.Fatalf("expected an AlignmentError, got %T: %v", err, err)
+			}
+			if alignErr.Address != tt.address || alignErr.Size != tt.size {
+				t.Errorf("expected %d-byte access at 0x%08X, got %d bytes at 0x%08X",
+					tt.size, tt.address, alignErr.Size, alignErr.Address)
+			}
+			if alignErr.Category() != vm.CategoryAlignment || alignErr.FaultPC() != 0x8000 || alignErr.FaultAddress() != tt.address {
+				t.Errorf("unexpected fault details: category=%s pc=0x%08X address=0x%08X",
+					alignErr.Category(), alignErr.FaultPC(), alignErr.FaultAddress())
+			}
+			if !errors.As(v.LastError, &alignErr) {
+				t.Errorf("expected LastError to wrap the AlignmentError, got %v", v.LastError)
+			}
+		})
+	}
+}
+
+func TestRuntimeError_Categories(t *testing.T) {
+	tests := []struct {
+		name     string
+		opcode   uint32
+		setup    func(v *vm.VM)
+		category vm.ErrorCategory
+		address  uint32
+	}{
+		{"unmapped load", 0xE5910000, func(v *vm.VM) { v.CPU.R[1] = 0x00500000 }, vm.CategoryMemoryFault, 0x00500000},
+		{"coprocessor", 0xEE000000, func(v *vm.VM) {}, vm.CategoryUndefinedInstruction, 0x8000},
+		{"stack overflow", 0xE24DD020, func(v *vm.VM) { // SUB SP, SP, #32
+			v.StackGuard = true
+			_ = v.InitializeStack(vm.StackSegmentStart + 16)
+		}, vm.CategoryStack, vm.StackSegmentStart - 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			tt.setup(v)
+			fault, ok := vm.AsRuntimeError(stepFault(t, v, tt.opcode))
+			if !ok {
+				t.Fatalf("expected a typed runtime error, got %v", v.LastError)
+			}
+			if fault.Category() != tt.category || fault.FaultPC() != 0x8000 || fault.FaultAddress() != tt.address {
+				t.Errorf("expected %s at 0x%08X, got %s pc=0x%08X address=0x%08X",
+					tt.category, tt.address, fault.Category(), fault.FaultPC(), fault.FaultAddress())
+			}
+		})
+	}
+}
+
+func TestRuntimeError_ExecuteFromData(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.PC = vm.DataSegmentStart
+	err := v.Step()
+
+	var memErr *vm.MemoryFaultError
+	if !errors.As(err, &memErr) {
+		t.Fatalf("expected a MemoryFaultError, got %T: %v", err, err)
+	}
+	if memErr.FaultPC() != vm.DataSegmentStart || memErr.Address != vm.DataSegmentStart {
+		t.Errorf("expected the fault at the data segment, got pc=0x%08X address=0x%08X", memErr.FaultPC(), memErr.Address)
+	}
+}
+
+func TestRuntimeError_UndefinedInstructionDetail(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  uint32
+		explain string
+	}{
+		{"undefined space", 0xE7F000F0, "cond=AL bits[27:25]=011 bits[7:4]=1111: bits [27:25]=011 with bit 4 set"},
+		{"swap", 0xE1001092, "cond=AL bits[27:25]=000 bits[7:4]=1001: bits [7:4]=1001 with SH=00"},
+		{"conditional coprocessor", 0x0E000000, "cond=EQ bits[27:25]=111 bits[7:4]=0000: coprocessor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			err := stepFault(t, v, tt.opcode)
+			var undef *vm.UndefinedInstructionError
+			if !errors.As(err, &undef) {
+				t.Fatalf("expected an UndefinedInstructionError, got %T: %v", err, err)
+			}
+			if undef.Opcode != tt.opcode || undef.FaultPC() != 0x8000 {
+				t.Errorf("expected opcode 0x%08X at 0x00008000, got 0x%08X at 0x%08X", tt.opcode, undef.Opcode, undef.FaultPC())
+			}
+			if !strings.HasPrefix(undef.Explain(), tt.explain) {
+				t.Errorf("expected explanation starting %q, got %q", tt.explain, undef.Explain())
+			}
+			if v.State != vm.StateError || v.CPU.PC != 0x8000 {
+				t.Errorf("expected the VM to stop in the error state at the instruction, got state %v PC=0x%08X", v.State, v.CPU.PC)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func runSaturating(t *testing.T, opcode, rm, rn uint32) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	v.CPU.R[1] = rm
+	v.CPU.R[2] = rn
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	return v
+}
+
+func TestQADD_SaturatesPositive(t *testing.T) {
+	// QADD R0, R1, R2 (E1020051) with INT32_MAX + 1
+	v := runSaturating(t, 0xE1020051, 0x7FFFFFFF, 1)
+
+	if v.CPU.R[0] != 0x7FFFFFFF {
+		t.Errorf("expected R0=0x7FFFFFFF, got 0x%08X", v.CPU.R[0])
+	}
+	if !v.CPU.CPSR.Q {
+		t.Error("expected Q flag to be set on saturation")
+	}
+	if v.CPU.CPSR.V || v.CPU.CPSR.N || v.CPU.CPSR.Z || v.CPU.CPSR.C {
+		t.Error("QADD must not affect NZCV")
+	}
+}
+
+func TestQADD_SaturatesNegative(t *testing.T) {
+	// QADD R0, R1, R2 with INT32_MIN + -1
+	v := runSaturating(t, 0xE1020051, 0x80000000, 0xFFFFFFFF)
+
+	if v.CPU.R[0] != 0x80000000 {
+		t.Errorf("expected R0=0x80000000, got 0x%08X", v.CPU.R[0])
+	}
+	if !v.CPU.CPSR.Q {
+		t.Error("expected Q flag to be set on saturation")
+	}
+}
+
+func TestQADD_NoSaturation(t *testing.T) {
+	v := runSaturating(t, 0xE1020051, 100, 0xFFFFFFF6) // 100 + -10
+
+	if v.CPU.R[0] != 90 {
+		t.Errorf("expected R0=90, got %d", v.CPU.R[0])
+	}
+	if v.CPU.CPSR.Q {
+		t.Error("Q flag should not be set without saturation")
+	}
+	if v.CPU.PC != 0x8004 {
+		t.Errorf("expected PC=0x8004, got 0x%X", v.CPU.PC)
+	}
+}
+
+func TestQSUB_SaturatesNegative(t *testing.T) {
+	// QSUB R0, R1, R2 (E1220051) with INT32_MIN - 1
+	v := runSaturating(t, 0xE1220051, 0x80000000, 1)
+
+	if v.CPU.R[0] != 0x80000000 {
+		t.Errorf("expected R0=0x80000000, got 0x%08X", v.CPU.R[0])
+	}
+	if !v.CPU.CPSR.Q {
+		t.Error("expected Q flag to be set on saturation")
+	}
+}
+
+func TestQFlag_Sticky(t *testing.T) {
+	v := vm.NewVM()
+	v.CPU.R[1] = 0x7FFFFFFF
+	v.CPU.R[2] = 1
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE1020051) // QADD R0, R1, R2 (saturates)
+	v.Memory.WriteWord(0x8004, 0xE1030052) // QADD R0, R2, R3 (1 + 0, no saturation)
+
+	for i := 0; i < 2; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	if !v.CPU.CPSR.Q {
+		t.Error("Q flag should remain set until cleared by MSR")
+	}
+	if v.CPU.CPSR.ToUint32()&(1<<vm.CPSRBitQ) == 0 {
+		t.Error("expected Q in bit 27 of CPSR value")
+	}
+}
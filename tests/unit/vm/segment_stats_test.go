@@ -0,0 +1,85 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+func TestMemoryPermission_String(t *testing.T) {
+	tests := []struct {
+		perm vm.MemoryPermission
+		want string
+	}{
+		{vm.PermNone, "---"},
+		{vm.PermRead, "r--"},
+		{vm.PermRead | vm.PermWrite, "rw-"},
+		{vm.PermRead | vm.PermExecute, "r-x"},
+		{vm.PermRead | vm.PermWrite | vm.PermExecute, "rwx"},
+	}
+	for _, tt := range tests {
+		if got := tt.perm.String(); got != tt.want {
+			t.Errorf("permission %d: expected %q, got %q", tt.perm, tt.want, got)
+		}
+	}
+}
+
+func TestMemorySegment_AccessCounts(t *testing.T) {
+	mem := vm.NewMemory()
+	seg := func(name string) *vm.MemorySegment {
+		for _, s := range mem.Segments {
+			if s.Name == name {
+				return s
+			}
+		}
+		t.Fatalf("no %s segment", name)
+		return nil
+	}
+
+	if err := mem.WriteWord(vm.DataSegmentStart, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.WriteByteAt(vm.DataSegmentStart+4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.ReadHalfword(vm.DataSegmentStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.FetchInstruction(vm.CodeSegmentStart); err != nil {
+		t.Fatal(err)
+	}
+
+	data, code := seg("data"), seg("code")
+	if data.Reads != 1 || data.Writes != 2 || data.Executes != 0 {
+		t.Errorf("data: expected 1 read, 2 writes, 0 executes, got %d, %d, %d", data.Reads, data.Writes, data.Executes)
+	}
+	// An instruction fetch is not also counted as a read
+	if code.Reads != 0 || code.Executes != 1 {
+		t.Errorf("code: expected 0 reads and 1 execute, got %d and %d", code.Reads, code.Executes)
+	}
+
+	mem.Reset()
+	if data.Reads != 0 || data.Writes != 0 || code.Executes != 0 {
+		t.Error("expected Reset to clear the segment counts")
+	}
+}
+
+func TestMemory_HeapInUse(t *testing.T) {
+	mem := vm.NewMemory()
+	a, err := mem.Allocate(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.Allocate(8); err != nil {
+		t.Fatal(err)
+	}
+	if used, blocks := mem.HeapInUse(); used != 20 || blocks != 2 {
+		t.Errorf("expected 20 bytes in 2 blocks, got %d in %d", used, blocks)
+	}
+	if err := mem.Free(a); err != nil {
+		t.Fatal(err)
+	}
+	if used, blocks := mem.HeapInUse(); used != 8 || blocks != 1 {
+		t.Errorf("expected 8 bytes in 1 block after free, got %d in %d", used, blocks)
+	}
+}
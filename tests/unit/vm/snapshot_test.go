@@ -0,0 +1,391 @@
+package vm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// writeProgram writes instruction words starting at the code segment
+func writeProgram(t *testing.T, v *vm.VM, words ...uint32) {
+	t.Helper()
+	v.CPU.PC = vm.CodeSegmentStart
+	for i, word := range words {
+		if err := v.Memory.WriteWord(vm.CodeSegmentStart+uint32(i*4), word); err != nil {
+			t.Fatalf("failed to write instruction %d: %v", i, err)
+		}
+	}
+}
+
+func TestExportImportState_RoundTrip(t *testing.T) {
+	v := vm.NewVM()
+	writeProgram(t, v,
+		0xE3A00005, // MOV R0, #5
+		0xE3A01802, // MOV R1, #0x20000 (data segment)
+		0xE5810000, // STR R0, [R1]
+		0xE2500005, // SUBS R0, R0, #5 (sets Z and C)
+		0xE3A02007, // MOV R2, #7 (not executed before export)
+	)
+	for i := 0; i < 4; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	heapAddr, err := v.Memory.Allocate(64)
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if err := v.Memory.WriteWord(heapAddr, 0xCAFEBABE); err != nil {
+		t.Fatalf("heap write failed: %v", err)
+	}
+	v.Memory.StrictAlign = false
+	v.Memory.MakeCodeReadOnly()
+
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+
+	// Compactness: untouched segments should not carry their zero bytes
+	if buf.Len() > 16*1024 {
+		t.Errorf("snapshot too large: %d bytes", buf.Len())
+	}
+
+	expectedR := v.CPU.R
+	expectedPC := v.CPU.PC
+	expectedCPSR := v.CPU.CPSR
+	expectedCycles := v.CPU.Cycles
+	expectedNextHeap := v.Memory.NextHeapAddress
+	expectedPerms := make([]vm.MemoryPermission, len(v.Memory.Segments))
+	expectedData := make([][]byte, len(v.Memory.Segments))
+	for i, seg := range v.Memory.Segments {
+		expectedPerms[i] = seg.Permissions
+		expectedData[i] = append([]byte(nil), seg.Data...)
+	}
+
+	// Reset, then leave stale state behind that the import must overwrite
+	restored := v
+	restored.Reset()
+	restored.CPU.R[3] = 0xFFFFFFFF
+	restored.Memory.StrictAlign = true
+	if err := restored.ImportState(&buf); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	if restored.CPU.R != expectedR || restored.CPU.PC != expectedPC {
+		t.Errorf("registers differ: got R=%v PC=0x%X, want R=%v PC=0x%X", restored.CPU.R, restored.CPU.PC, expectedR, expectedPC)
+	}
+	if restored.CPU.CPSR != expectedCPSR {
+		t.Errorf("CPSR differs: got %+v, want %+v", restored.CPU.CPSR, expectedCPSR)
+	}
+	if restored.CPU.Cycles != expectedCycles {
+		t.Errorf("cycles differ: got %d, want %d", restored.CPU.Cycles, expectedCycles)
+	}
+
+	for i, seg := range restored.Memory.Segments {
+		if seg.Permissions != expectedPerms[i] {
+			t.Errorf("segment %s permissions: got %v, want %v", seg.Name, seg.Permissions, expectedPerms[i])
+		}
+		if !bytes.Equal(seg.Data, expectedData[i]) {
+			t.Errorf("segment %s contents differ", seg.Name)
+		}
+	}
+	if restored.Memory.StrictAlign {
+		t.Error("StrictAlign not restored")
+	}
+	if alloc, ok := restored.Memory.HeapAllocations[heapAddr]; !ok || alloc.Size != 64 {
+		t.Errorf("heap allocation at 0x%X not restored", heapAddr)
+	}
+	if restored.Memory.NextHeapAddress != expectedNextHeap {
+		t.Errorf("NextHeapAddress: got 0x%X, want 0x%X", restored.Memory.NextHeapAddress, expectedNextHeap)
+	}
+
+	// Execution resumes from the restored point
+	if err := restored.Memory.WriteWord(vm.CodeSegmentStart, 0); err == nil {
+		t.Error("expected code segment to remain read-only")
+	}
+	if err := restored.Step(); err != nil {
+		t.Fatalf("step after import failed: %v", err)
+	}
+	if restored.CPU.R[2] != 7 {
+		t.Errorf("expected R2=7 after resuming, got %d", restored.CPU.R[2])
+	}
+}
+
+func TestExportImportState_ReopensFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "input.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	v := vm.NewVM()
+	v.FilesystemRoot = tmpDir
+	if err := v.Memory.LoadBytes(vm.DataSegmentStart, []byte("input.txt\x00")); err != nil {
+		t.Fatalf("LoadBytes failed: %v", err)
+	}
+	writeProgram(t, v,
+		0xEF000010, // SWI #0x10 (open)
+		0xE1A04000, // MOV R4, R0 (save fd)
+		0xE3A01802, // MOV R1, #0x20000
+		0xE2811040, // ADD R1, R1, #0x40 (read buffer)
+		0xE3A02002, // MOV R2, #2
+		0xEF000012, // SWI #0x12 (read 2 bytes)
+		0xE1A00004, // MOV R0, R4 (after import)
+		0xE2811002, // ADD R1, R1, #2
+		0xEF000012, // SWI #0x12 (read 2 more bytes)
+	)
+	v.CPU.R[0] = vm.DataSegmentStart
+	v.CPU.R[1] = vm.FileModeRead
+	for i := 0; i < 6; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "input.txt") {
+		t.Errorf("expected open file path in snapshot")
+	}
+
+	restored := vm.NewVM()
+	restored.FilesystemRoot = tmpDir
+	if err := restored.ImportState(&buf); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := restored.Step(); err != nil {
+			t.Fatalf("step %d after import failed: %v", i, err)
+		}
+	}
+
+	data, err := restored.Memory.GetBytes(vm.DataSegmentStart+0x40, 4)
+	if err != nil {
+		t.Fatalf("GetBytes failed: %v", err)
+	}
+	if string(data) != "hell" {
+		t.Errorf("expected read to continue at saved offset giving \"hell\", got %q", data)
+	}
+}
+
+func TestImportState_RejectsBadInput(t *testing.T) {
+	v := vm.NewVM()
+	if err := v.ImportState(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if err := v.ImportState(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+// exportWithOpenFile opens name in tmpDir with mode through SWI_OPEN and exports the state
+func exportWithOpenFile(t *testing.T, tmpDir, name string, mode uint32) string {
+	t.Helper()
+	v := vm.NewVM()
+	v.FilesystemRoot = tmpDir
+	if err := v.Memory.LoadBytes(vm.DataSegmentStart, []byte(name+"\x00")); err != nil {
+		t.Fatalf("LoadBytes failed: %v", err)
+	}
+	writeProgram(t, v, 0xEF000010) // SWI #0x10 (open)
+	v.CPU.R[0] = vm.DataSegmentStart
+	v.CPU.R[1] = mode
+	if err := v.Step(); err != nil || v.CPU.R[0] == vm.SyscallErrorGeneral {
+		t.Fatalf("open failed: %v (R0=0x%X)", err, v.CPU.R[0])
+	}
+
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestImportState_FilesStaySandboxed(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("failed to create root: %v", err)
+	}
+	secret := filepath.Join(tmpDir, "secret.txt")
+	for _, path := range []string{filepath.Join(root, "input.txt"), secret} {
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	state := exportWithOpenFile(t, root, "input.txt", vm.FileModeRead)
+	if !strings.Contains(state, `"path": "input.txt"`) {
+		t.Fatalf("expected the path relative to the filesystem root, got:\n%s", state)
+	}
+
+	for _, path := range []string{"../secret.txt", filepath.ToSlash(secret)} {
+		t.Run(path, func(t *testing.T) {
+			edited := strings.Replace(state, `"path": "input.txt"`, `"path": "`+path+`"`, 1)
+			restored := vm.NewVM()
+			restored.FilesystemRoot = root
+			if err := restored.ImportState(strings.NewReader(edited)); err == nil {
+				t.Error("expected a path outside the filesystem root to be refused")
+			}
+		})
+	}
+}
+
+func TestImportState_KeepsOpenMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "input.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	state := exportWithOpenFile(t, tmpDir, "input.txt", vm.FileModeRead)
+
+	restored := vm.NewVM()
+	restored.FilesystemRoot = tmpDir
+	if err := restored.ImportState(strings.NewReader(state)); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	// Writing to the read-only descriptor fails as it did before the export
+	writeProgram(t, restored, 0xEF000013) // SWI #0x13 (write)
+	restored.CPU.R[0] = vm.FirstUserFD
+	restored.CPU.R[1] = vm.DataSegmentStart
+	restored.CPU.R[2] = 4
+	if err := restored.Step(); err != nil {
+		t.Fatalf("write step failed: %v", err)
+	}
+	if restored.CPU.R[0] != vm.SyscallErrorGeneral {
+		t.Errorf("expected the write to a read-only file to fail, got R0=0x%X", restored.CPU.R[0])
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "input.txt")); string(data) != "data" {
+		t.Errorf("expected the file to be unchanged, got %q", data)
+	}
+}
+
+// editState exports v, lets edit change the decoded JSON and returns it re-encoded
+func editState(t *testing.T, v *vm.VM, edit func(state map[string]any)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	var state map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+		t.Fatalf("failed to decode state: %v", err)
+	}
+	edit(state)
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("failed to encode state: %v", err)
+	}
+	return string(data)
+}
+
+func TestImportState_FailureLeavesVMUnchanged(t *testing.T) {
+	source := vm.NewVM()
+	source.CPU.R[0] = 42
+	if err := source.Memory.WriteWord(vm.DataSegmentStart, 0x11111111); err != nil {
+		t.Fatalf("WriteWord failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(state map[string]any)
+	}{
+		{"segment size mismatch after a good segment", func(state map[string]any) {
+			segments := state["segments"].([]any)
+			segments[len(segments)-1].(map[string]any)["size"] = 16
+		}},
+		{"file that cannot be reopened", func(state map[string]any) {
+			state["files"] = []any{map[string]any{"fd": 3, "path": "missing.txt", "mode": vm.FileModeRead, "offset": 0}}
+		}},
+		{"duplicate file descriptor", func(state map[string]any) {
+			file := map[string]any{"fd": 3, "path": "present.txt", "mode": vm.FileModeRead, "offset": 0}
+			state["files"] = []any{file, file}
+		}},
+		{"oversized new segment", func(state map[string]any) {
+			state["segments"] = append(state["segments"].([]any),
+				map[string]any{"name": "huge", "start": 0x80000000, "size": 0xFFFFFFFF, "permissions": 3})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "present.txt"), []byte("data"), 0644); err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+			state := editState(t, source, tt.edit)
+
+			target := vm.NewVM()
+			target.FilesystemRoot = tmpDir
+			target.CPU.R[0] = 7
+			if err := target.Memory.WriteWord(vm.DataSegmentStart, 0x22222222); err != nil {
+				t.Fatalf("WriteWord failed: %v", err)
+			}
+			segments := len(target.Memory.Segments)
+
+			if err := target.ImportState(strings.NewReader(state)); err == nil {
+				t.Fatal("expected ImportState to fail")
+			}
+			if target.CPU.R[0] != 7 {
+				t.Errorf("expected R0 to stay 7, got %d", target.CPU.R[0])
+			}
+			if word, _ := target.Memory.ReadWord(vm.DataSegmentStart); word != 0x22222222 {
+				t.Errorf("expected memory to be unchanged, got 0x%08X", word)
+			}
+			if len(target.Memory.Segments) != segments {
+				t.Errorf("expected no segments to be added, got %d (was %d)", len(target.Memory.Segments), segments)
+			}
+		})
+	}
+}
+
+func TestExportImportState_TimerAndSPSR(t *testing.T) {
+	v := vm.NewVM()
+	writeProgram(t, v,
+		0xE3A00001, // MOV R0, #1 (interrupted after this)
+		0xE1A00000, // NOP (resumed here)
+		0xEF000051, // SWI #0x51 (IRQ return, the handler)
+	)
+	v.StartTimer(100, vm.CodeSegmentStart+8)
+	v.CPU.Cycles = 150
+	v.CPU.CPSR.N = true
+	v.State = vm.StateRunning
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if !v.Timer.InIRQ {
+		t.Fatal("expected the timer interrupt to have been taken")
+	}
+	v.CPU.CPSR.N = false
+
+	var buf bytes.Buffer
+	if err := v.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	restored := vm.NewVM()
+	if err := restored.ImportState(&buf); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+	if restored.Timer != v.Timer {
+		t.Errorf("expected timer %+v, got %+v", v.Timer, restored.Timer)
+	}
+	if restored.CPU.SPSR != v.CPU.SPSR {
+		t.Errorf("expected SPSR %+v, got %+v", v.CPU.SPSR, restored.CPU.SPSR)
+	}
+
+	// Returning from the handler restores the interrupted flags and PC
+	if err := restored.Step(); err != nil {
+		t.Fatalf("IRQ return failed: %v", err)
+	}
+	if !restored.CPU.CPSR.N || restored.CPU.PC != vm.CodeSegmentStart+4 {
+		t.Errorf("expected N set and PC 0x%X after the IRQ return, got N=%v PC 0x%X",
+			vm.CodeSegmentStart+4, restored.CPU.CPSR.N, restored.CPU.PC)
+	}
+}
@@ -94,16 +94,120 @@ func TestSTR_PC_AsSource(t *testing.T) {
 	v.Memory.WriteWord(0x8000, opcode)
 	v.Step()
 
-	// Expected: PC + 8 stored (ARM2 stores PC+8 for STR)
+	// Expected: PC + 12 stored (ARM2 stores PC+12 for STR)
 	// Note: Different ARM implementations vary
 	val, err := v.Memory.ReadWord(0x9000)
 	if err != nil {
 		t.Fatalf("failed to read stored value: %v", err)
 	}
 
-	// PC + 8 = 0x8008
-	if val != 0x8008 {
-		t.Errorf("expected stored value 0x8008, got 0x%X", val)
+	// PC + 12 = 0x800C
+	if val != 0x800C {
+		t.Errorf("expected stored value 0x800C, got 0x%X", val)
+	}
+}
+
+func TestSTRB_PC_AsSource(t *testing.T) {
+	// STRB PC, [R1] stores the low byte of PC + 12
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	v.CPU.R[1] = 0x9000
+
+	opcode := uint32(0xE5C1F000) // STRB PC, [R1]
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	v.Step()
+
+	val, err := v.Memory.ReadByteAt(0x9000)
+	if err != nil {
+		t.Fatalf("failed to read stored value: %v", err)
+	}
+	if val != 0x0C {
+		t.Errorf("expected stored byte 0x0C, got 0x%X", val)
+	}
+}
+
+func TestADD_PC_WithRegisterShift(t *testing.T) {
+	// ADD R0, PC, R1, LSL R2
+	// With a register-specified shift, PC reads as current instruction + 12
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	v.CPU.R[1] = 1
+	v.CPU.R[2] = 4
+
+	opcode := uint32(0xE08F0211) // ADD R0, PC, R1, LSL R2
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	v.Step()
+
+	// Expected: (0x8000 + 12) + (1 << 4) = 0x801C
+	if v.CPU.R[0] != 0x801C {
+		t.Errorf("expected R0=0x801C, got R0=0x%X", v.CPU.R[0])
+	}
+}
+
+func TestMOV_PC_WithRegisterShift(t *testing.T) {
+	// MOV R0, PC, LSL R1 with R1=0 reads PC as current instruction + 12
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+
+	opcode := uint32(0xE1A0011F) // MOV R0, PC, LSL R1
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, opcode)
+	v.Step()
+
+	if v.CPU.R[0] != 0x800C {
+		t.Errorf("expected R0=0x800C, got R0=0x%X", v.CPU.R[0])
+	}
+}
+
+// ====== PC (R15) as Test Instruction Operand ======
+
+// TestTestInstructions_PCOperand checks that TST, TEQ, CMP and CMN read PC as the
+// instruction address + 8 (+ 12 with a register-specified shift), like other
+// data-processing instructions, and then move on to the next instruction
+func TestTestInstructions_PCOperand(t *testing.T) {
+	tests := []struct {
+		name    string
+		address uint32
+		opcode  uint32
+		r1, r2  uint32
+		z, c    bool
+	}{
+		// PC reads as 0x9000, so the comparison is equal
+		{"CMP PC, #0x9000", 0x8FF8, 0xE35F0A09, 0, 0, true, true},
+		// 0x8008 + (-0x8008) is zero with a carry out
+		{"CMN PC, R1", 0x8000, 0xE17F0001, 0xFFFF7FF8, 0, true, true},
+		{"TEQ R1, PC", 0x8000, 0xE131000F, 0x8008, 0, true, false},
+		// Bit 3 is clear in 0x8000 but set in 0x8008
+		{"TST PC, #8", 0x8000, 0xE31F0008, 0, 0, false, false},
+		{"CMP R1, PC, LSL R2", 0x8000, 0xE151021F, 0x800C, 0, true, true},
+		// The legacy TEQP form sets Rd to PC; it only updates flags and must not branch
+		{"TEQP PC, #0", 0x8000, 0xE33FF000, 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.CPU.PC = tt.address
+			v.CPU.R[1] = tt.r1
+			v.CPU.R[2] = tt.r2
+
+			setupCodeWrite(v)
+			v.Memory.WriteWord(tt.address, tt.opcode)
+			if err := v.Step(); err != nil {
+				t.Fatalf("step failed: %v", err)
+			}
+
+			if v.CPU.CPSR.Z != tt.z {
+				t.Errorf("expected Z=%v, got Z=%v", tt.z, v.CPU.CPSR.Z)
+			}
+			if v.CPU.CPSR.C != tt.c {
+				t.Errorf("expected C=%v, got C=%v", tt.c, v.CPU.CPSR.C)
+			}
+			if v.CPU.PC != tt.address+4 {
+				t.Errorf("expected PC=0x%X, got PC=0x%X", tt.address+4, v.CPU.PC)
+			}
+		})
 	}
 }
 
@@ -0,0 +1,89 @@
+package vm_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+const (
+	pushR0  = 0xE52D0004 // STR R0, [SP, #-4]!
+	popR0   = 0xE49D0004 // LDR R0, [SP], #4
+	branch0 = 0xEAFFFFFD // B back one instruction
+)
+
+// runStackLoop repeats op in a loop with a 16-byte stack and returns the first error
+func runStackLoop(t *testing.T, op uint32, guard bool, steps int) (*vm.VM, error) {
+	t.Helper()
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, op)
+	v.Memory.WriteWord(0x8004, branch0)
+	v.CPU.PC = 0x8000
+	if err := v.InitializeStack(vm.StackSegmentStart + 16); err != nil {
+		t.Fatalf("InitializeStack failed: %v", err)
+	}
+	v.StackGuard = guard
+
+	for i := 0; i < steps; i++ {
+		if err := v.Step(); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func TestStackGuard_OverflowHaltsAtOffendingPush(t *testing.T) {
+	// Four pushes fill the stack exactly; the fifth moves SP below the segment
+	v, err := runStackLoop(t, pushR0, true, 20)
+	if !errors.Is(err, vm.ErrStackOverflow) {
+		t.Fatalf("expected a stack overflow error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "PC=0x00008000") || !strings.Contains(err.Error(), "SP=0x0003FFFC") {
+		t.Errorf("expected the offending PC and SP in %q", err)
+	}
+	if v.State != vm.StateError || v.CPU.Instructions != 9 {
+		t.Errorf("expected to halt on the ninth instruction, state=%v instructions=%d", v.State, v.CPU.Instructions)
+	}
+	if err := v.Step(); err == nil {
+		t.Error("expected the VM to stay halted")
+	}
+}
+
+func TestStackGuard_UnderflowHaltsAtOffendingPop(t *testing.T) {
+	_, err := runStackLoop(t, popR0, true, 20)
+	if !errors.Is(err, vm.ErrStackUnderflow) {
+		t.Fatalf("expected a stack underflow error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "PC=0x00008000") || !strings.Contains(err.Error(), "SP=0x00040014") {
+		t.Errorf("expected the offending PC and SP in %q", err)
+	}
+}
+
+func TestStackGuard_DisabledAllowsRelocatedSP(t *testing.T) {
+	v, err := runStackLoop(t, pushR0, false, 20)
+	if err != nil {
+		t.Fatalf("expected no error with the guard disabled, got %v", err)
+	}
+	if sp := v.CPU.GetSP(); sp >= vm.StackSegmentStart {
+		t.Errorf("expected SP to have moved below the stack segment, got 0x%08X", sp)
+	}
+}
+
+func TestStackGuard_IndependentOfStackTrace(t *testing.T) {
+	v := vm.NewVM()
+	v.StackTrace = vm.NewStackTrace(nil, vm.StackSegmentStart+16, vm.StackSegmentStart)
+	v.StackGuard = true
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xE24DD020) // SUB SP, SP, #32
+	v.CPU.PC = 0x8000
+	if err := v.InitializeStack(vm.StackSegmentStart + 16); err != nil {
+		t.Fatalf("InitializeStack failed: %v", err)
+	}
+
+	if err := v.Step(); !errors.Is(err, vm.ErrStackOverflow) {
+		t.Errorf("expected a stack overflow error, got %v", err)
+	}
+}
@@ -317,3 +317,38 @@ func TestPerformanceStatistics_Finalize(t *testing.T) {
 		t.Error("InstructionsPerSec is negative")
 	}
 }
+
+func TestPerformanceStatistics_RecordExecution(t *testing.T) {
+	stats := vm.NewPerformanceStatistics()
+	stats.Start()
+
+	tests := []struct {
+		opcode   uint32
+		typ      vm.InstructionType
+		mnemonic string
+		mode     string
+	}{
+		{0xE3A00001, vm.InstDataProcessing, "MOV", ""},  // MOV R0, #1
+		{0x03A00001, vm.InstDataProcessing, "MOV", ""},  // MOVEQ R0, #1
+		{0xE0910002, vm.InstDataProcessing, "ADDS", ""}, // ADDS R0, R1, R2
+		{0xE5910000, vm.InstLoadStore, "LDR", "[Rn]"},
+		{0xE5B10004, vm.InstLoadStore, "LDR", "[Rn, #imm]!"},
+		{0xE7910002, vm.InstLoadStore, "LDR", "[Rn, Rm]"},
+		{0xE6810004, vm.InstLoadStore, "STR", "[Rn], Rm"},
+		{0xE1D100B2, vm.InstLoadStore, "LDRH", "[Rn, #imm]"},
+		{0xE08100B2, vm.InstLoadStore, "STRH", "[Rn], Rm"},
+	}
+	for i, tt := range tests {
+		stats.RecordExecution(&vm.Instruction{Address: 0x8000 + uint32(i)*4, Opcode: tt.opcode, Type: tt.typ}, 1)
+		if tt.mode != "" && stats.AddressingModeCounts[tt.mnemonic][tt.mode] == 0 {
+			t.Errorf("0x%08X: expected %s %s, got %v", tt.opcode, tt.mnemonic, tt.mode, stats.AddressingModeCounts[tt.mnemonic])
+		}
+	}
+
+	if stats.InstructionCounts["MOV"] != 2 || stats.InstructionCounts["ADDS"] != 1 || stats.InstructionCounts["LDR"] != 3 {
+		t.Errorf("unexpected instruction counts: %v", stats.InstructionCounts)
+	}
+	if stats.TotalInstructions != uint64(len(tests)) {
+		t.Errorf("expected %d instructions, got %d", len(tests), stats.TotalInstructions)
+	}
+}
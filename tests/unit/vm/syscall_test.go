@@ -3,6 +3,7 @@ package vm_test
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/lookbusy1344/arm-emulator/vm"
@@ -149,6 +150,71 @@ func TestSWI_GetTime(t *testing.T) {
 	}
 }
 
+func TestSWI_GetDateTime(t *testing.T) {
+	// SWI #0x34 (get date/time into struct at R0)
+	v := vm.NewVM()
+	buffer := uint32(vm.DataSegmentStart)
+	v.CPU.R[0] = buffer
+	v.CPU.PC = 0x8000
+
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000034)
+	if err := v.Step(); err != nil {
+		t.Fatalf("get datetime failed: %v", err)
+	}
+	if v.CPU.R[0] != 0 {
+		t.Fatalf("expected R0=0 (success), got R0=0x%X", v.CPU.R[0])
+	}
+
+	ranges := []struct {
+		name     string
+		min, max uint32
+	}{
+		{"year", 2000, 9999},
+		{"month", 1, 12},
+		{"day", 1, 31},
+		{"hour", 0, 23},
+		{"minute", 0, 59},
+		{"second", 0, 60},
+		{"weekday", 0, 6},
+	}
+	for i, r := range ranges {
+		value, err := v.Memory.ReadWord(buffer + uint32(i)*4) // #nosec G115 -- i < 7
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", r.name, err)
+		}
+		if value < r.min || value > r.max {
+			t.Errorf("%s = %d, expected %d-%d", r.name, value, r.min, r.max)
+		}
+	}
+}
+
+func TestSWI_GetDateTimeInvalidBuffer(t *testing.T) {
+	tests := []struct {
+		name string
+		addr uint32
+	}{
+		{"address space overflow", 0xFFFFFFF0},
+		{"unmapped memory", 0x00000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.CPU.R[0] = tt.addr
+			v.CPU.PC = 0x8000
+
+			setupCodeWrite(v)
+			v.Memory.WriteWord(0x8000, 0xEF000034)
+			if err := v.Step(); err != nil {
+				t.Fatalf("get datetime should report failure in R0, got error: %v", err)
+			}
+			if v.CPU.R[0] != 0xFFFFFFFF {
+				t.Errorf("expected R0=0xFFFFFFFF (error), got R0=0x%X", v.CPU.R[0])
+			}
+		})
+	}
+}
+
 func TestSWI_GetRandom(t *testing.T) {
 	// SWI #0x31 (get random number)
 	v := vm.NewVM()
@@ -181,6 +247,47 @@ func TestSWI_GetRandom(t *testing.T) {
 	}
 }
 
+// readRandoms runs SWI #0x31 n times from 0x8000 and returns the values
+func readRandoms(t *testing.T, v *vm.VM, n int) []uint32 {
+	t.Helper()
+	setupCodeWrite(v)
+	values := make([]uint32, n)
+	for i := range values {
+		v.CPU.PC = 0x8000
+		v.Memory.WriteWord(0x8000, 0xEF000031)
+		if err := v.Step(); err != nil {
+			t.Fatalf("get random failed: %v", err)
+		}
+		values[i] = v.CPU.R[0]
+	}
+	return values
+}
+
+func TestSWI_GetRandomSeeded(t *testing.T) {
+	v := vm.NewVM()
+	v.SetRandomSeed(12345)
+	first := readRandoms(t, v, 3)
+
+	// Reset replays the seeded sequence
+	v.Reset()
+	if again := readRandoms(t, v, 3); !slices.Equal(again, first) {
+		t.Errorf("sequence after reset %v differs from %v", again, first)
+	}
+
+	// A separate VM with the same seed produces the same sequence
+	other := vm.NewVM()
+	other.SetRandomSeed(12345)
+	if seq := readRandoms(t, other, 3); !slices.Equal(seq, first) {
+		t.Errorf("sequence from second VM %v differs from %v", seq, first)
+	}
+
+	// A different seed gives a different sequence
+	other.SetRandomSeed(54321)
+	if seq := readRandoms(t, other, 3); slices.Equal(seq, first) {
+		t.Errorf("different seed produced the same sequence %v", seq)
+	}
+}
+
 func TestSWI_DebugPrint(t *testing.T) {
 	// SWI #0xF0 (debug print)
 	v := vm.NewVM()
@@ -480,10 +587,63 @@ func TestSWI_GetArguments(t *testing.T) {
 		t.Errorf("expected argc=3, got argc=%d", argc)
 	}
 
-	// R1 should contain argv pointer (currently 0 in implementation)
+	// R1 points at a NULL-terminated array of string pointers
 	argv := v.CPU.R[1]
-	if argv != 0 {
-		t.Logf("argv pointer = 0x%08X (currently unimplemented, returns 0)", argv)
+	if argv == 0 {
+		t.Fatal("expected a non-NULL argv pointer")
+	}
+	for i, want := range v.ProgramArguments {
+		ptr, err := v.Memory.ReadWord(argv + uint32(i)*4)
+		if err != nil {
+			t.Fatalf("failed to read argv[%d]: %v", i, err)
+		}
+		if got := readCString(t, v, ptr); got != want {
+			t.Errorf("argv[%d]: expected %q, got %q", i, want, got)
+		}
+	}
+	if end, _ := v.Memory.ReadWord(argv + 3*4); end != 0 {
+		t.Errorf("expected argv[3]=NULL, got 0x%08X", end)
+	}
+}
+
+func TestSWI_GetArguments_TwoArgs(t *testing.T) {
+	v := vm.NewVM()
+	v.SetProgramArguments([]string{"hello", "world!"})
+	v.CPU.PC = 0x8000
+
+	// SWI #0x32 twice
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000032)
+	v.Memory.WriteWord(0x8004, 0xEF000032)
+	if err := v.Step(); err != nil {
+		t.Fatalf("get_arguments failed: %v", err)
+	}
+	if v.CPU.R[0] != 2 {
+		t.Fatalf("expected argc=2, got %d", v.CPU.R[0])
+	}
+	argv := v.CPU.R[1]
+
+	for i, want := range []string{"hello", "world!"} {
+		ptr, err := v.Memory.ReadWord(argv + uint32(i)*4)
+		if err != nil {
+			t.Fatalf("failed to read argv[%d]: %v", i, err)
+		}
+		if got := readCString(t, v, ptr); got != want {
+			t.Errorf("argv[%d]: expected %q, got %q", i, want, got)
+		}
+	}
+
+	// A second call returns the same block rather than allocating another
+	if err := v.Step(); err != nil {
+		t.Fatalf("second get_arguments failed: %v", err)
+	}
+	if v.CPU.R[1] != argv {
+		t.Errorf("expected the same argv 0x%08X, got 0x%08X", argv, v.CPU.R[1])
+	}
+
+	// The emulator allocated the block, so it is not the program's leak
+	if leaks := v.HeapLeaks(); len(leaks) != 0 {
+		t.Errorf("expected argv not to be reported as a leak, got %+v", leaks)
 	}
 }
 
@@ -507,6 +667,9 @@ func TestSWI_GetArguments_Empty(t *testing.T) {
 	if argc != 0 {
 		t.Errorf("expected argc=0 for empty args, got argc=%d", argc)
 	}
+	if argv := v.CPU.R[1]; argv != 0 {
+		t.Errorf("expected argv=NULL for empty args, got 0x%08X", argv)
+	}
 }
 
 func TestSWI_GetEnvironment(t *testing.T) {
@@ -524,10 +687,10 @@ func TestSWI_GetEnvironment(t *testing.T) {
 		t.Fatalf("get_environment failed: %v", err)
 	}
 
-	// R0 should contain environment pointer (currently 0 in simplified implementation)
+	// Without any variables given, envp is NULL
 	envp := v.CPU.R[0]
 	if envp != 0 {
-		t.Errorf("expected envp=0 (unimplemented), got envp=0x%08X", envp)
+		t.Errorf("expected envp=0 with no variables, got envp=0x%08X", envp)
 	}
 
 	// PC should have advanced
@@ -536,6 +699,49 @@ func TestSWI_GetEnvironment(t *testing.T) {
 	}
 }
 
+func TestSWI_GetEnvironment_TwoVars(t *testing.T) {
+	v := vm.NewVM()
+	if err := v.SetEnvironment([]string{"HOME=/sandbox", "MODE=test=1"}); err != nil {
+		t.Fatalf("SetEnvironment failed: %v", err)
+	}
+	v.CPU.PC = 0x8000
+
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000033)
+	if err := v.Step(); err != nil {
+		t.Fatalf("get_environment failed: %v", err)
+	}
+
+	envp := v.CPU.R[0]
+	if envp == 0 {
+		t.Fatal("expected a non-NULL envp pointer")
+	}
+	for i, want := range []string{"HOME=/sandbox", "MODE=test=1"} {
+		ptr, err := v.Memory.ReadWord(envp + uint32(i)*4)
+		if err != nil {
+			t.Fatalf("failed to read envp[%d]: %v", i, err)
+		}
+		if got := readCString(t, v, ptr); got != want {
+			t.Errorf("envp[%d]: expected %q, got %q", i, want, got)
+		}
+	}
+	if end, _ := v.Memory.ReadWord(envp + 2*4); end != 0 {
+		t.Errorf("expected envp[2]=NULL, got 0x%08X", end)
+	}
+}
+
+func TestSetEnvironment_Invalid(t *testing.T) {
+	v := vm.NewVM()
+	for _, vars := range [][]string{{"NOEQUALS"}, {"=value"}} {
+		if err := v.SetEnvironment(vars); err == nil {
+			t.Errorf("expected %q to be rejected", vars)
+		}
+	}
+	if len(v.Environment) != 0 {
+		t.Errorf("expected rejected variables not to be set, got %v", v.Environment)
+	}
+}
+
 func TestSWI_Assert_Pass(t *testing.T) {
 	// Test ASSERT syscall (0xF4) with passing condition
 	v := vm.NewVM()
@@ -916,3 +1122,290 @@ func TestReallocateSetsMemoryTracking(t *testing.T) {
 		t.Errorf("expected LastMemoryWriteSize=%d, got %d", expectedSize, v.LastMemoryWriteSize)
 	}
 }
+
+// writeGuestString writes a null-terminated string into guest memory
+func writeGuestString(v *vm.VM, addr uint32, s string) {
+	setupDataWrite(v)
+	for i, ch := range []byte(s) {
+		v.Memory.WriteByteAt(addr+uint32(i), ch) // #nosec G115 -- short test string
+	}
+	v.Memory.WriteByteAt(addr+uint32(len(s)), 0) // #nosec G115 -- short test string
+}
+
+// regionSWI runs a DUMP_REGION or LOAD_REGION syscall and returns R0
+func regionSWI(t *testing.T, v *vm.VM, swi, addr, length, pathAddr uint32) uint32 {
+	t.Helper()
+	v.CPU.R[0] = addr
+	v.CPU.R[1] = length
+	v.CPU.R[2] = pathAddr
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000000|swi)
+	if err := v.Step(); err != nil {
+		t.Fatalf("SWI #0x%02X failed: %v", swi, err)
+	}
+	return v.CPU.R[0]
+}
+
+func TestSWI_DumpAndLoadRegion(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := vm.NewVM()
+	v.FilesystemRoot = tmpDir
+
+	const region, pathAddr = uint32(0x20000), uint32(0x21000)
+	writeGuestString(v, pathAddr, "checkpoint.bin")
+	want := []byte("checkpoint data\x00\x01\x02\xFF")
+	for i, b := range want {
+		v.Memory.WriteByteAt(region+uint32(i), b) // #nosec G115 -- small test offset
+	}
+	length := uint32(len(want)) // #nosec G115 -- small test data
+
+	if n := regionSWI(t, v, vm.SWI_DUMP_REGION, region, length, pathAddr); n != length {
+		t.Fatalf("DUMP_REGION: expected %d bytes, got 0x%08X", length, n)
+	}
+	onDisk, err := os.ReadFile(filepath.Join(tmpDir, "checkpoint.bin")) // #nosec G304 -- test temp file
+	if err != nil || !slices.Equal(onDisk, want) {
+		t.Fatalf("dump file mismatch: %q (err %v)", onDisk, err)
+	}
+
+	for i := uint32(0); i < length; i++ {
+		v.Memory.WriteByteAt(region+i, 0)
+	}
+
+	// Ask for more than the file holds: only the file's bytes are loaded
+	v.Memory.WriteByteAt(region+length, 0xAA)
+	if n := regionSWI(t, v, vm.SWI_LOAD_REGION, region, length+16, pathAddr); n != length {
+		t.Fatalf("LOAD_REGION: expected %d bytes, got 0x%08X", length, n)
+	}
+	for i, b := range want {
+		got, _ := v.Memory.ReadByteAt(region + uint32(i)) // #nosec G115 -- small test offset
+		if got != b {
+			t.Errorf("byte %d: expected 0x%02X after restore, got 0x%02X", i, b, got)
+		}
+	}
+	if b, _ := v.Memory.ReadByteAt(region + length); b != 0xAA {
+		t.Errorf("expected memory past the file to be untouched, got 0x%02X", b)
+	}
+	if !v.HasMemoryWrite || v.LastMemoryWrite != region || v.LastMemoryWriteSize != length {
+		t.Errorf("expected memory write tracking for the restored region, got 0x%08X size %d", v.LastMemoryWrite, v.LastMemoryWriteSize)
+	}
+}
+
+func TestSWI_DumpAndLoadRegion_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := vm.NewVM()
+	v.FilesystemRoot = tmpDir
+
+	const region, pathAddr, escapeAddr, missingAddr = uint32(0x20000), uint32(0x21000), uint32(0x21100), uint32(0x21200)
+	writeGuestString(v, pathAddr, "region.bin")
+	writeGuestString(v, escapeAddr, "../escape.bin")
+	writeGuestString(v, missingAddr, "missing.bin")
+
+	tests := []struct {
+		name     string
+		swi      uint32
+		length   uint32
+		pathAddr uint32
+	}{
+		{"dump over MaxWriteSize", vm.SWI_DUMP_REGION, vm.MaxWriteSize + 1, pathAddr},
+		{"load over MaxReadSize", vm.SWI_LOAD_REGION, vm.MaxReadSize + 1, pathAddr},
+		{"dump outside fsroot", vm.SWI_DUMP_REGION, 16, escapeAddr},
+		{"load outside fsroot", vm.SWI_LOAD_REGION, 16, escapeAddr},
+		{"load missing file", vm.SWI_LOAD_REGION, 16, missingAddr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := regionSWI(t, v, tt.swi, region, tt.length, tt.pathAddr); n != vm.SyscallErrorGeneral {
+				t.Errorf("expected error code, got 0x%08X", n)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "region.bin")); !os.IsNotExist(err) {
+		t.Error("expected rejected dump not to create a file")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(tmpDir), "escape.bin")); !os.IsNotExist(err) {
+		t.Error("expected dump outside fsroot not to create a file")
+	}
+}
+
+// runFSRootSWI executes SWI num with R0 and R1 set
+func runFSRootSWI(t *testing.T, v *vm.VM, num, r0, r1 uint32) {
+	t.Helper()
+	v.CPU.R[0] = r0
+	v.CPU.R[1] = r1
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000000|num)
+	if err := v.Step(); err != nil {
+		t.Fatalf("SWI 0x%02X failed: %v", num, err)
+	}
+}
+
+// readCString reads a null-terminated string from guest memory
+func readCString(t *testing.T, v *vm.VM, addr uint32) string {
+	t.Helper()
+	var s []byte
+	for {
+		b, err := v.Memory.ReadByteAt(addr)
+		if err != nil {
+			t.Fatalf("failed to read string at 0x%08X: %v", addr, err)
+		}
+		if b == 0 {
+			return string(s)
+		}
+		s = append(s, b)
+		addr++
+	}
+}
+
+func TestSWI_GetFSRoot(t *testing.T) {
+	root := "/tmp/sandbox"
+	buffer := uint32(vm.DataSegmentStart)
+	tests := []struct {
+		name   string
+		maxLen uint32
+		want   string
+	}{
+		{"fits", 64, root},
+		{"exact fit with terminator", uint32(len(root) + 1), root},
+		{"truncated", 5, "/tmp"},
+		{"terminator only", 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.FilesystemRoot = root
+			setupDataWrite(v)
+			v.Memory.WriteByteAt(buffer+tt.maxLen, 0xAA)
+
+			runFSRootSWI(t, v, vm.SWI_GET_FS_ROOT, buffer, tt.maxLen)
+			if v.CPU.R[0] != uint32(len(tt.want)) {
+				t.Errorf("expected R0=%d, got %d", len(tt.want), v.CPU.R[0])
+			}
+			if got := readCString(t, v, buffer); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if b, _ := v.Memory.ReadByteAt(buffer + tt.maxLen); b != 0xAA {
+				t.Errorf("wrote past the end of the buffer")
+			}
+		})
+	}
+}
+
+func TestSWI_GetFSRootInvalidBuffer(t *testing.T) {
+	tests := []struct {
+		name         string
+		addr, maxLen uint32
+	}{
+		{"zero length", vm.DataSegmentStart, 0},
+		{"address space overflow", 0xFFFFFFF0, 32},
+		{"unmapped memory", 0x00000000, 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.FilesystemRoot = "/tmp/sandbox"
+			runFSRootSWI(t, v, vm.SWI_GET_FS_ROOT, tt.addr, tt.maxLen)
+			if v.CPU.R[0] != vm.SyscallErrorGeneral {
+				t.Errorf("expected R0=0x%X, got 0x%X", uint32(vm.SyscallErrorGeneral), v.CPU.R[0])
+			}
+		})
+	}
+}
+
+func TestSWI_SetFSRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "file.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := uint32(vm.DataSegmentStart)
+
+	tests := []struct {
+		name    string
+		allow   bool
+		path    string
+		want    uint32
+		newRoot string
+	}{
+		{"refused without permission", false, "sub", vm.SyscallErrorGeneral, root},
+		{"subdirectory", true, "sub", 0, filepath.Join(root, "sub")},
+		{"escape", true, "../", vm.SyscallErrorGeneral, root},
+		{"missing directory", true, "missing", vm.SyscallErrorGeneral, root},
+		{"not a directory", true, "file.txt", vm.SyscallErrorGeneral, root},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vm.NewVM()
+			v.FilesystemRoot = root
+			v.AllowFSRootChange = tt.allow
+			setupDataWrite(v)
+			for i := 0; i < len(tt.path); i++ {
+				v.Memory.WriteByteAt(path+uint32(i), tt.path[i]) // #nosec G115 -- short test path
+			}
+			v.Memory.WriteByteAt(path+uint32(len(tt.path)), 0) // #nosec G115 -- short test path
+
+			runFSRootSWI(t, v, vm.SWI_SET_FS_ROOT, path, 0)
+			if v.CPU.R[0] != tt.want {
+				t.Errorf("expected R0=0x%X, got 0x%X", tt.want, v.CPU.R[0])
+			}
+			if v.FilesystemRoot != tt.newRoot {
+				t.Errorf("expected root %q, got %q", tt.newRoot, v.FilesystemRoot)
+			}
+		})
+	}
+}
+
+func TestSWI_GetCycles(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	program := []uint32{
+		0xE3A020FF, // MOV R2, #0xFF
+		0xEF000036, // SWI #0x36 (get cycles)
+		0xE1A04000, // MOV R4, R0
+		0xE0030292, // MUL R3, R2, R2 (6 cycles with a multiplier of 0xFF)
+		0xE2833001, // ADD R3, R3, #1
+		0x03A03000, // MOVEQ R3, #0 (skipped, Z is clear)
+		0xEF000036, // SWI #0x36 (get cycles)
+	}
+	for i, opcode := range program {
+		v.Memory.WriteWord(0x8000+uint32(i)*4, opcode)
+	}
+	v.CPU.PC = 0x8000
+
+	for i := range program {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i+1, err)
+		}
+	}
+
+	if v.CPU.R[4] != 1 {
+		t.Errorf("expected the first read to count the MOV before it, got %d", v.CPU.R[4])
+	}
+	// SWI + MOV + MUL + ADD + skipped MOVEQ
+	if delta := v.CPU.R[0] - v.CPU.R[4]; delta != 1+1+6+1+1 {
+		t.Errorf("expected a delta of 10 cycles, got %d", delta)
+	}
+	if v.CPU.R[1] != 0 {
+		t.Errorf("expected high word 0, got %d", v.CPU.R[1])
+	}
+}
+
+func TestSWI_GetCyclesHighWord(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000036) // SWI #0x36 (get cycles)
+	v.CPU.PC = 0x8000
+	v.CycleLimit = 0
+	v.CPU.Cycles = 0x1_2345_6789
+
+	if err := v.Step(); err != nil {
+		t.Fatalf("get cycles failed: %v", err)
+	}
+	if v.CPU.R[0] != 0x23456789 || v.CPU.R[1] != 1 {
+		t.Errorf("expected R1:R0 = 0x1:0x23456789, got 0x%X:0x%08X", v.CPU.R[1], v.CPU.R[0])
+	}
+}
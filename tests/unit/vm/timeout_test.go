@@ -0,0 +1,67 @@
+package vm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// newLoopVM returns a VM whose program is an endless branch to itself with no cycle limit
+func newLoopVM(t *testing.T) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	v.CycleLimit = 0
+	v.CPU.PC = vm.CodeSegmentStart
+	setupCodeWrite(v)
+	if err := v.Memory.WriteWord(vm.CodeSegmentStart, 0xEAFFFFFE); err != nil { // B .
+		t.Fatalf("write program: %v", err)
+	}
+	return v
+}
+
+// TestRunContext_Timeout tests that an infinite loop stops with a TimeoutError once the
+// context's deadline passes
+func TestRunContext_Timeout(t *testing.T) {
+	v := newLoopVM(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := v.RunContext(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run took %v to notice the deadline", elapsed)
+	}
+
+	var timeoutErr *vm.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a *vm.TimeoutError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the TimeoutError to unwrap to context.DeadlineExceeded")
+	}
+	if timeoutErr.PC != vm.CodeSegmentStart || timeoutErr.Instructions == 0 {
+		t.Errorf("expected PC=0x%X and a non-zero instruction count, got %+v", vm.CodeSegmentStart, timeoutErr)
+	}
+	if v.State != vm.StateError || v.LastError != err {
+		t.Errorf("expected the VM in the error state with LastError set, got %v / %v", v.State, v.LastError)
+	}
+}
+
+// TestRunContext_Cancelled tests that cancellation is reported as such, not as a timeout
+func TestRunContext_Cancelled(t *testing.T) {
+	v := newLoopVM(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := v.RunContext(ctx)
+	var timeoutErr *vm.TimeoutError
+	if errors.As(err, &timeoutErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+	if v.CPU.Instructions != 0 {
+		t.Errorf("expected no instructions after an already-cancelled context, got %d", v.CPU.Instructions)
+	}
+}
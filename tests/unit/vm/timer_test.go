@@ -0,0 +1,134 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+const (
+	timerHandler = 0x8100
+	swiIRQReturn = 0xEF000051 // SWI #0x51
+	addR4One     = 0xE2844001 // ADD R4, R4, #1
+	movsR0Zero   = 0xE3B00000 // MOVS R0, #0 (sets Z)
+	branchSelf   = 0xEAFFFFFE // B . (spin)
+)
+
+// newTimerVM builds a VM spinning at 0x8000 with a handler that counts interrupts in R4
+func newTimerVM(t *testing.T, period uint64) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, branchSelf)
+	v.Memory.WriteWord(timerHandler, movsR0Zero)
+	v.Memory.WriteWord(timerHandler+4, addR4One)
+	v.Memory.WriteWord(timerHandler+8, swiIRQReturn)
+	v.CPU.PC = 0x8000
+	v.StartTimer(period, timerHandler)
+	return v
+}
+
+// stepUntil steps until PC is pc, failing after limit steps
+func stepUntil(t *testing.T, v *vm.VM, pc uint32, limit int) {
+	t.Helper()
+	for i := 0; i < limit; i++ {
+		if v.CPU.PC == pc {
+			return
+		}
+		if err := v.Step(); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+	}
+	t.Fatalf("PC did not reach 0x%08X, at 0x%08X", pc, v.CPU.PC)
+}
+
+func TestTimer_InterruptEntersHandlerAndReturns(t *testing.T) {
+	v := newTimerVM(t, 5)
+	v.CPU.CPSR.C = true
+
+	stepUntil(t, v, timerHandler, 10)
+	if !v.Timer.InIRQ || v.Timer.ReturnPC != 0x8000 || !v.CPU.SPSR.C {
+		t.Fatalf("expected IRQ entry to save PC and CPSR, timer=%+v spsr=%+v", v.Timer, v.CPU.SPSR)
+	}
+	if v.CPU.Cycles < 5 {
+		t.Errorf("expected the interrupt after 5 cycles, got %d", v.CPU.Cycles)
+	}
+
+	// The handler clobbers the flags; returning restores them
+	for i := 0; i < 3; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+	}
+	if v.CPU.PC != 0x8000 || v.Timer.InIRQ {
+		t.Fatalf("expected to resume at 0x00008000, PC=0x%08X inIRQ=%v", v.CPU.PC, v.Timer.InIRQ)
+	}
+	if v.CPU.CPSR.Z || !v.CPU.CPSR.C {
+		t.Errorf("expected the interrupted flags to be restored, got %+v", v.CPU.CPSR)
+	}
+	if v.CPU.R[4] != 1 || v.Timer.Count != 1 {
+		t.Errorf("expected one interrupt, R4=%d count=%d", v.CPU.R[4], v.Timer.Count)
+	}
+}
+
+func TestTimer_PendingInterruptWaitsForReturn(t *testing.T) {
+	// Every cycle is due, but the handler is never re-entered while it runs
+	v := newTimerVM(t, 1)
+	for i := 0; i < 40; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+		if v.Timer.InIRQ && v.Timer.ReturnPC != 0x8000 {
+			t.Fatalf("handler was interrupted, return PC 0x%08X", v.Timer.ReturnPC)
+		}
+	}
+	if v.CPU.R[4] < 5 {
+		t.Errorf("expected repeated interrupts, R4=%d", v.CPU.R[4])
+	}
+}
+
+func TestTimer_StopAndReturnOutsideHandler(t *testing.T) {
+	v := newTimerVM(t, 0)
+	for i := 0; i < 20; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+	}
+	if v.CPU.PC != 0x8000 || v.Timer.Count != 0 {
+		t.Errorf("expected a stopped timer not to interrupt, PC=0x%08X count=%d", v.CPU.PC, v.Timer.Count)
+	}
+
+	v.CPU.PC = timerHandler + 8
+	if err := v.Step(); err == nil {
+		t.Error("expected IRQ_RETURN outside a handler to fail")
+	}
+}
+
+func TestTimer_SetTimerRejectsBadVector(t *testing.T) {
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	v.Memory.WriteWord(0x8000, 0xEF000050) // SWI #0x50
+	v.CPU.PC = 0x8000
+	v.CPU.R[0] = 10
+	v.CPU.R[1] = timerHandler + 2
+
+	if err := v.Step(); err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if v.CPU.R[0] != vm.SyscallErrorGeneral || v.Timer.Period != 0 {
+		t.Errorf("expected a misaligned vector to be refused, R0=0x%08X timer=%+v", v.CPU.R[0], v.Timer)
+	}
+}
+
+func TestTimer_StepBackUndoesInterrupt(t *testing.T) {
+	v := newTimerVM(t, 3)
+	v.EnableHistory(16)
+	stepUntil(t, v, timerHandler, 10)
+
+	if err := v.StepBack(); err != nil {
+		t.Fatalf("step back failed: %v", err)
+	}
+	if v.CPU.PC != 0x8000 || v.Timer.InIRQ || v.Timer.Count != 0 {
+		t.Errorf("expected the interrupt to be undone, PC=0x%08X timer=%+v", v.CPU.PC, v.Timer)
+	}
+}
@@ -0,0 +1,98 @@
+package vm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// runCountdownTrace runs the countdown program with a trace configured by setup and
+// returns the flushed output lines
+func runCountdownTrace(t *testing.T, setup func(*vm.ExecutionTrace) error) []string {
+	t.Helper()
+	v := loadCountdown(t, countdownProgram)
+	var buf bytes.Buffer
+	v.ExecutionTrace = vm.NewExecutionTrace(&buf)
+	if err := setup(v.ExecutionTrace); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	v.ExecutionTrace.Start()
+
+	if err := v.Run(); v.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+	if err := v.ExecutionTrace.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestExecutionTrace_CSVColumns(t *testing.T) {
+	lines := runCountdownTrace(t, func(trace *vm.ExecutionTrace) error {
+		if err := trace.SetFormat(vm.TraceFormatCSV); err != nil {
+			return err
+		}
+		return trace.SetColumns("pc, mnemonic,regs,cycles")
+	})
+
+	// Header plus 16 entries
+	if len(lines) != 17 {
+		t.Fatalf("expected 17 lines, got %d:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if lines[0] != "pc,mnemonic,regs,cycles" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	// The first ADD: the mnemonic holds a comma, so it is quoted
+	if want := `0x8008,"ADD R0, R0, R1",R0=0x00000005 PC=0x0000800C,3`; lines[3] != want {
+		t.Errorf("unexpected row:\n got %q\nwant %q", lines[3], want)
+	}
+}
+
+func TestExecutionTrace_JSONL(t *testing.T) {
+	lines := runCountdownTrace(t, func(trace *vm.ExecutionTrace) error {
+		if err := trace.SetFormat(vm.TraceFormatJSONL); err != nil {
+			return err
+		}
+		return trace.SetColumns("cpsr,pc,opcode,regs")
+	})
+	if len(lines) != 16 {
+		t.Fatalf("expected 16 lines, got %d", len(lines))
+	}
+
+	// Keys follow the column order
+	if !strings.HasPrefix(lines[15], `{"cpsr":"-ZC-","pc":32780,`) {
+		t.Errorf("unexpected key order: %s", lines[15])
+	}
+	var entry struct {
+		Opcode uint32            `json:"opcode"`
+		Regs   map[string]uint32 `json:"regs"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &entry); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if entry.Opcode != 0xE0800001 || entry.Regs["R0"] != 5 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestExecutionTrace_TextColumns(t *testing.T) {
+	lines := runCountdownTrace(t, func(trace *vm.ExecutionTrace) error {
+		return trace.SetColumns("mnemonic,opcode")
+	})
+	if lines[0] != "MOV R0, #0 | 0xE3A00000" {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+}
+
+func TestExecutionTrace_InvalidOptions(t *testing.T) {
+	trace := vm.NewExecutionTrace(nil)
+	if err := trace.SetFormat("xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if err := trace.SetColumns("pc,flags"); err == nil || !strings.Contains(err.Error(), `"flags"`) {
+		t.Errorf("expected an error naming the unknown column, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package vm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// countdownProgram sums 5+4+3+2+1 into R0 in a loop, then exits
+var countdownProgram = []uint32{
+	0xE3A00000, // MOV R0, #0
+	0xE3A01005, // MOV R1, #5
+	0xE0800001, // loop: ADD R0, R0, R1
+	0xE2511001, // SUBS R1, R1, #1
+	0x1AFFFFFC, // BNE loop
+	0xEF000000, // SWI #0
+}
+
+var countdownSymbols = map[string]uint32{"main": 0x8000, "loop": 0x8008}
+
+func loadCountdown(t *testing.T, program []uint32) *vm.VM {
+	t.Helper()
+	v := vm.NewVM()
+	v.CPU.PC = 0x8000
+	setupCodeWrite(v)
+	for i, op := range program {
+		v.Memory.WriteWord(0x8000+uint32(i*4), op) // #nosec G115 -- small test offset
+	}
+	return v
+}
+
+// recordCountdownTrace runs the program with an execution trace and returns the text written
+func recordCountdownTrace(t *testing.T) string {
+	t.Helper()
+	v := loadCountdown(t, countdownProgram)
+	var buf bytes.Buffer
+	v.ExecutionTrace = vm.NewExecutionTrace(&buf)
+	v.ExecutionTrace.LoadSymbols(countdownSymbols)
+	v.ExecutionTrace.Start()
+
+	if err := v.Run(); v.State != vm.StateHalted {
+		t.Fatalf("run failed: %v", err)
+	}
+	if err := v.ExecutionTrace.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExecutionTrace_RecordedByExecutor(t *testing.T) {
+	text := recordCountdownTrace(t)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	// 2 setup instructions and 5 loop iterations of 3 instructions. The final BNE fails
+	// its condition and the exiting SWI never completes, so neither is recorded.
+	if len(lines) != 16 {
+		t.Fatalf("expected 16 trace lines, got %d:\n%s", len(lines), text)
+	}
+	if !strings.Contains(lines[4], "loop+8") || !strings.Contains(lines[4], "BNE loop") {
+		t.Errorf("expected symbolic address and disassembly, got %q", lines[4])
+	}
+}
+
+func TestParseExecutionTrace(t *testing.T) {
+	entries, err := vm.ParseExecutionTrace(strings.NewReader(recordCountdownTrace(t)), countdownSymbols)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(entries) != 16 {
+		t.Fatalf("expected 16 entries, got %d", len(entries))
+	}
+
+	add := entries[2]
+	if add.Address != 0x8008 || add.Disassembly != "ADD R0, R0, R1" || add.RegisterChanges["R0"] != 5 {
+		t.Errorf("unexpected ADD entry: %+v", add)
+	}
+	subs := entries[15]
+	if subs.Address != 0x800C || !subs.Flags.Z || !subs.Flags.C || subs.RegisterChanges["R1"] != 0 {
+		t.Errorf("expected final SUBS to clear R1 and set Z and C: %+v", subs)
+	}
+
+	if _, err := vm.ParseExecutionTrace(strings.NewReader("[000001] nowhere+4 : NOP | (no changes)\n"), countdownSymbols); err == nil {
+		t.Error("expected an error for an unknown symbol")
+	}
+}
+
+func TestTraceReplay_MatchesRecording(t *testing.T) {
+	entries, err := vm.ParseExecutionTrace(strings.NewReader(recordCountdownTrace(t)), countdownSymbols)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	replay := vm.NewTraceReplay(entries)
+	divergence, err := replay.Run(loadCountdown(t, countdownProgram))
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if divergence != nil {
+		t.Fatalf("unexpected divergence: %s", divergence)
+	}
+	if replay.Matched != len(entries) {
+		t.Errorf("expected %d matched entries, got %d", len(entries), replay.Matched)
+	}
+}
+
+func TestTraceReplay_DetectsModifiedProgram(t *testing.T) {
+	entries, err := vm.ParseExecutionTrace(strings.NewReader(recordCountdownTrace(t)), countdownSymbols)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	modified := append([]uint32(nil), countdownProgram...)
+	modified[1] = 0xE3A01004 // MOV R1, #4
+
+	divergence, err := vm.NewTraceReplay(entries).Run(loadCountdown(t, modified))
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if divergence == nil {
+		t.Fatal("expected a divergence")
+	}
+	if divergence.Index != 1 || divergence.Address != 0x8004 {
+		t.Errorf("expected divergence at entry 1 (0x8004), got %s", divergence)
+	}
+	if !strings.Contains(divergence.Reason, "R1: expected 0x00000005, got 0x00000004") {
+		t.Errorf("unexpected reason: %s", divergence.Reason)
+	}
+}
+
+func TestTraceReplay_DetectsShorterRun(t *testing.T) {
+	entries, err := vm.ParseExecutionTrace(strings.NewReader(recordCountdownTrace(t)), countdownSymbols)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	// Exit straight after setup
+	modified := append([]uint32(nil), countdownProgram...)
+	modified[2] = 0xEF000000 // SWI #0
+
+	divergence, err := vm.NewTraceReplay(entries).Run(loadCountdown(t, modified))
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if divergence == nil || divergence.Index != 2 || !strings.Contains(divergence.Reason, "program stopped after 2 instructions") {
+		t.Errorf("expected the replay to stop early at entry 2, got %v", divergence)
+	}
+}
@@ -0,0 +1,201 @@
+package vm_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lookbusy1344/arm-emulator/vm"
+)
+
+// newUninitVM creates a VM checking for uninitialized reads in mode with opcodes at
+// 0x8000, returning it and the buffer its warnings go to
+func newUninitVM(t *testing.T, mode vm.UninitCheck, opcodes ...uint32) (*vm.VM, *bytes.Buffer) {
+	t.Helper()
+	v := vm.NewVM()
+	setupCodeWrite(v)
+	for i, opcode := range opcodes {
+		if err := v.Memory.WriteWord(0x8000+uint32(i)*4, opcode); err != nil {
+			t.Fatalf("failed to write opcode: %v", err)
+		}
+	}
+	if err := v.InitializeStack(vm.StackSegmentStart + vm.StackSegmentSize - 16); err != nil {
+		t.Fatalf("InitializeStack failed: %v", err)
+	}
+	var warnings bytes.Buffer
+	v.UninitCheck = mode
+	v.UninitWriter = &warnings
+	v.CPU.PC = 0x8000
+	v.State = vm.StateRunning
+	return v, &warnings
+}
+
+// stepN executes n instructions, failing the test on an error
+func stepN(t *testing.T, v *vm.VM, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := v.Step(); err != nil {
+			t.Fatalf("step %d failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestUninit_WarnsOnReadBeforeWrite(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitWarn,
+		0xE2850001, // ADD R0, R5, #1
+	)
+	stepN(t, v, 1)
+
+	want := "Warning: R5 read before being written at PC=0x00008000\n"
+	if warnings.String() != want {
+		t.Errorf("expected %q, got %q", want, warnings.String())
+	}
+	if v.CPU.R[0] != 1 {
+		t.Errorf("warn mode should still execute the instruction, R0 = %d", v.CPU.R[0])
+	}
+}
+
+func TestUninit_WriteThenReadIsClean(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitWarn,
+		0xE3A05000, // MOV R5, #0 (the value R5 already holds)
+		0xE2850001, // ADD R0, R5, #1
+		0xE1A01000, // MOV R1, R0
+		0xE58D1000, // STR R1, [SP]
+		0xE59D2000, // LDR R2, [SP]
+		0xE0823001, // ADD R3, R2, R1
+	)
+	stepN(t, v, 6)
+
+	if warnings.Len() != 0 {
+		t.Errorf("expected no warnings, got %q", warnings.String())
+	}
+}
+
+func TestUninit_SourceOperands(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint32
+		reg    string
+	}{
+		{"second operand", 0xE080000C, "R12"},  // ADD R0, R0, R12 (R0 is written first)
+		{"shift register", 0xE1A00716, "R7"},   // MOV R0, R6, LSL R7 (R6 is written first)
+		{"stored value", 0xE58D8000, "R8"},     // STR R8, [SP]
+		{"compare", 0xE3590000, "R9"},          // CMP R9, #0
+		{"multiply", 0xE0000A91, "R10"},        // MUL R0, R1, R10 (R1 is written first)
+		{"branch exchange", 0xE12FFF1B, "R11"}, // BX R11
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, warnings := newUninitVM(t, vm.UninitWarn,
+				0xE3A00001, // MOV R0, #1
+				0xE3A01001, // MOV R1, #1
+				0xE3A06001, // MOV R6, #1
+				tt.opcode,
+			)
+			stepN(t, v, 4)
+
+			want := "Warning: " + tt.reg + " read before being written at PC=0x0000800C\n"
+			if warnings.String() != want {
+				t.Errorf("expected %q, got %q", want, warnings.String())
+			}
+		})
+	}
+}
+
+func TestUninit_IgnoresSavesAndZeroingIdioms(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitWarn,
+		0xE1A00000, // NOP (MOV R0, R0)
+		0xE92D4010, // STMFD SP!, {R4, LR}
+		0xE0266006, // EOR R6, R6, R6
+		0xE0477007, // SUB R7, R7, R7
+		0xE0868007, // ADD R8, R6, R7
+		0xE8BD4010, // LDMFD SP!, {R4, LR}
+		0xE1A00004, // MOV R0, R4
+	)
+	stepN(t, v, 7)
+
+	if warnings.Len() != 0 {
+		t.Errorf("expected no warnings, got %q", warnings.String())
+	}
+}
+
+func TestUninit_WarnsOncePerInstruction(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitWarn,
+		0xE2800001, // loop: ADD R0, R0, #1
+		0xEAFFFFFD, // B loop
+	)
+	stepN(t, v, 6)
+
+	if count := strings.Count(warnings.String(), "Warning:"); count != 1 {
+		t.Errorf("expected one warning for the loop, got %d: %q", count, warnings.String())
+	}
+}
+
+func TestUninit_HaltMode(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitHalt,
+		0xE3A00001, // MOV R0, #1
+		0xE2850001, // ADD R0, R5, #1
+	)
+	stepN(t, v, 1)
+	err := v.Step()
+
+	var uninit *vm.UninitializedReadError
+	if !errors.As(err, &uninit) {
+		t.Fatalf("expected an UninitializedReadError, got %v", err)
+	}
+	if uninit.Register != 5 || uninit.PC != 0x8004 {
+		t.Errorf("expected R5 at 0x8004, got R%d at 0x%08X", uninit.Register, uninit.PC)
+	}
+	if err.Error() != "uninitialized read of R5 at PC=0x00008004" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if v.State != vm.StateError || v.CPU.PC != 0x8004 || v.CPU.R[0] != 1 {
+		t.Errorf("expected to stop before executing, got state %v PC 0x%08X R0 %d", v.State, v.CPU.PC, v.CPU.R[0])
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("halt mode should not also warn, got %q", warnings.String())
+	}
+}
+
+func TestUninit_ResetForgetsWrites(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitWarn,
+		0xE3A05001, // MOV R5, #1
+		0xE2850001, // ADD R0, R5, #1
+	)
+	v.EntryPoint = 0x8000
+	stepN(t, v, 2)
+	if warnings.Len() != 0 {
+		t.Fatalf("expected no warnings before the reset, got %q", warnings.String())
+	}
+
+	if err := v.ResetRegisters(); err != nil {
+		t.Fatalf("ResetRegisters failed: %v", err)
+	}
+	v.CPU.PC = 0x8004
+	v.State = vm.StateRunning
+	stepN(t, v, 1)
+	if !strings.Contains(warnings.String(), "R5 read before being written") {
+		t.Errorf("expected a warning after the reset, got %q", warnings.String())
+	}
+}
+
+func TestUninit_OffByDefault(t *testing.T) {
+	v, warnings := newUninitVM(t, vm.UninitOff, 0xE2850001) // ADD R0, R5, #1
+	stepN(t, v, 1)
+	if warnings.Len() != 0 {
+		t.Errorf("expected no checking by default, got %q", warnings.String())
+	}
+}
+
+func TestParseUninitCheck(t *testing.T) {
+	for input, want := range map[string]vm.UninitCheck{"": vm.UninitOff, "off": vm.UninitOff, "warn": vm.UninitWarn, "halt": vm.UninitHalt} {
+		got, err := vm.ParseUninitCheck(input)
+		if err != nil || got != want {
+			t.Errorf("ParseUninitCheck(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := vm.ParseUninitCheck("loud"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
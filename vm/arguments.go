@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// argvBlock returns the address of ProgramArguments marshalled into guest memory, building
+// it on first use. The block is reused by later calls until the program frees it or the
+// heap is reset.
+func (vm *VM) argvBlock() (uint32, error) {
+	if alloc, ok := vm.Memory.HeapAllocations[vm.argvAddress]; ok && alloc.System {
+		return vm.argvAddress, nil
+	}
+	addr, err := vm.marshalStrings(vm.ProgramArguments)
+	if err != nil {
+		return 0, err
+	}
+	vm.argvAddress = addr
+	return addr, nil
+}
+
+// marshalStrings copies strs into a single heap block laid out like a C argv: an array of
+// len(strs)+1 word pointers ending in NULL, followed by the null-terminated strings it
+// points to. It returns the address of the pointer array.
+func (vm *VM) marshalStrings(strs []string) (uint32, error) {
+	tableSize := uint64(len(strs)+1) * PointerSize
+	size := tableSize
+	for _, s := range strs {
+		size += uint64(len(s)) + 1
+	}
+	if size > HeapSegmentSize {
+		return 0, fmt.Errorf("%d bytes of strings do not fit in the heap", size)
+	}
+
+	addr, err := vm.allocateHeap(uint32(size)) // #nosec G115 -- size is bounded by HeapSegmentSize
+	if err != nil {
+		return 0, err
+	}
+	vm.Memory.HeapAllocations[addr].System = true
+
+	next := addr + uint32(tableSize) // #nosec G115 -- tableSize <= size, bounded above
+	for i, s := range strs {
+		if err := vm.Memory.WriteWord(addr+uint32(i)*PointerSize, next); err != nil { // #nosec G115 -- i < len(strs), bounded above
+			return 0, err
+		}
+		for j := 0; j < len(s); j++ {
+			if err := vm.Memory.WriteByteAt(next, s[j]); err != nil {
+				return 0, err
+			}
+			next++
+		}
+		// The block was zeroed by Allocate, so the terminators and the NULL entry are in place
+		next++
+	}
+	return addr, nil
+}
+
+// ValidateEnvironment checks that each entry has the form KEY=VALUE with a non-empty key
+func ValidateEnvironment(vars []string) error {
+	for _, v := range vars {
+		key, _, found := strings.Cut(v, "=")
+		if !found || key == "" {
+			return fmt.Errorf("invalid environment variable %q (expected KEY=VALUE)", v)
+		}
+	}
+	return nil
+}
+
+// SetEnvironment sets the KEY=VALUE variables returned by SWI_GET_ENVIRONMENT. Only these
+// are visible to the program; the host environment is never exposed.
+func (vm *VM) SetEnvironment(vars []string) error {
+	if err := ValidateEnvironment(vars); err != nil {
+		return err
+	}
+	vm.Environment = vars
+	vm.envpAddress = 0 // Marshal the new variables on the next SWI_GET_ENVIRONMENT
+	return nil
+}
+
+// envpBlock returns the address of Environment marshalled into guest memory, building it
+// on first use like argvBlock
+func (vm *VM) envpBlock() (uint32, error) {
+	if alloc, ok := vm.Memory.HeapAllocations[vm.envpAddress]; ok && alloc.System {
+		return vm.envpAddress, nil
+	}
+	addr, err := vm.marshalStrings(vm.Environment)
+	if err != nil {
+		return 0, err
+	}
+	vm.envpAddress = addr
+	return addr, nil
+}
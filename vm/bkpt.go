@@ -0,0 +1,28 @@
+package vm
+
+import "fmt"
+
+// BKPTTrap records a BKPT instruction taken during execution
+type BKPTTrap struct {
+	Address   uint32 // Address of the BKPT instruction
+	Immediate uint16 // 16-bit comment field
+}
+
+func (t *BKPTTrap) String() string {
+	return fmt.Sprintf("BKPT #0x%X at PC=0x%08X", t.Immediate, t.Address)
+}
+
+// BKPTImmediate extracts the 16-bit immediate from a BKPT opcode (split across bits 19-8 and 3-0)
+func BKPTImmediate(opcode uint32) uint16 {
+	return uint16((((opcode >> 8) & Mask12Bit) << 4) | (opcode & Mask4Bit)) // #nosec G115 -- 16-bit field
+}
+
+// ExecuteBKPT executes a BKPT instruction
+// The VM stops in StateBreakpoint with the trap recorded in LastBKPT. PC is advanced past
+// the BKPT so that resuming execution continues with the next instruction.
+func ExecuteBKPT(vm *VM, inst *Instruction) error {
+	vm.LastBKPT = &BKPTTrap{Address: inst.Address, Immediate: BKPTImmediate(inst.Opcode)}
+	vm.State = StateBreakpoint
+	vm.CPU.IncrementPC()
+	return nil
+}
@@ -1,6 +1,6 @@
 package vm
 
-import ()
+import "fmt"
 
 // ExecuteBranch executes branch instructions (B, BL, BX, BLX)
 func ExecuteBranch(vm *VM, inst *Instruction) error {
@@ -42,11 +42,12 @@ func ExecuteBranch(vm *VM, inst *Instruction) error {
 // This is primarily for ARM/Thumb interworking, but in ARM2 we just branch
 func ExecuteBranchExchange(vm *VM, inst *Instruction) error {
 	rm := int(inst.Opcode & Mask4Bit) // Register containing target address
-	targetAddr := vm.CPU.GetRegister(rm)
+	targetAddr, err := exchangeTarget(vm, "BX", rm)
+	if err != nil {
+		return err
+	}
 
-	// In a full ARM implementation, bit 0 would indicate Thumb mode
-	// For ARM2 emulation, we just branch to the address (clearing bit 0)
-	vm.CPU.Branch(targetAddr & ThumbModeClearMask)
+	vm.CPU.Branch(targetAddr)
 
 	return nil
 }
@@ -54,10 +55,25 @@ func ExecuteBranchExchange(vm *VM, inst *Instruction) error {
 // ExecuteBranchLinkExchange executes BLX register form (branch with link and exchange)
 func ExecuteBranchLinkExchange(vm *VM, inst *Instruction) error {
 	rm := int(inst.Opcode & Mask4Bit) // Register containing target address
-	targetAddr := vm.CPU.GetRegister(rm)
+	targetAddr, err := exchangeTarget(vm, "BLX", rm)
+	if err != nil {
+		return err
+	}
 
 	// Save return address and branch
-	vm.CPU.BranchWithLink(targetAddr & ThumbModeClearMask)
+	vm.CPU.BranchWithLink(targetAddr)
 
 	return nil
 }
+
+// exchangeTarget returns the ARM-state branch target held in register rm.
+// Bit 0 set would switch to Thumb state, which is not emulated, so it is an error
+// rather than a silent branch into the middle of an ARM instruction.
+func exchangeTarget(vm *VM, mnemonic string, rm int) (uint32, error) {
+	targetAddr := vm.CPU.GetRegister(rm)
+	if targetAddr&ThumbModeBit != 0 {
+		return 0, fmt.Errorf("%s R%d to 0x%08X: Thumb not supported (bit 0 of the target selects Thumb state)",
+			mnemonic, rm, targetAddr)
+	}
+	return targetAddr, nil
+}
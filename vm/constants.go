@@ -19,6 +19,7 @@ const (
 	CPSRBitZ = 30 // Zero flag
 	CPSRBitC = 29 // Carry flag
 	CPSRBitV = 28 // Overflow flag
+	CPSRBitQ = 27 // Sticky saturation flag (set by QADD/QSUB)
 
 	// Sign bit for overflow calculations
 	SignBitPos  = 31         // Position of sign bit in 32-bit word
@@ -45,6 +46,9 @@ const (
 	AlignmentHalfword = 2 // 2-byte halfword alignment
 	AlignmentByte     = 1 // no alignment required
 
+	// PointerSize is the size in bytes of a guest pointer, e.g. an argv entry
+	PointerSize = 4
+
 	// Computed alignment masks
 	AlignMaskWord        = AlignmentWord - 1      // mask for word alignment check (address & mask == 0 means aligned)
 	AlignMaskHalfword    = AlignmentHalfword - 1  // mask for halfword alignment check
@@ -126,13 +130,15 @@ const (
 
 const (
 	// PC offset adjustments
-	PCStoreOffset = 12 // PC+12 when storing PC in STM
-	PCBranchBase  = 8  // PC+8 base for branch calculations
+	PCStoreOffset         = 12 // PC+12 when storing PC with STR or STM
+	PCBranchBase          = 8  // PC+8 base for branch calculations
+	PCRegisterShiftOffset = 12 // PC+12 when read as Rn/Rm by a data-processing op with a register shift
 
 	// Bit shift for word-to-byte offset conversion
 	WordToByteShift = 2 // Shift left by 2 to convert word offset to byte offset
 
-	// Thumb mode bit (for BX instruction, though not fully supported in ARM2)
+	// Thumb mode bit (for BX/BLX; only ARM state is emulated, so a set bit is an error)
+	ThumbModeBit       = 0x00000001 // Bit 0 of a BX/BLX target selects Thumb state
 	ThumbModeClearMask = 0xFFFFFFFE // Mask to clear bit 0 (Thumb mode indicator)
 
 	// Rotate constants
@@ -178,6 +184,24 @@ const (
 	LongMultiplyPattern = 0x00800090 // UMULL/UMLAL/SMULL/SMLAL pattern
 	LongMultiplyMask    = 0x0F8000F0 // Mask to detect long multiply instructions
 
+	// Saturating arithmetic instruction patterns
+	SaturatingMask = 0x0FF000F0 // Mask to detect QADD/QSUB
+	QADDPattern    = 0x01000050 // QADD: cccc 0001 0000 nnnn dddd 0000 0101 mmmm
+	QSUBPattern    = 0x01200050 // QSUB: cccc 0001 0010 nnnn dddd 0000 0101 mmmm
+
+	// Division instruction patterns (ARMv7 extension)
+	DivideMask  = 0x0FF0F0F0 // Mask to detect SDIV/UDIV
+	SDIVPattern = 0x0710F010 // SDIV: cccc 0111 0001 dddd 1111 mmmm 0001 nnnn
+	UDIVPattern = 0x0730F010 // UDIV: cccc 0111 0011 dddd 1111 mmmm 0001 nnnn
+
+	// PC-relative literal load: LDR Rd, [PC, #±offset] (word, pre-indexed, no write-back)
+	LDRLiteralMask    = 0x0F7F0000 // Mask to detect a literal load (ignores U)
+	LDRLiteralPattern = 0x051F0000 // cccc 0101 U001 1111 dddd oooo oooo oooo
+
+	// Software breakpoint instruction pattern
+	BKPTMask    = 0x0FF000F0 // Mask to detect BKPT
+	BKPTPattern = 0x01200070 // BKPT: cccc 0001 0010 iiii iiii iiii 0111 iiii
+
 	// PSR transfer instruction patterns
 	MRSPattern    = 0x010F0000 // MRS instruction pattern
 	MRSMask       = 0x0FBF0FFF // Mask to detect MRS instruction
@@ -185,6 +209,10 @@ const (
 	MSRRegMask    = 0x0FB000F0 // Mask to detect MSR register
 	MSRImmPattern = 0x03200000 // MSR immediate form pattern
 	MSRImmMask    = 0x0FB00000 // Mask to detect MSR immediate
+	PSRSPSRBit    = 22         // Bit 22: SPSR rather than CPSR
+	PSRFieldShift = 16         // Bits 19-16: MSR field mask (f, s, x, c from high to low)
+	PSRFieldFlags = 0x8        // Field mask bit f: the condition flags, bits 31-24
+	PSRFieldSBO   = 0xF000     // Bits 15-12: should-be-one in MRS and MSR
 
 	// Branch detection patterns
 	BranchBitMask     = 0x02000000 // Bit 25 set indicates branch in bits27-26=10 case
@@ -212,6 +240,18 @@ const (
 	StackSegmentSize  = 0x00010000 // 64KB - stack segment size
 )
 
+// ============================================================================
+// Memory-Mapped I/O Constants
+// ============================================================================
+
+const (
+	UARTBaseAddress   = 0x00100000 // 1MB - UART register block
+	UARTRegionSize    = 0x00000010 // 16 bytes - UART register block size
+	UARTDataOffset    = 0x00000000 // Data register: write transmits low byte
+	UARTStatusOffset  = 0x00000004 // Status register (read-only)
+	UARTStatusTxReady = 0x00000001 // Status bit 0: transmitter ready
+)
+
 // ============================================================================
 // VM Execution Limits
 // ============================================================================
@@ -220,6 +260,14 @@ const (
 	DefaultMaxCycles   = 1000000 // Default instruction limit
 	DefaultLogCapacity = 1000    // Initial capacity for instruction log
 	DefaultFDTableSize = 3       // Initial FD table size (FDs 0-2: stdin, stdout, stderr)
+
+	// DefaultStackGuard is whether the command line, loader.RunProgram and the GUI service
+	// halt when SP leaves the stack segment
+	DefaultStackGuard = true
+
+	// ContextCheckInterval is how many instructions ContextStep runs between checks of its
+	// context, keeping the check off the per-instruction path
+	ContextCheckInterval = 1024
 )
 
 // ============================================================================
@@ -280,6 +328,7 @@ const (
 	DefaultStringBuffer = 256         // Default buffer for READ_STRING
 	MaxMemoryDump       = 1024        // 1KB limit for memory dumps
 	MaxStdinInputSize   = 4096        // 4KB maximum stdin input per read (DoS protection)
+	DateTimeStructSize  = 7 * 4       // GET_DATETIME: year, month, day, hour, minute, second, weekday words
 )
 
 // Note: Number bases (2, 8, 10, 16) are used directly as literals - they are self-documenting
@@ -298,4 +347,31 @@ const (
 
 	// CompactTopItemsCount is the number of top items to show in compact statistics views
 	CompactTopItemsCount = 10
+
+	// MaxProfilerCallDepth bounds the profiler's shadow call stack; calls beyond it are still
+	// counted but their cycles are attributed to the deepest tracked frame
+	MaxProfilerCallDepth = 4096
+
+	// DefaultHistoryCapacity is the number of steps StepBack can undo when no capacity is given
+	DefaultHistoryCapacity = 1000
+
+	// DefaultTraceMaxEntries is the number of instructions an execution trace records
+	// before it stops; a replay treats a trace of exactly this length as truncated
+	DefaultTraceMaxEntries = 100000
+
+	// RegisterDiffDecimalLimit is the value below which register diffs show decimal rather than hex
+	RegisterDiffDecimalLimit = 0x1000
+)
+
+// State Snapshot Constants
+const (
+	// SnapshotVersion is the format version written by ExportState
+	SnapshotVersion = 2
+
+	// SnapshotZeroRunBytes is the shortest run of zero bytes that splits a segment into
+	// separate chunks. Untouched memory is zero, so skipping long runs keeps snapshots small.
+	SnapshotZeroRunBytes = 32
+
+	// SnapshotMaxAddedMemory caps the total size of segments ImportState may create
+	SnapshotMaxAddedMemory = 0x00100000 // 1MB
 )
@@ -16,19 +16,37 @@ type CoverageEntry struct {
 	LastExecution  uint64 // Cycle number of last execution
 }
 
+// ConditionCoverage counts how often a conditional instruction's condition passed or failed
+type ConditionCoverage struct {
+	Address    uint32 // Instruction address
+	TrueCount  uint64 // Times the condition passed and the instruction executed
+	FalseCount uint64 // Times the condition failed and the instruction was skipped
+}
+
+// UntestedLine is an instruction in the code range that never executed
+type UntestedLine struct {
+	Address uint32 `json:"address"`
+	Symbol  string `json:"symbol,omitempty"` // Label at the address, if any
+	Source  string `json:"source,omitempty"` // Source location and text, if loaded
+}
+
 // CodeCoverage tracks which instructions have been executed
 type CodeCoverage struct {
 	Enabled bool
 	Writer  io.Writer
 
 	// Coverage data
-	executed  map[uint32]*CoverageEntry // address -> execution info
-	codeStart uint32                    // Start of code segment
-	codeEnd   uint32                    // End of code segment
+	executed   map[uint32]*CoverageEntry     // address -> execution info
+	conditions map[uint32]*ConditionCoverage // address -> condition outcomes (non-AL only)
+	codeStart  uint32                        // Start of code segment
+	codeEnd    uint32                        // End of code segment
+	codeAddrs  []uint32                      // Sorted instruction addresses, if set (overrides the range)
+	isCode     map[uint32]bool               // Membership set for codeAddrs
 
 	// Symbol information (optional)
 	symbols         map[string]uint32 // label -> address
 	addressToSymbol map[uint32]string // address -> label
+	sourceLines     map[uint32]string // address -> source location and text
 }
 
 // NewCodeCoverage creates a new code coverage tracker
@@ -37,8 +55,10 @@ func NewCodeCoverage(writer io.Writer) *CodeCoverage {
 		Enabled:         true,
 		Writer:          writer,
 		executed:        make(map[uint32]*CoverageEntry),
+		conditions:      make(map[uint32]*ConditionCoverage),
 		symbols:         make(map[string]uint32),
 		addressToSymbol: make(map[uint32]string),
+		sourceLines:     make(map[uint32]string),
 	}
 }
 
@@ -48,6 +68,22 @@ func (c *CodeCoverage) SetCodeRange(start, end uint32) {
 	c.codeEnd = end
 }
 
+// SetCodeAddresses sets the instruction addresses coverage is measured against, so data
+// and gaps between .org blocks inside the code range are not counted as unexecuted code
+func (c *CodeCoverage) SetCodeAddresses(addresses []uint32) {
+	c.codeAddrs = make([]uint32, 0, len(addresses))
+	c.isCode = make(map[uint32]bool, len(addresses))
+	for _, addr := range addresses {
+		if !c.isCode[addr] {
+			c.isCode[addr] = true
+			c.codeAddrs = append(c.codeAddrs, addr)
+		}
+	}
+	sort.Slice(c.codeAddrs, func(i, j int) bool {
+		return c.codeAddrs[i] < c.codeAddrs[j]
+	})
+}
+
 // LoadSymbols loads symbol information for better reporting
 func (c *CodeCoverage) LoadSymbols(symbols map[string]uint32) {
 	c.symbols = symbols
@@ -57,9 +93,51 @@ func (c *CodeCoverage) LoadSymbols(symbols map[string]uint32) {
 	}
 }
 
+// LoadSourceLines loads the source line of each instruction, reported against the
+// instructions that never executed
+func (c *CodeCoverage) LoadSourceLines(lines map[uint32]string) {
+	c.sourceLines = lines
+}
+
 // Start starts coverage tracking
 func (c *CodeCoverage) Start() {
 	c.executed = make(map[uint32]*CoverageEntry)
+	c.conditions = make(map[uint32]*ConditionCoverage)
+}
+
+// inRange reports whether address is inside the code range (always true if no range is set)
+func (c *CodeCoverage) inRange(address uint32) bool {
+	if c.codeStart == 0 && c.codeEnd == 0 {
+		return true
+	}
+	return address >= c.codeStart && address < c.codeEnd
+}
+
+// hasCode reports whether a code range or instruction address set has been configured
+func (c *CodeCoverage) hasCode() bool {
+	return c.isCode != nil || c.codeStart != 0 || c.codeEnd != 0
+}
+
+// totalInstructions returns the number of instructions coverage is measured against
+func (c *CodeCoverage) totalInstructions() int {
+	if c.isCode != nil {
+		return len(c.codeAddrs)
+	}
+	return int((c.codeEnd - c.codeStart) / 4)
+}
+
+// executedCount returns how many of the measured instructions executed
+func (c *CodeCoverage) executedCount() int {
+	if c.isCode == nil {
+		return len(c.executed)
+	}
+	count := 0
+	for addr := range c.executed {
+		if c.isCode[addr] {
+			count++
+		}
+	}
+	return count
 }
 
 // RecordExecution records that an instruction was executed
@@ -69,10 +147,8 @@ func (c *CodeCoverage) RecordExecution(address uint32, cycle uint64) {
 	}
 
 	// Only track if address is in code range (if range is set)
-	if c.codeStart != 0 || c.codeEnd != 0 {
-		if address < c.codeStart || address >= c.codeEnd {
-			return
-		}
+	if !c.inRange(address) {
+		return
 	}
 
 	if entry, exists := c.executed[address]; exists {
@@ -88,20 +164,36 @@ func (c *CodeCoverage) RecordExecution(address uint32, cycle uint64) {
 	}
 }
 
+// RecordCondition records whether a conditional instruction's condition passed
+func (c *CodeCoverage) RecordCondition(address uint32, passed bool) {
+	if !c.Enabled || !c.inRange(address) {
+		return
+	}
+
+	entry, exists := c.conditions[address]
+	if !exists {
+		entry = &ConditionCoverage{Address: address}
+		c.conditions[address] = entry
+	}
+	if passed {
+		entry.TrueCount++
+	} else {
+		entry.FalseCount++
+	}
+}
+
 // GetCoverage returns the coverage percentage
 func (c *CodeCoverage) GetCoverage() float64 {
-	if c.codeStart == 0 && c.codeEnd == 0 {
+	if !c.hasCode() {
 		return 0.0
 	}
 
-	totalInstructions := (c.codeEnd - c.codeStart) / 4
+	totalInstructions := c.totalInstructions()
 	if totalInstructions == 0 {
 		return 0.0
 	}
 
-	// Safe conversion: map size is bounded by memory constraints
-	executedCount := uint32(len(c.executed)) // #nosec G115 -- map size limited by available memory
-	return float64(executedCount) / float64(totalInstructions) * 100.0
+	return float64(c.executedCount()) / float64(totalInstructions) * 100.0
 }
 
 // GetExecutedAddresses returns all executed addresses sorted
@@ -118,11 +210,19 @@ func (c *CodeCoverage) GetExecutedAddresses() []uint32 {
 
 // GetUnexecutedAddresses returns addresses in code range that were not executed
 func (c *CodeCoverage) GetUnexecutedAddresses() []uint32 {
-	if c.codeStart == 0 && c.codeEnd == 0 {
+	if !c.hasCode() {
 		return nil
 	}
 
 	unexecuted := make([]uint32, 0)
+	if c.isCode != nil {
+		for _, addr := range c.codeAddrs {
+			if _, exists := c.executed[addr]; !exists {
+				unexecuted = append(unexecuted, addr)
+			}
+		}
+		return unexecuted
+	}
 	for addr := c.codeStart; addr < c.codeEnd; addr += 4 {
 		if _, exists := c.executed[addr]; !exists {
 			unexecuted = append(unexecuted, addr)
@@ -131,11 +231,41 @@ func (c *CodeCoverage) GetUnexecutedAddresses() []uint32 {
 	return unexecuted
 }
 
+// GetUntestedLines returns the unexecuted instructions with their labels and source lines
+func (c *CodeCoverage) GetUntestedLines() []UntestedLine {
+	unexecuted := c.GetUnexecutedAddresses()
+	lines := make([]UntestedLine, len(unexecuted))
+	for i, addr := range unexecuted {
+		lines[i] = UntestedLine{Address: addr, Symbol: c.addressToSymbol[addr], Source: c.sourceLines[addr]}
+	}
+	return lines
+}
+
 // GetEntry returns coverage entry for an address
 func (c *CodeCoverage) GetEntry(address uint32) *CoverageEntry {
 	return c.executed[address]
 }
 
+// GetConditionEntry returns the condition outcomes for a conditional instruction
+func (c *CodeCoverage) GetConditionEntry(address uint32) *ConditionCoverage {
+	return c.conditions[address]
+}
+
+// GetPartialConditions returns conditional instructions whose condition only ever
+// passed or only ever failed, sorted by address
+func (c *CodeCoverage) GetPartialConditions() []*ConditionCoverage {
+	partial := make([]*ConditionCoverage, 0)
+	for _, entry := range c.conditions {
+		if entry.TrueCount == 0 || entry.FalseCount == 0 {
+			partial = append(partial, entry)
+		}
+	}
+	sort.Slice(partial, func(i, j int) bool {
+		return partial[i].Address < partial[j].Address
+	})
+	return partial
+}
+
 // Flush writes coverage report to the writer
 func (c *CodeCoverage) Flush() error {
 	if c.Writer == nil {
@@ -146,16 +276,15 @@ func (c *CodeCoverage) Flush() error {
 	header := "Code Coverage Report\n"
 	header += "====================\n\n"
 
-	if c.codeStart != 0 || c.codeEnd != 0 {
-		totalInstructions := (c.codeEnd - c.codeStart) / 4
-		executedCount := len(c.executed)
+	if c.hasCode() {
+		totalInstructions := c.totalInstructions()
+		executedCount := c.executedCount()
 		coverage := c.GetCoverage()
 
 		header += fmt.Sprintf("Code Range:           0x%08X - 0x%08X\n", c.codeStart, c.codeEnd)
 		header += fmt.Sprintf("Total Instructions:   %d\n", totalInstructions)
 		header += fmt.Sprintf("Executed:             %d\n", executedCount)
-		// Safe conversion: executedCount is already bounded by map size
-		header += fmt.Sprintf("Not Executed:         %d\n", totalInstructions-uint32(executedCount)) // #nosec G115 -- executedCount bounded by memory
+		header += fmt.Sprintf("Not Executed:         %d\n", totalInstructions-executedCount)
 		header += fmt.Sprintf("Coverage:             %.2f%%\n\n", coverage)
 	} else {
 		header += fmt.Sprintf("Total Executed:       %d unique addresses\n\n", len(c.executed))
@@ -191,8 +320,7 @@ func (c *CodeCoverage) Flush() error {
 	}
 
 	// Write unexecuted addresses if code range is set
-	unexecuted := c.GetUnexecutedAddresses()
-	if len(unexecuted) > 0 {
+	if len(c.GetUnexecutedAddresses()) > 0 {
 		if _, err := c.Writer.Write([]byte("\nNot Executed:\n")); err != nil {
 			return err
 		}
@@ -200,11 +328,37 @@ func (c *CodeCoverage) Flush() error {
 			return err
 		}
 
-		for _, addr := range unexecuted {
-			line := fmt.Sprintf("0x%08X", addr)
+		for _, untested := range c.GetUntestedLines() {
+			line := fmt.Sprintf("0x%08X", untested.Address)
+
+			// Add symbol and source line if available
+			if untested.Symbol != "" {
+				line += fmt.Sprintf(" [%s]", untested.Symbol)
+			}
+			if untested.Source != "" {
+				line += "  " + untested.Source
+			}
+
+			line += "\n"
+			if _, err := c.Writer.Write([]byte(line)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write conditional instructions that never took one of their two outcomes
+	partial := c.GetPartialConditions()
+	if len(partial) > 0 {
+		if _, err := c.Writer.Write([]byte("\nPartially Covered Conditions:\n")); err != nil {
+			return err
+		}
+		if _, err := c.Writer.Write([]byte("-----------------------------\n")); err != nil {
+			return err
+		}
 
-			// Add symbol if available
-			if symbol, exists := c.addressToSymbol[addr]; exists {
+		for _, entry := range partial {
+			line := fmt.Sprintf("0x%08X: true %6d times, false %6d times", entry.Address, entry.TrueCount, entry.FalseCount)
+			if symbol, exists := c.addressToSymbol[entry.Address]; exists {
 				line += fmt.Sprintf(" [%s]", symbol)
 			}
 
@@ -221,13 +375,16 @@ func (c *CodeCoverage) Flush() error {
 // ExportJSON exports coverage data as JSON
 func (c *CodeCoverage) ExportJSON(w io.Writer) error {
 	data := map[string]interface{}{
-		"code_start":           c.codeStart,
-		"code_end":             c.codeEnd,
-		"coverage_percent":     c.GetCoverage(),
-		"executed_count":       len(c.executed),
-		"unexecuted_count":     len(c.GetUnexecutedAddresses()),
-		"executed_addresses":   c.executed,
-		"unexecuted_addresses": c.GetUnexecutedAddresses(),
+		"code_start":            c.codeStart,
+		"code_end":              c.codeEnd,
+		"coverage_percent":      c.GetCoverage(),
+		"executed_count":        c.executedCount(),
+		"unexecuted_count":      len(c.GetUnexecutedAddresses()),
+		"executed_addresses":    c.executed,
+		"unexecuted_addresses":  c.GetUnexecutedAddresses(),
+		"untested_lines":        c.GetUntestedLines(),
+		"conditional_addresses": c.conditions,
+		"partial_conditions":    len(c.GetPartialConditions()),
 	}
 
 	encoder := json.NewEncoder(w)
@@ -242,16 +399,15 @@ func (c *CodeCoverage) String() string {
 	sb.WriteString("Code Coverage Summary\n")
 	sb.WriteString("=====================\n\n")
 
-	if c.codeStart != 0 || c.codeEnd != 0 {
-		totalInstructions := (c.codeEnd - c.codeStart) / 4
-		executedCount := len(c.executed)
+	if c.hasCode() {
+		totalInstructions := c.totalInstructions()
+		executedCount := c.executedCount()
 		coverage := c.GetCoverage()
 
 		sb.WriteString(fmt.Sprintf("Code Range:         0x%08X - 0x%08X\n", c.codeStart, c.codeEnd))
 		sb.WriteString(fmt.Sprintf("Total Instructions: %d\n", totalInstructions))
 		sb.WriteString(fmt.Sprintf("Executed:           %d\n", executedCount))
-		// Safe conversion: executedCount is already bounded by map size
-		sb.WriteString(fmt.Sprintf("Not Executed:       %d\n", totalInstructions-uint32(executedCount))) // #nosec G115 -- executedCount bounded by memory
+		sb.WriteString(fmt.Sprintf("Not Executed:       %d\n", totalInstructions-executedCount))
 		sb.WriteString(fmt.Sprintf("Coverage:           %.2f%%\n", coverage))
 	} else {
 		sb.WriteString(fmt.Sprintf("Executed:           %d unique addresses\n", len(c.executed)))
@@ -1,7 +1,5 @@
 package vm
 
-import "fmt"
-
 // CPU represents the ARM2 processor state
 type CPU struct {
 	// General purpose registers R0-R14
@@ -19,6 +17,9 @@ type CPU struct {
 
 	// Cycle counter for statistics
 	Cycles uint64
+
+	// Instructions fetched and decoded, including those whose condition failed
+	Instructions uint64
 }
 
 // CPSR represents the Current Program Status Register with condition flags
@@ -27,10 +28,11 @@ type CPSR struct {
 	Z bool // Zero flag (result == 0)
 	C bool // Carry flag (unsigned overflow for arithmetic, last bit shifted out for shifts)
 	V bool // Overflow flag (signed overflow)
+	Q bool // Sticky saturation flag (set by saturating arithmetic, cleared only via MSR)
 }
 
 // ToUint32 converts CPSR flags to a 32-bit value
-// ARM CPSR format: NZCV flags are in bits 31-28
+// ARM CPSR format: NZCV flags are in bits 31-28, Q is bit 27
 func (c *CPSR) ToUint32() uint32 {
 	var result uint32
 	if c.N {
@@ -45,18 +47,22 @@ func (c *CPSR) ToUint32() uint32 {
 	if c.V {
 		result |= 1 << CPSRBitV // V flag in bit 28
 	}
-	// Bits 27-0 are reserved/unused in basic ARM2 CPSR
+	if c.Q {
+		result |= 1 << CPSRBitQ // Q flag in bit 27
+	}
+	// Bits 26-0 are reserved/unused in basic ARM2 CPSR
 	return result
 }
 
 // FromUint32 sets CPSR flags from a 32-bit value
-// ARM CPSR format: NZCV flags are in bits 31-28
+// ARM CPSR format: NZCV flags are in bits 31-28, Q is bit 27
 func (c *CPSR) FromUint32(value uint32) {
 	c.N = (value & (1 << CPSRBitN)) != 0 // N flag in bit 31
 	c.Z = (value & (1 << CPSRBitZ)) != 0 // Z flag in bit 30
 	c.C = (value & (1 << CPSRBitC)) != 0 // C flag in bit 29
 	c.V = (value & (1 << CPSRBitV)) != 0 // V flag in bit 28
-	// Bits 27-0 are ignored (reserved/unused in basic ARM2)
+	c.Q = (value & (1 << CPSRBitQ)) != 0 // Q flag in bit 27
+	// Bits 26-0 are ignored (reserved/unused in basic ARM2)
 }
 
 // Register aliases for convenience
@@ -99,6 +105,7 @@ func (c *CPU) Reset() {
 	c.CPSR = CPSR{}
 	c.SPSR = CPSR{}
 	c.Cycles = 0
+	c.Instructions = 0
 }
 
 // GetSP returns the stack pointer value
@@ -129,7 +136,8 @@ func (c *CPU) SetSPWithTrace(vm *VM, value uint32, pc uint32) error {
 	// Record stack trace if enabled and check for overflow
 	if vm.StackTrace != nil {
 		if vm.StackTrace.RecordSPMove(vm.CPU.Cycles, pc, oldSP, value) {
-			return fmt.Errorf("stack overflow: SP=0x%08X crossed into heap segment (below 0x%08X)", value, vm.StackTrace.StackTop)
+			return newStackError(pc, value, vm.StackTrace.StackTop, true,
+				"SP=0x%08X crossed into heap segment (below 0x%08X)", value, vm.StackTrace.StackTop)
 		}
 	}
 
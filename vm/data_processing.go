@@ -24,6 +24,17 @@ const (
 	OpMVN = 0xF // MVN - Move Not
 )
 
+// readDataProcessingOperand reads Rn or Rm. PC reads as the instruction address + 8,
+// or + 12 when the shift amount comes from a register, since the extra register read
+// delays the operand fetch by a cycle on ARM2.
+func readDataProcessingOperand(vm *VM, inst *Instruction, reg int) uint32 {
+	registerShift := (inst.Opcode>>IBitShift)&Mask1Bit == 0 && (inst.Opcode>>Bit4Pos)&Mask1Bit == 1
+	if reg == ARMRegisterPC && registerShift {
+		return vm.CPU.PC + PCRegisterShiftOffset
+	}
+	return vm.CPU.GetRegister(reg)
+}
+
 // ExecuteDataProcessing executes a data processing instruction
 func ExecuteDataProcessing(vm *VM, inst *Instruction) error {
 	opcode := (inst.Opcode >> OpcodeShift) & Mask4Bit
@@ -34,7 +45,7 @@ func ExecuteDataProcessing(vm *VM, inst *Instruction) error {
 	rn := int((inst.Opcode >> RnShift) & Mask4Bit) // First operand register
 
 	// Get first operand
-	op1 := vm.CPU.GetRegister(rn)
+	op1 := readDataProcessingOperand(vm, inst, rn)
 
 	// Get second operand (either immediate or register with shift)
 	var op2 uint32
@@ -55,7 +66,7 @@ func ExecuteDataProcessing(vm *VM, inst *Instruction) error {
 	} else {
 		// Register with optional shift
 		rm := int(inst.Opcode & Mask4Bit)
-		op2Value := vm.CPU.GetRegister(rm)
+		op2Value := readDataProcessingOperand(vm, inst, rm)
 
 		shiftType := ShiftType((inst.Opcode >> ShiftTypePos) & Mask2Bit)
 		shiftByReg := (inst.Opcode >> Bit4Pos) & Mask1Bit
@@ -110,40 +121,15 @@ func ExecuteDataProcessing(vm *VM, inst *Instruction) error {
 		overflow = CalculateAddOverflow(op1, op2, result)
 
 	case OpADC:
-		carryIn := uint32(0)
-		if vm.CPU.CPSR.C {
-			carryIn = 1
-		}
-		result = op1 + op2 + carryIn
-		// Check if carry occurred from either addition
-		temp := op1 + op2
-		carry = CalculateAddCarry(op1, op2, temp) || CalculateAddCarry(temp, carryIn, result)
-		// Overflow considers all three inputs (op1, op2, and carry)
-		tempOverflow := CalculateAddOverflow(op1, op2, temp)
-		finalOverflow := CalculateAddOverflow(temp, carryIn, result)
-		overflow = tempOverflow || finalOverflow
+		result, carry, overflow = AddWithCarry(op1, op2, vm.CPU.CPSR.C)
 
 	case OpSBC:
-		carryIn := uint32(1)
-		if !vm.CPU.CPSR.C {
-			carryIn = 0
-		}
-		result = op1 - op2 - (1 - carryIn)
-		carry = CalculateSubCarry(op1, op2+1-carryIn)
-		// Overflow considers all inputs including borrow (NOT carry)
-		temp := op1 - op2
-		tempOverflow := CalculateSubOverflow(op1, op2, temp)
-		finalOverflow := CalculateSubOverflow(temp, 1-carryIn, result)
-		overflow = tempOverflow || finalOverflow
+		// op1 - op2 - NOT(C), with C set afterwards if no borrow occurred
+		result, carry, overflow = AddWithCarry(op1, ^op2, vm.CPU.CPSR.C)
 
 	case OpRSC:
-		carryIn := uint32(1)
-		if !vm.CPU.CPSR.C {
-			carryIn = 0
-		}
-		result = op2 - op1 - (1 - carryIn)
-		carry = CalculateSubCarry(op2, op1+1-carryIn)
-		overflow = CalculateSubOverflow(op2, op1+(1-carryIn), result)
+		// op2 - op1 - NOT(C), with C set afterwards if no borrow occurred
+		result, carry, overflow = AddWithCarry(op2, ^op1, vm.CPU.CPSR.C)
 
 	case OpTST:
 		result = op1 & op2
@@ -188,7 +174,7 @@ func ExecuteDataProcessing(vm *VM, inst *Instruction) error {
 		carry = shiftCarry
 
 	default:
-		return fmt.Errorf("unknown data processing opcode: 0x%X", opcode)
+		return &UndefinedInstructionError{Opcode: inst.Opcode, Reason: fmt.Sprintf("unknown data processing opcode 0x%X", opcode)}
 	}
 
 	// Write result to destination register
@@ -0,0 +1,54 @@
+package vm
+
+// The decode cache keeps the decoded form of each instruction word executed, so a hot
+// loop decodes its instructions once. Entries are held per segment, one per word and
+// allocated on the segment's first fetch. Every write to a word drops its entry, which
+// keeps self-modifying code correct.
+
+// cachedInstruction returns the decoded instruction at address, or nil if it has not
+// been decoded since it was last written. A hit is counted as the fetch it replaces.
+func (m *Memory) cachedInstruction(address uint32) *Instruction {
+	if address&(AlignmentWord-1) != 0 {
+		return nil
+	}
+	seg, offset, err := m.findSegment(address)
+	if err != nil || seg.decoded == nil {
+		return nil
+	}
+
+	inst := seg.decoded[offset/AlignmentWord]
+	if inst != nil {
+		m.AccessCount++
+		m.ReadCount++
+		seg.Executes++
+	}
+	return inst
+}
+
+// cacheInstruction records inst, freshly decoded from memory, for reuse at its address
+func (m *Memory) cacheInstruction(inst *Instruction) {
+	seg, offset, err := m.findSegment(inst.Address)
+	if err != nil || seg.Device != nil || inst.Address&(AlignmentWord-1) != 0 {
+		return
+	}
+	if seg.decoded == nil {
+		seg.decoded = make([]*Instruction, (len(seg.Data)+AlignmentWord-1)/AlignmentWord)
+	}
+	seg.decoded[offset/AlignmentWord] = inst
+}
+
+// invalidateDecoded drops the cached instructions overlapping size bytes at offset
+func (seg *MemorySegment) invalidateDecoded(offset, size uint32) {
+	if seg.decoded == nil || size == 0 {
+		return
+	}
+	last := (offset + size - 1) / AlignmentWord
+	for word := offset / AlignmentWord; word <= last && int(word) < len(seg.decoded); word++ {
+		seg.decoded[word] = nil
+	}
+}
+
+// clearDecoded drops every cached instruction in the segment
+func (seg *MemorySegment) clearDecoded() {
+	seg.decoded = nil
+}
@@ -0,0 +1,512 @@
+package vm
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Disassemble converts a single instruction word at address into assembler syntax.
+// labels maps addresses to symbol names and is used for branch targets (may be nil).
+//
+// The second result reports whether the text reassembles to exactly the same word with
+// this project's assembler. Encodings the assembler cannot produce (long multiply,
+// non-canonical immediates, conditional S-suffixed instructions, ...) still return
+// readable text but report false, so callers can emit the raw word as .word data.
+func Disassemble(opcode, address uint32, labels map[uint32]string) (string, bool) {
+	cond := ConditionCode((opcode >> ConditionShift) & Mask4Bit)
+	if cond > CondAL {
+		return "UNDEFINED", false
+	}
+
+	instType, err := decodeInstructionType(opcode)
+	if err != nil {
+		return "UNDEFINED", false
+	}
+
+	switch instType {
+	case InstDataProcessing:
+		return disasmDataProcessing(opcode, cond)
+	case InstMultiply:
+		return disasmMultiply(opcode, cond)
+	case InstLoadStore:
+		if (opcode>>Bits27_26Shift)&Mask2Bit == 0 {
+			return disasmHalfword(opcode, cond)
+		}
+		return disasmLoadStore(opcode, cond)
+	case InstLoadStoreMultiple:
+		return disasmLoadStoreMultiple(opcode, cond)
+	case InstBranch:
+		return disasmBranch(opcode, address, cond, labels)
+	case InstSWI:
+		return fmt.Sprintf("SWI%s %s", condSuffix(cond), formatImmediate(opcode&SWIMask)), true
+	case InstPSRTransfer:
+		return disasmPSRTransfer(opcode, cond)
+	case InstSaturating:
+		return disasmSaturating(opcode, cond)
+	case InstDivide:
+		mnemonic := "SDIV"
+		if (opcode & DivideMask) == UDIVPattern {
+			mnemonic = "UDIV"
+		}
+		return fmt.Sprintf("%s%s %s, %s, %s", mnemonic, condSuffix(cond), regName(opcode>>RnShift), regName(opcode), regName(opcode>>RsShift)), true
+	case InstBreakpoint:
+		// BKPT is unconditional; the assembler does not accept a condition suffix
+		return fmt.Sprintf("BKPT%s %s", condSuffix(cond), formatImmediate(uint32(BKPTImmediate(opcode)))), cond == CondAL
+	default:
+		return "UNDEFINED", false
+	}
+}
+
+// LiteralAddress returns the literal pool address read by an LDR Rd, [PC, #offset] word
+// load at address
+func LiteralAddress(opcode, address uint32) (uint32, bool) {
+	if opcode&LDRLiteralMask != LDRLiteralPattern {
+		return 0, false
+	}
+	offset := opcode & Offset12BitMask
+	if (opcode>>UBitShift)&Mask1Bit == 0 {
+		return address + PCBranchBase - offset, true
+	}
+	return address + PCBranchBase + offset, true
+}
+
+// LiteralComment returns the annotation for a literal load at address: the word it loads
+// from mem as "=0x12345678", followed by the symbol labels gives that value, e.g.
+// "=0x00008020 (message)". It reports false for other instructions, unreadable pools and
+// memory-mapped devices.
+func LiteralComment(opcode, address uint32, mem *Memory, labels map[uint32]string) (string, bool) {
+	literal, ok := LiteralAddress(opcode, address)
+	if !ok {
+		return "", false
+	}
+	// Reading a device register could have side effects
+	if seg, _, err := mem.findSegment(literal); err != nil || seg.Device != nil {
+		return "", false
+	}
+	value, err := mem.ReadWord(literal)
+	if err != nil {
+		return "", false
+	}
+	if name, named := labels[value]; named {
+		return fmt.Sprintf("=0x%08X (%s)", value, name), true
+	}
+	return fmt.Sprintf("=0x%08X", value), true
+}
+
+var dataProcessingMnemonics = [...]string{
+	"AND", "EOR", "SUB", "RSB", "ADD", "ADC", "SBC", "RSC",
+	"TST", "TEQ", "CMP", "CMN", "ORR", "MOV", "BIC", "MVN",
+}
+
+var shiftMnemonics = [...]string{"LSL", "LSR", "ASR", "ROR"}
+
+// condSuffix returns the mnemonic suffix for a condition (empty for AL)
+func condSuffix(cond ConditionCode) string {
+	if cond == CondAL {
+		return ""
+	}
+	return cond.String()
+}
+
+// regName returns the assembler name of a register
+func regName(reg uint32) string {
+	return getRegisterName(int(reg & Mask4Bit)) // #nosec G115 -- register number is 0-15
+}
+
+// formatImmediate formats an immediate value: small values in decimal, others in hex
+func formatImmediate(value uint32) string {
+	if value < 10 {
+		return fmt.Sprintf("#%d", value)
+	}
+	return fmt.Sprintf("#0x%X", value)
+}
+
+// formatSignedOffset formats an addressing mode immediate offset with its U bit
+func formatSignedOffset(offset uint32, up bool) string {
+	if up {
+		return formatImmediate(offset)
+	}
+	return "#-" + formatImmediate(offset)[1:]
+}
+
+// canonicalImmediate returns the rotated 8-bit encoding the assembler picks for value
+// (the smallest right-rotation that fits), mirroring the encoder's search order
+func canonicalImmediate(value uint32) (uint32, bool) {
+	for rotate := 0; rotate < BitsInWord; rotate += 2 {
+		if rotated := bits.RotateLeft32(value, -rotate); rotated <= Mask8Bit {
+			decodeRotate := uint32((BitsInWord-rotate)%BitsInWord) / RotationMultiplier // #nosec G115 -- rotate is 0-30
+			return (decodeRotate << RotationShift) | rotated, true
+		}
+	}
+	return 0, false
+}
+
+// disasmShiftedRegister formats a register operand with an optional shift (operand2 or LDR/STR offset).
+// allowRegShift is false for load/store offsets, which only accept immediate shift amounts.
+func disasmShiftedRegister(opcode uint32, allowRegShift bool) (string, bool) {
+	rm := regName(opcode)
+	shiftType := (opcode >> ShiftTypePos) & Mask2Bit
+
+	if (opcode>>Bit4Pos)&Mask1Bit == 1 {
+		rs := regName(opcode >> RsShift)
+		return fmt.Sprintf("%s, %s %s", rm, shiftMnemonics[shiftType], rs), allowRegShift && (opcode>>Bit7Pos)&Mask1Bit == 0
+	}
+
+	amount := (opcode >> ShiftAmountPos) & Mask5Bit
+	if amount == 0 {
+		switch shiftType {
+		case 0:
+			return rm, true
+		case 3:
+			// ROR #0 encodes RRX, which the assembler does not accept
+			return rm + ", RRX", false
+		default:
+			// LSR/ASR #0 encode a shift by 32, which the assembler cannot express
+			return fmt.Sprintf("%s, %s #32", rm, shiftMnemonics[shiftType]), false
+		}
+	}
+	return fmt.Sprintf("%s, %s #%d", rm, shiftMnemonics[shiftType], amount), true
+}
+
+func disasmDataProcessing(opcode uint32, cond ConditionCode) (string, bool) {
+	op := (opcode >> OpcodeShift) & Mask4Bit
+	setFlags := (opcode>>SBitShift)&Mask1Bit == 1
+	rd := (opcode >> RdShift) & Mask4Bit
+	rn := (opcode >> RnShift) & Mask4Bit
+
+	var operand2 string
+	ok := true
+	if (opcode>>IBitShift)&Mask1Bit == 1 {
+		imm := opcode & ImmediateValueMask
+		rotation := int((opcode>>RotationShift)&RotationMask) * RotationMultiplier
+		value := bits.RotateLeft32(imm, -rotation)
+		operand2 = formatImmediate(value)
+		canonical, _ := canonicalImmediate(value)
+		ok = canonical == opcode&Mask12Bit
+	} else {
+		operand2, ok = disasmShiftedRegister(opcode, true)
+	}
+
+	mnemonic := dataProcessingMnemonics[op]
+	switch mnemonic {
+	case "TST", "TEQ", "CMP", "CMN":
+		// Comparisons always set flags and have no destination
+		text := fmt.Sprintf("%s%s %s, %s", mnemonic, condSuffix(cond), regName(rn), operand2)
+		return text, ok && setFlags && rd == 0
+	}
+
+	suffix := ""
+	if setFlags {
+		suffix = "S"
+		// The assembler does not recognise condition and S suffixes together
+		ok = ok && cond == CondAL
+	}
+
+	if mnemonic == "MOV" || mnemonic == "MVN" {
+		return fmt.Sprintf("%s%s%s %s, %s", mnemonic, condSuffix(cond), suffix, regName(rd), operand2), ok && rn == 0
+	}
+	return fmt.Sprintf("%s%s%s %s, %s, %s", mnemonic, condSuffix(cond), suffix, regName(rd), regName(rn), operand2), ok
+}
+
+func disasmMultiply(opcode uint32, cond ConditionCode) (string, bool) {
+	setFlags := (opcode>>SBitShift)&Mask1Bit == 1
+	accumulate := (opcode>>MultiplyAShift)&Mask1Bit == 1
+	rd := regName(opcode >> RnShift)
+	rn := regName(opcode >> RdShift)
+	rs := regName(opcode >> RsShift)
+	rm := regName(opcode)
+
+	suffix := ""
+	if setFlags {
+		suffix = "S"
+	}
+	ok := !setFlags || cond == CondAL
+
+	if (opcode & LongMultiplyMask) == LongMultiplyPattern {
+		mnemonic := "UMULL"
+		switch (opcode >> MultiplyAShift) & Mask2Bit {
+		case 1:
+			mnemonic = "UMLAL"
+		case 2:
+			mnemonic = "SMULL"
+		case 3:
+			mnemonic = "SMLAL"
+		}
+		// Long multiplies have RdLo in bits 15-12 and RdHi in bits 19-16; not supported by the assembler
+		return fmt.Sprintf("%s%s%s %s, %s, %s, %s", mnemonic, condSuffix(cond), suffix, rn, rd, rm, rs), false
+	}
+
+	if accumulate {
+		return fmt.Sprintf("MLA%s%s %s, %s, %s, %s", condSuffix(cond), suffix, rd, rm, rs, rn), ok
+	}
+	return fmt.Sprintf("MUL%s%s %s, %s, %s", condSuffix(cond), suffix, rd, rm, rs), ok && (opcode>>RdShift)&Mask4Bit == 0
+}
+
+func disasmLoadStore(opcode uint32, cond ConditionCode) (string, bool) {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	up := (opcode>>UBitShift)&Mask1Bit == 1
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	rn := regName(opcode >> RnShift)
+	rd := regName(opcode >> RdShift)
+
+	mnemonic := "STR"
+	if (opcode>>LBitShift)&Mask1Bit == 1 {
+		mnemonic = "LDR"
+	}
+	if (opcode>>BBitShift)&Mask1Bit == 1 {
+		mnemonic += "B"
+	}
+	mnemonic += condSuffix(cond)
+
+	ok := true
+	var offset string
+	if (opcode>>IBitShift)&Mask1Bit == 1 {
+		if (opcode>>Bit4Pos)&Mask1Bit == 1 {
+			return "UNDEFINED", false
+		}
+		offset, ok = disasmShiftedRegister(opcode, false)
+		if !up {
+			offset = "-" + offset
+		}
+	} else {
+		imm := opcode & Offset12BitMask
+		if imm != 0 || !up {
+			offset = formatSignedOffset(imm, up)
+			ok = imm != 0
+		}
+	}
+
+	if !pre {
+		// The assembler always sets W for post-indexed word/byte transfers
+		if offset == "" {
+			offset = "#0"
+		}
+		return fmt.Sprintf("%s %s, [%s], %s", mnemonic, rd, rn, offset), ok && writeBack
+	}
+
+	bang := ""
+	if writeBack {
+		bang = "!"
+		if offset == "" {
+			offset = "#0"
+		}
+	}
+	if offset == "" {
+		return fmt.Sprintf("%s %s, [%s]", mnemonic, rd, rn), ok
+	}
+	return fmt.Sprintf("%s %s, [%s, %s]%s", mnemonic, rd, rn, offset, bang), ok
+}
+
+func disasmHalfword(opcode uint32, cond ConditionCode) (string, bool) {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	up := (opcode>>UBitShift)&Mask1Bit == 1
+	immediate := (opcode>>BBitShift)&Mask1Bit == 1 // bit 22 selects immediate offset for halfwords
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	load := (opcode>>LBitShift)&Mask1Bit == 1
+	rn := regName(opcode >> RnShift)
+	rd := regName(opcode >> RdShift)
+
+	ok := true
+	var mnemonic string
+	switch (opcode >> ShiftTypePos) & Mask2Bit {
+	case 1:
+		mnemonic = "STRH"
+		if load {
+			mnemonic = "LDRH"
+		}
+	case 2:
+		mnemonic, ok = "LDRSB", false
+	case 3:
+		mnemonic, ok = "LDRSH", false
+	}
+	if mnemonic == "" || (!load && !ok) {
+		return "UNDEFINED", false
+	}
+	mnemonic += condSuffix(cond)
+
+	var offset string
+	if immediate {
+		imm := (((opcode >> HalfwordHighShift) & HalfwordOffsetHighMask) << HalfwordLowShift) | (opcode & HalfwordOffsetLowMask)
+		if imm != 0 || !up {
+			offset = formatSignedOffset(imm, up)
+			ok = ok && imm != 0
+		}
+	} else {
+		offset = regName(opcode)
+		if !up {
+			// The assembler does not accept negative register offsets for halfword transfers
+			offset = "-" + offset
+			ok = false
+		}
+		ok = ok && (opcode>>RsShift)&Mask4Bit == 0
+	}
+
+	if !pre {
+		if offset == "" {
+			offset = "#0"
+		}
+		return fmt.Sprintf("%s %s, [%s], %s", mnemonic, rd, rn, offset), ok && !writeBack
+	}
+
+	bang := ""
+	if writeBack {
+		bang = "!"
+		if offset == "" {
+			offset = "#0"
+		}
+	}
+	if offset == "" {
+		return fmt.Sprintf("%s %s, [%s]", mnemonic, rd, rn), ok
+	}
+	return fmt.Sprintf("%s %s, [%s, %s]%s", mnemonic, rd, rn, offset, bang), ok
+}
+
+// formatRegisterList formats an LDM/STM register mask, collapsing runs into ranges
+func formatRegisterList(mask uint32) string {
+	var parts []string
+	for reg := uint32(0); reg < 16; reg++ {
+		if mask&(1<<reg) == 0 {
+			continue
+		}
+		end := reg
+		for end+1 < 16 && mask&(1<<(end+1)) != 0 {
+			end++
+		}
+		switch {
+		case end-reg >= 2:
+			parts = append(parts, regName(reg)+"-"+regName(end))
+		case end > reg:
+			parts = append(parts, regName(reg), regName(end))
+		default:
+			parts = append(parts, regName(reg))
+		}
+		reg = end
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func disasmLoadStoreMultiple(opcode uint32, cond ConditionCode) (string, bool) {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	up := (opcode>>UBitShift)&Mask1Bit == 1
+	psr := (opcode>>BBitShift)&Mask1Bit == 1 // S bit (bit 22)
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	load := (opcode>>LBitShift)&Mask1Bit == 1
+	rn := (opcode >> RnShift) & Mask4Bit
+	regList := opcode & RegisterListMask
+
+	if regList == 0 {
+		return "UNDEFINED", false
+	}
+
+	list := formatRegisterList(regList)
+	if psr {
+		list += "^"
+	}
+
+	if writeBack && rn == SP && !psr {
+		if !load && pre && !up {
+			return fmt.Sprintf("PUSH%s %s", condSuffix(cond), list), true
+		}
+		if load && !pre && up {
+			return fmt.Sprintf("POP%s %s", condSuffix(cond), list), true
+		}
+	}
+
+	mode := "IA"
+	switch {
+	case pre && up:
+		mode = "IB"
+	case !pre && !up:
+		mode = "DA"
+	case pre && !up:
+		mode = "DB"
+	}
+
+	mnemonic := "STM"
+	if load {
+		mnemonic = "LDM"
+	}
+	base := regName(rn)
+	if writeBack {
+		base += "!"
+	}
+	return fmt.Sprintf("%s%s%s %s, %s", mnemonic, mode, condSuffix(cond), base, list), !psr
+}
+
+func disasmBranch(opcode, address uint32, cond ConditionCode, labels map[uint32]string) (string, bool) {
+	if (opcode & BXPatternMask) == BXEncodingBase {
+		return fmt.Sprintf("BX%s %s", condSuffix(cond), regName(opcode)), true
+	}
+	if (opcode & BXPatternMask) == BLXEncodingBase {
+		// The assembler only recognises unconditional BLX
+		return fmt.Sprintf("BLX%s %s", condSuffix(cond), regName(opcode)), cond == CondAL
+	}
+
+	offset := opcode & Offset24BitMask
+	if offset&(1<<23) != 0 {
+		offset |= ^uint32(Offset24BitMask) // sign-extend
+	}
+	target := address + PCBranchBase + offset<<WordToByteShift
+
+	dest := fmt.Sprintf("0x%X", target)
+	if name, ok := labels[target]; ok {
+		dest = name
+	}
+
+	mnemonic := "B"
+	if (opcode & BranchLinkMask) == BranchLinkPattern {
+		mnemonic = "BL"
+	}
+	// "BLS" is read back by the assembler as BL with an S suffix
+	ok := !(mnemonic == "B" && cond == CondLS)
+	return fmt.Sprintf("%s%s %s", mnemonic, condSuffix(cond), dest), ok
+}
+
+func disasmSaturating(opcode uint32, cond ConditionCode) (string, bool) {
+	mnemonic := "QADD"
+	if (opcode & SaturatingMask) == QSUBPattern {
+		mnemonic = "QSUB"
+	}
+	text := fmt.Sprintf("%s%s %s, %s, %s", mnemonic, condSuffix(cond), regName(opcode>>RdShift), regName(opcode), regName(opcode>>RnShift))
+	return text, (opcode>>RsShift)&Mask4Bit == 0
+}
+
+// disasmPSRTransfer formats MRS and MSR. The assembler sets the should-be-one bits 15-12
+// and the smallest immediate rotation, and never uses PC or an empty MSR field mask.
+func disasmPSRTransfer(opcode uint32, cond ConditionCode) (string, bool) {
+	psr := "CPSR"
+	if (opcode>>PSRSPSRBit)&Mask1Bit == 1 {
+		psr = "SPSR"
+	}
+	sbo := opcode&PSRFieldSBO == PSRFieldSBO
+
+	if (opcode & MRSMask) == MRSPattern {
+		rd := (opcode >> RdShift) & Mask4Bit
+		return fmt.Sprintf("MRS%s %s, %s", condSuffix(cond), regName(rd), psr), rd != ARMRegisterPC
+	}
+
+	mask := (opcode >> PSRFieldShift) & Mask4Bit
+	fields := ""
+	for i, name := range []string{"c", "x", "s", "f"} {
+		if (mask>>uint32(i))&Mask1Bit == 1 { // #nosec G115 -- i is 0-3
+			fields += name
+		}
+	}
+	ok := sbo && mask != 0
+
+	var source string
+	if (opcode>>IBitShift)&Mask1Bit == 1 {
+		rotation := int((opcode>>RotationShift)&RotationMask) * RotationMultiplier
+		value := bits.RotateLeft32(opcode&ImmediateValueMask, -rotation)
+		source = formatImmediate(value)
+		canonical, _ := canonicalImmediate(value)
+		ok = ok && canonical == opcode&Offset12BitMask
+	} else {
+		rm := opcode & Mask4Bit
+		source = regName(rm)
+		// Bits 11-4 are zero in the register form
+		ok = ok && rm != ARMRegisterPC && opcode&0xFF0 == 0
+	}
+	return fmt.Sprintf("MSR%s %s_%s, %s", condSuffix(cond), psr, fields, source), ok
+}
@@ -0,0 +1,48 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// ExecuteDivide executes the division instructions (SDIV, UDIV)
+// Syntax: SDIV{cond} Rd, Rn, Rm computes Rd = Rn / Rm, rounding toward zero.
+// These are ARMv7 instructions provided as an extension; ARM2 has no hardware divide.
+// As on ARMv7, dividing by zero gives 0 and no flags are affected.
+func ExecuteDivide(vm *VM, inst *Instruction) error {
+	rd := int((inst.Opcode >> RnShift) & Mask4Bit)
+	rm := int((inst.Opcode >> RsShift) & Mask4Bit)
+	rn := int(inst.Opcode & Mask4Bit)
+
+	// R15 (PC) is unpredictable for all operands
+	if rd == ARMRegisterPC || rn == ARMRegisterPC || rm == ARMRegisterPC {
+		return fmt.Errorf("divide: R15 (PC) cannot be used as an operand")
+	}
+
+	dividend := vm.CPU.GetRegister(rn)
+	divisor := vm.CPU.GetRegister(rm)
+
+	var result uint32
+	switch {
+	case divisor == 0:
+		result = 0
+	case (inst.Opcode & DivideMask) == UDIVPattern:
+		result = dividend / divisor
+	default:
+		// Go truncates toward zero, and 0x80000000 / -1 wraps to 0x80000000 as on ARMv7
+		result = uint32(AsInt32(dividend) / AsInt32(divisor)) // #nosec G115 -- two's complement reinterpretation
+	}
+
+	// Store result - if destination is SP, use SetSPWithTrace for bounds validation
+	if rd == SP {
+		if err := vm.CPU.SetSPWithTrace(vm, result, inst.Address); err != nil {
+			vm.State = StateError
+			vm.LastError = err
+			return err
+		}
+	} else {
+		vm.CPU.SetRegister(rd, result)
+	}
+
+	vm.CPU.IncrementPC()
+	return nil
+}
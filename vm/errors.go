@@ -0,0 +1,194 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorCategory classifies the runtime faults Step can return
+type ErrorCategory int
+
+const (
+	CategoryMemoryFault ErrorCategory = iota + 1
+	CategoryAlignment
+	CategoryUndefinedInstruction
+	CategoryStack
+)
+
+// String returns the category name used in CLI and API output
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryMemoryFault:
+		return "memory-fault"
+	case CategoryAlignment:
+		return "alignment"
+	case CategoryUndefinedInstruction:
+		return "undefined-instruction"
+	case CategoryStack:
+		return "stack"
+	default:
+		return "unknown"
+	}
+}
+
+// RuntimeError is implemented by the typed faults Step returns. FaultPC is the address of
+// the instruction that faulted; FaultAddress is the memory address involved (for an
+// undefined instruction this is the PC, for a stack error the offending SP).
+type RuntimeError interface {
+	error
+	Category() ErrorCategory
+	FaultPC() uint32
+	FaultAddress() uint32
+}
+
+// faultLocator lets Step record the faulting PC on errors raised below it (e.g. by Memory)
+type faultLocator interface {
+	setFaultPC(pc uint32)
+}
+
+// AsRuntimeError returns the typed runtime fault wrapped in err, if any
+func AsRuntimeError(err error) (RuntimeError, bool) {
+	var rt RuntimeError
+	if errors.As(err, &rt) {
+		return rt, true
+	}
+	return nil, false
+}
+
+// locateFault records pc on the typed fault in err unless one was already recorded
+func locateFault(err error, pc uint32) {
+	var loc faultLocator
+	if errors.As(err, &loc) {
+		loc.setFaultPC(pc)
+	}
+}
+
+// faultPC is embedded by the typed faults to hold the faulting instruction address
+type faultPC struct {
+	PC    uint32
+	pcSet bool
+}
+
+func (f *faultPC) FaultPC() uint32 { return f.PC }
+
+func (f *faultPC) setFaultPC(pc uint32) {
+	if !f.pcSet {
+		f.PC = pc
+		f.pcSet = true
+	}
+}
+
+// MemoryFaultError is an access to unmapped memory, outside a segment or without permission.
+// Access is the permission a protection violation needed and the segment lacks; it is
+// PermNone for the other faults.
+type MemoryFaultError struct {
+	faultPC
+	Address uint32
+	Access  MemoryPermission
+	Message string
+}
+
+// newMemoryFault builds a MemoryFaultError; Step fills in the PC
+func newMemoryFault(address uint32, format string, args ...any) *MemoryFaultError {
+	return &MemoryFaultError{Address: address, Message: fmt.Sprintf(format, args...)}
+}
+
+// newPermissionFault builds a MemoryFaultError for an access seg does not permit
+func newPermissionFault(seg *MemorySegment, address uint32, access MemoryPermission) *MemoryFaultError {
+	var kind string
+	switch access {
+	case PermRead:
+		kind = "read"
+	case PermWrite:
+		kind = "write"
+	default:
+		kind = "execute"
+	}
+	e := newMemoryFault(address, "%s permission denied for segment '%s' at 0x%08X", kind, seg.Name, address)
+	e.Access = access
+	return e
+}
+
+func (e *MemoryFaultError) Error() string           { return e.Message }
+func (e *MemoryFaultError) Category() ErrorCategory { return CategoryMemoryFault }
+func (e *MemoryFaultError) FaultAddress() uint32    { return e.Address }
+
+// AlignmentError is a word or halfword access to a misaligned address under StrictAlign
+type AlignmentError struct {
+	faultPC
+	Address uint32
+	Size    int
+}
+
+func (e *AlignmentError) Error() string {
+	if e.Size == AlignmentWord {
+		return fmt.Sprintf("unaligned word access at 0x%08X (must be 4-byte aligned)", e.Address)
+	}
+	return fmt.Sprintf("unaligned halfword access at 0x%08X (must be 2-byte aligned)", e.Address)
+}
+func (e *AlignmentError) Category() ErrorCategory { return CategoryAlignment }
+func (e *AlignmentError) FaultAddress() uint32    { return e.Address }
+
+// UndefinedInstructionError is an opcode the emulator cannot decode or execute
+type UndefinedInstructionError struct {
+	faultPC
+	Opcode uint32
+	Reason string
+}
+
+func (e *UndefinedInstructionError) Error() string {
+	return fmt.Sprintf("undefined instruction 0x%08X: %s", e.Opcode, e.Reason)
+}
+func (e *UndefinedInstructionError) Category() ErrorCategory { return CategoryUndefinedInstruction }
+func (e *UndefinedInstructionError) FaultAddress() uint32    { return e.PC }
+
+// Explain shows the opcode fields that select an instruction class and why none matched
+func (e *UndefinedInstructionError) Explain() string {
+	cond := ConditionCode((e.Opcode >> ConditionShift) & Mask4Bit)
+	return fmt.Sprintf("cond=%s bits[27:25]=%03b bits[7:4]=%04b: %s",
+		cond, (e.Opcode>>Bits27_25Shift)&Mask3Bit, (e.Opcode>>Bit4Pos)&Mask4Bit, e.Reason)
+}
+
+// StackError is SP leaving the stack: below Limit for an overflow or above it for an
+// underflow. It unwraps to ErrStackOverflow or ErrStackUnderflow.
+type StackError struct {
+	faultPC
+	SP       uint32
+	Limit    uint32
+	Overflow bool
+	Message  string
+}
+
+// newStackError builds a StackError for the instruction at pc
+func newStackError(pc, sp, limit uint32, overflow bool, format string, args ...any) *StackError {
+	e := &StackError{SP: sp, Limit: limit, Overflow: overflow, Message: fmt.Sprintf(format, args...)}
+	e.setFaultPC(pc)
+	return e
+}
+
+func (e *StackError) Error() string {
+	return fmt.Sprintf("%v at PC=0x%08X: %s", e.Unwrap(), e.PC, e.Message)
+}
+func (e *StackError) Category() ErrorCategory { return CategoryStack }
+func (e *StackError) FaultAddress() uint32    { return e.SP }
+
+func (e *StackError) Unwrap() error {
+	if e.Overflow {
+		return ErrStackOverflow
+	}
+	return ErrStackUnderflow
+}
+
+// TimeoutError is returned when a run's wall-clock deadline passes. It reports where
+// execution stopped and unwraps to context.DeadlineExceeded.
+type TimeoutError struct {
+	PC           uint32
+	Instructions uint64
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("wall-clock timeout exceeded after %d instructions", e.Instructions)
+}
+
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
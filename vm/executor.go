@@ -2,10 +2,14 @@ package vm
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"sync"
+	"time"
 )
 
 // ExecutionMode represents the execution mode of the VM
@@ -29,6 +33,16 @@ const (
 	StateWaitingForInput // VM is blocked waiting for stdin input
 )
 
+// ErrInstructionLimit is wrapped by the error Step returns when InstructionLimit is reached
+var ErrInstructionLimit = errors.New("instruction limit exceeded")
+
+// ErrStackOverflow and ErrStackUnderflow are wrapped by the error Step returns when
+// StackGuard is set and an instruction moves SP out of the stack segment
+var (
+	ErrStackOverflow  = errors.New("stack overflow")
+	ErrStackUnderflow = errors.New("stack underflow")
+)
+
 // Instruction represents a decoded ARM instruction
 type Instruction struct {
 	Address   uint32
@@ -51,6 +65,9 @@ const (
 	InstBranch
 	InstSWI
 	InstPSRTransfer
+	InstSaturating
+	InstBreakpoint
+	InstDivide
 )
 
 // VM represents the complete virtual machine
@@ -61,26 +78,50 @@ type VM struct {
 	Mode   ExecutionMode
 
 	// Execution limits and statistics
-	CycleLimit     uint64   // Maximum cycles before halt (0 = unlimited)
-	InstructionLog []uint32 // History of executed instruction addresses
+	CycleLimit       uint64        // Maximum cycles before halt (0 = unlimited)
+	InstructionLimit uint64        // Maximum instructions before halt (0 = unlimited)
+	SyscallLimits    SyscallLimits // Caps on SWI use; SyscallUsage counts against them
+	SyscallUsage     SyscallUsage
+	InstructionLog   []uint32 // History of executed instruction addresses
 
 	// Error handling
 	LastError error
 
 	// Runtime environment
-	EntryPoint       uint32
-	StackTop         uint32 // Initial stack pointer value for reset
-	ProgramArguments []string
-	ExitCode         int32
-	FilesystemRoot   string // Root directory for file operations (sandboxing)
+	EntryPoint        uint32
+	StackTop          uint32            // Initial stack pointer value for reset
+	StackGuard        bool              // Halt when SP moves below StackSegmentStart or above StackTop
+	UninitCheck       UninitCheck       // Report reads of registers nothing has written (see uninit.go)
+	UninitWriter      io.Writer         // Destination for UninitWarn warnings (defaults to os.Stderr)
+	registersWritten  uint16            // Bit n set once Rn has been written
+	uninitWarned      map[uint32]uint16 // Registers already warned about, by instruction address
+	registerCount     int               // Visible general-purpose registers (see SetRegisterCount)
+	ProgramArguments  []string
+	argvAddress       uint32   // Heap block holding the marshalled ProgramArguments, 0 until SWI_GET_ARGUMENTS
+	Environment       []string // KEY=VALUE variables returned by SWI_GET_ENVIRONMENT
+	envpAddress       uint32   // Heap block holding the marshalled Environment, 0 until SWI_GET_ENVIRONMENT
+	ExitCode          int32
+	FilesystemRoot    string // Root directory for file operations (sandboxing)
+	AllowFSRootChange bool   // Permit SWI_SET_FS_ROOT to narrow FilesystemRoot at runtime
+
+	// Random source for SWI_GET_RANDOM; time-seeded unless SetRandomSeed is called
+	Random     *rand.Rand
+	randomSeed *int64
+
+	// Clock for SWI_GET_TIME and SWI_GET_DATETIME; nil reads the host clock
+	Clock func() time.Time
 
 	// I/O redirection (for TUI and testing)
-	OutputWriter io.Writer // Writer for program output (defaults to os.Stdout)
+	OutputWriter io.Writer   // Writer for program output (defaults to os.Stdout)
+	outputSinks  []io.Writer // Extra writers receiving a copy of the output (see AddOutputWriter)
+	outputMu     sync.Mutex
 
 	// Tracing and statistics (Phase 10)
 	ExecutionTrace *ExecutionTrace
 	MemoryTrace    *MemoryTrace
+	MemoryHeatmap  *MemoryHeatmap
 	Statistics     *PerformanceStatistics
+	Profiler       *Profiler
 
 	// Additional diagnostic modes (Phase 11)
 	CodeCoverage  *CodeCoverage
@@ -88,9 +129,19 @@ type VM struct {
 	FlagTrace     *FlagTrace
 	RegisterTrace *RegisterTrace
 
+	// Interrupt timer peripheral, configured by SWI_SET_TIMER
+	Timer Timer
+
+	// BKPT trap taken by the most recent Step (nil if the step did not execute a BKPT)
+	LastBKPT *BKPTTrap
+
+	// Reverse execution (nil unless EnableHistory was called)
+	History *ExecutionHistory
+
 	// File descriptor table (simple)
-	files []*os.File
-	fdMu  sync.Mutex
+	files     []*os.File
+	fileModes map[uint32]uint32 // FileMode* each user fd was opened with, for ExportState
+	fdMu      sync.Mutex
 
 	// Per-instance stdin reader to avoid race conditions when multiple VMs
 	// run concurrently. Previously this was a global variable shared across
@@ -104,11 +155,15 @@ type VM struct {
 
 	// State change callback for API/GUI to broadcast state changes (e.g., waiting for input)
 	OnStateChange func(state ExecutionState)
+
+	// Memory access callback for range watchpoints, called with the address and size in
+	// bytes of each load and store, and of each buffer a syscall fills
+	OnMemoryAccess func(address, size uint32, write bool)
 }
 
 // NewVM creates a new virtual machine instance
 func NewVM() *VM {
-	return &VM{
+	machine := &VM{
 		CPU:              NewCPU(),
 		Memory:           NewMemory(),
 		State:            StateHalted,
@@ -118,9 +173,38 @@ func NewVM() *VM {
 		EntryPoint:       CodeSegmentStart,
 		ProgramArguments: make([]string, 0),
 		ExitCode:         0,
-		OutputWriter:     os.Stdout,                            // Default to stdout
-		files:            make([]*os.File, DefaultFDTableSize), // Will be lazily initialized to stdin/stdout/stderr
-		stdinReader:      bufio.NewReader(os.Stdin),            // Per-instance stdin reader
+		registerCount:    ARMTotalRegisterCount,
+		OutputWriter:     os.Stdout,                                       // Default to stdout
+		files:            make([]*os.File, DefaultFDTableSize),            // Will be lazily initialized to stdin/stdout/stderr
+		stdinReader:      bufio.NewReader(os.Stdin),                       // Per-instance stdin reader
+		Random:           rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404 -- pseudo-random for emulator, not crypto
+	}
+
+	// Map the UART peripheral; the address range is reserved so this cannot fail
+	_ = machine.Memory.MapDevice("uart", UARTBaseAddress, UARTRegionSize, PermRead|PermWrite, NewUART(machine))
+
+	return machine
+}
+
+// SetRandomSeed makes SWI_GET_RANDOM deterministic. The source is reseeded on every reset,
+// so restarting a program replays the same sequence.
+func (vm *VM) SetRandomSeed(seed int64) {
+	vm.randomSeed = &seed
+	vm.reseedRandom()
+}
+
+// now returns the time reported to the program
+func (vm *VM) now() time.Time {
+	if vm.Clock != nil {
+		return vm.Clock()
+	}
+	return time.Now()
+}
+
+// reseedRandom restarts the seeded random sequence; time-seeded sources are left alone
+func (vm *VM) reseedRandom() {
+	if vm.randomSeed != nil {
+		vm.Random = rand.New(rand.NewSource(*vm.randomSeed)) // #nosec G404 -- pseudo-random for emulator, not crypto
 	}
 }
 
@@ -132,6 +216,13 @@ func (vm *VM) SetState(state ExecutionState) {
 	}
 }
 
+// notifyMemoryAccess calls the memory access callback if registered
+func (vm *VM) notifyMemoryAccess(address, size uint32, write bool) {
+	if vm.OnMemoryAccess != nil {
+		vm.OnMemoryAccess(address, size, write)
+	}
+}
+
 // Reset resets the VM to initial state, clearing all program data and I/O state
 func (vm *VM) Reset() {
 	// Reset CPU and memory
@@ -147,7 +238,16 @@ func (vm *VM) Reset() {
 	vm.EntryPoint = 0
 	vm.StackTop = 0
 	vm.ProgramArguments = nil
+	vm.Environment = nil
 	vm.ExitCode = 0
+	vm.reseedRandom()
+	vm.LastBKPT = nil
+	vm.Timer = Timer{}
+	vm.SyscallUsage = SyscallUsage{}
+	vm.resetUninitTracking()
+	if vm.History != nil {
+		vm.History.Clear()
+	}
 
 	// Clear I/O state
 	vm.fdMu.Lock()
@@ -157,6 +257,7 @@ func (vm *VM) Reset() {
 		}
 	}
 	vm.files = nil
+	vm.fileModes = nil
 	vm.fdMu.Unlock()
 
 	// Note: Do NOT reset stdinReader here - it may be intentionally redirected
@@ -174,7 +275,9 @@ func (vm *VM) Reset() {
 	vm.RegisterTrace = nil
 	vm.ExecutionTrace = nil
 	vm.MemoryTrace = nil
+	vm.MemoryHeatmap = nil
 	vm.Statistics = nil
+	vm.Profiler = nil
 }
 
 // ResetRegisters resets only CPU registers and state, preserving memory contents
@@ -182,6 +285,14 @@ func (vm *VM) Reset() {
 // losing the loaded program
 func (vm *VM) ResetRegisters() error {
 	vm.CPU.Reset()
+	vm.reseedRandom()
+	vm.LastBKPT = nil
+	vm.Timer = Timer{}
+	vm.SyscallUsage = SyscallUsage{}
+	vm.resetUninitTracking()
+	if vm.History != nil {
+		vm.History.Clear()
+	}
 	// Restore PC to entry point after reset
 	vm.CPU.PC = vm.EntryPoint
 	// Restore stack pointer to initial value
@@ -226,50 +337,73 @@ func (vm *VM) InitializeStack(stackTop uint32) error {
 
 // Step executes a single instruction
 func (vm *VM) Step() error {
+	return vm.StepFunc()()
+}
+
+// StepFunc returns the step implementation for the VM's current diagnostics: a fast path
+// when no per-step hook (history, coverage, execution, flag or register trace, profiler,
+// statistics) is attached, otherwise the instrumented path. Both execute identically. Run loops call
+// it once before starting, so attach diagnostics first.
+func (vm *VM) StepFunc() func() error {
+	if vm.History != nil || vm.CodeCoverage != nil || vm.ExecutionTrace != nil || vm.Profiler != nil ||
+		vm.FlagTrace != nil || (vm.RegisterTrace != nil && vm.RegisterTrace.Enabled) ||
+		(vm.Statistics != nil && vm.Statistics.Enabled) {
+		return vm.stepInstrumented
+	}
+	return vm.stepFast
+}
+
+// stepFast executes a single instruction without diagnostic hooks
+func (vm *VM) stepFast() error {
 	if vm.State == StateError {
 		return fmt.Errorf("VM is in error state: %w", vm.LastError)
 	}
+	vm.LastBKPT = nil
 
-	// Check cycle limit
-	if vm.CycleLimit > 0 && vm.CPU.Cycles >= vm.CycleLimit {
-		vm.State = StateError
-		vm.LastError = fmt.Errorf("cycle limit exceeded (%d cycles)", vm.CycleLimit)
-		return vm.LastError
+	decoded, err := vm.fetchDecode()
+	if err != nil {
+		return err
 	}
 
-	// Check execute permission for current PC
-	if err := vm.Memory.CheckExecutePermission(vm.CPU.PC); err != nil {
-		vm.State = StateError
-		vm.LastError = err
+	if !vm.CPU.CPSR.EvaluateCondition(decoded.Condition) {
+		vm.skipInstruction()
+		return nil
+	}
+
+	stateBefore := vm.State
+	if err := vm.executeDecoded(decoded); err != nil {
 		return err
 	}
+	vm.finishStep(stateBefore)
+	return nil
+}
 
-	// Log instruction address
-	vm.InstructionLog = append(vm.InstructionLog, vm.CPU.PC)
+// stepInstrumented executes a single instruction, recording it in every attached diagnostic
+func (vm *VM) stepInstrumented() error {
+	if vm.State == StateError {
+		return fmt.Errorf("VM is in error state: %w", vm.LastError)
+	}
+	vm.LastBKPT = nil
 
-	// Fetch instruction
-	instruction, err := vm.Fetch()
-	if err != nil {
-		vm.State = StateError
-		vm.LastError = fmt.Errorf("fetch failed at PC=0x%08X: %w", vm.CPU.PC, err)
-		return vm.LastError
+	// Record the pre-step state for StepBack
+	if vm.History != nil {
+		vm.History.begin(vm)
+		defer vm.History.commit(vm)
 	}
 
-	// Decode instruction
-	decoded, err := vm.Decode(instruction)
+	decoded, err := vm.fetchDecode()
 	if err != nil {
-		vm.State = StateError
-		vm.LastError = fmt.Errorf("decode failed at PC=0x%08X: %w", vm.CPU.PC, err)
-		return vm.LastError
+		return err
 	}
 
 	// Check condition code
 	condResult := vm.CPU.CPSR.EvaluateCondition(decoded.Condition)
+	if vm.CodeCoverage != nil && decoded.Condition < CondAL {
+		vm.CodeCoverage.RecordCondition(decoded.Address, condResult)
+	}
 
 	if !condResult {
-		// Condition not met, skip instruction
-		vm.CPU.IncrementPC()
-		vm.CPU.IncrementCycles(1)
+		vm.skipInstruction()
 		return nil
 	}
 
@@ -282,21 +416,21 @@ func (vm *VM) Step() error {
 		regsBefore[15] = vm.CPU.PC
 	}
 
-	// Save state before execution to detect if Execute() changes it
 	stateBefore := vm.State
-
-	// Execute instruction
-	if err := vm.Execute(decoded); err != nil {
-		// Don't overwrite terminal states (Halted, Breakpoint) set by syscalls
-		if vm.State != StateHalted && vm.State != StateBreakpoint {
-			vm.State = StateError
-			vm.LastError = fmt.Errorf("execute failed at PC=0x%08X: %w", decoded.Address, err)
+	cyclesBefore := vm.CPU.Cycles
+	if err := vm.executeDecoded(decoded); err != nil {
+		// The exit SWI halts by returning an error, but it still executed
+		if vm.State == StateHalted {
+			if vm.CodeCoverage != nil {
+				vm.CodeCoverage.RecordExecution(decoded.Address, vm.CPU.Cycles)
+			}
+			if vm.Statistics != nil {
+				vm.Statistics.RecordExecution(decoded, vm.CPU.Cycles-cyclesBefore)
+			}
 		}
 		return err
 	}
 
-	vm.CPU.IncrementCycles(1)
-
 	// Record diagnostic information after instruction execution
 	currentPC := decoded.Address
 
@@ -305,6 +439,21 @@ func (vm *VM) Step() error {
 		vm.CodeCoverage.RecordExecution(currentPC, vm.CPU.Cycles)
 	}
 
+	// Execution trace
+	if vm.ExecutionTrace != nil {
+		vm.ExecutionTrace.RecordExecution(vm, currentPC, decoded.Opcode)
+	}
+
+	// Instruction histogram
+	if vm.Statistics != nil {
+		vm.Statistics.RecordExecution(decoded, vm.CPU.Cycles-cyclesBefore)
+	}
+
+	// Call-graph profiling
+	if vm.Profiler != nil {
+		vm.Profiler.RecordInstruction(decoded, vm.CPU.PC, vm.CPU.Cycles)
+	}
+
 	// Flag change tracking
 	if vm.FlagTrace != nil {
 		// Get simple instruction name for trace (we'll enhance this later with proper disassembly)
@@ -326,6 +475,115 @@ func (vm *VM) Step() error {
 		}
 	}
 
+	vm.finishStep(stateBefore)
+	return nil
+}
+
+// fetchDecode checks the execution limits and permissions, then fetches and decodes the
+// instruction at PC. Failures put the VM in the error state.
+func (vm *VM) fetchDecode() (*Instruction, error) {
+	// Check cycle limit
+	if vm.CycleLimit > 0 && vm.CPU.Cycles >= vm.CycleLimit {
+		vm.State = StateError
+		vm.LastError = fmt.Errorf("cycle limit exceeded (%d cycles)", vm.CycleLimit)
+		return nil, vm.LastError
+	}
+
+	// Check instruction limit. Unlike the cycle limit this does not depend on how
+	// many cycles each instruction is charged, so it always catches runaway loops.
+	if vm.InstructionLimit > 0 && vm.CPU.Instructions >= vm.InstructionLimit {
+		vm.State = StateError
+		vm.LastError = fmt.Errorf("%w (%d instructions)", ErrInstructionLimit, vm.InstructionLimit)
+		return nil, vm.LastError
+	}
+
+	// Check execute permission for current PC
+	if err := vm.Memory.CheckExecutePermission(vm.CPU.PC); err != nil {
+		locateFault(err, vm.CPU.PC)
+		vm.State = StateError
+		vm.LastError = err
+		return nil, err
+	}
+
+	// Log instruction address
+	vm.InstructionLog = append(vm.InstructionLog, vm.CPU.PC)
+
+	// Reuse the decoded form if the instruction has not been written since it last ran
+	if decoded := vm.Memory.cachedInstruction(vm.CPU.PC); decoded != nil {
+		vm.CPU.Instructions++
+		return decoded, nil
+	}
+
+	// Fetch instruction
+	instruction, err := vm.Fetch()
+	if err != nil {
+		locateFault(err, vm.CPU.PC)
+		vm.State = StateError
+		vm.LastError = fmt.Errorf("fetch failed at PC=0x%08X: %w", vm.CPU.PC, err)
+		return nil, vm.LastError
+	}
+
+	// Decode instruction
+	decoded, err := vm.Decode(instruction)
+	if err != nil {
+		locateFault(err, vm.CPU.PC)
+		vm.State = StateError
+		vm.LastError = fmt.Errorf("decode failed at PC=0x%08X: %w", vm.CPU.PC, err)
+		return nil, vm.LastError
+	}
+	vm.Memory.cacheInstruction(decoded)
+	vm.CPU.Instructions++
+	return decoded, nil
+}
+
+// skipInstruction steps over an instruction whose condition was not met
+func (vm *VM) skipInstruction() {
+	vm.CPU.IncrementPC()
+	vm.CPU.IncrementCycles(1)
+	vm.checkTimerIRQ()
+}
+
+// executeDecoded executes an instruction whose condition passed and charges its cycle
+func (vm *VM) executeDecoded(decoded *Instruction) error {
+	var regsBefore [ARMGeneralRegisterCount]uint32
+	if vm.UninitCheck != UninitOff {
+		if err := vm.checkUninitializedReads(decoded); err != nil {
+			vm.State = StateError
+			vm.LastError = err
+			return err
+		}
+		regsBefore = vm.CPU.R
+	}
+
+	if err := vm.Execute(decoded); err != nil {
+		locateFault(err, decoded.Address)
+		// Don't overwrite terminal states (Halted, Breakpoint) set by syscalls
+		if vm.State != StateHalted && vm.State != StateBreakpoint {
+			vm.State = StateError
+			vm.LastError = fmt.Errorf("execute failed at PC=0x%08X: %w", decoded.Address, err)
+		}
+		return err
+	}
+
+	vm.CPU.IncrementCycles(1)
+	if vm.UninitCheck != UninitOff {
+		vm.markRegistersWritten(decoded, &regsBefore)
+	}
+
+	if err := vm.checkStackGuard(decoded.Address); err != nil {
+		vm.State = StateError
+		vm.LastError = err
+		return err
+	}
+	return nil
+}
+
+// finishStep takes a due interrupt and settles the state after an executed instruction.
+// stateBefore is the state before Execute, to detect whether it changed.
+func (vm *VM) finishStep(stateBefore ExecutionState) {
+	// Interrupts are taken between instructions, so the handler starts on the next step
+	vm.checkTimerIRQ()
+
 	// Set state to breakpoint after successful step, unless:
 	// 1. Execute() changed the state (e.g., to StateHalted from EXIT or StateBreakpoint from hitting a breakpoint)
 	// 2. We're being called from Run() (state is StateRunning - should remain running)
@@ -335,13 +593,11 @@ func (vm *VM) Step() error {
 		vm.State = StateBreakpoint
 	}
 	// else: Either Execute() changed the state, or we're in Run() mode (preserve StateRunning)
-
-	return nil
 }
 
 // Fetch fetches the instruction at the current PC
 func (vm *VM) Fetch() (uint32, error) {
-	instruction, err := vm.Memory.ReadWord(vm.CPU.PC)
+	instruction, err := vm.Memory.FetchInstruction(vm.CPU.PC)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch instruction: %w", err)
 	}
@@ -350,12 +606,27 @@ func (vm *VM) Fetch() (uint32, error) {
 
 // Decode decodes a raw instruction word
 func (vm *VM) Decode(opcode uint32) (*Instruction, error) {
+	instType, err := decodeInstructionType(opcode)
+	if err != nil {
+		return nil, err
+	}
+
 	inst := &Instruction{
 		Address:   vm.CPU.PC,
 		Opcode:    opcode,
+		Type:      instType,
 		Condition: ConditionCode((opcode >> ConditionShift) & Mask4Bit),
 		SetFlags:  (opcode & (1 << SBitShift)) != 0, // S bit
 	}
+	if err := vm.checkRegisterLimit(inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// decodeInstructionType classifies a raw instruction word (shared by Decode and Disassemble)
+func decodeInstructionType(opcode uint32) (InstructionType, error) {
+	var instType InstructionType
 
 	// Determine instruction type based on bits 27-26
 	bits2726 := (opcode >> Bits27_26Shift) & Mask2Bit
@@ -364,29 +635,35 @@ func (vm *VM) Decode(opcode uint32) (*Instruction, error) {
 	case 0: // 00 - Could be data processing, multiply, BX, BLX, or load/store halfword
 		// Check for BX (Branch and Exchange) first: bits [27:4] = 0x12FFF1
 		if (opcode & BXPatternMask) == BXEncodingBase {
-			inst.Type = InstBranch
+			instType = InstBranch
 		} else if (opcode & BXPatternMask) == BLXEncodingBase {
 			// BLX register form: bits [27:4] = 0x12FFF3
-			inst.Type = InstBranch
+			instType = InstBranch
+		} else if (opcode&SaturatingMask) == QADDPattern || (opcode&SaturatingMask) == QSUBPattern {
+			// Saturating arithmetic (QADD, QSUB): bits [27:23]=00010, [20]=0, [7:4]=0101
+			instType = InstSaturating
+		} else if (opcode & BKPTMask) == BKPTPattern {
+			// Software breakpoint: bits [27:20]=00010010, [7:4]=0111
+			instType = InstBreakpoint
 		} else if (opcode & MultiplyMask) == MultiplyPattern {
 			// Multiply instruction pattern (MUL, MLA)
-			inst.Type = InstMultiply
+			instType = InstMultiply
 		} else if (opcode & LongMultiplyMask) == LongMultiplyPattern {
 			// Long multiply instruction pattern (UMULL, UMLAL, SMULL, SMLAL)
 			// Bits [27:23] = 0b00001, bits [7:4] = 0b1001
-			inst.Type = InstMultiply
+			instType = InstMultiply
 		} else if (opcode & MRSMask) == MRSPattern {
 			// MRS instruction: bits [27:23]=00010, [22]=PSR, [21]=0, [20]=0, [19:16]=1111, [11:0]=0
 			// Pattern: cccc 00010 x 00 1111 dddd 0000 0000 0000
-			inst.Type = InstPSRTransfer
+			instType = InstPSRTransfer
 		} else if (opcode & MSRRegMask) == MSRRegPattern {
 			// MSR instruction (register): bits [27:23]=00010, [21]=1, [20]=0, [7:4]=0000
 			// Pattern: cccc 00010 x 10 xxxx 1111 0000 0000 mmmm
-			inst.Type = InstPSRTransfer
+			instType = InstPSRTransfer
 		} else if (opcode & MSRImmMask) == MSRImmPattern {
 			// MSR instruction (immediate): bits [27:23]=00110, [21]=1, [20]=0
 			// Pattern: cccc 00110 x 10 xxxx 1111 rrrr iiii iiii
-			inst.Type = InstPSRTransfer
+			instType = InstPSRTransfer
 		} else {
 			// Check for halfword load/store: bit 25 = 0, bit 7 = 1, bit 4 = 1
 			// This distinguishes from data processing with immediate (bit 25 = 1)
@@ -394,36 +671,67 @@ func (vm *VM) Decode(opcode uint32) (*Instruction, error) {
 			bit7 := (opcode >> Bit7Pos) & Mask1Bit
 			bit4 := (opcode >> Bit4Pos) & Mask1Bit
 			if bit25 == 0 && bit7 == 1 && bit4 == 1 {
-				// This is a halfword/signed transfer (LDRH, STRH, LDRSB, LDRSH)
-				inst.Type = InstLoadStore
+				// This is a halfword/signed transfer (LDRH, STRH, LDRSB, LDRSH); SH=00 is
+				// the swap encoding, which ARM2 does not have
+				if (opcode>>ShiftTypePos)&Mask2Bit == 0 {
+					return InstUnknown, &UndefinedInstructionError{Opcode: opcode,
+						Reason: "bits [7:4]=1001 with SH=00 is neither a multiply nor a halfword transfer (SWP is not supported)"}
+				}
+				instType = InstLoadStore
 			} else {
 				// Data processing
-				inst.Type = InstDataProcessing
+				instType = InstDataProcessing
 			}
 		}
 
-	case 1: // 01 - Load/Store
-		inst.Type = InstLoadStore
+	case 1: // 01 - Load/Store, or SDIV/UDIV in the undefined register-offset space
+		if (opcode&DivideMask) == SDIVPattern || (opcode&DivideMask) == UDIVPattern {
+			instType = InstDivide
+		} else if (opcode>>IBitShift)&Mask1Bit == 1 && (opcode>>Bit4Pos)&Mask1Bit == 1 {
+			// A register offset never has bit 4 set, so this is not a load or store
+			return InstUnknown, &UndefinedInstructionError{Opcode: opcode,
+				Reason: "bits [27:25]=011 with bit 4 set is the architecturally undefined instruction space"}
+		} else {
+			instType = InstLoadStore
+		}
 
 	case 2: // 10 - Could be branch or load/store multiple
 		if (opcode & BranchBitMask) != 0 {
 			// Branch
-			inst.Type = InstBranch
+			instType = InstBranch
 		} else {
 			// Load/Store Multiple
-			inst.Type = InstLoadStoreMultiple
+			instType = InstLoadStoreMultiple
 		}
 
 	case 3: // 11 - Coprocessor or SWI
 		if (opcode & SWIDetectMask) == SWIPattern {
 			// SWI
-			inst.Type = InstSWI
+			instType = InstSWI
 		} else {
-			return nil, fmt.Errorf("coprocessor instructions not supported")
+			return InstUnknown, &UndefinedInstructionError{Opcode: opcode, Reason: "coprocessor instructions not supported"}
 		}
 	}
 
-	return inst, nil
+	return instType, nil
+}
+
+// checkStackGuard reports SP outside [StackSegmentStart, StackTop] after the instruction
+// at pc, when StackGuard is set and the stack has been initialised
+func (vm *VM) checkStackGuard(pc uint32) error {
+	if !vm.StackGuard || vm.StackTop == 0 {
+		return nil
+	}
+	sp := vm.CPU.GetSP()
+	switch {
+	case sp < StackSegmentStart:
+		return newStackError(pc, sp, StackSegmentStart, true,
+			"SP=0x%08X is below the stack segment start 0x%08X", sp, uint32(StackSegmentStart))
+	case sp > vm.StackTop:
+		return newStackError(pc, sp, vm.StackTop, false,
+			"SP=0x%08X is above the initial stack top 0x%08X", sp, vm.StackTop)
+	}
+	return nil
 }
 
 // Execute executes a decoded instruction
@@ -443,8 +751,14 @@ func (vm *VM) Execute(inst *Instruction) error {
 		return ExecuteSWI(vm, inst)
 	case InstPSRTransfer:
 		return ExecutePSRTransfer(vm, inst)
+	case InstSaturating:
+		return ExecuteSaturating(vm, inst)
+	case InstBreakpoint:
+		return ExecuteBKPT(vm, inst)
+	case InstDivide:
+		return ExecuteDivide(vm, inst)
 	default:
-		return fmt.Errorf("unknown instruction type at 0x%08X: opcode=0x%08X", inst.Address, inst.Opcode)
+		return &UndefinedInstructionError{Opcode: inst.Opcode, Reason: "unknown instruction type"}
 	}
 }
 
@@ -456,13 +770,16 @@ func (vm *VM) Execute(inst *Instruction) error {
 // - branch.go
 // - syscall.go
 // - psr.go
+// - saturating.go
+// - bkpt.go
 
 // Run executes instructions until halt, error, or breakpoint
 func (vm *VM) Run() error {
 	vm.State = StateRunning
 
+	step := vm.StepFunc()
 	for vm.State == StateRunning {
-		if err := vm.Step(); err != nil {
+		if err := step(); err != nil {
 			return err
 		}
 		// CycleLimit is enforced in Step(), no need to duplicate check here
@@ -471,6 +788,59 @@ func (vm *VM) Run() error {
 	return nil
 }
 
+// RunContext is Run with a context: once ctx is done execution stops with an error, a
+// *TimeoutError if its deadline passed. The context is checked between instructions, so
+// an instruction blocked in a host read is not interrupted.
+func (vm *VM) RunContext(ctx context.Context) error {
+	vm.State = StateRunning
+
+	step := vm.ContextStep(ctx, vm.StepFunc())
+	for vm.State == StateRunning {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ContextStep wraps step so that it fails, putting the VM in the error state, once ctx
+// is done. The context is checked every ContextCheckInterval calls.
+func (vm *VM) ContextStep(ctx context.Context, step func() error) func() error {
+	if ctx.Done() == nil {
+		return step
+	}
+	calls := 0
+	return func() error {
+		if calls%ContextCheckInterval == 0 {
+			if err := vm.CheckContext(ctx); err != nil {
+				return err
+			}
+		}
+		calls++
+		return step()
+	}
+}
+
+// CheckContext stops the VM if ctx is done, returning why: a *TimeoutError if its
+// deadline passed, otherwise an error wrapping the context's error. It returns nil while
+// ctx is live.
+func (vm *VM) CheckContext(ctx context.Context) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return nil
+	}
+	var err error
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		err = &TimeoutError{PC: vm.CPU.PC, Instructions: vm.CPU.Instructions}
+	} else {
+		err = fmt.Errorf("execution cancelled: %w", ctxErr)
+	}
+	vm.State = StateError
+	vm.LastError = err
+	return err
+}
+
 // GetState returns the current execution state
 func (vm *VM) GetState() ExecutionState {
 	return vm.State
@@ -542,6 +912,7 @@ func (vm *VM) FindEntryPoint(symbols map[string]uint32) (uint32, error) {
 // SetProgramArguments sets command-line arguments for the program
 func (vm *VM) SetProgramArguments(args []string) {
 	vm.ProgramArguments = args
+	vm.argvAddress = 0 // Marshal the new arguments on the next SWI_GET_ARGUMENTS
 }
 
 // GetExitCode returns the program exit code
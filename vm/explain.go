@@ -0,0 +1,446 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Explanation is a plain-English description of one instruction, for learners
+type Explanation struct {
+	Text      string // Disassembly, as Disassemble gives it
+	Condition string // When the instruction runs
+	Operation string // What it does in terms of its operands
+	Operands  string // Each operand and its role
+	Flags     string // Which CPSR flags it changes
+}
+
+// String returns the explanation as labelled lines
+func (e Explanation) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Text + "\n")
+	sb.WriteString("  Condition: " + e.Condition + "\n")
+	sb.WriteString("  Operation: " + e.Operation + "\n")
+	if e.Operands != "" {
+		sb.WriteString("  Operands:  " + e.Operands + "\n")
+	}
+	sb.WriteString("  Flags:     " + e.Flags + "\n")
+	return sb.String()
+}
+
+// conditionDescriptions says when each condition passes
+var conditionDescriptions = [...]string{
+	"Z is set (equal)",
+	"Z is clear (not equal)",
+	"C is set (unsigned higher or same)",
+	"C is clear (unsigned lower)",
+	"N is set (negative)",
+	"N is clear (positive or zero)",
+	"V is set (signed overflow)",
+	"V is clear (no signed overflow)",
+	"C is set and Z is clear (unsigned higher)",
+	"C is clear or Z is set (unsigned lower or same)",
+	"N equals V (signed greater than or equal)",
+	"N differs from V (signed less than)",
+	"Z is clear and N equals V (signed greater than)",
+	"Z is set or N differs from V (signed less than or equal)",
+}
+
+const (
+	flagsUnchanged  = "unchanged"
+	flagsNoSuffix   = "unchanged (no S suffix)"
+	flagsArithmetic = "N and Z from the result, C from the carry out, V on signed overflow"
+	flagsSubtract   = "N and Z from the result, C set if there was no borrow, V on signed overflow"
+	flagsLogical    = "N and Z from the result, C from the shifter carry out (unchanged if operand 2 is not shifted), V unchanged"
+)
+
+// ExplainInstruction describes the instruction word at address: the condition it tests,
+// what it does with its operands and the flags it would change. labels names branch
+// targets (may be nil).
+func ExplainInstruction(opcode, address uint32, labels map[uint32]string) Explanation {
+	text, _ := Disassemble(opcode, address, labels)
+	e := Explanation{Text: text, Flags: flagsUnchanged}
+
+	cond := ConditionCode((opcode >> ConditionShift) & Mask4Bit)
+	switch {
+	case cond == CondAL:
+		e.Condition = "always (AL)"
+	case cond > CondAL:
+		e.Condition = "never (NV, the instruction is ignored)"
+	default:
+		e.Condition = fmt.Sprintf("%s: runs only if %s, otherwise it is skipped", cond, conditionDescriptions[cond])
+	}
+
+	instType, err := decodeInstructionType(opcode)
+	if err != nil {
+		var undef *UndefinedInstructionError
+		e.Operation = "undefined instruction"
+		if errors.As(err, &undef) {
+			e.Operation += ": " + undef.Reason
+		}
+		e.Flags = "none: executing it stops the program"
+		return e
+	}
+
+	switch instType {
+	case InstDataProcessing:
+		explainDataProcessing(opcode, &e)
+	case InstMultiply:
+		explainMultiply(opcode, &e)
+	case InstLoadStore:
+		explainLoadStore(opcode, address, &e)
+	case InstLoadStoreMultiple:
+		explainLoadStoreMultiple(opcode, &e)
+	case InstBranch:
+		explainBranch(opcode, address, labels, &e)
+	case InstSWI:
+		e.Operation = fmt.Sprintf("calls emulator service 0x%02X (SWI number in bits 0-23)", opcode&SWIMask)
+		e.Flags = "unchanged (the service may set R0 and other registers)"
+	case InstPSRTransfer:
+		explainPSRTransfer(opcode, &e)
+	case InstSaturating:
+		rd, rm, rn := regName(opcode>>RdShift), regName(opcode), regName(opcode>>RnShift)
+		op := "+"
+		if (opcode & SaturatingMask) == QSUBPattern {
+			op = "-"
+		}
+		e.Operation = fmt.Sprintf("%s = %s %s %s, clamped to the signed 32-bit range instead of wrapping", rd, rm, op, rn)
+		e.Operands = fmt.Sprintf("%s (destination), %s and %s (sources)", rd, rm, rn)
+		e.Flags = "Q is set if the result saturated; N, Z, C and V unchanged"
+	case InstDivide:
+		kind := "signed"
+		if (opcode & DivideMask) == UDIVPattern {
+			kind = "unsigned"
+		}
+		rd, rn, rm := regName(opcode>>RnShift), regName(opcode), regName(opcode>>RsShift)
+		e.Operation = fmt.Sprintf("%s = %s / %s (%s, rounding towards zero)", rd, rn, rm, kind)
+		e.Operands = fmt.Sprintf("%s (destination), %s (dividend), %s (divisor)", rd, rn, rm)
+	case InstBreakpoint:
+		e.Operation = fmt.Sprintf("stops execution as a breakpoint, with comment %d", BKPTImmediate(opcode))
+	}
+	return e
+}
+
+// explainOperand2 returns how a data-processing operand 2 reads in an expression and a
+// description of it
+func explainOperand2(opcode uint32) (value, desc string) {
+	if (opcode>>IBitShift)&Mask1Bit == 1 {
+		rotation := int((opcode>>RotationShift)&RotationMask) * RotationMultiplier
+		imm := bits.RotateLeft32(opcode&ImmediateValueMask, -rotation)
+		if imm < 10 {
+			return fmt.Sprintf("%d", imm), fmt.Sprintf("the value %d", imm)
+		}
+		return fmt.Sprintf("0x%X", imm), fmt.Sprintf("the value 0x%X (%d)", imm, imm)
+	}
+	return explainShiftedRegister(opcode)
+}
+
+// explainShiftedRegister describes a register operand with its optional shift
+func explainShiftedRegister(opcode uint32) (value, desc string) {
+	rm := regName(opcode)
+	shiftType := (opcode >> ShiftTypePos) & Mask2Bit
+	shiftWords := [...]string{"shifted left", "shifted right logically", "shifted right arithmetically", "rotated right"}
+
+	if (opcode>>Bit4Pos)&Mask1Bit == 1 {
+		rs := regName(opcode >> RsShift)
+		return fmt.Sprintf("(%s %s %s)", rm, shiftMnemonics[shiftType], rs),
+			fmt.Sprintf("%s %s by the amount in %s", rm, shiftWords[shiftType], rs)
+	}
+	amount := (opcode >> ShiftAmountPos) & Mask5Bit
+	if amount == 0 {
+		switch shiftType {
+		case 0:
+			return rm, rm
+		case 3:
+			return fmt.Sprintf("(%s RRX)", rm), fmt.Sprintf("%s rotated right by one bit through C", rm)
+		default:
+			amount = BitsInWord // LSR/ASR #0 encode a shift by 32
+		}
+	}
+	return fmt.Sprintf("(%s %s %d)", rm, shiftMnemonics[shiftType], amount),
+		fmt.Sprintf("%s %s by %d", rm, shiftWords[shiftType], amount)
+}
+
+func explainDataProcessing(opcode uint32, e *Explanation) {
+	op := (opcode >> OpcodeShift) & Mask4Bit
+	setFlags := (opcode>>SBitShift)&Mask1Bit == 1
+	rdNum := (opcode >> RdShift) & Mask4Bit
+	rd, rn := regName(rdNum), regName(opcode>>RnShift)
+	op2, op2Desc := explainOperand2(opcode)
+
+	mnemonic := dataProcessingMnemonics[op]
+	compare := false
+	switch mnemonic {
+	case "AND":
+		e.Operation = fmt.Sprintf("%s = %s AND %s (bitwise)", rd, rn, op2)
+	case "EOR":
+		e.Operation = fmt.Sprintf("%s = %s XOR %s (bitwise)", rd, rn, op2)
+	case "SUB":
+		e.Operation = fmt.Sprintf("%s = %s - %s", rd, rn, op2)
+	case "RSB":
+		e.Operation = fmt.Sprintf("%s = %s - %s (reverse subtract)", rd, op2, rn)
+	case "ADD":
+		e.Operation = fmt.Sprintf("%s = %s + %s", rd, rn, op2)
+	case "ADC":
+		e.Operation = fmt.Sprintf("%s = %s + %s + C", rd, rn, op2)
+	case "SBC":
+		e.Operation = fmt.Sprintf("%s = %s - %s - NOT C", rd, rn, op2)
+	case "RSC":
+		e.Operation = fmt.Sprintf("%s = %s - %s - NOT C (reverse subtract)", rd, op2, rn)
+	case "TST":
+		e.Operation, compare = fmt.Sprintf("tests %s AND %s, keeping only the flags", rn, op2), true
+	case "TEQ":
+		e.Operation, compare = fmt.Sprintf("tests %s XOR %s, keeping only the flags", rn, op2), true
+	case "CMP":
+		e.Operation, compare = fmt.Sprintf("compares %s with %s by computing %s - %s, keeping only the flags", rn, op2, rn, op2), true
+	case "CMN":
+		e.Operation, compare = fmt.Sprintf("compares %s with -%s by computing %s + %s, keeping only the flags", rn, op2, rn, op2), true
+	case "ORR":
+		e.Operation = fmt.Sprintf("%s = %s OR %s (bitwise)", rd, rn, op2)
+	case "MOV":
+		e.Operation = fmt.Sprintf("%s = %s", rd, op2)
+	case "BIC":
+		e.Operation = fmt.Sprintf("%s = %s AND NOT %s (clears the bits set in %s)", rd, rn, op2, op2)
+	case "MVN":
+		e.Operation = fmt.Sprintf("%s = NOT %s (bitwise)", rd, op2)
+	}
+	if !compare && rdNum == ARMRegisterPC {
+		e.Operation += ", a jump since the destination is PC"
+	}
+
+	var operands []string
+	if !compare {
+		operands = append(operands, rd+" (destination)")
+	}
+	if mnemonic != "MOV" && mnemonic != "MVN" {
+		operands = append(operands, rn+" (operand 1)")
+	}
+	operands = append(operands, op2Desc+" (operand 2)")
+	e.Operands = strings.Join(operands, ", ")
+
+	switch {
+	case !setFlags:
+		e.Flags = flagsNoSuffix
+	case mnemonic == "ADD" || mnemonic == "ADC" || mnemonic == "CMN":
+		e.Flags = "S suffix sets " + flagsArithmetic
+	case mnemonic == "SUB" || mnemonic == "SBC" || mnemonic == "RSB" || mnemonic == "RSC" || mnemonic == "CMP":
+		e.Flags = "S suffix sets " + flagsSubtract
+	default:
+		e.Flags = "S suffix sets " + flagsLogical
+	}
+	if compare {
+		e.Flags = strings.Replace(e.Flags, "S suffix sets", "always sets", 1)
+	}
+}
+
+func explainMultiply(opcode uint32, e *Explanation) {
+	setFlags := (opcode>>SBitShift)&Mask1Bit == 1
+	hi, lo := regName(opcode>>RnShift), regName(opcode>>RdShift)
+	rs, rm := regName(opcode>>RsShift), regName(opcode)
+
+	if (opcode & LongMultiplyMask) == LongMultiplyPattern {
+		kind := "unsigned"
+		if (opcode>>MultiplyAShift)&Mask2Bit >= 2 {
+			kind = "signed"
+		}
+		op := "="
+		if (opcode>>MultiplyAShift)&Mask1Bit == 1 {
+			op = "+="
+		}
+		e.Operation = fmt.Sprintf("%s:%s %s %s * %s as a %s 64-bit product", hi, lo, op, rm, rs, kind)
+		e.Operands = fmt.Sprintf("%s (high word), %s (low word), %s and %s (multiplied)", hi, lo, rm, rs)
+	} else if (opcode>>MultiplyAShift)&Mask1Bit == 1 {
+		e.Operation = fmt.Sprintf("%s = %s * %s + %s (low 32 bits)", hi, rm, rs, lo)
+		e.Operands = fmt.Sprintf("%s (destination), %s and %s (multiplied), %s (added)", hi, rm, rs, lo)
+	} else {
+		e.Operation = fmt.Sprintf("%s = %s * %s (low 32 bits)", hi, rm, rs)
+		e.Operands = fmt.Sprintf("%s (destination), %s and %s (multiplied)", hi, rm, rs)
+	}
+
+	e.Flags = flagsNoSuffix
+	if setFlags {
+		e.Flags = "S suffix sets N and Z from the result, C and V unchanged"
+	}
+}
+
+func explainLoadStore(opcode, address uint32, e *Explanation) {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	up := (opcode>>UBitShift)&Mask1Bit == 1
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	load := (opcode>>LBitShift)&Mask1Bit == 1
+	rd, rn := regName(opcode>>RdShift), regName(opcode>>RnShift)
+
+	size := "a word"
+	var offset, offsetDesc string
+	if (opcode>>Bits27_26Shift)&Mask2Bit == 0 {
+		// Halfword and signed transfers
+		switch (opcode >> ShiftTypePos) & Mask2Bit {
+		case 1:
+			size = "a halfword"
+		case 2:
+			size = "a sign-extended byte"
+		case 3:
+			size = "a sign-extended halfword"
+		}
+		if (opcode>>BBitShift)&Mask1Bit == 1 {
+			imm := (((opcode >> HalfwordHighShift) & HalfwordOffsetHighMask) << HalfwordLowShift) | (opcode & HalfwordOffsetLowMask)
+			offset, offsetDesc = fmt.Sprintf("%d", imm), fmt.Sprintf("the value %d", imm)
+		} else {
+			offset = regName(opcode)
+			offsetDesc = offset
+		}
+	} else {
+		if (opcode>>BBitShift)&Mask1Bit == 1 {
+			size = "a byte"
+		}
+		if (opcode>>IBitShift)&Mask1Bit == 1 {
+			offset, offsetDesc = explainShiftedRegister(opcode)
+		} else {
+			imm := opcode & Offset12BitMask
+			offset, offsetDesc = fmt.Sprintf("%d", imm), fmt.Sprintf("the value %d", imm)
+		}
+	}
+	sign, applied := "+", "added"
+	if !up {
+		sign, applied = "-", "subtracted"
+	}
+
+	// where is the address accessed, then is the base register update that follows
+	var where, then string
+	switch {
+	case !pre:
+		where = "the address in " + rn
+		then = fmt.Sprintf(", then %s = %s %s %s", rn, rn, sign, offset)
+	case offset == "0":
+		where = "the address in " + rn
+	default:
+		where = fmt.Sprintf("%s %s %s", rn, sign, offset)
+		if writeBack {
+			then = ", then writes that address back to " + rn
+		}
+	}
+	if literal, ok := LiteralAddress(opcode, address); ok {
+		where = fmt.Sprintf("0x%08X (a PC-relative literal)", literal)
+	}
+
+	if load {
+		e.Operation = fmt.Sprintf("loads %s from memory at %s into %s%s", size, where, rd, then)
+		e.Operands = fmt.Sprintf("%s (destination), %s (base address)", rd, rn)
+	} else {
+		e.Operation = fmt.Sprintf("stores %s from %s to memory at %s%s", size, rd, where, then)
+		e.Operands = fmt.Sprintf("%s (source), %s (base address)", rd, rn)
+	}
+	if offset != "0" {
+		e.Operands += fmt.Sprintf(", %s (offset, %s)", offsetDesc, applied)
+	}
+}
+
+func explainLoadStoreMultiple(opcode uint32, e *Explanation) {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	up := (opcode>>UBitShift)&Mask1Bit == 1
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	load := (opcode>>LBitShift)&Mask1Bit == 1
+	rnNum := (opcode >> RnShift) & Mask4Bit
+	regList := opcode & RegisterListMask
+	list := formatRegisterList(regList)
+	count := bits.OnesCount32(regList)
+
+	e.Operands = fmt.Sprintf("%s (base address), %s (%d registers)", regName(rnNum), list, count)
+	switch {
+	case writeBack && rnNum == SP && !load && pre && !up:
+		e.Operation = fmt.Sprintf("pushes %s onto the stack, lowest register at the lowest address; SP decreases by %d", list, count*4)
+	case writeBack && rnNum == SP && load && !pre && up:
+		e.Operation = fmt.Sprintf("pops %s from the stack; SP increases by %d", list, count*4)
+	default:
+		mode := "increment after"
+		switch {
+		case pre && up:
+			mode = "increment before"
+		case !pre && !up:
+			mode = "decrement after"
+		case pre && !up:
+			mode = "decrement before"
+		}
+		rn := regName(rnNum)
+		if load {
+			e.Operation = fmt.Sprintf("loads %s from consecutive words at the address in %s (%s)", list, rn, mode)
+		} else {
+			e.Operation = fmt.Sprintf("stores %s to consecutive words at the address in %s (%s)", list, rn, mode)
+		}
+		if writeBack {
+			e.Operation += fmt.Sprintf(", then updates %s by %d", rn, count*4)
+		}
+	}
+	if load && regList&(1<<ARMRegisterPC) != 0 {
+		e.Operation += "; loading PC makes it a jump (e.g. a return)"
+	}
+}
+
+func explainBranch(opcode, address uint32, labels map[uint32]string, e *Explanation) {
+	if (opcode & BXPatternMask) == BXEncodingBase {
+		rm := regName(opcode)
+		e.Operation = "jumps to the address in " + rm
+		e.Operands = rm + " (target address)"
+		return
+	}
+	if (opcode & BXPatternMask) == BLXEncodingBase {
+		rm := regName(opcode)
+		e.Operation = fmt.Sprintf("calls the address in %s, saving the return address 0x%08X in LR", rm, address+4)
+		e.Operands = rm + " (target address)"
+		return
+	}
+
+	offset := opcode & Offset24BitMask
+	if offset&(1<<23) != 0 {
+		offset |= ^uint32(Offset24BitMask) // sign-extend
+	}
+	target := address + PCBranchBase + offset<<WordToByteShift
+	dest := fmt.Sprintf("0x%08X", target)
+	if name, ok := labels[target]; ok {
+		dest = fmt.Sprintf("%s at 0x%08X", name, target)
+	}
+
+	if (opcode & BranchLinkMask) == BranchLinkPattern {
+		e.Operation = fmt.Sprintf("calls %s, saving the return address 0x%08X in LR", dest, address+4)
+	} else {
+		e.Operation = "jumps to " + dest
+	}
+	e.Operands = dest + " (target, PC-relative)"
+}
+
+func explainPSRTransfer(opcode uint32, e *Explanation) {
+	psr := "CPSR"
+	if (opcode>>PSRSPSRBit)&Mask1Bit == 1 {
+		psr = "SPSR"
+	}
+	if (opcode & MRSMask) == MRSPattern {
+		rd := regName(opcode >> RdShift)
+		e.Operation = fmt.Sprintf("%s = %s (copies the status register into a register)", rd, psr)
+		e.Operands = fmt.Sprintf("%s (destination), %s (source)", rd, psr)
+		return
+	}
+
+	mask := (opcode >> PSRFieldShift) & Mask4Bit
+	var fields []string
+	for i, name := range []string{"control", "extension", "status", "flags"} {
+		if (mask>>uint32(i))&Mask1Bit == 1 { // #nosec G115 -- i is 0-3
+			fields = append(fields, name)
+		}
+	}
+	source, sourceDesc := regName(opcode&Mask4Bit), regName(opcode&Mask4Bit)
+	if (opcode>>IBitShift)&Mask1Bit == 1 {
+		rotation := int((opcode>>RotationShift)&RotationMask) * RotationMultiplier
+		value := bits.RotateLeft32(opcode&ImmediateValueMask, -rotation)
+		source, sourceDesc = fmt.Sprintf("0x%X", value), fmt.Sprintf("the value 0x%X", value)
+	}
+	noun := "field"
+	if len(fields) > 1 {
+		noun = "fields"
+	}
+	e.Operation = fmt.Sprintf("writes the %s %s of %s from %s", strings.Join(fields, " and "), noun, psr, source)
+	e.Operands = fmt.Sprintf("%s (destination), %s (source)", psr, sourceDesc)
+	if mask&(1<<3) != 0 {
+		e.Flags = "N, Z, C and V are set from bits 31-28 of the source"
+	}
+}
@@ -33,6 +33,7 @@ type FlagTrace struct {
 	zChanges     uint64 // Zero flag changes
 	cChanges     uint64 // Carry flag changes
 	vChanges     uint64 // Overflow flag changes
+	qChanges     uint64 // Saturation flag changes
 
 	// Symbol resolution
 	symbols *SymbolResolver // Symbol resolver for address annotation
@@ -62,6 +63,7 @@ func (f *FlagTrace) Start(initialFlags CPSR) {
 	f.zChanges = 0
 	f.cChanges = 0
 	f.vChanges = 0
+	f.qChanges = 0
 }
 
 // RecordFlags records the current flag state
@@ -112,6 +114,9 @@ func (f *FlagTrace) detectChanges(old, new CPSR) string {
 	if old.V != new.V {
 		changes = append(changes, "V")
 	}
+	if old.Q != new.Q {
+		changes = append(changes, "Q")
+	}
 
 	return strings.Join(changes, "")
 }
@@ -130,6 +135,9 @@ func (f *FlagTrace) updateStatistics(old, new CPSR) {
 	if old.V != new.V {
 		f.vChanges++
 	}
+	if old.Q != new.Q {
+		f.qChanges++
+	}
 }
 
 // GetEntries returns all flag trace entries
@@ -152,7 +160,8 @@ func (f *FlagTrace) Flush() error {
 	header.WriteString(fmt.Sprintf("  N flag changes:   %d\n", f.nChanges))
 	header.WriteString(fmt.Sprintf("  Z flag changes:   %d\n", f.zChanges))
 	header.WriteString(fmt.Sprintf("  C flag changes:   %d\n", f.cChanges))
-	header.WriteString(fmt.Sprintf("  V flag changes:   %d\n\n", f.vChanges))
+	header.WriteString(fmt.Sprintf("  V flag changes:   %d\n", f.vChanges))
+	header.WriteString(fmt.Sprintf("  Q flag changes:   %d\n\n", f.qChanges))
 
 	if _, err := f.Writer.Write([]byte(header.String())); err != nil {
 		return err
@@ -202,8 +211,8 @@ func (f *FlagTrace) formatEntry(entry FlagChangeEntry) string {
 
 // formatFlags formats CPSR flags as a string
 func (f *FlagTrace) formatFlags(flags CPSR) string {
-	// Use a fixed-size byte slice for efficiency (4 flags)
-	result := make([]byte, 4)
+	// Use a fixed-size byte slice for efficiency (5 flags)
+	result := make([]byte, 5)
 	if flags.N {
 		result[0] = 'N'
 	} else {
@@ -224,19 +233,25 @@ func (f *FlagTrace) formatFlags(flags CPSR) string {
 	} else {
 		result[3] = '-'
 	}
+	if flags.Q {
+		result[4] = 'Q'
+	} else {
+		result[4] = '-'
+	}
 	return string(result)
 }
 
 // highlightChanges highlights changed flags in the new flags string
 func (f *FlagTrace) highlightChanges(flags CPSR, changed string) string {
 	var sb strings.Builder
-	sb.Grow(8) // Max 4 flags * 2 chars each
+	sb.Grow(10) // Max 5 flags * 2 chars each
 
 	// Helper to check if flag changed
 	hasN := strings.Contains(changed, "N")
 	hasZ := strings.Contains(changed, "Z")
 	hasC := strings.Contains(changed, "C")
 	hasV := strings.Contains(changed, "V")
+	hasQ := strings.Contains(changed, "Q")
 
 	// N flag
 	if flags.N {
@@ -278,6 +293,16 @@ func (f *FlagTrace) highlightChanges(flags CPSR, changed string) string {
 		sb.WriteByte('*')
 	}
 
+	// Q flag
+	if flags.Q {
+		sb.WriteByte('Q')
+	} else {
+		sb.WriteByte('-')
+	}
+	if hasQ {
+		sb.WriteByte('*')
+	}
+
 	return sb.String()
 }
 
@@ -289,6 +314,7 @@ func (f *FlagTrace) ExportJSON(w io.Writer) error {
 		"z_changes":     f.zChanges,
 		"c_changes":     f.cChanges,
 		"v_changes":     f.vChanges,
+		"q_changes":     f.qChanges,
 		"entries":       f.entries,
 	}
 
@@ -309,6 +335,7 @@ func (f *FlagTrace) String() string {
 	sb.WriteString(fmt.Sprintf("Z flag changes:     %d\n", f.zChanges))
 	sb.WriteString(fmt.Sprintf("C flag changes:     %d\n", f.cChanges))
 	sb.WriteString(fmt.Sprintf("V flag changes:     %d\n", f.vChanges))
+	sb.WriteString(fmt.Sprintf("Q flag changes:     %d\n", f.qChanges))
 
 	return sb.String()
 }
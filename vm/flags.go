@@ -64,6 +64,22 @@ func CalculateSubOverflow(a, b, result uint32) bool {
 	return (aSign != bSign) && (aSign != resultSign)
 }
 
+// AddWithCarry computes a + b + carryIn with the C and V flags of the ARM AddWithCarry
+// pseudocode. Subtractions with borrow use it as a + NOT(b) + C, so C is NOT borrow and
+// V accounts for the carry-in in a single step rather than two.
+func AddWithCarry(a, b uint32, carryIn bool) (result uint32, carry, overflow bool) {
+	var c uint64
+	if carryIn {
+		c = 1
+	}
+	unsignedSum := uint64(a) + uint64(b) + c
+	signedSum := int64(int32(a)) + int64(int32(b)) + int64(c) // #nosec G115 -- reinterpreting bits as signed
+	result = uint32(unsignedSum)                              // #nosec G115 -- truncation to 32 bits is the point
+	carry = unsignedSum > 0xFFFFFFFF
+	overflow = int64(int32(result)) != signedSum // #nosec G115 -- reinterpreting bits as signed
+	return result, carry, overflow
+}
+
 // CalculateShiftCarry calculates the carry flag for shift operations
 // Returns the last bit that was shifted out, or current carry if shift amount is 0
 func CalculateShiftCarry(value uint32, shiftAmount int, shiftType ShiftType, currentCarry bool) bool {
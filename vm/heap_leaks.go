@@ -0,0 +1,50 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HeapLeaks returns the heap blocks the program allocated and has not freed, sorted by address
+func (vm *VM) HeapLeaks() []HeapAllocation {
+	leaks := make([]HeapAllocation, 0, len(vm.Memory.HeapAllocations))
+	for _, alloc := range vm.Memory.HeapAllocations {
+		if !alloc.System {
+			leaks = append(leaks, *alloc)
+		}
+	}
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].Address < leaks[j].Address
+	})
+	return leaks
+}
+
+// WriteLeakReport writes the unfreed heap blocks with the instruction that allocated each one.
+// Allocating PCs are shown relative to the nearest symbol when symbols are provided.
+func (vm *VM) WriteLeakReport(w io.Writer, symbols map[string]uint32) error {
+	leaks := vm.HeapLeaks()
+	if len(leaks) == 0 {
+		_, err := fmt.Fprintln(w, "No heap leaks detected")
+		return err
+	}
+
+	resolver := NewSymbolResolver(symbols)
+	var total uint64
+	for _, leak := range leaks {
+		total += uint64(leak.Size)
+	}
+
+	if _, err := fmt.Fprintf(w, "Heap leaks: %d block(s), %d byte(s) not freed\n", len(leaks), total); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "  %-10s  %10s  %s\n", "Address", "Size", "Allocated at"); err != nil {
+		return err
+	}
+	for _, leak := range leaks {
+		if _, err := fmt.Fprintf(w, "  0x%08X  %10d  %s\n", leak.Address, leak.Size, resolver.FormatAddressCompact(leak.PC)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
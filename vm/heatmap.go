@@ -0,0 +1,156 @@
+package vm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultHeatmapBlockSize is the default number of bytes per heatmap bucket
+const DefaultHeatmapBlockSize = 16
+
+// HeatmapPPMWidth is the number of buckets per row in the PPM image
+const HeatmapPPMWidth = 64
+
+// heatmapMaxPixels caps the PPM image size; larger spans need a bigger block size
+const heatmapMaxPixels = 1 << 22
+
+// HeatmapBucket holds access counts for one block of memory
+type HeatmapBucket struct {
+	Address uint32 // Start address of the block
+	Reads   uint64
+	Writes  uint64
+}
+
+// Total returns the combined read and write count
+func (b HeatmapBucket) Total() uint64 {
+	return b.Reads + b.Writes
+}
+
+// MemoryHeatmap accumulates per-block memory read and write counts
+type MemoryHeatmap struct {
+	Enabled   bool
+	BlockSize uint32 // Bytes per bucket
+
+	buckets map[uint32]*HeatmapBucket // block start address -> counts
+}
+
+// NewMemoryHeatmap creates a heatmap with the given block size (0 selects the default)
+func NewMemoryHeatmap(blockSize uint32) *MemoryHeatmap {
+	if blockSize == 0 {
+		blockSize = DefaultHeatmapBlockSize
+	}
+	return &MemoryHeatmap{
+		Enabled:   true,
+		BlockSize: blockSize,
+		buckets:   make(map[uint32]*HeatmapBucket),
+	}
+}
+
+// RecordRead counts a read of address
+func (h *MemoryHeatmap) RecordRead(address uint32) {
+	if b := h.bucket(address); b != nil {
+		b.Reads++
+	}
+}
+
+// RecordWrite counts a write to address
+func (h *MemoryHeatmap) RecordWrite(address uint32) {
+	if b := h.bucket(address); b != nil {
+		b.Writes++
+	}
+}
+
+func (h *MemoryHeatmap) bucket(address uint32) *HeatmapBucket {
+	if !h.Enabled {
+		return nil
+	}
+	start := address - address%h.BlockSize
+	b, ok := h.buckets[start]
+	if !ok {
+		b = &HeatmapBucket{Address: start}
+		h.buckets[start] = b
+	}
+	return b
+}
+
+// Buckets returns all touched buckets sorted by address
+func (h *MemoryHeatmap) Buckets() []HeatmapBucket {
+	result := make([]HeatmapBucket, 0, len(h.buckets))
+	for _, b := range h.buckets {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+	return result
+}
+
+// Hottest returns up to n buckets with the most accesses, hottest first
+func (h *MemoryHeatmap) Hottest(n int) []HeatmapBucket {
+	result := h.Buckets()
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Total() > result[j].Total()
+	})
+	if n >= 0 && n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+// Clear discards all counts
+func (h *MemoryHeatmap) Clear() {
+	h.buckets = make(map[uint32]*HeatmapBucket)
+}
+
+// ExportCSV writes one "address,reads,writes" row per touched bucket
+func (h *MemoryHeatmap) ExportCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintln(bw, "address,reads,writes"); err != nil {
+		return err
+	}
+	for _, b := range h.Buckets() {
+		if _, err := fmt.Fprintf(bw, "0x%08X,%d,%d\n", b.Address, b.Reads, b.Writes); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ExportPPM writes a binary PPM (P6) image covering the lowest to highest touched
+// bucket, HeatmapPPMWidth buckets per row. Brightness is the bucket's access count
+// relative to the hottest bucket; untouched buckets are black.
+func (h *MemoryHeatmap) ExportPPM(w io.Writer) error {
+	buckets := h.Buckets()
+	if len(buckets) == 0 {
+		_, err := fmt.Fprintf(w, "P6\n1 1\n255\n\x00\x00\x00")
+		return err
+	}
+
+	first := buckets[0].Address / h.BlockSize
+	last := buckets[len(buckets)-1].Address / h.BlockSize
+	pixels := uint64(last-first) + 1
+	if pixels > heatmapMaxPixels {
+		return fmt.Errorf("heatmap spans %d blocks; use a larger block size", pixels)
+	}
+	height := (pixels + HeatmapPPMWidth - 1) / HeatmapPPMWidth
+
+	var maxTotal uint64
+	for _, b := range buckets {
+		maxTotal = max(maxTotal, b.Total())
+	}
+
+	image := make([]byte, height*HeatmapPPMWidth*3)
+	for _, b := range buckets {
+		i := uint64(b.Address/h.BlockSize-first) * 3
+		level := byte(b.Total() * 255 / maxTotal) // #nosec G115 -- at most 255
+		image[i], image[i+1], image[i+2] = level, level, level
+	}
+
+	if _, err := fmt.Fprintf(w, "P6\n%d %d\n255\n", HeatmapPPMWidth, height); err != nil {
+		return err
+	}
+	_, err := w.Write(image)
+	return err
+}
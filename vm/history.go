@@ -0,0 +1,177 @@
+package vm
+
+import (
+	"fmt"
+	"sync"
+)
+
+// memoryUndo holds the bytes a guest write overwrote
+type memoryUndo struct {
+	address uint32
+	data    []byte
+}
+
+// historyEntry is the machine state before one executed step
+type historyEntry struct {
+	registers  [ARMGeneralRegisterCount]uint32
+	pc         uint32
+	cpsr, spsr CPSR
+	timer      Timer
+	cycles     uint64
+	instrs     uint64
+	exitCode   int32
+	logLen     int
+
+	lastMemoryWrite     uint32
+	lastMemoryWriteSize uint32
+	hasMemoryWrite      bool
+
+	// Heap bookkeeping, captured only for SWI instructions (the only ones that allocate)
+	heap            map[uint32]*HeapAllocation
+	nextHeapAddress uint32
+
+	writes []memoryUndo
+}
+
+// ExecutionHistory is a bounded ring of pre-step states used by StepBack.
+// Registers, flags, memory and heap bookkeeping are restored; side effects outside the
+// machine (console output, file I/O, consumed stdin, device registers) are not undone.
+type ExecutionHistory struct {
+	// mu is held for the whole of a recorded step, so StepBack never sees a half-executed one
+	mu       sync.Mutex
+	entries  []historyEntry
+	head     int // Index of the oldest entry
+	count    int
+	recorded historyEntry
+}
+
+// EnableHistory starts recording up to capacity pre-step states for StepBack
+func (vm *VM) EnableHistory(capacity int) {
+	if capacity <= 0 {
+		capacity = DefaultHistoryCapacity
+	}
+	vm.History = &ExecutionHistory{entries: make([]historyEntry, capacity)}
+}
+
+// Len returns the number of steps that can be undone
+func (h *ExecutionHistory) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Clear discards all recorded steps
+func (h *ExecutionHistory) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.head = 0
+	h.count = 0
+	clear(h.entries)
+}
+
+// begin captures the state before a step and starts journaling memory writes
+func (h *ExecutionHistory) begin(vm *VM) {
+	h.mu.Lock()
+
+	h.recorded = historyEntry{
+		registers:           vm.CPU.R,
+		pc:                  vm.CPU.PC,
+		cpsr:                vm.CPU.CPSR,
+		spsr:                vm.CPU.SPSR,
+		timer:               vm.Timer,
+		cycles:              vm.CPU.Cycles,
+		instrs:              vm.CPU.Instructions,
+		exitCode:            vm.ExitCode,
+		logLen:              len(vm.InstructionLog),
+		lastMemoryWrite:     vm.LastMemoryWrite,
+		lastMemoryWriteSize: vm.LastMemoryWriteSize,
+		hasMemoryWrite:      vm.HasMemoryWrite,
+	}
+	if opcode, err := vm.Memory.ReadInstruction(vm.CPU.PC); err == nil && opcode&SWIDetectMask == SWIPattern {
+		h.recorded.heap = make(map[uint32]*HeapAllocation, len(vm.Memory.HeapAllocations))
+		for addr, alloc := range vm.Memory.HeapAllocations {
+			copied := *alloc
+			h.recorded.heap[addr] = &copied
+		}
+		h.recorded.nextHeapAddress = vm.Memory.NextHeapAddress
+	}
+
+	vm.Memory.journal = nil
+	vm.Memory.journaling = true
+}
+
+// commit stores the captured state if the step changed the machine, dropping the oldest when full
+func (h *ExecutionHistory) commit(vm *VM) {
+	defer h.mu.Unlock()
+
+	h.recorded.writes = vm.Memory.journal
+	vm.Memory.journal = nil
+	vm.Memory.journaling = false
+
+	if vm.CPU.PC == h.recorded.pc && vm.CPU.Cycles == h.recorded.cycles && len(h.recorded.writes) == 0 {
+		return // Nothing executed (e.g. cycle limit or fetch error)
+	}
+
+	capacity := len(h.entries)
+	if h.count == capacity {
+		h.head = (h.head + 1) % capacity
+		h.count--
+	}
+	h.entries[(h.head+h.count)%capacity] = h.recorded
+	h.count++
+	h.recorded = historyEntry{}
+}
+
+// StepBack undoes the most recent recorded step, restoring registers, flags, memory and heap
+func (vm *VM) StepBack() error {
+	h := vm.History
+	if h == nil {
+		return fmt.Errorf("execution history is not enabled")
+	}
+	if !h.mu.TryLock() {
+		return fmt.Errorf("cannot step back while an instruction is executing")
+	}
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return fmt.Errorf("no execution history to step back to")
+	}
+	idx := (h.head + h.count - 1) % len(h.entries)
+	entry := h.entries[idx]
+	h.entries[idx] = historyEntry{}
+	h.count--
+
+	// Undo writes newest first so overlapping writes restore the original bytes
+	for i := len(entry.writes) - 1; i >= 0; i-- {
+		if err := vm.Memory.restoreBytes(entry.writes[i].address, entry.writes[i].data); err != nil {
+			return fmt.Errorf("failed to restore memory: %w", err)
+		}
+	}
+
+	if entry.heap != nil {
+		vm.Memory.HeapAllocations = entry.heap
+		vm.Memory.NextHeapAddress = entry.nextHeapAddress
+		vm.Memory.rebuildHeapFreeList()
+	}
+
+	vm.CPU.R = entry.registers
+	vm.CPU.PC = entry.pc
+	vm.CPU.CPSR = entry.cpsr
+	vm.CPU.SPSR = entry.spsr
+	vm.Timer = entry.timer
+	vm.CPU.Cycles = entry.cycles
+	vm.CPU.Instructions = entry.instrs
+	vm.ExitCode = entry.exitCode
+	if entry.logLen <= len(vm.InstructionLog) {
+		vm.InstructionLog = vm.InstructionLog[:entry.logLen]
+	}
+	vm.LastMemoryWrite = entry.lastMemoryWrite
+	vm.LastMemoryWriteSize = entry.lastMemoryWriteSize
+	vm.HasMemoryWrite = entry.hasMemoryWrite
+
+	// Stepping back always leaves the machine paused
+	vm.State = StateBreakpoint
+	vm.LastBKPT = nil
+	vm.LastError = nil
+	return nil
+}
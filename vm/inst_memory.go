@@ -97,32 +97,40 @@ func ExecuteLoadStore(v *VM, inst *Instruction) error {
 		var err error
 		var sizeStr string
 
+		var readSize uint32
 		if isHalfword {
 			// Load halfword
 			halfValue, err2 := vm.Memory.ReadHalfword(accessAddr)
 			value = uint32(halfValue)
 			err = err2
 			sizeStr = "HALF"
+			readSize = 2
 		} else if byteTransfer == 1 {
 			// Load byte
 			byteValue, err2 := vm.Memory.ReadByteAt(accessAddr)
 			value = uint32(byteValue)
 			err = err2
 			sizeStr = "BYTE"
+			readSize = 1
 		} else {
 			// Load word
 			value, err = vm.Memory.ReadWord(accessAddr)
 			sizeStr = "WORD"
+			readSize = 4
 		}
 
 		if err != nil {
 			return fmt.Errorf("load failed at 0x%08X: %w", accessAddr, err)
 		}
+		vm.notifyMemoryAccess(accessAddr, readSize, false)
 
 		// Record memory trace if enabled
 		if vm.MemoryTrace != nil {
 			vm.MemoryTrace.RecordRead(vm.CPU.Cycles, vm.CPU.PC, accessAddr, value, sizeStr)
 		}
+		if vm.MemoryHeatmap != nil {
+			vm.MemoryHeatmap.RecordRead(accessAddr)
+		}
 
 		// If loading to SP (R13), use SetSPWithTrace for bounds validation
 		if rd == SP {
@@ -135,8 +143,11 @@ func ExecuteLoadStore(v *VM, inst *Instruction) error {
 			vm.CPU.SetRegister(rd, value)
 		}
 	} else {
-		// Store instruction
+		// Store instruction; storing PC writes the instruction address + 12 on ARM2
 		value := vm.CPU.GetRegister(rd)
+		if rd == ARMRegisterPC {
+			value = vm.CPU.PC + PCStoreOffset
+		}
 		var err error
 		var sizeStr string
 
@@ -168,11 +179,15 @@ func ExecuteLoadStore(v *VM, inst *Instruction) error {
 		vm.LastMemoryWrite = accessAddr
 		vm.LastMemoryWriteSize = writeSize
 		vm.HasMemoryWrite = true
+		vm.notifyMemoryAccess(accessAddr, writeSize, true)
 
 		// Record memory trace if enabled
 		if vm.MemoryTrace != nil {
 			vm.MemoryTrace.RecordWrite(vm.CPU.Cycles, vm.CPU.PC, accessAddr, value, sizeStr)
 		}
+		if vm.MemoryHeatmap != nil {
+			vm.MemoryHeatmap.RecordWrite(accessAddr)
+		}
 	}
 
 	// Write back effective address to base register if requested
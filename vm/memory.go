@@ -2,6 +2,8 @@ package vm
 
 import (
 	"fmt"
+	"math/bits"
+	"sort"
 )
 
 // Memory access permissions
@@ -14,6 +16,47 @@ const (
 	PermExecute MemoryPermission = 1 << 2
 )
 
+// String formats the permissions as "rwx", with '-' for each one missing
+func (p MemoryPermission) String() string {
+	flags := []byte("---")
+	if p&PermRead != 0 {
+		flags[0] = 'r'
+	}
+	if p&PermWrite != 0 {
+		flags[1] = 'w'
+	}
+	if p&PermExecute != 0 {
+		flags[2] = 'x'
+	}
+	return string(flags)
+}
+
+// ParsePermissions parses permissions written as by String ("r-x") or with the missing
+// ones left out ("rx"). An empty string or "-" means PermNone.
+func ParsePermissions(s string) (MemoryPermission, error) {
+	var p MemoryPermission
+	for _, ch := range s {
+		var bit MemoryPermission
+		switch ch {
+		case 'r':
+			bit = PermRead
+		case 'w':
+			bit = PermWrite
+		case 'x':
+			bit = PermExecute
+		case '-':
+			continue
+		default:
+			return 0, fmt.Errorf("invalid permission %q in %q (use r, w, x or -)", ch, s)
+		}
+		if p&bit != 0 {
+			return 0, fmt.Errorf("permission %q given twice in %q", ch, s)
+		}
+		p |= bit
+	}
+	return p, nil
+}
+
 // MemorySegment represents a region of memory with permissions
 type MemorySegment struct {
 	Start       uint32
@@ -21,18 +64,37 @@ type MemorySegment struct {
 	Data        []byte
 	Permissions MemoryPermission
 	Name        string
+	Device      MMIODevice // Non-nil for memory-mapped I/O regions (Data is unused)
+
+	// Accesses to this segment since the last Reset. Instruction fetches count as
+	// executes rather than reads.
+	Reads    uint64
+	Writes   uint64
+	Executes uint64
+
+	decoded []*Instruction // Decode cache, one entry per word (see decode_cache.go)
 }
 
 // Memory represents the ARM2 virtual memory system
 type Memory struct {
-	Segments        []*MemorySegment
+	Segments []*MemorySegment
+	// LittleEndian selects the byte order of data accesses. Instruction words are always
+	// stored and fetched little-endian (see ReadInstruction), as in ARM's BE-8 mode.
 	LittleEndian    bool
 	StrictAlign     bool
 	AccessCount     uint64
 	ReadCount       uint64
 	WriteCount      uint64
 	HeapAllocations map[uint32]*HeapAllocation
-	NextHeapAddress uint32
+	NextHeapAddress uint32 // End of the used heap; everything above it is free
+
+	// Free blocks below NextHeapAddress, sorted by address with neighbours coalesced.
+	// It is derived from HeapAllocations and rebuilt whenever that is replaced.
+	heapFree []heapBlock
+
+	// Undo journal of overwritten bytes, recorded while a step is captured for StepBack
+	journal    []memoryUndo
+	journaling bool
 }
 
 // NewMemory creates and initializes a new Memory instance
@@ -93,7 +155,7 @@ func (m *Memory) findSegment(address uint32) (*MemorySegment, uint32, error) {
 			}
 		}
 	}
-	return nil, 0, fmt.Errorf("memory access violation: address 0x%08X is not mapped", address)
+	return nil, 0, newMemoryFault(address, "memory access violation: address 0x%08X is not mapped", address)
 }
 
 // checkAlignment checks if an address is properly aligned
@@ -105,11 +167,11 @@ func (m *Memory) checkAlignment(address uint32, size int) error {
 	switch size {
 	case AlignmentWord: // Word access
 		if address&AlignMaskWord != 0 {
-			return fmt.Errorf("unaligned word access at 0x%08X (must be 4-byte aligned)", address)
+			return &AlignmentError{Address: address, Size: size}
 		}
 	case AlignmentHalfword: // Halfword access
 		if address&AlignMaskHalfword != 0 {
-			return fmt.Errorf("unaligned halfword access at 0x%08X (must be 2-byte aligned)", address)
+			return &AlignmentError{Address: address, Size: size}
 		}
 	case AlignmentByte: // Byte access - no alignment required
 	default:
@@ -126,11 +188,17 @@ func (m *Memory) ReadByteAt(address uint32) (byte, error) {
 	}
 
 	if seg.Permissions&PermRead == 0 {
-		return 0, fmt.Errorf("read permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return 0, newPermissionFault(seg, address, PermRead)
+	}
+
+	if seg.Device != nil {
+		value, err := m.readDevice(seg, offset, AlignmentByte, address)
+		return byte(value), err // #nosec G115 -- readDevice masks to a single byte
 	}
 
 	m.AccessCount++
 	m.ReadCount++
+	seg.Reads++
 	return seg.Data[offset], nil
 }
 
@@ -142,11 +210,18 @@ func (m *Memory) WriteByteAt(address uint32, value byte) error {
 	}
 
 	if seg.Permissions&PermWrite == 0 {
-		return fmt.Errorf("write permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return newPermissionFault(seg, address, PermWrite)
+	}
+
+	if seg.Device != nil {
+		return m.writeDevice(seg, offset, AlignmentByte, address, uint32(value))
 	}
 
 	m.AccessCount++
 	m.WriteCount++
+	seg.Writes++
+	m.recordUndo(address, seg.Data[offset:offset+1])
+	seg.invalidateDecoded(offset, 1)
 	seg.Data[offset] = value
 	return nil
 }
@@ -163,16 +238,22 @@ func (m *Memory) ReadHalfword(address uint32) (uint16, error) {
 	}
 
 	if seg.Permissions&PermRead == 0 {
-		return 0, fmt.Errorf("read permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return 0, newPermissionFault(seg, address, PermRead)
+	}
+
+	if seg.Device != nil {
+		value, err := m.readDevice(seg, offset, AlignmentHalfword, address)
+		return uint16(value), err // #nosec G115 -- readDevice masks to a halfword
 	}
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset+1 >= segLen {
-		return 0, fmt.Errorf("halfword read exceeds segment bounds at 0x%08X", address)
+		return 0, newMemoryFault(address, "halfword read exceeds segment bounds at 0x%08X", address)
 	}
 
 	m.AccessCount++
 	m.ReadCount++
+	seg.Reads++
 
 	var value uint16
 	if m.LittleEndian {
@@ -195,16 +276,23 @@ func (m *Memory) WriteHalfword(address uint32, value uint16) error {
 	}
 
 	if seg.Permissions&PermWrite == 0 {
-		return fmt.Errorf("write permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return newPermissionFault(seg, address, PermWrite)
+	}
+
+	if seg.Device != nil {
+		return m.writeDevice(seg, offset, AlignmentHalfword, address, uint32(value))
 	}
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset+1 >= segLen {
-		return fmt.Errorf("halfword write exceeds segment bounds at 0x%08X", address)
+		return newMemoryFault(address, "halfword write exceeds segment bounds at 0x%08X", address)
 	}
 
 	m.AccessCount++
 	m.WriteCount++
+	seg.Writes++
+	m.recordUndo(address, seg.Data[offset:offset+2])
+	seg.invalidateDecoded(offset, 2)
 
 	if m.LittleEndian {
 		seg.Data[offset] = byte(value)        // #nosec G115 -- intentional byte extraction from uint16
@@ -218,22 +306,37 @@ func (m *Memory) WriteHalfword(address uint32, value uint16) error {
 
 // ReadWord reads a 32-bit word from memory
 func (m *Memory) ReadWord(address uint32) (uint32, error) {
+	value, seg, err := m.readWord(address)
+	if seg != nil && seg.Device == nil {
+		seg.Reads++
+	}
+	return value, err
+}
+
+// readWord reads a word, also returning the segment it was read from (nil if the access
+// failed before one was found)
+func (m *Memory) readWord(address uint32) (uint32, *MemorySegment, error) {
 	if err := m.checkAlignment(address, AlignmentWord); err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	seg, offset, err := m.findSegment(address)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	if seg.Permissions&PermRead == 0 {
-		return 0, fmt.Errorf("read permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return 0, nil, newPermissionFault(seg, address, PermRead)
+	}
+
+	if seg.Device != nil {
+		value, err := m.readDevice(seg, offset, AlignmentWord, address)
+		return value, seg, err
 	}
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset+3 >= segLen {
-		return 0, fmt.Errorf("word read exceeds segment bounds at 0x%08X", address)
+		return 0, nil, newMemoryFault(address, "word read exceeds segment bounds at 0x%08X", address)
 	}
 
 	m.AccessCount++
@@ -251,7 +354,7 @@ func (m *Memory) ReadWord(address uint32) (uint32, error) {
 			uint32(seg.Data[offset+2])<<ByteShift8 |
 			uint32(seg.Data[offset+3])
 	}
-	return value, nil
+	return value, seg, nil
 }
 
 // WriteWord writes a 32-bit word to memory
@@ -266,16 +369,23 @@ func (m *Memory) WriteWord(address uint32, value uint32) error {
 	}
 
 	if seg.Permissions&PermWrite == 0 {
-		return fmt.Errorf("write permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return newPermissionFault(seg, address, PermWrite)
+	}
+
+	if seg.Device != nil {
+		return m.writeDevice(seg, offset, AlignmentWord, address, value)
 	}
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset+3 >= segLen {
-		return fmt.Errorf("word write exceeds segment bounds at 0x%08X", address)
+		return newMemoryFault(address, "word write exceeds segment bounds at 0x%08X", address)
 	}
 
 	m.AccessCount++
 	m.WriteCount++
+	seg.Writes++
+	m.recordUndo(address, seg.Data[offset:offset+4])
+	seg.invalidateDecoded(offset, 4)
 
 	if m.LittleEndian {
 		seg.Data[offset] = byte(value)         // #nosec G115 -- intentional byte extraction from uint32
@@ -291,6 +401,28 @@ func (m *Memory) WriteWord(address uint32, value uint32) error {
 	return nil
 }
 
+// recordUndo saves the bytes about to be overwritten when a step is being recorded
+func (m *Memory) recordUndo(address uint32, old []byte) {
+	if m.journaling {
+		m.journal = append(m.journal, memoryUndo{address: address, data: append([]byte(nil), old...)})
+	}
+}
+
+// restoreBytes writes back journaled bytes, bypassing permissions since it undoes a write
+// that was already permitted
+func (m *Memory) restoreBytes(address uint32, data []byte) error {
+	seg, offset, err := m.findSegment(address)
+	if err != nil {
+		return err
+	}
+	if int(offset)+len(data) > len(seg.Data) {
+		return fmt.Errorf("restore exceeds segment bounds at 0x%08X", address)
+	}
+	seg.invalidateDecoded(offset, uint32(len(data))) // #nosec G115 -- bounded by the segment size checked above
+	copy(seg.Data[offset:], data)
+	return nil
+}
+
 // LoadBytes loads a byte array into memory at the specified address
 func (m *Memory) LoadBytes(address uint32, data []byte) error {
 	for i, b := range data {
@@ -330,9 +462,10 @@ func (m *Memory) WriteByteUnsafe(address uint32, value byte) error {
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset >= segLen {
-		return fmt.Errorf("write beyond segment bounds at 0x%08X", address)
+		return newMemoryFault(address, "write beyond segment bounds at 0x%08X", address)
 	}
 
+	seg.invalidateDecoded(offset, 1)
 	seg.Data[offset] = value
 	return nil
 }
@@ -350,10 +483,11 @@ func (m *Memory) WriteWordUnsafe(address uint32, value uint32) error {
 
 	segLen, err := SafeIntToUint32(len(seg.Data))
 	if err != nil || offset+3 >= segLen {
-		return fmt.Errorf("write beyond segment bounds at 0x%08X", address)
+		return newMemoryFault(address, "write beyond segment bounds at 0x%08X", address)
 	}
 
 	m.WriteCount++
+	seg.invalidateDecoded(offset, 4)
 
 	// Write word in appropriate endianness
 	if m.LittleEndian {
@@ -370,6 +504,41 @@ func (m *Memory) WriteWordUnsafe(address uint32, value uint32) error {
 	return nil
 }
 
+// ReadInstruction reads a 32-bit instruction word. Instructions are little-endian
+// regardless of the data byte order.
+func (m *Memory) ReadInstruction(address uint32) (uint32, error) {
+	value, err := m.ReadWord(address)
+	if err != nil || m.LittleEndian {
+		return value, err
+	}
+	return bits.ReverseBytes32(value), nil
+}
+
+// FetchInstruction is ReadInstruction for the CPU's instruction fetch, which the segment
+// counts as an execute rather than a read
+func (m *Memory) FetchInstruction(address uint32) (uint32, error) {
+	value, seg, err := m.readWord(address)
+	if err != nil {
+		return 0, err
+	}
+	if seg.Device == nil {
+		seg.Executes++
+	}
+	if m.LittleEndian {
+		return value, nil
+	}
+	return bits.ReverseBytes32(value), nil
+}
+
+// WriteInstructionUnsafe writes a 32-bit instruction word little-endian without permission checks
+// (for loading code)
+func (m *Memory) WriteInstructionUnsafe(address uint32, opcode uint32) error {
+	if !m.LittleEndian {
+		opcode = bits.ReverseBytes32(opcode)
+	}
+	return m.WriteWordUnsafe(address, opcode)
+}
+
 // GetBytes retrieves a byte array from memory
 func (m *Memory) GetBytes(address uint32, length uint32) ([]byte, error) {
 	result := make([]byte, length)
@@ -389,12 +558,15 @@ func (m *Memory) Reset() {
 		for i := range seg.Data {
 			seg.Data[i] = 0
 		}
+		seg.Reads, seg.Writes, seg.Executes = 0, 0, 0
+		seg.clearDecoded()
 	}
 	m.AccessCount = 0
 	m.ReadCount = 0
 	m.WriteCount = 0
 	m.HeapAllocations = make(map[uint32]*HeapAllocation)
 	m.NextHeapAddress = HeapSegmentStart
+	m.heapFree = nil
 }
 
 // CheckExecutePermission checks if an address has execute permission
@@ -405,11 +577,22 @@ func (m *Memory) CheckExecutePermission(address uint32) error {
 	}
 
 	if seg.Permissions&PermExecute == 0 {
-		return fmt.Errorf("execute permission denied for segment '%s' at 0x%08X", seg.Name, address)
+		return newPermissionFault(seg, address, PermExecute)
 	}
 	return nil
 }
 
+// SetSegmentPermissions replaces the permissions of the named segment
+func (m *Memory) SetSegmentPermissions(name string, permissions MemoryPermission) error {
+	for _, seg := range m.Segments {
+		if seg.Name == name {
+			seg.Permissions = permissions
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown memory segment %q", name)
+}
+
 // MakeCodeReadOnly locks the code segment to prevent writes after loading
 func (m *Memory) MakeCodeReadOnly() {
 	for _, seg := range m.Segments {
@@ -423,10 +606,18 @@ func (m *Memory) MakeCodeReadOnly() {
 type HeapAllocation struct {
 	Address uint32
 	Size    uint32
+	PC      uint32 // Address of the instruction that requested the block (0 if not from a syscall)
+	System  bool   // Allocated by the emulator on the program's behalf (e.g. argv); not reported as a leak
 }
 
-// Allocate allocates memory from the heap
-func (m *Memory) Allocate(size uint32) (uint32, error) {
+// heapBlock is a free region of the heap
+type heapBlock struct {
+	Address uint32
+	Size    uint32
+}
+
+// alignHeapSize rounds size up to a whole number of words
+func alignHeapSize(size uint32) (uint32, error) {
 	if size == 0 {
 		return 0, fmt.Errorf("cannot allocate 0 bytes")
 	}
@@ -436,30 +627,47 @@ func (m *Memory) Allocate(size uint32) (uint32, error) {
 	if size > Address32BitMaxSafe {
 		return 0, fmt.Errorf("allocation size too large (would overflow during alignment)")
 	}
+	return (size + AlignMaskWord) & AlignRoundUpMaskWord, nil
+}
 
-	// Align to 4-byte boundary (round up) with overflow check
-	if size&AlignMaskWord != 0 {
-		aligned := (size + AlignMaskWord) & AlignRoundUpMaskWord
-		// Check for wraparound: if aligned < size, overflow occurred
-		// This happens when size+3 exceeds 0xFFFFFFFF
-		if aligned < size {
-			return 0, fmt.Errorf("allocation size causes overflow during alignment")
-		}
-		size = aligned
+// Allocate allocates memory from the heap. The smallest free block that fits is used
+// (the lowest address on a tie); if none fits, the block comes from the top of the heap.
+func (m *Memory) Allocate(size uint32) (uint32, error) {
+	size, err := alignHeapSize(size)
+	if err != nil {
+		return 0, err
 	}
 
-	// Check for overflow in m.NextHeapAddress + size
-	if size > Address32BitMax-m.NextHeapAddress {
-		return 0, fmt.Errorf("allocation size causes address overflow")
+	best := -1
+	for i, block := range m.heapFree {
+		if block.Size >= size && (best < 0 || block.Size < m.heapFree[best].Size) {
+			best = i
+		}
 	}
 
-	// Check if we have space
-	if m.NextHeapAddress+size >= HeapSegmentStart+HeapSegmentSize {
-		return 0, fmt.Errorf("out of heap memory")
-	}
+	var addr uint32
+	if best >= 0 {
+		addr = m.heapFree[best].Address
+		if m.heapFree[best].Size == size {
+			m.heapFree = append(m.heapFree[:best], m.heapFree[best+1:]...)
+		} else {
+			m.heapFree[best].Address += size
+			m.heapFree[best].Size -= size
+		}
+	} else {
+		// Check for overflow in m.NextHeapAddress + size
+		if size > Address32BitMax-m.NextHeapAddress {
+			return 0, fmt.Errorf("allocation size causes address overflow")
+		}
 
-	addr := m.NextHeapAddress
-	m.NextHeapAddress += size
+		// Check if we have space
+		if m.NextHeapAddress+size >= HeapSegmentStart+HeapSegmentSize {
+			return 0, fmt.Errorf("out of heap memory")
+		}
+
+		addr = m.NextHeapAddress
+		m.NextHeapAddress += size
+	}
 
 	// Track allocation
 	m.HeapAllocations[addr] = &HeapAllocation{
@@ -475,7 +683,7 @@ func (m *Memory) Allocate(size uint32) (uint32, error) {
 	return addr, nil
 }
 
-// Free frees previously allocated memory
+// Free frees previously allocated memory, merging it with any free neighbours
 func (m *Memory) Free(address uint32) error {
 	if address == 0 {
 		return nil // Freeing NULL is a no-op
@@ -494,11 +702,129 @@ func (m *Memory) Free(address uint32) error {
 		_ = m.WriteByteAt(address+i, 0) // Ignore error - address is guaranteed valid
 	}
 
+	m.releaseHeap(address, alloc.Size)
 	return nil
 }
 
+// Resize changes the size of an allocation without moving it, and reports whether it
+// could. Shrinking always succeeds; growing needs a free block, or the unused top of
+// the heap, directly after the allocation. Memory added to the block is zeroed.
+func (m *Memory) Resize(address, size uint32) bool {
+	alloc, ok := m.HeapAllocations[address]
+	if !ok {
+		return false
+	}
+	size, err := alignHeapSize(size)
+	if err != nil {
+		return false
+	}
+
+	end := address + alloc.Size
+	switch {
+	case size == alloc.Size:
+		return true
+
+	case size < alloc.Size:
+		for i := size; i < alloc.Size; i++ {
+			_ = m.WriteByteAt(address+i, 0) // Ignore error - address is guaranteed valid
+		}
+		m.releaseHeap(address+size, alloc.Size-size)
+
+	case end == m.NextHeapAddress:
+		if size-alloc.Size >= HeapSegmentStart+HeapSegmentSize-end {
+			return false
+		}
+		m.NextHeapAddress = address + size
+
+	default:
+		i := sort.Search(len(m.heapFree), func(i int) bool { return m.heapFree[i].Address >= end })
+		if i == len(m.heapFree) || m.heapFree[i].Address != end {
+			return false
+		}
+		extra := size - alloc.Size
+		next := &m.heapFree[i]
+		switch {
+		case next.Size > extra:
+			next.Address += extra
+			next.Size -= extra
+		case next.Size == extra:
+			m.heapFree = append(m.heapFree[:i], m.heapFree[i+1:]...)
+		case next.Address+next.Size == m.NextHeapAddress &&
+			extra-next.Size < HeapSegmentStart+HeapSegmentSize-m.NextHeapAddress:
+			// The free block reaches the top of the heap, which supplies the rest
+			m.heapFree = m.heapFree[:i]
+			m.NextHeapAddress = address + size
+		default:
+			return false
+		}
+	}
+
+	for i := alloc.Size; i < size; i++ {
+		_ = m.WriteByteAt(address+i, 0) // Ignore error - address is guaranteed valid
+	}
+	alloc.Size = size
+	return true
+}
+
+// releaseHeap returns a region to the free list, coalescing it with the blocks either
+// side. A region that ends at the top of the heap lowers NextHeapAddress instead.
+func (m *Memory) releaseHeap(address, size uint32) {
+	i := sort.Search(len(m.heapFree), func(i int) bool { return m.heapFree[i].Address > address })
+
+	block := heapBlock{Address: address, Size: size}
+	if i > 0 && m.heapFree[i-1].Address+m.heapFree[i-1].Size == address {
+		i--
+		block.Address = m.heapFree[i].Address
+		block.Size += m.heapFree[i].Size
+		m.heapFree = append(m.heapFree[:i], m.heapFree[i+1:]...)
+	}
+	if i < len(m.heapFree) && block.Address+block.Size == m.heapFree[i].Address {
+		block.Size += m.heapFree[i].Size
+		m.heapFree = append(m.heapFree[:i], m.heapFree[i+1:]...)
+	}
+
+	if block.Address+block.Size == m.NextHeapAddress {
+		m.NextHeapAddress = block.Address
+		return
+	}
+	m.heapFree = append(m.heapFree, heapBlock{})
+	copy(m.heapFree[i+1:], m.heapFree[i:])
+	m.heapFree[i] = block
+}
+
+// rebuildHeapFreeList recomputes the free list from HeapAllocations and NextHeapAddress,
+// for use after either has been replaced (snapshot restore, StepBack)
+func (m *Memory) rebuildHeapFreeList() {
+	allocs := make([]*HeapAllocation, 0, len(m.HeapAllocations))
+	for _, alloc := range m.HeapAllocations {
+		allocs = append(allocs, alloc)
+	}
+	sort.Slice(allocs, func(i, j int) bool { return allocs[i].Address < allocs[j].Address })
+
+	m.heapFree = nil
+	next := uint32(HeapSegmentStart)
+	for _, alloc := range allocs {
+		if alloc.Address > next {
+			m.heapFree = append(m.heapFree, heapBlock{Address: next, Size: alloc.Address - next})
+		}
+		next = alloc.Address + alloc.Size
+	}
+	if m.NextHeapAddress > next {
+		m.heapFree = append(m.heapFree, heapBlock{Address: next, Size: m.NextHeapAddress - next})
+	}
+}
+
+// HeapInUse returns the bytes currently allocated on the heap and the number of blocks
+func (m *Memory) HeapInUse() (bytes uint32, blocks int) {
+	for _, alloc := range m.HeapAllocations {
+		bytes += alloc.Size
+	}
+	return bytes, len(m.HeapAllocations)
+}
+
 // ResetHeap resets the heap allocator
 func (m *Memory) ResetHeap() {
 	m.HeapAllocations = make(map[uint32]*HeapAllocation)
 	m.NextHeapAddress = HeapSegmentStart
+	m.heapFree = nil
 }
@@ -88,11 +88,15 @@ func ExecuteLoadStoreMultiple(vm *VM, inst *Instruction) error {
 			if err != nil {
 				return fmt.Errorf("load multiple failed at 0x%08X: %w", addr, err)
 			}
+			vm.notifyMemoryAccess(addr, MultiRegisterWordSize, false)
 
 			// Record memory trace if enabled
 			if vm.MemoryTrace != nil {
 				vm.MemoryTrace.RecordRead(vm.CPU.Cycles, vm.CPU.PC, addr, value, "WORD")
 			}
+			if vm.MemoryHeatmap != nil {
+				vm.MemoryHeatmap.RecordRead(addr)
+			}
 
 			// If loading to SP (R13), use SetSPWithTrace for bounds validation
 			if i == SP {
@@ -125,11 +129,15 @@ func ExecuteLoadStoreMultiple(vm *VM, inst *Instruction) error {
 			// Track last memory write for GUI
 			vm.LastMemoryWrite = addr
 			vm.HasMemoryWrite = true
+			vm.notifyMemoryAccess(addr, MultiRegisterWordSize, true)
 
 			// Record memory trace if enabled
 			if vm.MemoryTrace != nil {
 				vm.MemoryTrace.RecordWrite(vm.CPU.Cycles, vm.CPU.PC, addr, value, "WORD")
 			}
+			if vm.MemoryHeatmap != nil {
+				vm.MemoryHeatmap.RecordWrite(addr)
+			}
 		}
 
 		addr += MultiRegisterWordSize
@@ -153,7 +161,8 @@ func ExecuteLoadStoreMultiple(vm *VM, inst *Instruction) error {
 	if load == 1 && (regList&(1<<ARMRegisterSP)) != 0 && rn != ARMRegisterSP && vm.StackTrace != nil {
 		// SP was loaded from memory, record as SP move and check for overflow
 		if vm.StackTrace.RecordSPMove(vm.CPU.Cycles, inst.Address, baseAddr, vm.CPU.GetSP()) {
-			return fmt.Errorf("stack overflow: SP=0x%08X crossed into heap segment (below 0x%08X)", vm.CPU.GetSP(), vm.StackTrace.StackTop)
+			return newStackError(inst.Address, vm.CPU.GetSP(), vm.StackTrace.StackTop, true,
+				"SP=0x%08X crossed into heap segment (below 0x%08X)", vm.CPU.GetSP(), vm.StackTrace.StackTop)
 		}
 	}
 
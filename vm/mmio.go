@@ -0,0 +1,139 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+)
+
+// MMIODevice is a peripheral mapped into the address space.
+// Offsets are relative to the start of the mapped region. Word accesses are
+// always aligned (the usual alignment checks run first). Byte and halfword
+// reads are served from the containing word; byte and halfword writes are
+// passed through zero-extended with their exact offset so the device can
+// tell which lane was written.
+type MMIODevice interface {
+	ReadWord(offset uint32) (uint32, error)
+	WriteWord(offset uint32, value uint32) error
+}
+
+// MapDevice maps a device over an address range. Accesses in the range invoke
+// the device instead of touching backing storage. Permission and alignment
+// checks apply exactly as they do for ordinary segments.
+func (m *Memory) MapDevice(name string, start, size uint32, permissions MemoryPermission, device MMIODevice) error {
+	if device == nil {
+		return fmt.Errorf("cannot map nil device '%s'", name)
+	}
+	if size == 0 {
+		return fmt.Errorf("cannot map device '%s' with zero size", name)
+	}
+	if size-1 > Address32BitMax-start {
+		return fmt.Errorf("device '%s' at 0x%08X with size 0x%X overflows address space", name, start, size)
+	}
+
+	end := start + (size - 1)
+	for _, seg := range m.Segments {
+		segEnd := seg.Start + (seg.Size - 1)
+		if start <= segEnd && seg.Start <= end {
+			return fmt.Errorf("device '%s' at 0x%08X overlaps segment '%s'", name, start, seg.Name)
+		}
+	}
+
+	m.Segments = append(m.Segments, &MemorySegment{
+		Start:       start,
+		Size:        size,
+		Permissions: permissions,
+		Name:        name,
+		Device:      device,
+	})
+	return nil
+}
+
+// checkDeviceBounds verifies an access of the given width fits inside a device segment
+func checkDeviceBounds(seg *MemorySegment, offset, width uint32, address uint32) error {
+	if offset+(width-1) >= seg.Size {
+		return fmt.Errorf("access exceeds device '%s' bounds at 0x%08X", seg.Name, address)
+	}
+	return nil
+}
+
+// deviceLaneShift returns the bit shift of a sub-word lane within its containing word
+func (m *Memory) deviceLaneShift(offset, width uint32) uint32 {
+	lane := offset & AlignMaskWord
+	if m.LittleEndian {
+		return lane * ByteShift8
+	}
+	return (AlignmentWord - width - lane) * ByteShift8
+}
+
+// readDevice reads width bytes (1, 2 or 4) from a device segment
+func (m *Memory) readDevice(seg *MemorySegment, offset, width uint32, address uint32) (uint32, error) {
+	if err := checkDeviceBounds(seg, offset, width, address); err != nil {
+		return 0, err
+	}
+
+	m.AccessCount++
+	m.ReadCount++
+
+	word, err := seg.Device.ReadWord(offset &^ AlignMaskWord)
+	if err != nil {
+		return 0, fmt.Errorf("device '%s' read at 0x%08X failed: %w", seg.Name, address, err)
+	}
+	if width == AlignmentWord {
+		return word, nil
+	}
+
+	mask := uint32(1)<<(width*ByteShift8) - 1
+	return (word >> m.deviceLaneShift(offset, width)) & mask, nil
+}
+
+// writeDevice writes width bytes (1, 2 or 4) to a device segment
+func (m *Memory) writeDevice(seg *MemorySegment, offset, width uint32, address uint32, value uint32) error {
+	if err := checkDeviceBounds(seg, offset, width, address); err != nil {
+		return err
+	}
+
+	m.AccessCount++
+	m.WriteCount++
+
+	if err := seg.Device.WriteWord(offset, value); err != nil {
+		return fmt.Errorf("device '%s' write at 0x%08X failed: %w", seg.Name, address, err)
+	}
+	return nil
+}
+
+// UART is a minimal transmit-only serial port that writes bytes to the VM's OutputWriter
+// and output sinks. Writing the data register transmits its low byte; the status register
+// always reports the transmitter as ready. Reads of the data register return 0 (no receive path).
+type UART struct {
+	vm *VM
+}
+
+// NewUART creates a UART bound to the given VM's output
+func NewUART(machine *VM) *UART {
+	return &UART{vm: machine}
+}
+
+// ReadWord reads a UART register
+func (u *UART) ReadWord(offset uint32) (uint32, error) {
+	switch offset {
+	case UARTStatusOffset:
+		return UARTStatusTxReady, nil
+	default:
+		return 0, nil
+	}
+}
+
+// WriteWord writes a UART register
+func (u *UART) WriteWord(offset uint32, value uint32) error {
+	if offset != UARTDataOffset {
+		return nil // Status and reserved registers ignore writes
+	}
+
+	if _, err := u.vm.Output().Write([]byte{byte(value)}); err != nil { // #nosec G115 -- low byte is the transmitted character
+		return fmt.Errorf("uart write failed: %w", err)
+	}
+	if f, ok := u.vm.OutputWriter.(*os.File); ok && shouldSyncFile(f) {
+		_ = f.Sync() // Ignore sync errors on stdout
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package vm
+
+import (
+	"io"
+	"slices"
+)
+
+// AddOutputWriter registers an extra sink that receives a copy of everything console
+// syscalls and the UART write to OutputWriter, e.g. a capture buffer alongside the
+// screen. Sinks may be added and removed while the VM runs.
+func (vm *VM) AddOutputWriter(w io.Writer) {
+	vm.outputMu.Lock()
+	defer vm.outputMu.Unlock()
+	// Copy on write, so a fan-out already in progress keeps its own slice
+	vm.outputSinks = append(slices.Clip(vm.outputSinks), w)
+}
+
+// RemoveOutputWriter unregisters a sink added by AddOutputWriter, reporting whether it
+// was registered
+func (vm *VM) RemoveOutputWriter(w io.Writer) bool {
+	vm.outputMu.Lock()
+	defer vm.outputMu.Unlock()
+	i := slices.Index(vm.outputSinks, w)
+	if i < 0 {
+		return false
+	}
+	vm.outputSinks = slices.Delete(slices.Clone(vm.outputSinks), i, i+1)
+	return true
+}
+
+// sinks returns the registered sinks. The slice is never modified in place.
+func (vm *VM) sinks() []io.Writer {
+	vm.outputMu.Lock()
+	defer vm.outputMu.Unlock()
+	return vm.outputSinks
+}
+
+// Output returns the writer console output goes to: OutputWriter alone, or a fan-out
+// to it and every sink
+func (vm *VM) Output() io.Writer {
+	sinks := vm.sinks()
+	if len(sinks) == 0 {
+		return vm.OutputWriter
+	}
+	writers := make(fanoutWriter, 0, len(sinks)+1)
+	if vm.OutputWriter != nil {
+		writers = append(writers, vm.OutputWriter)
+	}
+	return append(writers, sinks...)
+}
+
+// copyToSinks gives the sinks output that bypassed OutputWriter, such as SWI_WRITE to
+// the real stdout or stderr
+func (vm *VM) copyToSinks(p []byte) {
+	_, _ = fanoutWriter(vm.sinks()).Write(p) // Sink errors do not fail the write
+}
+
+// fanoutWriter writes to every writer in turn. Unlike io.MultiWriter, a failing writer
+// does not stop the rest from receiving the data; the first error is returned.
+type fanoutWriter []io.Writer
+
+func (f fanoutWriter) Write(p []byte) (int, error) {
+	var firstErr error
+	for _, w := range f {
+		n, err := w.Write(p)
+		if err == nil && n < len(p) {
+			err = io.ErrShortWrite
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return len(p), nil
+}
@@ -0,0 +1,301 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FunctionProfile holds the cycles attributed to one function
+type FunctionProfile struct {
+	Name        string `json:"name"`
+	Address     uint32 `json:"address"`
+	Calls       uint64 `json:"calls"`
+	SelfCycles  uint64 `json:"self_cycles"`  // Cycles spent in the function's own instructions
+	TotalCycles uint64 `json:"total_cycles"` // Self cycles plus cycles spent in callees
+}
+
+// CallEdge is a caller→callee edge in the call graph
+type CallEdge struct {
+	Caller   string `json:"caller"`
+	Callee   string `json:"callee"`
+	Calls    uint64 `json:"calls"`
+	Cycles   uint64 `json:"cycles"` // Inclusive cycles spent in the callee when called from the caller
+	TailCall bool   `json:"tail_call,omitempty"`
+}
+
+type callEdgeKey struct {
+	caller, callee string
+}
+
+// profileFrame is an activation on the profiler's shadow call stack
+type profileFrame struct {
+	function    string
+	caller      string
+	returnAddr  uint32
+	startCycles uint64
+	tailCall    bool
+}
+
+// Profiler builds a call graph from BL/BLX calls and returns to the link address,
+// attributing cycles to functions named by the nearest preceding symbol
+type Profiler struct {
+	Enabled bool
+
+	functions map[string]*FunctionProfile
+	edges     map[callEdgeKey]*CallEdge
+	entries   map[uint32]bool // Addresses known to start a function (call targets and the root)
+
+	stack []profileFrame
+	// Active activations per function and edge, so recursive calls are not counted twice
+	activeFunctions map[string]int
+	activeEdges     map[callEdgeKey]int
+
+	lastCycles  uint64
+	totalCycles uint64
+
+	symbols *SymbolResolver
+}
+
+// NewProfiler creates a new call-graph profiler
+func NewProfiler() *Profiler {
+	p := &Profiler{
+		Enabled: true,
+		symbols: NewSymbolResolver(nil),
+	}
+	p.Start()
+	return p
+}
+
+// LoadSymbols loads the symbol table used to name functions
+func (p *Profiler) LoadSymbols(symbols map[string]uint32) {
+	p.symbols = NewSymbolResolver(symbols)
+}
+
+// Start clears all profile data
+func (p *Profiler) Start() {
+	p.functions = make(map[string]*FunctionProfile)
+	p.edges = make(map[callEdgeKey]*CallEdge)
+	p.entries = make(map[uint32]bool)
+	p.stack = nil
+	p.activeFunctions = make(map[string]int)
+	p.activeEdges = make(map[callEdgeKey]int)
+	p.lastCycles = 0
+	p.totalCycles = 0
+}
+
+// functionAt names the function containing addr and returns its start address
+func (p *Profiler) functionAt(addr uint32) (string, uint32) {
+	name, offset, found := p.symbols.ResolveAddress(addr)
+	if !found {
+		return fmt.Sprintf("0x%08x", addr), addr
+	}
+	return name, addr - offset
+}
+
+func (p *Profiler) function(name string, addr uint32) *FunctionProfile {
+	fn, exists := p.functions[name]
+	if !exists {
+		fn = &FunctionProfile{Name: name, Address: addr}
+		p.functions[name] = fn
+	}
+	return fn
+}
+
+// RecordInstruction records an executed instruction. nextPC is the PC after execution
+// and cycles is the CPU cycle counter after execution.
+func (p *Profiler) RecordInstruction(inst *Instruction, nextPC uint32, cycles uint64) {
+	if !p.Enabled {
+		return
+	}
+	pc := inst.Address
+
+	// The first instruction seen belongs to the root function
+	if len(p.stack) == 0 {
+		name, addr := p.functionAt(pc)
+		p.entries[addr] = true
+		p.function(name, addr).Calls++
+		p.enter(profileFrame{function: name, startCycles: p.lastCycles})
+	}
+
+	// Attribute the cycles since the last record (including skipped conditional instructions)
+	delta := cycles - p.lastCycles
+	p.lastCycles = cycles
+	p.totalCycles += delta
+	top := &p.stack[len(p.stack)-1]
+	p.functions[top.function].SelfCycles += delta
+
+	isBranch := inst.Type == InstBranch
+	isBX := inst.Opcode&BXPatternMask == BXEncodingBase
+	isBLX := inst.Opcode&BXPatternMask == BLXEncodingBase
+	isBL := isBranch && !isBX && !isBLX && (inst.Opcode>>BranchLinkShift)&Mask1Bit == 1
+
+	switch {
+	case isBL || isBLX:
+		p.call(top.function, nextPC, pc+ARMInstructionSize, false)
+	case p.returnTo(nextPC):
+		// Returned to an active caller
+	case isBranch && !isBLX && p.entries[nextPC] && nextPC != p.functions[top.function].Address:
+		// Branch without link to a known function: the callee returns to our caller
+		p.tailCall(nextPC)
+	}
+}
+
+// call pushes a frame for a call from caller to target
+func (p *Profiler) call(caller string, target, returnAddr uint32, tailCall bool) {
+	name, addr := p.functionAt(target)
+	p.entries[target] = true
+	p.function(name, addr).Calls++
+
+	key := callEdgeKey{caller, name}
+	edge, exists := p.edges[key]
+	if !exists {
+		edge = &CallEdge{Caller: caller, Callee: name}
+		p.edges[key] = edge
+	}
+	edge.Calls++
+	edge.TailCall = edge.TailCall || tailCall
+
+	if len(p.stack) >= MaxProfilerCallDepth {
+		return
+	}
+	p.enter(profileFrame{function: name, caller: caller, returnAddr: returnAddr, startCycles: p.lastCycles, tailCall: tailCall})
+}
+
+// tailCall replaces the current frame with the branch target, keeping its return address
+func (p *Profiler) tailCall(target uint32) {
+	top := p.stack[len(p.stack)-1]
+	p.leave()
+	p.call(top.function, target, top.returnAddr, true)
+}
+
+// returnTo unwinds the stack to the frame whose return address is addr, if any.
+// Frames above it are popped too, which covers returns that skip a level (longjmp-style).
+func (p *Profiler) returnTo(addr uint32) bool {
+	for i := len(p.stack) - 1; i > 0; i-- {
+		if p.stack[i].returnAddr == addr {
+			for len(p.stack) > i {
+				p.leave()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Profiler) enter(frame profileFrame) {
+	p.stack = append(p.stack, frame)
+	p.activeFunctions[frame.function]++
+	if frame.caller != "" {
+		p.activeEdges[callEdgeKey{frame.caller, frame.function}]++
+	}
+}
+
+// leave pops the top frame, adding its inclusive cycles to the outermost activation only
+func (p *Profiler) leave() {
+	frame := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	elapsed := p.lastCycles - frame.startCycles
+
+	p.activeFunctions[frame.function]--
+	if p.activeFunctions[frame.function] == 0 {
+		p.functions[frame.function].TotalCycles += elapsed
+	}
+	if frame.caller != "" {
+		key := callEdgeKey{frame.caller, frame.function}
+		p.activeEdges[key]--
+		if p.activeEdges[key] == 0 {
+			p.edges[key].Cycles += elapsed
+		}
+	}
+}
+
+// Finalize closes any frames still active (e.g. when the program exits from inside a call)
+func (p *Profiler) Finalize() {
+	for len(p.stack) > 0 {
+		p.leave()
+	}
+}
+
+// Functions returns the profiled functions, most expensive (inclusive) first
+func (p *Profiler) Functions() []*FunctionProfile {
+	functions := make([]*FunctionProfile, 0, len(p.functions))
+	for _, fn := range p.functions {
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].TotalCycles != functions[j].TotalCycles {
+			return functions[i].TotalCycles > functions[j].TotalCycles
+		}
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
+
+// Function returns the profile for a function name
+func (p *Profiler) Function(name string) (*FunctionProfile, bool) {
+	fn, exists := p.functions[name]
+	return fn, exists
+}
+
+// Edges returns the call graph edges, most expensive first
+func (p *Profiler) Edges() []*CallEdge {
+	edges := make([]*CallEdge, 0, len(p.edges))
+	for _, edge := range p.edges {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Cycles != edges[j].Cycles {
+			return edges[i].Cycles > edges[j].Cycles
+		}
+		if edges[i].Caller != edges[j].Caller {
+			return edges[i].Caller < edges[j].Caller
+		}
+		return edges[i].Callee < edges[j].Callee
+	})
+	return edges
+}
+
+// ExportJSON exports the call graph as JSON
+func (p *Profiler) ExportJSON(w io.Writer) error {
+	p.Finalize()
+
+	data := map[string]interface{}{
+		"total_cycles": p.totalCycles,
+		"functions":    p.Functions(),
+		"edges":        p.Edges(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// String returns a formatted string representation
+func (p *Profiler) String() string {
+	p.Finalize()
+
+	var sb strings.Builder
+
+	sb.WriteString("Function Profile\n")
+	sb.WriteString("================\n\n")
+	sb.WriteString(fmt.Sprintf("Total Cycles: %d\n\n", p.totalCycles))
+
+	sb.WriteString(fmt.Sprintf("  %-20s %8s %12s %12s\n", "Function", "Calls", "Self", "Total"))
+	for _, fn := range p.Functions() {
+		sb.WriteString(fmt.Sprintf("  %-20s %8d %12d %12d\n", fn.Name, fn.Calls, fn.SelfCycles, fn.TotalCycles))
+	}
+
+	sb.WriteString("\nCall Graph:\n")
+	for _, edge := range p.Edges() {
+		kind := ""
+		if edge.TailCall {
+			kind = " (tail call)"
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s: %d call(s), %d cycles%s\n", edge.Caller, edge.Callee, edge.Calls, edge.Cycles, kind))
+	}
+
+	return sb.String()
+}
@@ -9,7 +9,7 @@ func ExecutePSRTransfer(vm *VM, inst *Instruction) error {
 	// MRS/MSR instruction format:
 	// Bits [27:26] = 00
 	// Bit [25] = 1 (distinguishes from other instructions)
-	// Bit [22] = PSR type (0=CPSR, 1=SPSR) - we only support CPSR for now
+	// Bit [22] = PSR type (0=CPSR, 1=SPSR, the CPSR saved when an IRQ is taken)
 	// Bit [21] = Direction (0=MRS read PSR, 1=MSR write PSR)
 
 	isMSR := (inst.Opcode >> MultiplyAShift) & Mask1Bit // 1=MSR, 0=MRS
@@ -20,9 +20,17 @@ func ExecutePSRTransfer(vm *VM, inst *Instruction) error {
 	return executeMSR(vm, inst)
 }
 
+// transferPSR returns the PSR an MRS or MSR names: CPSR, or SPSR when bit 22 is set
+func transferPSR(vm *VM, inst *Instruction) *CPSR {
+	if (inst.Opcode>>PSRSPSRBit)&Mask1Bit == 1 {
+		return &vm.CPU.SPSR
+	}
+	return &vm.CPU.CPSR
+}
+
 // executeMRS implements MRS (Move PSR to Register)
 // Syntax: MRS{cond} Rd, PSR
-// Reads CPSR into a general-purpose register
+// Reads CPSR or SPSR into a general-purpose register
 func executeMRS(vm *VM, inst *Instruction) error {
 	rd := int((inst.Opcode >> RdShift) & Mask4Bit) // Destination register
 
@@ -31,8 +39,8 @@ func executeMRS(vm *VM, inst *Instruction) error {
 		return fmt.Errorf("MRS: R15 (PC) cannot be used as destination register")
 	}
 
-	// Read CPSR value
-	cpsrValue := vm.CPU.CPSR.ToUint32()
+	// Read PSR value
+	cpsrValue := transferPSR(vm, inst).ToUint32()
 
 	// Store in destination register - if destination is SP, use SetSPWithTrace for bounds validation
 	if rd == SP {
@@ -53,8 +61,8 @@ func executeMRS(vm *VM, inst *Instruction) error {
 }
 
 // executeMSR implements MSR (Move Register/Immediate to PSR)
-// Syntax: MSR{cond} PSR, Rm
-// Writes a general-purpose register value to CPSR
+// Syntax: MSR{cond} PSR_fields, Rm|#imm
+// Writes a general-purpose register or immediate value to CPSR or SPSR
 func executeMSR(vm *VM, inst *Instruction) error {
 	// Check if immediate or register source
 	immediateBit := (inst.Opcode >> IBitShift) & Mask1Bit
@@ -83,14 +91,12 @@ func executeMSR(vm *VM, inst *Instruction) error {
 		sourceValue = vm.CPU.GetRegister(rm)
 	}
 
-	// Check which fields to update (bit 16-19 specify field mask)
-	// For ARM2/basic implementation, we only update the flag bits (bits 31-28)
-	// More advanced implementations might support updating mode bits, etc.
-
-	// Update CPSR from source value
-	// Only update the flag bits (NZCV) in bits 31-28
-	// This is a safety measure to prevent mode changes in this basic implementation
-	vm.CPU.CPSR.FromUint32(sourceValue)
+	// Bits 19-16 select the fields to update. Only the flags field (f) holds anything
+	// modelled here, N, Z, C, V and Q; the control, extension and status fields have no
+	// mode or interrupt bits to change, so writes to them are ignored.
+	if (inst.Opcode>>PSRFieldShift)&PSRFieldFlags != 0 {
+		transferPSR(vm, inst).FromUint32(sourceValue)
+	}
 
 	// Increment PC
 	vm.CPU.IncrementPC()
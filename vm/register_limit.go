@@ -0,0 +1,128 @@
+package vm
+
+import "fmt"
+
+// A reduced register set models the simplified machines some courses teach with: only
+// R0 to RegisterCount-1 exist, plus SP, LR and PC, which are always available. An
+// instruction naming any other register is undefined on such a machine, which catches
+// typos like R9 for R0 in student code.
+
+// SetRegisterCount limits instructions to R0 through R(count-1) plus SP, LR and PC.
+// The default of ARMTotalRegisterCount allows every register.
+func (vm *VM) SetRegisterCount(count int) error {
+	if count < 1 || count > ARMTotalRegisterCount {
+		return fmt.Errorf("register count must be between 1 and %d, got %d", ARMTotalRegisterCount, count)
+	}
+	vm.registerCount = count
+
+	// Instructions decoded under the previous limit must be checked again
+	for _, seg := range vm.Memory.Segments {
+		seg.clearDecoded()
+	}
+	return nil
+}
+
+// RegisterCount returns the number of visible general-purpose registers
+func (vm *VM) RegisterCount() int {
+	return vm.registerCount
+}
+
+// checkRegisterLimit rejects an instruction that names a register outside the visible set
+func (vm *VM) checkRegisterLimit(inst *Instruction) error {
+	if vm.registerCount >= SP {
+		return nil
+	}
+	for _, reg := range instructionRegisters(inst) {
+		if reg >= vm.registerCount && reg < SP {
+			visible := "R0"
+			if vm.registerCount > 1 {
+				visible = fmt.Sprintf("R0-R%d", vm.registerCount-1)
+			}
+			return &UndefinedInstructionError{Opcode: inst.Opcode,
+				Reason: fmt.Sprintf("R%d is not available: this machine has only %s plus SP, LR and PC", reg, visible)}
+		}
+	}
+	return nil
+}
+
+// instructionRegisters lists the registers an instruction reads or writes, from the
+// fields its executor decodes. Fields an instruction ignores (such as Rn of MOV) are left
+// out, since the assembler fills them with zero.
+func instructionRegisters(inst *Instruction) []int {
+	op := inst.Opcode
+	field := func(shift int) int { return int((op >> shift) & Mask4Bit) }
+	rn, rd, rs, rm := field(RnShift), field(RdShift), field(RsShift), field(0)
+
+	switch inst.Type {
+	case InstDataProcessing:
+		var regs []int
+		switch opcode := (op >> OpcodeShift) & Mask4Bit; {
+		case opcode == OpMOV || opcode == OpMVN:
+			regs = append(regs, rd)
+		case opcode >= OpTST && opcode <= OpCMN:
+			regs = append(regs, rn)
+		default:
+			regs = append(regs, rn, rd)
+		}
+		if (op>>IBitShift)&Mask1Bit == 0 {
+			regs = append(regs, rm)
+			if (op>>Bit4Pos)&Mask1Bit == 1 {
+				regs = append(regs, rs)
+			}
+		}
+		return regs
+
+	case InstMultiply:
+		// MUL/MLA put Rd in bits 19-16 and the accumulator in 15-12; the long forms put
+		// RdHi and RdLo there
+		if (op>>Bits27_23Shift)&LongMultiplyMask5 == 1 || (op>>MultiplyAShift)&Mask1Bit == 1 {
+			return []int{rn, rd, rs, rm}
+		}
+		return []int{rn, rs, rm}
+
+	case InstLoadStore:
+		regs := []int{rn, rd}
+		if (op>>Bits27_25Shift)&Mask3Bit == 0 {
+			// Halfword transfers have a register offset when bit 22 is clear
+			if (op>>BBitShift)&Mask1Bit == 0 {
+				regs = append(regs, rm)
+			}
+		} else if (op>>IBitShift)&Mask1Bit == 1 {
+			regs = append(regs, rm)
+		}
+		return regs
+
+	case InstLoadStoreMultiple:
+		regs := []int{rn}
+		for reg := 0; reg < ARMTotalRegisterCount; reg++ {
+			if op&(1<<reg) != 0 {
+				regs = append(regs, reg)
+			}
+		}
+		return regs
+
+	case InstBranch:
+		if op&BXPatternMask == BXEncodingBase || op&BXPatternMask == BLXEncodingBase {
+			return []int{rm}
+		}
+		return nil
+
+	case InstPSRTransfer:
+		switch {
+		case op&MRSMask == MRSPattern:
+			return []int{rd}
+		case op&MSRRegMask == MSRRegPattern:
+			return []int{rm}
+		}
+		return nil
+
+	case InstSaturating:
+		return []int{rn, rd, rm}
+
+	case InstDivide:
+		return []int{rn, rs, rm}
+
+	default:
+		return nil
+	}
+}
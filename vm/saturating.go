@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExecuteSaturating executes saturating arithmetic instructions (QADD, QSUB)
+// Syntax: QADD{cond} Rd, Rm, Rn computes Rd = sat(Rm + Rn); QSUB computes Rd = sat(Rm - Rn)
+// Results are clamped to the signed 32-bit range. On saturation the sticky Q flag
+// is set; N, Z, C and V are never affected.
+func ExecuteSaturating(vm *VM, inst *Instruction) error {
+	rd := int((inst.Opcode >> RdShift) & Mask4Bit)
+	rn := int((inst.Opcode >> RnShift) & Mask4Bit)
+	rm := int(inst.Opcode & Mask4Bit)
+
+	// R15 (PC) is unpredictable for all operands
+	if rd == ARMRegisterPC || rn == ARMRegisterPC || rm == ARMRegisterPC {
+		return fmt.Errorf("saturating arithmetic: R15 (PC) cannot be used as an operand")
+	}
+
+	a := int64(AsInt32(vm.CPU.GetRegister(rm)))
+	b := int64(AsInt32(vm.CPU.GetRegister(rn)))
+
+	var result int64
+	if (inst.Opcode & SaturatingMask) == QSUBPattern {
+		result = a - b
+	} else {
+		result = a + b
+	}
+
+	value, saturated := saturateInt32(result)
+	if saturated {
+		vm.CPU.CPSR.Q = true
+	}
+
+	// Store result - if destination is SP, use SetSPWithTrace for bounds validation
+	if rd == SP {
+		if err := vm.CPU.SetSPWithTrace(vm, value, inst.Address); err != nil {
+			vm.State = StateError
+			vm.LastError = err
+			return err
+		}
+	} else {
+		vm.CPU.SetRegister(rd, value)
+	}
+
+	vm.CPU.IncrementPC()
+	return nil
+}
+
+// saturateInt32 clamps a 64-bit value to the signed 32-bit range, reporting whether clamping occurred
+func saturateInt32(value int64) (uint32, bool) {
+	switch {
+	case value > math.MaxInt32:
+		return uint32(math.MaxInt32), true
+	case value < math.MinInt32:
+		return SignBitMask, true
+	default:
+		return uint32(int32(value)), false // #nosec G115 -- value is within int32 range; two's complement conversion
+	}
+}
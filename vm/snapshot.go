@@ -0,0 +1,411 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stateSnapshot is the JSON form of a complete machine state written by ExportState
+type stateSnapshot struct {
+	Version          int               `json:"version"`
+	Registers        [15]uint32        `json:"registers"` // R0-R14
+	PC               uint32            `json:"pc"`
+	CPSR             uint32            `json:"cpsr"`
+	SPSR             uint32            `json:"spsr"`
+	Cycles           uint64            `json:"cycles"`
+	Instructions     uint64            `json:"instructions,omitempty"`
+	State            ExecutionState    `json:"state"`
+	EntryPoint       uint32            `json:"entry_point"`
+	StackTop         uint32            `json:"stack_top"`
+	ExitCode         int32             `json:"exit_code"`
+	ProgramArguments []string          `json:"program_arguments,omitempty"`
+	Environment      []string          `json:"environment,omitempty"`
+	LittleEndian     bool              `json:"little_endian"`
+	StrictAlign      bool              `json:"strict_align"`
+	Segments         []segmentSnapshot `json:"segments"`
+	NextHeapAddress  uint32            `json:"next_heap_address"`
+	HeapAllocations  []HeapAllocation  `json:"heap_allocations"`
+	Files            []fileSnapshot    `json:"files,omitempty"`
+	Timer            timerSnapshot     `json:"timer"`
+}
+
+// timerSnapshot holds the interval timer, including an interrupt being handled
+type timerSnapshot struct {
+	Period   uint64 `json:"period"`
+	Vector   uint32 `json:"vector"`
+	Due      uint64 `json:"due"`
+	Count    uint64 `json:"count"`
+	InIRQ    bool   `json:"in_irq"`
+	ReturnPC uint32 `json:"return_pc"`
+}
+
+// segmentSnapshot holds a memory segment's layout and its non-zero contents
+type segmentSnapshot struct {
+	Name        string           `json:"name"`
+	Start       uint32           `json:"start"`
+	Size        uint32           `json:"size"`
+	Permissions MemoryPermission `json:"permissions"`
+	Chunks      []memoryChunk    `json:"chunks,omitempty"`
+}
+
+// memoryChunk is a run of bytes at an offset within a segment, base64-encoded
+type memoryChunk struct {
+	Offset uint32 `json:"offset"`
+	Data   string `json:"data"`
+}
+
+// fileSnapshot records an open guest file descriptor so it can be reopened on import.
+// Path is relative to the filesystem root, as the guest would name it.
+type fileSnapshot struct {
+	FD     uint32 `json:"fd"`
+	Path   string `json:"path"`
+	Mode   uint32 `json:"mode"` // FileMode* the guest opened it with
+	Offset int64  `json:"offset"`
+}
+
+// ExportState writes the complete machine state as JSON: registers, CPSR and SPSR, memory
+// segments, heap allocations, the interval timer and open files. Memory-mapped device
+// regions are skipped since they have no backing storage. Tracing and statistics are not
+// included.
+func (vm *VM) ExportState(w io.Writer) error {
+	snap := stateSnapshot{
+		Version:          SnapshotVersion,
+		PC:               vm.CPU.PC,
+		CPSR:             vm.CPU.CPSR.ToUint32(),
+		SPSR:             vm.CPU.SPSR.ToUint32(),
+		Cycles:           vm.CPU.Cycles,
+		Instructions:     vm.CPU.Instructions,
+		State:            vm.State,
+		EntryPoint:       vm.EntryPoint,
+		StackTop:         vm.StackTop,
+		ExitCode:         vm.ExitCode,
+		ProgramArguments: vm.ProgramArguments,
+		Environment:      vm.Environment,
+		LittleEndian:     vm.Memory.LittleEndian,
+		StrictAlign:      vm.Memory.StrictAlign,
+		NextHeapAddress:  vm.Memory.NextHeapAddress,
+		Timer:            timerSnapshot(vm.Timer),
+	}
+	copy(snap.Registers[:], vm.CPU.R[:])
+
+	for _, seg := range vm.Memory.Segments {
+		if seg.Device != nil {
+			continue
+		}
+		snap.Segments = append(snap.Segments, segmentSnapshot{
+			Name:        seg.Name,
+			Start:       seg.Start,
+			Size:        seg.Size,
+			Permissions: seg.Permissions,
+			Chunks:      compactChunks(seg.Data),
+		})
+	}
+
+	snap.HeapAllocations = make([]HeapAllocation, 0, len(vm.Memory.HeapAllocations))
+	for _, alloc := range vm.Memory.HeapAllocations {
+		snap.HeapAllocations = append(snap.HeapAllocations, *alloc)
+	}
+	sort.Slice(snap.HeapAllocations, func(i, j int) bool {
+		return snap.HeapAllocations[i].Address < snap.HeapAllocations[j].Address
+	})
+
+	files, err := vm.snapshotFiles()
+	if err != nil {
+		return err
+	}
+	snap.Files = files
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&snap); err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	return nil
+}
+
+// ImportState restores machine state written by ExportState. Segments are matched by name
+// and start address; segments missing from the VM (such as low memory added by the loader)
+// are created. Open files are reopened by path and positioned at their saved offset.
+// Everything is checked, and the files opened, before the VM is changed, so a snapshot
+// that cannot be restored leaves the VM as it was.
+func (vm *VM) ImportState(r io.Reader) error {
+	var snap stateSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to decode state: %w", err)
+	}
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported state version %d (expected %d)", snap.Version, SnapshotVersion)
+	}
+
+	segments, err := vm.prepareSegments(snap.Segments)
+	if err != nil {
+		return err
+	}
+	files, err := vm.openSavedFiles(snap.Files)
+	if err != nil {
+		return err
+	}
+
+	for _, prepared := range segments {
+		seg := prepared.segment
+		if seg == nil {
+			vm.Memory.AddSegment(prepared.snap.Name, prepared.snap.Start, prepared.snap.Size, prepared.snap.Permissions)
+			seg = vm.Memory.Segments[len(vm.Memory.Segments)-1]
+		}
+		seg.Permissions = prepared.snap.Permissions
+		copy(seg.Data, prepared.data)
+		seg.clearDecoded()
+	}
+
+	vm.Memory.LittleEndian = snap.LittleEndian
+	vm.Memory.StrictAlign = snap.StrictAlign
+	vm.Memory.NextHeapAddress = snap.NextHeapAddress
+	vm.Memory.HeapAllocations = make(map[uint32]*HeapAllocation, len(snap.HeapAllocations))
+	for _, alloc := range snap.HeapAllocations {
+		a := alloc
+		vm.Memory.HeapAllocations[a.Address] = &a
+	}
+	vm.Memory.rebuildHeapFreeList()
+
+	copy(vm.CPU.R[:], snap.Registers[:])
+	vm.CPU.PC = snap.PC
+	vm.CPU.CPSR.FromUint32(snap.CPSR)
+	vm.CPU.SPSR.FromUint32(snap.SPSR)
+	vm.CPU.Cycles = snap.Cycles
+	vm.CPU.Instructions = snap.Instructions
+	vm.Timer = Timer(snap.Timer)
+	vm.State = snap.State
+	vm.LastError = nil
+	vm.EntryPoint = snap.EntryPoint
+	vm.StackTop = snap.StackTop
+	vm.ExitCode = snap.ExitCode
+	vm.ProgramArguments = snap.ProgramArguments
+	vm.Environment = snap.Environment
+
+	vm.installFiles(files)
+	if vm.History != nil {
+		vm.History.Clear()
+	}
+	return nil
+}
+
+// preparedSegment is a snapshot segment matched to the VM and decoded, ready to apply.
+// segment is nil when the segment has to be created.
+type preparedSegment struct {
+	segment *MemorySegment
+	snap    segmentSnapshot
+	data    []byte
+}
+
+// prepareSegments matches each saved segment to the VM and decodes its contents. Existing
+// segments must have the saved size, and the segments to be created may total at most
+// SnapshotMaxAddedMemory, so a small snapshot cannot make the VM allocate gigabytes.
+func (vm *VM) prepareSegments(saved []segmentSnapshot) ([]preparedSegment, error) {
+	prepared := make([]preparedSegment, len(saved))
+	var added uint64
+	for i, segSnap := range saved {
+		seg := vm.Memory.segmentAt(segSnap.Name, segSnap.Start)
+		if seg == nil {
+			added += uint64(segSnap.Size)
+			if added > SnapshotMaxAddedMemory {
+				return nil, fmt.Errorf("segment '%s': new segments exceed %d bytes", segSnap.Name, SnapshotMaxAddedMemory)
+			}
+		} else if seg.Size != segSnap.Size {
+			return nil, fmt.Errorf("segment '%s' size mismatch: snapshot 0x%X, VM 0x%X", seg.Name, segSnap.Size, seg.Size)
+		}
+
+		data, err := expandChunks(segSnap)
+		if err != nil {
+			return nil, err
+		}
+		prepared[i] = preparedSegment{segment: seg, snap: segSnap, data: data}
+	}
+	return prepared, nil
+}
+
+// segmentAt returns the non-device segment with the given name and start address, or nil
+func (m *Memory) segmentAt(name string, start uint32) *MemorySegment {
+	for _, seg := range m.Segments {
+		if seg.Device == nil && seg.Name == name && seg.Start == start {
+			return seg
+		}
+	}
+	return nil
+}
+
+// compactChunks splits data into runs of bytes separated by long runs of zeros
+func compactChunks(data []byte) []memoryChunk {
+	var chunks []memoryChunk
+	i := 0
+	for i < len(data) {
+		// Skip zeros
+		for i < len(data) && data[i] == 0 {
+			i++
+		}
+		if i == len(data) {
+			break
+		}
+
+		// Extend the chunk until a zero run long enough to split on
+		start, end := i, i
+		for i < len(data) {
+			if data[i] != 0 {
+				i++
+				end = i
+				continue
+			}
+			zeroStart := i
+			for i < len(data) && data[i] == 0 {
+				i++
+			}
+			if i-zeroStart >= SnapshotZeroRunBytes || i == len(data) {
+				break
+			}
+			end = i
+		}
+
+		chunks = append(chunks, memoryChunk{
+			Offset: uint32(start), // #nosec G115 -- segment sizes are uint32
+			Data:   base64.StdEncoding.EncodeToString(data[start:end]),
+		})
+	}
+	return chunks
+}
+
+// expandChunks rebuilds a segment's full contents from its chunks
+func expandChunks(segSnap segmentSnapshot) ([]byte, error) {
+	data := make([]byte, segSnap.Size)
+	for _, chunk := range segSnap.Chunks {
+		bytes, err := base64.StdEncoding.DecodeString(chunk.Data)
+		if err != nil {
+			return nil, fmt.Errorf("segment '%s': invalid chunk at offset 0x%X: %w", segSnap.Name, chunk.Offset, err)
+		}
+		if uint64(chunk.Offset)+uint64(len(bytes)) > uint64(segSnap.Size) {
+			return nil, fmt.Errorf("segment '%s': chunk at offset 0x%X exceeds segment size", segSnap.Name, chunk.Offset)
+		}
+		copy(data[chunk.Offset:], bytes)
+	}
+	return data, nil
+}
+
+// snapshotFiles records the path and position of each open user file descriptor
+func (vm *VM) snapshotFiles() ([]fileSnapshot, error) {
+	vm.fdMu.Lock()
+	defer vm.fdMu.Unlock()
+
+	var files []fileSnapshot
+	for fd := FirstUserFD; fd < len(vm.files); fd++ {
+		f := vm.files[fd]
+		if f == nil {
+			continue
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get position of fd %d: %w", fd, err)
+		}
+		path, err := filepath.Rel(vm.FilesystemRoot, f.Name())
+		if err != nil || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("fd %d (%s) is outside the filesystem root", fd, f.Name())
+		}
+		files = append(files, fileSnapshot{
+			FD:     uint32(fd), // #nosec G115 -- fd is bounded by MaxFileDescriptors
+			Path:   filepath.ToSlash(path),
+			Mode:   vm.fileModes[uint32(fd)], // #nosec G115 -- fd is bounded by MaxFileDescriptors
+			Offset: offset,
+		})
+	}
+	return files, nil
+}
+
+// openedFile is a saved file descriptor reopened by openSavedFiles
+type openedFile struct {
+	fd   uint32
+	mode uint32
+	file *os.File
+}
+
+// openSavedFiles reopens the saved file descriptors at their offsets. If any fails, the
+// ones already opened are closed again.
+func (vm *VM) openSavedFiles(files []fileSnapshot) ([]openedFile, error) {
+	var opened []openedFile
+	fail := func(err error) ([]openedFile, error) {
+		for _, o := range opened {
+			_ = o.file.Close()
+		}
+		return nil, err
+	}
+
+	seen := make(map[uint32]bool, len(files))
+	for _, saved := range files {
+		if saved.FD < FirstUserFD || saved.FD >= MaxFileDescriptors || seen[saved.FD] {
+			return fail(fmt.Errorf("invalid file descriptor %d in state", saved.FD))
+		}
+		seen[saved.FD] = true
+
+		f, err := vm.reopenFile(saved)
+		if err != nil {
+			return fail(fmt.Errorf("failed to reopen fd %d (%s): %w", saved.FD, saved.Path, err))
+		}
+		opened = append(opened, openedFile{fd: saved.FD, mode: saved.Mode, file: f})
+		if _, err := f.Seek(saved.Offset, io.SeekStart); err != nil {
+			return fail(fmt.Errorf("failed to seek fd %d (%s): %w", saved.FD, saved.Path, err))
+		}
+	}
+	return opened, nil
+}
+
+// installFiles closes the current user file descriptors and replaces them with files
+func (vm *VM) installFiles(files []openedFile) {
+	vm.fdMu.Lock()
+	defer vm.fdMu.Unlock()
+
+	for fd := FirstUserFD; fd < len(vm.files); fd++ {
+		if vm.files[fd] != nil {
+			_ = vm.files[fd].Close()
+		}
+	}
+	if len(vm.files) > FirstUserFD {
+		vm.files = vm.files[:FirstUserFD]
+	}
+	vm.fileModes = nil
+
+	for _, o := range files {
+		for uint32(len(vm.files)) <= o.fd { // #nosec G115 -- bounded by MaxFileDescriptors
+			vm.files = append(vm.files, nil)
+		}
+		vm.files[o.fd] = o.file
+		if vm.fileModes == nil {
+			vm.fileModes = make(map[uint32]uint32)
+		}
+		vm.fileModes[o.fd] = o.mode
+	}
+}
+
+// reopenFile opens a saved file in its original mode, sandboxed to the current filesystem
+// root like SWI_OPEN. Write modes neither create nor truncate, since the guest expects the
+// contents it had already written.
+func (vm *VM) reopenFile(saved fileSnapshot) (*os.File, error) {
+	path, err := vm.ValidatePath(saved.Path)
+	if err != nil {
+		return nil, err
+	}
+	var flags int
+	switch saved.Mode {
+	case FileModeRead:
+		flags = os.O_RDONLY
+	case FileModeWrite:
+		flags = os.O_WRONLY
+	case FileModeAppend:
+		flags = os.O_APPEND | os.O_RDWR
+	default:
+		return nil, fmt.Errorf("invalid file mode %d", saved.Mode)
+	}
+	//nolint:gosec // G304: File path is validated by ValidatePath above
+	return os.OpenFile(path, flags, 0)
+}
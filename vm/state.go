@@ -1,5 +1,10 @@
 package vm
 
+import (
+	"fmt"
+	"strings"
+)
+
 // RegisterSnapshot captures the state of CPU registers for change detection
 type RegisterSnapshot struct {
 	R    [16]uint32 // R0-R15 (PC is R15)
@@ -37,3 +42,28 @@ func (s *RegisterSnapshot) GetRegister(reg int) uint32 {
 	}
 	return 0
 }
+
+// Diff describes how the general registers and flags changed since before, e.g.
+// "R0: 5 -> 15, CPSR: ---- -> -Z--". The PC is left out since it changes every step.
+// Values below 0x1000 are shown in decimal and larger ones, such as addresses, in hex.
+func (s *RegisterSnapshot) Diff(before *RegisterSnapshot) string {
+	var parts []string
+	for _, reg := range s.ChangedRegisters(before) {
+		if reg == ARMRegisterPC {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s -> %s", getRegisterName(reg), formatDiffValue(before.R[reg]), formatDiffValue(s.R[reg])))
+	}
+	if was, now := formatTraceFlags(before.CPSR), formatTraceFlags(s.CPSR); was != now {
+		parts = append(parts, fmt.Sprintf("CPSR: %s -> %s", was, now))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatDiffValue shows small values in decimal and addresses and other large values in hex
+func formatDiffValue(v uint32) string {
+	if v < RegisterDiffDecimalLimit {
+		return fmt.Sprintf("%d", v)
+	}
+	return fmt.Sprintf("0x%08X", v)
+}
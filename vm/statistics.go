@@ -18,6 +18,13 @@ type InstructionStats struct {
 	Cycles   uint64
 }
 
+// AddressingModeStats counts the uses of one addressing mode by a load/store mnemonic
+type AddressingModeStats struct {
+	Mnemonic string
+	Mode     string
+	Count    uint64
+}
+
 // FunctionStats tracks statistics for a function
 type FunctionStats struct {
 	Name        string
@@ -43,7 +50,8 @@ type PerformanceStatistics struct {
 	InstructionsPerSec float64
 
 	// Instruction breakdown
-	InstructionCounts map[string]uint64 // mnemonic -> count
+	InstructionCounts    map[string]uint64            // mnemonic -> count
+	AddressingModeCounts map[string]map[string]uint64 // load/store mnemonic -> addressing mode -> count
 
 	// Branch statistics
 	BranchCount       uint64
@@ -66,17 +74,20 @@ type PerformanceStatistics struct {
 	startTime      time.Time
 	collectHotPath bool
 	trackCalls     bool
+	mnemonics      map[uint32]string // opcode -> mnemonic, so each opcode is disassembled once
 }
 
 // NewPerformanceStatistics creates a new statistics tracker
 func NewPerformanceStatistics() *PerformanceStatistics {
 	return &PerformanceStatistics{
-		Enabled:           true,
-		InstructionCounts: make(map[string]uint64),
-		FunctionCalls:     make(map[uint32]*FunctionStats),
-		HotPath:           make(map[uint32]uint64),
-		collectHotPath:    true,
-		trackCalls:        true,
+		Enabled:              true,
+		InstructionCounts:    make(map[string]uint64),
+		AddressingModeCounts: make(map[string]map[string]uint64),
+		FunctionCalls:        make(map[uint32]*FunctionStats),
+		HotPath:              make(map[uint32]uint64),
+		collectHotPath:       true,
+		trackCalls:           true,
+		mnemonics:            make(map[uint32]string),
 	}
 }
 
@@ -86,6 +97,7 @@ func (s *PerformanceStatistics) Start() {
 	s.TotalInstructions = 0
 	s.TotalCycles = 0
 	s.InstructionCounts = make(map[string]uint64)
+	s.AddressingModeCounts = make(map[string]map[string]uint64)
 	s.BranchCount = 0
 	s.BranchTakenCount = 0
 	s.BranchMissedCount = 0
@@ -113,6 +125,93 @@ func (s *PerformanceStatistics) RecordInstruction(mnemonic string, address uint3
 	}
 }
 
+// RecordAddressingMode records a load or store using the given addressing mode
+func (s *PerformanceStatistics) RecordAddressingMode(mnemonic, mode string) {
+	if !s.Enabled {
+		return
+	}
+
+	modes := s.AddressingModeCounts[mnemonic]
+	if modes == nil {
+		modes = make(map[string]uint64)
+		s.AddressingModeCounts[mnemonic] = modes
+	}
+	modes[mode]++
+}
+
+// RecordExecution records an executed instruction by its mnemonic, and by addressing mode
+// for single loads and stores. The condition is left out of the mnemonic, so MOVEQ counts
+// as MOV; S, B and H suffixes are kept.
+func (s *PerformanceStatistics) RecordExecution(inst *Instruction, cycles uint64) {
+	if !s.Enabled {
+		return
+	}
+
+	mnemonic, ok := s.mnemonics[inst.Opcode]
+	if !ok {
+		mnemonic = instructionMnemonic(inst.Opcode)
+		if s.mnemonics == nil {
+			s.mnemonics = make(map[uint32]string)
+		}
+		s.mnemonics[inst.Opcode] = mnemonic
+	}
+	s.RecordInstruction(mnemonic, inst.Address, cycles)
+
+	if inst.Type == InstLoadStore {
+		s.RecordAddressingMode(mnemonic, loadStoreAddressingMode(inst.Opcode))
+	}
+}
+
+// instructionMnemonic returns the mnemonic of opcode without its condition suffix
+func instructionMnemonic(opcode uint32) string {
+	always := opcode&^(Mask4Bit<<ConditionShift) | uint32(CondAL)<<ConditionShift
+	text, _ := Disassemble(always, 0, nil)
+	if fields := strings.Fields(text); len(fields) > 0 {
+		return fields[0]
+	}
+	return "UNDEFINED"
+}
+
+// loadStoreAddressingMode describes the addressing mode of a single load or store in
+// assembler syntax, e.g. "[Rn, #imm]!" or "[Rn], Rm, shift"
+func loadStoreAddressingMode(opcode uint32) string {
+	pre := (opcode>>PBitShift)&Mask1Bit == 1
+	writeBack := (opcode>>WBitShift)&Mask1Bit == 1
+	rn := (opcode >> RnShift) & Mask4Bit
+
+	var offset string
+	var imm uint32
+	if (opcode>>Bits27_26Shift)&Mask2Bit == 0 {
+		// Halfword and signed transfers: bit 22 selects an 8-bit immediate, else Rm
+		if (opcode>>BBitShift)&Mask1Bit == 1 {
+			offset = "#imm"
+			imm = (((opcode >> HalfwordHighShift) & HalfwordOffsetHighMask) << HalfwordLowShift) | (opcode & HalfwordOffsetLowMask)
+		} else {
+			offset = "Rm"
+		}
+	} else if (opcode>>IBitShift)&Mask1Bit == 1 {
+		offset = "Rm"
+		if (opcode>>ShiftAmountPos)&Mask5Bit != 0 || (opcode>>ShiftTypePos)&Mask2Bit != 0 {
+			offset = "Rm, shift"
+		}
+	} else {
+		offset = "#imm"
+		imm = opcode & Offset12BitMask
+	}
+
+	switch {
+	case !pre:
+		return "[Rn], " + offset
+	case writeBack:
+		return "[Rn, " + offset + "]!"
+	case offset == "#imm" && rn == ARMRegisterPC:
+		return "[PC, #imm]"
+	case offset == "#imm" && imm == 0:
+		return "[Rn]"
+	}
+	return "[Rn, " + offset + "]"
+}
+
 // RecordBranch records a branch instruction
 func (s *PerformanceStatistics) RecordBranch(taken bool) {
 	if !s.Enabled {
@@ -193,6 +292,28 @@ func (s *PerformanceStatistics) GetTopInstructions(n int) []InstructionStats {
 	return stats
 }
 
+// GetAddressingModes returns the load/store addressing mode counts, ordered by mnemonic
+// and then by count descending
+func (s *PerformanceStatistics) GetAddressingModes() []AddressingModeStats {
+	stats := make([]AddressingModeStats, 0)
+	for mnemonic, modes := range s.AddressingModeCounts {
+		for mode, count := range modes {
+			stats = append(stats, AddressingModeStats{Mnemonic: mnemonic, Mode: mode, Count: count})
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Mnemonic != stats[j].Mnemonic {
+			return stats[i].Mnemonic < stats[j].Mnemonic
+		}
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Mode < stats[j].Mode
+	})
+	return stats
+}
+
 // GetTopHotPath returns the most frequently executed addresses
 func (s *PerformanceStatistics) GetTopHotPath(n int) []HotPathEntry {
 	entries := make([]HotPathEntry, 0, len(s.HotPath))
@@ -249,6 +370,8 @@ func (s *PerformanceStatistics) ExportJSON(w io.Writer) error {
 		"bytes_read":           s.BytesRead,
 		"bytes_written":        s.BytesWritten,
 		"top_instructions":     s.GetTopInstructions(DefaultTopItemsCount),
+		"instruction_counts":   s.InstructionCounts,
+		"addressing_modes":     s.AddressingModeCounts,
 		"hot_path":             s.GetTopHotPath(DefaultTopItemsCount),
 		"top_functions":        s.GetTopFunctions(DefaultTopItemsCount),
 	}
@@ -303,6 +426,17 @@ func (s *PerformanceStatistics) ExportCSV(w io.Writer) error {
 		}
 	}
 
+	// Write addressing mode breakdown
+	if len(s.AddressingModeCounts) > 0 {
+		_ = writer.Write([]string{})                                          // Ignore error for separator
+		_ = writer.Write([]string{"Instruction", "Addressing Mode", "Count"}) // Ignore error for header
+		for _, mode := range s.GetAddressingModes() {
+			if err := writer.Write([]string{mode.Mnemonic, mode.Mode, fmt.Sprintf("%d", mode.Count)}); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -361,6 +495,16 @@ func (s *PerformanceStatistics) ExportHTML(w io.Writer) error {
         {{end}}
     </table>
 
+    {{if .AddressingModes}}
+    <h2>Load/Store Addressing Modes</h2>
+    <table>
+        <tr><th>Instruction</th><th>Addressing Mode</th><th>Count</th></tr>
+        {{range .AddressingModes}}
+        <tr><td>{{.Mnemonic}}</td><td>{{.Mode}}</td><td>{{.Count}}</td></tr>
+        {{end}}
+    </table>
+    {{end}}
+
     <h2>Hot Path (most executed addresses)</h2>
     <table>
         <tr><th>Address</th><th>Executions</th></tr>
@@ -401,8 +545,9 @@ func (s *PerformanceStatistics) ExportHTML(w io.Writer) error {
 			Count      uint64
 			Percentage float64
 		}
-		HotPath      []HotPathEntry
-		TopFunctions []*FunctionStats
+		AddressingModes []AddressingModeStats
+		HotPath         []HotPathEntry
+		TopFunctions    []*FunctionStats
 	}{
 		TotalInstructions:  s.TotalInstructions,
 		TotalCycles:        s.TotalCycles,
@@ -415,6 +560,7 @@ func (s *PerformanceStatistics) ExportHTML(w io.Writer) error {
 		MemoryWrites:       s.MemoryWrites,
 		BytesRead:          s.BytesRead,
 		BytesWritten:       s.BytesWritten,
+		AddressingModes:    s.GetAddressingModes(),
 		HotPath:            s.GetTopHotPath(DefaultTopItemsCount),
 		TopFunctions:       s.GetTopFunctions(DefaultTopItemsCount),
 	}
@@ -6,12 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 )
 
 var vmDebugEnabled = os.Getenv("ARM_EMULATOR_DEBUG") != ""
@@ -124,6 +122,10 @@ const (
 	SWI_TELL      = 0x15
 	SWI_FILE_SIZE = 0x16
 
+	// Memory Checkpointing
+	SWI_DUMP_REGION = 0x17
+	SWI_LOAD_REGION = 0x18
+
 	// Memory Operations
 	SWI_ALLOCATE   = 0x20
 	SWI_FREE       = 0x21
@@ -134,12 +136,20 @@ const (
 	SWI_GET_RANDOM      = 0x31
 	SWI_GET_ARGUMENTS   = 0x32
 	SWI_GET_ENVIRONMENT = 0x33
+	SWI_GET_DATETIME    = 0x34
+	SWI_GET_FS_ROOT     = 0x35
+	SWI_GET_CYCLES      = 0x36
+	SWI_SET_FS_ROOT     = 0x37
 
 	// Error Handling
 	SWI_GET_ERROR   = 0x40
 	SWI_SET_ERROR   = 0x41
 	SWI_PRINT_ERROR = 0x42
 
+	// Interrupts
+	SWI_SET_TIMER  = 0x50
+	SWI_IRQ_RETURN = 0x51
+
 	// Debugging Support
 	SWI_DEBUG_PRINT    = 0xF0
 	SWI_BREAKPOINT     = 0xF1
@@ -192,26 +202,36 @@ func (vm *VM) getFile(fd uint32) (*os.File, error) {
 	return f, nil
 }
 
-func (vm *VM) allocFD(f *os.File) uint32 {
+// allocFD stores f, opened with the given FileMode*, in the first free user descriptor
+func (vm *VM) allocFD(f *os.File, mode uint32) uint32 {
 	vm.fdMu.Lock()
 	defer vm.fdMu.Unlock()
 
+	fd := -1
 	for i := FirstUserFD; i < len(vm.files); i++ {
 		if vm.files[i] == nil {
 			vm.files[i] = f
-			//nolint:gosec // G115: i is bounded by len(vm.files) which is reasonable
-			return uint32(i)
+			fd = i
+			break
 		}
 	}
 
-	// Check limit before growing the table
-	if len(vm.files) >= MaxFileDescriptors {
-		return SyscallErrorGeneral // Return error if limit reached
+	if fd < 0 {
+		// Check limit before growing the table
+		if len(vm.files) >= MaxFileDescriptors {
+			return SyscallErrorGeneral // Return error if limit reached
+		}
+		vm.files = append(vm.files, f)
+		fd = len(vm.files) - 1
 	}
 
-	vm.files = append(vm.files, f)
-	//nolint:gosec // G115: len(vm.files)-1 is bounded by reasonable file count
-	return uint32(len(vm.files) - 1)
+	if vm.fileModes == nil {
+		vm.fileModes = make(map[uint32]uint32)
+	}
+	//nolint:gosec // G115: fd is bounded by MaxFileDescriptors
+	vm.fileModes[uint32(fd)] = mode
+	//nolint:gosec // G115: fd is bounded by MaxFileDescriptors
+	return uint32(fd)
 }
 
 func (vm *VM) closeFD(fd uint32) error {
@@ -222,6 +242,7 @@ func (vm *VM) closeFD(fd uint32) error {
 	}
 	_ = vm.files[fd].Close()
 	vm.files[fd] = nil
+	delete(vm.fileModes, fd)
 	return nil
 }
 
@@ -234,6 +255,10 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 	// ARM2 traditional convention: SWI #num
 	swiNum := inst.Opcode & SWIMask
 
+	if refused, limitErr := vm.checkSyscallLimits(swiNum); limitErr != nil || refused {
+		return limitErr
+	}
+
 	switch swiNum {
 	// Console I/O
 	case SWI_EXIT:
@@ -251,7 +276,11 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 	case SWI_READ_INT:
 		err = handleReadInt(vm)
 	case SWI_WRITE_NEWLINE:
-		if _, err = fmt.Fprintln(vm.OutputWriter); err != nil {
+		if !vm.chargeWrite(1) {
+			vm.refuseSyscall()
+			break
+		}
+		if _, err = fmt.Fprintln(vm.Output()); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: console write failed: %v\n", err)
 		}
 		// Sync if it's a regular file (not a character device like stdout)
@@ -277,6 +306,10 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 		err = handleTell(vm)
 	case SWI_FILE_SIZE:
 		err = handleFileSize(vm)
+	case SWI_DUMP_REGION:
+		err = handleDumpRegion(vm)
+	case SWI_LOAD_REGION:
+		err = handleLoadRegion(vm)
 
 	// Memory Operations
 	case SWI_ALLOCATE:
@@ -295,6 +328,14 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 		err = handleGetArguments(vm)
 	case SWI_GET_ENVIRONMENT:
 		err = handleGetEnvironment(vm)
+	case SWI_GET_DATETIME:
+		err = handleGetDateTime(vm)
+	case SWI_GET_FS_ROOT:
+		err = handleGetFSRoot(vm)
+	case SWI_GET_CYCLES:
+		err = handleGetCycles(vm)
+	case SWI_SET_FS_ROOT:
+		err = handleSetFSRoot(vm)
 
 	// Error Handling
 	case SWI_GET_ERROR:
@@ -304,6 +345,14 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 	case SWI_PRINT_ERROR:
 		err = handlePrintError(vm)
 
+	// Interrupts
+	case SWI_SET_TIMER:
+		err = handleSetTimer(vm)
+	case SWI_IRQ_RETURN:
+		err = vm.returnFromIRQ()
+		// The flags restored from SPSR must survive the restore below
+		saved = vm.CPU.CPSR
+
 	// Debugging Support
 	case SWI_DEBUG_PRINT:
 		err = handleDebugPrint(vm)
@@ -324,6 +373,7 @@ func ExecuteSWI(vm *VM, inst *Instruction) error {
 	vm.CPU.CPSR.Z = saved.Z
 	vm.CPU.CPSR.C = saved.C
 	vm.CPU.CPSR.V = saved.V
+	vm.CPU.CPSR.Q = saved.Q
 	return err
 }
 
@@ -338,8 +388,12 @@ func handleExit(vm *VM) error {
 }
 
 func handleWriteChar(vm *VM) error {
-	char := vm.CPU.GetRegister(0)
-	if _, err := fmt.Fprintf(vm.OutputWriter, "%c", char); err != nil {
+	char := fmt.Sprintf("%c", vm.CPU.GetRegister(0))
+	if !vm.chargeWrite(len(char)) {
+		vm.refuseSyscall()
+		return nil
+	}
+	if _, err := fmt.Fprint(vm.Output(), char); err != nil {
 		// Console write errors are logged but don't halt execution
 		// (broken pipe, disk full, etc. are typically non-recoverable)
 		fmt.Fprintf(os.Stderr, "Warning: console write failed: %v\n", err)
@@ -382,10 +436,14 @@ func handleWriteString(vm *VM) error {
 		}
 	}
 
+	if !vm.chargeWrite(len(str)) {
+		vm.refuseSyscall()
+		return nil
+	}
 	if vmDebugEnabled {
 		log.Printf("VM: handleWriteString writing %d bytes: %q to OutputWriter %T", len(str), string(str), vm.OutputWriter)
 	}
-	_, _ = fmt.Fprint(vm.OutputWriter, string(str)) // Ignore write errors
+	_, _ = fmt.Fprint(vm.Output(), string(str)) // Ignore write errors
 	// Sync if it's a regular file (not a character device like stdout)
 	if f, ok := vm.OutputWriter.(*os.File); ok && shouldSyncFile(f) {
 		_ = f.Sync() // Ignore sync errors
@@ -404,22 +462,26 @@ func handleWriteInt(vm *VM) error {
 		base = BaseDecimal // Default to decimal
 	}
 
-	var err error
+	var text string
 	switch base {
 	case BaseBinary:
-		_, err = fmt.Fprintf(vm.OutputWriter, "%b", value)
+		text = fmt.Sprintf("%b", value)
 	case BaseOctal:
-		_, err = fmt.Fprintf(vm.OutputWriter, "%o", value)
+		text = fmt.Sprintf("%o", value)
 	case BaseDecimal:
-		_, err = fmt.Fprintf(vm.OutputWriter, "%d", AsInt32(value))
+		text = fmt.Sprintf("%d", AsInt32(value))
 	case BaseHexadecimal:
-		_, err = fmt.Fprintf(vm.OutputWriter, "%x", value)
+		text = fmt.Sprintf("%x", value)
 	default:
 		// This should never happen due to validation above, but keep for safety
-		_, err = fmt.Fprintf(vm.OutputWriter, "%d", AsInt32(value))
+		text = fmt.Sprintf("%d", AsInt32(value))
 	}
 
-	if err != nil {
+	if !vm.chargeWrite(len(text)) {
+		vm.refuseSyscall()
+		return nil
+	}
+	if _, err := fmt.Fprint(vm.Output(), text); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: console write failed: %v\n", err)
 	}
 
@@ -511,6 +573,7 @@ func handleReadString(vm *VM) error {
 	vm.LastMemoryWrite = addr
 	vm.LastMemoryWriteSize = bytesToWrite + 1 // Include null terminator
 	vm.HasMemoryWrite = true
+	vm.notifyMemoryAccess(addr, bytesToWrite+1, true)
 
 	vm.CPU.IncrementPC()
 	return nil
@@ -559,7 +622,7 @@ func handleAllocate(vm *VM) error {
 	size := vm.CPU.GetRegister(0)
 
 	// Allocate memory from heap
-	addr, err := vm.Memory.Allocate(size)
+	addr, err := vm.allocateHeap(size)
 	if err != nil {
 		vm.CPU.SetRegister(0, 0) // Return NULL on failure
 	} else {
@@ -570,6 +633,16 @@ func handleAllocate(vm *VM) error {
 	return nil
 }
 
+// allocateHeap allocates a heap block and records the allocating instruction for leak reports
+func (vm *VM) allocateHeap(size uint32) (uint32, error) {
+	addr, err := vm.Memory.Allocate(size)
+	if err != nil {
+		return 0, err
+	}
+	vm.Memory.HeapAllocations[addr].PC = vm.CPU.PC
+	return addr, nil
+}
+
 func handleFree(vm *VM) error {
 	addr := vm.CPU.GetRegister(0)
 
@@ -587,16 +660,69 @@ func handleFree(vm *VM) error {
 // System information handlers
 func handleGetTime(vm *VM) error {
 	// Return time in milliseconds since Unix epoch
-	millis := time.Now().UnixMilli()
+	millis := vm.now().UnixMilli()
 	// Safe: masking with Mask32Bit before conversion ensures result fits in uint32
 	vm.CPU.SetRegister(0, uint32(millis&Mask32Bit)) // #nosec G115 -- masked to 32 bits
 	vm.CPU.IncrementPC()
 	return nil
 }
 
+// handleGetCycles returns the emulated cycle count in R0 (low word) and R1 (high word).
+// The count covers the instructions before this SWI, so the difference between two reads
+// is the cost of everything between them plus the first SWI's own cycle.
+func handleGetCycles(vm *VM) error {
+	cycles := vm.CPU.Cycles
+	vm.CPU.SetRegister(0, uint32(cycles&Mask32Bit))               // #nosec G115 -- masked to 32 bits
+	vm.CPU.SetRegister(1, uint32((cycles>>BitsInWord)&Mask32Bit)) // #nosec G115 -- masked to 32 bits
+	vm.CPU.IncrementPC()
+	return nil
+}
+
+// handleGetDateTime fills the struct at R0 with the local date and time as words:
+// year, month (1-12), day (1-31), hour (0-23), minute, second, day of week (0=Sunday).
+// Returns 0 in R0 on success, or SyscallErrorGeneral if the buffer is invalid.
+func handleGetDateTime(vm *VM) error {
+	bufferAddr := vm.CPU.GetRegister(0)
+
+	// Security: validate buffer address range to prevent overflow
+	if bufferAddr > Address32BitMax-DateTimeStructSize {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	now := vm.now()
+	// #nosec G115 -- all fields are small non-negative calendar values
+	fields := []uint32{
+		uint32(now.Year()),
+		uint32(now.Month()),
+		uint32(now.Day()),
+		uint32(now.Hour()),
+		uint32(now.Minute()),
+		uint32(now.Second()),
+		uint32(now.Weekday()),
+	}
+	for i, value := range fields {
+		if err := vm.Memory.WriteWord(bufferAddr+uint32(i)*4, value); err != nil { // #nosec G115 -- i < 7
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+	}
+
+	vm.LastMemoryWrite = bufferAddr
+	vm.LastMemoryWriteSize = DateTimeStructSize
+	vm.HasMemoryWrite = true
+	vm.notifyMemoryAccess(bufferAddr, DateTimeStructSize, true)
+
+	vm.CPU.SetRegister(0, 0)
+	vm.CPU.IncrementPC()
+	return nil
+}
+
 func handleGetRandom(vm *VM) error {
 	// Return a random 32-bit number (non-cryptographic use)
-	vm.CPU.SetRegister(0, rand.Uint32()) // #nosec G404 -- pseudo-random for emulator, not crypto
+	vm.CPU.SetRegister(0, vm.Random.Uint32())
 	vm.CPU.IncrementPC()
 	return nil
 }
@@ -640,18 +766,19 @@ func handleBreakpoint(vm *VM) error {
 }
 
 func handleDumpRegisters(vm *VM) error {
-	_, _ = fmt.Fprintln(vm.OutputWriter, "=== Register Dump ===") // Ignore write errors
+	_, _ = fmt.Fprintln(vm.Output(), "=== Register Dump ===") // Ignore write errors
 	for i := 0; i < 15; i++ {
 		// Safe: intentional conversion to show signed interpretation of register value
-		_, _ = fmt.Fprintf(vm.OutputWriter, "R%-2d = 0x%08X (%d)\n", i, vm.CPU.R[i], int32(vm.CPU.R[i])) // #nosec G115 -- intentional uint32->int32 for display, ignore write errors
+		_, _ = fmt.Fprintf(vm.Output(), "R%-2d = 0x%08X (%d)\n", i, vm.CPU.R[i], int32(vm.CPU.R[i])) // #nosec G115 -- intentional uint32->int32 for display, ignore write errors
 	}
-	_, _ = fmt.Fprintf(vm.OutputWriter, "PC  = 0x%08X\n", vm.CPU.PC) // Ignore write errors
-	_, _ = fmt.Fprintf(vm.OutputWriter, "CPSR = [%s%s%s%s]\n",       // Ignore write errors
+	_, _ = fmt.Fprintf(vm.Output(), "PC  = 0x%08X\n", vm.CPU.PC) // Ignore write errors
+	_, _ = fmt.Fprintf(vm.Output(), "CPSR = [%s%s%s%s%s]\n",     // Ignore write errors
 		map[bool]string{true: "N", false: "-"}[vm.CPU.CPSR.N],
 		map[bool]string{true: "Z", false: "-"}[vm.CPU.CPSR.Z],
 		map[bool]string{true: "C", false: "-"}[vm.CPU.CPSR.C],
-		map[bool]string{true: "V", false: "-"}[vm.CPU.CPSR.V])
-	_, _ = fmt.Fprintln(vm.OutputWriter, "====================") // Ignore write errors
+		map[bool]string{true: "V", false: "-"}[vm.CPU.CPSR.V],
+		map[bool]string{true: "Q", false: ""}[vm.CPU.CPSR.Q]) // Q shown only when set (saturation extension)
+	_, _ = fmt.Fprintln(vm.Output(), "====================") // Ignore write errors
 
 	vm.CPU.IncrementPC()
 	return nil
@@ -665,35 +792,35 @@ func handleDumpMemory(vm *VM) error {
 		length = MaxMemoryDump // Limit to 1KB
 	}
 
-	_, _ = fmt.Fprintf(vm.OutputWriter, "=== Memory Dump at 0x%08X (length=%d) ===\n", addr, length) // Ignore write errors
+	_, _ = fmt.Fprintf(vm.Output(), "=== Memory Dump at 0x%08X (length=%d) ===\n", addr, length) // Ignore write errors
 
 	for i := uint32(0); i < length; i += 16 {
-		_, _ = fmt.Fprintf(vm.OutputWriter, "%08X: ", addr+i) // Ignore write errors
+		_, _ = fmt.Fprintf(vm.Output(), "%08X: ", addr+i) // Ignore write errors
 
 		// Hex bytes
 		for j := uint32(0); j < 16 && i+j < length; j++ {
 			b, err := vm.Memory.ReadByteAt(addr + i + j)
 			if err != nil {
-				_, _ = fmt.Fprint(vm.OutputWriter, "?? ") // Ignore write errors
+				_, _ = fmt.Fprint(vm.Output(), "?? ") // Ignore write errors
 			} else {
-				_, _ = fmt.Fprintf(vm.OutputWriter, "%02X ", b) // Ignore write errors
+				_, _ = fmt.Fprintf(vm.Output(), "%02X ", b) // Ignore write errors
 			}
 		}
 
 		// ASCII representation
-		_, _ = fmt.Fprint(vm.OutputWriter, " |") // Ignore write errors
+		_, _ = fmt.Fprint(vm.Output(), " |") // Ignore write errors
 		for j := uint32(0); j < 16 && i+j < length; j++ {
 			b, err := vm.Memory.ReadByteAt(addr + i + j)
 			if err != nil || b < 32 || b > 126 {
-				_, _ = fmt.Fprint(vm.OutputWriter, ".") // Ignore write errors
+				_, _ = fmt.Fprint(vm.Output(), ".") // Ignore write errors
 			} else {
-				_, _ = fmt.Fprintf(vm.OutputWriter, "%c", b) // Ignore write errors
+				_, _ = fmt.Fprintf(vm.Output(), "%c", b) // Ignore write errors
 			}
 		}
-		_, _ = fmt.Fprintln(vm.OutputWriter, "|") // Ignore write errors
+		_, _ = fmt.Fprintln(vm.Output(), "|") // Ignore write errors
 	}
 
-	_, _ = fmt.Fprintln(vm.OutputWriter, "=======================================") // Ignore write errors
+	_, _ = fmt.Fprintln(vm.Output(), "=======================================") // Ignore write errors
 	vm.CPU.IncrementPC()
 	return nil
 }
@@ -774,21 +901,17 @@ func (vm *VM) ValidatePath(path string) (string, error) {
 }
 
 // File operation handlers
-func handleOpen(vm *VM) error {
-	filenameAddr := vm.CPU.GetRegister(0)
-	mode := vm.CPU.GetRegister(1) // 0=read, 1=write, 2=append
 
-	// Read filename from memory
-	// Note: Wraparound detection returns 0xFFFFFFFF (error code) rather than halting VM
-	// This follows the file operation error handling pattern (see error handling philosophy at top of file)
+// readGuestPath reads a null-terminated filename from guest memory. It returns false if the
+// string is unreadable, wraps around the address space or exceeds MaxFilenameLength.
+// Callers return 0xFFFFFFFF to the guest rather than halting the VM (see error handling
+// philosophy at top of file).
+func readGuestPath(vm *VM, addr uint32) (string, bool) {
 	var filename []byte
-	addr := filenameAddr
 	for {
 		b, err := vm.Memory.ReadByteAt(addr)
 		if err != nil {
-			vm.CPU.SetRegister(0, SyscallErrorGeneral)
-			vm.CPU.IncrementPC()
-			return nil
+			return "", false
 		}
 		if b == 0 {
 			break
@@ -798,21 +921,29 @@ func handleOpen(vm *VM) error {
 		// Security: check for address wraparound before incrementing
 		// If addr is at Address32BitMax, incrementing would wrap to 0
 		if addr == Address32BitMax {
-			vm.CPU.SetRegister(0, SyscallErrorGeneral)
-			vm.CPU.IncrementPC()
-			return nil
+			return "", false
 		}
 		addr++
 
 		if len(filename) > MaxFilenameLength {
-			vm.CPU.SetRegister(0, SyscallErrorGeneral)
-			vm.CPU.IncrementPC()
-			return nil
+			return "", false
 		}
 	}
+	return string(filename), true
+}
+
+func handleOpen(vm *VM) error {
+	filenameAddr := vm.CPU.GetRegister(0)
+	mode := vm.CPU.GetRegister(1) // 0=read, 1=write, 2=append
+
+	s, ok := readGuestPath(vm, filenameAddr)
+	if !ok {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
 	var file *os.File
 	var err error
-	s := string(filename)
 
 	// Validate path for filesystem sandboxing
 	validatedPath, err := vm.ValidatePath(s)
@@ -841,7 +972,7 @@ func handleOpen(vm *VM) error {
 	if err != nil {
 		vm.CPU.SetRegister(0, SyscallErrorGeneral)
 	} else {
-		fd := vm.allocFD(file)
+		fd := vm.allocFD(file, mode)
 		vm.CPU.SetRegister(0, fd)
 	}
 	vm.CPU.IncrementPC()
@@ -925,6 +1056,7 @@ func handleRead(vm *VM) error {
 		vm.LastMemoryWrite = bufferAddr
 		vm.LastMemoryWriteSize = uint32(n) // #nosec G115 -- n <= len(data) <= MaxReadSize (1MB), fits in uint32
 		vm.HasMemoryWrite = true
+		vm.notifyMemoryAccess(bufferAddr, vm.LastMemoryWriteSize, true)
 	}
 
 	vm.CPU.IncrementPC()
@@ -960,11 +1092,15 @@ func handleWrite(vm *VM) error {
 		}
 		data[i] = b
 	}
+	if !vm.chargeWrite(len(data)) {
+		vm.refuseSyscall()
+		return nil
+	}
 
 	// Special handling for stdout/stderr when OutputWriter is configured
 	// This ensures consistency with SWI #0x10, #0x11, #0x12 which write to OutputWriter
 	if (fd == StdOut || fd == StdErr) && vm.OutputWriter != nil && vm.OutputWriter != os.Stdout {
-		n, err := vm.OutputWriter.Write(data)
+		n, err := vm.Output().Write(data)
 		if err != nil {
 			vm.CPU.SetRegister(0, SyscallErrorGeneral)
 		} else {
@@ -983,6 +1119,9 @@ func handleWrite(vm *VM) error {
 		return nil
 	}
 	n, err := f.Write(data)
+	if fd == StdOut || fd == StdErr {
+		vm.copyToSinks(data[:n])
+	}
 	if err != nil {
 		vm.CPU.SetRegister(0, SyscallErrorGeneral)
 	} else {
@@ -1083,13 +1222,131 @@ func handleFileSize(vm *VM) error {
 	return nil
 }
 
+// regionPath reads and sandboxes the host path for DUMP_REGION and LOAD_REGION
+func regionPath(vm *VM, addr uint32) (string, bool) {
+	name, ok := readGuestPath(vm, addr)
+	if !ok {
+		return "", false
+	}
+	validatedPath, err := vm.ValidatePath(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Security Warning: filesystem access denied: %v\n", err)
+		return "", false
+	}
+	return validatedPath, true
+}
+
+// handleDumpRegion writes R1 bytes of guest memory starting at R0 to the file named by R2,
+// replacing any existing file. Returns the number of bytes written in R0.
+func handleDumpRegion(vm *VM) error {
+	addr := vm.CPU.GetRegister(0)
+	length := vm.CPU.GetRegister(1)
+	pathAddr := vm.CPU.GetRegister(2)
+
+	// Security: same size and range limits as WRITE
+	if length > MaxWriteSize || addr > Address32BitMax-length {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	path, ok := regionPath(vm, pathAddr)
+	if !ok {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	data := make([]byte, length)
+	for i := uint32(0); i < length; i++ {
+		b, err := vm.Memory.ReadByteAt(addr + i)
+		if err != nil {
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+		data[i] = b
+	}
+
+	// The dumped bytes count against MaxBytesWritten like any other write
+	if !vm.chargeWrite(len(data)) {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	if err := os.WriteFile(path, data, FilePermDefault); err != nil {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+	} else {
+		vm.CPU.SetRegister(0, length)
+	}
+	vm.CPU.IncrementPC()
+	return nil
+}
+
+// handleLoadRegion reads up to R1 bytes from the file named by R2 into guest memory at R0.
+// Returns the number of bytes read in R0, which is less than R1 if the file is shorter.
+func handleLoadRegion(vm *VM) error {
+	addr := vm.CPU.GetRegister(0)
+	length := vm.CPU.GetRegister(1)
+	pathAddr := vm.CPU.GetRegister(2)
+
+	// Security: same size and range limits as READ
+	if length > MaxReadSize || addr > Address32BitMax-length {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	path, ok := regionPath(vm, pathAddr)
+	if !ok {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	//nolint:gosec // G304: File path is validated by ValidatePath in regionPath
+	f, err := os.Open(path)
+	if err != nil {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	defer f.Close()
+
+	data := make([]byte, length)
+	n, err := io.ReadFull(f, data)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		//nolint:gosec // G115: i is bounded by n which is at most MaxReadSize
+		if err := vm.Memory.WriteByteAt(addr+uint32(i), data[i]); err != nil {
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+	}
+	vm.CPU.SetRegister(0, uint32(n)) // #nosec G115 -- n <= len(data) <= MaxReadSize (1MB), fits in uint32
+
+	// Track memory write for GUI highlighting
+	if n > 0 {
+		vm.LastMemoryWrite = addr
+		vm.LastMemoryWriteSize = uint32(n) // #nosec G115 -- n <= len(data) <= MaxReadSize (1MB), fits in uint32
+		vm.HasMemoryWrite = true
+		vm.notifyMemoryAccess(addr, vm.LastMemoryWriteSize, true)
+	}
+
+	vm.CPU.IncrementPC()
+	return nil
+}
+
 func handleReallocate(vm *VM) error {
 	oldAddr := vm.CPU.GetRegister(0)
 	newSize := vm.CPU.GetRegister(1)
 
 	// Handle NULL pointer (allocate new)
 	if oldAddr == 0 {
-		newAddr, err := vm.Memory.Allocate(newSize)
+		newAddr, err := vm.allocateHeap(newSize)
 		if err != nil {
 			vm.CPU.SetRegister(0, 0) // NULL on failure
 		} else {
@@ -1108,20 +1365,30 @@ func handleReallocate(vm *VM) error {
 		return nil
 	}
 
+	// Bytes kept by the reallocation (minimum of old and new sizes)
+	copySize := oldAlloc.Size
+	if newSize < copySize {
+		copySize = newSize
+	}
+
+	// Shrink, or grow into the free space that follows, without moving the data
+	if newSize != 0 && vm.Memory.Resize(oldAddr, newSize) {
+		vm.CPU.SetRegister(0, oldAddr)
+		vm.LastMemoryWrite = oldAddr
+		vm.LastMemoryWriteSize = copySize
+		vm.HasMemoryWrite = true
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
 	// Allocate new memory
-	newAddr, err := vm.Memory.Allocate(newSize)
+	newAddr, err := vm.allocateHeap(newSize)
 	if err != nil {
 		vm.CPU.SetRegister(0, 0) // NULL on failure
 		vm.CPU.IncrementPC()
 		return nil
 	}
 
-	// Copy old data to new location (up to minimum of old and new sizes)
-	copySize := oldAlloc.Size
-	if newSize < copySize {
-		copySize = newSize
-	}
-
 	for i := uint32(0); i < copySize; i++ {
 		b, err := vm.Memory.ReadByteAt(oldAddr + i)
 		if err != nil {
@@ -1149,6 +1416,7 @@ func handleReallocate(vm *VM) error {
 	vm.LastMemoryWrite = newAddr
 	vm.LastMemoryWriteSize = copySize
 	vm.HasMemoryWrite = true
+	vm.notifyMemoryAccess(newAddr, copySize, true)
 
 	vm.CPU.IncrementPC()
 	return nil
@@ -1164,23 +1432,116 @@ func handleGetArguments(vm *VM) error {
 		vm.CPU.IncrementPC()
 		return nil
 	}
-	argc := uint32(argLen)
-	vm.CPU.SetRegister(0, argc)
 
-	// In a full implementation, we would:
-	// 1. Allocate memory for argv array
-	// 2. Copy argument strings to memory
-	// 3. Return pointer to argv array in R1
-	// For now, return 0 for argv pointer
-	vm.CPU.SetRegister(1, 0)
+	// With no arguments argv stays NULL
+	var argv uint32
+	if argLen > 0 {
+		var err error
+		if argv, err = vm.argvBlock(); err != nil {
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.SetRegister(1, 0)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+	}
 
+	vm.CPU.SetRegister(0, uint32(argLen))
+	vm.CPU.SetRegister(1, argv)
 	vm.CPU.IncrementPC()
 	return nil
 }
 
 func handleGetEnvironment(vm *VM) error {
-	// Return pointer to environment variables
-	// Simplified: return NULL
+	// Return pointer to a NULL-terminated array of "KEY=VALUE" strings in R0. Only the
+	// variables given to the emulator are exposed; without any, envp is NULL.
+	var envp uint32
+	if len(vm.Environment) > 0 {
+		// If the heap is too full for the table, the program sees no variables
+		if addr, err := vm.envpBlock(); err == nil {
+			envp = addr
+		}
+	}
+	vm.CPU.SetRegister(0, envp)
+	vm.CPU.IncrementPC()
+	return nil
+}
+
+// handleGetFSRoot copies FilesystemRoot into the buffer at R0 of R1 bytes, truncating
+// to R1-1 characters plus a null terminator. Returns the number of characters written
+// in R0, or SyscallErrorGeneral if the buffer is empty or invalid.
+func handleGetFSRoot(vm *VM) error {
+	addr := vm.CPU.GetRegister(0)
+	maxLen := vm.CPU.GetRegister(1)
+
+	// Security: validate buffer address range to prevent overflow
+	if maxLen == 0 || addr > Address32BitMax-maxLen {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	root := vm.FilesystemRoot
+	bytesToWrite := uint32(len(root)) // #nosec G115 -- bounded by maxLen below
+	if bytesToWrite >= maxLen {
+		bytesToWrite = maxLen - 1
+	}
+
+	for i := uint32(0); i < bytesToWrite; i++ {
+		if err := vm.Memory.WriteByteAt(addr+i, root[i]); err != nil {
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+	}
+	if err := vm.Memory.WriteByteAt(addr+bytesToWrite, 0); err != nil {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	vm.CPU.SetRegister(0, bytesToWrite)
+
+	// Track memory write for GUI highlighting
+	vm.LastMemoryWrite = addr
+	vm.LastMemoryWriteSize = bytesToWrite + 1 // Include null terminator
+	vm.HasMemoryWrite = true
+	vm.notifyMemoryAccess(addr, bytesToWrite+1, true)
+
+	vm.CPU.IncrementPC()
+	return nil
+}
+
+// handleSetFSRoot narrows FilesystemRoot to the directory named by the null-terminated
+// path at R0, resolved inside the current root. Only permitted when AllowFSRootChange is
+// set. Returns 0 in R0 on success, or SyscallErrorGeneral if the change is refused.
+func handleSetFSRoot(vm *VM) error {
+	if !vm.AllowFSRootChange {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	path, ok := readGuestPath(vm, vm.CPU.GetRegister(0))
+	if !ok {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	// Security: the new root must lie inside the current sandbox and already exist
+	newRoot, err := vm.ValidatePath(path)
+	if err != nil {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+	if info, err := os.Stat(newRoot); err != nil || !info.IsDir() {
+		vm.CPU.SetRegister(0, SyscallErrorGeneral)
+		vm.CPU.IncrementPC()
+		return nil
+	}
+
+	vm.FilesystemRoot = newRoot
 	vm.CPU.SetRegister(0, 0)
 	vm.CPU.IncrementPC()
 	return nil
@@ -1210,6 +1571,27 @@ func handlePrintError(vm *VM) error {
 	return nil
 }
 
+// handleSetTimer starts the interrupt timer with a period of R0 cycles and the IRQ handler
+// at R1, or stops it when R0 is 0. Returns 0 in R0, or SyscallErrorGeneral if the handler
+// address is misaligned or not executable.
+func handleSetTimer(vm *VM) error {
+	period := vm.CPU.GetRegister(0)
+	vector := vm.CPU.GetRegister(1)
+
+	if period != 0 {
+		if vector%4 != 0 || vm.Memory.CheckExecutePermission(vector) != nil {
+			vm.CPU.SetRegister(0, SyscallErrorGeneral)
+			vm.CPU.IncrementPC()
+			return nil
+		}
+	}
+
+	vm.StartTimer(uint64(period), vector)
+	vm.CPU.SetRegister(0, 0)
+	vm.CPU.IncrementPC()
+	return nil
+}
+
 func handleAssert(vm *VM) error {
 	condition := vm.CPU.GetRegister(0)
 	msgAddr := vm.CPU.GetRegister(1)
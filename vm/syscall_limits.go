@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSyscallLimit is wrapped by the error Step returns when SyscallLimits.MaxCalls is reached
+var ErrSyscallLimit = errors.New("syscall limit exceeded")
+
+// SyscallLimits caps what a program may do through SWIs, for running untrusted code such
+// as grading submissions. Zero fields are unlimited.
+type SyscallLimits struct {
+	MaxCalls        uint64 // SWIs before the program is stopped with ErrSyscallLimit; SWI_EXIT is never refused
+	MaxFileOpens    uint64 // SWI_OPEN, SWI_DUMP_REGION and SWI_LOAD_REGION calls allowed; later ones fail with SyscallErrorGeneral
+	MaxBytesWritten uint64 // Bytes written to the console and files; a write that would pass it writes nothing and fails
+}
+
+// SyscallUsage counts what the program has used against its SyscallLimits
+type SyscallUsage struct {
+	Calls        uint64
+	FileOpens    uint64
+	BytesWritten uint64
+}
+
+// checkSyscallLimits counts the SWI against the call and file-open limits before it is
+// dispatched. It returns an error to stop the program, or refused=true if the SWI has
+// already failed with SyscallErrorGeneral in R0 and must not run.
+func (vm *VM) checkSyscallLimits(swiNum uint32) (refused bool, err error) {
+	if swiNum == SWI_EXIT {
+		return false, nil
+	}
+
+	limits, usage := &vm.SyscallLimits, &vm.SyscallUsage
+	if limits.MaxCalls > 0 && usage.Calls >= limits.MaxCalls {
+		return false, fmt.Errorf("%w (%d syscalls)", ErrSyscallLimit, limits.MaxCalls)
+	}
+	usage.Calls++
+
+	// The region SWIs open a host file too, so they count as opens
+	if swiNum == SWI_OPEN || swiNum == SWI_DUMP_REGION || swiNum == SWI_LOAD_REGION {
+		if limits.MaxFileOpens > 0 && usage.FileOpens >= limits.MaxFileOpens {
+			vm.refuseSyscall()
+			return true, nil
+		}
+		usage.FileOpens++
+	}
+	return false, nil
+}
+
+// chargeWrite counts n bytes against MaxBytesWritten, returning false without counting
+// them if they would exceed it
+func (vm *VM) chargeWrite(n int) bool {
+	limit := vm.SyscallLimits.MaxBytesWritten
+	if limit > 0 && vm.SyscallUsage.BytesWritten+uint64(n) > limit { // #nosec G115 -- n is a non-negative length
+		return false
+	}
+	vm.SyscallUsage.BytesWritten += uint64(n) // #nosec G115 -- n is a non-negative length
+	return true
+}
+
+// refuseSyscall fails the current SWI with SyscallErrorGeneral in R0 and moves past it
+func (vm *VM) refuseSyscall() {
+	vm.CPU.SetRegister(0, SyscallErrorGeneral)
+	vm.CPU.IncrementPC()
+}
@@ -0,0 +1,60 @@
+package vm
+
+import "fmt"
+
+// Timer is a cycle-driven peripheral for teaching interrupt handling. Once started it
+// raises an IRQ each time Period more cycles have elapsed. Taking the IRQ saves the
+// CPSR in SPSR and the interrupted PC in ReturnPC, enters a simulated IRQ mode that
+// holds off further interrupts, and jumps to Vector. The handler finishes with
+// SWI_IRQ_RETURN, which restores the saved PC and CPSR.
+//
+// There are no banked registers: the handler shares R0-R14 with the interrupted code
+// and must preserve any it uses.
+type Timer struct {
+	Period uint64 // Cycles between interrupts; 0 means stopped
+	Vector uint32 // Address of the IRQ handler
+	Due    uint64 // Cycle count at which the next interrupt is raised
+	Count  uint64 // Interrupts taken since the timer was started
+
+	InIRQ    bool   // The handler is running; a due interrupt stays pending until it returns
+	ReturnPC uint32 // Address of the interrupted instruction, resumed by SWI_IRQ_RETURN
+}
+
+// StartTimer arms the timer to interrupt every period cycles from now, jumping to vector.
+// A period of 0 stops the timer. An interrupt already being handled is unaffected.
+func (vm *VM) StartTimer(period uint64, vector uint32) {
+	vm.Timer.Period = period
+	vm.Timer.Vector = vector
+	vm.Timer.Due = vm.CPU.Cycles + period
+	vm.Timer.Count = 0
+}
+
+// checkTimerIRQ takes the timer interrupt once it is due, unless the handler is already
+// running or the step stopped the program
+func (vm *VM) checkTimerIRQ() {
+	t := &vm.Timer
+	if t.Period == 0 || t.InIRQ || vm.CPU.Cycles < t.Due {
+		return
+	}
+	if vm.State == StateHalted || vm.LastBKPT != nil {
+		return
+	}
+
+	vm.CPU.SPSR = vm.CPU.CPSR
+	t.ReturnPC = vm.CPU.PC
+	t.InIRQ = true
+	t.Count++
+	t.Due = vm.CPU.Cycles + t.Period
+	vm.CPU.PC = t.Vector
+}
+
+// returnFromIRQ leaves the IRQ handler, restoring the PC and CPSR saved when it was entered
+func (vm *VM) returnFromIRQ() error {
+	if !vm.Timer.InIRQ {
+		return fmt.Errorf("IRQ_RETURN outside an interrupt handler at PC=0x%08X", vm.CPU.PC)
+	}
+	vm.Timer.InIRQ = false
+	vm.CPU.CPSR = vm.CPU.SPSR
+	vm.CPU.PC = vm.Timer.ReturnPC
+	return nil
+}
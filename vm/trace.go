@@ -1,9 +1,12 @@
 package vm
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,6 +28,33 @@ type TraceEntry struct {
 	RegisterChanges map[string]uint32 // Register changes (name -> new value)
 	Flags           CPSR              // CPSR flags after execution
 	Duration        time.Duration     // Execution time
+
+	hasFlags bool // Flags were present when parsed from a trace file
+}
+
+// traceRegisterOrder is the order register changes are written in
+var traceRegisterOrder = append(registerNames[:], "PC", "SP", "LR")
+
+// Execution trace output formats
+const (
+	TraceFormatText  = "text"
+	TraceFormatCSV   = "csv"
+	TraceFormatJSONL = "jsonl" // One JSON object per line
+)
+
+// Execution trace columns, selectable with SetColumns
+const (
+	TraceColumnPC       = "pc"
+	TraceColumnOpcode   = "opcode"
+	TraceColumnMnemonic = "mnemonic"
+	TraceColumnRegs     = "regs" // Registers changed by the instruction
+	TraceColumnCPSR     = "cpsr"
+	TraceColumnCycles   = "cycles"
+)
+
+// DefaultTraceColumns are the columns used by csv and jsonl output when none are chosen
+var DefaultTraceColumns = []string{
+	TraceColumnCycles, TraceColumnPC, TraceColumnOpcode, TraceColumnMnemonic, TraceColumnRegs, TraceColumnCPSR,
 }
 
 // ExecutionTrace manages execution tracing
@@ -35,11 +65,14 @@ type ExecutionTrace struct {
 	IncludeFlags  bool
 	IncludeTiming bool
 	MaxEntries    int
+	Format        string   // TraceFormatText (default), TraceFormatCSV or TraceFormatJSONL
+	Columns       []string // Columns and their order; empty = the fixed text layout or DefaultTraceColumns
 
 	entries      []TraceEntry
 	startTime    time.Time
 	lastSnapshot RegisterSnapshot // Previous register values
 	symbols      *SymbolResolver  // Symbol resolver for address annotation
+	labels       map[uint32]string
 }
 
 // NewExecutionTrace creates a new execution trace
@@ -50,7 +83,8 @@ func NewExecutionTrace(writer io.Writer) *ExecutionTrace {
 		FilterRegs:    make(map[string]bool),
 		IncludeFlags:  true,
 		IncludeTiming: true,
-		MaxEntries:    100000,
+		MaxEntries:    DefaultTraceMaxEntries,
+		Format:        TraceFormatText,
 		entries:       make([]TraceEntry, 0, 1000),
 		lastSnapshot:  RegisterSnapshot{},
 	}
@@ -65,9 +99,48 @@ func (t *ExecutionTrace) SetFilterRegisters(regs []string) {
 	}
 }
 
+// SetFormat selects the output format
+func (t *ExecutionTrace) SetFormat(format string) error {
+	switch format {
+	case TraceFormatText, TraceFormatCSV, TraceFormatJSONL:
+		t.Format = format
+		return nil
+	}
+	return fmt.Errorf("unknown trace format %q (expected text, csv or jsonl)", format)
+}
+
+// SetColumns selects which columns are written and in what order, e.g. "pc,mnemonic,regs".
+// An empty spec restores the default layout.
+func (t *ExecutionTrace) SetColumns(spec string) error {
+	if strings.TrimSpace(spec) == "" {
+		t.Columns = nil
+		return nil
+	}
+	var columns []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case TraceColumnPC, TraceColumnOpcode, TraceColumnMnemonic, TraceColumnRegs, TraceColumnCPSR, TraceColumnCycles:
+			columns = append(columns, name)
+		default:
+			return fmt.Errorf("unknown trace column %q (expected pc, opcode, mnemonic, regs, cpsr or cycles)", name)
+		}
+	}
+	t.Columns = columns
+	return nil
+}
+
 // LoadSymbols loads a symbol table for address annotation
 func (t *ExecutionTrace) LoadSymbols(symbols map[string]uint32) {
 	t.symbols = NewSymbolResolver(symbols)
+
+	// Branch targets are named after the first label (alphabetically) at each address
+	t.labels = make(map[uint32]string, len(symbols))
+	for name, addr := range symbols {
+		if existing, ok := t.labels[addr]; !ok || name < existing {
+			t.labels[addr] = name
+		}
+	}
 }
 
 // Start starts the trace
@@ -79,6 +152,20 @@ func (t *ExecutionTrace) Start() {
 
 // RecordInstruction records an instruction execution
 func (t *ExecutionTrace) RecordInstruction(vm *VM, disasm string) {
+	t.record(vm, vm.CPU.PC-4, 0, disasm) // PC has already advanced
+}
+
+// RecordExecution records the instruction at address after it has executed,
+// disassembling the opcode for the trace
+func (t *ExecutionTrace) RecordExecution(vm *VM, address, opcode uint32) {
+	if !t.Enabled {
+		return
+	}
+	disasm, _ := Disassemble(opcode, address, t.labels)
+	t.record(vm, address, opcode, disasm)
+}
+
+func (t *ExecutionTrace) record(vm *VM, address, opcode uint32, disasm string) {
 	if !t.Enabled {
 		return
 	}
@@ -90,8 +177,8 @@ func (t *ExecutionTrace) RecordInstruction(vm *VM, disasm string) {
 
 	entry := TraceEntry{
 		Sequence:        vm.CPU.Cycles,
-		Address:         vm.CPU.PC - 4, // PC has already advanced
-		Opcode:          0,             // Will be filled by caller if needed
+		Address:         address,
+		Opcode:          opcode,
 		Disassembly:     disasm,
 		RegisterChanges: make(map[string]uint32),
 		Flags:           vm.CPU.CPSR,
@@ -150,8 +237,21 @@ func (t *ExecutionTrace) Flush() error {
 		return nil
 	}
 
+	switch t.Format {
+	case TraceFormatCSV:
+		return t.writeCSV()
+	case TraceFormatJSONL:
+		return t.writeJSONL()
+	}
+
 	for _, entry := range t.entries {
-		if err := t.writeEntry(entry); err != nil {
+		var err error
+		if len(t.Columns) > 0 {
+			err = t.writeColumns(entry)
+		} else {
+			err = t.writeEntry(entry)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -159,55 +259,139 @@ func (t *ExecutionTrace) Flush() error {
 	return nil
 }
 
-// writeEntry writes a single trace entry
-func (t *ExecutionTrace) writeEntry(entry TraceEntry) error {
-	// Format: [seq] addr: instruction | changes | flags | time
-	// Use symbol-aware formatting if symbols are available
-	addrStr := fmt.Sprintf("0x%04X", entry.Address)
+// columns returns the selected columns, or the defaults
+func (t *ExecutionTrace) columns() []string {
+	if len(t.Columns) > 0 {
+		return t.Columns
+	}
+	return DefaultTraceColumns
+}
+
+// formatAddress renders an address, symbolically when symbols are loaded
+func (t *ExecutionTrace) formatAddress(addr uint32) string {
 	if t.symbols != nil && t.symbols.HasSymbols() {
-		addrStr = t.symbols.FormatAddressCompact(entry.Address)
+		return t.symbols.FormatAddressCompact(addr)
 	}
+	return fmt.Sprintf("0x%04X", addr)
+}
 
+// formatRegisterChanges renders changed registers as "R0=0x00000001 PC=0x00008004"
+func formatRegisterChanges(changes map[string]uint32) string {
+	parts := make([]string, 0, len(changes))
+	for _, name := range traceRegisterOrder {
+		if value, ok := changes[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s=0x%08X", name, value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// columnValue renders one column of an entry for text and csv output
+func (t *ExecutionTrace) columnValue(entry TraceEntry, column string) string {
+	switch column {
+	case TraceColumnPC:
+		return t.formatAddress(entry.Address)
+	case TraceColumnOpcode:
+		return fmt.Sprintf("0x%08X", entry.Opcode)
+	case TraceColumnMnemonic:
+		return entry.Disassembly
+	case TraceColumnRegs:
+		return formatRegisterChanges(entry.RegisterChanges)
+	case TraceColumnCPSR:
+		return formatTraceFlags(entry.Flags)
+	case TraceColumnCycles:
+		return strconv.FormatUint(entry.Sequence, 10)
+	}
+	return ""
+}
+
+// writeColumns writes an entry as the selected columns separated by " | "
+func (t *ExecutionTrace) writeColumns(entry TraceEntry) error {
+	values := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		values[i] = t.columnValue(entry, column)
+	}
+	_, err := fmt.Fprintln(t.Writer, strings.Join(values, " | "))
+	return err
+}
+
+// writeCSV writes a header row followed by one row per entry
+func (t *ExecutionTrace) writeCSV() error {
+	columns := t.columns()
+	w := csv.NewWriter(t.Writer)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, entry := range t.entries {
+		for i, column := range columns {
+			row[i] = t.columnValue(entry, column)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeJSONL writes one JSON object per entry, with keys in column order. Addresses,
+// opcodes and cycles are numbers; changed registers are an object of name to value.
+func (t *ExecutionTrace) writeJSONL() error {
+	columns := t.columns()
+	for _, entry := range t.entries {
+		var sb strings.Builder
+		sb.WriteByte('{')
+		for i, column := range columns {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			var value interface{}
+			switch column {
+			case TraceColumnPC:
+				value = entry.Address
+			case TraceColumnOpcode:
+				value = entry.Opcode
+			case TraceColumnCycles:
+				value = entry.Sequence
+			case TraceColumnRegs:
+				value = entry.RegisterChanges // encoding/json sorts map keys
+			default:
+				value = t.columnValue(entry, column)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&sb, "%q:%s", column, encoded)
+		}
+		sb.WriteString("}\n")
+		if _, err := io.WriteString(t.Writer, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntry writes a single trace entry in the fixed text layout
+func (t *ExecutionTrace) writeEntry(entry TraceEntry) error {
+	// Format: [seq] addr: instruction | changes | flags | time
+	// Use symbol-aware formatting if symbols are available
 	line := fmt.Sprintf("[%06d] %-20s: %-30s",
 		entry.Sequence,
-		addrStr,
+		t.formatAddress(entry.Address),
 		entry.Disassembly)
 
 	// Add register changes
 	if len(entry.RegisterChanges) > 0 {
-		changes := make([]string, 0, len(entry.RegisterChanges))
-		for name, value := range entry.RegisterChanges {
-			changes = append(changes, fmt.Sprintf("%s=0x%08X", name, value))
-		}
-		line += " | " + strings.Join(changes, " ")
+		line += " | " + formatRegisterChanges(entry.RegisterChanges)
 	} else {
 		line += " | (no changes)"
 	}
 
 	// Add flags if enabled
 	if t.IncludeFlags {
-		flags := ""
-		if entry.Flags.N {
-			flags += "N"
-		} else {
-			flags += "-"
-		}
-		if entry.Flags.Z {
-			flags += "Z"
-		} else {
-			flags += "-"
-		}
-		if entry.Flags.C {
-			flags += "C"
-		} else {
-			flags += "-"
-		}
-		if entry.Flags.V {
-			flags += "V"
-		} else {
-			flags += "-"
-		}
-		line += " | " + flags
+		line += " | " + formatTraceFlags(entry.Flags)
 	}
 
 	// Add timing if enabled
@@ -221,6 +405,24 @@ func (t *ExecutionTrace) writeEntry(entry TraceEntry) error {
 	return err
 }
 
+// formatTraceFlags renders the NZCV flags as e.g. "N-C-"
+func formatTraceFlags(cpsr CPSR) string {
+	flags := []byte("----")
+	if cpsr.N {
+		flags[0] = 'N'
+	}
+	if cpsr.Z {
+		flags[1] = 'Z'
+	}
+	if cpsr.C {
+		flags[2] = 'C'
+	}
+	if cpsr.V {
+		flags[3] = 'V'
+	}
+	return string(flags)
+}
+
 // GetEntries returns all trace entries
 func (t *ExecutionTrace) GetEntries() []TraceEntry {
 	return t.entries
@@ -0,0 +1,257 @@
+package vm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseExecutionTrace reads a trace written by ExecutionTrace.Flush. Symbolic
+// addresses such as "main+4" are resolved through symbols.
+func ParseExecutionTrace(r io.Reader, symbols map[string]uint32) ([]TraceEntry, error) {
+	var entries []TraceEntry
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry, err := parseTraceLine(line, symbols)
+		if err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseTraceLine parses "[seq] addr: disasm | changes | flags | time"
+func parseTraceLine(line string, symbols map[string]uint32) (TraceEntry, error) {
+	var entry TraceEntry
+
+	end := strings.Index(line, "]")
+	if !strings.HasPrefix(line, "[") || end < 0 {
+		return entry, fmt.Errorf("missing [sequence] in %q", line)
+	}
+	seq, err := strconv.ParseUint(line[1:end], 10, 64)
+	if err != nil {
+		return entry, fmt.Errorf("invalid sequence %q", line[1:end])
+	}
+	entry.Sequence = seq
+
+	rest := line[end+1:]
+	colon := strings.Index(rest, ": ")
+	if colon < 0 {
+		return entry, fmt.Errorf("missing address in %q", line)
+	}
+	if entry.Address, err = parseTraceAddress(strings.TrimSpace(rest[:colon]), symbols); err != nil {
+		return entry, err
+	}
+
+	fields := strings.Split(rest[colon+2:], " | ")
+	entry.Disassembly = strings.TrimSpace(fields[0])
+	if len(fields) < 2 {
+		return entry, fmt.Errorf("missing register changes in %q", line)
+	}
+
+	entry.RegisterChanges = make(map[string]uint32)
+	if changes := strings.TrimSpace(fields[1]); changes != "(no changes)" {
+		for _, change := range strings.Fields(changes) {
+			name, value, ok := strings.Cut(change, "=")
+			if !ok {
+				return entry, fmt.Errorf("invalid register change %q", change)
+			}
+			v, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 32)
+			if err != nil {
+				return entry, fmt.Errorf("invalid value in register change %q", change)
+			}
+			entry.RegisterChanges[name] = uint32(v) // #nosec G115 -- parsed as 32-bit
+		}
+	}
+
+	// Optional flags and timing columns
+	for _, field := range fields[2:] {
+		field = strings.TrimSpace(field)
+		if cpsr, ok := parseTraceFlags(field); ok {
+			entry.Flags = cpsr
+			entry.hasFlags = true
+		} else if d, err := time.ParseDuration(field); err == nil {
+			entry.Duration = d
+		} else {
+			return entry, fmt.Errorf("unrecognised column %q", field)
+		}
+	}
+	return entry, nil
+}
+
+// parseTraceAddress parses "0x8004", "main" or "main+4"
+func parseTraceAddress(s string, symbols map[string]uint32) (uint32, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid address %q", s)
+		}
+		return uint32(v), nil // #nosec G115 -- parsed as 32-bit
+	}
+
+	name, offsetStr, hasOffset := strings.Cut(s, "+")
+	base, ok := symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown symbol %q in trace address", name)
+	}
+	if !hasOffset {
+		return base, nil
+	}
+	offset, err := strconv.ParseUint(offsetStr, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address offset %q", s)
+	}
+	return base + uint32(offset), nil // #nosec G115 -- parsed as 32-bit
+}
+
+// parseTraceFlags parses the output of formatTraceFlags
+func parseTraceFlags(s string) (CPSR, bool) {
+	var cpsr CPSR
+	if len(s) != len("NZCV") {
+		return cpsr, false
+	}
+	bits := []*bool{&cpsr.N, &cpsr.Z, &cpsr.C, &cpsr.V}
+	for i, set := range bits {
+		switch s[i] {
+		case "NZCV"[i]:
+			*set = true
+		case '-':
+		default:
+			return cpsr, false
+		}
+	}
+	return cpsr, true
+}
+
+// TraceDivergence describes the first instruction where a replay differs from its trace
+type TraceDivergence struct {
+	Index    int    // Index of the trace entry (0-based)
+	Sequence uint64 // Sequence number of the trace entry, or the replay's cycle count past the end
+	Address  uint32 // Address of the instruction the replay executed, if any
+	Reason   string
+}
+
+func (d *TraceDivergence) String() string {
+	return fmt.Sprintf("entry %d (sequence %d) at 0x%08X: %s", d.Index+1, d.Sequence, d.Address, d.Reason)
+}
+
+// TraceReplay re-runs a program and checks every executed instruction against a
+// trace recorded from an earlier run of the same program
+type TraceReplay struct {
+	Expected   []TraceEntry
+	FilterRegs []string // Must match the register filter used when recording
+	Matched    int      // Number of entries matched so far
+}
+
+// NewTraceReplay creates a replay of the given trace entries
+func NewTraceReplay(expected []TraceEntry) *TraceReplay {
+	return &TraceReplay{Expected: expected}
+}
+
+// Run steps machine until it halts, reaches the end of a truncated trace or diverges.
+// It returns the first divergence, or nil if the run matched the trace exactly.
+// The machine's ExecutionTrace is replaced for the duration of the replay.
+func (r *TraceReplay) Run(machine *VM) (*TraceDivergence, error) {
+	trace := NewExecutionTrace(nil)
+	trace.IncludeTiming = false
+	trace.MaxEntries = 0
+	trace.SetFilterRegisters(r.FilterRegs)
+	machine.ExecutionTrace = trace
+	defer func() { machine.ExecutionTrace = nil }()
+
+	// A trace that hit the recording limit says nothing about what came after it
+	truncated := len(r.Expected) == DefaultTraceMaxEntries
+
+	machine.State = StateRunning
+	for machine.State == StateRunning {
+		stepErr := machine.Step()
+
+		for _, actual := range trace.entries {
+			if d := r.compare(actual); d != nil {
+				return d, nil
+			}
+		}
+		trace.entries = trace.entries[:0]
+
+		if stepErr != nil {
+			if machine.State == StateHalted || r.Matched == len(r.Expected) {
+				break
+			}
+			return nil, stepErr
+		}
+		if truncated && r.Matched == len(r.Expected) {
+			return nil, nil
+		}
+	}
+
+	if r.Matched < len(r.Expected) {
+		next := r.Expected[r.Matched]
+		return &TraceDivergence{
+			Index:    r.Matched,
+			Sequence: next.Sequence,
+			Address:  machine.CPU.PC,
+			Reason:   fmt.Sprintf("program stopped after %d instructions but the trace has %d", r.Matched, len(r.Expected)),
+		}, nil
+	}
+	return nil, nil
+}
+
+// compare checks one executed instruction against the next trace entry
+func (r *TraceReplay) compare(actual TraceEntry) *TraceDivergence {
+	if r.Matched >= len(r.Expected) {
+		return &TraceDivergence{
+			Index:    r.Matched,
+			Sequence: actual.Sequence,
+			Address:  actual.Address,
+			Reason:   fmt.Sprintf("program executed %s past the end of the trace", actual.Disassembly),
+		}
+	}
+
+	expected := r.Expected[r.Matched]
+	diverged := func(format string, args ...interface{}) *TraceDivergence {
+		return &TraceDivergence{
+			Index:    r.Matched,
+			Sequence: expected.Sequence,
+			Address:  actual.Address,
+			Reason:   fmt.Sprintf(format, args...),
+		}
+	}
+
+	if actual.Address != expected.Address {
+		return diverged("expected PC 0x%08X (%s), executed %s", expected.Address, expected.Disassembly, actual.Disassembly)
+	}
+	for _, name := range traceRegisterOrder {
+		want, wantChanged := expected.RegisterChanges[name]
+		got, gotChanged := actual.RegisterChanges[name]
+		switch {
+		case wantChanged && !gotChanged:
+			return diverged("%s: expected 0x%08X, register unchanged", name, want)
+		case !wantChanged && gotChanged:
+			return diverged("%s: expected unchanged, got 0x%08X", name, got)
+		case want != got:
+			return diverged("%s: expected 0x%08X, got 0x%08X", name, want, got)
+		}
+	}
+	if expected.hasFlags {
+		want, got := formatTraceFlags(expected.Flags), formatTraceFlags(actual.Flags)
+		if want != got {
+			return diverged("flags: expected %s, got %s", want, got)
+		}
+	}
+
+	r.Matched++
+	return nil
+}
@@ -0,0 +1,239 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+)
+
+// UninitCheck selects what happens when an instruction reads a register that nothing has
+// written since the program started, a common bug in student code
+type UninitCheck int
+
+const (
+	UninitOff  UninitCheck = iota // No checking (the default)
+	UninitWarn                    // Print a warning the first time each instruction does it
+	UninitHalt                    // Stop with an *UninitializedReadError
+)
+
+// ParseUninitCheck parses the -uninit flag value: off, warn or halt
+func ParseUninitCheck(s string) (UninitCheck, error) {
+	switch s {
+	case "", "off":
+		return UninitOff, nil
+	case "warn":
+		return UninitWarn, nil
+	case "halt":
+		return UninitHalt, nil
+	}
+	return UninitOff, fmt.Errorf("invalid uninitialized-read mode %q (use off, warn or halt)", s)
+}
+
+// UninitializedReadError is an instruction reading a register before anything wrote it
+type UninitializedReadError struct {
+	PC       uint32
+	Register int
+}
+
+func (e *UninitializedReadError) Error() string {
+	return fmt.Sprintf("uninitialized read of %s at PC=0x%08X", getRegisterName(e.Register), e.PC)
+}
+
+// checkUninitializedReads reports the first source register of inst that has not been
+// written: as an error in UninitHalt mode, otherwise as a warning once per instruction
+// and register. SP counts as written, since the loader sets it up.
+func (vm *VM) checkUninitializedReads(inst *Instruction) error {
+	for _, reg := range sourceRegisters(inst) {
+		if reg == SP || reg >= ARMGeneralRegisterCount || vm.registersWritten&(1<<reg) != 0 {
+			continue
+		}
+		if vm.UninitCheck == UninitHalt {
+			return &UninitializedReadError{PC: inst.Address, Register: reg}
+		}
+
+		if vm.uninitWarned == nil {
+			vm.uninitWarned = make(map[uint32]uint16)
+		}
+		if vm.uninitWarned[inst.Address]&(1<<reg) != 0 {
+			continue
+		}
+		vm.uninitWarned[inst.Address] |= 1 << reg
+
+		w := vm.UninitWriter
+		if w == nil {
+			w = os.Stderr
+		}
+		fmt.Fprintf(w, "Warning: %s read before being written at PC=0x%08X\n", getRegisterName(reg), inst.Address)
+	}
+	return nil
+}
+
+// markRegistersWritten records the registers inst wrote: its destinations, plus any whose
+// value changed, which covers results written by SWI handlers
+func (vm *VM) markRegistersWritten(inst *Instruction, before *[ARMGeneralRegisterCount]uint32) {
+	for _, reg := range destinationRegisters(inst) {
+		if reg < ARMGeneralRegisterCount {
+			vm.registersWritten |= 1 << reg
+		}
+	}
+	for reg := range vm.CPU.R {
+		if vm.CPU.R[reg] != before[reg] {
+			vm.registersWritten |= 1 << reg
+		}
+	}
+}
+
+// resetUninitTracking marks every register unwritten again, for a restarted program
+func (vm *VM) resetUninitTracking() {
+	vm.registersWritten = 0
+	vm.uninitWarned = nil
+}
+
+// sourceRegisters lists the registers inst reads as operands. Register lists stored by
+// STM and PUSH are left out, since saving a register is not a use of its value, and so
+// are EOR and SUB of a register with itself, the usual idioms for zeroing one, and NOP
+// (MOV R0, R0).
+func sourceRegisters(inst *Instruction) []int {
+	op := inst.Opcode
+	field := func(shift int) int { return int((op >> shift) & Mask4Bit) }
+	rn, rd, rs, rm := field(RnShift), field(RdShift), field(RsShift), field(0)
+
+	switch inst.Type {
+	case InstDataProcessing:
+		opcode := (op >> OpcodeShift) & Mask4Bit
+		immediate := (op>>IBitShift)&Mask1Bit == 1
+		if !immediate && (op>>Bit4Pos)&Mask8Bit == 0 {
+			if rn == rm && (opcode == OpEOR || opcode == OpSUB) || rd == rm && opcode == OpMOV {
+				return nil
+			}
+		}
+
+		var regs []int
+		if opcode != OpMOV && opcode != OpMVN {
+			regs = append(regs, rn)
+		}
+		if !immediate {
+			regs = append(regs, rm)
+			if (op>>Bit4Pos)&Mask1Bit == 1 {
+				regs = append(regs, rs)
+			}
+		}
+		return regs
+
+	case InstMultiply:
+		regs := []int{rm, rs}
+		if (op>>MultiplyAShift)&Mask1Bit == 1 {
+			if (op>>Bits27_23Shift)&LongMultiplyMask5 == 1 {
+				regs = append(regs, rd, rn) // UMLAL/SMLAL add to RdLo:RdHi
+			} else {
+				regs = append(regs, rd) // MLA accumulator
+			}
+		}
+		return regs
+
+	case InstLoadStore:
+		regs := []int{rn}
+		if (op>>Bits27_25Shift)&Mask3Bit == 0 {
+			// Halfword transfers have a register offset when bit 22 is clear
+			if (op>>BBitShift)&Mask1Bit == 0 {
+				regs = append(regs, rm)
+			}
+		} else if (op>>IBitShift)&Mask1Bit == 1 {
+			regs = append(regs, rm)
+		}
+		if (op>>LBitShift)&Mask1Bit == 0 {
+			regs = append(regs, rd) // The value stored
+		}
+		return regs
+
+	case InstLoadStoreMultiple:
+		return []int{rn}
+
+	case InstBranch:
+		if op&BXPatternMask == BXEncodingBase || op&BXPatternMask == BLXEncodingBase {
+			return []int{rm}
+		}
+		return nil
+
+	case InstPSRTransfer:
+		if op&MSRRegMask == MSRRegPattern {
+			return []int{rm}
+		}
+		return nil
+
+	case InstSaturating:
+		return []int{rn, rm}
+
+	case InstDivide:
+		return []int{rs, rm}
+
+	default:
+		return nil
+	}
+}
+
+// destinationRegisters lists the registers inst writes, so that writing a register's
+// current value still counts. SWIs return their results in R0 and R1.
+func destinationRegisters(inst *Instruction) []int {
+	op := inst.Opcode
+	field := func(shift int) int { return int((op >> shift) & Mask4Bit) }
+	rn, rd := field(RnShift), field(RdShift)
+
+	switch inst.Type {
+	case InstDataProcessing:
+		if opcode := (op >> OpcodeShift) & Mask4Bit; opcode >= OpTST && opcode <= OpCMN {
+			return nil
+		}
+		return []int{rd}
+
+	case InstMultiply:
+		if (op>>Bits27_23Shift)&LongMultiplyMask5 == 1 {
+			return []int{rd, rn}
+		}
+		return []int{rn}
+
+	case InstLoadStore:
+		if (op>>LBitShift)&Mask1Bit == 1 {
+			return []int{rd}
+		}
+		return nil
+
+	case InstLoadStoreMultiple:
+		if (op>>LBitShift)&Mask1Bit == 0 {
+			return nil
+		}
+		var regs []int
+		for reg := 0; reg < ARMGeneralRegisterCount; reg++ {
+			if op&(1<<reg) != 0 {
+				regs = append(regs, reg)
+			}
+		}
+		return regs
+
+	case InstBranch:
+		switch {
+		case op&BXPatternMask == BXEncodingBase:
+			return nil
+		case op&BXPatternMask == BLXEncodingBase, (op>>BranchLinkShift)&Mask1Bit == 1:
+			return []int{LR}
+		}
+		return nil
+
+	case InstPSRTransfer:
+		if op&MRSMask == MRSPattern {
+			return []int{rd}
+		}
+		return nil
+
+	case InstSaturating:
+		return []int{rd}
+
+	case InstDivide:
+		return []int{rn}
+
+	case InstSWI:
+		return []int{R0, R1}
+
+	default:
+		return nil
+	}
+}